@@ -17,6 +17,8 @@ import (
 	"os/signal"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -25,12 +27,15 @@ import (
 	"github.com/caffix/netmap"
 	"github.com/caffix/stringset"
 	"github.com/fatih/color"
+	"github.com/miekg/dns"
 	"github.com/owasp-amass/amass/v4/datasrcs"
+	"github.com/owasp-amass/amass/v4/datasrcs/scripting"
 	"github.com/owasp-amass/amass/v4/enum"
 	"github.com/owasp-amass/amass/v4/format"
 	"github.com/owasp-amass/amass/v4/resources"
 	"github.com/owasp-amass/amass/v4/systems"
 	"github.com/owasp-amass/config/config"
+	"golang.org/x/term"
 )
 
 const enumUsageMsg = "enum [options] -d DOMAIN"
@@ -45,18 +50,99 @@ type enumArgs struct {
 	BruteWordListMask *stringset.Set
 	Blacklist         *stringset.Set
 	Domains           *stringset.Set
+	Enrichment        *stringset.Set
+	WildcardRcodes    *stringset.Set
 	Excluded          *stringset.Set
+	ExcludeNames      []string
+	PriorityNames     []string
+	ResolversByType   map[string][]string
+	ResolverAffinity  map[string][]string
+	CanaryChecks      map[string][]string
+	VantageProxies    map[string]string
+	WordlistURL       string
+	WordlistHeaders   map[string]string
+	ExpandScope       string
+	PrivateAddrs      string
+	Sinkholes         []string
+	ExpandSuffixes    []string
+	MaxExpansion      int
+	CNAMEScope        []string
+	CNAMEScopeDepth   int
+	DomainSettings    map[string]*enum.DomainConfig
+	ReverseCIDR       *net.IPNet
+	ReverseSweepSize  int
+	ReverseBatchSize  int
 	Included          *stringset.Set
 	Interface         string
+	SOCKS5Proxy       string
+	ForceResolver     string
+	OutputTemplate    string
+	Neo4jURI          string
+	Neo4jUser         string
+	Neo4jPass         string
+	S3Endpoint        string
+	GRPCAddr          string
+	SOAPrevious       string
+	Compression       string
+	RedactFields      []string
+	RedactCIDRs       []*net.IPNet
+	S3Bucket          string
+	S3Prefix          string
+	S3Region          string
+	NATSURL           string
+	NATSSubject       string
 	MaxDNSQueries     int
+	EDNSBufferSize    int
+	MaxInFlight       int
+	MaxWorkers        int
+	GraphBatchSize    int
+	GraphFlush        int
+	OutputFlush       int
+	ProbeTimeout      int
+	MaxQueries        int64
+	SourcePorts       enum.PortRange
+	SourceIP          net.IP
+	TCPPoolMaxIdle    int
+	TCPPoolIdle       int
+	QueryJitter       int
+	QPSRampUp         int
+	MaxAnswerBytes    int
+	DedupeFilter      string
+	DedupeCapacity    uint
+	DedupeFPRate      float64
+	SRVServices       []scripting.SRVService
+	SRVPorts          []int
+	EmailLocalParts   []string
+	MaxResults        int
+	MinConfidence     int
+	DiscoveryBalance  float64
+	ClusterSimilarity float64
+	SourceTimeouts    map[string]time.Duration
+	KnownWildcards    map[string][]string
+	SourceTimeout     int
+	MaxPerParent      int
+	FloodThreshold    int
+	FloodWindow       int
+	FloodRate         float64
+	GracePeriod       int
+	BruteSample       int
+	SampleSeed        int64
+	NegCacheTTL       int
+	NegCacheSize      int
+	HealthInterval    int
+	HealthFailures    int
 	ResolverQPS       int
 	TrustedQPS        int
 	MaxDepth          int
 	MinForRecursive   int
+	MinTTLFlag        int
+	PipelineBuffer    int
 	Names             *stringset.Set
 	Ports             format.ParseInts
 	Resolvers         *stringset.Set
+	ScopeCIDRs        format.ParseCIDRs
 	Trusted           *stringset.Set
+	SplitHorizon      []string
 	Timeout           int
 	Options           struct {
 		Active       bool
@@ -68,7 +154,46 @@ type enumArgs struct {
 		NoColor      bool
 		NoRecursive  bool
 		Passive      bool
+		ResolvePTR   bool
+		NSECWalk     bool
+		OpenRes      bool
+		LameDeleg    bool
+		Takeover     bool
+		Rebinding    bool
+		NSConsist    bool
+		Clusters     bool
+		MailInfra    bool
+		CERTRecords  bool
+		LOCRecords   bool
+		HINFORecords bool
+		APLRecords   bool
+		URIRecords   bool
+		GlueRecords  bool
+		RequireCreds bool
+		ReplayGraph  bool
+		Partition    bool
+		OnlyNew      bool
+		ActiveOnly   bool
+		PassiveOnly  bool
+		Cookies      bool
+		CacheSnoop   bool
+		OOSCNAMEs    bool
+		CNAMEAddrs   bool
+		RandomOrder  bool
+		FullResponse bool
+		Permute      bool
+		Unresolvable bool
+		CanaryAbort  bool
+		ProbeHTTP    bool
+		CertBases    bool
+		ForceTCP     bool
+		TryANY       bool
+		ExpandSPF    bool
+		ValidateAuth bool
+		ResolveOnly  bool
+		ValidateList bool
 		Silent       bool
+		Tree         bool
 		Verbose      bool
 	}
 	Filepaths struct {
@@ -87,6 +212,17 @@ type enumArgs struct {
 		Resolvers        format.ParseStrings
 		Trusted          format.ParseStrings
 		ScriptsDirectory string
+		SQLiteOutput     string
+		STIXOutput       string
+		CSVOutput        string
+		GeoIPDatabase    string
+		NamesCSV         string
+		CertificatesPEM  string
+		RawResponseDir   string
+		GraphFailover    string
+		SweepCheckpoint  string
+		SrcCheckpoints   string
+		SOASerials       string
 		TermOut          string
 	}
 }
@@ -96,11 +232,257 @@ func defineEnumArgumentFlags(enumFlags *flag.FlagSet, args *enumArgs) {
 	enumFlags.Var(args.AltWordListMask, "awm", "\"hashcat-style\" wordlist masks for name alterations")
 	enumFlags.Var(&args.ASNs, "asn", "ASNs separated by commas (can be used multiple times)")
 	enumFlags.Var(&args.CIDRs, "cidr", "CIDRs separated by commas (can be used multiple times)")
+	enumFlags.Var(&args.ScopeCIDRs, "scope-cidr", "Names resolving within these CIDRs are in scope (can be used multiple times)")
 	enumFlags.Var(args.Blacklist, "bl", "Blacklist of subdomain names that will not be investigated")
 	enumFlags.Var(args.BruteWordListMask, "wm", "\"hashcat-style\" wordlist masks for DNS brute forcing")
 	enumFlags.Var(args.Domains, "d", "Domain names separated by commas (can be used multiple times)")
+	enumFlags.Var(args.Enrichment, "enrich", "DNS record types separated by commas to query for the names with -resolve-only")
+	enumFlags.Var(args.WildcardRcodes, "wildcard-rcodes", "DNS rcodes separated by commas, such as SERVFAIL, counting toward the wildcard detection")
 	enumFlags.Var(args.Excluded, "exclude", "Data source names separated by commas to be excluded")
+	enumFlags.Func("exclude-re", "Drop the names matching this regular expression (can be used multiple times)", func(s string) error {
+		args.ExcludeNames = append(args.ExcludeNames, s)
+		return nil
+	})
+	enumFlags.Func("redact", "Output fields separated by commas to omit when sharing the results, such as source,tag", func(s string) error {
+		for _, f := range strings.Split(s, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				args.RedactFields = append(args.RedactFields, f)
+			}
+		}
+		return nil
+	})
+	enumFlags.Func("redact-cidr", "Address range masked in the output, such as 10.0.0.0/8 (can be used multiple times)", func(s string) error {
+		_, cidr, err := net.ParseCIDR(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("the value %q is not a valid CIDR", s)
+		}
+		args.RedactCIDRs = append(args.RedactCIDRs, cidr)
+		return nil
+	})
+	enumFlags.Func("priority-re", "Resolve the names matching this regular expression first, such as vpn (can be used multiple times)", func(s string) error {
+		args.PriorityNames = append(args.PriorityNames, s)
+		return nil
+	})
+	enumFlags.Func("rtype", "Dedicated resolvers for a record type, such as TXT=192.0.2.1,192.0.2.2 (can be used multiple times)", func(s string) error {
+		rtype, addrs, found := strings.Cut(s, "=")
+		if !found || strings.TrimSpace(rtype) == "" || strings.TrimSpace(addrs) == "" {
+			return fmt.Errorf("the value %q must have the format TYPE=resolver,resolver", s)
+		}
+		if args.ResolversByType == nil {
+			args.ResolversByType = make(map[string][]string)
+		}
+		for _, addr := range strings.Split(addrs, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				args.ResolversByType[rtype] = append(args.ResolversByType[rtype], addr)
+			}
+		}
+		return nil
+	})
+	enumFlags.Func("affinity", "Preferred resolvers for the names under a domain, such as example.com=192.0.2.1,192.0.2.2 (can be used multiple times)", func(s string) error {
+		suffix, addrs, found := strings.Cut(s, "=")
+		if !found || strings.TrimSpace(suffix) == "" || strings.TrimSpace(addrs) == "" {
+			return fmt.Errorf("the value %q must have the format domain=resolver,resolver", s)
+		}
+		if args.ResolverAffinity == nil {
+			args.ResolverAffinity = make(map[string][]string)
+		}
+		for _, addr := range strings.Split(addrs, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				args.ResolverAffinity[suffix] = append(args.ResolverAffinity[suffix], addr)
+			}
+		}
+		return nil
+	})
+	enumFlags.Func("canary", "Known-good addresses for a name to detect DNS tampering, such as www.example.com=192.0.2.1 (can be used multiple times)", func(s string) error {
+		name, addrs, found := strings.Cut(s, "=")
+		if !found || strings.TrimSpace(name) == "" || strings.TrimSpace(addrs) == "" {
+			return fmt.Errorf("the value %q must have the format name=address,address", s)
+		}
+		if args.CanaryChecks == nil {
+			args.CanaryChecks = make(map[string][]string)
+		}
+		name = strings.TrimSpace(name)
+		for _, addr := range strings.Split(addrs, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				args.CanaryChecks[name] = append(args.CanaryChecks[name], addr)
+			}
+		}
+		return nil
+	})
+	enumFlags.Func("vantage", "SOCKS5 proxy used to resolve the names from a region, such as eu=socks5://192.0.2.1:1080 (can be used multiple times)", func(s string) error {
+		region, addr, found := strings.Cut(s, "=")
+		if !found || strings.TrimSpace(region) == "" || strings.TrimSpace(addr) == "" {
+			return fmt.Errorf("the value %q must have the format region=proxy", s)
+		}
+		if args.VantageProxies == nil {
+			args.VantageProxies = make(map[string]string)
+		}
+		args.VantageProxies[strings.TrimSpace(region)] = strings.TrimSpace(addr)
+		return nil
+	})
+	enumFlags.StringVar(&args.WordlistURL, "wordlist-url", "", "HTTP service providing the brute forcing wordlist, falling back to the local wordlist")
+	enumFlags.Func("wordlist-header", "Header sent to the wordlist service, such as 'Authorization: Bearer token' (can be used multiple times)", func(s string) error {
+		name, value, found := strings.Cut(s, ":")
+		if !found || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("the value %q must have the format name: value", s)
+		}
+		if args.WordlistHeaders == nil {
+			args.WordlistHeaders = make(map[string]string)
+		}
+		args.WordlistHeaders[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		return nil
+	})
+	enumFlags.StringVar(&args.PrivateAddrs, "private-addrs", "", "Treatment of the private addresses found in the answers: keep, flag or drop (default: keep)")
+	enumFlags.Func("sinkhole", "Sinkhole IP addresses or CIDRs separated by commas, whose answers are discarded (can be used multiple times)", func(s string) error {
+		for _, a := range strings.Split(s, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				args.Sinkholes = append(args.Sinkholes, a)
+			}
+		}
+		return nil
+	})
+	enumFlags.StringVar(&args.ExpandScope, "expand-scope", "", "Add the related apex domains discovered outside of the scope: sibling or suffix")
+	enumFlags.Func("expand-suffix", "Suffix of the apex domains added by the suffix scope expansion (can be used multiple times)", func(s string) error {
+		args.ExpandSuffixes = append(args.ExpandSuffixes, strings.TrimSpace(s))
+		return nil
+	})
+	enumFlags.Func("cname-scope", "Suffix of the apex domains brought into the scope through the CNAME targets of the names in scope (can be used multiple times)", func(s string) error {
+		args.CNAMEScope = append(args.CNAMEScope, strings.TrimSpace(s))
+		return nil
+	})
+	enumFlags.IntVar(&args.CNAMEScopeDepth, "cname-scope-depth", 0, "Maximum depth of the domains brought into the scope through the CNAME targets (default 2)")
+	enumFlags.IntVar(&args.MaxExpansion, "max-expansion", 0, "Maximum number of apex domains added to the scope (default 10)")
+	enumFlags.Func("domain-settings", "Settings overridden for a domain, such as example.com:active=false,unresolvable=true,wildcards=false (can be used multiple times)", func(s string) error {
+		domain, settings, found := strings.Cut(s, ":")
+		if !found || strings.TrimSpace(domain) == "" {
+			return fmt.Errorf("the value %q must have the format domain:setting=bool,setting=bool", s)
+		}
+
+		dc := new(enum.DomainConfig)
+		for _, setting := range strings.Split(settings, ",") {
+			key, value, found := strings.Cut(setting, "=")
+			v, err := strconv.ParseBool(strings.TrimSpace(value))
+			if !found || err != nil {
+				return fmt.Errorf("the setting %q must have the format setting=bool", setting)
+			}
+
+			switch strings.TrimSpace(key) {
+			case "active":
+				dc.Active = &v
+			case "unresolvable":
+				dc.IncludeUnresolvable = &v
+			case "wildcards":
+				dc.DetectWildcards = &v
+			default:
+				return fmt.Errorf("the setting %q must be active, unresolvable or wildcards", key)
+			}
+		}
+		if args.DomainSettings == nil {
+			args.DomainSettings = make(map[string]*enum.DomainConfig)
+		}
+		args.DomainSettings[strings.TrimSpace(domain)] = dc
+		return nil
+	})
+	enumFlags.Func("reverse-only", "Only sweep the reverse DNS of the netblock, such as 192.0.2.0/24, keeping the names in scope", func(s string) error {
+		_, cidr, err := net.ParseCIDR(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("the value %q is not a valid CIDR", s)
+		}
+		args.ReverseCIDR = cidr
+		return nil
+	})
+	enumFlags.Func("srv", "SRV service and protocol to query, such as ldap/tcp, replacing the default names (can be used multiple times)", func(s string) error {
+		svc, proto, found := strings.Cut(s, "/")
+		if !found {
+			return fmt.Errorf("the value %q must have the format service/proto", s)
+		}
+		args.SRVServices = append(args.SRVServices, scripting.SRVService{Service: svc, Proto: proto})
+		return nil
+	})
+	enumFlags.Func("known-wildcard", "Wildcard answers of a subdomain skipping the detection queries, such as dyn.example.com=192.0.2.1,192.0.2.2 (can be used multiple times)", func(s string) error {
+		sub, addrs, found := strings.Cut(s, "=")
+		if !found || strings.TrimSpace(sub) == "" {
+			return fmt.Errorf("the value %q must have the format subdomain=addresses", s)
+		}
+		if args.KnownWildcards == nil {
+			args.KnownWildcards = make(map[string][]string)
+		}
+
+		var ips []string
+		for _, addr := range strings.Split(addrs, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				ips = append(ips, addr)
+			}
+		}
+		args.KnownWildcards[strings.TrimSpace(sub)] = ips
+		return nil
+	})
+	enumFlags.Func("src-timeout", "Seconds a data source can take to accept each request, such as Shodan=60 (can be used multiple times)", func(s string) error {
+		name, secs, found := strings.Cut(s, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(secs))
+		if !found || strings.TrimSpace(name) == "" || err != nil || n < 0 {
+			return fmt.Errorf("the value %q must have the format source=seconds", s)
+		}
+		if args.SourceTimeouts == nil {
+			args.SourceTimeouts = make(map[string]time.Duration)
+		}
+		args.SourceTimeouts[strings.TrimSpace(name)] = time.Duration(n) * time.Second
+		return nil
+	})
+	enumFlags.Func("srv-port", "Port numbers separated by commas to query as _<port>._tcp and _<port>._udp SRV records", func(s string) error {
+		for _, p := range strings.Split(s, ",") {
+			port, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil {
+				return fmt.Errorf("the value %q is not a valid port", p)
+			}
+			args.SRVPorts = append(args.SRVPorts, port)
+		}
+		return nil
+	})
+	enumFlags.Func("email-keys", "Local parts of email addresses separated by commas to query for OPENPGPKEY and SMIMEA records", func(s string) error {
+		for _, p := range strings.Split(s, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				args.EmailLocalParts = append(args.EmailLocalParts, p)
+			}
+		}
+		return nil
+	})
 	enumFlags.Var(args.Included, "include", "Data source names separated by commas to be included")
+	enumFlags.StringVar(&args.Neo4jURI, "neo4j", "", "Bolt URI of a Neo4j database that will mirror the results, such as bolt://localhost:7687")
+	enumFlags.StringVar(&args.Neo4jUser, "neo4j-user", "neo4j", "Username for the Neo4j database")
+	enumFlags.StringVar(&args.Neo4jPass, "neo4j-pass", "", "Password for the Neo4j database")
+	enumFlags.StringVar(&args.GRPCAddr, "grpc", "", "Address of a gRPC server streaming the results as they are produced, such as 127.0.0.1:9090")
+	enumFlags.StringVar(&args.S3Endpoint, "s3", "", "URL of S3-compatible storage receiving the results as JSON-lines objects")
+	enumFlags.StringVar(&args.S3Bucket, "s3-bucket", "", "Bucket of the S3-compatible storage, using the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	enumFlags.StringVar(&args.S3Prefix, "s3-prefix", "", "Prefix of the keys of the objects written to the S3-compatible storage")
+	enumFlags.StringVar(&args.S3Region, "s3-region", "us-east-1", "Region used to sign the requests to the S3-compatible storage")
+	enumFlags.StringVar(&args.NATSURL, "nats", "", "URL of a NATS server receiving each resolved name as a JSON message, using the NATS_TOKEN")
+	enumFlags.StringVar(&args.NATSSubject, "nats-subject", "amass.results", "Subject of the messages published to the NATS server")
+	enumFlags.StringVar(&args.SOCKS5Proxy, "socks5", "", "SOCKS5 proxy (host:port) used for the DNS queries over TCP")
+	enumFlags.Func("source-ports", "Range of source ports for the DNS queries, such as 40000-40999", func(s string) error {
+		min, max, found := strings.Cut(s, "-")
+		if !found {
+			max = min
+		}
+
+		var err error
+		if args.SourcePorts.Min, err = strconv.Atoi(strings.TrimSpace(min)); err != nil {
+			return fmt.Errorf("the value %q must have the format min-max", s)
+		}
+		if args.SourcePorts.Max, err = strconv.Atoi(strings.TrimSpace(max)); err != nil {
+			return fmt.Errorf("the value %q must have the format min-max", s)
+		}
+		return nil
+	})
+	enumFlags.Func("source-ip", "Local IP address the DNS queries and zone transfers are bound to", func(s string) error {
+		if args.SourceIP = net.ParseIP(strings.TrimSpace(s)); args.SourceIP == nil {
+			return fmt.Errorf("the value %q is not a valid IP address", s)
+		}
+		return nil
+	})
+	enumFlags.StringVar(&args.SOAPrevious, "soa-prev", "", "Event UUID of the previous enumeration in -soa-serials, reporting the zones with changed SOA serials")
+	enumFlags.StringVar(&args.ForceResolver, "force-resolver", "", "Send all the DNS queries to the single nameserver at host:port, bypassing the resolver pools")
+	enumFlags.StringVar(&args.OutputTemplate, "template", "", "Go text/template printed for each result, such as '{{.Name}} {{join .Addresses \",\"}}'")
 	enumFlags.StringVar(&args.Interface, "iface", "", "Provide the network interface to send traffic through")
 	enumFlags.IntVar(&args.MaxDNSQueries, "max-dns-queries", 0, "Deprecated flag to be replaced by dns-qps in version 4.0")
 	enumFlags.IntVar(&args.MaxDNSQueries, "dns-qps", 0, "Maximum number of DNS queries per second across all resolvers")
@@ -108,10 +490,54 @@ func defineEnumArgumentFlags(enumFlags *flag.FlagSet, args *enumArgs) {
 	enumFlags.IntVar(&args.TrustedQPS, "trqps", 0, "Maximum number of DNS queries per second for each trusted resolver")
 	enumFlags.IntVar(&args.MaxDepth, "max-depth", 0, "Maximum number of subdomain labels for brute forcing")
 	enumFlags.IntVar(&args.MinForRecursive, "min-for-recursive", 1, "Subdomain labels seen before recursive brute forcing (Default: 1)")
+	enumFlags.Int64Var(&args.MaxQueries, "max-queries", 0, "Maximum number of DNS queries for the whole enumeration (0 means no limit)")
+	enumFlags.IntVar(&args.MaxInFlight, "max-inflight", 0, "Maximum number of names being processed at once (0 means no limit)")
+	enumFlags.IntVar(&args.MaxWorkers, "max-workers", 0, "Maximum number of goroutines resolving the names at once (0 means no limit)")
+	enumFlags.StringVar(&args.DedupeFilter, "dedupe", enum.DedupeFilterBloom, "Filter for the names already seen: bloom (bounded memory) or exact (no false positives)")
+	enumFlags.UintVar(&args.DedupeCapacity, "dedupe-cells", 0, "Number of cells in the bloom filter for the names already seen (default 1000000)")
+	enumFlags.Float64Var(&args.DedupeFPRate, "dedupe-fp", 0, "False-positive rate of the bloom filter for the names already seen (default 0.01)")
+	enumFlags.IntVar(&args.MaxAnswerBytes, "max-answer-bytes", 0, "Maximum bytes of data kept from each DNS answer set (0 means no limit)")
+	enumFlags.IntVar(&args.QueryJitter, "jitter", 0, "Maximum milliseconds of random delay before each DNS query (0 disables the jitter)")
+	enumFlags.IntVar(&args.QPSRampUp, "qps-ramp", 0, "Seconds over which the DNS query rate increases to the maximum QPS (0 disables the ramp-up)")
+	enumFlags.IntVar(&args.TCPPoolMaxIdle, "tcp-pool", 0, "Idle TCP connections kept for each DNS server (0 opens one for each query)")
+	enumFlags.IntVar(&args.TCPPoolIdle, "tcp-pool-idle", 10, "Seconds an idle TCP connection is kept in the pool")
+	enumFlags.IntVar(&args.ProbeTimeout, "probe-timeout", 5, "Seconds before each HTTP probe times out")
+	enumFlags.IntVar(&args.GraphBatchSize, "graph-batch", 0, "Number of graph upserts executed together (0 stores each record immediately)")
+	enumFlags.IntVar(&args.GraphFlush, "graph-flush", 500, "Maximum milliseconds the batched graph upserts wait before being stored")
+	enumFlags.IntVar(&args.OutputFlush, "out-flush", 0, "Seconds between the flushes of the buffered outputs (0 flushes when the batches are full)")
+	enumFlags.IntVar(&args.ReverseBatchSize, "reverse-batch", 0, "Number of PTR queries of -reverse-only pipelined over one TCP connection (0 sends them individually)")
+	enumFlags.IntVar(&args.ReverseSweepSize, "reverse-size", 0, "Maximum number of addresses swept by -reverse-only (0 sweeps blocks up to a /16)")
+	enumFlags.IntVar(&args.MaxResults, "max-results", 0, "Stop after this number of resolved names have been found (0 means no limit)")
+	enumFlags.IntVar(&args.SourceTimeout, "src-timeout-default", 0, "Seconds the data sources missing from -src-timeout can take to accept each request (0 means no limit)")
+	enumFlags.IntVar(&args.MinConfidence, "min-confidence", 0, "Lowest confidence score (0-100) of the names sent to the output")
+	enumFlags.Float64Var(&args.ClusterSimilarity, "cluster-similarity", 0, "Minimum similarity, from 0 to 1, of the address sets merged into the same -clusters cluster")
+	enumFlags.Float64Var(&args.DiscoveryBalance, "balance", -1, "Share of the data source requests sent to brute forcing, from 0 (passive only) to 1 (brute force only)")
+	enumFlags.IntVar(&args.BruteSample, "brute-sample", 0, "Percentage of the brute forcing wordlist randomly sampled for a quick pass (0 tries all the labels)")
+	enumFlags.Int64Var(&args.SampleSeed, "sample-seed", 0, "Seed of the -brute-sample sampling, so the sample is reproducible (0 uses a random seed)")
+	enumFlags.IntVar(&args.MaxPerParent, "max-per-parent", 0, "Resolved names below a parent that trigger more requests (0 means no limit)")
+	enumFlags.IntVar(&args.FloodThreshold, "flood-threshold", 0, "Wildcard matches below a parent within the window that throttle its new names (0 means no throttle)")
+	enumFlags.IntVar(&args.FloodWindow, "flood-window", 0, "Seconds the wildcard matches below each parent are counted over (default 10)")
+	enumFlags.Float64Var(&args.FloodRate, "flood-rate", 0, "New names per second released below a parent throttled by wildcard matches (default 1)")
+	enumFlags.IntVar(&args.GracePeriod, "grace", 0, "Seconds the enumeration waits for late data source results once idle before completing")
+	enumFlags.IntVar(&args.NegCacheTTL, "neg-cache-ttl", 0, "Seconds the names receiving NXDOMAIN are not queried again (0 disables the cache)")
+	enumFlags.IntVar(&args.NegCacheSize, "neg-cache-size", 0, "Maximum number of names kept in the negative cache (default 100000)")
+	enumFlags.IntVar(&args.EDNSBufferSize, "edns-size", 1232, "EDNS0 UDP buffer size advertised in the DNS queries (512-65535)")
+	enumFlags.IntVar(&args.HealthInterval, "health-interval", 0, "Seconds between the health checks of the untrusted resolvers (0 disables the checks)")
+	enumFlags.IntVar(&args.HealthFailures, "health-failures", 3, "Failed health checks before a resolver is removed until it recovers")
+	enumFlags.IntVar(&args.PipelineBuffer, "pipeline-buffer", 50, "Number of names buffered between the enumeration pipeline stages")
+	enumFlags.IntVar(&args.MinTTLFlag, "min-ttl", 0, "Flag DNS answers with a TTL below this number of seconds")
 	enumFlags.Var(&args.Ports, "p", "Ports separated by commas (default: 80, 443)")
 	enumFlags.Var(args.Resolvers, "r", "IP addresses of untrusted DNS resolvers (can be used multiple times)")
 	enumFlags.Var(args.Resolvers, "tr", "IP addresses of trusted DNS resolvers (can be used multiple times)")
 	enumFlags.IntVar(&args.Timeout, "timeout", 0, "Number of minutes to let enumeration run before quitting")
+	enumFlags.Func("split-horizon", "Internal and public resolvers compared for each name, such as 10.0.0.2,8.8.8.8", func(s string) error {
+		internal, public, found := strings.Cut(s, ",")
+		if !found || strings.TrimSpace(internal) == "" || strings.TrimSpace(public) == "" {
+			return fmt.Errorf("the value %q must have the format internal,public", s)
+		}
+		args.SplitHorizon = []string{strings.TrimSpace(internal), strings.TrimSpace(public)}
+		return nil
+	})
 }
 
 func defineEnumOptionFlags(enumFlags *flag.FlagSet, args *enumArgs) {
@@ -120,10 +546,49 @@ func defineEnumOptionFlags(enumFlags *flag.FlagSet, args *enumArgs) {
 	enumFlags.BoolVar(&args.Options.DemoMode, "demo", false, "Censor output to make it suitable for demonstrations")
 	enumFlags.BoolVar(&args.Options.ListSources, "list", false, "Print the names of all available data sources")
 	enumFlags.BoolVar(&args.Options.Alterations, "alts", false, "Enable generation of altered names")
+	enumFlags.BoolVar(&args.Options.CacheSnoop, "cache-snoop", false, "Send non-recursive queries that are only answered from the resolver caches")
+	enumFlags.BoolVar(&args.Options.Cookies, "cookies", false, "Include DNS Cookies in the queries and validate them in the responses")
 	enumFlags.BoolVar(&args.Options.NoColor, "nocolor", false, "Disable colorized output")
 	enumFlags.BoolVar(&args.Options.NoRecursive, "norecursive", false, "Turn off recursive brute forcing")
+	enumFlags.BoolVar(&args.Options.ActiveOnly, "output-active", false, "Only output the names obtained by active techniques, such as brute forcing and zone transfers")
+	enumFlags.BoolVar(&args.Options.PassiveOnly, "output-passive", false, "Only output the names obtained from the passive data sources")
+	enumFlags.BoolVar(&args.Options.OnlyNew, "new", false, "Only output the names that were not discovered by a previous enumeration")
+	enumFlags.BoolVar(&args.Options.OOSCNAMEs, "oos-cnames", false, "Record the CNAME targets outside of the scope without resolving them")
+	enumFlags.BoolVar(&args.Options.CNAMEAddrs, "cname-addrs", false, "Keep the addresses of the CNAME targets included in the CNAME responses")
+	enumFlags.BoolVar(&args.Options.RandomOrder, "random-order", false, "Shuffle the order of the record types queried for each name")
+	enumFlags.BoolVar(&args.Options.FullResponse, "full-response", false, "Keep the authority and additional records of the responses in the output")
+	enumFlags.BoolVar(&args.Options.Partition, "partition", false, "Separate the names of each root domain into their own output file")
+	enumFlags.BoolVar(&args.Options.Permute, "permute", false, "Resolve permutations of the discovered names, such as changed numbers and common prefixes")
+	enumFlags.BoolVar(&args.Options.Unresolvable, "include-unresolvable", false, "Output the names within the scope that did not resolve")
+	enumFlags.BoolVar(&args.Options.CanaryAbort, "canary-abort", false, "Stop the enumeration when a canary check detects DNS tampering")
+	enumFlags.BoolVar(&args.Options.ProbeHTTP, "probe-http", false, "Send HTTP and HTTPS HEAD requests to the resolved hosts within the scope")
+	enumFlags.BoolVar(&args.Options.CertBases, "cert-wildcard-base", false, "Seed the base names of the wildcard names in the -cert-pem certificates")
 	enumFlags.BoolVar(&args.Options.Passive, "passive", false, "Deprecated since passive is the default setting")
+	enumFlags.BoolVar(&args.Options.NSECWalk, "nsec-walk", false, "Walk the NSEC chain of DNSSEC-signed zones")
+	enumFlags.BoolVar(&args.Options.ForceTCP, "tcp", false, "Send all the DNS queries over TCP")
+	enumFlags.BoolVar(&args.Options.ValidateAuth, "validate-auth", false, "Drop the names with answers that differ from the authoritative nameservers")
+	enumFlags.BoolVar(&args.Options.ExpandSPF, "spf-expand", false, "Follow the include chains of the SPF records and resolve the referenced domains in scope")
+	enumFlags.BoolVar(&args.Options.TryANY, "any-first", false, "Query ANY records before the individual record types")
+	enumFlags.BoolVar(&args.Options.OpenRes, "open-resolvers", false, "Flag the discovered nameservers that are open recursive resolvers")
+	enumFlags.BoolVar(&args.Options.LameDeleg, "lame", false, "Flag the nameservers that do not answer authoritatively for the delegated zone")
+	enumFlags.BoolVar(&args.Options.Rebinding, "rebinding", false, "Flag the names resolving to both public and private addresses")
+	enumFlags.BoolVar(&args.Options.MailInfra, "mail-infra", false, "Resolve the MX targets in scope and list the mail exchangers with their priorities")
+	enumFlags.BoolVar(&args.Options.NSConsist, "ns-consistency", false, "Flag the names answered differently by the authoritative nameservers of the zone")
+	enumFlags.BoolVar(&args.Options.Clusters, "clusters", false, "List the names within the scope sharing the same resolved addresses")
+	enumFlags.BoolVar(&args.Options.Takeover, "takeover", false, "Flag the CNAME and NS delegations to unclaimed cloud service targets")
+	enumFlags.BoolVar(&args.Options.CERTRecords, "cert-records", false, "Query the CERT records of the domains and subdomains")
+	enumFlags.BoolVar(&args.Options.ReplayGraph, "graph-failover-replay", false, "Replay the graph failover file once the graph accepts writes again")
+	enumFlags.BoolVar(&args.Options.LOCRecords, "loc-records", false, "Query the LOC records of the domains and subdomains")
+	enumFlags.BoolVar(&args.Options.HINFORecords, "hinfo-records", false, "Query the HINFO records of the domains and subdomains, flagging the hardware and OS disclosed")
+	enumFlags.BoolVar(&args.Options.URIRecords, "uri-records", false, "Query the URI records at the SRV service names of the domains and subdomains")
+	enumFlags.BoolVar(&args.Options.APLRecords, "apl-records", false, "Query the APL records of the domains and subdomains, sweeping their prefixes in active mode")
+	enumFlags.BoolVar(&args.Options.GlueRecords, "glue", false, "Keep the nameserver addresses from the additional section of the NS responses")
+	enumFlags.BoolVar(&args.Options.RequireCreds, "require-creds", false, "Abort when a selected data source lacks valid credentials")
+	enumFlags.BoolVar(&args.Options.ResolvePTR, "ptr", false, "Resolve PTR records for the discovered addresses")
+	enumFlags.BoolVar(&args.Options.ResolveOnly, "resolve-only", false, "Only resolve the provided and known names")
+	enumFlags.BoolVar(&args.Options.ValidateList, "validate-list", false, "Only resolve and validate the provided names against the wildcards, without discovering more names")
 	enumFlags.BoolVar(&args.Options.Silent, "silent", false, "Disable all output during execution")
+	enumFlags.BoolVar(&args.Options.Tree, "tree", false, "Render the names discovered as a tree that is updated during execution")
 	enumFlags.BoolVar(&args.Options.Verbose, "v", false, "Output status / debug / troubleshooting info")
 }
 
@@ -142,6 +607,19 @@ func defineEnumFilepathFlags(enumFlags *flag.FlagSet, args *enumArgs) {
 	enumFlags.Var(&args.Filepaths.Resolvers, "rf", "Path to a file providing untrusted DNS resolvers")
 	enumFlags.Var(&args.Filepaths.Trusted, "trf", "Path to a file providing trusted DNS resolvers")
 	enumFlags.StringVar(&args.Filepaths.ScriptsDirectory, "scripts", "", "Path to a directory containing ADS scripts")
+	enumFlags.StringVar(&args.Filepaths.CertificatesPEM, "cert-pem", "", "Path to a file of PEM certificates providing known names")
+	enumFlags.StringVar(&args.Filepaths.NamesCSV, "nf-csv", "", "Path to a CSV file of known names with the columns name, domain and source")
+	enumFlags.StringVar(&args.Filepaths.GeoIPDatabase, "geoip", "", "Path to a MaxMind database used to geolocate the resolved addresses")
+	enumFlags.StringVar(&args.Filepaths.GraphFailover, "graph-failover", "", "Path to the file receiving the graph writes that fail")
+	enumFlags.StringVar(&args.Filepaths.SrcCheckpoints, "src-checkpoints", "", "Path to the directory keeping the pagination progress of the data sources, so an interrupted run resumes")
+	enumFlags.StringVar(&args.Filepaths.SweepCheckpoint, "reverse-checkpoint", "", "Path to the file keeping the progress of -reverse-only, so an interrupted sweep resumes")
+	enumFlags.StringVar(&args.Filepaths.SOASerials, "soa-serials", "", "Path to the file keeping the SOA serials of the zones seen by each enumeration")
+	enumFlags.StringVar(&args.Filepaths.RawResponseDir, "raw-dir", "", "Path to a directory where the raw DNS requests and responses will be written")
+	enumFlags.StringVar(&args.Filepaths.JSONOutput, "json", "", "Path to the JSON lines file of the resolved names, or - for STDOUT")
+	enumFlags.StringVar(&args.Compression, "compress", "", "Compress the -json and -template outputs with gzip or zstd")
+	enumFlags.StringVar(&args.Filepaths.SQLiteOutput, "sqlite", "", "Path to the SQLite database file that will store the resolved records")
+	enumFlags.StringVar(&args.Filepaths.STIXOutput, "stix", "", "Path to the STIX 2.1 bundle file for the names, addresses and infrastructure")
+	enumFlags.StringVar(&args.Filepaths.CSVOutput, "csv", "", "Path to the CSV file with a row for each record of the names: name, domain, record_type, record_data, source, tag and first_seen")
 	enumFlags.StringVar(&args.Filepaths.TermOut, "o", "", "Path to the text file containing terminal stdout/stderr")
 }
 
@@ -171,6 +649,16 @@ func runEnumCommand(clArgs []string) {
 	}
 	defer func() { _ = sys.Shutdown() }()
 
+	if args.HealthInterval > 0 {
+		if err := sys.StartResolverHealthChecks(time.Duration(args.HealthInterval)*time.Second, args.HealthFailures); err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := scripting.SetSRVServices(args.SRVServices); err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		os.Exit(1)
+	}
 	if err := sys.SetDataSources(datasrcs.GetAllSources(sys)); err != nil {
 		r.Fprintf(color.Error, "%v\n", err)
 		os.Exit(1)
@@ -182,13 +670,190 @@ func runEnumCommand(clArgs []string) {
 		r.Fprintf(color.Error, "%s\n", "Failed to setup the enumeration")
 		os.Exit(1)
 	}
+	e.MinTTLFlag = args.MinTTLFlag
+	if len(args.SplitHorizon) == 2 {
+		e.SplitHorizonResolvers.Internal = args.SplitHorizon[0]
+		e.SplitHorizonResolvers.Public = args.SplitHorizon[1]
+	}
+	e.ResolveOnly = args.Options.ResolveOnly
+	e.ValidateListOnly = args.Options.ValidateList
+	e.MaxDuration = time.Duration(args.Timeout) * time.Minute
+	e.ResolvePTRForAddresses = args.Options.ResolvePTR
+	e.ScopeCIDRs = args.ScopeCIDRs
+	e.PipelineBufferSize = args.PipelineBuffer
+	e.NSECWalk = args.Options.NSECWalk
+	e.SOCKS5Proxy = args.SOCKS5Proxy
+	e.EnrichmentTypes = args.Enrichment.Slice()
+	e.WildcardDetectRCODEs = args.WildcardRcodes.Slice()
+	e.KnownWildcards = args.KnownWildcards
+	e.TestOpenResolvers = args.Options.OpenRes
+	e.CheckLameDelegation = args.Options.LameDeleg
+	e.DetectTakeovers = args.Options.Takeover
+	e.DetectNSInconsistency = args.Options.NSConsist
+	e.DetectRebinding = args.Options.Rebinding
+	e.ClusterSimilarity = args.ClusterSimilarity
+	e.MapMailInfra = args.Options.MailInfra
+	e.RedactFields = args.RedactFields
+	e.RedactCIDRs = args.RedactCIDRs
+	e.QueryCERT = args.Options.CERTRecords
+	e.QueryLOC = args.Options.LOCRecords
+	e.QueryHINFO = args.Options.HINFORecords
+	e.QueryAPL = args.Options.APLRecords
+	e.QueryURI = args.Options.URIRecords
+	e.SRVPortProbes = args.SRVPorts
+	e.EmailLocalParts = args.EmailLocalParts
+	e.CollectGlueRecords = args.Options.GlueRecords
+	e.RequireAllCredentials = args.Options.RequireCreds
+	e.PartitionByDomain = args.Options.Partition
+	e.OnlyNewNames = args.Options.OnlyNew
+	e.OutputActiveOnly = args.Options.ActiveOnly
+	e.OutputPassiveOnly = args.Options.PassiveOnly
+	e.UseDNSCookies = args.Options.Cookies
+	e.NonRecursive = args.Options.CacheSnoop
+	e.RecordOutOfScopeCNAMEs = args.Options.OOSCNAMEs
+	e.PreferCNAMEWithAddresses = args.Options.CNAMEAddrs
+	e.RandomizeQueryOrder = args.Options.RandomOrder
+	e.CaptureFullResponse = args.Options.FullResponse
+	e.EnablePermutations = args.Options.Permute
+	e.IncludeUnresolvable = args.Options.Unresolvable
+	e.GeoIPDatabase = args.Filepaths.GeoIPDatabase
+	e.ProvidedNamesCSV = args.Filepaths.NamesCSV
+	e.ProvidedCertificatesPEM = args.Filepaths.CertificatesPEM
+	e.CertificateWildcardBases = args.Options.CertBases
+	e.RawResponseDir = args.Filepaths.RawResponseDir
+	e.GraphFailoverFile = args.Filepaths.GraphFailover
+	e.ReverseSweepCheckpointFile = args.Filepaths.SweepCheckpoint
+	e.DataSourceCheckpointDir = args.Filepaths.SrcCheckpoints
+	e.SOASerialFile = args.Filepaths.SOASerials
+	e.GraphFailoverReplay = args.Options.ReplayGraph
+	e.ForceTCP = args.Options.ForceTCP
+	e.TryANYFirst = args.Options.TryANY
+	e.ExpandSPF = args.Options.ExpandSPF
+	e.ValidateAgainstAuthoritative = args.Options.ValidateAuth
+	e.MaxInFlightNames = args.MaxInFlight
+	e.MaxResolveWorkers = args.MaxWorkers
+	e.MaxTotalQueries = args.MaxQueries
+	e.SourcePortRange = args.SourcePorts
+	e.SourceIP = args.SourceIP
+	e.TCPPoolMaxIdle = args.TCPPoolMaxIdle
+	e.QueryJitter = time.Duration(args.QueryJitter) * time.Millisecond
+	e.QPSRampUpDuration = time.Duration(args.QPSRampUp) * time.Second
+	e.ForceResolver = args.ForceResolver
+	e.OutputTemplate = args.OutputTemplate
+	e.MaxAnswerBytes = args.MaxAnswerBytes
+	e.DedupeFilterType = args.DedupeFilter
+	e.DedupeFilterCapacity = args.DedupeCapacity
+	e.DedupeFalsePositiveRate = args.DedupeFPRate
+	e.TCPPoolIdleTimeout = time.Duration(args.TCPPoolIdle) * time.Second
+	e.GraphBatchSize = args.GraphBatchSize
+	e.GraphFlushInterval = time.Duration(args.GraphFlush) * time.Millisecond
+	e.OutputFlushInterval = time.Duration(args.OutputFlush) * time.Second
+	e.ProbeHTTP = args.Options.ProbeHTTP
+	e.HTTPProbeTimeout = time.Duration(args.ProbeTimeout) * time.Second
+	e.MaxResults = args.MaxResults
+	e.MinConfidence = args.MinConfidence
+	if args.DiscoveryBalance >= 0 {
+		e.DiscoveryBalance = &args.DiscoveryBalance
+	}
+	e.SourceTimeouts = args.SourceTimeouts
+	e.DefaultSourceTimeout = time.Duration(args.SourceTimeout) * time.Second
+	e.MaxSubdomainsPerParent = args.MaxPerParent
+	e.WildcardFloodThreshold = args.FloodThreshold
+	e.WildcardFloodWindow = time.Duration(args.FloodWindow) * time.Second
+	e.WildcardFloodRate = args.FloodRate
+	e.CompletionGracePeriod = time.Duration(args.GracePeriod) * time.Second
+	e.BruteSamplePercent = args.BruteSample
+	e.SampleSeed = args.SampleSeed
+	e.NegativeCacheTTL = time.Duration(args.NegCacheTTL) * time.Second
+	e.NegativeCacheSize = args.NegCacheSize
+	e.ExcludeNameRegexps = args.ExcludeNames
+	e.PriorityPatterns = args.PriorityNames
+	e.ResolversByType = args.ResolversByType
+	e.DomainResolverAffinity = args.ResolverAffinity
+	e.CanaryChecks = args.CanaryChecks
+	e.VantageProxies = args.VantageProxies
+	e.WordlistURL = args.WordlistURL
+	e.WordlistHeaders = args.WordlistHeaders
+	e.AutoExpandScope = args.ExpandScope
+	e.PrivateAddressPolicy = args.PrivateAddrs
+	e.SinkholeAddresses = args.Sinkholes
+	e.ScopeExpansionSuffixes = args.ExpandSuffixes
+	e.MaxScopeExpansion = args.MaxExpansion
+	e.InheritScopeViaCNAME = len(args.CNAMEScope) > 0
+	e.CNAMEScopeSuffixes = args.CNAMEScope
+	e.MaxCNAMEScopeDepth = args.CNAMEScopeDepth
+	e.DomainSettings = args.DomainSettings
+	e.ReverseSweepSize = args.ReverseSweepSize
+	e.ReverseBatchSize = args.ReverseBatchSize
+	e.CanaryAbort = args.Options.CanaryAbort
+	if args.EDNSBufferSize < dns.MinMsgSize || args.EDNSBufferSize > dns.MaxMsgSize {
+		r.Fprintf(color.Error, "The EDNS buffer size must be between %d and %d\n", dns.MinMsgSize, dns.MaxMsgSize)
+		os.Exit(1)
+	}
+	e.EDNSBufferSize = uint16(args.EDNSBufferSize)
+	e.CompressOutput = args.Compression
+	if args.Filepaths.JSONOutput != "" {
+		if err := e.SetJSONLOutput(args.Filepaths.JSONOutput); err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+	if args.Filepaths.SQLiteOutput != "" {
+		if err := e.SetSQLiteOutput(args.Filepaths.SQLiteOutput); err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+	if args.Neo4jURI != "" {
+		if err := e.SetNeo4jOutput(args.Neo4jURI, args.Neo4jUser, args.Neo4jPass); err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+	if args.S3Endpoint != "" {
+		if err := e.SetObjectStorageOutput(enum.ObjectStorageConfig{
+			Endpoint:     args.S3Endpoint,
+			Region:       args.S3Region,
+			Bucket:       args.S3Bucket,
+			Prefix:       args.S3Prefix,
+			AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		}); err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+	if args.NATSURL != "" {
+		if err := e.SetMessageQueueOutput(enum.MessageQueueConfig{
+			URL:     args.NATSURL,
+			Subject: args.NATSSubject,
+			Token:   os.Getenv("NATS_TOKEN"),
+		}); err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+	if args.GRPCAddr != "" {
+		if err := e.ServeGRPC(args.GRPCAddr); err != nil {
+			r.Fprintf(color.Error, "%v\n", err)
+			os.Exit(1)
+		}
+	}
 
 	var wg sync.WaitGroup
 	var outChans []chan string
 	// This channel sends the signal for goroutines to terminate
 	done := make(chan struct{})
-	// Print output only if JSONOutput is not meant for STDOUT
-	if args.Filepaths.JSONOutput != "-" {
+	// Print output only if JSONOutput is not meant for STDOUT, and the tree falls back to
+	// the flat output when STDOUT is not a terminal. The output template replaces both
+	printed := args.Filepaths.JSONOutput != "-" && args.OutputTemplate == ""
+	if printed && args.Options.Tree && term.IsTerminal(int(os.Stdout.Fd())) {
+		wg.Add(1)
+		tree := format.NewTree()
+		e.SubscribeOutput(tree.Insert)
+		go printTreeOutput(tree, done, &wg)
+	} else if printed {
 		wg.Add(1)
 		// This goroutine will handle printing the output
 		printOutChan := make(chan string, 10)
@@ -202,13 +867,7 @@ func runEnumCommand(clArgs []string) {
 	go saveTextOutput(e, args, txtOutChan, &wg)
 	outChans = append(outChans, txtOutChan)
 
-	var ctx context.Context
-	var cancel context.CancelFunc
-	if args.Timeout == 0 {
-		ctx, cancel = context.WithCancel(context.Background())
-	} else {
-		ctx, cancel = context.WithTimeout(context.Background(), time.Duration(args.Timeout)*time.Minute)
-	}
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	wg.Add(1)
@@ -226,14 +885,74 @@ func runEnumCommand(clArgs []string) {
 		case <-c.Done():
 		}
 	}(done, ctx, cancel)
-	// Start the enumeration process
-	if err := e.Start(ctx); err != nil {
+	// Start the enumeration process, or only the reverse DNS sweep of the netblock
+	if args.ReverseCIDR != nil {
+		// an interrupted sweep keeps the names already stored
+		if _, err = e.ReverseOnly(ctx, args.ReverseCIDR); ctx.Err() != nil {
+			err = nil
+		}
+	} else {
+		err = e.Start(ctx)
+	}
+	if err != nil {
 		r.Println(err)
 		os.Exit(1)
 	}
 	// Let all the output goroutines know that the enumeration has finished
 	close(done)
 	wg.Wait()
+	if len(args.SplitHorizon) == 2 {
+		printSplitHorizonFindings(e)
+	}
+	if args.MinTTLFlag > 0 {
+		printLowTTLAnswers(e)
+	}
+	if args.Options.Partition {
+		savePartitionedOutput(e, args)
+	}
+	if args.Filepaths.STIXOutput != "" {
+		saveSTIXOutput(e, args.Filepaths.STIXOutput)
+	}
+	if args.Filepaths.CSVOutput != "" {
+		saveCSVOutput(e, args.Filepaths.CSVOutput)
+	}
+	if args.Options.OOSCNAMEs {
+		printOutOfScopeCNAMEs(e)
+	}
+	if args.Options.ProbeHTTP {
+		printHTTPProbes(e)
+	}
+	if args.Options.Takeover {
+		printTakeoverFindings(e)
+	}
+	if args.Options.Rebinding {
+		printRebindingFindings(e)
+	}
+	if args.Options.HINFORecords {
+		printHINFOFindings(e)
+	}
+	if args.Options.NSConsist {
+		printNSInconsistencies(e)
+	}
+	if args.PrivateAddrs == enum.PrivateAddressFlag {
+		printPrivateAddresses(e)
+	}
+	if args.Options.Clusters {
+		printAddressClusters(e)
+	}
+	if args.Filepaths.SOASerials != "" {
+		printSOASerialChanges(e, args.SOAPrevious)
+	}
+	if args.Options.MailInfra {
+		printMailInfrastructure(e)
+	}
+	if args.Options.Verbose {
+		printResolverStats(e)
+		printSinkholeCounts(e)
+	}
+	if !args.Options.Silent {
+		printSummary(e)
+	}
 	fmt.Fprintf(color.Error, "\n%s\n", green("The enumeration has finished"))
 }
 
@@ -245,6 +964,8 @@ func argsAndConfig(clArgs []string) (*config.Config, *enumArgs) {
 		BruteWordListMask: stringset.New(),
 		Blacklist:         stringset.New(),
 		Domains:           stringset.New(),
+		Enrichment:        stringset.New(),
+		WildcardRcodes:    stringset.New(),
 		Excluded:          stringset.New(),
 		Included:          stringset.New(),
 		Names:             stringset.New(),
@@ -346,6 +1067,33 @@ func argsAndConfig(clArgs []string) (*config.Config, *enumArgs) {
 	return cfg, &args
 }
 
+func printSplitHorizonFindings(e *enum.Enumeration) {
+	findings := e.SplitHorizonFindings()
+	if len(findings) == 0 {
+		return
+	}
+
+	fmt.Fprintf(color.Output, "\n%s\n", yellow("Names answered differently by the internal and public resolvers:"))
+	for _, f := range findings {
+		fmt.Fprintf(color.Output, "%s\n", green(f.Name))
+		fmt.Fprintf(color.Output, "    %s %s\n", blue("internal"), strings.Join(f.Internal, ", "))
+		fmt.Fprintf(color.Output, "    %s %s\n", blue("public"), strings.Join(f.Public, ", "))
+	}
+}
+
+func printLowTTLAnswers(e *enum.Enumeration) {
+	answers := e.LowTTLAnswers()
+	if len(answers) == 0 {
+		return
+	}
+
+	fmt.Fprintf(color.Output, "\n%s\n", yellow("DNS answers with a low TTL:"))
+	for _, a := range answers {
+		fmt.Fprintf(color.Output, "%s %s %s %s\n", green(a.Name), blue(dns.TypeToString[uint16(a.Type)]),
+			yellow("TTL "+strconv.Itoa(a.TTL)), a.Data)
+	}
+}
+
 func printOutput(e *enum.Enumeration, args *enumArgs, output chan string, wg *sync.WaitGroup) {
 	defer wg.Done()
 
@@ -361,6 +1109,44 @@ func printOutput(e *enum.Enumeration, args *enumArgs, output chan string, wg *sy
 	}
 }
 
+// printTreeOutput renders the tree of the names discovered each second, with the lines cut to the width
+// of the terminal and the children of each node collapsed, so the large trees fit the height.
+func printTreeOutput(tree *format.Tree, done chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	render := func() {
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			width, height = 80, 24
+		}
+		children := height / 4
+		if children < 3 {
+			children = 3
+		}
+
+		var buf bytes.Buffer
+		// clear the terminal before rendering the tree again
+		buf.WriteString("\033[H\033[2J")
+		tree.Fprint(&buf, width, children)
+		_, _ = color.Output.Write(buf.Bytes())
+	}
+
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-done:
+			render()
+			if tree.Len() == 0 {
+				r.Println("No assets were discovered")
+			}
+			return
+		case <-t.C:
+			render()
+		}
+	}
+}
+
 func saveTextOutput(e *enum.Enumeration, args *enumArgs, output chan string, wg *sync.WaitGroup) {
 	defer wg.Done()
 
@@ -395,6 +1181,275 @@ func saveTextOutput(e *enum.Enumeration, args *enumArgs, output chan string, wg
 	}
 }
 
+// savePartitionedOutput writes the names stored under the sub-event of each root domain to a separate text file.
+func savePartitionedOutput(e *enum.Enumeration, args *enumArgs) {
+	prefix := filepath.Join(config.OutputDirectory(e.Config.Dir), "amass")
+	if args.Filepaths.AllFilePrefix != "" {
+		prefix = args.Filepaths.AllFilePrefix
+	}
+
+	for d, id := range e.DomainEvents() {
+		var data []byte
+		if names := e.EventFQDNs(id); len(names) > 0 {
+			data = []byte(strings.Join(names, "\n") + "\n")
+		}
+
+		path := prefix + "." + d + ".txt"
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			r.Fprintf(color.Error, "Failed to write the output for %s: %v\n", d, err)
+		}
+	}
+}
+
+func saveSTIXOutput(e *enum.Enumeration, path string) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to open the STIX output file: %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	if err := e.ExportSTIX(f); err != nil {
+		r.Fprintf(color.Error, "Failed to write the STIX output: %v\n", err)
+	}
+}
+
+func saveCSVOutput(e *enum.Enumeration, path string) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		r.Fprintf(color.Error, "Failed to open the CSV output file: %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	if err := e.ExportCSV(f); err != nil {
+		r.Fprintf(color.Error, "Failed to write the CSV output: %v\n", err)
+	}
+}
+
+func printOutOfScopeCNAMEs(e *enum.Enumeration) {
+	cnames := e.OutOfScopeCNAMEs()
+	if len(cnames) == 0 {
+		return
+	}
+
+	fmt.Fprintf(color.Output, "\n%s\n", yellow("CNAME records pointing outside of the scope:"))
+	for _, c := range cnames {
+		fmt.Fprintf(color.Output, "%s %s %s\n", green(c.Name), blue("-->"), yellow(c.Target))
+	}
+}
+
+func printTakeoverFindings(e *enum.Enumeration) {
+	findings := e.TakeoverFindings()
+	if len(findings) == 0 {
+		return
+	}
+
+	fmt.Fprintf(color.Output, "\n%s\n", yellow("Possible subdomain takeovers:"))
+	for _, f := range findings {
+		fmt.Fprintf(color.Output, "%s %s %s %s %s\n", green(f.Name), blue(f.Type), yellow(f.Target),
+			blue(f.Service+" "+f.Rcode), r.Sprint(f.Severity))
+	}
+}
+
+func printNSInconsistencies(e *enum.Enumeration) {
+	findings := e.NSInconsistencies()
+	if len(findings) == 0 {
+		return
+	}
+
+	fmt.Fprintf(color.Output, "\n%s\n", yellow("Names answered differently by the nameservers:"))
+	for _, f := range findings {
+		servers := make([]string, 0, len(f.Answers))
+		for server := range f.Answers {
+			servers = append(servers, server)
+		}
+		sort.Strings(servers)
+
+		fmt.Fprintf(color.Output, "%s %s %s\n", green(f.Name), blue(f.Type), yellow(f.Zone))
+		for _, server := range servers {
+			fmt.Fprintf(color.Output, "    %s %s\n", blue(server), strings.Join(f.Answers[server], ", "))
+		}
+	}
+}
+
+func printAddressClusters(e *enum.Enumeration) {
+	clusters := e.AddressClusters()
+	if len(clusters) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(clusters))
+	for key := range clusters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(color.Output, "\n%s\n", yellow("Names sharing the same addresses:"))
+	for _, key := range keys {
+		fmt.Fprintf(color.Output, "%s\n", blue(strings.ReplaceAll(key, ",", ", ")))
+		for _, name := range clusters[key] {
+			fmt.Fprintf(color.Output, "    %s\n", green(name))
+		}
+	}
+}
+
+func printSOASerialChanges(e *enum.Enumeration, prev string) {
+	fmt.Fprintf(color.Output, "\n%s %s\n", yellow("The SOA serials were kept under the event UUID"), green(e.SOASerialUUID()))
+	if prev == "" {
+		return
+	}
+
+	changes, err := e.SOASerialChanges(prev)
+	if err != nil {
+		r.Fprintf(color.Error, "%v\n", err)
+		return
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	fmt.Fprintf(color.Output, "%s\n", yellow("Zones with changed SOA serials:"))
+	for _, c := range changes {
+		fmt.Fprintf(color.Output, "%s %d -> %d\n", green(c.Zone), c.Previous, c.Current)
+	}
+}
+
+func printRebindingFindings(e *enum.Enumeration) {
+	findings := e.RebindingFindings()
+	if len(findings) == 0 {
+		return
+	}
+
+	fmt.Fprintf(color.Output, "\n%s\n", yellow("Possible DNS rebinding:"))
+	for _, f := range findings {
+		line := fmt.Sprintf("%s %s %s", green(f.Name), yellow(strings.Join(f.Public, ",")), blue(strings.Join(f.Private, ",")))
+		if f.LowTTL {
+			line += " " + yellow("TTL "+strconv.Itoa(f.MinTTL))
+		}
+		fmt.Fprintf(color.Output, "%s %s\n", line, r.Sprint(f.Severity))
+	}
+}
+
+func printHINFOFindings(e *enum.Enumeration) {
+	findings := e.HINFOFindings()
+	if len(findings) == 0 {
+		return
+	}
+
+	fmt.Fprintf(color.Output, "\n%s\n", yellow("Host information disclosed in HINFO records:"))
+	for _, f := range findings {
+		fmt.Fprintf(color.Output, "%s %s %s %s\n", green(f.Name), yellow(f.CPU), blue(f.OS), r.Sprint(f.Severity))
+	}
+}
+
+func printPrivateAddresses(e *enum.Enumeration) {
+	addrs := e.PrivateAddresses()
+	if len(addrs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(color.Output, "\n%s\n", yellow("Private addresses found in the answers:"))
+	for _, a := range addrs {
+		fmt.Fprintf(color.Output, "%s %s %s\n", green(a.Name), blue(a.Type), yellow(a.Address))
+	}
+}
+
+func printMailInfrastructure(e *enum.Enumeration) {
+	exchangers := e.MailInfrastructure()
+	if len(exchangers) == 0 {
+		return
+	}
+
+	fmt.Fprintf(color.Output, "\n%s\n", yellow("Mail exchangers:"))
+	for _, mx := range exchangers {
+		line := fmt.Sprintf("%s %s %s", green(mx.Name), blue(strconv.Itoa(mx.Priority)), yellow(mx.Host))
+		if !mx.InScope {
+			line += " " + r.Sprint("out of scope")
+		}
+		fmt.Fprintln(color.Output, line)
+	}
+}
+
+func printHTTPProbes(e *enum.Enumeration) {
+	probes := e.HTTPProbes()
+	if len(probes) == 0 {
+		return
+	}
+
+	fmt.Fprintf(color.Output, "\n%s\n", yellow("HTTP probes:"))
+	for _, p := range probes {
+		line := fmt.Sprintf("%s %s", green(p.URL), yellow(strconv.Itoa(p.StatusCode)))
+		if p.Server != "" {
+			line += " " + blue(p.Server)
+		}
+		if p.Location != "" {
+			line += fmt.Sprintf(" %s %s", blue("-->"), p.Location)
+		}
+		fmt.Fprintln(color.Output, line)
+	}
+}
+
+func printResolverStats(e *enum.Enumeration) {
+	stats := e.ResolverStats()
+	if len(stats) == 0 {
+		return
+	}
+
+	fmt.Fprintf(color.Error, "\n%s\n", yellow("Resolver response times:"))
+	for _, s := range stats {
+		fmt.Fprintf(color.Error, "%s %s %s, %s %s, %s %s, %s %s\n", green(s.Resolver),
+			yellow(strconv.FormatInt(s.Queries, 10)), blue("queries"),
+			yellow(fmt.Sprintf("%.1f%%", s.ErrorRate*100)), blue("errors"),
+			yellow(s.P50.Round(time.Millisecond).String()), blue("p50"),
+			yellow(s.P95.Round(time.Millisecond).String()), blue("p95"))
+	}
+}
+
+func printSinkholeCounts(e *enum.Enumeration) {
+	counts := e.SinkholeCounts()
+	if len(counts) == 0 {
+		return
+	}
+
+	resolvers := make([]string, 0, len(counts))
+	for r := range counts {
+		resolvers = append(resolvers, r)
+	}
+	sort.Strings(resolvers)
+
+	fmt.Fprintf(color.Error, "\n%s\n", yellow("Resolvers answering with sinkhole addresses:"))
+	for _, r := range resolvers {
+		fmt.Fprintf(color.Error, "%s %s %s\n", green(r), yellow(strconv.Itoa(counts[r])), blue("sinkholed answers"))
+	}
+}
+
+func printSummary(e *enum.Enumeration) {
+	s, err := e.Summary()
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintf(color.Error, "\n%s %s, %s %s, %s %s, %s %s\n",
+		yellow(strconv.Itoa(s.Names)), green("names"), yellow(strconv.Itoa(s.Addresses)), green("addresses"),
+		yellow(strconv.Itoa(s.Netblocks)), green("netblocks"), yellow(strconv.Itoa(s.ASNs)), green("ASNs"))
+	for _, counts := range []map[string]int{s.Records, s.Sources} {
+		keys := make([]string, 0, len(counts))
+		for k := range counts {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var parts []string
+		for _, k := range keys {
+			parts = append(parts, fmt.Sprintf("%s: %s", green(k), yellow(strconv.Itoa(counts[k]))))
+		}
+		if len(parts) > 0 {
+			fmt.Fprintln(color.Error, strings.Join(parts, ", "))
+		}
+	}
+}
+
 func processOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, outputs []chan string, done chan struct{}, wg *sync.WaitGroup) {
 	defer wg.Done()
 	defer func() {
@@ -439,6 +1494,12 @@ func processOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, ou
 func writeLogsAndMessages(logs *io.PipeReader, logfile string, verbose bool) {
 	wildcard := regexp.MustCompile("DNS wildcard")
 	queries := regexp.MustCompile("Querying")
+	zonexfr := regexp.MustCompile("Zone transfer allowed")
+	openres := regexp.MustCompile("Open resolver")
+	lame := regexp.MustCompile("Lame delegation")
+	takeover := regexp.MustCompile("Takeover")
+	rebinding := regexp.MustCompile("Possible rebinding")
+	hinfo := regexp.MustCompile("HINFO disclosure")
 
 	var filePtr *os.File
 	if logfile != "" {
@@ -479,6 +1540,30 @@ func writeLogsAndMessages(logs *io.PipeReader, logfile string, verbose bool) {
 		if verbose && queries.FindString(line) != "" {
 			fgY.Fprintln(color.Error, line)
 		}
+		// Nameservers that permit a full zone transfer
+		if zonexfr.FindString(line) != "" {
+			r.Fprintln(color.Error, line)
+		}
+		// Nameservers that answer recursive queries for anyone
+		if openres.FindString(line) != "" {
+			r.Fprintln(color.Error, line)
+		}
+		// Nameservers listed for zones they do not serve
+		if lame.FindString(line) != "" {
+			fgR.Fprintln(color.Error, line)
+		}
+		// Delegations to unclaimed cloud service targets
+		if takeover.FindString(line) != "" {
+			r.Fprintln(color.Error, line)
+		}
+		// Names resolving to both public and private addresses
+		if rebinding.FindString(line) != "" {
+			r.Fprintln(color.Error, line)
+		}
+		// Hardware and operating systems published in HINFO records
+		if hinfo.FindString(line) != "" {
+			fgY.Fprintln(color.Error, line)
+		}
 	}
 }
 
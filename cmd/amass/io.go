@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/caffix/netmap"
@@ -41,7 +42,10 @@ func NewOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, filter
 	arrow := white("-->")
 	start := e.Config.CollectionStartTime.UTC()
 	for _, from := range assets {
-		fromstr := extractAssetName(from)
+		if _, ok := from.Asset.(domain.FQDN); ok && e.OnlyNewNames && e.SeenBefore(from.CreatedAt) {
+			continue
+		}
+		fromstr := redactAssetName(e, from) + geoLocationInfo(e, from)
 
 		if rels, err := g.DB.OutgoingRelations(from, start); err == nil {
 			for _, rel := range rels {
@@ -50,7 +54,7 @@ func NewOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, filter
 					continue
 				}
 				if to, err := g.DB.FindById(rel.ToAsset.ID, start); err == nil {
-					tostr := extractAssetName(to)
+					tostr := redactAssetName(e, to) + geoLocationInfo(e, to)
 
 					output = append(output, fmt.Sprintf("%s %s %s %s %s", fromstr, arrow, magenta(rel.Type), arrow, tostr))
 					filter.Insert(lineid)
@@ -91,9 +95,66 @@ func extractAssetName(a *types.Asset) string {
 	return result
 }
 
+// redactAssetName replaces the addresses and netblocks within the RedactCIDRs with the placeholder.
+func redactAssetName(e *enum.Enumeration, a *types.Asset) string {
+	switch v := a.Asset.(type) {
+	case network.IPAddress:
+		if addr := v.Address.String(); e.RedactAddress(addr) != addr {
+			return green(enum.RedactPlaceholder) + blue(" (IPAddress)")
+		}
+	case network.Netblock:
+		if addr := v.Cidr.Addr().String(); e.RedactAddress(addr) != addr {
+			return green(enum.RedactPlaceholder) + blue(" (Netblock)")
+		}
+	}
+	return extractAssetName(a)
+}
+
+func geoLocationInfo(e *enum.Enumeration, a *types.Asset) string {
+	ip, ok := a.Asset.(network.IPAddress)
+	if !ok || e.Redacted("geo") || e.RedactAddress(ip.Address.String()) != ip.Address.String() {
+		return ""
+	}
+
+	loc := e.GeoLocation(ip.Address.String())
+	if loc == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, p := range []string{loc.CountryCode, loc.City} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if loc.ASN != 0 {
+		parts = append(parts, "AS"+strconv.Itoa(loc.ASN))
+	}
+	return yellow(" [" + strings.Join(parts, ", ") + "]")
+}
+
 // ExtractOutput is a convenience method for obtaining new discoveries made by the enumeration process.
 func ExtractOutput(ctx context.Context, g *netmap.Graph, e *enum.Enumeration, filter *stringset.Set, asinfo bool) []*requests.Output {
-	return EventOutput(ctx, g, e.Config.Domains(), e.Config.CollectionStartTime, filter, asinfo, e.Sys.Cache())
+	output := EventOutput(ctx, g, e.Config.Domains(), e.Config.CollectionStartTime, filter, asinfo, e.Sys.Cache())
+
+	if e.OnlyNewNames {
+		var fresh []*requests.Output
+
+		for _, o := range output {
+			if !e.SeenBefore(o.FirstSeen) {
+				fresh = append(fresh, o)
+			}
+		}
+		output = fresh
+	}
+
+	for i, o := range output {
+		for j := range o.Addresses {
+			o.Addresses[j].Geo = e.GeoLocation(o.Addresses[j].Address.String())
+		}
+		output[i] = e.RedactOutput(o)
+	}
+	return output
 }
 
 type outLookup map[string]*requests.Output
@@ -128,9 +189,12 @@ func EventOutput(ctx context.Context, g *netmap.Graph, domains []string, since t
 	}
 
 	var names []string
+	// The graph records when each name was first and last seen across runs
+	seen := make(map[string]*types.Asset)
 	for _, a := range assets {
 		if n, ok := a.Asset.(domain.FQDN); ok && !f.Has(n.Name) {
 			names = append(names, n.Name)
+			seen[n.Name] = a
 		}
 	}
 
@@ -142,8 +206,11 @@ func EventOutput(ctx context.Context, g *netmap.Graph, domains []string, since t
 		}
 
 		o := &requests.Output{
-			Name:   n,
-			Domain: d,
+			Name:        n,
+			Domain:      d,
+			FirstSeen:   seen[n].CreatedAt,
+			LastSeen:    seen[n].LastSeen,
+			UnicodeName: enum.UnicodeName(n),
 		}
 		res = append(res, o)
 		lookup[n] = o
@@ -0,0 +1,63 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/owasp-amass/resolve"
+	lua "github.com/yuin/gopher-lua"
+)
+
+var (
+	activeLock    sync.Mutex
+	domainActives map[string]bool
+)
+
+// SetDomainActive replaces the per-domain overrides of the active mode, which allow or prevent
+// the active techniques of the scripts for the names within each domain. An empty map restores
+// the mode of the configuration for all the domains.
+func SetDomainActive(domains map[string]bool) {
+	clean := make(map[string]bool, len(domains))
+	for d, active := range domains {
+		clean[strings.ToLower(resolve.RemoveLastDot(strings.TrimSpace(d)))] = active
+	}
+
+	activeLock.Lock()
+	defer activeLock.Unlock()
+
+	domainActives = clean
+}
+
+// activeFor returns the override of the most specific domain containing the name, and false
+// for the second value when the name has no override.
+func activeFor(name string) (bool, bool) {
+	activeLock.Lock()
+	defer activeLock.Unlock()
+
+	var best string
+	var active, found bool
+	name = strings.ToLower(resolve.RemoveLastDot(name))
+	for d, a := range domainActives {
+		if len(d) > len(best) && (name == d || strings.HasSuffix(name, "."+d)) {
+			best = d
+			active = a
+			found = true
+		}
+	}
+	return active, found
+}
+
+// Wrapper so that scripts can check whether the active techniques are allowed for the name.
+func (s *Script) active(L *lua.LState) int {
+	if active, found := activeFor(L.CheckString(1)); found {
+		L.Push(lua.LBool(active))
+		return 1
+	}
+
+	L.Push(lua.LBool(s.sys.Config().Active))
+	return 1
+}
@@ -0,0 +1,55 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestDomainActive(t *testing.T) {
+	defer SetDomainActive(nil)
+
+	SetDomainActive(map[string]bool{
+		"Owasp.org":       true,
+		"admin.owasp.org": false,
+	})
+
+	cfg := config.NewConfig()
+	L := lua.NewState()
+	defer L.Close()
+	s := &Script{sys: &systems.SimpleSystem{Cfg: cfg}}
+	L.SetGlobal("active", L.NewFunction(s.active))
+
+	for _, test := range []struct {
+		name     string
+		expected bool
+	}{
+		{"owasp.org", true},
+		{"www.owasp.org", true},
+		{"admin.owasp.org", false},
+		{"vpn.admin.owasp.org", false},
+		{"example.com", false},
+	} {
+		if err := L.DoString(`result = active("` + test.name + `")`); err != nil {
+			t.Fatalf("failed to call active: %v", err)
+		}
+		if got := L.GetGlobal("result") == lua.LTrue; got != test.expected {
+			t.Errorf("%s: expected %t, but got %t", test.name, test.expected, got)
+		}
+	}
+
+	// names without an override follow the configuration
+	cfg.Active = true
+	if err := L.DoString(`result = active("example.com")`); err != nil {
+		t.Fatalf("failed to call active: %v", err)
+	}
+	if L.GetGlobal("result") != lua.LTrue {
+		t.Errorf("the active mode of the configuration was not followed")
+	}
+}
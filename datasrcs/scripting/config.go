@@ -123,7 +123,7 @@ func (s *Script) dataSourceConfig(L *lua.LState) int {
 		tb.RawSetString("ttl", lua.LNumber(cfg.TTL))
 	}
 
-	if creds := dsc.GetCredentials(cfg.Name); creds != nil {
+	if creds := s.credentials(cfg); creds != nil {
 		c := L.NewTable()
 
 		c.RawSetString("name", lua.LString(creds.Name))
@@ -146,6 +146,28 @@ func (s *Script) dataSourceConfig(L *lua.LState) int {
 	return 1
 }
 
+// credentials returns the next set of credentials in the rotation across the keys of the data source.
+func (s *Script) credentials(cfg *config.DataSource) *config.Credentials {
+	s.keysLock.Lock()
+	if s.keys == nil {
+		s.keys = newKeyRotation(cfg)
+	}
+	keys := s.keys
+	s.keysLock.Unlock()
+
+	return keys.nextCredentials()
+}
+
+// keyFailed informs the rotation that the most recent key returned an auth or rate limit error.
+func (s *Script) keyFailed() {
+	s.keysLock.Lock()
+	defer s.keysLock.Unlock()
+
+	if s.keys != nil {
+		s.keys.lastFailed()
+	}
+}
+
 // Wrapper so that scripts can check if a subdomain name is in scope.
 func (s *Script) inScope(L *lua.LState) int {
 	result := lua.LFalse
@@ -0,0 +1,70 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	lua "github.com/yuin/gopher-lua"
+)
+
+// PaginationCursors returns the pagination cursors the script has set for its queries in progress,
+// such as the next page for a domain, so the enumeration can save them.
+func (s *Script) PaginationCursors() map[string]string {
+	s.curLock.Lock()
+	defer s.curLock.Unlock()
+
+	cursors := make(map[string]string, len(s.cursors))
+	for key, cursor := range s.cursors {
+		cursors[key] = cursor
+	}
+	return cursors
+}
+
+// RestorePaginationCursors provides the cursors saved by a previous enumeration, which the script
+// obtains through pagination_cursor to resume the pagination where it left off.
+func (s *Script) RestorePaginationCursors(cursors map[string]string) {
+	s.curLock.Lock()
+	defer s.curLock.Unlock()
+
+	s.cursors = make(map[string]string, len(cursors))
+	for key, cursor := range cursors {
+		s.cursors[key] = cursor
+	}
+}
+
+// Wrapper so scripts can obtain the cursor saved for the query, or nil to start from the first page.
+func (s *Script) paginationCursor(L *lua.LState) int {
+	key := L.CheckString(1)
+
+	s.curLock.Lock()
+	cursor, found := s.cursors[key]
+	s.curLock.Unlock()
+
+	if found && cursor != "" {
+		L.Push(lua.LString(cursor))
+	} else {
+		L.Push(lua.LNil)
+	}
+	return 1
+}
+
+// Wrapper so scripts can save the cursor of the next page for the query, and remove it with
+// an empty cursor or nil once the pagination has completed.
+func (s *Script) setPaginationCursor(L *lua.LState) int {
+	key := L.CheckString(1)
+	cursor := L.OptString(2, "")
+
+	s.curLock.Lock()
+	defer s.curLock.Unlock()
+
+	if cursor == "" {
+		delete(s.cursors, key)
+		return 0
+	}
+	if s.cursors == nil {
+		s.cursors = make(map[string]string)
+	}
+	s.cursors[key] = cursor
+	return 0
+}
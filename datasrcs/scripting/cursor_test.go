@@ -0,0 +1,42 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"reflect"
+	"testing"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestPaginationCursors(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+	s := &Script{}
+	L.SetGlobal("pagination_cursor", L.NewFunction(s.paginationCursor))
+	L.SetGlobal("set_pagination_cursor", L.NewFunction(s.setPaginationCursor))
+
+	s.RestorePaginationCursors(map[string]string{"owasp.org": "4"})
+	if err := L.DoString(`
+		resumed = pagination_cursor("owasp.org")
+		fresh = pagination_cursor("example.com")
+		set_pagination_cursor("owasp.org", nil)
+		set_pagination_cursor("example.com", "2")
+	`); err != nil {
+		t.Fatalf("failed to call the cursor functions: %v", err)
+	}
+
+	if got := L.GetGlobal("resumed").String(); got != "4" {
+		t.Errorf("expected the restored cursor 4, but got %s", got)
+	}
+	if got := L.GetGlobal("fresh"); got != lua.LNil {
+		t.Errorf("expected nil for the query without a cursor, but got %v", got)
+	}
+
+	expected := map[string]string{"example.com": "2"}
+	if c := s.PaginationCursors(); !reflect.DeepEqual(c, expected) {
+		t.Errorf("expected the cursors %v, but got %v", expected, c)
+	}
+}
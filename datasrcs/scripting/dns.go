@@ -11,6 +11,7 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/miekg/dns"
@@ -226,6 +227,7 @@ func (s *Script) getPTR(ctx context.Context, addr string, ch chan struct{}) {
 		return
 	}
 
+	atomic.AddInt64(&sweepStats.AddressesQueried, 1)
 	msg := resolve.ReverseMsg(addr)
 	resp, err := s.dnsQuery(ctx, msg, s.sys.Resolvers(), 5)
 	if err != nil || resp == nil {
@@ -239,6 +241,7 @@ func (s *Script) getPTR(ctx context.Context, addr string, ch chan struct{}) {
 
 	if ans := resolve.ExtractAnswers(resp); len(ans) > 0 {
 		if records := resolve.AnswersByType(ans, dns.TypePTR); len(records) > 0 {
+			atomic.AddInt64(&sweepStats.NamesFound, 1)
 			s.newPTR(ctx, records[0])
 			return
 		}
@@ -311,8 +314,18 @@ func (s *Script) wrapZoneTransfer(L *lua.LState) int {
 		return 2
 	}
 
-	server := L.CheckString(3)
-	if server == "" {
+	var servers []string
+	switch v := L.Get(3).(type) {
+	case lua.LString:
+		servers = append(servers, string(v))
+	case *lua.LTable:
+		v.ForEach(func(_, value lua.LValue) {
+			if str, ok := value.(lua.LString); ok {
+				servers = append(servers, string(str))
+			}
+		})
+	}
+	if len(servers) == 0 {
 		L.Push(lua.LNil)
 		L.Push(lua.LString("failed to obtain the nameserver"))
 		return 2
@@ -325,26 +338,42 @@ func (s *Script) wrapZoneTransfer(L *lua.LState) int {
 		return 2
 	}
 
+	results := ZoneTransfers(ctx, name, domain, servers)
+
 	tb := L.NewTable()
-	if reqs, err := ZoneTransfer(ctx, name, domain, server); err == nil && len(reqs) > 0 {
-		for _, req := range reqs {
+	for _, res := range results {
+		// A permitted AXFR is a serious misconfiguration of the nameserver
+		if res.Type == "AXFR" {
+			s.sys.Config().Log.Printf("Zone transfer allowed: %s permitted an AXFR of %s", res.Server, name)
+		}
+		if res.Partial {
+			s.sys.Config().Log.Printf("Zone transfer incomplete: the %s of %s from %s ended before the closing SOA record", res.Type, name, res.Server)
+		}
+
+		for _, req := range res.Requests {
 			for _, rr := range req.Records {
 				entry := L.NewTable()
 				entry.RawSetString("rrname", lua.LString(rr.Name))
 				entry.RawSetString("rrtype", lua.LNumber(rr.Type))
 				entry.RawSetString("rrdata", lua.LString(rr.Data))
+				entry.RawSetString("server", lua.LString(res.Server))
+				entry.RawSetString("xfr", lua.LString(res.Type))
+				entry.RawSetString("partial", lua.LBool(res.Partial))
 				tb.Append(entry)
 			}
-			// Zone Transfers can reveal DNS wildcards
-			if n := amassdns.RemoveAsteriskLabel(req.Name); len(n) < len(req.Name) {
-				// Signal the wildcard discovery
-				s.Output() <- &requests.DNSRequest{
-					Name:   "www." + n,
-					Domain: req.Domain,
-				}
-			} else {
-				s.Output() <- req
+		}
+	}
+
+	for _, req := range MergeZoneTransferResults(results) {
+		// Zone Transfers can reveal DNS wildcards
+		if n := amassdns.RemoveAsteriskLabel(req.Name); len(n) < len(req.Name) {
+			// Signal the wildcard discovery
+			s.Output() <- &requests.DNSRequest{
+				Name:   "www." + n,
+				Domain: req.Domain,
 			}
+		} else {
+			s.Output() <- req
 		}
 	}
 	L.Push(tb)
@@ -352,9 +381,109 @@ func (s *Script) wrapZoneTransfer(L *lua.LState) int {
 	return 2
 }
 
+// errIncompleteXFR is returned when the zone transfer ended before the closing SOA record.
+var errIncompleteXFR = errors.New("the zone transfer ended before the closing SOA record")
+
+// ZoneTransferResult contains the records obtained from a nameserver that permitted a zone transfer.
+type ZoneTransferResult struct {
+	Server   string
+	Type     string
+	Requests []*requests.DNSRequest
+	// Partial is true when the transfer ended before the closing SOA record, such as
+	// when the connection was dropped, so the records of the zone are incomplete
+	Partial bool
+}
+
+// ZoneTransfers attempts DNS zone transfers concurrently using all the provided servers, so the
+// zone is still obtained when some of the servers refuse or drop the transfer. The returned slice
+// contains a result for each server that permitted the zone transfer, including the partial ones.
+func ZoneTransfers(ctx context.Context, sub, domain string, servers []string) []*ZoneTransferResult {
+	var wg sync.WaitGroup
+	ch := make(chan *ZoneTransferResult, len(servers))
+
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server string) {
+			defer wg.Done()
+
+			if res, err := zoneTransfer(ctx, sub, domain, server); err == nil {
+				ch <- res
+			}
+		}(server)
+	}
+	wg.Wait()
+	close(ch)
+
+	var results []*ZoneTransferResult
+	for res := range ch {
+		results = append(results, res)
+	}
+	return results
+}
+
+// MergeZoneTransferResults combines the records from all the zone transfer results by name.
+func MergeZoneTransferResults(results []*ZoneTransferResult) []*requests.DNSRequest {
+	var merged []*requests.DNSRequest
+	byname := make(map[string]*requests.DNSRequest)
+	seen := make(map[string]struct{})
+
+	for _, res := range results {
+		for _, req := range res.Requests {
+			r, found := byname[req.Name]
+			if !found {
+				r = &requests.DNSRequest{
+					Name:   req.Name,
+					Domain: req.Domain,
+				}
+				byname[req.Name] = r
+				merged = append(merged, r)
+			}
+
+			for _, rr := range req.Records {
+				key := fmt.Sprintf("%s:%d:%s", rr.Name, rr.Type, rr.Data)
+				if _, dup := seen[key]; !dup {
+					seen[key] = struct{}{}
+					r.Records = append(r.Records, rr)
+				}
+			}
+		}
+	}
+	return merged
+}
+
 // ZoneTransfer attempts a DNS zone transfer using the provided server.
 // The returned slice contains all the records discovered from the zone transfer.
 func ZoneTransfer(ctx context.Context, sub, domain, server string) ([]*requests.DNSRequest, error) {
+	res, err := zoneTransfer(ctx, sub, domain, server)
+	if err != nil {
+		return nil, err
+	}
+	return res.Requests, nil
+}
+
+func zoneTransfer(ctx context.Context, sub, domain, server string) (*ZoneTransferResult, error) {
+	addr := server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		addr = net.JoinHostPort(server, "53")
+	}
+
+	m := &dns.Msg{}
+	m.SetAxfr(dns.Fqdn(sub))
+	reqs, err := xfrRequests(ctx, addr, m, domain)
+	if err == nil || errors.Is(err, errIncompleteXFR) {
+		return &ZoneTransferResult{Server: server, Type: "AXFR", Requests: reqs, Partial: err != nil}, nil
+	}
+
+	// Fallback to an incremental zone transfer starting from serial zero
+	m = &dns.Msg{}
+	m.SetIxfr(dns.Fqdn(sub), 0, ".", ".")
+	if reqs, ierr := xfrRequests(ctx, addr, m, domain); ierr == nil || errors.Is(ierr, errIncompleteXFR) {
+		return &ZoneTransferResult{Server: server, Type: "IXFR", Requests: reqs, Partial: ierr != nil}, nil
+	}
+	return nil, err
+}
+
+func xfrRequests(ctx context.Context, addr string, m *dns.Msg, domain string) ([]*requests.DNSRequest, error) {
 	timeout := 15 * time.Second
 	var results []*requests.DNSRequest
 
@@ -362,7 +491,7 @@ func ZoneTransfer(ctx context.Context, sub, domain, server string) ([]*requests.
 	tctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	addr := net.JoinHostPort(server, "53")
+	// The transfer streams the zone, so it always uses a dedicated connection instead of the pooled ones
 	conn, err := amassnet.DialContext(tctx, "tcp", addr)
 	if err != nil {
 		return results, fmt.Errorf("zone xfr error: Failed to obtain TCP connection to [%s]: %v", addr, err)
@@ -374,21 +503,33 @@ func ZoneTransfer(ctx context.Context, sub, domain, server string) ([]*requests.
 		ReadTimeout: timeout,
 	}
 
-	m := &dns.Msg{}
-	m.SetAxfr(dns.Fqdn(sub))
-
 	in, err := xfr.In(m, "")
 	if err != nil {
 		return results, fmt.Errorf("DNS zone transfer error for [%s]: %v", addr, err)
 	}
 
+	// The complete transfer starts and ends with the SOA record of the zone
+	var soas int
+	var closed bool
 	for en := range in {
-		reqs := getXfrRequests(en, domain)
-		if reqs == nil {
+		if en.Error != nil {
+			err = fmt.Errorf("DNS zone transfer error for [%s]: %v", addr, en.Error)
 			continue
 		}
 
-		results = append(results, reqs...)
+		for _, rr := range en.RR {
+			closed = rr.Header().Rrtype == dns.TypeSOA
+			if closed {
+				soas++
+			}
+		}
+		results = append(results, getXfrRequests(en, domain)...)
+	}
+	if err != nil && len(results) == 0 {
+		return nil, err
+	}
+	if err != nil || soas < 2 || !closed {
+		return results, fmt.Errorf("DNS zone transfer error for [%s]: %w", addr, errIncompleteXFR)
 	}
 	return results, nil
 }
@@ -5,9 +5,12 @@
 package scripting
 
 import (
+	"context"
+	"net"
 	"testing"
 	"time"
 
+	"github.com/miekg/dns"
 	"github.com/owasp-amass/amass/v4/requests"
 )
 
@@ -62,3 +65,126 @@ loop:
 		}
 	}
 }
+
+func TestZoneTransfers(t *testing.T) {
+	axfr := startMockAuthServer(t, true, "www.owasp.org", "72.237.4.113")
+	ixfr := startMockAuthServer(t, false, "mail.owasp.org", "72.237.4.114")
+	refused := startMockAuthServer(t, false, "", "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	results := ZoneTransfers(ctx, "owasp.org", "owasp.org", []string{axfr, ixfr, refused})
+	if len(results) != 2 {
+		t.Fatalf("Expected results from 2 nameservers, but got %d", len(results))
+	}
+
+	types := make(map[string]string)
+	for _, res := range results {
+		types[res.Server] = res.Type
+	}
+	if types[axfr] != "AXFR" {
+		t.Errorf("Expected %s to permit an AXFR, but got %s", axfr, types[axfr])
+	}
+	if types[ixfr] != "IXFR" {
+		t.Errorf("Expected %s to permit an IXFR, but got %s", ixfr, types[ixfr])
+	}
+	if _, found := types[refused]; found {
+		t.Errorf("Expected %s to refuse the zone transfer", refused)
+	}
+
+	names := make(map[string]bool)
+	for _, req := range MergeZoneTransferResults(results) {
+		names[req.Name] = true
+	}
+	for _, name := range []string{"owasp.org", "www.owasp.org", "mail.owasp.org"} {
+		if !names[name] {
+			t.Errorf("The merged results did not include %s", name)
+		}
+	}
+}
+
+func TestPartialZoneTransfers(t *testing.T) {
+	refused := startMockAuthServer(t, false, "", "")
+	partial := startPartialAuthServer(t)
+	axfr := startMockAuthServer(t, true, "www.owasp.org", "72.237.4.113")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	results := ZoneTransfers(ctx, "owasp.org", "owasp.org", []string{refused, partial, axfr})
+	if len(results) != 2 {
+		t.Fatalf("Expected results from 2 nameservers, but got %d", len(results))
+	}
+
+	for _, res := range results {
+		if expected := res.Server == partial; res.Partial != expected {
+			t.Errorf("Expected the transfer from %s to have partial set to %t", res.Server, expected)
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, req := range MergeZoneTransferResults(results) {
+		names[req.Name] = true
+	}
+	for _, name := range []string{"owasp.org", "www.owasp.org", "dropped.owasp.org"} {
+		if !names[name] {
+			t.Errorf("The merged results did not include %s", name)
+		}
+	}
+}
+
+// startPartialAuthServer returns the address of an authoritative server for owasp.org that
+// drops the connection before sending the closing SOA record of the AXFR.
+func startPartialAuthServer(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on a TCP port: %v", err)
+	}
+
+	soa, _ := dns.NewRR("owasp.org. 3600 IN SOA ns1.owasp.org. admin.owasp.org. 1 3600 600 86400 3600")
+	a, _ := dns.NewRR("dropped.owasp.org. 3600 IN A 72.237.4.115")
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		m.Answer = []dns.RR{soa, a}
+		_ = w.WriteMsg(m)
+		_ = w.Close()
+	})
+
+	srv := &dns.Server{Listener: ln, Handler: handler}
+	go func() { _ = srv.ActivateAndServe() }()
+	t.Cleanup(func() { _ = srv.Shutdown() })
+	return ln.Addr().String()
+}
+
+// startMockAuthServer returns the address of an authoritative server for owasp.org that
+// permits an AXFR or IXFR, or refuses both when the host is not provided.
+func startMockAuthServer(t *testing.T, allowAXFR bool, host, addr string) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on a TCP port: %v", err)
+	}
+
+	soa, _ := dns.NewRR("owasp.org. 3600 IN SOA ns1.owasp.org. admin.owasp.org. 1 3600 600 86400 3600")
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+
+		qtype := req.Question[0].Qtype
+		if host == "" || (qtype == dns.TypeAXFR && !allowAXFR) {
+			m.SetRcode(req, dns.RcodeRefused)
+			_ = w.WriteMsg(m)
+			return
+		}
+
+		a, _ := dns.NewRR(host + ". 3600 IN A " + addr)
+		m.SetReply(req)
+		m.Answer = []dns.RR{soa, a, soa}
+		_ = w.WriteMsg(m)
+	})
+
+	srv := &dns.Server{Listener: ln, Handler: handler}
+	go func() { _ = srv.ActivateAndServe() }()
+	t.Cleanup(func() { _ = srv.Shutdown() })
+	return ln.Addr().String()
+}
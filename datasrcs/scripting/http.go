@@ -12,6 +12,7 @@ import (
 
 	"github.com/owasp-amass/amass/v4/net/dns"
 	"github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/requests"
 	lua "github.com/yuin/gopher-lua"
 )
 
@@ -212,9 +213,27 @@ func (s *Script) req(ctx context.Context, url, data string, hdr http.Header, aut
 			cfg.Log.Printf("%s: %s: %v", s.String(), url, err)
 		}
 	}
+	if resp != nil {
+		switch resp.StatusCode {
+		case 401, 403:
+			s.keyFailed()
+		case 429:
+			s.keyFailed()
+			s.rateLimited(ctx)
+		}
+	}
 	return resp, err
 }
 
+// Lets the enumeration know that this data source is being rate limited.
+func (s *Script) rateLimited(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-s.Done():
+	case s.Output() <- requests.ErrRateLimited:
+	}
+}
+
 // Wrapper so that scripts can crawl for subdomain names in scope.
 func (s *Script) crawl(L *lua.LState) int {
 	cfg := s.sys.Config()
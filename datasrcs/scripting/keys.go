@@ -0,0 +1,83 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+// keyCooldown is the period a key is skipped after returning an auth or rate limit error.
+const keyCooldown = 5 * time.Minute
+
+// keyRotation hands out the credentials of a data source in round-robin order, which spreads the
+// quota across all the configured keys. Keys that recently failed are skipped until the cooldown expires.
+type keyRotation struct {
+	sync.Mutex
+	names  []string
+	creds  map[string]*config.Credentials
+	next   int
+	last   string
+	failed map[string]time.Time
+}
+
+func newKeyRotation(src *config.DataSource) *keyRotation {
+	k := &keyRotation{
+		creds:  make(map[string]*config.Credentials),
+		failed: make(map[string]time.Time),
+	}
+
+	for name, c := range src.Creds {
+		if c != nil {
+			k.names = append(k.names, name)
+			k.creds[name] = c
+		}
+	}
+	sort.Strings(k.names)
+	return k
+}
+
+// nextCredentials returns the next key that is not cooling down, or the key with the
+// earliest cooldown expiration when all the keys recently failed.
+func (k *keyRotation) nextCredentials() *config.Credentials {
+	k.Lock()
+	defer k.Unlock()
+
+	num := len(k.names)
+	if num == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	selected := -1
+	for i := 0; i < num; i++ {
+		idx := (k.next + i) % num
+		until, found := k.failed[k.names[idx]]
+		if !found || now.After(until) {
+			selected = idx
+			break
+		}
+		if selected == -1 || until.Before(k.failed[k.names[selected]]) {
+			selected = idx
+		}
+	}
+
+	k.next = (selected + 1) % num
+	k.last = k.names[selected]
+	return k.creds[k.last]
+}
+
+// lastFailed starts the cooldown of the key most recently handed out.
+func (k *keyRotation) lastFailed() {
+	k.Lock()
+	defer k.Unlock()
+
+	if k.last != "" && len(k.names) > 1 {
+		k.failed[k.last] = time.Now().Add(keyCooldown)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestKeyRotation(t *testing.T) {
+	k := newKeyRotation(&config.DataSource{
+		Name: "Shodan",
+		Creds: map[string]*config.Credentials{
+			"first":  {Apikey: "key1"},
+			"second": {Apikey: "key2"},
+			"third":  {Apikey: "key3"},
+		},
+	})
+
+	next := func() string {
+		if c := k.nextCredentials(); c != nil {
+			return c.Apikey
+		}
+		return ""
+	}
+
+	for i, expected := range []string{"key1", "key2", "key3", "key1"} {
+		if got := next(); got != expected {
+			t.Errorf("Selection %d: expected %s, but got %s", i, expected, got)
+		}
+	}
+	// key1 was handed out last and is now skipped during the cooldown
+	k.lastFailed()
+	for i, expected := range []string{"key2", "key3", "key2"} {
+		if got := next(); got != expected {
+			t.Errorf("Selection %d after the failure: expected %s, but got %s", i, expected, got)
+		}
+	}
+	// the key with the earliest cooldown expiration is used when all keys failed
+	k.lastFailed()
+	_ = next()
+	k.lastFailed()
+	if got := next(); got != "key1" {
+		t.Errorf("expected key1 when all the keys are cooling down, but got %s", got)
+	}
+
+	if c := newKeyRotation(&config.DataSource{Name: "Empty"}).nextCredentials(); c != nil {
+		t.Errorf("credentials were returned for a data source without keys")
+	}
+
+	single := newKeyRotation(&config.DataSource{
+		Name:  "Single",
+		Creds: map[string]*config.Credentials{"only": {Apikey: "key"}},
+	})
+	_ = single.nextCredentials()
+	single.lastFailed()
+	if c := single.nextCredentials(); c == nil || c.Apikey != "key" {
+		t.Errorf("the only key was not returned after a failure")
+	}
+}
@@ -29,6 +29,8 @@ func (s *Script) newNameWithContext(ctx context.Context, name string) {
 		case s.Output() <- &requests.DNSRequest{
 			Name:   name,
 			Domain: domain,
+			Tag:    s.SourceType,
+			Source: s.String(),
 		}:
 		}
 	}
@@ -125,6 +127,8 @@ func (s *Script) internalSendDNSRecords(ctx context.Context, name string, record
 			Name:    name,
 			Domain:  domain,
 			Records: records,
+			Tag:     s.SourceType,
+			Source:  s.String(),
 		}:
 		}
 	}
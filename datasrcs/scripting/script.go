@@ -13,11 +13,13 @@ import (
 
 	"github.com/caffix/service"
 	luaurl "github.com/cjoudrey/gluaurl"
+	amassnet "github.com/owasp-amass/amass/v4/net"
 	"github.com/owasp-amass/amass/v4/net/dns"
 	"github.com/owasp-amass/amass/v4/requests"
 	"github.com/owasp-amass/amass/v4/systems"
 	"github.com/owasp-amass/config/config"
 	lua "github.com/yuin/gopher-lua"
+	"golang.org/x/net/proxy"
 	luajson "layeh.com/gopher-json"
 )
 
@@ -47,8 +49,14 @@ type Script struct {
 	cbsLock    sync.Mutex
 	subre      *regexp.Regexp
 	seconds    int
+	keys       *keyRotation
+	keysLock   sync.Mutex
+	cursors    map[string]string
+	curLock    sync.Mutex
 	ctx        context.Context
 	cancel     context.CancelFunc
+	dialer     proxy.ContextDialer
+	dialLock   sync.Mutex
 }
 
 // NewScript returns the object initialized, but not yet started.
@@ -110,6 +118,8 @@ func (s *Script) newLuaState(cfg *config.Config) *lua.LState {
 	L.SetGlobal("datasrc_config", L.NewFunction(s.dataSourceConfig))
 	L.SetGlobal("brute_wordlist", L.NewFunction(s.bruteWordlist))
 	L.SetGlobal("alt_wordlist", L.NewFunction(s.altWordlist))
+	L.SetGlobal("srv_services", L.NewFunction(s.srvServices))
+	L.SetGlobal("active", L.NewFunction(s.active))
 	L.SetGlobal("log", L.NewFunction(s.log))
 	L.SetGlobal("find", L.NewFunction(s.find))
 	L.SetGlobal("submatch", L.NewFunction(s.submatch))
@@ -126,11 +136,14 @@ func (s *Script) newLuaState(cfg *config.Config) *lua.LState {
 	L.SetGlobal("crawl", L.NewFunction(s.crawl))
 	L.SetGlobal("resolve", L.NewFunction(s.resolve))
 	L.SetGlobal("reverse_sweep", L.NewFunction(s.reverseSweep))
+	L.SetGlobal("reverse_sweep_netblocks", L.NewFunction(s.reverseSweepNetblocks))
 	L.SetGlobal("zone_walk", L.NewFunction(s.zoneWalk))
 	L.SetGlobal("zone_transfer", L.NewFunction(s.wrapZoneTransfer))
 	L.SetGlobal("output_dir", L.NewFunction(s.outputdir))
 	L.SetGlobal("set_rate_limit", L.NewFunction(s.setRateLimit))
 	L.SetGlobal("check_rate_limit", L.NewFunction(s.checkRateLimit))
+	L.SetGlobal("pagination_cursor", L.NewFunction(s.paginationCursor))
+	L.SetGlobal("set_pagination_cursor", L.NewFunction(s.setPaginationCursor))
 	L.SetGlobal("subdomain_regex", lua.LString(dns.AnySubdomainRegexString()))
 	return L
 }
@@ -185,6 +198,15 @@ func (s *Script) Description() string {
 	return s.SourceType
 }
 
+// RequiresCredentials returns true when the script checks the configuration of the data source,
+// which is how the scripts requiring API keys or accounts decline to start without them.
+func (s *Script) RequiresCredentials() bool {
+	s.cbsLock.Lock()
+	defer s.cbsLock.Unlock()
+
+	return s.cbs.Check.Type() != lua.LTNil
+}
+
 // OnStart implements the Service interface.
 func (s *Script) OnStart() error {
 	s.start <- struct{}{}
@@ -321,7 +343,25 @@ func (s *Script) stopScript() {
 	s.luaState = nil
 }
 
+// SetDialer causes the connections of the script, such as the HTTP requests and the zone transfers,
+// to be established through the dialer, and nil restores the default dialer.
+func (s *Script) SetDialer(d proxy.ContextDialer) {
+	s.dialLock.Lock()
+	defer s.dialLock.Unlock()
+
+	s.dialer = d
+}
+
+// requestContext returns the context of the callbacks, carrying the dialer provided to SetDialer.
+func (s *Script) requestContext() context.Context {
+	s.dialLock.Lock()
+	defer s.dialLock.Unlock()
+
+	return amassnet.WithDialer(s.ctx, s.dialer)
+}
+
 func (s *Script) dispatch(in interface{}) {
+	ctx := s.requestContext()
 	s.cbsLock.Lock()
 
 	switch req := in.(type) {
@@ -330,28 +370,28 @@ func (s *Script) dispatch(in interface{}) {
 			callback := s.cbs.Vertical
 			s.cbsLock.Unlock()
 			s.CheckRateLimit()
-			s.dnsRequest(s.ctx, callback, req)
+			s.dnsRequest(ctx, callback, req)
 		}
 	case *requests.ResolvedRequest:
 		if s.cbs.Resolved.Type() != lua.LTNil && req != nil && req.Name != "" && len(req.Records) > 0 {
 			callback := s.cbs.Resolved
 			s.cbsLock.Unlock()
 			s.CheckRateLimit()
-			s.resolvedRequest(s.ctx, callback, req)
+			s.resolvedRequest(ctx, callback, req)
 		}
 	case *requests.SubdomainRequest:
 		if s.cbs.Subdomain.Type() != lua.LTNil && req != nil && req.Name != "" {
 			callback := s.cbs.Subdomain
 			s.cbsLock.Unlock()
 			s.CheckRateLimit()
-			s.subdomainRequest(s.ctx, callback, req)
+			s.subdomainRequest(ctx, callback, req)
 		}
 	case *requests.AddrRequest:
 		if s.cbs.Address.Type() != lua.LTNil && req != nil && req.Address != "" {
 			callback := s.cbs.Address
 			s.cbsLock.Unlock()
 			s.CheckRateLimit()
-			s.addrRequest(s.ctx, callback, req)
+			s.addrRequest(ctx, callback, req)
 		}
 	case *requests.ASNRequest:
 		if s.cbs.Asn.Type() != lua.LTNil && req != nil && (req.Address != "" || req.ASN != 0) {
@@ -360,7 +400,7 @@ func (s *Script) dispatch(in interface{}) {
 			// check that the cache entry has not already been made by a previous request
 			if s.sys.Cache().AddrSearch(req.Address) == nil {
 				s.CheckRateLimit()
-				s.asnRequest(s.ctx, callback, req)
+				s.asnRequest(ctx, callback, req)
 			}
 		}
 	case *requests.WhoisRequest:
@@ -368,7 +408,7 @@ func (s *Script) dispatch(in interface{}) {
 			callback := s.cbs.Horizontal
 			s.cbsLock.Unlock()
 			s.CheckRateLimit()
-			s.whoisRequest(s.ctx, callback, req)
+			s.whoisRequest(ctx, callback, req)
 		}
 	default:
 		s.cbsLock.Unlock()
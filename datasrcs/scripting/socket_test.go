@@ -5,8 +5,10 @@
 package scripting
 
 import (
+	"context"
 	"io"
 	"net"
+	"sync"
 	"testing"
 	"time"
 
@@ -70,6 +72,87 @@ func TestSocketRecv(t *testing.T) {
 	}
 }
 
+// redirectDialer records the targets of the connections, and connects them to the listener instead.
+type redirectDialer struct {
+	sync.Mutex
+	addr    string
+	targets []string
+}
+
+func (d *redirectDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	d.Lock()
+	d.targets = append(d.targets, network+" "+addr)
+	d.Unlock()
+
+	var nd net.Dialer
+	return nd.DialContext(ctx, network, d.addr)
+}
+
+func TestSocketDialer(t *testing.T) {
+	script, sys := setupMockScriptEnv(`
+		name="dialer"
+		type="testing"
+
+		function vertical(ctx, domain)
+			local conn, err = socket.connect(ctx, "192.0.2.1", 8080, "tcp")
+			if (err ~= nil and err ~= "") then
+				log(ctx, err)
+				return
+			end
+
+			local data
+			data, err = conn:recv(15)
+			if (err == nil and data == "Hello unit test") then
+				new_name(ctx, "yes.owasp.org")
+			end
+			conn:close()
+		end
+	`)
+	if script == nil || sys == nil {
+		t.Fatal("failed to initialize the scripting environment")
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("failed to listen on a TCP port")
+	}
+	defer ln.Close()
+
+	go func(ln net.Listener) {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_, _ = io.WriteString(conn, "Hello unit test")
+	}(ln)
+
+	d := &redirectDialer{addr: ln.Addr().String()}
+	script.(*Script).SetDialer(d)
+	sys.Config().AddDomain("owasp.org")
+	script.Input() <- &requests.DNSRequest{Domain: "owasp.org"}
+
+	timer := time.NewTimer(time.Duration(15) * time.Second)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		t.Error("the test timed out")
+	case msg := <-script.Output():
+		if ans, ok := msg.(*requests.DNSRequest); !ok || ans.Name != "yes.owasp.org" {
+			t.Error("the connection was not established through the dialer")
+		}
+	}
+
+	d.Lock()
+	defer d.Unlock()
+	if len(d.targets) != 1 || d.targets[0] != "tcp 192.0.2.1:8080" {
+		t.Errorf("expected the dialer to connect to tcp 192.0.2.1:8080, but got %v", d.targets)
+	}
+}
+
 func TestSocketRecvAll(t *testing.T) {
 	script, sys := setupMockScriptEnv(`
 		name="recv_all"
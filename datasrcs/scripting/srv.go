@@ -0,0 +1,79 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// SRVService is a service and protocol combination queried for SRV records, such as ldap and tcp.
+type SRVService struct {
+	Service string
+	Proto   string
+}
+
+// Name returns the label prefix of the SRV record name, such as _ldap._tcp.
+func (s SRVService) Name() string {
+	return "_" + s.Service + "._" + s.Proto
+}
+
+var (
+	srvLock     sync.Mutex
+	srvServices []SRVService
+)
+
+// SetSRVServices replaces the list of the SRV record names queried by the scripts, and an empty
+// list restores the default names. The protocol of each service must be tcp or udp.
+func SetSRVServices(services []SRVService) error {
+	var clean []SRVService
+	for _, s := range services {
+		svc := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s.Service), "_"))
+		proto := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s.Proto), "_"))
+
+		if proto != "tcp" && proto != "udp" {
+			return fmt.Errorf("the protocol %q of the SRV service %q must be tcp or udp", s.Proto, s.Service)
+		}
+		if _, ok := dns.IsDomainName("_" + svc); !ok || svc == "" || strings.Contains(svc, ".") {
+			return fmt.Errorf("the SRV service %q is not a valid label", s.Service)
+		}
+		clean = append(clean, SRVService{Service: svc, Proto: proto})
+	}
+
+	srvLock.Lock()
+	defer srvLock.Unlock()
+
+	srvServices = clean
+	return nil
+}
+
+// SRVServiceNames returns the label prefixes of the SRV record names provided by the user, which
+// is empty when the scripts use their own names.
+func SRVServiceNames() []string {
+	srvLock.Lock()
+	defer srvLock.Unlock()
+
+	var names []string
+	for _, svc := range srvServices {
+		names = append(names, svc.Name())
+	}
+	return names
+}
+
+// Wrapper so that scripts can obtain the SRV record names provided by the user, which is
+// an empty table when the scripts should use their own names.
+func (s *Script) srvServices(L *lua.LState) int {
+	tb := L.NewTable()
+	for _, name := range SRVServiceNames() {
+		tb.Append(lua.LString(name))
+	}
+
+	L.Push(tb)
+	return 1
+}
@@ -0,0 +1,54 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"testing"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestSetSRVServices(t *testing.T) {
+	defer func() { _ = SetSRVServices(nil) }()
+
+	for _, invalid := range [][]SRVService{
+		{{Service: "ldap", Proto: "tls"}},
+		{{Service: "", Proto: "tcp"}},
+		{{Service: "ldap.admin", Proto: "tcp"}},
+	} {
+		if err := SetSRVServices(invalid); err == nil {
+			t.Errorf("%v was accepted", invalid)
+		}
+	}
+
+	if err := SetSRVServices([]SRVService{
+		{Service: "LDAP", Proto: "tcp"},
+		{Service: "_sip", Proto: "_UDP"},
+	}); err != nil {
+		t.Fatalf("the services were rejected: %v", err)
+	}
+
+	L := lua.NewState()
+	defer L.Close()
+	s := &Script{}
+	L.SetGlobal("srv_services", L.NewFunction(s.srvServices))
+	if err := L.DoString("names = srv_services()"); err != nil {
+		t.Fatalf("failed to call srv_services: %v", err)
+	}
+
+	tb, ok := L.GetGlobal("names").(*lua.LTable)
+	if !ok {
+		t.Fatalf("srv_services did not return a table")
+	}
+	expected := []string{"_ldap._tcp", "_sip._udp"}
+	if tb.Len() != len(expected) {
+		t.Fatalf("expected %d names, but got %d", len(expected), tb.Len())
+	}
+	for i, name := range expected {
+		if got := tb.RawGetInt(i + 1).String(); got != name {
+			t.Errorf("expected %s, but got %s", name, got)
+		}
+	}
+}
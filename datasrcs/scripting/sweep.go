@@ -0,0 +1,164 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// maxConcurrentNetblockSweeps is the number of netblocks swept at the same time.
+const maxConcurrentNetblockSweeps = 4
+
+// SweepStats provides the progress of the reverse DNS sweeps.
+type SweepStats struct {
+	NetblocksQueued    int64
+	NetblocksCompleted int64
+	AddressesQueried   int64
+	NamesFound         int64
+}
+
+var sweepStats SweepStats
+
+// ReverseSweepStats returns the progress of the reverse DNS sweeps across all the scripts.
+func ReverseSweepStats() SweepStats {
+	return SweepStats{
+		NetblocksQueued:    atomic.LoadInt64(&sweepStats.NetblocksQueued),
+		NetblocksCompleted: atomic.LoadInt64(&sweepStats.NetblocksCompleted),
+		AddressesQueried:   atomic.LoadInt64(&sweepStats.AddressesQueried),
+		NamesFound:         atomic.LoadInt64(&sweepStats.NamesFound),
+	}
+}
+
+// Wrapper so that scripts can sweep a table of netblocks, or the netblocks of an ASN in the cache.
+func (s *Script) reverseSweepNetblocks(L *lua.LState) int {
+	ctx, err := extractContext(L.CheckUserData(1))
+	if err != nil {
+		L.Push(lua.LString("failed to obtain the context"))
+		return 1
+	}
+
+	var cidrs []*net.IPNet
+	add := func(str string) {
+		if _, cidr, err := net.ParseCIDR(str); err == nil {
+			cidrs = append(cidrs, cidr)
+		}
+	}
+
+	switch v := L.Get(2).(type) {
+	case lua.LNumber:
+		if entry := s.sys.Cache().ASNSearch(int(v)); entry != nil {
+			for _, nb := range entry.Netblocks {
+				add(nb)
+			}
+		}
+	case lua.LString:
+		add(string(v))
+	case *lua.LTable:
+		v.ForEach(func(_, value lua.LValue) {
+			if str, ok := value.(lua.LString); ok {
+				add(string(str))
+			}
+		})
+	}
+	if len(cidrs) == 0 {
+		L.Push(lua.LString("failed to obtain the netblocks"))
+		return 1
+	}
+
+	size := defaultSweepSize
+	if s.sys.Config().Active {
+		size = activeSweepSize
+	}
+
+	go s.sweepNetblocks(ctx, cidrs, size)
+	L.Push(lua.LNil)
+	return 1
+}
+
+// sweepNetblocks performs the reverse DNS sweeps of the netblocks with bounded concurrency. Each sweep
+// queries up to size addresses from the start of the netblock, and the queries share the global sweep limit.
+func (s *Script) sweepNetblocks(ctx context.Context, cidrs []*net.IPNet, size int) {
+	atomic.AddInt64(&sweepStats.NetblocksQueued, int64(len(cidrs)))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentNetblockSweeps)
+loop:
+	for _, cidr := range cidrs {
+		select {
+		case <-ctx.Done():
+			break loop
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(c *net.IPNet) {
+			defer func() { <-sem }()
+			defer wg.Done()
+
+			s.sweepNetblock(ctx, c, size)
+			atomic.AddInt64(&sweepStats.NetblocksCompleted, 1)
+		}(cidr)
+	}
+	wg.Wait()
+}
+
+func (s *Script) sweepNetblock(ctx context.Context, cidr *net.IPNet, size int) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for _, ip := range netblockHosts(cidr, size) {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		sweepLock.Lock()
+		a := ip.String()
+		queued := !sweepFilter.TestAndAdd([]byte(a))
+		sweepLock.Unlock()
+		if !queued {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-sweepMaxCh:
+		}
+
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			s.getPTR(ctx, addr, sweepMaxCh)
+		}(a)
+	}
+}
+
+// netblockHosts returns up to num addresses from the start of the netblock.
+func netblockHosts(cidr *net.IPNet, num int) []net.IP {
+	var hosts []net.IP
+
+	ip := make(net.IP, len(cidr.IP))
+	copy(ip, cidr.IP)
+	for len(hosts) < num && cidr.Contains(ip) {
+		host := make(net.IP, len(ip))
+		copy(host, ip)
+		hosts = append(hosts, host)
+
+		amassnet.IPInc(ip)
+		if ip.Equal(cidr.IP) {
+			// the address wrapped around
+			break
+		}
+	}
+	return hosts
+}
@@ -0,0 +1,40 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package scripting
+
+import (
+	"net"
+	"testing"
+)
+
+func TestNetblockHosts(t *testing.T) {
+	tests := []struct {
+		cidr     string
+		num      int
+		expected []string
+	}{
+		{"192.0.2.0/30", 10, []string{"192.0.2.0", "192.0.2.1", "192.0.2.2", "192.0.2.3"}},
+		{"192.0.2.0/24", 3, []string{"192.0.2.0", "192.0.2.1", "192.0.2.2"}},
+		{"192.0.2.7/32", 5, []string{"192.0.2.7"}},
+		{"255.255.255.254/31", 5, []string{"255.255.255.254", "255.255.255.255"}},
+		{"2001:db8::/64", 2, []string{"2001:db8::", "2001:db8::1"}},
+		{"192.0.2.0/24", 0, nil},
+	}
+
+	for _, test := range tests {
+		_, cidr, _ := net.ParseCIDR(test.cidr)
+
+		hosts := netblockHosts(cidr, test.num)
+		if len(hosts) != len(test.expected) {
+			t.Errorf("%s: expected %d hosts, but got %d", test.cidr, len(test.expected), len(hosts))
+			continue
+		}
+		for i, host := range hosts {
+			if host.String() != test.expected[i] {
+				t.Errorf("%s: expected host %d to be %s, but got %s", test.cidr, i, test.expected[i], host)
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/resolve"
+)
+
+// errNoAffinity is returned when no affine resolvers are assigned to the name of the query.
+var errNoAffinity = errors.New("no affine resolvers are assigned to the name")
+
+// parseResolverAffinity validates the domain suffixes and the resolver addresses of the
+// DomainResolverAffinity, and returns the resolvers for each suffix with the port included.
+func (e *Enumeration) parseResolverAffinity() (map[string][]string, error) {
+	addrs := make(map[string][]string)
+
+	for suffix, resolvers := range e.DomainResolverAffinity {
+		s := strings.Trim(strings.ToLower(strings.TrimSpace(suffix)), ".")
+		if _, ok := dns.IsDomainName(s); !ok || s == "" {
+			return nil, fmt.Errorf("the resolver affinity suffix %s is not a valid domain name", suffix)
+		}
+		if len(resolvers) == 0 {
+			return nil, fmt.Errorf("no resolvers were provided for the %s resolver affinity", suffix)
+		}
+
+		for _, r := range resolvers {
+			addr, err := affinityAddr(r)
+			if err != nil {
+				return nil, fmt.Errorf("the resolver affinity for %s: %v", suffix, err)
+			}
+			addrs[s] = append(addrs[s], addr)
+		}
+	}
+	return addrs, nil
+}
+
+// affinityAddr requires the resolver to be an IP address, optionally with a port, and returns the host:port.
+func affinityAddr(resolver string) (string, error) {
+	resolver = strings.TrimSpace(resolver)
+
+	host, port, err := net.SplitHostPort(resolver)
+	if err != nil {
+		host, port = strings.Trim(resolver, "[]"), "53"
+	}
+	if net.ParseIP(host) == nil {
+		return "", fmt.Errorf("the resolver %s is not an IP address", resolver)
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return "", fmt.Errorf("the resolver %s does not have a valid port", resolver)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// buildAffinityPools creates a resolver pool for each of the domain suffixes in DomainResolverAffinity.
+func (e *Enumeration) buildAffinityPools() error {
+	addrs, err := e.parseResolverAffinity()
+	if err != nil {
+		return err
+	}
+
+	e.affAddr = addrs
+	e.affPool = make(map[string]*resolve.Resolvers, len(addrs))
+	for suffix, resolvers := range addrs {
+		pool := systems.NewUntrustedPool(e.Config, resolvers)
+		if pool.Len() == 0 {
+			e.stopAffinityPools()
+			return fmt.Errorf("none of the resolvers for the %s resolver affinity are usable", suffix)
+		}
+		e.affPool[suffix] = pool
+	}
+	return nil
+}
+
+func (e *Enumeration) stopAffinityPools() {
+	for _, pool := range e.affPool {
+		pool.Stop()
+	}
+}
+
+// affinitySuffix returns the longest suffix of DomainResolverAffinity matching the name, or the
+// empty string when the name has no affine resolvers.
+func (e *Enumeration) affinitySuffix(name string) string {
+	name = strings.ToLower(resolve.RemoveLastDot(name))
+
+	var best string
+	for suffix := range e.affAddr {
+		if (name == suffix || strings.HasSuffix(name, "."+suffix)) && len(suffix) > len(best) {
+			best = suffix
+		}
+	}
+	return best
+}
+
+// affinityExchange sends the untrusted query to the affine resolvers of the name. An error is returned
+// when the name has no affine resolvers or they provided no response, and the query falls back to the
+// general pool. The ForceResolver takes precedence over the affinity.
+func (e *Enumeration) affinityExchange(ctx context.Context, msg *dns.Msg) (*dns.Msg, error) {
+	if len(e.affAddr) == 0 || e.forced() {
+		return nil, errNoAffinity
+	}
+	suffix := e.affinitySuffix(msg.Question[0].Name)
+	if suffix == "" {
+		return nil, errNoAffinity
+	}
+
+	addrs := e.affAddr[suffix]
+	addr := addrs[rand.Intn(len(addrs))]
+	// the queries sent without the pool of the affine resolvers are paced to their QPS
+	if (e.tcpOnly() || e.SourcePortRange.set() || e.bound() || e.Dialer != nil) && !e.paceDirect(ctx, addr, false) {
+		return nil, ctx.Err()
+	}
+
+	var resp *dns.Msg
+	var err error
+	switch {
+	case e.tcpOnly():
+		resp, err = e.addrExchange(ctx, "tcp", addr, msg)
+	case e.SourcePortRange.set() || e.bound() || e.Dialer != nil:
+		resp, err = e.addrExchange(ctx, "udp", addr, msg)
+	default:
+		resp, err = e.poolExchange(ctx, msg, e.affPool[suffix], false)
+	}
+	if err == nil && resp != nil && resp.Truncated && !e.tcpOnly() {
+		if tresp, terr := e.addrExchange(ctx, "tcp", addr, msg); terr == nil {
+			resp = tresp
+		}
+	}
+
+	if err == nil && (resp == nil || resp.Rcode == resolve.RcodeNoResponse) {
+		err = errors.New("the affine resolvers did not respond")
+	}
+	if err != nil {
+		e.log().Debugf("Resolver affinity: %s falls back to the general pool: %v", msg.Question[0].Name, err)
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,117 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestParseResolverAffinity(t *testing.T) {
+	e := &Enumeration{DomainResolverAffinity: map[string][]string{
+		" OWASP.org. ": {"192.0.2.1", "192.0.2.2:5353"},
+		"example.com":  {"2001:db8::1", "[2001:db8::2]:53"},
+	}}
+
+	addrs, err := e.parseResolverAffinity()
+	if err != nil {
+		t.Fatalf("the valid resolver affinity was rejected: %v", err)
+	}
+	if a := addrs["owasp.org"]; len(a) != 2 || a[0] != "192.0.2.1:53" || a[1] != "192.0.2.2:5353" {
+		t.Errorf("unexpected resolvers for owasp.org: %v", a)
+	}
+	if a := addrs["example.com"]; len(a) != 2 || a[0] != "[2001:db8::1]:53" || a[1] != "[2001:db8::2]:53" {
+		t.Errorf("unexpected resolvers for example.com: %v", a)
+	}
+
+	for _, invalid := range []map[string][]string{
+		{"": {"192.0.2.1"}},
+		{"owasp..org": {"192.0.2.1"}},
+		{"owasp.org": nil},
+		{"owasp.org": {"resolver.owasp.org"}},
+		{"owasp.org": {"192.0.2.1:0"}},
+	} {
+		e.DomainResolverAffinity = invalid
+		if _, err := e.parseResolverAffinity(); err == nil {
+			t.Errorf("the invalid setting %v was accepted", invalid)
+		}
+	}
+}
+
+func TestDomainResolverAffinity(t *testing.T) {
+	start := func(hits chan string, label string) (string, func()) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen on a TCP port: %v", err)
+		}
+		stop := serveMockDNS(t, l, nil, func(w dns.ResponseWriter, req *dns.Msg) {
+			hits <- label
+
+			m := new(dns.Msg)
+			m.SetReply(req)
+			_ = w.WriteMsg(m)
+		})
+		return l.Addr().String(), stop
+	}
+
+	hits := make(chan string, 1)
+	cfg := config.NewConfig()
+	def, _ := start(hits, "default")
+	cfg.Resolvers = []string{def}
+	cfg.TrustedResolvers = []string{def}
+	near, _ := start(hits, "near")
+	nearer, stop := start(hits, "nearer")
+
+	pool := resolve.NewResolvers()
+	defer pool.Stop()
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: pool, Trusted: trusted},
+		ForceTCP: true,
+		DomainResolverAffinity: map[string][]string{
+			"owasp.org":     {near},
+			"dev.owasp.org": {nearer},
+		},
+	}
+	addrs, err := e.parseResolverAffinity()
+	if err != nil {
+		t.Fatalf("failed to parse the resolver affinity: %v", err)
+	}
+	e.affAddr = addrs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := func(name string, r *resolve.Resolvers, expected string) {
+		if _, err := e.queryBlocking(ctx, resolve.QueryMsg(name, dns.TypeA), r); err != nil {
+			t.Fatalf("the query for %s failed: %v", name, err)
+		}
+		if got := <-hits; got != expected {
+			t.Errorf("the query for %s was sent to the %s resolver, expected %s", name, got, expected)
+		}
+	}
+
+	query("www.owasp.org", pool, "near")
+	query("owasp.org", pool, "near")
+	query("api.dev.owasp.org", pool, "nearer")
+	query("www.example.com", pool, "default")
+	query("notowasp.org", pool, "default")
+	// the trusted resolvers are not affected by the affinity
+	query("www.owasp.org", trusted, "default")
+
+	// the queries fall back to the general pool when the affine resolvers do not respond
+	stop()
+	query("api.dev.owasp.org", pool, "default")
+}
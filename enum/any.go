@@ -0,0 +1,65 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+// anyAnswers returns the answers of the forward query types in the ANY response. Nothing is returned
+// when the response is refused, empty or minimal, such as the single HINFO record described in
+// RFC 8482, and the individual queries for the forward types are then required.
+func (e *Enumeration) anyAnswers(resp *dns.Msg) []*resolve.ExtractedAnswer {
+	if resp == nil || resp.Rcode != dns.RcodeSuccess || minimalANYResponse(resp) {
+		return nil
+	}
+
+	types := e.fwdTypes
+	if len(types) == 0 {
+		types = FwdQueryTypes
+	}
+
+	var rr []*resolve.ExtractedAnswer
+	ans := resolve.ExtractAnswers(resp)
+	for _, qtype := range types {
+		if r := resolve.AnswersByType(ans, qtype); len(r) > 0 {
+			// Do not enter more than the CNAME record
+			if qtype == dns.TypeCNAME {
+				return r
+			}
+			rr = append(rr, r...)
+		}
+	}
+	return rr
+}
+
+// minimalANYResponse returns true when the server has declined the ANY query as described in RFC 8482.
+func minimalANYResponse(resp *dns.Msg) bool {
+	for _, rr := range resp.Answer {
+		if h, ok := rr.(*dns.HINFO); ok && strings.EqualFold(h.Cpu, "RFC8482") {
+			return true
+		}
+	}
+	return false
+}
+
+// anyQuery sends the ANY query once to each pool, since the servers refusing the query
+// would not answer the retries.
+func (e *Enumeration) anyQuery(ctx context.Context, name string) (*dns.Msg, error) {
+	if resp, err := e.dnsQuery(ctx, name, dns.TypeANY, e.Sys.Resolvers(), 1); err != nil || resp == nil {
+		return nil, errors.New("the ANY query failed")
+	}
+
+	resp, err := e.dnsQuery(ctx, name, dns.TypeANY, e.Sys.TrustedResolvers(), 1)
+	if err != nil || resp == nil {
+		return nil, errors.New("the ANY query failed")
+	}
+	return resp, nil
+}
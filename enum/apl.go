@@ -0,0 +1,113 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+// aplSweepSize is the number of addresses swept from the start of each prefix disclosed by the APL records.
+const aplSweepSize = 256
+
+// queryAPL obtains the APL records of the name, which are not extracted by the resolve package.
+// In active mode, the prefixes of the records are swept for PTR records pointing at names in scope.
+func (dt *dnsTask) queryAPL(ctx context.Context, name string, ch chan []requests.DNSAnswer) {
+	if resp, err := dt.enum.dnsQuery(ctx, name, dns.TypeAPL, dt.enum.Sys.TrustedResolvers(), maxDNSQueryAttempts); err == nil {
+		if records := aplAnswers(resp); len(records) > 0 {
+			if dt.enum.Config.Active {
+				dt.enum.sweepAPLPrefixes(ctx, records)
+			}
+			ch <- truncateAnswers(records, dt.enum.MaxAnswerBytes)
+			return
+		}
+	}
+	ch <- nil
+}
+
+// aplAnswers converts the APL records in the answer section, and decodes the address prefixes.
+func aplAnswers(resp *dns.Msg) []requests.DNSAnswer {
+	if resp == nil {
+		return nil
+	}
+
+	var answers []requests.DNSAnswer
+	for _, rr := range resp.Answer {
+		apl, ok := rr.(*dns.APL)
+		if !ok {
+			continue
+		}
+
+		var prefixes []requests.APLPrefix
+		for _, p := range apl.Prefixes {
+			family := 1
+			if p.Network.IP.To4() == nil {
+				family = 2
+			}
+
+			prefixes = append(prefixes, requests.APLPrefix{
+				Family:   family,
+				Prefix:   p.Network.String(),
+				Negation: p.Negation,
+			})
+		}
+
+		answers = append(answers, requests.DNSAnswer{
+			Name:     resolve.RemoveLastDot(apl.Hdr.Name),
+			Type:     int(dns.TypeAPL),
+			TTL:      int(apl.Hdr.Ttl),
+			Data:     strings.TrimSpace(strings.TrimPrefix(apl.String(), apl.Hdr.String())),
+			Prefixes: prefixes,
+		})
+	}
+	return answers
+}
+
+// sweepAPLPrefixes sweeps the reverse DNS of the prefixes listed by the APL records, skipping the
+// negated prefixes and those swept previously, and submits the names in scope to the enumeration.
+func (e *Enumeration) sweepAPLPrefixes(ctx context.Context, records []requests.DNSAnswer) {
+	for _, rec := range records {
+		for _, p := range rec.Prefixes {
+			if p.Negation {
+				continue
+			}
+
+			_, cidr, err := net.ParseCIDR(p.Prefix)
+			if err != nil {
+				continue
+			}
+			if _, loaded := e.aplSwept.LoadOrStore(cidr.String(), struct{}{}); loaded {
+				continue
+			}
+
+			e.goTracked(ctx, func() {
+				e.submitSweptNames(e.sweepReverse(ctx, cidr, aplSweepSize))
+			})
+		}
+	}
+}
+
+// submitSweptNames sends the PTR targets of the swept addresses into the enumeration.
+func (e *Enumeration) submitSweptNames(reqs []*requests.DNSRequest) {
+	for _, req := range reqs {
+		for _, rec := range req.Records {
+			name := strings.ToLower(resolve.RemoveLastDot(rec.Data))
+
+			if domain := e.Config.WhichDomain(name); domain != "" {
+				e.nameSrc.newNameWithoutWait(&requests.DNSRequest{
+					Name:   name,
+					Domain: domain,
+					Tag:    requests.DNS,
+					Source: "Reverse DNS",
+				})
+			}
+		}
+	}
+}
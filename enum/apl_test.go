@@ -0,0 +1,55 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func TestAPLAnswers(t *testing.T) {
+	rr, err := dns.NewRR("owasp.org. 300 IN APL 1:192.0.2.0/24 !1:192.0.2.128/25 2:2001:db8::/32")
+	if err != nil {
+		t.Fatalf("failed to parse the APL record: %v", err)
+	}
+
+	resp := new(dns.Msg)
+	resp.Answer = []dns.RR{rr, &dns.A{
+		Hdr: dns.RR_Header{Name: "owasp.org.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+	}}
+
+	answers := aplAnswers(resp)
+	if len(answers) != 1 {
+		t.Fatalf("expected 1 APL answer, but got %d", len(answers))
+	}
+
+	a := answers[0]
+	if a.Name != "owasp.org" || a.Type != int(dns.TypeAPL) || a.TTL != 300 {
+		t.Errorf("unexpected APL answer: %+v", a)
+	}
+	if expected := "1:192.0.2.0/24 !1:192.0.2.128/25 2:2001:db8::/32"; a.Data != expected {
+		t.Errorf("expected the data %s, but got %s", expected, a.Data)
+	}
+
+	expected := []requests.APLPrefix{
+		{Family: 1, Prefix: "192.0.2.0/24"},
+		{Family: 1, Prefix: "192.0.2.128/25", Negation: true},
+		{Family: 2, Prefix: "2001:db8::/32"},
+	}
+	if len(a.Prefixes) != len(expected) {
+		t.Fatalf("expected %d prefixes, but got %d", len(expected), len(a.Prefixes))
+	}
+	for i, p := range expected {
+		if a.Prefixes[i] != p {
+			t.Errorf("expected the prefix %+v, but got %+v", p, a.Prefixes[i])
+		}
+	}
+
+	if aplAnswers(nil) != nil {
+		t.Errorf("answers were returned without a response")
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+// authoritativeTypes are the record types compared against the authoritative nameservers.
+var authoritativeTypes = []uint16{dns.TypeCNAME, dns.TypeA, dns.TypeAAAA}
+
+type authZone struct {
+	once  sync.Once
+	addrs []string
+}
+
+// authValidator compares the answers from the resolvers with those from the authoritative
+// nameservers of the zone. The nameserver addresses are cached for each zone cut.
+type authValidator struct {
+	sync.Mutex
+	enum  *Enumeration
+	port  string
+	zones map[string]*authZone
+}
+
+func newAuthValidator(e *Enumeration) *authValidator {
+	return &authValidator{
+		enum:  e,
+		port:  "53",
+		zones: make(map[string]*authZone),
+	}
+}
+
+// validate returns false when an authoritative nameserver of the zone provides different answers for
+// the name. The name is accepted when the zone has no reachable authoritative nameserver.
+func (av *authValidator) validate(ctx context.Context, req *requests.DNSRequest) bool {
+	qname := av.enum.queryName(req.Name)
+
+	addrs := av.servers(ctx, qname, req.Domain)
+	if len(addrs) == 0 {
+		av.enum.log().Debugf("No authoritative nameserver was found to validate %s", req.Name)
+		return true
+	}
+
+	for _, qtype := range authoritativeTypes {
+		var recursive []string
+		for _, rec := range req.Records {
+			if uint16(rec.Type) == qtype && strings.EqualFold(resolve.RemoveLastDot(rec.Name), req.Name) {
+				recursive = append(recursive, strings.ToLower(resolve.RemoveLastDot(rec.Data)))
+			}
+		}
+		if len(recursive) == 0 {
+			continue
+		}
+
+		auth, ok := av.query(ctx, qname, qtype, addrs)
+		if !ok {
+			av.enum.log().Debugf("The authoritative nameservers did not answer for %s, so it was not validated", req.Name)
+			return true
+		}
+		sort.Strings(recursive)
+		sort.Strings(auth)
+		if !sameAnswers(recursive, auth) {
+			av.enum.log().Warnf("Authoritative mismatch: the %s records for %s were %v from the resolvers and %v from the authoritative nameservers",
+				dns.TypeToString[qtype], req.Name, recursive, auth)
+			return false
+		}
+	}
+	return true
+}
+
+// query sends the query to the authoritative nameservers until one answers authoritatively.
+func (av *authValidator) query(ctx context.Context, name string, qtype uint16, addrs []string) ([]string, bool) {
+	network := "udp"
+	// UDP is not sent through the SOCKS5 proxy
+	if av.enum.tcpOnly() {
+		network = "tcp"
+	}
+
+	for _, addr := range addrs {
+		msg := resolve.QueryMsg(name, qtype)
+		av.enum.setEDNSBufferSize(msg)
+		msg.RecursionDesired = false
+
+		resp, err := av.enum.exchange(ctx, network, net.JoinHostPort(addr, av.port), msg)
+		if err == nil && resp != nil && resp.Truncated && network == "udp" {
+			resp, err = av.enum.exchange(ctx, "tcp", net.JoinHostPort(addr, av.port), msg)
+		}
+		if err != nil || resp == nil || !resp.Authoritative {
+			continue
+		}
+
+		switch resp.Rcode {
+		case dns.RcodeNameError:
+			return nil, true
+		case dns.RcodeSuccess:
+			var answers []string
+			for _, rr := range resolve.AnswersByType(resolve.ExtractAnswers(resp), qtype) {
+				if strings.EqualFold(resolve.RemoveLastDot(rr.Name), name) {
+					answers = append(answers, strings.ToLower(resolve.RemoveLastDot(rr.Data)))
+				}
+			}
+			return answers, true
+		}
+	}
+	return nil, false
+}
+
+// servers returns the addresses of the nameservers for the closest zone cut containing the name,
+// checking each parent of the name up to the root domain.
+func (av *authValidator) servers(ctx context.Context, name, domain string) []string {
+	name = strings.ToLower(resolve.RemoveLastDot(name))
+	domain = strings.ToLower(domain)
+
+	for zone := name; zone != ""; {
+		if addrs := av.zoneServers(ctx, zone); len(addrs) > 0 {
+			return addrs
+		}
+		if zone == domain || !strings.HasSuffix(zone, "."+domain) {
+			break
+		}
+		zone = zone[strings.Index(zone, ".")+1:]
+	}
+	return nil
+}
+
+func (av *authValidator) zoneServers(ctx context.Context, zone string) []string {
+	av.Lock()
+	z, found := av.zones[zone]
+	if !found {
+		z = new(authZone)
+		av.zones[zone] = z
+	}
+	av.Unlock()
+
+	z.once.Do(func() {
+		z.addrs = av.lookupServers(ctx, zone)
+	})
+	return z.addrs
+}
+
+func (av *authValidator) lookupServers(ctx context.Context, zone string) []string {
+	trusted := av.enum.Sys.TrustedResolvers()
+
+	resp, err := av.enum.queryBlocking(ctx, resolve.QueryMsg(zone, dns.TypeNS), trusted)
+	if err != nil || resp == nil || resp.Rcode != dns.RcodeSuccess {
+		return nil
+	}
+
+	var addrs []string
+	for _, ns := range resolve.AnswersByType(resolve.ExtractAnswers(resp), dns.TypeNS) {
+		if !strings.EqualFold(resolve.RemoveLastDot(ns.Name), zone) {
+			continue
+		}
+
+		for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+			resp, err := av.enum.queryBlocking(ctx, resolve.QueryMsg(ns.Data, qtype), trusted)
+			if err != nil || resp == nil {
+				continue
+			}
+			for _, rr := range resolve.AnswersByType(resolve.ExtractAnswers(resp), qtype) {
+				addrs = append(addrs, rr.Data)
+			}
+		}
+	}
+	return addrs
+}
+
+// validateAndSend moves the name to the store stage after the validation, or out of flight when it failed.
+func (dt *dnsTask) validateAndSend(ctx context.Context, req *requests.DNSRequest) {
+	if dt.enum.authval.validate(ctx, req) {
+		dt.nextStage(ctx, req)
+		return
+	}
+	dt.enum.nameSrc.leaveFlight(req.Name)
+}
@@ -0,0 +1,98 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestValidateAgainstAuthoritative(t *testing.T) {
+	// the server is both the resolver and the authoritative nameserver of owasp.org
+	addr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		q := req.Question[0]
+		hdr := dns.RR_Header{Name: q.Name, Rrtype: q.Qtype, Class: dns.ClassINET, Ttl: 300}
+		switch name := strings.ToLower(q.Name); {
+		case name == "owasp.org." && q.Qtype == dns.TypeNS:
+			m.Answer = append(m.Answer, &dns.NS{Hdr: hdr, Ns: "ns1.owasp.org."})
+		case name == "ns1.owasp.org." && q.Qtype == dns.TypeA:
+			m.Answer = append(m.Answer, &dns.A{Hdr: hdr, A: net.ParseIP("127.0.0.1")})
+		case !req.RecursionDesired:
+			m.Authoritative = true
+			switch {
+			case name == "good.owasp.org." && q.Qtype == dns.TypeA:
+				m.Answer = append(m.Answer, &dns.A{Hdr: hdr, A: net.ParseIP("192.0.2.1")})
+			case name == "bad.owasp.org." && q.Qtype == dns.TypeA:
+				m.Answer = append(m.Answer, &dns.A{Hdr: hdr, A: net.ParseIP("192.0.2.99")})
+			case name == "gone.owasp.org.":
+				m.Rcode = dns.RcodeNameError
+			}
+		}
+		_ = w.WriteMsg(m)
+	})
+
+	_, port, _ := net.SplitHostPort(addr)
+	cfg := config.NewConfig()
+	cfg.AddDomains("owasp.org", "example.com")
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP: true,
+	}
+	defer e.Sys.Resolvers().Stop()
+	av := newAuthValidator(e)
+	av.port = port
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, test := range []struct {
+		name     string
+		domain   string
+		expected bool
+	}{
+		{"good.owasp.org", "owasp.org", true},
+		{"bad.owasp.org", "owasp.org", false},
+		{"gone.owasp.org", "owasp.org", false},
+		// the zone without an authoritative nameserver is accepted
+		{"www.example.com", "example.com", true},
+	} {
+		req := &requests.DNSRequest{
+			Name:   test.name,
+			Domain: test.domain,
+			Records: []requests.DNSAnswer{{
+				Name: test.name,
+				Type: int(dns.TypeA),
+				Data: "192.0.2.1",
+			}},
+		}
+		if got := av.validate(ctx, req); got != test.expected {
+			t.Errorf("%s: expected the validation to return %t, but got %t", test.name, test.expected, got)
+		}
+	}
+
+	av.Lock()
+	z := av.zones["owasp.org"]
+	av.Unlock()
+	if z == nil || len(z.addrs) != 1 {
+		t.Errorf("the authoritative nameserver of the zone was not cached")
+	}
+}
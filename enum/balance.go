@@ -0,0 +1,112 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+const (
+	// balanceRetryDelay is how often a held submission checks whether its share is available.
+	balanceRetryDelay = 100 * time.Millisecond
+	// balanceIdleWindow is how long one side goes without submissions before the other side
+	// is no longer held to its share.
+	balanceIdleWindow = 5 * time.Second
+)
+
+// discoveryBalance gates the requests submitted to the brute forcing and the passive data sources,
+// so each side receives its share of the submissions. A side is held while it is ahead of its share,
+// unless the other side has not submitted during the idle window, since there is nothing to balance.
+type discoveryBalance struct {
+	sync.Mutex
+	share  float64
+	counts [2]int
+	last   [2]time.Time
+}
+
+func newDiscoveryBalance(share float64) *discoveryBalance {
+	now := time.Now()
+	return &discoveryBalance{
+		share: share,
+		last:  [2]time.Time{now, now},
+	}
+}
+
+func balanceSide(brute bool) int {
+	if brute {
+		return 1
+	}
+	return 0
+}
+
+// excluded returns true when the side does not receive any share of the submissions.
+func (b *discoveryBalance) excluded(brute bool) bool {
+	if brute {
+		return b.share <= 0
+	}
+	return b.share >= 1
+}
+
+// allow returns true, and counts the submission, when the side is within its share.
+func (b *discoveryBalance) allow(brute bool) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	side := balanceSide(brute)
+	share := b.share
+	if !brute {
+		share = 1 - share
+	}
+
+	now := time.Now()
+	// one submission of slack lets the sides alternate when the shares are small
+	total := float64(b.counts[0] + b.counts[1] + 1)
+	if float64(b.counts[side]) < share*total+1 || now.Sub(b.last[1-side]) > balanceIdleWindow {
+		b.counts[side]++
+		b.last[side] = now
+		return true
+	}
+	return false
+}
+
+// wait blocks until the side is within its share, and returns false when the enumeration ends first.
+func (b *discoveryBalance) wait(ctx context.Context, done <-chan struct{}, brute bool) bool {
+	t := time.NewTicker(balanceRetryDelay)
+	defer t.Stop()
+
+	for !b.allow(brute) {
+		select {
+		case <-done:
+			return false
+		case <-ctx.Done():
+			return false
+		case <-t.C:
+		}
+	}
+	return true
+}
+
+// bruteForceSource returns true when the data source performs the brute forcing.
+func bruteForceSource(srv service.Service) bool {
+	return srv.Description() == requests.BRUTE
+}
+
+// balanceExcludes returns true when the DiscoveryBalance leaves no share to the data source.
+func (e *Enumeration) balanceExcludes(srv service.Service) bool {
+	return e.balance != nil && e.balance.excluded(bruteForceSource(srv))
+}
+
+// waitForBalance holds the request for the data source until its side is within the DiscoveryBalance.
+func (e *Enumeration) waitForBalance(srv service.Service) bool {
+	if e.balance == nil {
+		return true
+	}
+	return e.balance.wait(e.ctx, e.done, bruteForceSource(srv))
+}
@@ -0,0 +1,71 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestDiscoveryBalanceShares(t *testing.T) {
+	for _, share := range []float64{0.25, 0.5, 0.8} {
+		b := newDiscoveryBalance(share)
+
+		// both sides keep submitting, so each is held to its share
+		for i := 0; i < 1000; i++ {
+			_ = b.allow(true)
+			_ = b.allow(false)
+		}
+
+		brute := float64(b.counts[1]) / float64(b.counts[0]+b.counts[1])
+		if brute < share-0.02 || brute > share+0.02 {
+			t.Errorf("expected the brute forcing to receive a share of %g, but got %g", share, brute)
+		}
+	}
+}
+
+func TestDiscoveryBalanceIdleSide(t *testing.T) {
+	b := newDiscoveryBalance(0.1)
+	// the passive side has not submitted during the idle window
+	b.last[0] = time.Now().Add(-2 * balanceIdleWindow)
+
+	for i := 0; i < 20; i++ {
+		if !b.allow(true) {
+			t.Fatalf("the brute forcing was held while the passive data sources were idle")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*balanceRetryDelay)
+	defer cancel()
+	b.last[0] = time.Now()
+	if b.wait(ctx, make(chan struct{}), true) {
+		t.Errorf("the brute forcing was not held beyond its share")
+	}
+}
+
+func TestDiscoveryBalanceExcludes(t *testing.T) {
+	if b := newDiscoveryBalance(0); !b.excluded(true) || b.excluded(false) {
+		t.Errorf("a balance of zero must only exclude the brute forcing")
+	}
+	if b := newDiscoveryBalance(1); b.excluded(true) || !b.excluded(false) {
+		t.Errorf("a balance of one must only exclude the passive data sources")
+	}
+}
+
+func TestDiscoveryBalanceRange(t *testing.T) {
+	for _, share := range []float64{-0.1, 1.5} {
+		cfg := config.NewConfig()
+		cfg.AddDomain("owasp.org")
+		e := &Enumeration{Config: cfg, PipelineBufferSize: 1, DiscoveryBalance: &share}
+
+		if err := e.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "discovery balance") {
+			t.Errorf("the enumeration started with a discovery balance of %g: %v", share, err)
+		}
+	}
+}
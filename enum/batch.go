@@ -0,0 +1,106 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultGraphFlushInterval is the period between the flushes when GraphFlushInterval is not set.
+const defaultGraphFlushInterval = 500 * time.Millisecond
+
+type graphOp struct {
+	key string
+	run func() error
+}
+
+// graphBatch accumulates the graph upserts and executes them in groups, which removes the graph
+// latency from the store stage and drops the identical upserts made within the same group.
+type graphBatch struct {
+	sync.Mutex
+	flushLock sync.Mutex
+	size      int
+	ops       []graphOp
+	keys      map[string]struct{}
+	onError   func(error)
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+func newGraphBatch(size int, interval time.Duration, onError func(error)) *graphBatch {
+	if interval <= 0 {
+		interval = defaultGraphFlushInterval
+	}
+
+	b := &graphBatch{
+		size:    size,
+		keys:    make(map[string]struct{}),
+		onError: onError,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go b.flushPeriodically(interval)
+	return b
+}
+
+// add queues the upsert identified by the key, and flushes the batch once it reaches the size.
+func (b *graphBatch) add(key string, run func() error) {
+	b.Lock()
+	if _, found := b.keys[key]; found {
+		b.Unlock()
+		return
+	}
+	b.keys[key] = struct{}{}
+	b.ops = append(b.ops, graphOp{key: key, run: run})
+
+	full := len(b.ops) >= b.size
+	b.Unlock()
+
+	if full {
+		b.flush()
+	}
+}
+
+// flush executes the queued upserts in the order they were added.
+func (b *graphBatch) flush() {
+	b.flushLock.Lock()
+	defer b.flushLock.Unlock()
+
+	b.Lock()
+	ops := b.ops
+	b.ops = nil
+	b.keys = make(map[string]struct{})
+	b.Unlock()
+
+	for _, op := range ops {
+		if err := op.run(); err != nil && b.onError != nil {
+			b.onError(err)
+		}
+	}
+}
+
+func (b *graphBatch) flushPeriodically(interval time.Duration) {
+	defer close(b.stopped)
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-t.C:
+			b.flush()
+		}
+	}
+}
+
+// stop performs the final flush once the periodic flushes have ended.
+func (b *graphBatch) stop() {
+	close(b.done)
+	<-b.stopped
+	b.flush()
+}
@@ -0,0 +1,94 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestGraphBatch(t *testing.T) {
+	var executed int32
+	op := func() error {
+		atomic.AddInt32(&executed, 1)
+		return nil
+	}
+
+	b := newGraphBatch(3, time.Hour, nil)
+	b.add("a", op)
+	b.add("b", op)
+	// identical upserts within the batch are executed once
+	b.add("a", op)
+	if n := atomic.LoadInt32(&executed); n != 0 {
+		t.Errorf("%d upserts were executed before the batch was full", n)
+	}
+
+	b.add("c", op)
+	if n := atomic.LoadInt32(&executed); n != 3 {
+		t.Errorf("expected 3 upserts to be executed once the batch was full, but got %d", n)
+	}
+
+	var errs int32
+	b.onError = func(error) { atomic.AddInt32(&errs, 1) }
+	b.add("a", op)
+	b.add("d", func() error { return fmt.Errorf("failed") })
+	// the final flush executes the partial batch
+	b.stop()
+	if n := atomic.LoadInt32(&executed); n != 4 {
+		t.Errorf("expected 4 upserts to be executed after the final flush, but got %d", n)
+	}
+	if n := atomic.LoadInt32(&errs); n != 1 {
+		t.Errorf("expected one error to be reported, but got %d", n)
+	}
+
+	b = newGraphBatch(100, 10*time.Millisecond, nil)
+	defer b.stop()
+	b.add("a", op)
+	time.Sleep(100 * time.Millisecond)
+	if n := atomic.LoadInt32(&executed); n != 5 {
+		t.Errorf("the upsert was not executed by the periodic flush")
+	}
+}
+
+func BenchmarkGraphUpserts(b *testing.B) {
+	for _, size := range []int{0, 100} {
+		name := "per-record"
+		if size > 0 {
+			name = fmt.Sprintf("batch-%d", size)
+		}
+
+		b.Run(name, func(b *testing.B) {
+			cfg := config.NewConfig()
+			cfg.AddDomain("owasp.org")
+			g := netmap.NewGraph("memory", "", "")
+			defer g.Remove()
+
+			dm := &dataManager{enum: &Enumeration{Config: cfg, graph: g}}
+			if size > 0 {
+				dm.batch = newGraphBatch(size, time.Second, nil)
+			}
+
+			ctx := context.Background()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// the records are found repeatedly, as when multiple data sources provide the names
+				name := fmt.Sprintf("host%d.owasp.org", i%50)
+				addr := fmt.Sprintf("192.0.2.%d", i%50)
+				_ = dm.upsert("A record", name, addr, func() error {
+					return g.UpsertA(ctx, name, addr)
+				})
+			}
+			if dm.batch != nil {
+				dm.batch.stop()
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+// canaryCheckInterval is the period between the verifications of the canary names.
+const canaryCheckInterval = time.Minute
+
+// checkCanarySettings returns an error when a canary name or expected address is malformed.
+func (e *Enumeration) checkCanarySettings() error {
+	for name, addrs := range e.CanaryChecks {
+		if _, ok := dns.IsDomainName(name); !ok || name == "" {
+			return fmt.Errorf("the canary name %s is not a valid DNS name", name)
+		}
+		if len(addrs) == 0 {
+			return fmt.Errorf("the canary name %s does not have any expected addresses", name)
+		}
+		for _, addr := range addrs {
+			if net.ParseIP(addr) == nil {
+				return fmt.Errorf("the expected address %s for the canary name %s is not valid", addr, name)
+			}
+		}
+	}
+	return nil
+}
+
+// verifyCanaries periodically checks that the resolvers return the expected addresses for the canary
+// names, until the context expires. The enumeration is cancelled after tampering when CanaryAbort is set.
+func (e *Enumeration) verifyCanaries(ctx context.Context) {
+	t := time.NewTicker(canaryCheckInterval)
+	defer t.Stop()
+
+	for {
+		for name, expected := range e.CanaryChecks {
+			unexpected, err := e.checkCanary(ctx, name, expected)
+			if err != nil {
+				e.log().Debugf("The canary check for %s failed: %v", name, err)
+				continue
+			}
+			if len(unexpected) == 0 {
+				continue
+			}
+
+			e.log().Warnf("DNS tampering: the resolvers returned %s for the canary name %s, expected %s",
+				strings.Join(unexpected, ", "), name, strings.Join(expected, ", "))
+			if e.CanaryAbort && e.cancel != nil {
+				e.log().Errorf("Stopping the enumeration since the DNS responses may have been tampered with")
+				e.cancel()
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// checkCanary queries the untrusted resolvers for the canary name, and returns the addresses
+// in the answers that were not expected.
+func (e *Enumeration) checkCanary(ctx context.Context, name string, expected []string) ([]string, error) {
+	want := make(map[string]struct{}, len(expected))
+	qtypes := make(map[uint16]struct{})
+	for _, addr := range expected {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			continue
+		}
+
+		want[ip.String()] = struct{}{}
+		if ip.To4() != nil {
+			qtypes[dns.TypeA] = struct{}{}
+		} else {
+			qtypes[dns.TypeAAAA] = struct{}{}
+		}
+	}
+
+	var answered bool
+	var unexpected []string
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		if _, found := qtypes[qtype]; !found {
+			continue
+		}
+
+		resp, err := e.dnsQuery(ctx, name, qtype, e.Sys.Resolvers(), maxDNSQueryAttempts)
+		if err != nil || resp == nil {
+			continue
+		}
+
+		for _, rr := range resolve.AnswersByType(resolve.ExtractAnswers(resp), qtype) {
+			answered = true
+			if ip := net.ParseIP(rr.Data); ip == nil {
+				unexpected = append(unexpected, rr.Data)
+			} else if _, found := want[ip.String()]; !found {
+				unexpected = append(unexpected, ip.String())
+			}
+		}
+	}
+
+	if !answered && len(unexpected) == 0 {
+		return nil, fmt.Errorf("no addresses were returned for %s", name)
+	}
+	return unexpected, nil
+}
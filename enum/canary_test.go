@@ -0,0 +1,80 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestCanaryChecks(t *testing.T) {
+	// the resolver injects a different address for the poisoned name
+	srvAddr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		addr := net.IP{192, 0, 2, 1}
+		if req.Question[0].Name == "poisoned.owasp.org." {
+			addr = net.IP{203, 0, 113, 66}
+		}
+		if req.Question[0].Qtype == dns.TypeA {
+			m.Answer = append(m.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: req.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+				A:   addr,
+			})
+		}
+		_ = w.WriteMsg(m)
+	})
+
+	cfg := config.NewConfig()
+	cfg.Resolvers = []string{srvAddr}
+	pool := resolve.NewResolvers()
+	defer pool.Stop()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: pool, Trusted: resolve.NewResolvers()},
+		ForceTCP: true,
+		CanaryChecks: map[string][]string{
+			"www.owasp.org":      {"192.0.2.1"},
+			"poisoned.owasp.org": {"192.0.2.1"},
+		},
+		CanaryAbort: true,
+	}
+	defer e.Sys.TrustedResolvers().Stop()
+	if err := e.checkCanarySettings(); err != nil {
+		t.Fatalf("the canary settings were rejected: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if unexpected, err := e.checkCanary(ctx, "www.owasp.org", []string{"192.0.2.1"}); err != nil || len(unexpected) > 0 {
+		t.Errorf("the expected answer was flagged: %v %v", unexpected, err)
+	}
+	if unexpected, err := e.checkCanary(ctx, "poisoned.owasp.org", []string{"192.0.2.1"}); err != nil || len(unexpected) != 1 || unexpected[0] != "203.0.113.66" {
+		t.Errorf("the injected answer was not detected: %v %v", unexpected, err)
+	}
+
+	e.ctx, e.cancel = context.WithCancel(ctx)
+	e.verifyCanaries(e.ctx)
+	select {
+	case <-e.ctx.Done():
+	default:
+		t.Errorf("the enumeration was not stopped after detecting the tampering")
+	}
+
+	e.CanaryChecks = map[string][]string{"www.owasp.org": {"not an address"}}
+	if err := e.checkCanarySettings(); err == nil {
+		t.Errorf("the malformed expected address was accepted")
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+// queryCERT obtains the CERT records of the name, which are not extracted by the resolve package.
+func (dt *dnsTask) queryCERT(ctx context.Context, name string, ch chan []requests.DNSAnswer) {
+	if resp, err := dt.enum.dnsQuery(ctx, name, dns.TypeCERT, dt.enum.Sys.TrustedResolvers(), maxDNSQueryAttempts); err == nil {
+		if records := certAnswers(resp); len(records) > 0 {
+			ch <- truncateAnswers(records, dt.enum.MaxAnswerBytes)
+			return
+		}
+	}
+	ch <- nil
+}
+
+// certAnswers converts the CERT records in the answer section, and decodes the certificates.
+// The records with a certificate that is not valid base64 are dropped.
+func certAnswers(resp *dns.Msg) []requests.DNSAnswer {
+	if resp == nil {
+		return nil
+	}
+
+	var answers []requests.DNSAnswer
+	for _, rr := range resp.Answer {
+		cert, ok := rr.(*dns.CERT)
+		if !ok {
+			continue
+		}
+
+		der, err := base64.StdEncoding.DecodeString(cert.Certificate)
+		if err != nil {
+			continue
+		}
+
+		ctype, found := dns.CertTypeToString[cert.Type]
+		if !found {
+			ctype = strconv.Itoa(int(cert.Type))
+		}
+		alg, found := dns.AlgorithmToString[cert.Algorithm]
+		if !found {
+			alg = strconv.Itoa(int(cert.Algorithm))
+		}
+
+		answers = append(answers, requests.DNSAnswer{
+			Name: resolve.RemoveLastDot(cert.Hdr.Name),
+			Type: int(dns.TypeCERT),
+			TTL:  int(cert.Hdr.Ttl),
+			Data: fmt.Sprintf("%s %d %s %s", ctype, cert.KeyTag, alg, cert.Certificate),
+			Cert: &requests.CERTData{
+				Type:        ctype,
+				KeyTag:      cert.KeyTag,
+				Algorithm:   alg,
+				Certificate: der,
+			},
+		})
+	}
+	return answers
+}
@@ -0,0 +1,48 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestCERTAnswers(t *testing.T) {
+	der := []byte{0x30, 0x82, 0x01, 0x0a, 0x02, 0x82}
+	hdr := dns.RR_Header{Name: "www.owasp.org.", Rrtype: dns.TypeCERT, Class: dns.ClassINET, Ttl: 300}
+
+	resp := new(dns.Msg)
+	resp.Answer = []dns.RR{
+		&dns.CERT{Hdr: hdr, Type: dns.CertPKIX, KeyTag: 12345, Algorithm: dns.RSASHA256, Certificate: base64.StdEncoding.EncodeToString(der)},
+		&dns.CERT{Hdr: hdr, Type: 65280, KeyTag: 1, Algorithm: 200, Certificate: base64.StdEncoding.EncodeToString([]byte("key"))},
+		&dns.CERT{Hdr: hdr, Type: dns.CertPGP, Certificate: "not base64!"},
+		&dns.A{Hdr: dns.RR_Header{Name: "www.owasp.org.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300}},
+	}
+
+	answers := certAnswers(resp)
+	if len(answers) != 2 {
+		t.Fatalf("expected 2 CERT answers, but got %d", len(answers))
+	}
+
+	a := answers[0]
+	if a.Name != "www.owasp.org" || a.Type != int(dns.TypeCERT) || a.TTL != 300 {
+		t.Errorf("unexpected CERT answer: %+v", a)
+	}
+	if expected := "PKIX 12345 RSASHA256 " + base64.StdEncoding.EncodeToString(der); a.Data != expected {
+		t.Errorf("expected the data %s, but got %s", expected, a.Data)
+	}
+	if a.Cert == nil || a.Cert.Type != "PKIX" || a.Cert.KeyTag != 12345 || a.Cert.Algorithm != "RSASHA256" || !bytes.Equal(a.Cert.Certificate, der) {
+		t.Errorf("the CERT record was not decoded: %+v", a.Cert)
+	}
+	if c := answers[1].Cert; c == nil || c.Type != "65280" || c.Algorithm != "200" || string(c.Certificate) != "key" {
+		t.Errorf("the unknown certificate type and algorithm were not kept: %+v", c)
+	}
+	if certAnswers(nil) != nil {
+		t.Errorf("answers were returned without a response")
+	}
+}
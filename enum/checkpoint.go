@@ -0,0 +1,207 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// reverseCheckpointInterval is the minimum period between the writes of the checkpoint file during a sweep.
+const reverseCheckpointInterval = 5 * time.Second
+
+// reverseCheckpoint keeps the last address swept in each block, keyed by CIDR, so the reverse
+// sweeps interrupted by a restart resume after it.
+type reverseCheckpoint struct {
+	sync.Mutex
+	path    string
+	last    map[string]string
+	written time.Time
+}
+
+func newReverseCheckpoint(path string) (*reverseCheckpoint, error) {
+	c := &reverseCheckpoint{
+		path: path,
+		last: make(map[string]string),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the reverse sweep checkpoint file %s: %v", path, err)
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &c.last); err != nil {
+			return nil, fmt.Errorf("failed to parse the reverse sweep checkpoint file %s: %v", path, err)
+		}
+	}
+	return c, nil
+}
+
+// remaining returns the addresses following the last address swept in the block.
+func (c *reverseCheckpoint) remaining(cidr string, hosts []net.IP) []net.IP {
+	c.Lock()
+	last := net.ParseIP(c.last[cidr])
+	c.Unlock()
+
+	if last == nil {
+		return hosts
+	}
+	for i, ip := range hosts {
+		if bytes.Compare(ip.To16(), last.To16()) > 0 {
+			return hosts[i:]
+		}
+	}
+	return nil
+}
+
+// update records the last address swept in the block, and writes the file when the interval has
+// elapsed since the previous write, or when flush is set.
+func (c *reverseCheckpoint) update(cidr, last string, flush bool) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if last != "" {
+		c.last[cidr] = last
+	}
+	if !flush && time.Since(c.written) < reverseCheckpointInterval {
+		return nil
+	}
+	return c.write()
+}
+
+// write replaces the file atomically, through a temporary file renamed over it.
+func (c *reverseCheckpoint) write() error {
+	data, err := json.MarshalIndent(c.last, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return err
+	}
+
+	c.written = time.Now()
+	return nil
+}
+
+// sweepProgress tracks the addresses completed by a sweep running them concurrently, and advances
+// the checkpoint only past the addresses completed without gaps.
+type sweepProgress struct {
+	sync.Mutex
+	enum  *Enumeration
+	cp    *reverseCheckpoint
+	cidr  string
+	hosts []net.IP
+	done  []bool
+	next  int
+}
+
+// newSweepProgress returns the progress of the sweep over the hosts of the block, with the hosts
+// already swept according to the ReverseSweepCheckpointFile removed.
+func (e *Enumeration) newSweepProgress(cidr *net.IPNet, hosts []net.IP) *sweepProgress {
+	p := &sweepProgress{enum: e, cidr: cidr.String(), hosts: hosts}
+
+	cp, err := e.sweepCheckpoint()
+	if err != nil {
+		e.log().Errorf("%v", err)
+	} else if cp != nil {
+		p.cp = cp
+		p.hosts = cp.remaining(p.cidr, hosts)
+		if skipped := len(hosts) - len(p.hosts); skipped > 0 {
+			e.log().Infof("Resuming the reverse sweep of %s after %d addresses", p.cidr, skipped)
+		}
+	}
+
+	p.done = make([]bool, len(p.hosts))
+	return p
+}
+
+// complete marks the address at the index of the hosts as swept.
+func (p *sweepProgress) complete(idx int) {
+	if p.cp == nil {
+		return
+	}
+
+	p.Lock()
+	p.done[idx] = true
+	start := p.next
+	for p.next < len(p.done) && p.done[p.next] {
+		p.next++
+	}
+	var last string
+	if p.next > start {
+		last = p.hosts[p.next-1].String()
+	}
+	p.Unlock()
+
+	if last != "" {
+		if err := p.cp.update(p.cidr, last, false); err != nil {
+			p.enum.log().Errorf("Failed to write the reverse sweep checkpoint file: %v", err)
+		}
+	}
+}
+
+// finish writes the last address swept to the checkpoint file once the sweep has stopped.
+func (p *sweepProgress) finish() {
+	if p.cp == nil {
+		return
+	}
+
+	p.Lock()
+	var last string
+	if p.next > 0 {
+		last = p.hosts[p.next-1].String()
+	}
+	p.Unlock()
+
+	if err := p.cp.update(p.cidr, last, true); err != nil {
+		p.enum.log().Errorf("Failed to write the reverse sweep checkpoint file: %v", err)
+	}
+}
+
+// sweepCheckpoint loads the ReverseSweepCheckpointFile once, and returns nil when it has not been set.
+func (e *Enumeration) sweepCheckpoint() (*reverseCheckpoint, error) {
+	if e.ReverseSweepCheckpointFile == "" {
+		return nil, nil
+	}
+
+	e.cpLock.Lock()
+	defer e.cpLock.Unlock()
+
+	if e.sweepcp == nil {
+		cp, err := newReverseCheckpoint(e.ReverseSweepCheckpointFile)
+		if err != nil {
+			return nil, err
+		}
+		e.sweepcp = cp
+	}
+	return e.sweepcp, nil
+}
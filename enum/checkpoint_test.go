@@ -0,0 +1,124 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/config/config"
+)
+
+func readCheckpoint(t *testing.T, path string) map[string]string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read the checkpoint file: %v", err)
+	}
+
+	last := make(map[string]string)
+	if err := json.Unmarshal(data, &last); err != nil {
+		t.Fatalf("Failed to parse the checkpoint file: %v", err)
+	}
+	return last
+}
+
+func TestSweepProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sweep.json")
+	e := &Enumeration{Config: config.NewConfig(), ReverseSweepCheckpointFile: path}
+
+	_, cidr, _ := net.ParseCIDR("192.0.2.0/29")
+	prog := e.newSweepProgress(cidr, sweepHosts(cidr, 8))
+	if len(prog.hosts) != 8 {
+		t.Fatalf("expected the full sweep without a checkpoint, but got %d addresses", len(prog.hosts))
+	}
+
+	// the checkpoint does not advance past the gap left by the second address
+	for _, idx := range []int{0, 2, 3} {
+		prog.complete(idx)
+	}
+	prog.finish()
+	if last := readCheckpoint(t, path); last[cidr.String()] != "192.0.2.0" {
+		t.Errorf("expected the checkpoint at 192.0.2.0, but got %v", last)
+	}
+
+	prog.complete(1)
+	prog.finish()
+	if last := readCheckpoint(t, path); last[cidr.String()] != "192.0.2.3" {
+		t.Errorf("expected the checkpoint at 192.0.2.3, but got %v", last)
+	}
+
+	// the checkpoint survives the restart, and a swept block leaves nothing to sweep
+	e = &Enumeration{Config: config.NewConfig(), ReverseSweepCheckpointFile: path}
+	if prog = e.newSweepProgress(cidr, sweepHosts(cidr, 8)); len(prog.hosts) != 4 || prog.hosts[0].String() != "192.0.2.4" {
+		t.Errorf("expected the sweep to resume at 192.0.2.4, but got %v", prog.hosts)
+	}
+	for i := range prog.hosts {
+		prog.complete(i)
+	}
+	prog.finish()
+	if prog = e.newSweepProgress(cidr, sweepHosts(cidr, 8)); len(prog.hosts) != 0 {
+		t.Errorf("expected the swept block to be skipped, but got %v", prog.hosts)
+	}
+
+	if matches, _ := filepath.Glob(path + ".tmp*"); len(matches) > 0 {
+		t.Errorf("the temporary checkpoint files were left behind: %v", matches)
+	}
+}
+
+func TestReverseOnlyCheckpoint(t *testing.T) {
+	addr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		_ = w.WriteMsg(ptrHandler(req))
+	})
+
+	_, cidr, _ := net.ParseCIDR("192.0.2.0/29")
+	path := filepath.Join(t.TempDir(), "sweep.json")
+	if err := os.WriteFile(path, []byte(`{"192.0.2.0/29": "192.0.2.1"}`), 0640); err != nil {
+		t.Fatalf("Failed to write the checkpoint file: %v", err)
+	}
+
+	for _, batch := range []int{0, 4} {
+		e := newReverseBatchEnum(t, addr, batch)
+		e.ReverseSweepCheckpointFile = path
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		reqs, err := e.ReverseOnly(ctx, cidr)
+		cancel()
+		if err != nil {
+			t.Errorf("batch %d: the sweep failed: %v", batch, err)
+		} else if len(reqs) != 0 {
+			t.Errorf("batch %d: expected the address swept previously to be skipped, but got %v", batch, reqs)
+		}
+		if last := readCheckpoint(t, path); last[cidr.String()] != "192.0.2.7" {
+			t.Errorf("batch %d: expected the checkpoint at the end of the block, but got %v", batch, last)
+		}
+		e.Sys.Resolvers().Stop()
+		e.Sys.TrustedResolvers().Stop()
+		e.graph.Remove()
+
+		// reset the checkpoint for the next pass
+		if err := os.WriteFile(path, []byte(`{"192.0.2.0/29": "192.0.2.1"}`), 0640); err != nil {
+			t.Fatalf("Failed to write the checkpoint file: %v", err)
+		}
+	}
+
+	// a malformed checkpoint file stops the sweep
+	if err := os.WriteFile(path, []byte("not json"), 0640); err != nil {
+		t.Fatalf("Failed to write the checkpoint file: %v", err)
+	}
+	e := newReverseBatchEnum(t, addr, 0)
+	e.ReverseSweepCheckpointFile = path
+	defer e.Sys.Resolvers().Stop()
+	defer e.Sys.TrustedResolvers().Stop()
+	defer e.graph.Remove()
+	if _, err := e.ReverseOnly(context.Background(), cidr); err == nil {
+		t.Errorf("expected the malformed checkpoint file to fail the sweep")
+	}
+}
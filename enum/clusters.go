@@ -0,0 +1,175 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"sort"
+	"strings"
+
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+// addressCluster is a group of names with the union of their address sets.
+type addressCluster struct {
+	addrs map[string]struct{}
+	names []string
+}
+
+// AddressClusters groups the names within the scope found since the enumeration started by their
+// resolved address sets, revealing the names on shared hosting or behind the same load balancers.
+// The keys are the sorted addresses of each cluster joined by commas, and the values are the sorted
+// names. When ClusterSimilarity is between 0 and 1, the clusters with address sets at least that
+// similar are merged, and the key is the union of their addresses. Only the clusters with two or
+// more names are returned.
+func (e *Enumeration) AddressClusters() map[string][]string {
+	clusters := make(map[string][]string)
+	if e.graph == nil {
+		return clusters
+	}
+
+	var groups []*addressCluster
+	byKey := make(map[string]*addressCluster)
+	for name, addrs := range e.nameAddresses() {
+		key := clusterKey(addrs)
+		if c, found := byKey[key]; found {
+			c.names = append(c.names, name)
+			continue
+		}
+
+		c := &addressCluster{addrs: addrs, names: []string{name}}
+		byKey[key] = c
+		groups = append(groups, c)
+	}
+	// the merges must not depend on the order of the map iteration
+	sort.Slice(groups, func(i, j int) bool {
+		return clusterKey(groups[i].addrs) < clusterKey(groups[j].addrs)
+	})
+
+	if t := e.ClusterSimilarity; t > 0 && t < 1 {
+		groups = mergeClusters(groups, t)
+	}
+
+	for _, c := range groups {
+		if len(c.names) < 2 {
+			continue
+		}
+		sort.Strings(c.names)
+		clusters[clusterKey(c.addrs)] = c.names
+	}
+	return clusters
+}
+
+// nameAddresses returns the A and AAAA record addresses of each name within the scope.
+func (e *Enumeration) nameAddresses() map[string]map[string]struct{} {
+	since := e.Config.CollectionStartTime.UTC()
+
+	var scope []oam.Asset
+	for _, d := range e.Config.Domains() {
+		scope = append(scope, domain.FQDN{Name: d})
+	}
+	// the graph returns an error when no names are within the scope
+	names, _ := e.graph.DB.FindByScope(scope, since)
+
+	results := make(map[string]map[string]struct{})
+	for _, a := range names {
+		fqdn, ok := a.Asset.(domain.FQDN)
+		if !ok || !e.Config.IsDomainInScope(fqdn.Name) {
+			continue
+		}
+
+		rels, err := e.graph.DB.OutgoingRelations(a, since, "a_record", "aaaa_record")
+		if err != nil {
+			continue
+		}
+
+		addrs := make(map[string]struct{})
+		for _, rel := range rels {
+			to := rel.ToAsset
+			if to != nil && to.Asset == nil {
+				to, _ = e.graph.DB.FindById(to.ID, since)
+			}
+			if to == nil {
+				continue
+			}
+			if ip, ok := to.Asset.(network.IPAddress); ok {
+				addrs[ip.Address.Unmap().String()] = struct{}{}
+			}
+		}
+		if len(addrs) > 0 {
+			results[strings.ToLower(fqdn.Name)] = addrs
+		}
+	}
+	return results
+}
+
+// mergeClusters joins the clusters with a Jaccard similarity of their address sets at or above the
+// threshold, and the clusters connected through a chain of similar clusters end up together.
+func mergeClusters(groups []*addressCluster, threshold float64) []*addressCluster {
+	parent := make([]int, len(groups))
+	for i := range parent {
+		parent[i] = i
+	}
+	root := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	for i := range groups {
+		for j := i + 1; j < len(groups); j++ {
+			if jaccard(groups[i].addrs, groups[j].addrs) >= threshold {
+				if ri, rj := root(i), root(j); ri != rj {
+					parent[rj] = ri
+				}
+			}
+		}
+	}
+
+	merged := make(map[int]*addressCluster)
+	var results []*addressCluster
+	for i, g := range groups {
+		r := root(i)
+		c, found := merged[r]
+		if !found {
+			c = &addressCluster{addrs: make(map[string]struct{})}
+			merged[r] = c
+			results = append(results, c)
+		}
+		for addr := range g.addrs {
+			c.addrs[addr] = struct{}{}
+		}
+		c.names = append(c.names, g.names...)
+	}
+	return results
+}
+
+// jaccard returns the size of the intersection of the address sets divided by the size of their union.
+func jaccard(a, b map[string]struct{}) float64 {
+	var shared int
+	for addr := range a {
+		if _, found := b[addr]; found {
+			shared++
+		}
+	}
+
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+func clusterKey(addrs map[string]struct{}) string {
+	keys := make([]string, 0, len(addrs))
+	for addr := range addrs {
+		keys = append(keys, addr)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
@@ -0,0 +1,82 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestAddressClusters(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.CollectionStartTime = time.Now().Add(-time.Minute)
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	e := &Enumeration{Config: cfg}
+	if c := e.AddressClusters(); len(c) != 0 {
+		t.Errorf("clusters were returned without a graph: %v", c)
+	}
+	e.graph = g
+
+	ctx := context.Background()
+	for name, addrs := range map[string][]string{
+		"www.owasp.org":   {"192.0.2.1", "192.0.2.2"},
+		"docs.owasp.org":  {"192.0.2.2", "192.0.2.1"},
+		"api.owasp.org":   {"192.0.2.1", "192.0.2.2", "192.0.2.3"},
+		"mail.owasp.org":  {"198.51.100.1"},
+		"www.example.com": {"192.0.2.1", "192.0.2.2"},
+	} {
+		for _, addr := range addrs {
+			if err := g.UpsertA(ctx, name, addr); err != nil {
+				t.Fatalf("failed to insert the A record: %v", err)
+			}
+		}
+	}
+
+	expected := map[string][]string{
+		"192.0.2.1,192.0.2.2": {"docs.owasp.org", "www.owasp.org"},
+	}
+	if c := e.AddressClusters(); !reflect.DeepEqual(c, expected) {
+		t.Errorf("expected the clusters %v, but got %v", expected, c)
+	}
+
+	// the similarity of the address sets is 2/3
+	e.ClusterSimilarity = 0.6
+	expected = map[string][]string{
+		"192.0.2.1,192.0.2.2,192.0.2.3": {"api.owasp.org", "docs.owasp.org", "www.owasp.org"},
+	}
+	if c := e.AddressClusters(); !reflect.DeepEqual(c, expected) {
+		t.Errorf("expected the clusters %v, but got %v", expected, c)
+	}
+
+	e.ClusterSimilarity = 0.7
+	expected = map[string][]string{
+		"192.0.2.1,192.0.2.2": {"docs.owasp.org", "www.owasp.org"},
+	}
+	if c := e.AddressClusters(); !reflect.DeepEqual(c, expected) {
+		t.Errorf("expected the clusters %v, but got %v", expected, c)
+	}
+}
+
+func TestClusterSimilarityRange(t *testing.T) {
+	for _, s := range []float64{-0.1, 1.5} {
+		cfg := config.NewConfig()
+		cfg.AddDomain("owasp.org")
+		e := &Enumeration{Config: cfg, PipelineBufferSize: 1, ClusterSimilarity: s}
+
+		if err := e.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "cluster similarity") {
+			t.Errorf("the enumeration started with a cluster similarity of %g: %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+// chainAddresses returns the A and AAAA answers of the response belonging to the targets of the
+// CNAME answers, which the resolvers often include along with the chain.
+func chainAddresses(ans, cnames []*resolve.ExtractedAnswer) []*resolve.ExtractedAnswer {
+	targets := make(map[string]struct{})
+	for _, a := range cnames {
+		targets[strings.ToLower(resolve.RemoveLastDot(a.Data))] = struct{}{}
+	}
+
+	var addrs []*resolve.ExtractedAnswer
+	for _, a := range ans {
+		if a.Type != dns.TypeA && a.Type != dns.TypeAAAA {
+			continue
+		}
+		if _, found := targets[strings.ToLower(resolve.RemoveLastDot(a.Name))]; found {
+			addrs = append(addrs, a)
+		}
+	}
+	return addrs
+}
+
+// chainTarget returns true when the name is the target of a CNAME record of the request.
+func chainTarget(req *requests.DNSRequest, name string) bool {
+	name = strings.ToLower(resolve.RemoveLastDot(name))
+	if name == "" || strings.EqualFold(name, req.Name) {
+		return false
+	}
+
+	for _, rec := range req.Records {
+		if uint16(rec.Type) == dns.TypeCNAME && strings.EqualFold(resolve.RemoveLastDot(rec.Data), name) {
+			return true
+		}
+	}
+	return false
+}
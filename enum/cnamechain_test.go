@@ -0,0 +1,99 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestPreferCNAMEWithAddresses(t *testing.T) {
+	// the CNAME answers include the address of the target, as the recursive resolvers do
+	addr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		q := req.Question[0]
+		if strings.ToLower(q.Name) != "www.owasp.org." {
+			m.Rcode = dns.RcodeNameError
+		} else if q.Qtype == dns.TypeCNAME || q.Qtype == dns.TypeA {
+			m.Answer = append(m.Answer, &dns.CNAME{
+				Hdr:    dns.RR_Header{Name: q.Name, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 300},
+				Target: "owasp.cdn.example.net.",
+			}, &dns.A{
+				Hdr: dns.RR_Header{Name: "owasp.cdn.example.net.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+				A:   net.ParseIP("192.0.2.10"),
+			}, &dns.A{
+				Hdr: dns.RR_Header{Name: "other.example.net.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+				A:   net.ParseIP("192.0.2.99"),
+			})
+		}
+		_ = w.WriteMsg(m)
+	})
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.Resolvers = []string{addr}
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP: true,
+		graph:    g,
+		chains:   newCNAMEChains(),
+	}
+	defer e.Sys.Resolvers().Stop()
+	e.fwdTypes = FwdQueryTypes
+	e.nameSrc = newTestEnumSource(e, 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := e.ResolveName(ctx, "www.owasp.org", "owasp.org")
+	if err != nil {
+		t.Fatalf("failed to resolve the name: %v", err)
+	}
+	if len(req.Records) != 1 || req.Records[0].Type != int(dns.TypeCNAME) {
+		t.Errorf("expected only the CNAME record without PreferCNAMEWithAddresses, but got %v", req.Records)
+	}
+
+	e.PreferCNAMEWithAddresses = true
+	req, err = e.ResolveName(ctx, "www.owasp.org", "owasp.org")
+	if err != nil {
+		t.Fatalf("failed to resolve the name: %v", err)
+	}
+	if len(req.Records) != 2 || req.Records[1].Type != int(dns.TypeA) ||
+		req.Records[1].Name != "owasp.cdn.example.net" || req.Records[1].Data != "192.0.2.10" {
+		t.Fatalf("expected the CNAME record and the address of the target, but got %v", req.Records)
+	}
+
+	dm := &dataManager{enum: e}
+	if err := dm.dnsRequest(ctx, req, nil); err != nil {
+		t.Fatalf("failed to store the records: %v", err)
+	}
+	if !g.IsCNAMENode(ctx, "www.owasp.org", time.Time{}) {
+		t.Errorf("the CNAME record was not stored")
+	}
+	// the address is stored for the target, which is outside of the scope and not resolved
+	pairs, err := g.NamesToAddrs(ctx, time.Time{}, "owasp.cdn.example.net")
+	if err != nil || len(pairs) != 1 || pairs[0].Addr.Address.String() != "192.0.2.10" {
+		t.Errorf("the address of the CNAME target was not stored: %v", pairs)
+	}
+}
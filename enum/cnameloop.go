@@ -0,0 +1,56 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"strings"
+	"sync"
+)
+
+// cnameChains keeps the CNAME records followed during the enumeration, so that a target
+// leading back to the name through the chain is detected before it is resolved again.
+type cnameChains struct {
+	sync.Mutex
+	targets map[string]string
+}
+
+func newCNAMEChains() *cnameChains {
+	return &cnameChains{targets: make(map[string]string)}
+}
+
+// follow records the CNAME from name to target, and returns the chain of names when the
+// target leads back to the name. The record is not kept when it closes a loop, so the
+// chains remain free of cycles and each walk ends.
+func (c *cnameChains) follow(name, target string) []string {
+	name = strings.ToLower(name)
+	target = strings.ToLower(target)
+
+	c.Lock()
+	defer c.Unlock()
+
+	chain := []string{name}
+	for cur := target; cur != ""; cur = c.targets[cur] {
+		chain = append(chain, cur)
+		if cur == name {
+			return chain
+		}
+	}
+
+	c.targets[name] = target
+	return nil
+}
+
+// cnameLoop returns true when following the CNAME from name to target would loop.
+func (e *Enumeration) cnameLoop(name, target string) bool {
+	if e.chains == nil {
+		return false
+	}
+
+	if chain := e.chains.follow(name, target); chain != nil {
+		e.log().Warnf("CNAME loop detected: %s", strings.Join(chain, " -> "))
+		return true
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestCNAMEChains(t *testing.T) {
+	c := newCNAMEChains()
+
+	if chain := c.follow("a.owasp.org", "a.owasp.org"); len(chain) != 2 {
+		t.Errorf("the CNAME to the name itself was not detected as a loop: %v", chain)
+	}
+	for _, link := range [][]string{
+		{"a.owasp.org", "b.owasp.org"},
+		{"b.owasp.org", "c.owasp.org"},
+		{"d.owasp.org", "b.owasp.org"},
+	} {
+		if chain := c.follow(link[0], link[1]); chain != nil {
+			t.Errorf("%s -> %s was detected as a loop: %v", link[0], link[1], chain)
+		}
+	}
+	if chain := c.follow("C.owasp.org", "a.owasp.org"); len(chain) != 4 {
+		t.Errorf("the loop through the chain was not detected: %v", chain)
+	}
+}
+
+func TestCNAMELoop(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	e := &Enumeration{Config: cfg, graph: g, chains: newCNAMEChains()}
+	e.nameSrc = newTestEnumSource(e, 10)
+	dm := &dataManager{enum: e}
+	ctx := context.Background()
+
+	// a.owasp.org CNAMEs to b.owasp.org, and b.owasp.org CNAMEs back to a.owasp.org
+	for i, link := range [][]string{
+		{"a.owasp.org", "b.owasp.org."},
+		{"b.owasp.org", "a.owasp.org."},
+	} {
+		req := &requests.DNSRequest{
+			Name:    link[0],
+			Domain:  "owasp.org",
+			Records: []requests.DNSAnswer{{Name: link[0], Type: int(dns.TypeCNAME), Data: link[1]}},
+		}
+		if err := dm.insertCNAME(ctx, req, 0, nil); err != nil {
+			t.Fatalf("failed to insert the CNAME record for %s: %v", link[0], err)
+		}
+		// only the first target is submitted for resolution
+		if n := e.nameSrc.queue.Len(); n != 1 {
+			t.Errorf("after the CNAME record %d, expected one name to be resolved, but got %d", i, n)
+		}
+	}
+
+	if !g.IsCNAMENode(ctx, "b.owasp.org", time.Time{}) {
+		t.Errorf("the CNAME record closing the loop was not stored")
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression formats of the CompressOutput.
+const (
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
+)
+
+// compressor is the writer compressing an output, which must be closed to complete the stream.
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// checkCompressOutput validates the compression format of the CompressOutput.
+func (e *Enumeration) checkCompressOutput() error {
+	switch e.CompressOutput {
+	case "", CompressGzip, CompressZstd:
+		return nil
+	}
+	return fmt.Errorf("the output compression %s is not supported, use %s or %s", e.CompressOutput, CompressGzip, CompressZstd)
+}
+
+// newCompressor wraps the writer in the compressor of the format. Closing the compressor does
+// not close the underlying writer.
+func newCompressor(format string, w io.Writer) (compressor, error) {
+	switch format {
+	case CompressGzip:
+		return gzip.NewWriter(w), nil
+	case CompressZstd:
+		return zstd.NewWriter(w)
+	}
+	return nil, fmt.Errorf("the output compression %s is not supported", format)
+}
+
+// compressedPath appends the file extension of the compression format to the path, unless present.
+func compressedPath(path, format string) string {
+	ext := map[string]string{CompressGzip: ".gz", CompressZstd: ".zst"}[format]
+	if ext == "" || strings.HasSuffix(path, ext) {
+		return path
+	}
+	return path + ext
+}
@@ -0,0 +1,125 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestCompressedJSONLOutput(t *testing.T) {
+	req := &requests.DNSRequest{
+		Name:    "www.owasp.org",
+		Domain:  "owasp.org",
+		Records: []requests.DNSAnswer{{Name: "www.owasp.org", Type: int(dns.TypeA), Data: "192.0.2.1"}},
+	}
+
+	for format, ext := range map[string]string{"": "", CompressGzip: ".gz", CompressZstd: ".zst"} {
+		path := filepath.Join(t.TempDir(), "amass.jsonl")
+		e := &Enumeration{Config: config.NewConfig(), CompressOutput: format}
+		if err := e.SetJSONLOutput(path); err != nil {
+			t.Fatalf("Failed to set the %q JSONL output: %v", format, err)
+		}
+		if e.jsonl.path != path+ext {
+			t.Errorf("expected the output file %s, but got %s", path+ext, e.jsonl.path)
+		}
+		for i := 0; i < 3; i++ {
+			if err := e.jsonl.write(req, []string{"DNS"}); err != nil {
+				t.Fatalf("Failed to write the %q JSONL output: %v", format, err)
+			}
+		}
+		if err := e.jsonl.stop(); err != nil {
+			t.Fatalf("Failed to close the %q JSONL output: %v", format, err)
+		}
+
+		f, err := os.Open(e.jsonl.path)
+		if err != nil {
+			t.Fatalf("Failed to open the %q JSONL output: %v", format, err)
+		}
+		defer f.Close()
+
+		var r io.Reader = f
+		switch format {
+		case CompressGzip:
+			zr, err := gzip.NewReader(f)
+			if err != nil {
+				t.Fatalf("the gzip output is not valid: %v", err)
+			}
+			r = zr
+		case CompressZstd:
+			zr, err := zstd.NewReader(f)
+			if err != nil {
+				t.Fatalf("the zstd output is not valid: %v", err)
+			}
+			defer zr.Close()
+			r = zr
+		}
+
+		dec := json.NewDecoder(r)
+		var count int
+		for dec.More() {
+			var rec objectRecord
+			if err := dec.Decode(&rec); err != nil {
+				t.Fatalf("the %q output is not valid JSON lines: %v", format, err)
+			}
+			if rec.Name != "www.owasp.org" || len(rec.Records) != 1 {
+				t.Errorf("unexpected record in the %q output: %+v", format, rec)
+			}
+			count++
+		}
+		if count != 3 {
+			t.Errorf("expected 3 lines in the %q output, but got %d", format, count)
+		}
+	}
+}
+
+func TestCompressedTemplateOutput(t *testing.T) {
+	var buf bytes.Buffer
+	comp, err := newCompressor(CompressGzip, &buf)
+	if err != nil {
+		t.Fatalf("Failed to create the compressor: %v", err)
+	}
+
+	to, err := newTemplateOutput("{{.Name}}", comp)
+	if err != nil {
+		t.Fatalf("Failed to parse the template: %v", err)
+	}
+	to.comp = comp
+	if err := to.write(&requests.DNSRequest{Name: "www.owasp.org", Domain: "owasp.org"}, nil); err != nil {
+		t.Fatalf("Failed to write the template output: %v", err)
+	}
+	if err := to.stop(); err != nil {
+		t.Fatalf("Failed to close the template output: %v", err)
+	}
+
+	zr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("the gzip output is not valid: %v", err)
+	}
+	if data, err := io.ReadAll(zr); err != nil || string(data) != "www.owasp.org\n" {
+		t.Errorf("unexpected template output: %q, %v", data, err)
+	}
+}
+
+func TestCheckCompressOutput(t *testing.T) {
+	e := &Enumeration{Config: config.NewConfig(), CompressOutput: "bzip2"}
+
+	if err := e.checkCompressOutput(); err == nil {
+		t.Errorf("the unsupported compression was accepted")
+	}
+	if err := e.SetJSONLOutput(filepath.Join(t.TempDir(), "amass.jsonl")); err == nil {
+		t.Errorf("the JSONL output was set with an unsupported compression")
+	}
+}
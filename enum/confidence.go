@@ -0,0 +1,97 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+const (
+	// confidenceResolved is the part of the score earned by the names that resolved.
+	confidenceResolved = 40
+	// confidencePerSource is earned by each data source that provided the name, up to maxConfidenceSources.
+	confidencePerSource  = 20
+	maxConfidenceSources = 3
+)
+
+// scoreName computes the confidence score of the name from the data sources that provided it,
+// whether it resolved, and whether its parent has a DNS wildcard. The score ranges from 0 to 100,
+// and is halved for the names under a wildcard, since their records could be produced by it.
+func (e *Enumeration) scoreName(req *requests.DNSRequest) int {
+	name := strings.ToLower(resolve.RemoveLastDot(strings.TrimSpace(req.Name)))
+
+	var sources, score int
+	for _, src := range e.SourceHistory(name) {
+		if src != resolvingSource {
+			sources++
+		}
+	}
+	if sources > maxConfidenceSources {
+		sources = maxConfidenceSources
+	}
+	score += sources * confidencePerSource
+
+	if len(req.Records) > 0 {
+		score += confidenceResolved
+	}
+	if e.underWildcard(name) {
+		score /= 2
+	}
+
+	e.histLock.Lock()
+	defer e.histLock.Unlock()
+
+	if e.scores == nil {
+		e.scores = make(map[string]int)
+	}
+	e.scores[name] = score
+	return score
+}
+
+// Confidence returns the confidence score, from 0 to 100, computed by the store stage for the name.
+// The second return value is false when the name has not been scored.
+func (e *Enumeration) Confidence(name string) (int, bool) {
+	name = strings.ToLower(resolve.RemoveLastDot(strings.TrimSpace(name)))
+
+	e.histLock.Lock()
+	defer e.histLock.Unlock()
+
+	score, found := e.scores[name]
+	return score, found
+}
+
+// markWildcard records the parent of the name, since the name matched a DNS wildcard of it.
+func (e *Enumeration) markWildcard(name string) {
+	name = strings.ToLower(resolve.RemoveLastDot(name))
+
+	if idx := strings.Index(name, "."); idx != -1 {
+		e.wildzone.Store(name[idx+1:], struct{}{})
+	}
+	if e.floods != nil {
+		e.floods.match(name)
+	}
+}
+
+// underWildcard returns true when a DNS wildcard has been detected on the parent of the name.
+func (e *Enumeration) underWildcard(name string) bool {
+	if idx := strings.Index(name, "."); idx != -1 {
+		_, found := e.wildzone.Load(name[idx+1:])
+		return found
+	}
+	return false
+}
+
+// belowMinConfidence returns true when the name scored lower than MinConfidence.
+func (e *Enumeration) belowMinConfidence(name string) bool {
+	if e.MinConfidence <= 0 {
+		return false
+	}
+
+	score, found := e.Confidence(name)
+	return found && score < e.MinConfidence
+}
@@ -0,0 +1,59 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func TestConfidence(t *testing.T) {
+	e := &Enumeration{MinConfidence: 50}
+	resolved := []requests.DNSAnswer{{Type: int(dns.TypeA), Data: "192.0.2.1"}}
+
+	if _, found := e.Confidence("www.owasp.org"); found {
+		t.Errorf("expected no confidence score before the name was scored")
+	}
+
+	tests := []struct {
+		name     string
+		sources  []string
+		records  []requests.DNSAnswer
+		wildcard bool
+		expected int
+	}{
+		{"single.owasp.org", []string{"crtsh"}, nil, false, 20},
+		{"www.owasp.org", []string{"crtsh", "HackerTarget", resolvingSource}, resolved, false, 80},
+		{"many.owasp.org", []string{"a", "b", "c", "d", "e"}, resolved, false, 100},
+		{"foo.wild.owasp.org", []string{"Brute Forcing"}, resolved, true, 30},
+	}
+
+	for _, test := range tests {
+		for _, src := range test.sources {
+			e.addSourceHistory(test.name, src)
+		}
+		if test.wildcard {
+			e.markWildcard("random.wild.owasp.org")
+		}
+
+		req := &requests.DNSRequest{Name: test.name, Records: test.records}
+		if score := e.scoreName(req); score != test.expected {
+			t.Errorf("%s: expected the confidence score %d, but got %d", test.name, test.expected, score)
+		}
+		if score, found := e.Confidence(test.name + "."); !found || score != test.expected {
+			t.Errorf("%s: expected Confidence to return %d, but got %d", test.name, test.expected, score)
+		}
+		if below := e.belowMinConfidence(test.name); below != (test.expected < e.MinConfidence) {
+			t.Errorf("%s: the score %d was not filtered against MinConfidence %d", test.name, test.expected, e.MinConfidence)
+		}
+	}
+
+	// the names not scored by the store stage are not filtered
+	if e.belowMinConfidence("unknown.owasp.org") {
+		t.Errorf("a name without a score was filtered")
+	}
+}
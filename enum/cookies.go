@@ -0,0 +1,93 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+// clientCookieLen is the length of the hex encoded client cookie defined in RFC 7873.
+const clientCookieLen = 16
+
+// dnsCookies adds the RFC 7873 client cookie to the queries, validates the cookies echoed in
+// the responses, and caches the server cookies for the resolvers that the queries are sent to.
+type dnsCookies struct {
+	sync.Mutex
+	client  string
+	servers map[string]string
+}
+
+func newDNSCookies() (*dnsCookies, error) {
+	b := make([]byte, clientCookieLen/2)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	return &dnsCookies{
+		client:  hex.EncodeToString(b),
+		servers: make(map[string]string),
+	}, nil
+}
+
+// add includes the cookie in the DNS message, along with the server cookie cached for the address.
+// The server address is unknown for the queries sent through the resolver pools, so only the client
+// cookie is sent to them.
+func (c *dnsCookies) add(msg *dns.Msg, addr string) {
+	opt := msg.IsEdns0()
+	if opt == nil {
+		msg.SetEdns0(defaultEDNSBufferSize, false)
+		opt = msg.IsEdns0()
+	}
+
+	cookie := c.client
+	if addr != "" {
+		c.Lock()
+		cookie += c.servers[addr]
+		c.Unlock()
+	}
+
+	var options []dns.EDNS0
+	for _, o := range opt.Option {
+		if o.Option() != dns.EDNS0COOKIE {
+			options = append(options, o)
+		}
+	}
+	opt.Option = append(options, &dns.EDNS0_COOKIE{
+		Code:   dns.EDNS0COOKIE,
+		Cookie: cookie,
+	})
+}
+
+// check returns false when the response echoes a client cookie that was not sent by this
+// enumeration, which indicates a spoofed response. Responses without a cookie are from servers
+// that do not support them. The server cookie is cached when the address is known.
+func (c *dnsCookies) check(resp *dns.Msg, addr string) bool {
+	opt := resp.IsEdns0()
+	if opt == nil {
+		return true
+	}
+
+	for _, o := range opt.Option {
+		cookie, ok := o.(*dns.EDNS0_COOKIE)
+		if !ok {
+			continue
+		}
+		if len(cookie.Cookie) < clientCookieLen ||
+			!strings.EqualFold(cookie.Cookie[:clientCookieLen], c.client) {
+			return false
+		}
+		if server := cookie.Cookie[clientCookieLen:]; addr != "" && server != "" {
+			c.Lock()
+			c.servers[addr] = server
+			c.Unlock()
+		}
+	}
+	return true
+}
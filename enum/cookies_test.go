@@ -0,0 +1,69 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestDNSCookies(t *testing.T) {
+	c, err := newDNSCookies()
+	if err != nil {
+		t.Fatalf("failed to generate the client cookie: %v", err)
+	}
+	addr := "192.0.2.1:53"
+	server := "0123456789abcdef"
+
+	sent := func(a string) string {
+		msg := resolve.QueryMsg("www.owasp.org", dns.TypeA)
+		c.add(msg, a)
+		c.add(msg, a)
+
+		var cookies []string
+		for _, o := range msg.IsEdns0().Option {
+			if cookie, ok := o.(*dns.EDNS0_COOKIE); ok {
+				cookies = append(cookies, cookie.Cookie)
+			}
+		}
+		if len(cookies) != 1 {
+			t.Fatalf("expected one cookie option in the query, but got %d", len(cookies))
+		}
+		return cookies[0]
+	}
+	reply := func(cookie string) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetReply(resolve.QueryMsg("www.owasp.org", dns.TypeA))
+		m.SetEdns0(defaultEDNSBufferSize, false)
+		if cookie != "" {
+			m.IsEdns0().Option = append(m.IsEdns0().Option, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: cookie})
+		}
+		return m
+	}
+
+	if got := sent(addr); got != c.client {
+		t.Errorf("expected only the client cookie before the server responded, but got %s", got)
+	}
+	if !c.check(reply(c.client+server), addr) {
+		t.Errorf("the response echoing the client cookie was rejected")
+	}
+	if got := sent(addr); got != c.client+server {
+		t.Errorf("expected the cached server cookie to be sent, but got %s", got)
+	}
+	if got := sent(""); got != c.client {
+		t.Errorf("expected only the client cookie for the resolver pools, but got %s", got)
+	}
+	if !c.check(reply(""), addr) {
+		t.Errorf("the response without a cookie was rejected")
+	}
+	if c.check(reply("fedcba9876543210"+server), addr) {
+		t.Errorf("the response with the wrong client cookie was accepted")
+	}
+	if c.check(reply("abc"), addr) {
+		t.Errorf("the response with the malformed cookie was accepted")
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+// CSVColumns is the header of the rows written by ExportCSV, in the order of the columns:
+//   - name: the name within the scope
+//   - domain: the root domain of the name
+//   - record_type: the type of the DNS record, such as A, AAAA or CNAME
+//   - record_data: the address or the name provided by the record
+//   - source: the data source that discovered the name
+//   - tag: the technique of the data source, such as cert, api or brute
+//   - first_seen: the time the record was first seen, in RFC 3339 format
+var CSVColumns = []string{"name", "domain", "record_type", "record_data", "source", "tag", "first_seen"}
+
+// ExportCSV writes the names within the scope found since the enumeration started to w, with the
+// CSVColumns header and the RFC 4180 quoting. Each record of a name is written on its own row, and
+// the names without records are written on a row with empty record columns. The rows are written
+// as each name is read from the graph, instead of being collected first.
+func (e *Enumeration) ExportCSV(w io.Writer) error {
+	if e.graph == nil {
+		return errors.New("the enumeration does not have a graph")
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(CSVColumns); err != nil {
+		return err
+	}
+
+	since := e.Config.CollectionStartTime.UTC()
+	var scope []oam.Asset
+	for _, d := range e.Config.Domains() {
+		scope = append(scope, domain.FQDN{Name: d})
+	}
+	// the graph returns an error when no names are within the scope
+	names, _ := e.graph.DB.FindByScope(scope, since)
+	sort.Slice(names, func(i, j int) bool {
+		return stixKey(names[i]) < stixKey(names[j])
+	})
+
+	for _, a := range names {
+		fqdn, ok := a.Asset.(domain.FQDN)
+		if !ok || !e.Config.IsDomainInScope(fqdn.Name) {
+			continue
+		}
+
+		for _, row := range e.csvRows(a, fqdn.Name, since) {
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvRows returns the rows of the records stored for the name, ordered by type and data.
+func (e *Enumeration) csvRows(a *types.Asset, name string, since time.Time) [][]string {
+	var source, tag string
+	if history := e.SourceHistory(name); len(history) > 0 {
+		source = history[0]
+		tag = e.sourceTag(source)
+	}
+	row := func(rtype, data string, seen time.Time) []string {
+		return []string{name, e.Config.WhichDomain(name), rtype, data, source, tag, seen.UTC().Format(time.RFC3339)}
+	}
+
+	var rows [][]string
+	if rels, err := e.graph.DB.OutgoingRelations(a, since); err == nil {
+		for _, rel := range rels {
+			if !strings.HasSuffix(rel.Type, "_record") {
+				continue
+			}
+			if data := e.csvRecordData(rel.ToAsset, since); data != "" {
+				rtype := strings.ToUpper(strings.TrimSuffix(rel.Type, "_record"))
+				rows = append(rows, row(rtype, data, rel.CreatedAt))
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return [][]string{row("", "", a.CreatedAt)}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i][2] != rows[j][2] {
+			return rows[i][2] < rows[j][2]
+		}
+		return rows[i][3] < rows[j][3]
+	})
+	return rows
+}
+
+// csvRecordData returns the address or the name the record points to.
+func (e *Enumeration) csvRecordData(a *types.Asset, since time.Time) string {
+	if a != nil && a.Asset == nil {
+		// the relation only provides the identifier
+		found, err := e.graph.DB.FindById(a.ID, since)
+		if err != nil {
+			return ""
+		}
+		a = found
+	}
+	if a == nil {
+		return ""
+	}
+
+	switch v := a.Asset.(type) {
+	case domain.FQDN:
+		return v.Name
+	case network.IPAddress:
+		return v.Address.Unmap().String()
+	}
+	return ""
+}
+
+// sourceTag returns the technique of the data source, or an empty string when the source is unknown.
+func (e *Enumeration) sourceTag(source string) string {
+	if source == resolvingSource {
+		return requests.DNS
+	}
+
+	for _, src := range e.srcs {
+		if strings.EqualFold(src.String(), source) {
+			return src.Description()
+		}
+	}
+	return ""
+}
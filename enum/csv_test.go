@@ -0,0 +1,98 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/caffix/service"
+	"github.com/owasp-amass/config/config"
+)
+
+type taggedSource struct {
+	testSource
+	tag string
+}
+
+func (src *taggedSource) Description() string {
+	return src.tag
+}
+
+func TestExportCSV(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.CollectionStartTime = time.Now().Add(-time.Minute)
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	e := &Enumeration{Config: cfg}
+	if err := e.ExportCSV(&bytes.Buffer{}); err == nil {
+		t.Errorf("the export did not fail without a graph")
+	}
+
+	e.graph = g
+	src := &taggedSource{tag: "api"}
+	src.BaseService = *service.NewBaseService(src, "Asset Inventory")
+	e.srcs = []service.Service{src}
+	ctx := context.Background()
+	if err := g.UpsertA(ctx, "www.owasp.org", "192.0.2.1"); err != nil {
+		t.Fatalf("failed to insert the A record: %v", err)
+	}
+	if err := g.UpsertAAAA(ctx, "www.owasp.org", "2001:db8::1"); err != nil {
+		t.Fatalf("failed to insert the AAAA record: %v", err)
+	}
+	if err := g.UpsertCNAME(ctx, "docs.owasp.org", "docs.example.com"); err != nil {
+		t.Fatalf("failed to insert the CNAME record: %v", err)
+	}
+	if _, err := g.UpsertFQDN(ctx, "dev.owasp.org"); err != nil {
+		t.Fatalf("failed to insert the name: %v", err)
+	}
+	e.addSourceHistory("www.owasp.org", "Asset Inventory")
+	e.addSourceHistory("www.owasp.org", resolvingSource)
+	e.addSourceHistory("docs.owasp.org", `Archive, "Old"`)
+
+	var buf bytes.Buffer
+	if err := e.ExportCSV(&buf); err != nil {
+		t.Fatalf("failed to export the CSV: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"Archive, ""Old"""`) {
+		t.Errorf("the source with a comma and quotes was not quoted: %s", buf.String())
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("the export is not valid CSV: %v", err)
+	}
+	if len(rows) == 0 || !reflect.DeepEqual(rows[0], CSVColumns) {
+		t.Fatalf("the export does not start with the header: %v", rows)
+	}
+
+	var got [][]string
+	for _, row := range rows[1:] {
+		if _, err := time.Parse(time.RFC3339, row[6]); err != nil {
+			t.Errorf("the first seen time of %s is not valid: %v", row[0], err)
+		}
+		if row[0] != "owasp.org" {
+			got = append(got, row[:6])
+		}
+	}
+	expected := [][]string{
+		{"dev.owasp.org", "owasp.org", "", "", "", ""},
+		{"docs.owasp.org", "owasp.org", "CNAME", "docs.example.com", `Archive, "Old"`, ""},
+		{"www.owasp.org", "owasp.org", "A", "192.0.2.1", "Asset Inventory", "api"},
+		{"www.owasp.org", "owasp.org", "AAAA", "2001:db8::1", "Asset Inventory", "api"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected the rows %v, but got %v", expected, got)
+	}
+}
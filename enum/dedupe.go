@@ -0,0 +1,109 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	bf "github.com/tylertreat/BoomFilters"
+)
+
+const (
+	// defaultDedupeFilterCapacity is the number of cells in the bloom filter when DedupeFilterCapacity is not set
+	defaultDedupeFilterCapacity = 1000000
+	// defaultDedupeFalsePositiveRate is the false-positive rate of the bloom filter when DedupeFalsePositiveRate is not set
+	defaultDedupeFalsePositiveRate = 0.01
+)
+
+// The values of the DedupeFilterType setting.
+const (
+	DedupeFilterBloom = "bloom"
+	DedupeFilterExact = "exact"
+)
+
+// dedupeFilter remembers the strings already seen by a stage of the enumeration.
+type dedupeFilter interface {
+	// testAndAdd returns true when the string was seen before, and adds it otherwise
+	testAndAdd(s string) bool
+	reset()
+}
+
+// bloomFilter keeps the strings in a stable bloom filter, which has a bounded size since
+// the older strings are evicted as new strings are added.
+type bloomFilter struct {
+	sync.Mutex
+	filter *bf.StableBloomFilter
+}
+
+func (b *bloomFilter) testAndAdd(s string) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.filter.TestAndAdd([]byte(s))
+}
+
+func (b *bloomFilter) reset() {
+	b.Lock()
+	defer b.Unlock()
+
+	b.filter.Reset()
+}
+
+// exactFilter keeps every string seen, so no new string is skipped.
+type exactFilter struct {
+	sync.Mutex
+	seen map[string]struct{}
+}
+
+func (x *exactFilter) testAndAdd(s string) bool {
+	x.Lock()
+	defer x.Unlock()
+
+	if _, found := x.seen[s]; found {
+		return true
+	}
+	x.seen[s] = struct{}{}
+	return false
+}
+
+func (x *exactFilter) reset() {
+	x.Lock()
+	defer x.Unlock()
+
+	x.seen = make(map[string]struct{})
+}
+
+// checkDedupeSettings validates the DedupeFilterType, DedupeFilterCapacity and DedupeFalsePositiveRate settings.
+func (e *Enumeration) checkDedupeSettings() error {
+	switch t := strings.ToLower(strings.TrimSpace(e.DedupeFilterType)); t {
+	case "", DedupeFilterBloom, DedupeFilterExact:
+	default:
+		return fmt.Errorf("the dedupe filter type %s is not valid", e.DedupeFilterType)
+	}
+
+	if r := e.DedupeFalsePositiveRate; r < 0 || r >= 1 {
+		return fmt.Errorf("the dedupe false-positive rate must be between 0 and 1: %f", r)
+	}
+	return nil
+}
+
+// newDedupeFilter returns the filter selected by the DedupeFilterType setting.
+func (e *Enumeration) newDedupeFilter() dedupeFilter {
+	if strings.EqualFold(strings.TrimSpace(e.DedupeFilterType), DedupeFilterExact) {
+		return &exactFilter{seen: make(map[string]struct{})}
+	}
+
+	capacity := e.DedupeFilterCapacity
+	if capacity == 0 {
+		capacity = defaultDedupeFilterCapacity
+	}
+	rate := e.DedupeFalsePositiveRate
+	if rate == 0 {
+		rate = defaultDedupeFalsePositiveRate
+	}
+	return &bloomFilter{filter: bf.NewDefaultStableBloomFilter(capacity, rate)}
+}
@@ -0,0 +1,85 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestDedupeFilters(t *testing.T) {
+	for _, ftype := range []string{"", DedupeFilterBloom, DedupeFilterExact, "EXACT"} {
+		e := &Enumeration{DedupeFilterType: ftype}
+		if err := e.checkDedupeSettings(); err != nil {
+			t.Errorf("the filter type %q was rejected: %v", ftype, err)
+			continue
+		}
+
+		f := e.newDedupeFilter()
+		if f.testAndAdd("www.owasp.org") {
+			t.Errorf("%q: the new name was reported as seen", ftype)
+		}
+		if !f.testAndAdd("www.owasp.org") {
+			t.Errorf("%q: the name was not remembered", ftype)
+		}
+		if f.testAndAdd("mail.owasp.org") {
+			t.Errorf("%q: the other name was reported as seen", ftype)
+		}
+
+		f.reset()
+		if f.testAndAdd("www.owasp.org") {
+			t.Errorf("%q: the name was remembered after the reset", ftype)
+		}
+	}
+
+	if _, ok := (&Enumeration{DedupeFilterType: "exact"}).newDedupeFilter().(*exactFilter); !ok {
+		t.Errorf("the exact filter was not selected")
+	}
+	if _, ok := (&Enumeration{}).newDedupeFilter().(*bloomFilter); !ok {
+		t.Errorf("the bloom filter is not the default")
+	}
+}
+
+func TestCheckDedupeSettings(t *testing.T) {
+	for _, e := range []*Enumeration{
+		{DedupeFilterType: "cuckoo"},
+		{DedupeFalsePositiveRate: -0.1},
+		{DedupeFalsePositiveRate: 1},
+	} {
+		if err := e.checkDedupeSettings(); err == nil {
+			t.Errorf("the settings %q with the rate %f were accepted", e.DedupeFilterType, e.DedupeFalsePositiveRate)
+		}
+	}
+}
+
+// BenchmarkDedupeFilterMemory reports the heap retained by each filter after 10M names,
+// such as go test -run=NONE -bench=DedupeFilterMemory -benchtime=1x ./enum
+func BenchmarkDedupeFilterMemory(b *testing.B) {
+	const names = 10000000
+
+	for _, ftype := range []string{DedupeFilterBloom, DedupeFilterExact} {
+		b.Run(ftype, func(b *testing.B) {
+			var retained uint64
+
+			for i := 0; i < b.N; i++ {
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+
+				f := (&Enumeration{DedupeFilterType: ftype}).newDedupeFilter()
+				for n := 0; n < names; n++ {
+					f.testAndAdd("host" + strconv.Itoa(n) + ".owasp.org")
+				}
+
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				runtime.KeepAlive(f)
+				retained += after.HeapAlloc - before.HeapAlloc
+			}
+			b.ReportMetric(float64(retained)/float64(b.N)/(1<<20), "MB/filter")
+		})
+	}
+}
@@ -33,17 +33,30 @@ var FwdQueryTypes = []uint16{
 	dns.TypeAAAA,
 }
 
-var fwdQueryTypesLookup = map[uint16]int{dns.TypeCNAME: 0, dns.TypeA: 1, dns.TypeAAAA: 2}
+// DKIMSelectors are the common DKIM selectors queried under the _domainkey subdomain of the root domains.
+var DKIMSelectors = []string{
+	"default",
+	"dkim",
+	"google",
+	"k1",
+	"mail",
+	"s1",
+	"s2",
+	"selector1",
+	"selector2",
+}
 
 type req struct {
 	Ctx        context.Context
 	Data       pipeline.Data
 	Qtype      uint16
+	Types      []uint16
 	Attempts   int
 	Servfails  int
 	InScope    bool
 	Sent       bool
 	HasRecords bool
+	Wildcard   bool
 }
 
 // dnsTask is the task that handles all DNS name resolution requests within the pipeline.
@@ -54,12 +67,12 @@ type dnsTask struct {
 	trusted   bool
 	enum      *Enumeration
 	done      chan struct{}
-	pool      *resolve.Resolvers
 	params    pipeline.TaskParams
 	reqs      map[string]*req
 	resps     chan *dns.Msg
 	respQueue queue.Queue
 	release   chan struct{}
+	walked    map[string]struct{}
 }
 
 // newDNSTask returns a dNSTask specific to the provided Enumeration.
@@ -79,11 +92,11 @@ func newDNSTask(e *Enumeration, trusted bool) *dnsTask {
 		trusted:   trusted,
 		enum:      e,
 		done:      make(chan struct{}, 2),
-		pool:      pool,
 		reqs:      make(map[string]*req),
 		resps:     make(chan *dns.Msg, plen),
 		respQueue: queue.NewQueue(),
 		release:   make(chan struct{}, plen),
+		walked:    make(map[string]struct{}),
 	}
 
 	for i := 0; i < plen; i++ {
@@ -146,7 +159,10 @@ func (dt *dnsTask) rootTaskFunc() pipeline.TaskFunc {
 		}
 
 		if r != nil && dt.enum.Config.IsDomainInScope(r.Name) {
-			go dt.subdomainQueries(ctx, r, tp)
+			dt.enum.goResolve(ctx, func() { dt.subdomainQueries(ctx, r, tp) })
+			if dt.enum.NSECWalk {
+				dt.enum.goResolve(ctx, func() { dt.nsecWalk(ctx, r.Name, r.Domain) })
+			}
 		}
 		return data, nil
 	})
@@ -169,26 +185,52 @@ func (dt *dnsTask) Process(ctx context.Context, data pipeline.Data, tp pipeline.
 	})
 
 	if v, ok := data.(*requests.DNSRequest); ok {
-		qtype := FwdQueryTypes[0]
-		msg := resolve.QueryMsg(v.Name, qtype)
+		if dt.negativeCacheHit(ctx, v) {
+			return nil, nil
+		}
+
+		types := dt.enum.queryOrder()
+		qtype := types[0]
+		if dt.enum.TryANYFirst {
+			qtype = dns.TypeANY
+		}
+		msg := resolve.QueryMsg(dt.enum.queryName(v.Name), qtype)
 		k := key(msg.Id, msg.Question[0].Name)
 
 		if dt.addReqWithIncrement(k, &req{
 			Ctx:        ctx,
 			Data:       data.Clone(),
 			Qtype:      qtype,
+			Types:      types,
 			Attempts:   1,
 			HasRecords: len(v.Records) > 0,
 		}) {
-			dt.pool.Query(ctx, msg, dt.resps)
+			dt.query(ctx, msg)
 		} else {
-			dt.enum.Config.Log.Printf("Failed to enter %s into the request registry on the %s DNS task", msg.Question[0].Name, dt.trust)
+			dt.enum.log().Warnf("Failed to enter %s into the request registry on the %s DNS task", msg.Question[0].Name, dt.trust)
+			dt.enum.nameSrc.leaveFlight(v.Name)
 		}
 		return nil, nil
 	}
 	return data, nil
 }
 
+// negativeCacheHit returns true when the name without records received NXDOMAIN within the
+// NegativeCacheTTL, and finishes the request without sending the query.
+func (dt *dnsTask) negativeCacheHit(ctx context.Context, req *requests.DNSRequest) bool {
+	if dt.enum.negcache == nil || len(req.Records) > 0 || !dt.enum.negcache.has(req.Name) {
+		return false
+	}
+
+	dt.enum.log().Debugf("Negative cache: skipped the query for %s", req.Name)
+	if dt.enum.unresolvable(req) {
+		dt.sendUnresolved(ctx, req)
+	} else {
+		dt.enum.nameSrc.leaveFlight(req.Name)
+	}
+	return true
+}
+
 func (dt *dnsTask) nextStage(ctx context.Context, data pipeline.Data) {
 	dt.Lock()
 	params := dt.params
@@ -203,9 +245,39 @@ func (dt *dnsTask) nextStage(ctx context.Context, data pipeline.Data) {
 		stage = "store"
 	}
 
+	dt.lapTimings(data)
 	pipeline.SendData(ctx, stage, data, params)
 }
 
+// sendUnresolved skips the remaining DNS stages, so the name is stored without any records.
+func (dt *dnsTask) sendUnresolved(ctx context.Context, req *requests.DNSRequest) {
+	dt.Lock()
+	params := dt.params
+	dt.Unlock()
+
+	if params == nil {
+		dt.enum.nameSrc.leaveFlight(req.Name)
+		return
+	}
+
+	dt.lapTimings(req)
+	pipeline.SendData(ctx, "store", req, params)
+}
+
+// lapTimings ends the resolution or the validation of the name leaving the DNS task.
+func (dt *dnsTask) lapTimings(data pipeline.Data) {
+	req, ok := data.(*requests.DNSRequest)
+	if !ok {
+		return
+	}
+
+	if dt.trusted {
+		req.Timings.Validation = req.Timings.Lap()
+	} else {
+		req.Timings.Resolution = req.Timings.Lap()
+	}
+}
+
 func key(id uint16, name string) string {
 	return fmt.Sprintf("%d:%s", id, strings.ToLower(resolve.RemoveLastDot(name)))
 }
@@ -257,7 +329,18 @@ func (dt *dnsTask) delReqWithDecrement(key string) {
 		dt.release <- struct{}{}
 
 		if !req.Sent && (req.InScope || req.HasRecords) {
+			if v, ok := req.Data.(*requests.DNSRequest); ok && dt.trusted && req.HasRecords && dt.enum.authval != nil {
+				// delReqWithDecrement runs within the resolution workers, so goResolve cannot be used
+				dt.enum.goTracked(req.Ctx, func() { dt.validateAndSend(req.Ctx, v) })
+				return
+			}
 			dt.nextStage(req.Ctx, req.Data)
+		} else if v, ok := req.Data.(*requests.DNSRequest); ok && !req.Sent {
+			if !req.Wildcard && dt.enum.unresolvable(v) {
+				dt.sendUnresolved(req.Ctx, v)
+				return
+			}
+			dt.enum.nameSrc.leaveFlight(v.Name)
 		}
 	}
 }
@@ -282,13 +365,29 @@ func (dt *dnsTask) processResp(resp *dns.Msg) {
 
 	entry := dt.getReq(k)
 	if entry == nil {
-		dt.enum.Config.Log.Printf("Failed to find %s in the request registry on the %s DNS task", resp.Question[0].Name, dt.trust)
+		dt.enum.log().Warnf("Failed to find %s in the request registry on the %s DNS task", resp.Question[0].Name, dt.trust)
+		return
+	}
+
+	if resp.Truncated && !dt.enum.tcpOnly() {
+		dt.retryTruncated(entry.Ctx, resp)
 		return
 	}
+	// a response with the wrong client cookie may be spoofed, so the query is retried
+	if dt.enum.cookies != nil && !dt.enum.cookies.check(resp, "") {
+		resp.Rcode = dns.RcodeServerFailure
+	}
+	// the answers from the direct queries have already been checked by the exchange
+	if dt.enum.sinkhole != nil {
+		dt.enum.sinkhole.check(dt.trust+" pool", resp)
+	}
 
 	switch resp.Rcode {
 	// check if the response indicates that the name doesn't exist
 	case dns.RcodeNameError:
+		if v, ok := entry.Data.(*requests.DNSRequest); ok && dt.enum.negcache != nil {
+			dt.enum.negcache.add(v.Name)
+		}
 		dt.delReqWithDecrement(k)
 		return
 	// the rest are errors that should not continue across many resolvers
@@ -317,8 +416,13 @@ func (dt *dnsTask) processResp(resp *dns.Msg) {
 	case *requests.DNSRequest:
 		if resp.Rcode == dns.RcodeSuccess {
 			dt.processFwdRequest(ctx, resp, name, qtype, v, entry)
+		} else if qtype == dns.TypeANY {
+			// the servers refusing the ANY query would not answer the retries
+			dt.nextType(ctx, name, resp.Id, qtype, entry)
+		} else if dt.enum.rcodes != nil && dt.enum.rcodes.matches(resp.Rcode) {
+			dt.enum.goResolve(ctx, func() { dt.rcodeWildcardOrRetry(ctx, k, resp.Rcode, resp.Id, qtype, v, entry) })
 		} else {
-			go dt.retry(resolve.QueryMsg(v.Name, qtype), resp.Id, entry)
+			dt.enum.goResolve(ctx, func() { dt.retry(resolve.QueryMsg(dt.enum.queryName(v.Name), qtype), resp.Id, entry) })
 		}
 	default:
 		dt.delReqWithDecrement(k)
@@ -333,30 +437,59 @@ func (dt *dnsTask) retry(msg *dns.Msg, id uint16, entry *req) {
 		dt.delReq(k)
 		dt.addReq(key(msg.Id, msg.Question[0].Name), entry)
 		time.Sleep(resolve.TruncatedExponentialBackoff(entry.Attempts-1, initialBackoffDelay, maximumBackoffDelay))
-		dt.pool.Query(entry.Ctx, msg, dt.resps)
+		dt.query(entry.Ctx, msg)
 	} else {
-		dt.enum.Config.Log.Printf("%s was dropped after failing to resolve %d times on the %s DNS task", msg.Question[0].Name, entry.Attempts-1, dt.trust)
+		dt.enum.log().Debugf("%s was dropped after failing to resolve %d times on the %s DNS task", msg.Question[0].Name, entry.Attempts-1, dt.trust)
 		dt.delReqWithDecrement(k)
 	}
 }
 
+// rcodeWildcardOrRetry drops the name when the rcode matched a wildcard of its subdomain, since the
+// retries would receive the same rcode, and retries the query otherwise.
+func (dt *dnsTask) rcodeWildcardOrRetry(ctx context.Context, k string, rcode int, id, qtype uint16, req *requests.DNSRequest, entry *req) {
+	detected, override := dt.enum.wildcardOverride(req.Name)
+	if !override {
+		detected = dt.enum.rcodes.detected(ctx, req.Name, req.Domain, rcode)
+		if detected {
+			dt.enum.markWildcard(req.Name)
+		}
+	}
+	if detected {
+		entry.Wildcard = true
+		dt.delReqWithDecrement(k)
+		return
+	}
+	dt.retry(resolve.QueryMsg(dt.enum.queryName(req.Name), qtype), id, entry)
+}
+
 func (dt *dnsTask) nextType(ctx context.Context, name string, id, qtype uint16, entry *req) {
 	k := key(id, name)
 
-	if idx, found := fwdQueryTypesLookup[qtype]; found && idx+1 < len(FwdQueryTypes) {
+	next, found := nextTypeIn(entry.Types, qtype)
+	if qtype == dns.TypeANY {
+		// fall back to the individual queries for the forward types
+		next, found = entry.Types[0], true
+	}
+	if found {
 		entry.Attempts = 1
 		entry.Servfails = 0
-		entry.Qtype = FwdQueryTypes[idx+1]
+		entry.Qtype = next
 		msg := resolve.QueryMsg(name, entry.Qtype)
 		dt.delReq(k)
 		dt.addReq(key(msg.Id, msg.Question[0].Name), entry)
-		dt.pool.Query(ctx, msg, dt.resps)
+		dt.query(ctx, msg)
 	} else {
 		dt.delReqWithDecrement(k)
 	}
 }
 
 func (dt *dnsTask) processFwdRequest(ctx context.Context, resp *dns.Msg, name string, qtype uint16, req *requests.DNSRequest, entry *req) {
+	if dt.enum.NonRecursive && cacheReferral(resp) {
+		dt.enum.log().Debugf("The %s records for %s were not in the resolver cache", dns.TypeToString[qtype], name)
+		dt.nextType(ctx, name, resp.Id, qtype, entry)
+		return
+	}
+
 	ans := resolve.ExtractAnswers(resp)
 	if len(ans) == 0 {
 		dt.nextType(ctx, name, resp.Id, qtype, entry)
@@ -364,6 +497,16 @@ func (dt *dnsTask) processFwdRequest(ctx context.Context, resp *dns.Msg, name st
 	}
 
 	rr := resolve.AnswersByType(ans, qtype)
+	if qtype == dns.TypeANY {
+		rr = dt.enum.anyAnswers(resp)
+	} else if dt.enum.RandomizeQueryOrder && qtype != dns.TypeCNAME {
+		if aliases := aliasAnswers(ans, name); len(aliases) > 0 {
+			rr, qtype = aliases, dns.TypeCNAME
+		}
+	}
+	if qtype == dns.TypeCNAME && dt.enum.PreferCNAMEWithAddresses && len(rr) > 0 {
+		rr = append(rr, chainAddresses(ans, rr)...)
+	}
 	if len(rr) == 0 {
 		dt.nextType(ctx, name, resp.Id, qtype, entry)
 		return
@@ -378,14 +521,22 @@ func (dt *dnsTask) processFwdRequest(ctx context.Context, resp *dns.Msg, name st
 	}
 
 	if dt.enum.wildcardDetected(ctx, req, resp) {
+		entry.Wildcard = true
 		dt.delReqWithDecrement(k)
 		return
 	}
 
-	req.Records = append(req.Records, convertAnswers(rr)...)
+	req.Records = append(req.Records, attributeAnswers(dt.enum.collectAnswers(resp, rr), name, req.Name)...)
+	req.Records = dt.enum.appendSections(req.Records, resp)
+	if dt.enum.NonRecursive {
+		// the answers were served from the resolver cache
+		req.Tag = requests.DNS
+		req.Source = cacheSnoopSource
+		req.Active = true
+	}
 	entry.HasRecords = len(req.Records) > 0
 	// are there additional record types to query for?
-	if idx, found := fwdQueryTypesLookup[qtype]; found && qtype != dns.TypeCNAME && idx+1 < len(FwdQueryTypes) {
+	if _, found := nextTypeIn(entry.Types, qtype); found && qtype != dns.TypeCNAME {
 		dt.nextType(ctx, name, resp.Id, qtype, entry)
 		return
 	}
@@ -394,14 +545,44 @@ func (dt *dnsTask) processFwdRequest(ctx context.Context, resp *dns.Msg, name st
 }
 
 func (dt *dnsTask) subdomainQueries(ctx context.Context, req *requests.DNSRequest, tp pipeline.TaskParams) {
-	ch := make(chan []requests.DNSAnswer, 4)
+	queries := 5
+	ch := make(chan []requests.DNSAnswer, queries+5)
 
 	go dt.queryNS(ctx, req.Name, req.Domain, ch, tp)
 	go dt.queryMX(ctx, req.Name, ch, tp)
 	go dt.querySOA(ctx, req.Name, ch, tp)
 	go dt.querySPF(ctx, req.Name, ch, tp)
+	go dt.queryEmailPolicies(ctx, req.Name, req.Domain, ch)
+	if dt.enum.QueryCERT {
+		queries++
+		go dt.queryCERT(ctx, req.Name, ch)
+	}
+	if dt.enum.QueryLOC {
+		queries++
+		go dt.queryLOC(ctx, req.Name, ch)
+	}
+	if dt.enum.QueryHINFO {
+		queries++
+		go dt.queryHINFO(ctx, req.Name, ch)
+	}
+	if dt.enum.QueryAPL {
+		queries++
+		go dt.queryAPL(ctx, req.Name, ch)
+	}
+	if len(dt.enum.srvports) > 0 {
+		queries++
+		go dt.querySRVPorts(ctx, req.Name, ch)
+	}
+	if len(dt.enum.urisvcs) > 0 {
+		queries++
+		go dt.queryURI(ctx, req.Name, ch)
+	}
+	if len(dt.enum.mailkeys) > 0 {
+		queries++
+		go dt.queryEmailKeys(ctx, req.Name, ch)
+	}
 
-	for i := 0; i < 4; i++ {
+	for i := 0; i < queries; i++ {
 		if rr := <-ch; rr != nil {
 			req.Records = append(req.Records, rr...)
 		}
@@ -418,14 +599,29 @@ func (dt *dnsTask) queryNS(ctx context.Context, name, domain string, ch chan []r
 		if ans := resolve.ExtractAnswers(resp); len(ans) > 0 {
 			if rr := resolve.AnswersByType(ans, dns.TypeNS); len(rr) > 0 {
 				var records []requests.DNSAnswer
+				var nameservers []string
 
 				for _, record := range rr {
+					ns := record.Data
+					nameservers = append(nameservers, ns)
 					pipeline.SendData(ctx, "active", &requests.ZoneXFRRequest{
 						Name:   name,
 						Domain: domain,
 						Server: record.Data,
 					}, tp)
-					records = append(records, convertAnswers([]*resolve.ExtractedAnswer{record})...)
+					records = append(records, dt.enum.collectAnswers(resp, []*resolve.ExtractedAnswer{record})...)
+					if dt.enum.openres != nil {
+						dt.enum.goTracked(ctx, func() { dt.enum.openres.test(ctx, ns) })
+					}
+					if dt.enum.lame != nil {
+						dt.enum.goTracked(ctx, func() { dt.enum.lame.test(ctx, name, ns) })
+					}
+					if dt.enum.takeover != nil && dt.enum.Config.IsDomainInScope(name) {
+						dt.enum.goTracked(ctx, func() { dt.enum.takeover.check(ctx, name, "NS", ns) })
+					}
+				}
+				if dt.enum.CollectGlueRecords {
+					records = append(records, glueAnswers(resp, nameservers)...)
 				}
 
 				ch <- records
@@ -441,7 +637,7 @@ func (dt *dnsTask) queryMX(ctx context.Context, name string, ch chan []requests.
 	if resp, err := dt.enum.dnsQuery(ctx, name, dns.TypeMX, dt.enum.Sys.TrustedResolvers(), maxDNSQueryAttempts); err == nil {
 		if ans := resolve.ExtractAnswers(resp); len(ans) > 0 {
 			if rr := resolve.AnswersByType(ans, dns.TypeMX); len(rr) > 0 {
-				ch <- convertAnswers(rr)
+				ch <- dt.enum.collectAnswers(resp, rr)
 				return
 			}
 		}
@@ -454,14 +650,16 @@ func (dt *dnsTask) querySOA(ctx context.Context, name string, ch chan []requests
 	if resp, err := dt.enum.dnsQuery(ctx, name, dns.TypeSOA, dt.enum.Sys.TrustedResolvers(), maxDNSQueryAttempts); err == nil {
 		if ans := resolve.ExtractAnswers(resp); len(ans) > 0 {
 			if rr := resolve.AnswersByType(ans, dns.TypeSOA); len(rr) > 0 {
-				var records []requests.DNSAnswer
+				if dt.enum.soaser != nil && dt.enum.Config.IsDomainInScope(name) {
+					dt.enum.soaser.record(ctx, name, resp)
+				}
+				records := dt.enum.collectAnswers(resp, rr)
 
-				for _, a := range rr {
-					pieces := strings.Split(a.Data, ",")
-					a.Data = pieces[len(pieces)-1]
-					records = append(records, convertAnswers([]*resolve.ExtractedAnswer{a})...)
+				for i := range records {
+					requests.SanitizeDNSAnswer(&records[i])
 				}
 				ch <- records
+				return
 			}
 		}
 	}
@@ -473,7 +671,7 @@ func (dt *dnsTask) querySPF(ctx context.Context, name string, ch chan []requests
 	if resp, err := dt.enum.dnsQuery(ctx, name, dns.TypeSPF, dt.enum.Sys.TrustedResolvers(), maxDNSQueryAttempts); err == nil {
 		if ans := resolve.ExtractAnswers(resp); len(ans) > 0 {
 			if rr := resolve.AnswersByType(ans, dns.TypeSPF); len(rr) > 0 {
-				ch <- convertAnswers(rr)
+				ch <- dt.enum.collectAnswers(resp, rr)
 				return
 			}
 		}
@@ -481,6 +679,139 @@ func (dt *dnsTask) querySPF(ctx context.Context, name string, ch chan []requests
 	ch <- nil
 }
 
+// queryEmailPolicies obtains the SPF, DMARC and DKIM TXT records of the root domains.
+func (dt *dnsTask) queryEmailPolicies(ctx context.Context, name, domain string, ch chan []requests.DNSAnswer) {
+	if name != domain {
+		ch <- nil
+		return
+	}
+
+	names := []string{name, "_dmarc." + name}
+	for _, selector := range DKIMSelectors {
+		names = append(names, selector+"._domainkey."+name)
+	}
+
+	var records []requests.DNSAnswer
+	for _, n := range names {
+		if resp, err := dt.enum.dnsQuery(ctx, n, dns.TypeTXT, dt.enum.Sys.TrustedResolvers(), maxDNSQueryAttempts); err == nil && resp != nil {
+			for _, a := range dt.enum.collectAnswers(resp, resolve.AnswersByType(resolve.ExtractAnswers(resp), dns.TypeTXT)) {
+				if a.Classification != "" {
+					records = append(records, a)
+				}
+				if dt.enum.ExpandSPF && n == name && a.Classification == requests.SPF {
+					spf := a.Data
+					dt.enum.goTracked(ctx, func() { dt.enum.expandSPF(ctx, name, spf) })
+				}
+			}
+		}
+	}
+	ch <- records
+}
+
+// ResolveName performs the forward resolution of a single name using the same query types and
+// wildcard filtering as the enumeration pipeline, and returns the name with its records. The
+// queries are sent through the System resolvers, so the configured rate limits are respected.
+// The domain is selected from the configuration when empty, and the enumeration does not need
+// to be started. The name is also resolved from the regions of the VantageProxies when provided.
+func (e *Enumeration) ResolveName(ctx context.Context, name, domain string) (*requests.DNSRequest, error) {
+	req := &requests.DNSRequest{
+		Name:   name,
+		Domain: domain,
+		Tag:    requests.DNS,
+		Source: "DNS",
+	}
+	requests.SanitizeDNSRequest(req)
+	if req.Domain == "" {
+		req.Domain = e.Config.WhichDomain(req.Name)
+	}
+	if req.Domain == "" || !req.Valid() {
+		return nil, fmt.Errorf("%s is not a valid name within the scope", name)
+	}
+	if e.blacklisted(req.Name) || e.excludedName(req.Name) {
+		return nil, fmt.Errorf("%s has been excluded from the enumeration", req.Name)
+	}
+
+	types := e.queryOrder()
+	qname := e.queryName(req.Name)
+	if e.TryANYFirst {
+		if resp, err := e.anyQuery(ctx, qname); err == nil {
+			if rr := e.anyAnswers(resp); len(rr) > 0 {
+				if e.wildcardDetected(ctx, req, resp) {
+					return nil, fmt.Errorf("%s matched a DNS wildcard", req.Name)
+				}
+				// the individual queries are skipped
+				types = nil
+				req.Records = attributeAnswers(e.collectAnswers(resp, rr), qname, req.Name)
+			}
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	for _, qtype := range types {
+		resp, err := e.fwdQuery(ctx, qname, qtype)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			continue
+		}
+
+		ans := resolve.ExtractAnswers(resp)
+		rr := resolve.AnswersByType(ans, qtype)
+		if e.RandomizeQueryOrder && qtype != dns.TypeCNAME {
+			if aliases := aliasAnswers(ans, qname); len(aliases) > 0 {
+				rr, qtype = aliases, dns.TypeCNAME
+			}
+		}
+		if len(rr) == 0 {
+			continue
+		}
+		if e.wildcardDetected(ctx, req, resp) {
+			return nil, fmt.Errorf("%s matched a DNS wildcard", req.Name)
+		}
+		if qtype == dns.TypeCNAME && e.PreferCNAMEWithAddresses {
+			rr = append(rr, chainAddresses(ans, rr)...)
+		}
+
+		req.Records = append(req.Records, attributeAnswers(e.collectAnswers(resp, rr), qname, req.Name)...)
+		req.Records = e.appendSections(req.Records, resp)
+		if qtype == dns.TypeCNAME {
+			break
+		}
+	}
+
+	if len(req.Records) == 0 {
+		return nil, fmt.Errorf("%s did not resolve", req.Name)
+	}
+	if len(e.VantageProxies) > 0 {
+		vp := e.vantage
+		if vp == nil {
+			var err error
+			if vp, err = newVantagePoints(e); err != nil {
+				return nil, err
+			}
+		}
+		// the answers of each region are returned by RegionalAnswers
+		vp.check(ctx, req.Name)
+	}
+	return req, nil
+}
+
+// attributeAnswers attributes the records of a rewritten name to the original name.
+func attributeAnswers(answers []requests.DNSAnswer, qname, name string) []requests.DNSAnswer {
+	if qname == name {
+		return answers
+	}
+
+	for i := range answers {
+		if strings.EqualFold(resolve.RemoveLastDot(answers[i].Name), qname) {
+			answers[i].Name = name
+		}
+	}
+	return answers
+}
+
 func (e *Enumeration) fwdQuery(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
 	resp, err := e.dnsQuery(ctx, name, qtype, e.Sys.Resolvers(), maxDNSQueryAttempts)
 	if err != nil {
@@ -507,7 +838,7 @@ func (e *Enumeration) dnsQuery(ctx context.Context, name string, qtype uint16, r
 		default:
 		}
 
-		resp, err := r.QueryBlocking(ctx, msg)
+		resp, err := e.queryBlocking(ctx, msg, r)
 		if err != nil {
 			continue
 		}
@@ -524,19 +855,150 @@ func (e *Enumeration) dnsQuery(ctx context.Context, name string, qtype uint16, r
 	return nil, nil
 }
 
+// reverseDNSQuery returns a DNSRequest containing the PTR records obtained for the provided address.
+func (e *Enumeration) reverseDNSQuery(ctx context.Context, addr string) (*requests.DNSRequest, error) {
+	msg := resolve.ReverseMsg(addr)
+	if msg == nil {
+		return nil, fmt.Errorf("failed to create the reverse DNS query for %s", addr)
+	}
+	ptr := resolve.RemoveLastDot(msg.Question[0].Name)
+
+	resp, err := e.dnsQuery(ctx, ptr, dns.TypePTR, e.Sys.TrustedResolvers(), maxRcodeServerFails)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, fmt.Errorf("the reverse DNS query for %s failed", addr)
+	}
+	return e.ptrRequest(msg, resp)
+}
+
+// wildcardDetected returns true when the response matched a DNS wildcard. The names provided by the
+// user are kept regardless, unless the wildcard suffix lists say otherwise.
 func (e *Enumeration) wildcardDetected(ctx context.Context, req *requests.DNSRequest, resp *dns.Msg) bool {
-	return e.Sys.TrustedResolvers().WildcardDetected(ctx, resp, req.Domain)
+	if detected, override := e.wildcardOverride(req.Name); override {
+		return detected
+	}
+	if req.Tag == requests.EXTERNAL {
+		return false
+	}
+	if e.known != nil {
+		if detected, found := e.known.detected(resp, req.Domain); found {
+			if detected {
+				e.markWildcard(req.Name)
+			}
+			return detected
+		}
+	}
+	if (e.cnames != nil && e.cnames.detected(ctx, resp, req.Domain)) ||
+		e.Sys.TrustedResolvers().WildcardDetected(ctx, resp, req.Domain) {
+		e.markWildcard(req.Name)
+		return true
+	}
+	return false
 }
 
-func convertAnswers(ans []*resolve.ExtractedAnswer) []requests.DNSAnswer {
-	var answers []requests.DNSAnswer
+// wildcardOverride checks the name against the wildcard suffix lists, with the whitelist
+// taking precedence, and then the DomainSettings. The second return value is false when
+// neither list matched the name and its domain does not bypass the detection.
+func (e *Enumeration) wildcardOverride(name string) (bool, bool) {
+	if hasDomainSuffix(name, e.WildcardWhitelist) {
+		return false, true
+	}
+	if hasDomainSuffix(name, e.WildcardForceDynamic) {
+		return true, true
+	}
+	if dc := e.domainConfig(name); dc != nil && dc.DetectWildcards != nil && !*dc.DetectWildcards {
+		return false, true
+	}
+	return false, false
+}
+
+func hasDomainSuffix(name string, suffixes []string) bool {
+	name = strings.ToLower(resolve.RemoveLastDot(name))
 
+	for _, suffix := range suffixes {
+		suffix = strings.ToLower(strings.Trim(suffix, "."))
+
+		if suffix != "" && (name == suffix || strings.HasSuffix(name, "."+suffix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// convertAnswers carries the TTLs from the resp Answer section through to the returned answers,
+// and classifies the policies carried by the TXT records.
+func convertAnswers(resp *dns.Msg, ans []*resolve.ExtractedAnswer) []requests.DNSAnswer {
+	ttls := make(map[string]int)
+	if resp != nil {
+		for _, rr := range resp.Answer {
+			hdr := rr.Header()
+			ttls[ttlKey(hdr.Name, hdr.Rrtype)] = int(hdr.Ttl)
+		}
+	}
+
+	var answers []requests.DNSAnswer
 	for _, a := range ans {
-		answers = append(answers, requests.DNSAnswer{
+		answer := requests.DNSAnswer{
 			Name: a.Name,
 			Type: int(a.Type),
+			TTL:  ttls[ttlKey(a.Name, a.Type)],
 			Data: a.Data,
-		})
+		}
+		if a.Type == dns.TypeTXT || a.Type == dns.TypeSPF {
+			answer.Classification = requests.ClassifyTXT(a.Name, a.Data)
+		}
+		if a.Type == dns.TypeMX {
+			answer.Priority = mxPreference(resp, a.Name, a.Data)
+		}
+		answers = append(answers, answer)
+	}
+	return answers
+}
+
+// mxPreference returns the preference of the MX record of the name pointing at the target.
+func mxPreference(resp *dns.Msg, name, target string) int {
+	if resp == nil {
+		return 0
+	}
+
+	for _, rr := range resp.Answer {
+		if mx, ok := rr.(*dns.MX); ok && strings.EqualFold(resolve.RemoveLastDot(mx.Hdr.Name), name) &&
+			strings.EqualFold(resolve.RemoveLastDot(mx.Mx), resolve.RemoveLastDot(target)) {
+			return int(mx.Preference)
+		}
+	}
+	return 0
+}
+
+// collectAnswers converts the answers, drops those rejected by the RecordValidators, and truncates
+// the answer set to MaxAnswerBytes of data.
+func (e *Enumeration) collectAnswers(resp *dns.Msg, ans []*resolve.ExtractedAnswer) []requests.DNSAnswer {
+	return truncateAnswers(e.validAnswers(convertAnswers(resp, ans)), e.MaxAnswerBytes)
+}
+
+// truncateAnswers keeps the answers until their data reaches max bytes. The answer crossing the
+// limit is cut and flagged as truncated, and the answers after it are dropped. Zero means no limit.
+func truncateAnswers(answers []requests.DNSAnswer, max int) []requests.DNSAnswer {
+	if max <= 0 {
+		return answers
+	}
+
+	var size int
+	for i := range answers {
+		if size+len(answers[i].Data) <= max {
+			size += len(answers[i].Data)
+			continue
+		}
+
+		answers[i].Data = strings.ToValidUTF8(answers[i].Data[:max-size], "")
+		answers[i].Truncated = true
+		return answers[:i+1]
 	}
 	return answers
 }
+
+func ttlKey(name string, rrtype uint16) string {
+	return fmt.Sprintf("%d:%s", rrtype, strings.ToLower(resolve.RemoveLastDot(name)))
+}
@@ -0,0 +1,391 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+// startMockDNS returns the address of a DNS server answering the queries over TCP with the handler.
+// The server is shut down once the test and its subtests have completed.
+func startMockDNS(t testing.TB, handler dns.HandlerFunc) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on a TCP port: %v", err)
+	}
+
+	serveMockDNS(t, l, nil, handler)
+	return l.Addr().String()
+}
+
+// startMockUDPDNS returns the address of a DNS server answering the queries over UDP with the handler.
+// The server is shut down once the test and its subtests have completed.
+func startMockUDPDNS(t testing.TB, handler dns.HandlerFunc) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on a UDP port: %v", err)
+	}
+
+	serveMockDNS(t, nil, pc, handler)
+	return pc.LocalAddr().String()
+}
+
+// serveMockDNS answers the queries received by the listener, or the packet conn, with the handler.
+// The returned function shuts the server down before the test has completed.
+func serveMockDNS(t testing.TB, l net.Listener, pc net.PacketConn, handler dns.HandlerFunc) func() {
+	var start, shutdown sync.Once
+	started := make(chan struct{})
+	notify := func() { start.Do(func() { close(started) }) }
+	srv := &dns.Server{Listener: l, PacketConn: pc, Handler: handler, NotifyStartedFunc: notify}
+
+	go func() {
+		_ = srv.ActivateAndServe()
+		notify()
+	}()
+	<-started
+
+	stop := func() { shutdown.Do(func() { _ = srv.Shutdown() }) }
+	t.Cleanup(stop)
+	return stop
+}
+
+func TestConvertAnswersTTL(t *testing.T) {
+	resp := resolve.QueryMsg("www.owasp.org", dns.TypeA)
+	resp.Answer = append(resp.Answer,
+		&dns.CNAME{
+			Hdr:    dns.RR_Header{Name: "www.owasp.org.", Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 300},
+			Target: "owasp.org.",
+		},
+		&dns.A{
+			Hdr: dns.RR_Header{Name: "owasp.org.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 30},
+			A:   net.ParseIP("104.22.27.77"),
+		},
+	)
+
+	ans := convertAnswers(resp, resolve.ExtractAnswers(resp))
+	if len(ans) != 2 {
+		t.Fatalf("expected 2 answers, got %d", len(ans))
+	}
+
+	expected := map[string]int{"owasp.org": 300, "104.22.27.77": 30}
+	for _, a := range ans {
+		if ttl := expected[a.Data]; a.TTL != ttl {
+			t.Errorf("%s answer %s had a TTL of %d, expected %d", dns.TypeToString[uint16(a.Type)], a.Data, a.TTL, ttl)
+		}
+	}
+}
+
+func TestMaxAnswerBytes(t *testing.T) {
+	resp := resolve.QueryMsg("owasp.org", dns.TypeTXT)
+	resp.Answer = append(resp.Answer,
+		&dns.TXT{
+			Hdr: dns.RR_Header{Name: "owasp.org.", Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 300},
+			Txt: []string{"v=spf1 -all"},
+		},
+		&dns.TXT{
+			Hdr: dns.RR_Header{Name: "owasp.org.", Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 300},
+			Txt: []string{strings.Repeat("a", 255), strings.Repeat("b", 255), strings.Repeat("c", 255)},
+		},
+		&dns.TXT{
+			Hdr: dns.RR_Header{Name: "owasp.org.", Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 300},
+			Txt: []string{"google-site-verification=abc"},
+		},
+	)
+	ans := resolve.ExtractAnswers(resp)
+
+	e := &Enumeration{}
+	if got := e.collectAnswers(resp, ans); len(got) != 3 {
+		t.Fatalf("expected 3 answers without a limit, got %d", len(got))
+	}
+
+	e.MaxAnswerBytes = 100
+	got := e.collectAnswers(resp, ans)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 answers with the limit, got %d", len(got))
+	}
+	if got[0].Truncated || got[0].Data != "v=spf1 -all" {
+		t.Errorf("the first answer should not have been truncated: %v", got[0])
+	}
+	if !got[1].Truncated {
+		t.Errorf("the oversized answer was not flagged as truncated")
+	}
+	if size := len(got[0].Data) + len(got[1].Data); size != e.MaxAnswerBytes {
+		t.Errorf("the answer set had %d bytes of data, expected %d", size, e.MaxAnswerBytes)
+	}
+}
+
+func TestWildcardOverride(t *testing.T) {
+	e := &Enumeration{
+		WildcardWhitelist:    []string{"cdn.owasp.org"},
+		WildcardForceDynamic: []string{"owasp.org", "example.com."},
+	}
+
+	tests := []struct {
+		name     string
+		detected bool
+		override bool
+	}{
+		{"www.cdn.owasp.org", false, true},
+		{"cdn.owasp.org", false, true},
+		{"www.owasp.org", true, true},
+		{"WWW.EXAMPLE.COM.", true, true},
+		{"notexample.com", false, false},
+		{"owasp.net", false, false},
+	}
+
+	for _, test := range tests {
+		if detected, override := e.wildcardOverride(test.name); detected != test.detected || override != test.override {
+			t.Errorf("%s returned (%t, %t), expected (%t, %t)", test.name, detected, override, test.detected, test.override)
+		}
+	}
+}
+
+func TestEnrichmentQueryTypes(t *testing.T) {
+	e := &Enumeration{ResolveOnly: true, EnrichmentTypes: []string{"mx", " TXT "}}
+	if err := e.setQueryTypes(); err != nil {
+		t.Fatalf("failed to set the query types: %v", err)
+	}
+	if len(e.fwdTypes) != 2 || e.fwdTypes[0] != dns.TypeMX {
+		t.Fatalf("expected MX and TXT query types, got %v", e.fwdTypes)
+	}
+	if next, found := e.nextQueryType(dns.TypeMX); !found || next != dns.TypeTXT {
+		t.Errorf("expected TXT to follow MX, got %d", next)
+	}
+	if _, found := e.nextQueryType(dns.TypeTXT); found {
+		t.Errorf("expected no query type to follow TXT")
+	}
+
+	e.EnrichmentTypes = []string{"BOGUS"}
+	if err := e.setQueryTypes(); err == nil {
+		t.Errorf("expected an error for an invalid record type")
+	}
+
+	e.ResolveOnly = false
+	if err := e.setQueryTypes(); err != nil || len(e.fwdTypes) != len(FwdQueryTypes) {
+		t.Errorf("expected the forward query types without ResolveOnly")
+	}
+}
+
+func TestQueryEmailPolicies(t *testing.T) {
+	zone := map[string][]string{
+		"owasp.org.":                      {"v=spf1 include:_spf.google.com -all", "google-site-verification=abc123"},
+		"_dmarc.owasp.org.":               {"v=DMARC1; p=reject"},
+		"selector1._domainkey.owasp.org.": {"v=DKIM1; k=rsa; p=MIGfMA0"},
+	}
+
+	addr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		if txt, found := zone[strings.ToLower(req.Question[0].Name)]; found {
+			for _, data := range txt {
+				m.Answer = append(m.Answer, &dns.TXT{
+					Hdr: dns.RR_Header{Name: req.Question[0].Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 300},
+					Txt: []string{data},
+				})
+			}
+		} else {
+			m.Rcode = dns.RcodeNameError
+		}
+		_ = w.WriteMsg(m)
+	})
+
+	cfg := config.NewConfig()
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP: true,
+	}
+	defer e.Sys.Resolvers().Stop()
+	dt := &dnsTask{enum: e}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ch := make(chan []requests.DNSAnswer, 1)
+	dt.queryEmailPolicies(ctx, "www.owasp.org", "owasp.org", ch)
+	if rr := <-ch; rr != nil {
+		t.Errorf("the policies were queried for a name that is not a root domain")
+	}
+
+	dt.queryEmailPolicies(ctx, "owasp.org", "owasp.org", ch)
+	got := make(map[string]string)
+	for _, a := range <-ch {
+		got[a.Classification] = strings.ToLower(resolve.RemoveLastDot(a.Name))
+	}
+
+	expected := map[string]string{
+		requests.SPF:   "owasp.org",
+		requests.DMARC: "_dmarc.owasp.org",
+		requests.DKIM:  "selector1._domainkey.owasp.org",
+	}
+	if len(got) != len(expected) {
+		t.Errorf("expected %d classified records, but got %d", len(expected), len(got))
+	}
+	for class, name := range expected {
+		if got[class] != name {
+			t.Errorf("expected the %s record at %s, but got %q", class, name, got[class])
+		}
+	}
+}
+
+func TestResolveName(t *testing.T) {
+	addr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		q := req.Question[0]
+		switch name := strings.ToLower(q.Name); {
+		case name == "www.owasp.org." && q.Qtype == dns.TypeA:
+			m.Answer = append(m.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+				A:   net.ParseIP("192.0.2.1"),
+			})
+		case name != "www.owasp.org.":
+			m.Rcode = dns.RcodeNameError
+		}
+		_ = w.WriteMsg(m)
+	})
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.Resolvers = []string{addr}
+	// the queries sent without the pools are paced to the QPS of the resolvers
+	cfg.ResolversQPS, cfg.TrustedQPS = 1000, 1000
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP: true,
+	}
+	defer e.Sys.Resolvers().Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := e.ResolveName(ctx, "WWW.owasp.org", "")
+	if err != nil {
+		t.Fatalf("failed to resolve the name: %v", err)
+	}
+	if req.Name != "www.owasp.org" || req.Domain != "owasp.org" {
+		t.Errorf("the name %s in domain %s was not expected", req.Name, req.Domain)
+	}
+	if len(req.Records) != 1 || req.Records[0].Data != "192.0.2.1" {
+		t.Errorf("unexpected records: %v", req.Records)
+	}
+
+	if _, err := e.ResolveName(ctx, "nx.owasp.org", "owasp.org"); err == nil {
+		t.Errorf("the name that does not exist was resolved")
+	}
+	if _, err := e.ResolveName(ctx, "www.example.com", ""); err == nil {
+		t.Errorf("the out of scope name was resolved")
+	}
+}
+
+func TestTryANYFirst(t *testing.T) {
+	var individual int32
+	addr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		q := req.Question[0]
+		a := &dns.A{
+			Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+			A:   net.ParseIP("192.0.2.1"),
+		}
+		if q.Qtype != dns.TypeANY {
+			atomic.AddInt32(&individual, 1)
+			if q.Qtype == dns.TypeA {
+				m.Answer = append(m.Answer, a)
+			}
+			_ = w.WriteMsg(m)
+			return
+		}
+
+		switch strings.ToLower(q.Name) {
+		case "any.owasp.org.":
+			m.Answer = append(m.Answer, a, &dns.AAAA{
+				Hdr:  dns.RR_Header{Name: q.Name, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: 300},
+				AAAA: net.ParseIP("2001:db8::1"),
+			}, &dns.TXT{
+				Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 300},
+				Txt: []string{"v=spf1 -all"},
+			})
+		case "minimal.owasp.org.":
+			m.Answer = append(m.Answer, &dns.HINFO{
+				Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeHINFO, Class: dns.ClassINET, Ttl: 300},
+				Cpu: "RFC8482",
+			})
+		default:
+			m.Rcode = dns.RcodeRefused
+		}
+		_ = w.WriteMsg(m)
+	})
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.Resolvers = []string{addr}
+	// the queries sent without the pools are paced to the QPS of the resolvers
+	cfg.ResolversQPS, cfg.TrustedQPS = 1000, 1000
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:      cfg,
+		Sys:         &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP:    true,
+		TryANYFirst: true,
+	}
+	defer e.Sys.Resolvers().Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := e.ResolveName(ctx, "any.owasp.org", "")
+	if err != nil {
+		t.Fatalf("failed to resolve the name: %v", err)
+	}
+	if len(req.Records) != 2 {
+		t.Errorf("expected the A and AAAA records, but got %v", req.Records)
+	}
+	if n := atomic.LoadInt32(&individual); n != 0 {
+		t.Errorf("%d individual queries were sent after the useful ANY response", n)
+	}
+
+	// the minimal and refused ANY responses fall back to the individual queries
+	for _, name := range []string{"minimal.owasp.org", "refused.owasp.org"} {
+		atomic.StoreInt32(&individual, 0)
+
+		req, err := e.ResolveName(ctx, name, "")
+		if err != nil {
+			t.Fatalf("failed to resolve %s: %v", name, err)
+		}
+		if len(req.Records) != 1 || req.Records[0].Data != "192.0.2.1" {
+			t.Errorf("%s: unexpected records: %v", name, req.Records)
+		}
+		if n := atomic.LoadInt32(&individual); n == 0 {
+			t.Errorf("%s: the individual queries were not sent", name)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/datasrcs/scripting"
+	"github.com/owasp-amass/resolve"
+)
+
+// DomainConfig overrides the settings of the enumeration for the names within a domain,
+// and the fields left nil fall back to the global settings.
+type DomainConfig struct {
+	// Active allows or prevents the active techniques for the domain, such as the zone transfers,
+	// zone walking and crawling performed by the scripts
+	Active *bool
+	// IncludeUnresolvable overrides the IncludeUnresolvable setting of the enumeration
+	IncludeUnresolvable *bool
+	// DetectWildcards set to false bypasses the DNS wildcard detection for the domain, while
+	// the WildcardWhitelist and WildcardForceDynamic suffixes still take precedence
+	DetectWildcards *bool
+}
+
+// checkDomainSettings requires each domain with settings to be within the configured scope,
+// and provides the active overrides to the scripts.
+func (e *Enumeration) checkDomainSettings() error {
+	active := make(map[string]bool)
+	for domain, dc := range e.DomainSettings {
+		d := strings.ToLower(resolve.RemoveLastDot(strings.TrimSpace(domain)))
+		if !e.Config.IsDomainInScope(d) {
+			return fmt.Errorf("the domain settings for %s are outside of the scope", domain)
+		}
+		if dc != nil && dc.Active != nil {
+			active[d] = *dc.Active
+		}
+	}
+
+	scripting.SetDomainActive(active)
+	return nil
+}
+
+// domainConfig returns the settings of the most specific domain containing the name, or nil.
+func (e *Enumeration) domainConfig(name string) *DomainConfig {
+	if len(e.DomainSettings) == 0 {
+		return nil
+	}
+
+	var best string
+	var dc *DomainConfig
+	name = strings.ToLower(resolve.RemoveLastDot(name))
+	for domain, c := range e.DomainSettings {
+		d := strings.ToLower(resolve.RemoveLastDot(strings.TrimSpace(domain)))
+		if c == nil || len(d) <= len(best) {
+			continue
+		}
+		if name == d || strings.HasSuffix(name, "."+d) {
+			best = d
+			dc = c
+		}
+	}
+	return dc
+}
+
+// includeUnresolvable returns the IncludeUnresolvable setting for the domain of the name.
+func (e *Enumeration) includeUnresolvable(name string) bool {
+	if dc := e.domainConfig(name); dc != nil && dc.IncludeUnresolvable != nil {
+		return *dc.IncludeUnresolvable
+	}
+	return e.IncludeUnresolvable
+}
@@ -0,0 +1,58 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/datasrcs/scripting"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestDomainSettings(t *testing.T) {
+	defer scripting.SetDomainActive(nil)
+
+	yes, no := true, false
+	cfg := config.NewConfig()
+	cfg.AddDomains("owasp.org", "example.com")
+
+	e := &Enumeration{
+		Config:              cfg,
+		IncludeUnresolvable: true,
+		DomainSettings: map[string]*DomainConfig{
+			"owasp.org":     {IncludeUnresolvable: &no, DetectWildcards: &no},
+			"dev.owasp.org": {IncludeUnresolvable: &yes},
+			"example.com":   {Active: &yes},
+		},
+	}
+	if err := e.checkDomainSettings(); err != nil {
+		t.Fatalf("the domain settings were rejected: %v", err)
+	}
+
+	for _, test := range []struct {
+		name         string
+		unresolvable bool
+		wildcards    bool
+	}{
+		{"www.owasp.org", false, false},
+		// the most specific domain applies, and dev.owasp.org keeps the wildcard detection
+		{"api.dev.owasp.org", true, true},
+		{"www.example.com", true, true},
+	} {
+		req := &requests.DNSRequest{Name: test.name}
+		if got := e.unresolvable(req); got != test.unresolvable {
+			t.Errorf("%s: expected the unresolvable setting %t, but got %t", test.name, test.unresolvable, got)
+		}
+		if _, override := e.wildcardOverride(test.name); override == test.wildcards {
+			t.Errorf("%s: expected the wildcard detection %t, but got %t", test.name, test.wildcards, !override)
+		}
+	}
+
+	e.DomainSettings["example.net"] = &DomainConfig{Active: &no}
+	if err := e.checkDomainSettings(); err == nil {
+		t.Errorf("the settings for a domain outside of the scope were accepted")
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+// maxEmailLocalParts is the number of local parts looked up for the keys under each subdomain.
+const maxEmailLocalParts = 32
+
+// emailKeyLabel is a local part and the label of the names holding its keys.
+type emailKeyLabel struct {
+	local string
+	label string
+}
+
+// emailLocalPartLabel returns the SHA2-256 digest of the local part truncated to 28 octets, in hexadecimal,
+// which is the left-most label of the OPENPGPKEY and SMIMEA record names in RFC 7929 and RFC 8162.
+func emailLocalPartLabel(local string) string {
+	sum := sha256.Sum256([]byte(local))
+	return hex.EncodeToString(sum[:28])
+}
+
+// buildEmailKeyLabels prepares the hashed labels of the EmailLocalParts, skipping the duplicates.
+func (e *Enumeration) buildEmailKeyLabels() error {
+	seen := make(map[string]struct{})
+
+	e.mailkeys = nil
+	for _, local := range e.EmailLocalParts {
+		local = strings.TrimSpace(local)
+		if local == "" || strings.Contains(local, "@") {
+			return fmt.Errorf("the email local part %q is not valid", local)
+		}
+		if _, found := seen[local]; found {
+			continue
+		}
+
+		seen[local] = struct{}{}
+		e.mailkeys = append(e.mailkeys, emailKeyLabel{local: local, label: emailLocalPartLabel(local)})
+	}
+
+	if len(e.mailkeys) > maxEmailLocalParts {
+		e.log().Warnf("Email keys: only the first %d of %d local parts are queried for each subdomain", maxEmailLocalParts, len(e.mailkeys))
+		e.mailkeys = e.mailkeys[:maxEmailLocalParts]
+	}
+	return nil
+}
+
+// queryEmailKeys obtains the OPENPGPKEY and SMIMEA records published for the local parts under the
+// subdomain, which are not extracted by the resolve package.
+func (dt *dnsTask) queryEmailKeys(ctx context.Context, name string, ch chan []requests.DNSAnswer) {
+	var records []requests.DNSAnswer
+
+	for _, k := range dt.enum.mailkeys {
+		for _, q := range []struct {
+			prefix string
+			qtype  uint16
+		}{
+			{prefix: "._openpgpkey.", qtype: dns.TypeOPENPGPKEY},
+			{prefix: "._smimecert.", qtype: dns.TypeSMIMEA},
+		} {
+			select {
+			case <-ctx.Done():
+				ch <- records
+				return
+			default:
+			}
+
+			resp, err := dt.enum.dnsQuery(ctx, k.label+q.prefix+name, q.qtype, dt.enum.Sys.TrustedResolvers(), maxDNSQueryAttempts)
+			if err != nil {
+				continue
+			}
+			if rr := emailKeyAnswers(resp, k.local); len(rr) > 0 {
+				records = append(records, truncateAnswers(rr, dt.enum.MaxAnswerBytes)...)
+			}
+		}
+	}
+	ch <- records
+}
+
+// emailKeyAnswers converts the OPENPGPKEY and SMIMEA records in the answer section, and decodes the
+// keys published for the local part. The records with a key that cannot be decoded are dropped.
+func emailKeyAnswers(resp *dns.Msg, local string) []requests.DNSAnswer {
+	if resp == nil {
+		return nil
+	}
+
+	var answers []requests.DNSAnswer
+	for _, rr := range resp.Answer {
+		switch v := rr.(type) {
+		case *dns.OPENPGPKEY:
+			key, err := base64.StdEncoding.DecodeString(v.PublicKey)
+			if err != nil {
+				continue
+			}
+
+			answers = append(answers, requests.DNSAnswer{
+				Name: resolve.RemoveLastDot(v.Hdr.Name),
+				Type: int(dns.TypeOPENPGPKEY),
+				TTL:  int(v.Hdr.Ttl),
+				Data: v.PublicKey,
+				OpenPGPKey: &requests.OpenPGPKeyData{
+					LocalPart: local,
+					PublicKey: key,
+				},
+			})
+		case *dns.SMIMEA:
+			cert, err := hex.DecodeString(v.Certificate)
+			if err != nil {
+				continue
+			}
+
+			answers = append(answers, requests.DNSAnswer{
+				Name: resolve.RemoveLastDot(v.Hdr.Name),
+				Type: int(dns.TypeSMIMEA),
+				TTL:  int(v.Hdr.Ttl),
+				Data: fmt.Sprintf("%d %d %d %s", v.Usage, v.Selector, v.MatchingType, strings.ToLower(v.Certificate)),
+				SMIMEA: &requests.SMIMEAData{
+					LocalPart:    local,
+					Usage:        v.Usage,
+					Selector:     v.Selector,
+					MatchingType: v.MatchingType,
+					Certificate:  cert,
+				},
+			})
+		}
+	}
+	return answers
+}
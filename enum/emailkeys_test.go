@@ -0,0 +1,87 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestEmailLocalPartLabel(t *testing.T) {
+	// the example of RFC 7929, section 3
+	if label := emailLocalPartLabel("hugh"); label != "c93f1e400f26708f98cb19d936620da35eec8f72e57f9eec01c1afd6" {
+		t.Errorf("unexpected label for the local part: %s", label)
+	}
+	if name, _ := dns.SMIMEAName("hugh", "owasp.org."); name != emailLocalPartLabel("hugh")+"._smimecert.owasp.org." {
+		t.Errorf("the label does not match the SMIMEA name %s", name)
+	}
+
+	e := &Enumeration{Config: config.NewConfig(), EmailLocalParts: []string{"security", " jdoe ", "security"}}
+	if err := e.buildEmailKeyLabels(); err != nil {
+		t.Fatalf("the local parts were rejected: %v", err)
+	}
+	if len(e.mailkeys) != 2 || e.mailkeys[1].local != "jdoe" || e.mailkeys[1].label != emailLocalPartLabel("jdoe") {
+		t.Errorf("unexpected labels for the local parts: %v", e.mailkeys)
+	}
+
+	e.EmailLocalParts = []string{"jdoe@owasp.org"}
+	if err := e.buildEmailKeyLabels(); err == nil {
+		t.Errorf("a full email address was accepted as a local part")
+	}
+}
+
+func TestEmailKeyAnswers(t *testing.T) {
+	key := []byte{0x99, 0x01, 0x0d, 0x04}
+	cert := []byte{0x30, 0x82, 0x01, 0x0a}
+	name := emailLocalPartLabel("hugh") + "._openpgpkey.owasp.org."
+
+	resp := new(dns.Msg)
+	resp.Answer = []dns.RR{
+		&dns.OPENPGPKEY{
+			Hdr:       dns.RR_Header{Name: name, Rrtype: dns.TypeOPENPGPKEY, Class: dns.ClassINET, Ttl: 300},
+			PublicKey: base64.StdEncoding.EncodeToString(key),
+		},
+		&dns.OPENPGPKEY{
+			Hdr:       dns.RR_Header{Name: name, Rrtype: dns.TypeOPENPGPKEY, Class: dns.ClassINET, Ttl: 300},
+			PublicKey: "not base64!",
+		},
+		&dns.SMIMEA{
+			Hdr:          dns.RR_Header{Name: name, Rrtype: dns.TypeSMIMEA, Class: dns.ClassINET, Ttl: 600},
+			Usage:        3,
+			Selector:     1,
+			MatchingType: 0,
+			Certificate:  "3082010A",
+		},
+		&dns.A{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300}},
+	}
+
+	answers := emailKeyAnswers(resp, "hugh")
+	if len(answers) != 2 {
+		t.Fatalf("expected 2 key answers, but got %d", len(answers))
+	}
+
+	a := answers[0]
+	if a.Type != int(dns.TypeOPENPGPKEY) || a.TTL != 300 || a.Name != emailLocalPartLabel("hugh")+"._openpgpkey.owasp.org" {
+		t.Errorf("unexpected OPENPGPKEY answer: %+v", a)
+	}
+	if k := a.OpenPGPKey; k == nil || k.LocalPart != "hugh" || !bytes.Equal(k.PublicKey, key) {
+		t.Errorf("the OPENPGPKEY record was not decoded: %+v", k)
+	}
+
+	a = answers[1]
+	if a.Type != int(dns.TypeSMIMEA) || a.Data != "3 1 0 3082010a" {
+		t.Errorf("unexpected SMIMEA answer: %+v", a)
+	}
+	if s := a.SMIMEA; s == nil || s.LocalPart != "hugh" || s.Usage != 3 || s.Selector != 1 || !bytes.Equal(s.Certificate, cert) {
+		t.Errorf("the SMIMEA record was not decoded: %+v", s)
+	}
+	if emailKeyAnswers(nil, "hugh") != nil {
+		t.Errorf("answers were returned without a response")
+	}
+}
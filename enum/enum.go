@@ -6,26 +6,546 @@ package enum
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/caffix/netmap"
 	"github.com/caffix/pipeline"
 	"github.com/caffix/queue"
 	"github.com/caffix/service"
+	"github.com/miekg/dns"
 	"github.com/owasp-amass/amass/v4/datasrcs"
+	amassnet "github.com/owasp-amass/amass/v4/net"
 	"github.com/owasp-amass/amass/v4/requests"
 	"github.com/owasp-amass/amass/v4/systems"
 	"github.com/owasp-amass/config/config"
 	oam "github.com/owasp-amass/open-asset-model"
 	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/resolve"
+	"golang.org/x/net/proxy"
 )
 
+// defaultPipelineBufferSize is the number of data elements buffered between the pipeline stages.
+const defaultPipelineBufferSize = 50
+
+// defaultEDNSBufferSize is the advertised EDNS0 UDP buffer size recommended by DNS Flag Day 2020.
+const defaultEDNSBufferSize = 1232
+
+// sourceCooldown is the period a rate limited data source does not receive requests.
+const sourceCooldown = time.Minute
+
+// ProvidedName is a DNS name provided to seed the enumeration along with its provenance.
+type ProvidedName struct {
+	Name   string
+	Domain string
+	Tag    string
+	Source string
+}
+
+// Blacklister determines if a DNS name should be excluded from the enumeration.
+type Blacklister interface {
+	Blacklisted(name string) bool
+}
+
 // Enumeration is the object type used to execute a DNS enumeration.
 type Enumeration struct {
-	Config   *config.Config
-	Sys      systems.System
+	Config *config.Config
+	Sys    systems.System
+	// Blacklist is consulted for each name before it is processed,
+	// and defaults to the blacklist maintained by the Config
+	Blacklist Blacklister
+	// MinTTLFlag causes DNS answers with a TTL below the threshold, in seconds, to be flagged with
+	// LowTTL in the output records, and returned by LowTTLAnswers
+	MinTTLFlag int
+	// Names under the WildcardWhitelist domain suffixes bypass DNS wildcard detection, while
+	// names under the WildcardForceDynamic suffixes are always treated as dynamic wildcards.
+	// The WildcardWhitelist takes precedence when a name matches suffixes in both lists
+	WildcardWhitelist    []string
+	WildcardForceDynamic []string
+	// WildcardDetectRCODEs lists the rcodes, such as SERVFAIL, counting toward the wildcard detection.
+	// When the unlikely names within a subdomain consistently receive one of them while the subdomain
+	// resolves, the names receiving them within the subdomain are treated as wildcard matches
+	WildcardDetectRCODEs []string
+	// KnownWildcards maps the domains and subdomains with a known wildcard behavior to their wildcard
+	// answers, as IP addresses, so the names below them are checked against these answers without
+	// the probe queries of the wildcard detection. An empty list marks a subdomain without a wildcard.
+	// The names below none of these subdomains fall back to the live detection
+	KnownWildcards map[string][]string
+	// When both resolvers are provided, each stored name is also resolved using the Internal and
+	// Public resolver, and the names with differing answer sets are returned by SplitHorizonFindings
+	SplitHorizonResolvers struct {
+		Internal string
+		Public   string
+	}
+	// VantageProxies maps region names to SOCKS5 proxies, and each stored name is also resolved
+	// through the proxy of each region. The names with answers that differ across the regions
+	// are flagged, and the answers of each region are returned by RegionalAnswers
+	VantageProxies map[string]string
+	// AutoExpandScope adds the apex domains outside of the scope, discovered through the PTR records,
+	// the CNAME targets and the provided certificates, to the scope when allowed by the policy, which
+	// is ScopeExpansionSibling or ScopeExpansionSuffix. An empty value disables the expansion
+	AutoExpandScope string
+	// ScopeExpansionSuffixes are the suffixes of the apex domains added by the ScopeExpansionSuffix policy
+	ScopeExpansionSuffixes []string
+	// MaxScopeExpansion caps the number of apex domains added to the scope, so the scope does not
+	// keep growing. Zero allows the default of 10 domains
+	MaxScopeExpansion int
+	// ResolveOnly limits the enumeration to forward resolution of the provided and known names,
+	// without the data source queries or the root and subdomain stages of the pipeline
+	ResolveOnly bool
+	// ValidateListOnly resolves only the provided names, validating them against the DNS wildcards
+	// before they are stored, for the bulk validation of a list of candidates. The root domains, the
+	// known names of the graph databases and the names discovered in the records are not submitted,
+	// and the data sources are not queried. It implies ResolveOnly
+	ValidateListOnly bool
+	// ProvidedNamesDetailed seeds the enumeration with names that carry their own tag and source,
+	// in addition to the Config ProvidedNames that are attributed to user input
+	ProvidedNamesDetailed []ProvidedName
+	// ProvidedNamesCSV is the path to a CSV file of seed names with the columns name, domain
+	// and source. The header row is optional, and rows outside of the scope are skipped
+	ProvidedNamesCSV string
+	// ProvidedCertificatesPEM is the path to a file of PEM certificates, such as those harvested
+	// elsewhere, and the names within the scope from their subjects and SANs seed the enumeration
+	ProvidedCertificatesPEM string
+	// CertificateWildcardBases seeds the base name of each wildcard certificate name, such as
+	// dev.owasp.org for *.dev.owasp.org, while the wildcard names are only logged otherwise
+	CertificateWildcardBases bool
+	// OnlyNewNames suppresses the output of names already in the graph from a prior enumeration,
+	// so only the first-time discoveries are emitted. The known names are still stored
+	OnlyNewNames bool
+	// UseDNSCookies includes RFC 7873 DNS Cookies in the queries, which helps cookie-enforcing
+	// resolvers identify the enumeration, and discards responses that echo the wrong client cookie
+	UseDNSCookies bool
+	// NameRewriter maps a name to the form that is resolvable from this vantage point, such as
+	// stripping a region suffix. It is applied just before resolution, after the Blacklist,
+	// ExcludeNameRegexps and NameFilter have approved the original name. The original name is
+	// still recorded in the graph, and the rewritten form is only used for the DNS queries
+	NameRewriter func(string) string
+	// MaxDuration bounds the runtime of the enumeration, and zero means no limit
+	MaxDuration time.Duration
+	// ResolvePTRForAddresses causes reverse DNS queries for the addresses found in A/AAAA records
+	ResolvePTRForAddresses bool
+	// SourceRequestCaps limits the number of requests sent to the named data sources,
+	// and sources without a cap receive an unlimited number of requests
+	SourceRequestCaps map[string]int
+	// Names resolving to addresses within the ScopeCIDRs are considered in scope, in addition
+	// to the names within the domain-based scope. The two are combined using union semantics
+	ScopeCIDRs []*net.IPNet
+	// AddressInScope replaces the ScopeCIDRs in the address scope decisions when provided, such as
+	// the names resolving to the addresses, the PTR records of the addresses, the addresses reported
+	// by the data sources and the reverse DNS sweeps. It must be safe for concurrent use
+	AddressInScope func(net.IP) bool
+	// PipelineBufferSize is the number of names buffered between the pipeline stages. Larger
+	// buffers improve throughput at the cost of memory, while smaller buffers reduce memory
+	// consumption and the latency of each name moving through the pipeline
+	PipelineBufferSize int
+	// NSECWalk causes the NSEC chain of DNSSEC-signed zones to be walked for additional names.
+	// Zones using NSEC3 are detected and skipped, since the chain only provides hashed names
+	NSECWalk bool
+	// SOCKS5Proxy causes the DNS queries, zone transfers and data sources of the enumeration to connect
+	// through the proxy, and the DNS queries are sent over TCP, since UDP is often unsupported by SOCKS5 proxies
+	SOCKS5Proxy string
+	// Dialer establishes the connections of the DNS queries, zone transfers and data sources of the
+	// enumeration, such as to route the traffic over a VPN split tunnel or a TUN interface. The DNS
+	// queries are sent directly to the resolvers instead of through the pools, and the SOCKS5Proxy
+	// takes precedence
+	Dialer proxy.ContextDialer
+	// EnrichmentTypes, combined with ResolveOnly, limits the DNS record types queried for the
+	// provided and known names (e.g. MX and TXT). Records already present on the names are kept
+	EnrichmentTypes []string
+	// TestOpenResolvers causes each discovered nameserver to be probed with a query for an
+	// unrelated name, and the nameservers providing recursive answers are flagged
+	TestOpenResolvers bool
+	// NameFilter approves each name before it enters the pipeline, and the name is dropped when
+	// false is returned. Unlike the Blacklist, the filter can implement arbitrary policy, but it
+	// is called for every discovered name and must be fast and safe for concurrent use
+	NameFilter func(*requests.DNSRequest) bool
+	// SourceNameFilters approve the names provided by the data source with the same name as the key,
+	// matched against the Source of each request, and the names rejected by the filter of their
+	// source are dropped. The map must not be changed once Start has been called, and the filters
+	// are called concurrently from the goroutines reading the data source output
+	SourceNameFilters map[string]func(string) bool
+	// SourceTimeouts bound the time each request waits to be accepted by the data source with the
+	// same name as the key, matched without regard to case. The request is skipped by the source
+	// once the timeout expires, and the source keeps receiving the following requests
+	SourceTimeouts map[string]time.Duration
+	// DefaultSourceTimeout applies to the data sources missing from SourceTimeouts, and zero lets
+	// them wait until the enumeration ends
+	DefaultSourceTimeout time.Duration
+	// DiscoveryBalance, when set, is the share of the data source requests submitted to the brute
+	// forcing, from 0 for only the passive data sources to 1 for only the brute forcing. The side
+	// ahead of its share is held until the other side catches up or stops submitting requests
+	DiscoveryBalance *float64
+	// AnswerProcessors are called in order on each resolved request before it is stored, and can
+	// sanitize, normalize or add to the records. Each processor receives the request as changed by
+	// the previous ones. When a processor returns an error, the remaining processors are skipped and
+	// the request is logged and dropped without being stored or sent to the output. The processors
+	// are called concurrently for different requests
+	AnswerProcessors []func(*requests.DNSRequest) error
+	// RecordValidators approve the answers of the record type named by the key, such as "A" or "TXT",
+	// and the answers rejected by the validator of their type are dropped from the DNS responses.
+	// The A records pointing into 0.0.0.0/8 are rejected unless a validator is provided for the type.
+	// The validators are called concurrently and must be safe for concurrent use
+	RecordValidators map[string]func(requests.DNSAnswer) bool
+	// OnStageComplete is called by Start as each stage of the enumeration completes, with one of
+	// StageSeeded, StageNamesSubmitted, StagePipelineDrained and StageStored. Each stage is reported
+	// once and in that order, and the calls are never concurrent. The enumeration waits for the
+	// callback, which must return quickly. The stages are still reported when the enumeration is
+	// cancelled, once the work in progress has stopped
+	OnStageComplete func(stage string)
+	// GeoIPDatabase is the path to a local MaxMind database used to geolocate the
+	// resolved addresses in the output, and the enrichment is skipped when empty
+	GeoIPDatabase string
+	// ForceTCP causes all the DNS queries to be sent over TCP. Otherwise, queries are sent
+	// over UDP and automatically retried over TCP when the response has been truncated
+	ForceTCP bool
+	// Logger receives the leveled messages from the enumeration, and defaults
+	// to writing all the messages to the Config Log
+	Logger Logger
+	// MaxInFlightNames caps the memory consumed by large enumerations, since the submission of new
+	// names blocks while this number of names is between entering the pipeline and leaving the
+	// store stage. Names discovered by the pipeline itself are not blocked. Zero means no limit
+	MaxInFlightNames int
+	// MaxResolveWorkers caps the goroutines started by the DNS resolution for each name, such as the
+	// queries for the records of the subdomains, the NSEC walks and the retries, so a huge scan
+	// cannot exhaust the memory or file descriptors. The resolution waits while this number of
+	// workers is busy. Zero means no limit
+	MaxResolveWorkers int
+	// CheckLameDelegation causes each nameserver listed in the NS records of a zone to be queried
+	// for the SOA, and the nameservers that do not exist, do not respond, or do not answer
+	// authoritatively for the zone are reported
+	CheckLameDelegation bool
+	// DetectTakeovers checks the CNAME and NS targets of the names within the scope against the
+	// TakeoverFingerprints, and reports the targets that are unclaimed, such as a CNAME to a cloud
+	// service hostname returning NXDOMAIN, as high severity findings in TakeoverFindings
+	DetectTakeovers bool
+	// DetectNSInconsistency compares the answers of the authoritative nameservers of the zone for a
+	// sample of the names within each root domain, queried directly, and reports the names with
+	// different address sets and the zones with different SOA serials in NSInconsistencies
+	DetectNSInconsistency bool
+	// TakeoverFingerprints replace the built-in fingerprints of the cloud services when provided
+	TakeoverFingerprints []TakeoverFingerprint
+	// DetectRebinding reports the names resolving to both public and private addresses, the setup
+	// used by DNS rebinding attacks, as findings in RebindingFindings
+	DetectRebinding bool
+	// RebindingPrivateRanges replace the DefaultRebindingPrivateRanges when provided, as CIDRs
+	RebindingPrivateRanges []string
+	// RebindingLowTTL is the TTL, in seconds, at or below which the rebinding findings are flagged
+	// as having a low TTL, and zero uses 60 seconds
+	RebindingLowTTL int
+	// MapMailInfra records the mail exchangers of the MX records with their priorities, returned by
+	// MailInfrastructure, and submits those within the scope for the forward resolution. The
+	// exchangers outside of the scope are recorded as such, and are not resolved
+	MapMailInfra bool
+	// RedactFields are the fields omitted from the outputs when the results are shared, among the
+	// RedactableFields, such as the source names tied to paid credentials
+	RedactFields []string
+	// RedactCIDRs are the address ranges replaced by the RedactPlaceholder in the outputs, such as
+	// the internal ranges. The graph keeps the full data, since the redaction only applies to output
+	RedactCIDRs []*net.IPNet
+	// QueryCERT adds the CERT records of the domains and subdomains to the queries for the NS, MX
+	// and SOA records. The certificates and PGP keys are decoded into the Cert of the DNSAnswer
+	QueryCERT bool
+	// QueryLOC adds the LOC records of the domains and subdomains to the queries for the NS, MX and
+	// SOA records. The coordinates are decoded into the Location of the DNSAnswer
+	QueryLOC bool
+	// QueryHINFO adds the HINFO records of the domains and subdomains to the queries for the NS, MX
+	// and SOA records. The CPU and OS fields are decoded into the HINFO of the DNSAnswer, and the
+	// disclosures are reported in HINFOFindings
+	QueryHINFO bool
+	// QueryAPL adds the APL records of the domains and subdomains to the queries for the NS, MX and
+	// SOA records. The address prefixes are decoded into the Prefixes of the DNSAnswer, and they are
+	// swept for PTR records pointing at names in scope when the enumeration is active
+	QueryAPL bool
+	// QueryURI adds the URI records at the _service._proto names of the domains and subdomains to
+	// the queries for the NS, MX and SOA records, using the same service names as the SRV records.
+	// The target URIs are decoded into the URI of the DNSAnswer, and their hosts within the scope
+	// are submitted to the enumeration
+	QueryURI bool
+	// EmailLocalParts are the local parts of the known email addresses or usernames, such as
+	// security or jdoe, looked up as the OPENPGPKEY and SMIMEA records of the domains and subdomains
+	// at the hashed names of RFC 7929 and RFC 8162. The keys are decoded into the OpenPGPKey and
+	// SMIMEA of the DNSAnswer
+	EmailLocalParts []string
+	// SRVPortProbes are the port numbers queried as the _<port>._tcp and _<port>._udp SRV records
+	// under each subdomain, in addition to the SRV service names queried by the data sources
+	SRVPortProbes []int
+	// CollectGlueRecords keeps the A and AAAA records of the nameservers found in the additional
+	// section of the NS responses, and stores them for the nameserver names. The nameservers
+	// without glue records are resolved as before
+	CollectGlueRecords bool
+	// NegativeCacheTTL is how long the names receiving NXDOMAIN are remembered, so the names
+	// submitted again by the data sources, brute forcing and alterations are not queried again
+	// before it expires. Zero disables the negative cache
+	NegativeCacheTTL time.Duration
+	// NegativeCacheSize is the number of names kept in the negative cache, and the oldest names are
+	// evicted once it is full. Zero selects the default of 100000 names
+	NegativeCacheSize int
+	// RequireAllCredentials stops Start with an error when any data source selected by the
+	// configuration requires credentials that are missing or rejected, as reported by
+	// ValidateDataSources, instead of running without those data sources
+	RequireAllCredentials bool
+	// PartitionByDomain assigns each name to the most specific root domain that contains it, instead
+	// of the first matching domain in the configuration, and each root domain its own sub-event UUID,
+	// so the results for unrelated or nested root domains scanned in the same enumeration can be
+	// separated using DomainEvents and EventFQDNs
+	PartitionByDomain bool
+	// EDNSBufferSize is the UDP buffer size advertised in the EDNS0 OPT record of the queries.
+	// Networks that do not fragment can increase it to reduce the fallbacks to TCP
+	EDNSBufferSize uint16
+	// DedupeFilterType selects how the names already seen are remembered. The default "bloom" keeps
+	// a stable bloom filter with bounded memory, which skips a small rate of new names as false
+	// positives and can process a name again once it was evicted. The "exact" filter never skips
+	// a new name, but its memory grows with each name, which matters for scans of millions of names
+	DedupeFilterType string
+	// DedupeFilterCapacity is the number of cells in the bloom filter, defaulting to one million,
+	// and DedupeFalsePositiveRate is its rate of false positives, defaulting to 1%
+	DedupeFilterCapacity    uint
+	DedupeFalsePositiveRate float64
+	// ExcludeNameRegexps drops the names matching any of these regular expressions before
+	// resolution, such as auto-generated hostnames. The expressions are not anchored implicitly,
+	// and the internationalized names are matched in both their A-label and Unicode forms
+	ExcludeNameRegexps []string
+	// PriorityPatterns are the regular expressions of the interesting names, such as admin, vpn or
+	// api, which enter the pipeline ahead of the other names waiting in the input source, so they
+	// are resolved early even when the enumeration is cut short. The same matching rules apply as
+	// for the ExcludeNameRegexps
+	PriorityPatterns []string
+	// RawResponseDir is the directory where each DNS request and response exchanged with the
+	// resolvers is written in wire format, along with an index of the timestamp, resolver and
+	// name of each record, which allows reprocessing offline. Nothing is written when empty
+	RawResponseDir string
+	// OutputTemplate is a text/template rendered for each resolved name in the output, with the
+	// fields of TemplateResult, such as {{.Name}} {{join .Addresses ","}}. Each result is written
+	// to the OutputWriter on its own line. Nothing is rendered when empty
+	OutputTemplate string
+	// OutputWriter receives the results rendered by the OutputTemplate, and defaults to STDOUT
+	OutputWriter io.Writer
+	// CompressOutput compresses the JSONL output and the results rendered by the OutputTemplate with
+	// gzip or zstd, and the extension of the format is appended to the JSONL output file. The
+	// compressed streams are completed when the enumeration finishes. Empty disables the compression
+	CompressOutput string
+	// OutputFlushInterval causes the buffered outputs, such as the SQLite and Neo4j batches and an
+	// OutputWriter implementing Flush, to be flushed periodically, so the results already produced
+	// are written while the enumeration is quiet. Zero only flushes when the batches are full and
+	// when the enumeration finishes
+	OutputFlushInterval time.Duration
+	// NonRecursive clears the recursion desired bit of the queries, so the resolvers only answer
+	// from their caches. The names found in a cache are attributed to the Cache Snoop source, and
+	// referrals are treated as the name not being cached. This is only meaningful against resolvers
+	// that you are permitted to query and that answer non-recursive queries from their caches
+	NonRecursive bool
+	// CompletionGracePeriod is the period the enumeration remains idle, without names in the pipeline
+	// or pending data source requests, before it is declared complete, so the results provided late
+	// by the slow data sources are not lost. The period starts over when more names are provided
+	CompletionGracePeriod time.Duration
+	// MaxResults stops the enumeration once this number of resolved names within the scope have
+	// been output, which provides a quick sample of a large attack surface. Names already in the
+	// pipeline are still stored while it drains. Zero means no limit
+	MaxResults int
+	// MaxSubdomainsPerParent caps the resolved names below each parent that trigger recursion,
+	// permutations and data source requests, so a branch with thousands of generated names does not
+	// dominate the enumeration. The names beyond the cap are still stored. Zero means no limit
+	MaxSubdomainsPerParent int
+	// WildcardFloodThreshold is the number of names matching the DNS wildcards below a parent within
+	// the WildcardFloodWindow that throttles the new names below the parent, so a flood of generated
+	// names does not starve the discoveries in the other branches. Zero disables the throttle
+	WildcardFloodThreshold int
+	// WildcardFloodWindow is the period the wildcard matches below each parent are counted over,
+	// and zero uses the default of 10 seconds
+	WildcardFloodWindow time.Duration
+	// WildcardFloodRate is the number of new names per second released below a throttled parent,
+	// and zero uses the default of one. The throttle ends once the matches drop below the threshold
+	WildcardFloodRate float64
+	// WordlistURL is an HTTP service providing the brute forcing wordlist, one label per line,
+	// which receives the domains in scope as the domain query parameters and can be paginated
+	// using the next links of the Link header. The local wordlist is used when the request fails
+	WordlistURL string
+	// WordlistHeaders are added to the requests sent to the WordlistURL, such as Authorization
+	WordlistHeaders map[string]string
+	// BruteSamplePercent is the percentage, from 1 to 100, of the brute forcing wordlist randomly
+	// sampled for a quick pass, and zero tries all the labels
+	BruteSamplePercent int
+	// SampleSeed seeds the random sampling of the BruteSamplePercent, so the sample is reproducible,
+	// and zero uses a random seed reported in the log
+	SampleSeed int64
+	// RecordOutOfScopeCNAMEs stores the CNAME targets outside of the scope in the graph without
+	// resolving them, and marks them as out of scope in the results from OutOfScopeCNAMEs. This
+	// helps find dangling records pointing at unclaimed third-party services
+	RecordOutOfScopeCNAMEs bool
+	// InheritScopeViaCNAME adds the apex domains of the CNAME targets of the names in scope to the
+	// scope when they match one of the CNAMEScopeSuffixes, such as the other domains owned by the
+	// organization, so the targets are resolved and enumerated. The other targets remain out of scope
+	InheritScopeViaCNAME bool
+	// CNAMEScopeSuffixes are the suffixes of the apex domains inherited through the CNAME targets
+	CNAMEScopeSuffixes []string
+	// MaxCNAMEScopeDepth limits the chains of domains inherited through the CNAME targets, so the
+	// scope does not explode. One only inherits the targets of the configured domains, and zero
+	// allows the default depth of 2
+	MaxCNAMEScopeDepth int
+	// PreferCNAMEWithAddresses keeps the A and AAAA records of the CNAME targets included in the
+	// responses along with the CNAME records, which are dropped otherwise, and stores them for the
+	// targets. The addresses are kept even when the targets are outside of the scope
+	PreferCNAMEWithAddresses bool
+	// RandomizeQueryOrder shuffles the order of the record types queried for each name, so the
+	// query pattern of the names is less predictable. The benefit is marginal, since the same
+	// types are still queried from the same resolvers, and the order of the queries, and so the
+	// traffic of an enumeration, cannot be reproduced. An alias still ends the queries for the
+	// name, whichever type revealed the CNAME record
+	RandomizeQueryOrder bool
+	// CaptureFullResponse keeps the records in the authority and additional sections of the
+	// responses along with the answers, such as the NS records of the zone, the glue and the OPT
+	// record, marked by the Section of the DNSAnswer. The records are sent to the outputs, and are
+	// not stored in the graph as the records of the name. Up to 16 records of each section are kept
+	CaptureFullResponse bool
+	// ResolversByType assigns dedicated untrusted resolvers to the record types, such as "TXT",
+	// which isolates the heavy record types onto specialized infrastructure. The queries for the
+	// types not listed are sent to the default pool, and the trusted resolvers are not affected
+	ResolversByType map[string][]string
+	// DomainResolverAffinity assigns preferred untrusted resolvers to the names under the domain
+	// suffixes, such as the resolvers near the nameservers of an anycast or geographically split
+	// target. The longest matching suffix is used, and the queries fall back to the general pool
+	// when the preferred resolvers do not respond. The affinity takes precedence over the
+	// ResolversByType, and the trusted resolvers are not affected
+	DomainResolverAffinity map[string][]string
+	// EnablePermutations generates altered forms of each resolved name, such as changed numbers,
+	// swapped hyphens and common prefixes or suffixes, and resolves those within the scope. The
+	// number of names generated from each resolved name is capped to avoid an explosion
+	EnablePermutations bool
+	// IncludeUnresolvable stores the names within the scope that failed to resolve, so they are
+	// reported along with the resolved names. This applies to the names from every source, including
+	// the data sources, and not only to the names produced by the enumeration itself
+	IncludeUnresolvable bool
+	// DomainSettings overrides the settings above for the names within each domain, such as
+	// allowing the active techniques against only some of the domains in the scope. The most
+	// specific domain containing a name applies, and each domain must be within the scope
+	DomainSettings map[string]*DomainConfig
+	// ReverseSweepSize is the maximum number of addresses swept from the start of the block by
+	// ReverseOnly, and zero allows full sweeps of the blocks up to a /16
+	ReverseSweepSize int
+	// ReverseBatchSize is the number of PTR queries of a reverse sweep pipelined over a single TCP
+	// connection to a trusted resolver, and zero sends each query individually. The queries are sent
+	// individually to the resolvers that do not answer the pipelined queries
+	ReverseBatchSize int
+	// ReverseSweepCheckpointFile is the path of the file keeping the last address swept in each
+	// block, keyed by CIDR, so the reverse sweeps interrupted by a restart resume after it. The
+	// file is written periodically during the sweeps, and replaced atomically
+	ReverseSweepCheckpointFile string
+	// DataSourceCheckpointDir is the directory keeping the pagination cursors of the data sources
+	// implementing the PaginationCheckpointer, with a file for each data source, so the paginated
+	// queries interrupted by a crash resume where they left off on restart, which preserves the quota
+	DataSourceCheckpointDir string
+	// ValidateAgainstAuthoritative queries the authoritative nameservers of the zone directly for
+	// each resolved name, and drops the names with answers that differ from the resolvers, which
+	// protects against poisoned resolvers. The names are accepted when no authoritative nameserver
+	// of the zone is reachable
+	ValidateAgainstAuthoritative bool
+	// SOASerialFile is the path of the file keeping the SOA serials of the zones seen by each
+	// enumeration, keyed by the event UUID, so SOASerialChanges reports the zones modified since
+	// a previous enumeration. The serials of this enumeration are added once it completes
+	SOASerialFile string
+	// MaxAnswerBytes limits the data kept from each answer set, which protects the memory and the
+	// graph from pathological records, such as TXT records of several kilobytes. The answer crossing
+	// the limit is cut and flagged as truncated, and the rest are dropped. Zero means no limit
+	MaxAnswerBytes int
+	// CanaryChecks maps names to their known-good IP addresses, which are periodically verified
+	// against the answers from the resolvers to detect DNS tampering on untrusted networks
+	CanaryChecks map[string][]string
+	// CanaryAbort stops the enumeration when a canary check detects an unexpected address
+	CanaryAbort bool
+	// GraphBatchSize is the number of graph upserts accumulated by the store stage before they
+	// are executed together, and zero stores each record immediately. The identical upserts within
+	// a batch are only executed once, while the graph lags behind the pipeline until the flush
+	GraphBatchSize int
+	// GraphFlushInterval is the maximum period the batched graph upserts wait before being executed
+	GraphFlushInterval time.Duration
+	// GraphFailoverFile receives the graph writes that fail, such as when the graph database becomes
+	// unavailable during the enumeration, as JSON lines. The enumeration continues, and the records
+	// are kept in the file instead of being dropped
+	GraphFailoverFile string
+	// GraphFailoverReplay writes the records from the GraphFailoverFile into the graph once the graph
+	// accepts the writes again, and before the enumeration ends. The records failing again are kept
+	GraphFailoverReplay bool
+	// ProbeHTTP sends a HEAD request over HTTP and HTTPS to each resolved host within the scope,
+	// and records the status code, server header and redirect target provided by HTTPProbes
+	ProbeHTTP bool
+	// HTTPProbeTimeout is the maximum time spent on each HTTP probe
+	HTTPProbeTimeout time.Duration
+	// MaxTotalQueries is the budget of DNS queries for the whole enumeration, and zero means
+	// unlimited. Once the budget has been spent, further queries are refused, the data source
+	// requests stop and the names drain from the pipeline without being resolved
+	MaxTotalQueries int64
+	// SourcePortRange restricts the source ports of the DNS queries, which are then sent without the
+	// resolver pools at the QPS of each resolver. The zero value keeps the random ports assigned by the OS
+	SourcePortRange PortRange
+	// SourceIP binds the DNS queries and zone transfers to the local address, such as to use a specific
+	// egress on a multi-homed host. The queries are then sent without the resolver pools at the QPS of
+	// each resolver
+	SourceIP net.IP
+	// Interface binds the DNS queries and zone transfers to an address of the network interface,
+	// which must be assigned the SourceIP when both are provided
+	Interface string
+	// TCPPoolMaxIdle is the number of idle TCP connections kept for each DNS server, so the
+	// following queries avoid the handshakes. Zero opens a connection for each TCP query
+	TCPPoolMaxIdle int
+	// TCPPoolIdleTimeout is the period an idle TCP connection is kept before being closed
+	TCPPoolIdleTimeout time.Duration
+	// TryANYFirst sends an ANY query for each name before the forward query types, and skips the
+	// individual queries when the answers include those types. Refused and minimal ANY responses
+	// fall back to the individual queries
+	TryANYFirst bool
+	// QueryJitter is the maximum random delay inserted before each DNS query, on top of the rate
+	// limits, so the traffic is less regular. Each query waits half of the value on average, and
+	// the names remain in flight while waiting, so large values lower the throughput. Zero
+	// disables the jitter
+	QueryJitter time.Duration
+	// ForceResolver is the host:port of a single nameserver receiving all the DNS queries, which
+	// bypasses the resolver pools and their health checks. It is meant for diagnosing resolver
+	// specific behavior, and an empty value uses the pools
+	ForceResolver string
+	// QPSRampUpDuration is the period at the start of the enumeration during which the query rate
+	// of each resolver pool increases from a tenth of its maximum QPS to the full rate, so the
+	// resolver protections are not tripped immediately. Zero disables the ramp-up
+	QPSRampUpDuration time.Duration
+	// ExpandSPF follows the include and redirect chains of the SPF records for the root domains,
+	// up to the 10 DNS lookups allowed by RFC 7208, and the referenced domains within the scope
+	// are resolved. The results are returned by ExpandedSPF
+	ExpandSPF bool
+	// MinConfidence is the lowest confidence score, from 0 to 100, of the names sent to the output.
+	// The score is earned by the data sources providing the name and its resolution, and is halved
+	// under a DNS wildcard. Zero outputs all the names
+	MinConfidence int
+	// OutputActiveOnly only sends the names obtained by the active techniques to the output, such
+	// as brute forcing and zone transfers, according to requests.ActiveTag
+	OutputActiveOnly bool
+	// OutputPassiveOnly only sends the names obtained from the data sources and the provided names
+	// to the output, which excludes the names obtained by the active techniques
+	OutputPassiveOnly bool
+	// ClusterSimilarity is the minimum Jaccard similarity, from 0 to 1, of the address sets of the
+	// clusters merged by AddressClusters, so the names with overlapping addresses are grouped. Zero
+	// and one only group the names with identical address sets
+	ClusterSimilarity float64
+	// PrivateAddressPolicy is the treatment of the private and internal addresses found in the
+	// answers, such as the RFC 1918 addresses leaked by zone transfers and TXT records, which is
+	// PrivateAddressKeep, PrivateAddressFlag or PrivateAddressDrop. An empty value keeps them
+	PrivateAddressPolicy string
+	// SinkholeAddresses are the IP addresses and CIDRs of the known sinkholes. The answers consisting
+	// solely of these addresses are discarded and retried, and the resolvers repeatedly returning
+	// them are avoided by the queries sent without the pools, as counted by SinkholeCounts
+	SinkholeAddresses []string
+
 	ctx      context.Context
+	socks    proxy.ContextDialer
+	cancel   context.CancelFunc
 	graph    *netmap.Graph
 	srcs     []service.Service
 	done     chan struct{}
@@ -34,37 +554,668 @@ type Enumeration struct {
 	dnsTask  *dnsTask
 	valTask  *dnsTask
 	store    *dataManager
+	horizon  *splitHorizon
+	tracked  *trackedWork
+	vantage  *vantagePoints
+	openres  *openResolverTests
+	lame     *lameDelegationTests
+	negcache *negativeCache
+	takeover *takeoverChecks
+	rebind   *rebindingChecks
+	privaddr *privateAddresses
+	sinkhole *sinkholes
+	srcaddr  net.IP
+	floods   *wildcardFloods
+	geoip    *geoIPLookup
+	sqlite   *sqliteOutput
+	neo4j    *neo4jOutput
+	objout   *objectOutput
+	mqout    *mqOutput
+	balance  *discoveryBalance
+	tmplout  *templateOutput
+	jsonl    *jsonlOutput
+	outputs  []namedOutput
 	requests queue.Queue
+	limited  chan string
+	resume   chan struct{}
+	srcPause bool
+	fwdTypes []uint16
+	excludes []*regexp.Regexp
+	priority []*regexp.Regexp
+	csvNames []ProvidedName
+	srvports []string
+	urisvcs  []string
+	mailkeys []emailKeyLabel
+	workers  chan struct{}
+	aplSwept sync.Map
+	failover *graphFailover
+	grpcsrv  *grpcServer
+	cpLock   sync.Mutex
+	sweepcp  *reverseCheckpoint
+	srccp    *sourceCheckpoints
+	nopipe   sync.Map
+	paces    sync.Map
+	pemNames []ProvidedName
+	cookies  *dnsCookies
+	rawlog   *rawResponseLog
+	cnames   *cnameWildcards
+	rcodes   *rcodeWildcards
+	known    *knownWildcards
+	chains   *cnameChains
 	plock    sync.Mutex
 	pending  bool
+	ttlLock  sync.Mutex
+	lowttl   map[string]requests.DNSAnswer
+	seenLock sync.Mutex
+	seen     map[string]time.Time
+	events   *domainEvents
+	srcLock  sync.RWMutex
+	resLock  sync.Mutex
+	results  map[string]struct{}
+	oosLock  sync.Mutex
+	oosNames map[string]string
+	mailLock sync.Mutex
+	mailxs   map[string]MailExchanger
+	hinfLock sync.Mutex
+	hinfos   []HINFOFinding
+	prober   *httpProber
+	tcpConns *tcpPool
+	ramp     *qpsRamp
+	trRamp   *qpsRamp
+	authval  *authValidator
+	nscheck  *nsConsistencyChecks
+	soaser   *soaSerials
+	queries  atomic.Int64
+	subs     subscriptions
+	sumLock  sync.Mutex
+	srcCount map[string]int
+	histLock sync.Mutex
+	history  map[string][]string
+	scores   map[string]int
+	wildzone sync.Map
+	spfLock  sync.Mutex
+	spf      map[string]*SPFExpansion
+	geoLock  sync.Mutex
+	regional map[string]*RegionalAnswers
+	expLock  sync.Mutex
+	expanded []string
+	inhLock  sync.Mutex
+	inherit  map[string]int
+	statLock sync.Mutex
+	rstats   map[string]*resolverLatency
+	setLock  sync.RWMutex
+	blocked  []string
+	typeAddr map[uint16][]string
+	typePool map[uint16]*resolve.Resolvers
+	affAddr  map[string][]string
+	affPool  map[string]*resolve.Resolvers
 }
 
 // NewEnumeration returns an initialized Enumeration that has not been started yet.
 func NewEnumeration(cfg *config.Config, sys systems.System, graph *netmap.Graph) *Enumeration {
 	return &Enumeration{
-		Config:   cfg,
-		Sys:      sys,
-		graph:    graph,
-		srcs:     datasrcs.SelectedDataSources(cfg, sys.DataSources()),
-		requests: queue.NewQueue(),
+		Config:             cfg,
+		Sys:                sys,
+		Blacklist:          cfg,
+		PipelineBufferSize: defaultPipelineBufferSize,
+		EDNSBufferSize:     defaultEDNSBufferSize,
+		graph:              graph,
+		srcs:               datasrcs.SelectedDataSources(cfg, sys.DataSources()),
+		requests:           queue.NewQueue(),
+		limited:            make(chan string, 10),
+		resume:             make(chan struct{}, 1),
+		geoip:              &geoIPLookup{cache: make(map[string]*requests.GeoLocation)},
+	}
+}
+
+func (e *Enumeration) log() Logger {
+	if e.Logger == nil {
+		return NewLogger(e.Config.Log, LevelDebug)
+	}
+	return e.Logger
+}
+
+func (e *Enumeration) blacklisted(name string) bool {
+	if e.blacklistedAtRuntime(name) {
+		return true
+	}
+	if e.Blacklist == nil {
+		return e.Config.Blacklisted(name)
+	}
+	if uni := UnicodeName(name); uni != "" && e.Blacklist.Blacklisted(uni) {
+		return true
+	}
+	return e.Blacklist.Blacklisted(name)
+}
+
+// RootDomain returns the root domain that the name belongs to within this enumeration,
+// or an empty string when the name is not in scope.
+func (e *Enumeration) RootDomain(name string) string {
+	if !e.PartitionByDomain {
+		return e.Config.WhichDomain(name)
+	}
+
+	var root string
+	n := strings.ToLower(strings.TrimSpace(name))
+	for _, d := range e.Config.Domains() {
+		if (n == d || strings.HasSuffix(n, "."+d)) && len(d) > len(root) {
+			root = d
+		}
+	}
+	return root
+}
+
+func (e *Enumeration) compileNameExclusions() error {
+	e.excludes = nil
+
+	for _, expr := range e.ExcludeNameRegexps {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("the name exclusion regular expression %s is not valid: %v", expr, err)
+		}
+		e.excludes = append(e.excludes, re)
+	}
+	return nil
+}
+
+// excludedName returns true when the name matches any of the ExcludeNameRegexps.
+func (e *Enumeration) excludedName(name string) bool {
+	return matchName(e.excludes, name)
+}
+
+func (e *Enumeration) compilePriorityPatterns() error {
+	e.priority = nil
+
+	for _, expr := range e.PriorityPatterns {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("the priority pattern %s is not valid: %v", expr, err)
+		}
+		e.priority = append(e.priority, re)
 	}
+	return nil
+}
+
+// priorityName returns true when the name matches any of the PriorityPatterns.
+func (e *Enumeration) priorityName(name string) bool {
+	return matchName(e.priority, name)
+}
+
+// matchName returns true when the name, in its A-label or Unicode form, matches any of the expressions.
+func matchName(exprs []*regexp.Regexp, name string) bool {
+	if len(exprs) == 0 {
+		return false
+	}
+
+	uni := UnicodeName(name)
+	for _, re := range exprs {
+		if re.MatchString(name) || (uni != "" && re.MatchString(uni)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceNameAllowed returns false when the filter of the data source providing the name rejects it.
+// The data source names are matched without regard to case.
+func (e *Enumeration) sourceNameAllowed(req *requests.DNSRequest) bool {
+	if len(e.SourceNameFilters) == 0 || req.Source == "" {
+		return true
+	}
+
+	filter, found := e.SourceNameFilters[req.Source]
+	if !found {
+		for name, f := range e.SourceNameFilters {
+			if strings.EqualFold(name, req.Source) {
+				filter = f
+				break
+			}
+		}
+	}
+	return filter == nil || filter(req.Name)
+}
+
+// queryName returns the name used in the DNS queries, after the NameRewriter has been applied.
+func (e *Enumeration) queryName(name string) string {
+	if e.NameRewriter == nil {
+		return name
+	}
+
+	if r := strings.ToLower(strings.TrimSpace(e.NameRewriter(name))); r != "" {
+		return r
+	}
+	return name
+}
+
+// addrInScope returns true when the AddressInScope hook accepts the address, or when the
+// address is within the ScopeCIDRs and the hook was not provided.
+func (e *Enumeration) addrInScope(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	if e.AddressInScope != nil {
+		return e.AddressInScope(ip)
+	}
+
+	for _, cidr := range e.ScopeCIDRs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// addrPermitted returns false when the AddressInScope hook rejects the address, which keeps the
+// reverse DNS queries away from the addresses outside the scope of the integrator.
+func (e *Enumeration) addrPermitted(addr string) bool {
+	return e.AddressInScope == nil || e.addrInScope(addr)
+}
+
+// unresolvable returns true when the name that failed to resolve should be stored anyway.
+func (e *Enumeration) unresolvable(req *requests.DNSRequest) bool {
+	// the names are not reported when the queries were refused due to the budget
+	return e.includeUnresolvable(req.Name) && len(req.Records) == 0 && !e.budgetSpent() &&
+		e.Config.IsDomainInScope(req.Name) && !e.blacklisted(req.Name)
+}
+
+// nameInScope returns true when the name is within the domain-based scope,
+// or any of the A/AAAA records for the name are within the address scope.
+func (e *Enumeration) nameInScope(req *requests.DNSRequest) bool {
+	if e.Config.IsDomainInScope(req.Name) {
+		return true
+	}
+
+	for _, rec := range req.Records {
+		if t := uint16(rec.Type); (t == dns.TypeA || t == dns.TypeAAAA) && !sectionRecord(rec) && e.addrInScope(rec.Data) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Enumeration) setQueryTypes() error {
+	e.fwdTypes = FwdQueryTypes
+	if !e.resolveOnly() || len(e.EnrichmentTypes) == 0 {
+		return nil
+	}
+
+	var types []uint16
+	for _, t := range e.EnrichmentTypes {
+		qtype, found := dns.StringToType[strings.ToUpper(strings.TrimSpace(t))]
+		if !found {
+			return fmt.Errorf("the enrichment record type %s is not valid", t)
+		}
+		types = append(types, qtype)
+	}
+	e.fwdTypes = types
+	return nil
+}
+
+// nextQueryType returns the DNS record type queried after the provided type.
+func (e *Enumeration) nextQueryType(qtype uint16) (uint16, bool) {
+	return nextTypeIn(e.fwdTypes, qtype)
 }
 
 // Start begins the vertical domain correlation process.
 func (e *Enumeration) Start(ctx context.Context) error {
 	e.done = make(chan struct{})
 	defer close(e.done)
+	defer e.unsubscribeAll()
+	defer e.stopGRPC()
 
 	if err := e.Config.CheckSettings(); err != nil {
 		return err
 	}
+	if e.PipelineBufferSize <= 0 {
+		return fmt.Errorf("the pipeline buffer size must be positive: %d", e.PipelineBufferSize)
+	}
+	if e.GraphBatchSize < 0 || e.GraphFlushInterval < 0 {
+		return fmt.Errorf("the graph batch size and flush interval cannot be negative: %d, %s", e.GraphBatchSize, e.GraphFlushInterval)
+	}
+	if e.OutputFlushInterval < 0 {
+		return fmt.Errorf("the output flush interval cannot be negative: %s", e.OutputFlushInterval)
+	}
+	if e.MaxTotalQueries < 0 {
+		return fmt.Errorf("the DNS query budget cannot be negative: %d", e.MaxTotalQueries)
+	}
+	if e.ReverseBatchSize < 0 {
+		return fmt.Errorf("the reverse DNS batch size cannot be negative: %d", e.ReverseBatchSize)
+	}
+	if e.DefaultSourceTimeout < 0 {
+		return fmt.Errorf("the default data source timeout cannot be negative: %s", e.DefaultSourceTimeout)
+	}
+	for name, d := range e.SourceTimeouts {
+		if d < 0 {
+			return fmt.Errorf("the timeout of the data source %s cannot be negative: %s", name, d)
+		}
+	}
+	if err := e.checkRedactFields(); err != nil {
+		return err
+	}
+	if b := e.DiscoveryBalance; b != nil {
+		if *b < 0 || *b > 1 {
+			return fmt.Errorf("the discovery balance must be between 0 and 1: %g", *b)
+		}
+		e.balance = newDiscoveryBalance(*b)
+	}
+	if e.MinConfidence < 0 || e.MinConfidence > 100 {
+		return fmt.Errorf("the minimum confidence score must be between 0 and 100: %d", e.MinConfidence)
+	}
+	if err := e.checkCompressOutput(); err != nil {
+		return err
+	}
+	if e.OutputActiveOnly && e.OutputPassiveOnly {
+		return errors.New("the output cannot be limited to both the active and the passive techniques")
+	}
+	if e.MaxResults < 0 {
+		return fmt.Errorf("the maximum number of results cannot be negative: %d", e.MaxResults)
+	}
+	if e.MaxResolveWorkers < 0 {
+		return fmt.Errorf("the maximum number of resolution workers cannot be negative: %d", e.MaxResolveWorkers)
+	}
+	e.workers = nil
+	if e.MaxResolveWorkers > 0 {
+		e.workers = make(chan struct{}, e.MaxResolveWorkers)
+	}
+	if e.NegativeCacheTTL < 0 || e.NegativeCacheSize < 0 {
+		return fmt.Errorf("the negative cache TTL and size cannot be negative: %s, %d", e.NegativeCacheTTL, e.NegativeCacheSize)
+	}
+	if e.CompletionGracePeriod < 0 {
+		return fmt.Errorf("the completion grace period cannot be negative: %s", e.CompletionGracePeriod)
+	}
+	if e.MaxSubdomainsPerParent < 0 {
+		return fmt.Errorf("the maximum number of subdomains per parent cannot be negative: %d", e.MaxSubdomainsPerParent)
+	}
+	if e.BruteSamplePercent < 0 || e.BruteSamplePercent > 100 {
+		return fmt.Errorf("the brute forcing sample percentage must be between 1 and 100: %d", e.BruteSamplePercent)
+	}
+	if err := e.checkPrivateAddressPolicy(); err != nil {
+		return err
+	}
+	if e.WildcardFloodThreshold != 0 {
+		floods, err := newWildcardFloods(e)
+		if err != nil {
+			return err
+		}
+		e.floods = floods
+	}
+	if len(e.SinkholeAddresses) > 0 {
+		sinkhole, err := newSinkholes(e)
+		if err != nil {
+			return err
+		}
+		e.sinkhole = sinkhole
+	}
+	if e.ClusterSimilarity < 0 || e.ClusterSimilarity > 1 {
+		return fmt.Errorf("the cluster similarity must be between 0 and 1: %g", e.ClusterSimilarity)
+	}
+	if e.EDNSBufferSize < dns.MinMsgSize {
+		return fmt.Errorf("the EDNS buffer size must be between %d and %d: %d", dns.MinMsgSize, dns.MaxMsgSize, e.EDNSBufferSize)
+	}
+	if err := e.SourcePortRange.check(); err != nil {
+		return err
+	}
+	if err := e.checkSourceAddress(); err != nil {
+		return err
+	}
+	if e.SOCKS5Proxy != "" {
+		if e.SourcePortRange.set() {
+			return errors.New("the source port range cannot be used with the SOCKS5 proxy")
+		}
+
+		d, err := amassnet.NewSOCKS5Dialer(e.SOCKS5Proxy)
+		if err != nil {
+			return err
+		}
+		e.socks = d
+	}
+	if e.Dialer != nil && e.SourcePortRange.set() {
+		return errors.New("the source port range cannot be used with the custom dialer")
+	}
+	if e.MaxAnswerBytes < 0 {
+		return fmt.Errorf("the maximum answer size cannot be negative: %d", e.MaxAnswerBytes)
+	}
+	if e.QueryJitter < 0 {
+		return fmt.Errorf("the query jitter cannot be negative: %s", e.QueryJitter)
+	}
+	if e.QPSRampUpDuration < 0 {
+		return fmt.Errorf("the QPS ramp-up duration cannot be negative: %s", e.QPSRampUpDuration)
+	}
+	if e.TCPPoolMaxIdle < 0 || e.TCPPoolIdleTimeout < 0 {
+		return fmt.Errorf("the TCP pool max idle and idle timeout cannot be negative: %d, %s", e.TCPPoolMaxIdle, e.TCPPoolIdleTimeout)
+	}
+	if err := e.checkForceResolver(ctx); err != nil {
+		return err
+	}
+	if err := e.normalizeIDNScope(); err != nil {
+		return err
+	}
+	if err := e.checkDedupeSettings(); err != nil {
+		return err
+	}
+	if err := e.setQueryTypes(); err != nil {
+		return err
+	}
+	if err := e.compilePriorityPatterns(); err != nil {
+		return err
+	}
+	if err := e.compileNameExclusions(); err != nil {
+		return err
+	}
+	if err := e.checkCanarySettings(); err != nil {
+		return err
+	}
+	if err := e.checkDomainSettings(); err != nil {
+		return err
+	}
+	if err := e.checkScopeExpansion(); err != nil {
+		return err
+	}
+	if err := e.checkScopeInheritance(); err != nil {
+		return err
+	}
+	if err := e.checkWordlistURL(); err != nil {
+		return err
+	}
+	if err := e.checkRequiredCredentials(); err != nil {
+		return err
+	}
+	if err := e.buildSRVPortProbes(); err != nil {
+		return err
+	}
+	if e.QueryURI {
+		e.buildURIServices()
+	}
+	if err := e.buildEmailKeyLabels(); err != nil {
+		return err
+	}
+	if e.WordlistURL != "" && e.Config.BruteForcing {
+		e.fetchWordlist(ctx)
+	}
+	if e.BruteSamplePercent > 0 && e.Config.BruteForcing {
+		e.sampleWordlist()
+	}
+	if e.DetectTakeovers {
+		takeover, err := newTakeoverChecks(e)
+		if err != nil {
+			return err
+		}
+		e.takeover = takeover
+	}
+	if e.DetectNSInconsistency {
+		e.nscheck = newNSConsistencyChecks(e)
+	}
+	if e.SOASerialFile != "" {
+		soaser, err := newSOASerials(e, e.SOASerialFile)
+		if err != nil {
+			return err
+		}
+		e.soaser = soaser
+		e.log().Infof("The SOA serials of this enumeration carry the event UUID %s", soaser.uuid)
+	}
+	if p := e.PrivateAddressPolicy; p == PrivateAddressFlag || p == PrivateAddressDrop {
+		e.privaddr = newPrivateAddresses(e)
+	}
+	if e.DetectRebinding {
+		rebind, err := newRebindingChecks(e)
+		if err != nil {
+			return err
+		}
+		e.rebind = rebind
+	}
+	if len(e.VantageProxies) > 0 {
+		vp, err := newVantagePoints(e)
+		if err != nil {
+			return err
+		}
+		e.vantage = vp
+	}
+	if len(e.WildcardDetectRCODEs) > 0 {
+		rcodes, err := newRcodeWildcards(e)
+		if err != nil {
+			return err
+		}
+		e.rcodes = rcodes
+	}
+	if len(e.KnownWildcards) > 0 {
+		known, err := newKnownWildcards(e)
+		if err != nil {
+			return err
+		}
+		e.known = known
+	}
+	if len(e.ResolversByType) > 0 {
+		if err := e.buildTypePools(); err != nil {
+			return err
+		}
+		defer e.stopTypePools()
+	}
+	if len(e.DomainResolverAffinity) > 0 {
+		if err := e.buildAffinityPools(); err != nil {
+			return err
+		}
+		defer e.stopAffinityPools()
+	}
+	if e.UseDNSCookies {
+		cookies, err := newDNSCookies()
+		if err != nil {
+			return fmt.Errorf("failed to generate the DNS client cookie: %v", err)
+		}
+		e.cookies = cookies
+	}
+	if e.OutputTemplate != "" {
+		w := e.OutputWriter
+		if w == nil {
+			w = os.Stdout
+		}
+
+		var comp compressor
+		if e.CompressOutput != "" {
+			c, err := newCompressor(e.CompressOutput, w)
+			if err != nil {
+				return err
+			}
+			comp = c
+			w = c
+		}
+
+		tmplout, err := newTemplateOutput(e.OutputTemplate, w)
+		if err != nil {
+			return err
+		}
+		tmplout.comp = comp
+		e.tmplout = tmplout
+		e.addOutput("templated", tmplout)
+	}
+	if e.RawResponseDir != "" {
+		rawlog, err := newRawResponseLog(e.RawResponseDir)
+		if err != nil {
+			return err
+		}
+		e.rawlog = rawlog
+		defer func() {
+			if err := e.rawlog.close(); err != nil {
+				e.log().Errorf("Failed to write the raw DNS responses: %v", err)
+			}
+		}()
+	}
+	if e.GraphFailoverFile != "" {
+		failover, err := newGraphFailover(e.GraphFailoverFile)
+		if err != nil {
+			return err
+		}
+		e.failover = failover
+		defer e.stopGraphFailover()
+	}
+	if e.ProvidedNamesCSV != "" {
+		if err := e.loadProvidedNamesCSV(e.ProvidedNamesCSV); err != nil {
+			return err
+		}
+	}
+	if e.ProvidedCertificatesPEM != "" {
+		if err := e.loadProvidedCertificates(e.ProvidedCertificatesPEM); err != nil {
+			return err
+		}
+	}
 	// This context, used throughout the enumeration, will provide the
 	// ability to pass the configuration and event bus to all the components
-	var cancel context.CancelFunc
-	e.ctx, cancel = context.WithCancel(ctx)
-	defer cancel()
+	ctx = amassnet.WithDialer(ctx, e.netDialer())
+	if e.MaxDuration > 0 {
+		e.ctx, e.cancel = context.WithTimeout(ctx, e.MaxDuration)
+	} else {
+		e.ctx, e.cancel = context.WithCancel(ctx)
+	}
+	defer e.cancel()
+	if d := e.netDialer(); d != nil {
+		defer e.setSourceDialers(d)()
+	}
+	if e.DataSourceCheckpointDir != "" {
+		srccp, err := newSourceCheckpoints(e)
+		if err != nil {
+			return err
+		}
+		e.srccp = srccp
+		go srccp.run(e.done)
+	}
 	go e.manageDataSrcRequests()
+	e.tracked = newTrackedWork(maxTrackedWork)
+	if len(e.CanaryChecks) > 0 {
+		go e.verifyCanaries(e.ctx)
+	}
 
+	if e.horizon = newSplitHorizon(e); e.horizon != nil {
+		defer e.horizon.stop()
+	}
+	if e.TestOpenResolvers {
+		e.openres = newOpenResolverTests(e)
+		defer e.openres.stop()
+	}
+	if e.ProbeHTTP {
+		e.prober = newHTTPProber(e)
+		defer e.prober.stop()
+	}
+	if e.TCPPoolMaxIdle > 0 {
+		e.tcpConns = newTCPPool(e)
+		defer e.tcpConns.stop()
+	}
+	if e.CheckLameDelegation {
+		e.lame = newLameDelegationTests(e)
+	}
+	e.events = nil
+	if e.PartitionByDomain {
+		e.events = newDomainEvents()
+	}
+	if e.ValidateAgainstAuthoritative {
+		e.authval = newAuthValidator(e)
+	}
+	if e.NegativeCacheTTL > 0 {
+		e.negcache = newNegativeCache(e.NegativeCacheTTL, e.NegativeCacheSize)
+	}
+
+	e.startQPSRamps()
+	e.cnames = newCNAMEWildcards(e)
+	e.chains = newCNAMEChains()
 	e.dnsTask = newDNSTask(e, false)
 	e.valTask = newDNSTask(e, true)
 	e.store = newDataManager(e)
@@ -74,49 +1225,100 @@ func (e *Enumeration) Start(ctx context.Context) error {
 	defer e.valTask.stop()
 
 	var stages []pipeline.Stage
-	stages = append(stages, pipeline.FIFO("root", e.valTask.rootTaskFunc()))
+	if !e.resolveOnly() {
+		stages = append(stages, pipeline.FIFO("root", e.valTask.rootTaskFunc()))
+	}
 	stages = append(stages, pipeline.FIFO("dns", e.dnsTask))
 	stages = append(stages, pipeline.FIFO("validate", e.valTask))
 	stages = append(stages, pipeline.FIFO("store", e.store))
-	stages = append(stages, pipeline.FIFO("", e.subTask))
+	if !e.resolveOnly() {
+		stages = append(stages, pipeline.FIFO("", e.subTask))
+	}
 
 	p := pipeline.NewPipeline(stages...)
 	// The pipeline input source will receive all the names
-	e.nameSrc = newEnumSource(p, e)
-	defer e.nameSrc.Stop()
+	src := newEnumSource(p, e)
+	e.srcLock.Lock()
+	e.nameSrc = src
+	e.srcLock.Unlock()
+	defer src.Stop()
+	if e.floods != nil {
+		go e.floods.run(src)
+	}
 
 	e.submitASNs()
 	e.submitDomainNames()
+	e.stageComplete(StageSeeded)
 	/*
 	 * Now that the pipeline input source has been setup, names provided
 	 * by the user and names acquired from the graph database can be brought
 	 * into the enumeration
 	 */
-	go e.submitKnownNames()
-	go e.submitProvidedNames()
+	var submitters sync.WaitGroup
+	submitters.Add(2)
+	go func() {
+		defer submitters.Done()
+		e.submitKnownNames()
+	}()
+	go func() {
+		defer submitters.Done()
+		e.submitProvidedNames()
+	}()
+	submitted := make(chan struct{})
+	go func() {
+		submitters.Wait()
+		e.stageComplete(StageNamesSubmitted)
+		close(submitted)
+	}()
 
-	err := p.ExecuteBuffered(e.ctx, e.nameSrc, e.makeOutputSink(), 50)
+	stopFlush := e.startOutputFlusher()
+	err := p.ExecuteBuffered(e.ctx, e.nameSrc, e.makeOutputSink(), e.PipelineBufferSize)
+	// The work started outside of the pipeline can still be storing data
+	e.tracked.wait()
+	// The submissions stop once the input source is done
+	<-submitted
+	e.stageComplete(StagePipelineDrained)
 	// Ensure all data has been stored
 	<-e.store.Stop()
+	e.stageComplete(StageStored)
+	stopFlush()
+	if e.srccp != nil {
+		e.srccp.write()
+	}
+	if e.soaser != nil {
+		if serr := e.soaser.write(); serr != nil {
+			e.log().Errorf("Failed to write the SOA serial file %s: %v", e.SOASerialFile, serr)
+		}
+	}
+	for _, o := range e.outputs {
+		if oerr := o.stop(); oerr != nil {
+			e.log().Errorf("Failed to complete the %s output: %v", o.name, oerr)
+		}
+	}
 	return err
 }
 
 // Release the root domain names to the input source and each data source.
 func (e *Enumeration) submitDomainNames() {
+	if e.ValidateListOnly {
+		return
+	}
+
 	for _, domain := range e.Config.Domains() {
-		req := &requests.DNSRequest{
-			Name:   domain,
-			Domain: domain,
+		if e.events != nil {
+			e.log().Infof("The results for %s carry the sub-event UUID %s", domain, e.events.assign(domain))
 		}
-
-		e.nameSrc.newName(req)
-		e.sendRequests(req.Clone().(*requests.DNSRequest))
+		e.submitDomain(domain)
 	}
 }
 
 // If requests were made for specific ASNs, then those requests are
 // sent to included data sources at this point.
 func (e *Enumeration) submitASNs() {
+	if e.resolveOnly() {
+		return
+	}
+
 	for _, asn := range e.Config.Scope.ASNs {
 		e.sendRequests(&requests.ASNRequest{ASN: asn})
 	}
@@ -126,6 +1328,86 @@ func (e *Enumeration) sendRequests(element interface{}) {
 	e.requests.Append(element)
 }
 
+// dialerSetter is implemented by the data sources establishing their own connections, such as the scripts.
+type dialerSetter interface {
+	SetDialer(d proxy.ContextDialer)
+}
+
+// setSourceDialers causes the data sources of the enumeration to establish their connections through
+// the dialer, and returns the function restoring the default dialer of the data sources.
+func (e *Enumeration) setSourceDialers(d proxy.ContextDialer) func() {
+	var set []dialerSetter
+	for _, src := range e.srcs {
+		if ds, ok := src.(dialerSetter); ok {
+			ds.SetDialer(d)
+			set = append(set, ds)
+		}
+	}
+
+	return func() {
+		for _, ds := range set {
+			ds.SetDialer(nil)
+		}
+	}
+}
+
+// requestIdentity returns the key identifying the data source request, so the same request is not
+// sent twice to a data source. The empty string is returned for the requests that are always sent.
+func requestIdentity(element interface{}) string {
+	switch v := element.(type) {
+	case *requests.DNSRequest:
+		return "dns:" + strings.ToLower(v.Name) + "|" + strings.ToLower(v.Domain)
+	case *requests.ASNRequest:
+		if v.ASN > 0 {
+			return "asn:" + strconv.Itoa(v.ASN)
+		}
+		if v.Address != "" {
+			return "asn_addr:" + v.Address
+		}
+	}
+	return ""
+}
+
+// AddDataSource registers a custom data source, such as an internal asset inventory, that receives
+// requests alongside the built-in data sources. It must be called before Start, and the source is
+// started and added to the System, which stops it during shutdown. The source must implement the
+// service.Service contract, which is easiest by embedding service.BaseService:
+//
+//   - String returns the unique name used for the request caps, rate limiting and attribution
+//   - Description returns the type of the source, such as "api" or "scrape"
+//   - HandlesReq returns true for the requests the source processes (e.g. *requests.DNSRequest)
+//   - Input receives the requests, and must be read continuously until Done is closed
+//   - Output sends the discovered *requests.DNSRequest and *requests.AddrRequest values, and
+//     requests.ErrRateLimited pauses the requests sent to the source
+//   - Done is closed once the source has been stopped
+func (e *Enumeration) AddDataSource(src service.Service) error {
+	if e.done != nil {
+		return errors.New("data sources cannot be added after the enumeration has been started")
+	}
+
+	name := src.String()
+	for _, s := range e.srcs {
+		if s.String() == name {
+			return fmt.Errorf("a data source named %s has already been added", name)
+		}
+	}
+
+	if err := e.Sys.AddAndStart(src); err != nil {
+		return fmt.Errorf("failed to start the %s data source: %v", name, err)
+	}
+	e.srcs = append(e.srcs, src)
+	return nil
+}
+
+// sourceRateLimited informs the data source request manager that the named source is being rate limited.
+func (e *Enumeration) sourceRateLimited(name string) {
+	select {
+	case <-e.done:
+	case <-e.ctx.Done():
+	case e.limited <- name:
+	}
+}
+
 func (e *Enumeration) manageDataSrcRequests() {
 	nameToSrc := make(map[string]service.Service)
 	for _, src := range e.srcs {
@@ -139,6 +1421,45 @@ func (e *Enumeration) manageDataSrcRequests() {
 
 	finished := make(chan string, len(e.srcs)*2)
 	requestsMap := make(map[string][]interface{})
+	// data sources that are rate limited do not receive requests until the cooldown expires
+	cooldowns := make(map[string]time.Time)
+	coolingDown := func(name string) bool {
+		if until, found := cooldowns[name]; found {
+			if time.Now().Before(until) {
+				return true
+			}
+			delete(cooldowns, name)
+		}
+		return false
+	}
+	// data sources do not receive the same request twice, such as a domain submitted again
+	seen := make(map[string]map[string]struct{})
+	duplicate := func(name, id string) bool {
+		if id == "" {
+			return false
+		}
+		if _, found := seen[name][id]; found {
+			return true
+		}
+		if seen[name] == nil {
+			seen[name] = make(map[string]struct{})
+		}
+		seen[name][id] = struct{}{}
+		return false
+	}
+	// data sources that reach their request cap do not receive additional requests
+	fired := make(map[string]int)
+	capped := func(name string) bool {
+		max, found := e.sourceRequestCap(name)
+		if !found || max <= 0 || fired[name] < max {
+			return false
+		}
+		if fired[name] == max {
+			e.log().Infof("%s: Reached the cap of %d requests", name, max)
+			fired[name]++
+		}
+		return true
+	}
 loop:
 	for {
 		select {
@@ -152,18 +1473,61 @@ loop:
 				continue loop
 			}
 
+			id := requestIdentity(element)
 			for name := range nameToSrc {
-				if src := nameToSrc[name]; src != nil && src.HandlesReq(element) {
-					if len(requestsMap[name]) == 0 && !pending[name] {
+				if src := nameToSrc[name]; src != nil && src.HandlesReq(element) && !e.balanceExcludes(src) && !capped(name) && !duplicate(name, id) {
+					if len(requestsMap[name]) == 0 && !pending[name] && !coolingDown(name) && !e.dataSourcesPaused() {
 						go e.fireRequest(src, element, finished)
 						pending[name] = true
+						fired[name]++
 					} else {
 						requestsMap[name] = append(requestsMap[name], element)
 					}
 				}
 			}
+		case name := <-e.limited:
+			if _, found := nameToSrc[name]; !found || coolingDown(name) {
+				continue loop
+			}
+
+			e.log().Warnf("%s: Rate limited, pausing requests for %s", name, sourceCooldown)
+			cooldowns[name] = time.Now().Add(sourceCooldown)
+			go func(n string) {
+				t := time.NewTimer(sourceCooldown)
+				defer t.Stop()
+
+				select {
+				case <-e.done:
+				case <-e.ctx.Done():
+				case <-t.C:
+					finished <- n
+				}
+			}(name)
+		case <-e.resume:
+			// the requests held while the data sources were paused are released
+			for name, queued := range requestsMap {
+				if len(queued) == 0 || pending[name] || coolingDown(name) {
+					continue
+				}
+				if capped(name) {
+					requestsMap[name] = nil
+					continue
+				}
+
+				go e.fireRequest(nameToSrc[name], queued[0], finished)
+				requestsMap[name] = queued[1:]
+				pending[name] = true
+				fired[name]++
+			}
+			e.setRequestsPending(pending)
 		case name := <-finished:
-			if len(requestsMap[name]) == 0 {
+			if coolingDown(name) {
+				continue loop
+			}
+			if capped(name) {
+				requestsMap[name] = nil
+			}
+			if len(requestsMap[name]) == 0 || e.dataSourcesPaused() {
 				pending[name] = false
 				e.setRequestsPending(pending)
 				continue loop
@@ -171,6 +1535,7 @@ loop:
 
 			go e.fireRequest(nameToSrc[name], requestsMap[name][0], finished)
 			requestsMap[name] = requestsMap[name][1:]
+			fired[name]++
 		}
 	}
 	e.requests.Process(func(e interface{}) {})
@@ -180,7 +1545,7 @@ func (e *Enumeration) requestsPending() bool {
 	e.plock.Lock()
 	defer e.plock.Unlock()
 
-	return e.pending
+	return e.pending || e.tracked.pending()
 }
 
 func (e *Enumeration) setRequestsPending(p map[string]bool) {
@@ -199,28 +1564,235 @@ func (e *Enumeration) setRequestsPending(p map[string]bool) {
 }
 
 func (e *Enumeration) fireRequest(srv service.Service, req interface{}, finished chan string) {
+	if !e.waitForBalance(srv) {
+		finished <- srv.String()
+		return
+	}
+
+	var expired <-chan time.Time
+	timeout := e.sourceTimeout(srv.String())
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		expired = t.C
+	}
+
 	select {
 	case <-e.done:
 	case <-e.ctx.Done():
 	case <-srv.Done():
+	case <-expired:
+		e.log().Warnf("Data source %s timed out after %s, and the request was skipped", srv.String(), timeout)
 	case srv.Input() <- req:
 	}
 	finished <- srv.String()
 }
 
+// markSeen sets the discovery timestamps of the resolved name. The first seen time comes from the
+// graph, or the earlier discovery during the enumeration, and a repeated discovery only moves the
+// last seen time forward.
+func (e *Enumeration) markSeen(req *requests.DNSRequest) {
+	now := time.Now().UTC()
+
+	e.seenLock.Lock()
+	defer e.seenLock.Unlock()
+
+	if e.seen == nil {
+		e.seen = make(map[string]time.Time)
+	}
+	first, found := e.seen[req.Name]
+	if !found {
+		first = now
+		if e.graph != nil {
+			if assets, err := e.graph.DB.FindByContent(domain.FQDN{Name: req.Name}, time.Time{}); err == nil {
+				for _, a := range assets {
+					if !a.CreatedAt.IsZero() && a.CreatedAt.Before(first) {
+						first = a.CreatedAt.UTC()
+					}
+				}
+			}
+		}
+		e.seen[req.Name] = first
+	}
+
+	req.FirstSeen = first
+	if req.LastSeen.Before(now) {
+		req.LastSeen = now
+	}
+}
+
+// sourceTimeout returns the timeout of the data source from SourceTimeouts, or DefaultSourceTimeout
+// when the source is not listed. The data source names are matched without regard to case.
+func (e *Enumeration) sourceTimeout(name string) time.Duration {
+	if d, found := e.SourceTimeouts[name]; found {
+		return d
+	}
+	for src, d := range e.SourceTimeouts {
+		if strings.EqualFold(src, name) {
+			return d
+		}
+	}
+	return e.DefaultSourceTimeout
+}
+
+// NewlyDiscovered returns true when the name was not in the graph before this enumeration started.
+func (e *Enumeration) NewlyDiscovered(name string) bool {
+	assets, err := e.graph.DB.FindByContent(domain.FQDN{Name: name}, time.Time{})
+	if err != nil || len(assets) == 0 {
+		return true
+	}
+
+	for _, a := range assets {
+		if e.SeenBefore(a.CreatedAt) {
+			return false
+		}
+	}
+	return true
+}
+
+// SeenBefore returns true when the first seen timestamp from the graph precedes this enumeration.
+// The graph timestamps have a precision of one second, so the start time is truncated to match.
+func (e *Enumeration) SeenBefore(first time.Time) bool {
+	return first.Before(e.Config.CollectionStartTime.UTC().Truncate(time.Second))
+}
+
+// outputWriter is implemented by the outputs receiving the results from the pipeline sink.
+type outputWriter interface {
+	// write delivers the request and the source history of the name to the output
+	write(req *requests.DNSRequest, sources []string) error
+	// stop completes the output once all the data of the enumeration has been stored
+	stop() error
+}
+
+// namedOutput is a registered output, with the name identifying it in the log messages.
+type namedOutput struct {
+	outputWriter
+	name string
+}
+
+// addOutput registers the output, so it receives each result of the sink and is stopped by Start.
+func (e *Enumeration) addOutput(name string, w outputWriter) {
+	e.outputs = append(e.outputs, namedOutput{outputWriter: w, name: name})
+}
+
 func (e *Enumeration) makeOutputSink() pipeline.SinkFunc {
 	return pipeline.SinkFunc(func(ctx context.Context, data pipeline.Data) error {
+		req, ok := data.(*requests.DNSRequest)
+		if ok && e.OnlyNewNames && !e.NewlyDiscovered(req.Name) {
+			return nil
+		}
+		if ok && e.belowMinConfidence(req.Name) {
+			return nil
+		}
+		if ok && ((e.OutputActiveOnly && !req.Active) || (e.OutputPassiveOnly && req.Active)) {
+			return nil
+		}
+		if ok && e.MaxResults > 0 && !e.countResult(req) {
+			return nil
+		}
+		if ok && len(req.Records) > 0 {
+			e.markSeen(req)
+		}
+		if ok {
+			e.countSource(req)
+		}
+		// the outputs receive the redacted request, while the graph keeps the full data
+		var out *requests.DNSRequest
+		var history []string
+		if ok && len(req.Records) > 0 {
+			out = e.redactRequest(req)
+			if !e.Redacted("source") {
+				history = e.SourceHistory(req.Name)
+			}
+			e.publishOutput(out)
+		}
+		if ok && e.prober != nil {
+			e.prober.probe(e.ctx, req)
+		}
+		if ok && len(req.Records) > 0 {
+			for _, o := range e.outputs {
+				if err := o.write(out, history); err != nil {
+					e.log().Errorf("Failed to write the %s output: %v", o.name, err)
+				}
+			}
+		}
 		return nil
 	})
 }
 
+// countResult returns false when the name would exceed MaxResults, and cancels
+// the enumeration once the number of resolved names in scope reaches the limit.
+func (e *Enumeration) countResult(req *requests.DNSRequest) bool {
+	if len(req.Records) == 0 || !e.Config.IsDomainInScope(req.Name) {
+		return true
+	}
+
+	e.resLock.Lock()
+	defer e.resLock.Unlock()
+
+	if e.results == nil {
+		e.results = make(map[string]struct{})
+	}
+	if _, found := e.results[req.Name]; found {
+		return true
+	}
+	if len(e.results) >= e.MaxResults {
+		return false
+	}
+
+	e.results[req.Name] = struct{}{}
+	if len(e.results) == e.MaxResults {
+		e.log().Infof("Reached the maximum of %d results, stopping the enumeration", e.MaxResults)
+		if e.cancel != nil {
+			e.cancel()
+		}
+	}
+	return true
+}
+
+// resolveOnly returns true when the enumeration is limited to the forward resolution of the names.
+func (e *Enumeration) resolveOnly() bool {
+	return e.ResolveOnly || e.ValidateListOnly
+}
+
 func (e *Enumeration) submitKnownNames() {
-	for _, g := range e.Sys.GraphDatabases() {
-		e.readNamesFromDatabase(g)
+	if e.ValidateListOnly {
+		return
+	}
+
+	var names []string
+	// Names found in multiple databases are submitted once with the sources combined
+	sources := make(map[string][]string)
+	for i, g := range e.Sys.GraphDatabases() {
+		src := fmt.Sprintf("Graph Database %d", i+1)
+
+		for _, name := range e.readNamesFromDatabase(g) {
+			if _, found := sources[name]; !found {
+				names = append(names, name)
+			}
+			sources[name] = append(sources[name], src)
+		}
+	}
+
+	for _, name := range names {
+		select {
+		case <-e.done:
+			return
+		default:
+		}
+
+		e.nameSrc.newName(&requests.DNSRequest{
+			Name:   name,
+			Domain: e.Config.WhichDomain(name),
+			Source: strings.Join(sources[name], ", "),
+		})
 	}
 }
 
-func (e *Enumeration) readNamesFromDatabase(db *netmap.Graph) {
+func (e *Enumeration) readNamesFromDatabase(db *netmap.Graph) []string {
+	var names []string
+	seen := make(map[string]struct{})
+
 	for _, d := range e.Config.Domains() {
 		assets, err := db.DB.FindByScope([]oam.Asset{domain.FQDN{Name: d}}, time.Time{})
 		if err != nil {
@@ -231,36 +1803,104 @@ func (e *Enumeration) readNamesFromDatabase(db *netmap.Graph) {
 			if fqdn, ok := a.Asset.(domain.FQDN); ok {
 				select {
 				case <-e.done:
-					return
+					return names
 				default:
 				}
 
-				domain := e.Config.WhichDomain(fqdn.Name)
-				if domain == "" {
+				name := strings.ToLower(fqdn.Name)
+				if e.Config.WhichDomain(name) == "" {
 					continue
 				}
-
-				e.nameSrc.newName(&requests.DNSRequest{
-					Name:   fqdn.Name,
-					Domain: domain,
-				})
+				if _, found := seen[name]; !found {
+					seen[name] = struct{}{}
+					names = append(names, name)
+				}
 			}
 		}
 	}
+	return names
 }
 
 func (e *Enumeration) submitProvidedNames() {
+	provided := append(append([]ProvidedName(nil), e.ProvidedNamesDetailed...), e.csvNames...)
+	provided = append(provided, e.pemNames...)
 	for _, name := range e.Config.ProvidedNames {
+		provided = append(provided, ProvidedName{Name: name})
+	}
+
+	for _, p := range provided {
 		select {
 		case <-e.done:
 			return
 		default:
 		}
-		if domain := e.Config.WhichDomain(name); domain != "" {
-			e.nameSrc.newName(&requests.DNSRequest{
+
+		tag := p.Tag
+		if tag == "" {
+			tag = requests.EXTERNAL
+		}
+		source := p.Source
+		if source == "" {
+			source = "User Input"
+		}
+
+		name, err := toASCIIName(strings.ToLower(strings.TrimSpace(p.Name)))
+		if err != nil {
+			e.log().Errorf("Failed to submit the provided name: %v", err)
+			continue
+		}
+		pdomain, err := toASCIIName(strings.ToLower(strings.TrimSpace(p.Domain)))
+		if err != nil {
+			e.log().Errorf("Failed to submit the provided name %s: %v", p.Name, err)
+			continue
+		}
+
+		domain := e.Config.WhichDomain(name)
+		if pdomain != "" && e.Config.IsDomainInScope(pdomain) {
+			domain = pdomain
+		}
+		if domain != "" {
+			e.nameSrc.newProvidedName(&requests.DNSRequest{
 				Name:   name,
 				Domain: domain,
+				Tag:    tag,
+				Source: source,
 			})
 		}
 	}
 }
+
+// SubmitName feeds a name discovered outside of the enumeration into the running pipeline. It is
+// safe to call from other goroutines, blocks while MaxInFlightNames has been reached, and returns
+// an error when the name is out of scope or the enumeration is not currently running.
+func (e *Enumeration) SubmitName(name string) error {
+	e.srcLock.RLock()
+	src := e.nameSrc
+	e.srcLock.RUnlock()
+
+	if src == nil {
+		return errors.New("the enumeration has not been started")
+	}
+	select {
+	case <-src.done:
+		return errors.New("the enumeration is shutting down")
+	default:
+	}
+
+	name, err := toASCIIName(strings.ToLower(strings.TrimSpace(name)))
+	if err != nil {
+		return err
+	}
+	domain := e.Config.WhichDomain(name)
+	if domain == "" {
+		return fmt.Errorf("the name %s is not in scope", name)
+	}
+
+	src.newName(&requests.DNSRequest{
+		Name:   name,
+		Domain: domain,
+		Tag:    requests.EXTERNAL,
+		Source: "User Input",
+	})
+	return nil
+}
@@ -0,0 +1,574 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/caffix/queue"
+	"github.com/caffix/service"
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+func TestSubmitName(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{Config: cfg}
+
+	if err := e.SubmitName("www.owasp.org"); err == nil {
+		t.Errorf("the name was accepted before the enumeration started")
+	}
+
+	r := newTestEnumSource(e, 10)
+	e.nameSrc = r
+
+	if err := e.SubmitName(" WWW.owasp.org "); err != nil {
+		t.Errorf("the in scope name was rejected: %v", err)
+	}
+	if err := e.SubmitName("www.example.com"); err == nil {
+		t.Errorf("the out of scope name was accepted")
+	}
+	if r.queue.Len() != 1 {
+		t.Fatalf("expected one name to be queued, got %d", r.queue.Len())
+	}
+	if element, ok := r.queue.Next(); !ok {
+		t.Errorf("failed to obtain the queued name")
+	} else if req := element.(*requests.DNSRequest); req.Name != "www.owasp.org" || req.Domain != "owasp.org" {
+		t.Errorf("the queued name %s in domain %s was not expected", req.Name, req.Domain)
+	}
+
+	r.markDone()
+	if err := e.SubmitName("mail.owasp.org"); err == nil {
+		t.Errorf("the name was accepted after the enumeration terminated")
+	}
+}
+
+func TestRediscoveryTimestamps(t *testing.T) {
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	if _, err := g.UpsertFQDN(context.Background(), "old.owasp.org"); err != nil {
+		t.Fatalf("failed to insert the name from the prior enumeration: %v", err)
+	}
+	assets, err := g.DB.FindByContent(domain.FQDN{Name: "old.owasp.org"}, time.Time{})
+	if err != nil || len(assets) == 0 {
+		t.Fatalf("failed to obtain the name from the prior enumeration: %v", err)
+	}
+	// The graph timestamps have a precision of one second
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{Config: cfg, graph: g}
+	records := []requests.DNSAnswer{{Name: "old.owasp.org", Type: 1, Data: "192.0.2.1"}}
+
+	req := &requests.DNSRequest{Name: "old.owasp.org", Domain: "owasp.org", Records: records}
+	e.markSeen(req)
+	if !req.FirstSeen.Equal(assets[0].CreatedAt) {
+		t.Errorf("expected the first seen time %s from the graph, but got %s", assets[0].CreatedAt, req.FirstSeen)
+	}
+	if !req.FirstSeen.Before(req.LastSeen) {
+		t.Errorf("expected the last seen time %s to follow the first seen time %s", req.LastSeen, req.FirstSeen)
+	}
+
+	// the repeated discovery keeps the first seen time, and moves the last seen time forward
+	time.Sleep(10 * time.Millisecond)
+	again := &requests.DNSRequest{Name: "old.owasp.org", Domain: "owasp.org", Records: records}
+	e.markSeen(again)
+	if !again.FirstSeen.Equal(req.FirstSeen) {
+		t.Errorf("the rediscovery changed the first seen time from %s to %s", req.FirstSeen, again.FirstSeen)
+	}
+	if !again.LastSeen.After(req.LastSeen) {
+		t.Errorf("the rediscovery did not update the last seen time %s", req.LastSeen)
+	}
+
+	fresh := &requests.DNSRequest{Name: "new.owasp.org", Domain: "owasp.org", Records: records}
+	e.markSeen(fresh)
+	if fresh.FirstSeen.IsZero() || !fresh.FirstSeen.Equal(fresh.LastSeen) {
+		t.Errorf("expected the name discovered once to be first and last seen at the same time, but got %s and %s",
+			fresh.FirstSeen, fresh.LastSeen)
+	}
+}
+
+func TestRootDomain(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomains("owasp.org", "dev.owasp.org", "example.com")
+	e := &Enumeration{Config: cfg}
+
+	tests := []struct {
+		name      string
+		partition bool
+		expected  string
+	}{
+		{"www.owasp.org", false, "owasp.org"},
+		{"www.owasp.org", true, "owasp.org"},
+		{"api.dev.owasp.org", true, "dev.owasp.org"},
+		{"DEV.owasp.org", true, "dev.owasp.org"},
+		{"mail.example.com", true, "example.com"},
+		{"notowasp.org", true, ""},
+		{"www.example.net", true, ""},
+	}
+
+	for _, test := range tests {
+		e.PartitionByDomain = test.partition
+		if got := e.RootDomain(test.name); got != test.expected {
+			t.Errorf("%s: expected root domain %s, but got %s", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestNameExclusions(t *testing.T) {
+	e := &Enumeration{
+		Config: config.NewConfig(),
+		ExcludeNameRegexps: []string{
+			`^[0-9a-f]{16,}\.`,
+			`cache-[0-9]+`,
+			`^dev\.owasp\.org$`,
+		},
+	}
+	if err := e.compileNameExclusions(); err != nil {
+		t.Fatalf("failed to compile the valid expressions: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"3f2a9c4d5e6f7a8b9c.owasp.org", true},
+		{"www.3f2a9c4d5e6f7a8b9c.owasp.org", false},
+		{"cache-1234.cdn.owasp.org", true},
+		{"edge.cache-99.owasp.org", true},
+		{"cache.owasp.org", false},
+		{"dev.owasp.org", true},
+		{"api.dev.owasp.org", false},
+		{"www.owasp.org", false},
+	}
+
+	for _, test := range tests {
+		if got := e.excludedName(test.name); got != test.expected {
+			t.Errorf("%s: expected %t, but got %t", test.name, test.expected, got)
+		}
+	}
+
+	e.ExcludeNameRegexps = append(e.ExcludeNameRegexps, `[a-z`)
+	if err := e.compileNameExclusions(); err == nil {
+		t.Errorf("the invalid expression was accepted")
+	}
+}
+
+func TestNewlyDiscovered(t *testing.T) {
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{Config: cfg, graph: g}
+
+	ctx := context.Background()
+	if _, err := g.UpsertFQDN(ctx, "old.owasp.org"); err != nil {
+		t.Fatalf("failed to insert the name from the prior enumeration: %v", err)
+	}
+	// The graph timestamps have a precision of one second
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	cfg.CollectionStartTime = time.Now()
+
+	for _, name := range []string{"old.owasp.org", "new.owasp.org"} {
+		if _, err := g.UpsertFQDN(ctx, name); err != nil {
+			t.Fatalf("failed to insert %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"old.owasp.org", false},
+		{"new.owasp.org", true},
+		{"missing.owasp.org", true},
+	}
+
+	for _, test := range tests {
+		if got := e.NewlyDiscovered(test.name); got != test.expected {
+			t.Errorf("%s: expected %t, but got %t", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestQueryName(t *testing.T) {
+	e := &Enumeration{Config: config.NewConfig()}
+	if got := e.queryName("www.us-east.owasp.org"); got != "www.us-east.owasp.org" {
+		t.Errorf("the name was changed without a rewriter: %s", got)
+	}
+
+	e.NameRewriter = func(name string) string {
+		if strings.HasPrefix(name, "skip.") {
+			return ""
+		}
+		return strings.Replace(name, ".us-east.", ".", 1)
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"www.us-east.owasp.org", "www.owasp.org"},
+		{"www.owasp.org", "www.owasp.org"},
+		{"skip.us-east.owasp.org", "skip.us-east.owasp.org"},
+	}
+
+	for _, test := range tests {
+		if got := e.queryName(test.name); got != test.expected {
+			t.Errorf("%s: expected %s, but got %s", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestSourceNameFilters(t *testing.T) {
+	e := &Enumeration{Config: config.NewConfig()}
+	if !e.sourceNameAllowed(&requests.DNSRequest{Name: "dev.owasp.org", Source: "Crtsh"}) {
+		t.Errorf("the name was rejected without filters")
+	}
+
+	e.SourceNameFilters = map[string]func(string) bool{
+		"Crtsh": func(name string) bool { return !strings.HasPrefix(name, "dev.") },
+	}
+
+	tests := []struct {
+		name     string
+		source   string
+		expected bool
+	}{
+		{"dev.owasp.org", "Crtsh", false},
+		{"dev.owasp.org", "crtsh", false},
+		{"www.owasp.org", "Crtsh", true},
+		{"dev.owasp.org", "DNS", true},
+		{"dev.owasp.org", "", true},
+	}
+
+	for _, test := range tests {
+		req := &requests.DNSRequest{Name: test.name, Source: test.source}
+		if got := e.sourceNameAllowed(req); got != test.expected {
+			t.Errorf("%s from %q: expected %t, but got %t", test.name, test.source, test.expected, got)
+		}
+	}
+}
+
+func TestAnswerProcessors(t *testing.T) {
+	var order []string
+	e := &Enumeration{Config: config.NewConfig()}
+	e.AnswerProcessors = []func(*requests.DNSRequest) error{
+		func(req *requests.DNSRequest) error {
+			order = append(order, "first")
+			req.Records[0].Data = strings.ToLower(req.Records[0].Data)
+			return nil
+		},
+		func(req *requests.DNSRequest) error {
+			order = append(order, "second")
+			if strings.HasPrefix(req.Name, "drop.") {
+				return fmt.Errorf("rejected")
+			}
+			return nil
+		},
+		func(req *requests.DNSRequest) error {
+			order = append(order, "third")
+			return nil
+		},
+	}
+
+	if err := e.processAnswers(&requests.DNSRequest{Name: "www.owasp.org"}); err != nil || len(order) != 0 {
+		t.Errorf("the processors were called for the unresolved request")
+	}
+
+	req := &requests.DNSRequest{
+		Name:    "www.owasp.org",
+		Records: []requests.DNSAnswer{{Name: "www.owasp.org", Type: int(dns.TypeCNAME), Data: "WEB.owasp.org"}},
+	}
+	if err := e.processAnswers(req); err != nil {
+		t.Errorf("the request was rejected: %v", err)
+	}
+	if req.Records[0].Data != "web.owasp.org" {
+		t.Errorf("the change of the processor was lost: %s", req.Records[0].Data)
+	}
+	if got := strings.Join(order, ","); got != "first,second,third" {
+		t.Errorf("the processors were called out of order: %s", got)
+	}
+
+	order = nil
+	req.Name = "drop.owasp.org"
+	if err := e.processAnswers(req); err == nil {
+		t.Errorf("the error of the processor was not returned")
+	}
+	if got := strings.Join(order, ","); got != "first,second" {
+		t.Errorf("the processors after the error were called: %s", got)
+	}
+}
+
+func TestAddressInScope(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	_, cidr, _ := net.ParseCIDR("192.0.2.0/24")
+	e := &Enumeration{Config: cfg, ScopeCIDRs: []*net.IPNet{cidr}}
+
+	req := &requests.DNSRequest{
+		Name:    "www.example.com",
+		Records: []requests.DNSAnswer{{Type: int(dns.TypeA), Data: "192.0.2.10"}},
+	}
+	if !e.nameInScope(req) || !e.addrPermitted("198.51.100.1") {
+		t.Errorf("the ScopeCIDRs were not used without the hook")
+	}
+
+	var asked []string
+	e.AddressInScope = func(ip net.IP) bool {
+		asked = append(asked, ip.String())
+		return ip.Equal(net.ParseIP("198.51.100.1"))
+	}
+	if e.nameInScope(req) {
+		t.Errorf("the hook did not replace the ScopeCIDRs")
+	}
+	req.Records[0].Data = "198.51.100.1"
+	if !e.nameInScope(req) {
+		t.Errorf("the address accepted by the hook was not in scope")
+	}
+	if e.addrPermitted("192.0.2.10") || !e.addrPermitted("198.51.100.1") {
+		t.Errorf("the hook was not consulted for the reverse DNS queries")
+	}
+	if e.addrInScope("not an address") || len(asked) != 4 {
+		t.Errorf("expected the hook to be consulted for the 4 valid addresses, but got %v", asked)
+	}
+}
+
+type testSource struct {
+	service.BaseService
+}
+
+func newTestSource(name string) *testSource {
+	src := new(testSource)
+	src.BaseService = *service.NewBaseService(src, name)
+	return src
+}
+
+func TestAddDataSource(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	sys := &systems.SimpleSystem{Cfg: cfg}
+	e := &Enumeration{Config: cfg, Sys: sys, requests: queue.NewQueue()}
+
+	src := newTestSource("Inventory")
+	defer func() { _ = src.Stop() }()
+	if err := e.AddDataSource(src); err != nil {
+		t.Fatalf("failed to add the data source: %v", err)
+	}
+	if sys.Service != src {
+		t.Errorf("the data source was not added to the system")
+	}
+	if err := e.AddDataSource(newTestSource("Inventory")); err == nil {
+		t.Errorf("a second data source with the same name was accepted")
+	}
+
+	var cancel context.CancelFunc
+	e.ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	e.done = make(chan struct{})
+	defer close(e.done)
+	go e.manageDataSrcRequests()
+
+	if err := e.AddDataSource(newTestSource("Late")); err == nil {
+		t.Errorf("the data source was accepted after the enumeration started")
+	}
+
+	e.sendRequests(&requests.DNSRequest{Name: "owasp.org", Domain: "owasp.org"})
+	select {
+	case <-e.ctx.Done():
+		t.Errorf("the data source did not receive the request")
+	case in := <-src.Input():
+		if req, ok := in.(*requests.DNSRequest); !ok || req.Domain != "owasp.org" {
+			t.Errorf("the data source received an unexpected request: %v", in)
+		}
+	}
+}
+
+func TestMaxResults(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{Config: cfg, MaxResults: 10}
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+	defer e.cancel()
+
+	records := []requests.DNSAnswer{{Name: "owasp.org", Type: 1, Data: "192.0.2.1"}}
+	if !e.countResult(&requests.DNSRequest{Name: "www.example.com", Domain: "example.com", Records: records}) {
+		t.Errorf("the out of scope name was not passed through")
+	}
+
+	var wg sync.WaitGroup
+	var accepted sync.Map
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("host%d.owasp.org", n%50)
+			if e.countResult(&requests.DNSRequest{Name: name, Domain: "owasp.org", Records: records}) {
+				accepted.Store(name, struct{}{})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var count int
+	accepted.Range(func(_, _ interface{}) bool {
+		count++
+		return true
+	})
+	if count != e.MaxResults {
+		t.Errorf("expected %d names to be accepted, but got %d", e.MaxResults, count)
+	}
+	select {
+	case <-e.ctx.Done():
+	default:
+		t.Errorf("the enumeration was not cancelled after reaching the maximum")
+	}
+}
+
+func TestIncludeUnresolvable(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	e := &Enumeration{Config: cfg, graph: g}
+	dm := &dataManager{enum: e}
+	ctx := context.Background()
+
+	dead := &requests.DNSRequest{Name: "dead.owasp.org", Domain: "owasp.org", Tag: requests.API, Source: "Test"}
+	if e.unresolvable(dead) {
+		t.Errorf("the name was unresolvable without IncludeUnresolvable being set")
+	}
+	if err := dm.dnsRequest(ctx, dead, nil); err != nil {
+		t.Fatalf("failed to process the unresolved name: %v", err)
+	}
+	if assets, err := g.DB.FindByContent(domain.FQDN{Name: dead.Name}, time.Time{}); err == nil && len(assets) > 0 {
+		t.Errorf("the unresolved name was stored without IncludeUnresolvable being set")
+	}
+
+	e.IncludeUnresolvable = true
+	out := &requests.DNSRequest{Name: "dead.example.com", Domain: "example.com", Tag: requests.API, Source: "Test"}
+	for _, req := range []*requests.DNSRequest{dead, out} {
+		if err := dm.dnsRequest(ctx, req, nil); err != nil {
+			t.Fatalf("failed to process the unresolved name %s: %v", req.Name, err)
+		}
+	}
+	if assets, err := g.DB.FindByContent(domain.FQDN{Name: dead.Name}, time.Time{}); err != nil || len(assets) == 0 {
+		t.Errorf("the unresolved name within the scope was not stored")
+	}
+	if assets, err := g.DB.FindByContent(domain.FQDN{Name: out.Name}, time.Time{}); err == nil && len(assets) > 0 {
+		t.Errorf("the unresolved name outside of the scope was stored")
+	}
+}
+
+func TestSourceTimeouts(t *testing.T) {
+	cfg := config.NewConfig()
+	e := &Enumeration{
+		Config:               cfg,
+		done:                 make(chan struct{}),
+		SourceTimeouts:       map[string]time.Duration{"slow": 100 * time.Millisecond},
+		DefaultSourceTimeout: time.Hour,
+	}
+	defer close(e.done)
+	var cancel context.CancelFunc
+	e.ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if d := e.sourceTimeout("Slow"); d != 100*time.Millisecond {
+		t.Errorf("expected the timeout of the source to be matched without regard to case, but got %s", d)
+	}
+	if d := e.sourceTimeout("Inventory"); d != time.Hour {
+		t.Errorf("expected the default timeout for the unlisted source, but got %s", d)
+	}
+
+	// the source never reads its input, so only the timeout releases the request
+	src := newTestSource("Slow")
+	finished := make(chan string, 1)
+	start := time.Now()
+	go e.fireRequest(src, &requests.DNSRequest{Name: "owasp.org", Domain: "owasp.org"}, finished)
+
+	select {
+	case name := <-finished:
+		if name != "Slow" {
+			t.Errorf("expected the request of the source Slow to finish, but got %s", name)
+		}
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("the request was released before the timeout expired: %s", elapsed)
+		}
+	case <-e.ctx.Done():
+		t.Errorf("the request was not released once the source timed out")
+	}
+}
+
+func TestDuplicateSourceRequests(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	src := newTestSource("Inventory")
+	if err := src.Start(); err != nil {
+		t.Fatalf("failed to start the data source: %v", err)
+	}
+	defer func() { _ = src.Stop() }()
+
+	e := &Enumeration{
+		Config:   cfg,
+		srcs:     []service.Service{src},
+		requests: queue.NewQueue(),
+		limited:  make(chan string, 10),
+		resume:   make(chan struct{}, 1),
+	}
+	var cancel context.CancelFunc
+	e.ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	e.done = make(chan struct{})
+	defer close(e.done)
+	go e.manageDataSrcRequests()
+
+	e.sendRequests(&requests.DNSRequest{Name: "owasp.org", Domain: "owasp.org"})
+	e.sendRequests(&requests.DNSRequest{Name: "OWASP.org", Domain: "owasp.org"})
+	e.sendRequests(&requests.ASNRequest{ASN: 26808})
+	e.sendRequests(&requests.ASNRequest{ASN: 26808})
+	e.sendRequests(&requests.ASNRequest{Address: "192.0.2.1"})
+	e.sendRequests(&requests.DNSRequest{Name: "example.com", Domain: "example.com"})
+
+	var received []interface{}
+loop:
+	for {
+		select {
+		case in := <-src.Input():
+			received = append(received, in)
+		case <-time.After(250 * time.Millisecond):
+			break loop
+		}
+	}
+
+	if len(received) != 4 {
+		t.Fatalf("expected the data source to receive 4 requests, but got %d: %v", len(received), received)
+	}
+	ids := make(map[string]struct{})
+	for _, in := range received {
+		ids[requestIdentity(in)] = struct{}{}
+	}
+	for _, id := range []string{"dns:owasp.org|owasp.org", "asn:26808", "asn_addr:192.0.2.1", "dns:example.com|example.com"} {
+		if _, found := ids[id]; !found {
+			t.Errorf("the data source did not receive the request %s", id)
+		}
+	}
+}
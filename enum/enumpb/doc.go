@@ -0,0 +1,14 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package enumpb holds the protobuf messages and the gRPC service streaming the enumeration results.
+package enumpb
+
+// The generated files are reproduced by the pinned plugin versions below. The copyright header of
+// the generated files is copied from enum.proto, and the protoc version reads (unknown) when the
+// descriptor is compiled without protoc, which does not report a compiler version to the plugins.
+
+//go:generate go install google.golang.org/protobuf/cmd/protoc-gen-go@v1.31.0
+//go:generate go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@v1.3.0
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative enum.proto
@@ -0,0 +1,208 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: enum.proto
+
+package enumpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Enumeration_StreamOutput_FullMethodName   = "/amass.enum.v1.Enumeration/StreamOutput"
+	Enumeration_StreamResolved_FullMethodName = "/amass.enum.v1.Enumeration/StreamResolved"
+)
+
+// EnumerationClient is the client API for Enumeration service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EnumerationClient interface {
+	// StreamOutput sends each resolved name within the scope, as reported in the output.
+	StreamOutput(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (Enumeration_StreamOutputClient, error)
+	// StreamResolved sends each resolved name with its records, including the names outside of the scope.
+	StreamResolved(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (Enumeration_StreamResolvedClient, error)
+}
+
+type enumerationClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEnumerationClient(cc grpc.ClientConnInterface) EnumerationClient {
+	return &enumerationClient{cc}
+}
+
+func (c *enumerationClient) StreamOutput(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (Enumeration_StreamOutputClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Enumeration_ServiceDesc.Streams[0], Enumeration_StreamOutput_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &enumerationStreamOutputClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Enumeration_StreamOutputClient interface {
+	Recv() (*Output, error)
+	grpc.ClientStream
+}
+
+type enumerationStreamOutputClient struct {
+	grpc.ClientStream
+}
+
+func (x *enumerationStreamOutputClient) Recv() (*Output, error) {
+	m := new(Output)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *enumerationClient) StreamResolved(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (Enumeration_StreamResolvedClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Enumeration_ServiceDesc.Streams[1], Enumeration_StreamResolved_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &enumerationStreamResolvedClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Enumeration_StreamResolvedClient interface {
+	Recv() (*Record, error)
+	grpc.ClientStream
+}
+
+type enumerationStreamResolvedClient struct {
+	grpc.ClientStream
+}
+
+func (x *enumerationStreamResolvedClient) Recv() (*Record, error) {
+	m := new(Record)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// EnumerationServer is the server API for Enumeration service.
+// All implementations must embed UnimplementedEnumerationServer
+// for forward compatibility
+type EnumerationServer interface {
+	// StreamOutput sends each resolved name within the scope, as reported in the output.
+	StreamOutput(*StreamRequest, Enumeration_StreamOutputServer) error
+	// StreamResolved sends each resolved name with its records, including the names outside of the scope.
+	StreamResolved(*StreamRequest, Enumeration_StreamResolvedServer) error
+	mustEmbedUnimplementedEnumerationServer()
+}
+
+// UnimplementedEnumerationServer must be embedded to have forward compatible implementations.
+type UnimplementedEnumerationServer struct {
+}
+
+func (UnimplementedEnumerationServer) StreamOutput(*StreamRequest, Enumeration_StreamOutputServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamOutput not implemented")
+}
+func (UnimplementedEnumerationServer) StreamResolved(*StreamRequest, Enumeration_StreamResolvedServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamResolved not implemented")
+}
+func (UnimplementedEnumerationServer) mustEmbedUnimplementedEnumerationServer() {}
+
+// UnsafeEnumerationServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EnumerationServer will
+// result in compilation errors.
+type UnsafeEnumerationServer interface {
+	mustEmbedUnimplementedEnumerationServer()
+}
+
+func RegisterEnumerationServer(s grpc.ServiceRegistrar, srv EnumerationServer) {
+	s.RegisterService(&Enumeration_ServiceDesc, srv)
+}
+
+func _Enumeration_StreamOutput_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EnumerationServer).StreamOutput(m, &enumerationStreamOutputServer{stream})
+}
+
+type Enumeration_StreamOutputServer interface {
+	Send(*Output) error
+	grpc.ServerStream
+}
+
+type enumerationStreamOutputServer struct {
+	grpc.ServerStream
+}
+
+func (x *enumerationStreamOutputServer) Send(m *Output) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Enumeration_StreamResolved_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EnumerationServer).StreamResolved(m, &enumerationStreamResolvedServer{stream})
+}
+
+type Enumeration_StreamResolvedServer interface {
+	Send(*Record) error
+	grpc.ServerStream
+}
+
+type enumerationStreamResolvedServer struct {
+	grpc.ServerStream
+}
+
+func (x *enumerationStreamResolvedServer) Send(m *Record) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Enumeration_ServiceDesc is the grpc.ServiceDesc for Enumeration service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Enumeration_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "amass.enum.v1.Enumeration",
+	HandlerType: (*EnumerationServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamOutput",
+			Handler:       _Enumeration_StreamOutput_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamResolved",
+			Handler:       _Enumeration_StreamResolved_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "enum.proto",
+}
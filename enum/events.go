@@ -0,0 +1,126 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"net"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+// subscriptions holds the callbacks registered by the external consumers of the enumeration events.
+type subscriptions struct {
+	sync.Mutex
+	next     int
+	resolved map[int]func(*requests.DNSRequest)
+	output   map[int]func(*requests.Output)
+}
+
+// SubscribeResolved registers the callback for each name resolved by the enumeration, including the
+// names outside of the scope, such as CNAME targets. The callback receives a copy of the request, and
+// is called from the pipeline, so it must be fast and safe for concurrent use. The returned function
+// removes the subscription, and all subscriptions are removed once Start returns.
+func (e *Enumeration) SubscribeResolved(fn func(*requests.DNSRequest)) func() {
+	e.subs.Lock()
+	defer e.subs.Unlock()
+
+	if e.subs.resolved == nil {
+		e.subs.resolved = make(map[int]func(*requests.DNSRequest))
+	}
+	id := e.subs.next
+	e.subs.next++
+	e.subs.resolved[id] = fn
+
+	return func() {
+		e.subs.Lock()
+		defer e.subs.Unlock()
+
+		delete(e.subs.resolved, id)
+	}
+}
+
+// SubscribeOutput registers the callback for each resolved name within the scope, as reported in the
+// output of the enumeration. The Output provides the addresses of the name, while the timestamps are
+// kept by the graph. The same requirements and unsubscription apply as for SubscribeResolved.
+func (e *Enumeration) SubscribeOutput(fn func(*requests.Output)) func() {
+	e.subs.Lock()
+	defer e.subs.Unlock()
+
+	if e.subs.output == nil {
+		e.subs.output = make(map[int]func(*requests.Output))
+	}
+	id := e.subs.next
+	e.subs.next++
+	e.subs.output[id] = fn
+
+	return func() {
+		e.subs.Lock()
+		defer e.subs.Unlock()
+
+		delete(e.subs.output, id)
+	}
+}
+
+func (e *Enumeration) publishResolved(req *requests.DNSRequest) {
+	e.subs.Lock()
+	callbacks := make([]func(*requests.DNSRequest), 0, len(e.subs.resolved))
+	for _, fn := range e.subs.resolved {
+		callbacks = append(callbacks, fn)
+	}
+	e.subs.Unlock()
+
+	for _, fn := range callbacks {
+		fn(req.Clone().(*requests.DNSRequest))
+	}
+}
+
+func (e *Enumeration) publishOutput(req *requests.DNSRequest) {
+	e.subs.Lock()
+	callbacks := make([]func(*requests.Output), 0, len(e.subs.output))
+	for _, fn := range e.subs.output {
+		callbacks = append(callbacks, fn)
+	}
+	e.subs.Unlock()
+
+	if len(callbacks) == 0 {
+		return
+	}
+
+	var addrs []requests.AddressInfo
+	for _, rec := range req.Records {
+		if t := uint16(rec.Type); (t != dns.TypeA && t != dns.TypeAAAA) || isGlue(rec) || sectionRecord(rec) {
+			continue
+		}
+		if ip := net.ParseIP(rec.Data); ip != nil {
+			addrs = append(addrs, requests.AddressInfo{Address: ip})
+		}
+	}
+
+	score, _ := e.Confidence(req.Name)
+	for _, fn := range callbacks {
+		fn(e.RedactOutput(&requests.Output{
+			Confidence:  score,
+			Active:      req.Active,
+			Timings:     req.Timings,
+			Name:        req.Name,
+			UnicodeName: UnicodeName(req.Name),
+			Domain:      req.Domain,
+			Addresses:   append([]requests.AddressInfo(nil), addrs...),
+			FirstSeen:   req.FirstSeen,
+			LastSeen:    req.LastSeen,
+		}))
+	}
+}
+
+// unsubscribeAll removes the subscriptions once the enumeration has finished.
+func (e *Enumeration) unsubscribeAll() {
+	e.subs.Lock()
+	defer e.subs.Unlock()
+
+	e.subs.resolved = nil
+	e.subs.output = nil
+}
@@ -0,0 +1,149 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestSubscriptions(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{Config: cfg}
+
+	var resolved []string
+	unsubResolved := e.SubscribeResolved(func(req *requests.DNSRequest) {
+		resolved = append(resolved, req.Name)
+		// the subscribers receive a copy of the request
+		req.Name = "modified"
+	})
+
+	var outputs []*requests.Output
+	e.SubscribeOutput(func(o *requests.Output) {
+		outputs = append(outputs, o)
+	})
+
+	req := &requests.DNSRequest{
+		Name:   "www.owasp.org",
+		Domain: "owasp.org",
+		Records: []requests.DNSAnswer{
+			{Name: "www.owasp.org", Type: int(dns.TypeA), Data: "192.0.2.1"},
+			{Name: "www.owasp.org", Type: int(dns.TypeTXT), Data: "text"},
+		},
+	}
+	e.publishResolved(req)
+	if req.Name != "www.owasp.org" {
+		t.Errorf("the subscriber modified the request")
+	}
+
+	sink := e.makeOutputSink()
+	if err := sink(context.Background(), req); err != nil {
+		t.Fatalf("the output sink failed: %v", err)
+	}
+	// names without records are not part of the output
+	_ = sink(context.Background(), &requests.DNSRequest{Name: "dead.owasp.org", Domain: "owasp.org"})
+
+	if len(resolved) != 1 || resolved[0] != "www.owasp.org" {
+		t.Errorf("unexpected resolved events: %v", resolved)
+	}
+	if len(outputs) != 1 || outputs[0].Name != "www.owasp.org" ||
+		len(outputs[0].Addresses) != 1 || outputs[0].Addresses[0].Address.String() != "192.0.2.1" {
+		t.Errorf("unexpected output events: %+v", outputs)
+	}
+
+	unsubResolved()
+	e.publishResolved(req)
+	if len(resolved) != 1 {
+		t.Errorf("the callback was called after the subscription was removed")
+	}
+
+	e.unsubscribeAll()
+	e.publishOutput(req)
+	if len(outputs) != 1 {
+		t.Errorf("the callback was called after the enumeration finished")
+	}
+}
+
+func TestOutputActivePassive(t *testing.T) {
+	names := []*requests.DNSRequest{
+		{Name: "brute.owasp.org", Domain: "owasp.org", Tag: requests.BRUTE, Active: true},
+		{Name: "api.owasp.org", Domain: "owasp.org", Tag: requests.API},
+	}
+
+	for _, c := range []struct {
+		active, passive bool
+		want            []string
+	}{
+		{false, false, []string{"brute.owasp.org", "api.owasp.org"}},
+		{true, false, []string{"brute.owasp.org"}},
+		{false, true, []string{"api.owasp.org"}},
+	} {
+		cfg := config.NewConfig()
+		cfg.AddDomain("owasp.org")
+		e := &Enumeration{Config: cfg, OutputActiveOnly: c.active, OutputPassiveOnly: c.passive}
+
+		var outputs []*requests.Output
+		e.SubscribeOutput(func(o *requests.Output) {
+			outputs = append(outputs, o)
+		})
+
+		sink := e.makeOutputSink()
+		for _, req := range names {
+			req.Records = []requests.DNSAnswer{{Name: req.Name, Type: int(dns.TypeA), Data: "192.0.2.1"}}
+			if err := sink(context.Background(), req); err != nil {
+				t.Fatalf("the output sink failed: %v", err)
+			}
+		}
+
+		if len(outputs) != len(c.want) {
+			t.Fatalf("expected the outputs %v, but got %d outputs", c.want, len(outputs))
+		}
+		for i, o := range outputs {
+			if o.Name != c.want[i] || o.Active != (o.Name == "brute.owasp.org") {
+				t.Errorf("unexpected output: %+v", o)
+			}
+		}
+	}
+}
+
+func TestOutputTimings(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{Config: cfg}
+
+	var outputs []*requests.Output
+	e.SubscribeOutput(func(o *requests.Output) {
+		outputs = append(outputs, o)
+	})
+
+	timings := requests.Timings{
+		Queued:     time.Millisecond,
+		Resolution: 40 * time.Millisecond,
+		Validation: 15 * time.Millisecond,
+		Store:      2 * time.Millisecond,
+	}
+	req := &requests.DNSRequest{
+		Name:    "www.owasp.org",
+		Domain:  "owasp.org",
+		Records: []requests.DNSAnswer{{Name: "www.owasp.org", Type: int(dns.TypeA), Data: "192.0.2.1"}},
+		Timings: timings,
+	}
+	if err := e.makeOutputSink()(context.Background(), req); err != nil {
+		t.Fatalf("the output sink failed: %v", err)
+	}
+
+	if len(outputs) != 1 {
+		t.Fatalf("expected one output, but got %d", len(outputs))
+	}
+	if outputs[0].Timings != timings {
+		t.Errorf("expected the timings %+v, but got %+v", timings, outputs[0].Timings)
+	}
+}
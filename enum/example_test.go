@@ -0,0 +1,95 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum_test
+
+import (
+	"context"
+	"strings"
+
+	"github.com/caffix/netmap"
+	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/enum"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+)
+
+// inventorySource is a custom data source that provides the names held by an internal asset inventory.
+type inventorySource struct {
+	service.BaseService
+	names []string
+}
+
+func newInventorySource(names []string) *inventorySource {
+	src := &inventorySource{names: names}
+	src.BaseService = *service.NewBaseService(src, "Asset Inventory")
+	return src
+}
+
+// Description implements the Service interface.
+func (src *inventorySource) Description() string {
+	return "api"
+}
+
+// OnStart implements the Service interface.
+func (src *inventorySource) OnStart() error {
+	go src.processRequests()
+	return nil
+}
+
+// HandlesReq implements the Service interface.
+func (src *inventorySource) HandlesReq(req interface{}) bool {
+	_, ok := req.(*requests.DNSRequest)
+	return ok
+}
+
+func (src *inventorySource) processRequests() {
+	for {
+		select {
+		case <-src.Done():
+			return
+		case in := <-src.Input():
+			if req, ok := in.(*requests.DNSRequest); ok {
+				src.lookup(req.Domain)
+			}
+		}
+	}
+}
+
+func (src *inventorySource) lookup(domain string) {
+	for _, name := range src.names {
+		if !strings.HasSuffix(name, "."+domain) {
+			continue
+		}
+
+		select {
+		case <-src.Done():
+			return
+		case src.Output() <- &requests.DNSRequest{
+			Name:   name,
+			Domain: domain,
+			Tag:    requests.API,
+			Source: src.String(),
+		}:
+		}
+	}
+}
+
+func ExampleEnumeration_AddDataSource() {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+
+	sys, err := systems.NewLocalSystem(cfg)
+	if err != nil {
+		return
+	}
+	defer func() { _ = sys.Shutdown() }()
+
+	e := enum.NewEnumeration(cfg, sys, netmap.NewGraph("memory", "", ""))
+	if err := e.AddDataSource(newInventorySource([]string{"vpn.owasp.org"})); err != nil {
+		return
+	}
+	_ = e.Start(context.Background())
+}
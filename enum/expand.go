@@ -0,0 +1,147 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+	"golang.org/x/net/publicsuffix"
+)
+
+// The policies of AutoExpandScope for the apex domains discovered outside of the scope.
+const (
+	// ScopeExpansionSibling adds the apex domains sharing the organization label of a domain
+	// in scope, such as owasp.net and owasp.co.uk for owasp.org
+	ScopeExpansionSibling = "sibling"
+	// ScopeExpansionSuffix adds the apex domains matching one of the ScopeExpansionSuffixes
+	ScopeExpansionSuffix = "suffix"
+)
+
+// defaultMaxScopeExpansion is the number of apex domains added when MaxScopeExpansion is not set.
+const defaultMaxScopeExpansion = 10
+
+// checkScopeExpansion validates the policy and the cap on the apex domains added to the scope.
+func (e *Enumeration) checkScopeExpansion() error {
+	switch e.AutoExpandScope {
+	case "", ScopeExpansionSibling:
+	case ScopeExpansionSuffix:
+		if len(e.ScopeExpansionSuffixes) == 0 {
+			return errors.New("the suffix scope expansion requires the allowed suffixes")
+		}
+	default:
+		return fmt.Errorf("the scope expansion policy %s is not supported", e.AutoExpandScope)
+	}
+	if e.MaxScopeExpansion < 0 {
+		return fmt.Errorf("the maximum number of domains added to the scope cannot be negative: %d", e.MaxScopeExpansion)
+	}
+	return nil
+}
+
+// expandScope adds the apex domain of the name to the scope when allowed by the AutoExpandScope
+// policy, and releases the apex domain to the pipeline and the data sources once the enumeration
+// is running. The apex domain is returned, or an empty string when it was not added.
+func (e *Enumeration) expandScope(name string) string {
+	if e.AutoExpandScope == "" {
+		return ""
+	}
+
+	apex, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(resolve.RemoveLastDot(strings.TrimSpace(name))))
+	if err != nil || apex == "" {
+		return ""
+	}
+	if e.Config.IsDomainInScope(apex) {
+		return e.Config.WhichDomain(apex)
+	}
+	if e.blacklisted(apex) || e.excludedName(apex) || !e.expansionAllowed(apex) {
+		return ""
+	}
+
+	max := e.MaxScopeExpansion
+	if max == 0 {
+		max = defaultMaxScopeExpansion
+	}
+
+	e.expLock.Lock()
+	if len(e.expanded) >= max {
+		e.expLock.Unlock()
+		e.log().Debugf("Scope expansion: %s was not added, since the cap of %d domains was reached", apex, max)
+		return ""
+	}
+	for _, d := range e.expanded {
+		if d == apex {
+			e.expLock.Unlock()
+			return apex
+		}
+	}
+	e.expanded = append(e.expanded, apex)
+	if len(e.expanded) == max {
+		e.log().Warnf("Scope expansion: the cap of %d domains added to the scope was reached", max)
+	}
+	e.expLock.Unlock()
+
+	e.Config.AddDomain(apex)
+	e.log().Infof("Scope expansion: %s was added to the scope after discovering %s", apex, name)
+
+	e.srcLock.RLock()
+	running := e.nameSrc != nil
+	e.srcLock.RUnlock()
+	// the domains added before the pipeline was setup are released with the configured domains
+	if running {
+		e.submitDomain(apex)
+	}
+	return apex
+}
+
+// expansionAllowed returns true when the policy allows the apex domain to be added to the scope.
+func (e *Enumeration) expansionAllowed(apex string) bool {
+	switch e.AutoExpandScope {
+	case ScopeExpansionSibling:
+		label := orgLabel(apex)
+		for _, d := range e.Config.Domains() {
+			if label != "" && orgLabel(d) == label {
+				return true
+			}
+		}
+	case ScopeExpansionSuffix:
+		return matchesSuffix(apex, e.ScopeExpansionSuffixes)
+	}
+	return false
+}
+
+// ExpandedDomains returns the apex domains added to the scope by AutoExpandScope, in the order they were added.
+func (e *Enumeration) ExpandedDomains() []string {
+	e.expLock.Lock()
+	defer e.expLock.Unlock()
+
+	return append([]string(nil), e.expanded...)
+}
+
+// orgLabel returns the label of the domain preceding the public suffix, such as owasp for owasp.co.uk.
+func orgLabel(domain string) string {
+	apex, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return ""
+	}
+
+	label, _, _ := strings.Cut(apex, ".")
+	return label
+}
+
+// submitDomain releases the root domain to the input source and each data source.
+func (e *Enumeration) submitDomain(domain string) {
+	req := &requests.DNSRequest{
+		Name:   domain,
+		Domain: domain,
+	}
+
+	e.nameSrc.newNameWithoutWait(req)
+	if !e.resolveOnly() {
+		e.sendRequests(req.Clone().(*requests.DNSRequest))
+	}
+}
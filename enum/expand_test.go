@@ -0,0 +1,90 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestExpandScope(t *testing.T) {
+	tests := []struct {
+		label    string
+		policy   string
+		suffixes []string
+		name     string
+		expected string
+	}{
+		{"expansion disabled", "", nil, "www.owasp.net", ""},
+		{"sibling apex", ScopeExpansionSibling, nil, "www.owasp.net", "owasp.net"},
+		{"sibling apex under a public suffix", ScopeExpansionSibling, nil, "mail.owasp.co.uk", "owasp.co.uk"},
+		{"unrelated apex", ScopeExpansionSibling, nil, "www.example.com", ""},
+		{"name already in scope", ScopeExpansionSibling, nil, "www.owasp.org", "owasp.org"},
+		{"allowed suffix", ScopeExpansionSuffix, []string{"example.com"}, "a.example.com", "example.com"},
+		{"suffix below the apex", ScopeExpansionSuffix, []string{".com"}, "www.example.com", "example.com"},
+		{"suffix not allowed", ScopeExpansionSuffix, []string{"example.com"}, "www.owasp.net", ""},
+	}
+
+	for _, test := range tests {
+		cfg := config.NewConfig()
+		cfg.AddDomain("owasp.org")
+		e := &Enumeration{
+			Config:                 cfg,
+			AutoExpandScope:        test.policy,
+			ScopeExpansionSuffixes: test.suffixes,
+		}
+
+		if got := e.expandScope(test.name); got != test.expected {
+			t.Errorf("%s: expected %q, but got %q", test.label, test.expected, got)
+		}
+		if test.expected != "" && !cfg.IsDomainInScope(test.name) {
+			t.Errorf("%s: %s was not brought into the scope", test.label, test.name)
+		}
+	}
+}
+
+func TestMaxScopeExpansion(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{
+		Config:            cfg,
+		AutoExpandScope:   ScopeExpansionSibling,
+		MaxScopeExpansion: 2,
+	}
+
+	for _, name := range []string{"owasp.net", "www.owasp.net", "owasp.com", "owasp.io"} {
+		e.expandScope(name)
+	}
+	if got := e.ExpandedDomains(); !reflect.DeepEqual(got, []string{"owasp.net", "owasp.com"}) {
+		t.Errorf("expected the first two apex domains to be added, but got %v", got)
+	}
+	if cfg.IsDomainInScope("owasp.io") {
+		t.Errorf("the apex domain beyond the cap was added to the scope")
+	}
+}
+
+func TestCheckScopeExpansion(t *testing.T) {
+	for _, test := range []struct {
+		policy   string
+		suffixes []string
+		max      int
+		valid    bool
+	}{
+		{"", nil, 0, true},
+		{ScopeExpansionSibling, nil, 5, true},
+		{ScopeExpansionSuffix, []string{"example.com"}, 0, true},
+		{ScopeExpansionSuffix, nil, 0, false},
+		{"registrant", nil, 0, false},
+		{ScopeExpansionSibling, nil, -1, false},
+	} {
+		e := &Enumeration{AutoExpandScope: test.policy, ScopeExpansionSuffixes: test.suffixes, MaxScopeExpansion: test.max}
+
+		if err := e.checkScopeExpansion(); (err == nil) != test.valid {
+			t.Errorf("%q %v %d: expected valid to be %t, but got the error %v", test.policy, test.suffixes, test.max, test.valid, err)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// graphInfrastructure is the type of the failover records keeping the addresses with their netblocks
+// and autonomous systems. The other types are the labels of the upserts made by the store stage.
+const graphInfrastructure = "Infrastructure"
+
+// graphFailoverRecord is the line written to the GraphFailoverFile for each failed graph write.
+type graphFailoverRecord struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Type        string    `json:"type"`
+	Name        string    `json:"name"`
+	Value       string    `json:"value,omitempty"`
+	ASN         int       `json:"asn,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Error       string    `json:"error"`
+}
+
+// graphFailover appends the graph writes that failed to a local JSONL file, so the records are kept
+// while the graph database is unavailable, and can be replayed once it recovers.
+type graphFailover struct {
+	sync.Mutex
+	wg        sync.WaitGroup
+	file      *os.File
+	pending   int
+	replaying bool
+}
+
+func newGraphFailover(path string) (*graphFailover, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the graph failover file %s: %v", path, err)
+	}
+	return &graphFailover{file: f}, nil
+}
+
+// add appends the record of the failed graph write to the file.
+func (g *graphFailover) add(rec graphFailoverRecord, failure error) error {
+	rec.Error = failure.Error()
+	if rec.Timestamp.IsZero() {
+		rec.Timestamp = time.Now().UTC()
+	}
+
+	line, err := json.Marshal(&rec)
+	if err != nil {
+		return err
+	}
+
+	g.Lock()
+	defer g.Unlock()
+
+	if _, err := g.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	g.pending++
+	return nil
+}
+
+// take removes the records from the file and returns them, while the replay is in progress.
+func (g *graphFailover) take() ([]graphFailoverRecord, error) {
+	g.Lock()
+	defer g.Unlock()
+
+	if _, err := g.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var recs []graphFailoverRecord
+	scanner := bufio.NewScanner(g.file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec graphFailoverRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err == nil {
+			recs = append(recs, rec)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := g.file.Truncate(0); err != nil {
+		return nil, err
+	}
+	g.pending = 0
+	return recs, nil
+}
+
+// startReplay returns true when records are waiting in the file and no replay is in progress.
+func (g *graphFailover) startReplay() bool {
+	g.Lock()
+	defer g.Unlock()
+
+	if g.pending == 0 || g.replaying {
+		return false
+	}
+	g.replaying = true
+	return true
+}
+
+func (g *graphFailover) endReplay() {
+	g.Lock()
+	defer g.Unlock()
+
+	g.replaying = false
+}
+
+func (g *graphFailover) close() error {
+	g.Lock()
+	defer g.Unlock()
+
+	return g.file.Close()
+}
+
+// graphWriteFailed keeps the record of the failed graph write in the GraphFailoverFile, and returns
+// false when the failover has not been set or the record could not be written.
+func (e *Enumeration) graphWriteFailed(rec graphFailoverRecord, failure error) bool {
+	if e.failover == nil {
+		return false
+	}
+
+	if err := e.failover.add(rec, failure); err != nil {
+		e.log().Errorf("Failed to write the graph failover file: %v", err)
+		return false
+	}
+	return true
+}
+
+// graphWriteSucceeded starts the replay of the failover records once the graph accepts the writes
+// again, when GraphFailoverReplay has been enabled.
+func (e *Enumeration) graphWriteSucceeded() {
+	if e.failover != nil && e.GraphFailoverReplay && e.failover.startReplay() {
+		e.failover.wg.Add(1)
+		go func() {
+			defer e.failover.wg.Done()
+			defer e.failover.endReplay()
+			_, _ = e.replayGraphFailover(context.Background())
+		}()
+	}
+}
+
+// stopGraphFailover waits for the replay in progress, replays the remaining records one last time
+// when GraphFailoverReplay has been enabled, and closes the GraphFailoverFile.
+func (e *Enumeration) stopGraphFailover() {
+	e.failover.wg.Wait()
+	if e.GraphFailoverReplay {
+		if _, err := e.replayGraphFailover(context.Background()); err != nil {
+			e.log().Errorf("%v", err)
+		}
+	}
+
+	e.failover.Lock()
+	pending := e.failover.pending
+	e.failover.Unlock()
+	if pending > 0 {
+		e.log().Warnf("Graph failover: %d records were kept in %s", pending, e.GraphFailoverFile)
+	}
+	if err := e.failover.close(); err != nil {
+		e.log().Errorf("Failed to close the graph failover file: %v", err)
+	}
+}
+
+// replayGraphFailover writes the records from the GraphFailoverFile into the graph, and returns the
+// number of records replayed. The records failing again are appended back to the file.
+func (e *Enumeration) replayGraphFailover(ctx context.Context) (int, error) {
+	if e.failover == nil {
+		return 0, errors.New("the graph failover file has not been set")
+	}
+
+	recs, err := e.failover.take()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the graph failover file: %v", err)
+	}
+
+	var count int
+	for _, rec := range recs {
+		if err := e.writeGraphRecord(ctx, rec); err != nil {
+			if ferr := e.failover.add(rec, err); ferr != nil {
+				e.log().Errorf("Failed to write the graph failover file: %v", ferr)
+			}
+			continue
+		}
+		count++
+	}
+	if count > 0 {
+		e.log().Infof("Graph failover: replayed %d of %d records", count, len(recs))
+	}
+	return count, nil
+}
+
+// writeGraphRecord performs the graph write described by the failover record.
+func (e *Enumeration) writeGraphRecord(ctx context.Context, rec graphFailoverRecord) error {
+	var err error
+
+	switch rec.Type {
+	case "FQDN":
+		_, err = e.graph.UpsertFQDN(ctx, rec.Name)
+	case "CNAME":
+		err = e.graph.UpsertCNAME(ctx, rec.Name, rec.Value)
+	case "A record":
+		err = e.graph.UpsertA(ctx, rec.Name, rec.Value)
+	case "AAAA record":
+		err = e.graph.UpsertAAAA(ctx, rec.Name, rec.Value)
+	case "PTR record":
+		err = e.graph.UpsertPTR(ctx, rec.Name, rec.Value)
+	case "SRV record":
+		err = e.graph.UpsertSRV(ctx, rec.Name, rec.Value)
+	case "NS record":
+		err = e.graph.UpsertNS(ctx, rec.Name, rec.Value)
+	case "MX record":
+		err = e.graph.UpsertMX(ctx, rec.Name, rec.Value)
+	case graphInfrastructure:
+		err = e.graph.UpsertInfrastructure(ctx, rec.ASN, rec.Description, rec.Name, rec.Value)
+	default:
+		err = fmt.Errorf("the graph failover record type %s is not supported", rec.Type)
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+func TestGraphFailover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "failover.jsonl")
+	failover, err := newGraphFailover(path)
+	if err != nil {
+		t.Fatalf("failed to open the failover file: %v", err)
+	}
+
+	e := &Enumeration{Config: config.NewConfig(), failover: failover}
+	dm := &dataManager{enum: e}
+	unavailable := func() error { return errors.New("the graph is unavailable") }
+
+	writes := []graphFailoverRecord{
+		{Type: "FQDN", Name: "www.owasp.org"},
+		{Type: "CNAME", Name: "docs.owasp.org", Value: "www.owasp.org"},
+		{Type: "A record", Name: "www.owasp.org", Value: "192.0.2.1"},
+		{Type: "MX record", Name: "owasp.org", Value: "mail.owasp.org"},
+	}
+	for _, w := range writes {
+		if err := dm.upsert(w.Type, w.Name, w.Value, unavailable); err == nil {
+			t.Errorf("the failed %s write was not reported", w.Type)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open the failover file: %v", err)
+	}
+	var recs []graphFailoverRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec graphFailoverRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Errorf("the line is not valid JSON: %s", scanner.Text())
+		}
+		recs = append(recs, rec)
+	}
+	f.Close()
+
+	if len(recs) != len(writes) {
+		t.Fatalf("expected %d failover records, but got %d", len(writes), len(recs))
+	}
+	for i, rec := range recs {
+		if rec.Type != writes[i].Type || rec.Name != writes[i].Name || rec.Value != writes[i].Value {
+			t.Errorf("expected the record %+v, but got %+v", writes[i], rec)
+		}
+		if rec.Error == "" || rec.Timestamp.IsZero() {
+			t.Errorf("the record is missing the error or timestamp: %+v", rec)
+		}
+	}
+
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+	e.graph = g
+	e.GraphFailoverReplay = true
+	if err := dm.upsert("FQDN", "owasp.org", "", func() error { return nil }); err != nil {
+		t.Fatalf("the successful write was reported as failed: %v", err)
+	}
+	e.failover.wg.Wait()
+
+	for _, name := range []string{"www.owasp.org", "docs.owasp.org", "mail.owasp.org"} {
+		if assets, err := g.DB.FindByContent(domain.FQDN{Name: name}, time.Time{}); err != nil || len(assets) == 0 {
+			t.Errorf("%s was not replayed into the graph", name)
+		}
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Errorf("the replayed records were kept in the failover file")
+	}
+
+	e.stopGraphFailover()
+}
@@ -0,0 +1,196 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+const (
+	// defaultWildcardFloodWindow is the period the wildcard matches are counted over when
+	// WildcardFloodWindow is not set.
+	defaultWildcardFloodWindow = 10 * time.Second
+	// defaultWildcardFloodRate is the names per second released below a throttled parent
+	// when WildcardFloodRate is not set.
+	defaultWildcardFloodRate = 1
+	// maxWildcardFloodBacklog is the number of names held for each throttled parent, and the
+	// names beyond it are dropped, since they are most likely generated by the wildcard.
+	maxWildcardFloodBacklog = 10000
+	// wildcardFloodTick is the period between the releases of the held names.
+	wildcardFloodTick = 100 * time.Millisecond
+)
+
+// wildcardFlood keeps the recent wildcard matches below a parent, and the names held while throttled.
+type wildcardFlood struct {
+	// matches are the times of the most recent wildcard matches, up to the threshold
+	matches []time.Time
+	backlog []*requests.DNSRequest
+	next    time.Time
+	dropped int
+}
+
+// wildcardFloods throttles the new names below the parents receiving a high rate of names matching
+// the DNS wildcards, while the names below the other parents enter the pipeline at full speed.
+type wildcardFloods struct {
+	sync.Mutex
+	enum      *Enumeration
+	threshold int
+	window    time.Duration
+	interval  time.Duration
+	parents   map[string]*wildcardFlood
+}
+
+func newWildcardFloods(e *Enumeration) (*wildcardFloods, error) {
+	if e.WildcardFloodThreshold < 0 || e.WildcardFloodWindow < 0 || e.WildcardFloodRate < 0 {
+		return nil, fmt.Errorf("the wildcard flood threshold, window and rate cannot be negative: %d, %s, %g",
+			e.WildcardFloodThreshold, e.WildcardFloodWindow, e.WildcardFloodRate)
+	}
+
+	window := e.WildcardFloodWindow
+	if window == 0 {
+		window = defaultWildcardFloodWindow
+	}
+	rate := e.WildcardFloodRate
+	if rate == 0 {
+		rate = defaultWildcardFloodRate
+	}
+
+	return &wildcardFloods{
+		enum:      e,
+		threshold: e.WildcardFloodThreshold,
+		window:    window,
+		interval:  time.Duration(float64(time.Second) / rate),
+		parents:   make(map[string]*wildcardFlood),
+	}, nil
+}
+
+func floodParent(name string) string {
+	if idx := strings.Index(name, "."); idx != -1 {
+		return strings.ToLower(name[idx+1:])
+	}
+	return ""
+}
+
+// throttled returns true when the threshold of wildcard matches has been reached within the window.
+func (w *wildcardFloods) throttled(f *wildcardFlood, now time.Time) bool {
+	return len(f.matches) >= w.threshold && now.Sub(f.matches[0]) <= w.window
+}
+
+// match counts the name matching a DNS wildcard against its parent.
+func (w *wildcardFloods) match(name string) {
+	parent := floodParent(name)
+	if parent == "" {
+		return
+	}
+
+	w.Lock()
+	defer w.Unlock()
+
+	f, found := w.parents[parent]
+	if !found {
+		f = new(wildcardFlood)
+		w.parents[parent] = f
+	}
+
+	now := time.Now()
+	before := w.throttled(f, now)
+	if f.matches = append(f.matches, now); len(f.matches) > w.threshold {
+		f.matches = f.matches[1:]
+	}
+	if !before && w.throttled(f, now) {
+		w.enum.log().Infof("Wildcard flood: the new names below %s are throttled after %d wildcard matches within %s", parent, w.threshold, w.window)
+	}
+}
+
+// hold returns true when the name was held or dropped, since its parent is throttled and the name
+// cannot be released yet. The names below a throttled parent are released in order, one per interval.
+func (w *wildcardFloods) hold(req *requests.DNSRequest) bool {
+	w.Lock()
+	defer w.Unlock()
+
+	f, found := w.parents[floodParent(req.Name)]
+	if !found {
+		return false
+	}
+
+	now := time.Now()
+	if !w.throttled(f, now) && len(f.backlog) == 0 {
+		return false
+	}
+	if len(f.backlog) == 0 && !now.Before(f.next) {
+		f.next = now.Add(w.interval)
+		return false
+	}
+	if len(f.backlog) >= maxWildcardFloodBacklog {
+		if f.dropped == 0 {
+			w.enum.log().Warnf("Wildcard flood: the names below %s are dropped, since %d names are held", floodParent(req.Name), maxWildcardFloodBacklog)
+		}
+		f.dropped++
+		return true
+	}
+	f.backlog = append(f.backlog, req)
+	return true
+}
+
+// release returns the held names that can enter the pipeline, which are all the names below the
+// parents no longer throttled, and one name for each throttled parent once its interval has elapsed.
+func (w *wildcardFloods) release() []*requests.DNSRequest {
+	w.Lock()
+	defer w.Unlock()
+
+	var reqs []*requests.DNSRequest
+	now := time.Now()
+	for _, f := range w.parents {
+		if len(f.backlog) == 0 {
+			continue
+		}
+		if !w.throttled(f, now) {
+			reqs = append(reqs, f.backlog...)
+			f.backlog = nil
+			continue
+		}
+		if !now.Before(f.next) {
+			reqs = append(reqs, f.backlog[0])
+			f.backlog = f.backlog[1:]
+			f.next = now.Add(w.interval)
+		}
+	}
+	return reqs
+}
+
+// holding returns true while names are held below the throttled parents.
+func (w *wildcardFloods) holding() bool {
+	w.Lock()
+	defer w.Unlock()
+
+	for _, f := range w.parents {
+		if len(f.backlog) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// run releases the held names into the input source until the enumeration is done.
+func (w *wildcardFloods) run(r *enumSource) {
+	t := time.NewTicker(wildcardFloodTick)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-t.C:
+			for _, req := range w.release() {
+				r.admit(req, false)
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestWildcardFloods(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{Config: cfg, WildcardFloodThreshold: 5, WildcardFloodWindow: time.Minute}
+
+	floods, err := newWildcardFloods(e)
+	if err != nil {
+		t.Fatalf("failed to create the wildcard flood throttle: %v", err)
+	}
+	e.floods = floods
+	e.nameSrc = newTestEnumSource(e, 10)
+
+	// the wildcard of dyn.owasp.org was matched by the names already resolved
+	for i := 0; i < 5; i++ {
+		e.markWildcard(fmt.Sprintf("match%d.dyn.owasp.org", i))
+	}
+	// the data sources and brute forcing flood the parent, along with the legitimate names
+	for i := 0; i < 50; i++ {
+		e.nameSrc.newNameWithoutWait(&requests.DNSRequest{Name: fmt.Sprintf("gen%d.dyn.owasp.org", i), Domain: "owasp.org"})
+		if i%5 == 0 {
+			e.nameSrc.newNameWithoutWait(&requests.DNSRequest{Name: fmt.Sprintf("host%d.owasp.org", i), Domain: "owasp.org"})
+		}
+	}
+
+	queued := func() (legit, flood int) {
+		for {
+			element, ok := e.nameSrc.queue.Next()
+			if !ok {
+				return
+			}
+			if req := element.(*requests.DNSRequest); strings.HasSuffix(req.Name, ".dyn.owasp.org") {
+				flood++
+			} else {
+				legit++
+			}
+		}
+	}
+
+	if legit, flood := queued(); legit != 10 || flood != 1 {
+		t.Errorf("expected the 10 legitimate names and 1 flooding name to be queued, but got %d and %d", legit, flood)
+	}
+	if !floods.holding() {
+		t.Fatalf("the flooding names were not held")
+	}
+	if reqs := floods.release(); len(reqs) != 0 {
+		t.Errorf("expected no name to be released before the interval, but got %d", len(reqs))
+	}
+
+	f := floods.parents["dyn.owasp.org"]
+	f.next = time.Now().Add(-time.Millisecond)
+	if reqs := floods.release(); len(reqs) != 1 || reqs[0].Name != "gen1.dyn.owasp.org" {
+		t.Errorf("expected the next held name to be released after the interval, but got %v", reqs)
+	}
+
+	// the wildcard matches have left the window, so the parent is released at full speed
+	for i := range f.matches {
+		f.matches[i] = f.matches[i].Add(-2 * time.Minute)
+	}
+	if reqs := floods.release(); len(reqs) != 48 {
+		t.Errorf("expected the remaining 48 names to be released, but got %d", len(reqs))
+	}
+	if floods.holding() {
+		t.Errorf("names remained held after the throttle ended")
+	}
+}
+
+func TestWildcardFloodsInvalid(t *testing.T) {
+	for _, e := range []*Enumeration{
+		{WildcardFloodThreshold: -1},
+		{WildcardFloodThreshold: 5, WildcardFloodWindow: -time.Second},
+		{WildcardFloodThreshold: 5, WildcardFloodRate: -1},
+	} {
+		if _, err := newWildcardFloods(e); err == nil {
+			t.Errorf("the wildcard flood settings %d, %s and %g were accepted", e.WildcardFloodThreshold, e.WildcardFloodWindow, e.WildcardFloodRate)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+// forced returns true when all the DNS queries are sent to the ForceResolver.
+func (e *Enumeration) forced() bool {
+	return e.ForceResolver != ""
+}
+
+// checkForceResolver requires the ForceResolver to be a host:port that answers a DNS query,
+// and warns that the resolver pools and their health checks are bypassed.
+func (e *Enumeration) checkForceResolver(ctx context.Context) error {
+	if !e.forced() {
+		return nil
+	}
+
+	host, port, err := net.SplitHostPort(e.ForceResolver)
+	if err != nil {
+		return fmt.Errorf("the forced resolver must be a host:port: %v", err)
+	}
+	if p, err := strconv.Atoi(port); host == "" || err != nil || p < 1 || p > 65535 {
+		return fmt.Errorf("the forced resolver %s is not a valid host:port", e.ForceResolver)
+	}
+
+	network := "udp"
+	if e.tcpOnly() {
+		network = "tcp"
+	}
+
+	msg := resolve.QueryMsg(".", dns.TypeNS)
+	e.setRecursionDesired(msg)
+	if _, err := e.exchange(ctx, network, e.ForceResolver, msg); err != nil {
+		return fmt.Errorf("the forced resolver %s is not reachable: %v", e.ForceResolver, err)
+	}
+
+	e.log().Warnf("All the DNS queries will be sent to the single resolver %s, "+
+		"bypassing the resolver pools and their health checks, so there is no redundancy", e.ForceResolver)
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestForceResolver(t *testing.T) {
+	var queries int32
+	addr := startMockUDPDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		atomic.AddInt32(&queries, 1)
+
+		m := new(dns.Msg)
+		m.SetReply(req)
+		if req.Question[0].Qtype == dns.TypeA {
+			m.Answer = append(m.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: req.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+				A:   net.IP{192, 0, 2, 1},
+			})
+		}
+		_ = w.WriteMsg(m)
+	})
+
+	cfg := config.NewConfig()
+	// the pools are empty, so the answers must come from the forced resolver
+	cfg.Resolvers = []string{"192.0.2.53"}
+	cfg.TrustedResolvers = []string{"192.0.2.53"}
+	e := &Enumeration{
+		Config:        cfg,
+		Sys:           &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: resolve.NewResolvers()},
+		ForceResolver: addr,
+	}
+	defer e.Sys.Resolvers().Stop()
+	defer e.Sys.TrustedResolvers().Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := e.checkForceResolver(ctx); err != nil {
+		t.Fatalf("the reachable resolver was rejected: %v", err)
+	}
+
+	for _, pool := range []*resolve.Resolvers{e.Sys.Resolvers(), e.Sys.TrustedResolvers()} {
+		resp, err := e.queryBlocking(ctx, resolve.QueryMsg("www.owasp.org", dns.TypeA), pool)
+		if err != nil || resp == nil || len(resp.Answer) == 0 {
+			t.Fatalf("the query failed: %v", err)
+		}
+	}
+	// the reachability check and both queries
+	if n := atomic.LoadInt32(&queries); n != 3 {
+		t.Errorf("expected the forced resolver to receive 3 queries, but got %d", n)
+	}
+}
+
+func TestCheckForceResolver(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on a TCP port: %v", err)
+	}
+	closed := l.Addr().String()
+	l.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, test := range []struct {
+		addr  string
+		valid bool
+	}{
+		{"", true},
+		{"8.8.8.8", false},
+		{":53", false},
+		{"127.0.0.1:0", false},
+		{"127.0.0.1:70000", false},
+		{closed, false},
+	} {
+		e := &Enumeration{Config: config.NewConfig(), ForceTCP: true, ForceResolver: test.addr}
+
+		if err := e.checkForceResolver(ctx); (err == nil) != test.valid {
+			t.Errorf("%q: expected valid to be %t, but got the error %v", test.addr, test.valid, err)
+		}
+	}
+}
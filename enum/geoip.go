@@ -0,0 +1,82 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"net"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+// geoIPRecord contains the fields of interest from the MaxMind City and ASN databases.
+type geoIPRecord struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	ASN          int    `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+// geoIPLookup performs the geolocation lookups and caches the results for each address.
+type geoIPLookup struct {
+	sync.Mutex
+	once  sync.Once
+	db    *maxminddb.Reader
+	cache map[string]*requests.GeoLocation
+}
+
+// GeoLocation returns the geolocation of the address from the GeoIPDatabase,
+// or nil when no database has been configured or the address was not found.
+func (e *Enumeration) GeoLocation(addr string) *requests.GeoLocation {
+	if e.GeoIPDatabase == "" {
+		return nil
+	}
+
+	g := e.geoip
+	g.once.Do(func() {
+		db, err := maxminddb.Open(e.GeoIPDatabase)
+		if err != nil {
+			e.log().Errorf("Failed to open the GeoIP database %s: %v", e.GeoIPDatabase, err)
+			return
+		}
+		g.db = db
+	})
+	if g.db == nil {
+		return nil
+	}
+
+	g.Lock()
+	defer g.Unlock()
+
+	if loc, found := g.cache[addr]; found {
+		return loc
+	}
+
+	var loc *requests.GeoLocation
+	if ip := net.ParseIP(addr); ip != nil {
+		var rec geoIPRecord
+
+		if err := g.db.Lookup(ip, &rec); err == nil {
+			loc = &requests.GeoLocation{
+				CountryCode:  rec.Country.ISOCode,
+				Country:      rec.Country.Names["en"],
+				City:         rec.City.Names["en"],
+				ASN:          rec.ASN,
+				Organization: rec.Organization,
+			}
+			if *loc == (requests.GeoLocation{}) {
+				loc = nil
+			}
+		}
+	}
+	g.cache[addr] = loc
+	return loc
+}
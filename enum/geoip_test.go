@@ -0,0 +1,29 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestGeoLocationWithoutDatabase(t *testing.T) {
+	e := &Enumeration{
+		Config: config.NewConfig(),
+		geoip:  &geoIPLookup{cache: make(map[string]*requests.GeoLocation)},
+	}
+
+	if loc := e.GeoLocation("8.8.8.8"); loc != nil {
+		t.Errorf("expected no geolocation without a database, got %v", loc)
+	}
+
+	e.GeoIPDatabase = filepath.Join(t.TempDir(), "missing.mmdb")
+	if loc := e.GeoLocation("8.8.8.8"); loc != nil {
+		t.Errorf("expected no geolocation with a missing database, got %v", loc)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+// glueAnswers returns the A and AAAA records in the additional section of the NS response
+// for the provided nameservers, classified as glue so they are stored for the nameserver names.
+func glueAnswers(resp *dns.Msg, nameservers []string) []requests.DNSAnswer {
+	if resp == nil {
+		return nil
+	}
+
+	ns := make(map[string]struct{}, len(nameservers))
+	for _, name := range nameservers {
+		ns[strings.ToLower(resolve.RemoveLastDot(name))] = struct{}{}
+	}
+
+	var answers []requests.DNSAnswer
+	for _, rr := range resp.Extra {
+		var ip net.IP
+		switch v := rr.(type) {
+		case *dns.A:
+			ip = v.A
+		case *dns.AAAA:
+			ip = v.AAAA
+		default:
+			continue
+		}
+
+		hdr := rr.Header()
+		name := strings.ToLower(resolve.RemoveLastDot(hdr.Name))
+		if _, found := ns[name]; !found || ip == nil {
+			continue
+		}
+
+		answers = append(answers, requests.DNSAnswer{
+			Name:           name,
+			Type:           int(hdr.Rrtype),
+			TTL:            int(hdr.Ttl),
+			Data:           ip.String(),
+			Classification: requests.GLUE,
+		})
+	}
+	return answers
+}
+
+// isGlue returns true when the record belongs to a nameserver of the name, instead of the name itself.
+func isGlue(rec requests.DNSAnswer) bool {
+	return rec.Classification == requests.GLUE
+}
+
+// hasGlue returns true when the request carries the glue records of the nameserver.
+func hasGlue(req *requests.DNSRequest, nameserver string) bool {
+	nameserver = strings.ToLower(resolve.RemoveLastDot(nameserver))
+
+	for _, rec := range req.Records {
+		if isGlue(rec) && rec.Name == nameserver {
+			return true
+		}
+	}
+	return false
+}
+
+// recordOwner returns the name that the A or AAAA record is stored for.
+func recordOwner(req *requests.DNSRequest, rec requests.DNSAnswer) string {
+	if isGlue(rec) && rec.Name != "" {
+		return rec.Name
+	}
+	if chainTarget(req, rec.Name) {
+		return rec.Name
+	}
+	return req.Name
+}
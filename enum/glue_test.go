@@ -0,0 +1,61 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func TestGlueAnswers(t *testing.T) {
+	resp := new(dns.Msg)
+	resp.Extra = []dns.RR{
+		&dns.A{
+			Hdr: dns.RR_Header{Name: "NS1.owasp.org.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 172800},
+			A:   net.ParseIP("192.0.2.53"),
+		},
+		&dns.AAAA{
+			Hdr:  dns.RR_Header{Name: "ns1.owasp.org.", Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: 172800},
+			AAAA: net.ParseIP("2001:db8::53"),
+		},
+		// the addresses of other names in the additional section are not glue for the nameservers
+		&dns.A{
+			Hdr: dns.RR_Header{Name: "www.owasp.org.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+			A:   net.ParseIP("192.0.2.80"),
+		},
+		&dns.OPT{Hdr: dns.RR_Header{Name: ".", Rrtype: dns.TypeOPT}},
+	}
+
+	glue := glueAnswers(resp, []string{"ns1.owasp.org.", "ns2.owasp.org"})
+	if len(glue) != 2 {
+		t.Fatalf("expected 2 glue records, but got %v", glue)
+	}
+	if g := glue[0]; g.Name != "ns1.owasp.org" || g.Type != int(dns.TypeA) || g.Data != "192.0.2.53" || g.TTL != 172800 || !isGlue(g) {
+		t.Errorf("unexpected glue record: %+v", g)
+	}
+	if g := glue[1]; g.Type != int(dns.TypeAAAA) || g.Data != "2001:db8::53" {
+		t.Errorf("unexpected glue record: %+v", g)
+	}
+
+	req := &requests.DNSRequest{
+		Name:    "owasp.org",
+		Records: append([]requests.DNSAnswer{{Name: "owasp.org", Type: int(dns.TypeNS), Data: "ns1.owasp.org"}}, glue...),
+	}
+	if !hasGlue(req, "ns1.owasp.org.") || hasGlue(req, "ns2.owasp.org") {
+		t.Errorf("the glue records were not matched to the nameservers")
+	}
+	if owner := recordOwner(req, glue[0]); owner != "ns1.owasp.org" {
+		t.Errorf("the glue record was stored for %s", owner)
+	}
+	if owner := recordOwner(req, requests.DNSAnswer{Name: "owasp.org", Type: int(dns.TypeA)}); owner != "owasp.org" {
+		t.Errorf("the address of the name was stored for %s", owner)
+	}
+	if glueAnswers(nil, []string{"ns1.owasp.org"}) != nil {
+		t.Errorf("glue records were returned without a response")
+	}
+}
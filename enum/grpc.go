@@ -0,0 +1,194 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/owasp-amass/amass/v4/enum/enumpb"
+	"github.com/owasp-amass/amass/v4/requests"
+	"google.golang.org/grpc"
+)
+
+// grpcStreamBuffer is the number of messages queued for each stream, and the messages are dropped
+// for the clients falling further behind, so a slow client cannot stall the enumeration.
+const grpcStreamBuffer = 1000
+
+// grpcServer streams the enumeration events to the gRPC clients, through the subscriptions.
+type grpcServer struct {
+	enumpb.UnimplementedEnumerationServer
+	enum *Enumeration
+	srv  *grpc.Server
+	addr net.Addr
+	quit chan struct{}
+	once sync.Once
+}
+
+// ServeGRPC starts a gRPC server listening on the address, which streams the names resolved by the
+// enumeration to the remote clients as they are produced, through the same feed as SubscribeOutput
+// and SubscribeResolved. The service is defined by enum/enumpb/enum.proto. It should be called before
+// Start, so the clients can connect before the first names are resolved, and the streams end once
+// the enumeration has finished.
+func (e *Enumeration) ServeGRPC(addr string) error {
+	e.subs.Lock()
+	defer e.subs.Unlock()
+
+	if e.grpcsrv != nil {
+		return errors.New("the gRPC server has already been started")
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s for the gRPC server: %v", addr, err)
+	}
+
+	s := &grpcServer{
+		enum: e,
+		srv:  grpc.NewServer(),
+		addr: l.Addr(),
+		quit: make(chan struct{}),
+	}
+	enumpb.RegisterEnumerationServer(s.srv, s)
+	e.grpcsrv = s
+	e.log().Infof("The gRPC server is listening on %s", s.addr)
+
+	go func() {
+		if err := s.srv.Serve(l); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			e.log().Errorf("The gRPC server failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+// stopGRPC ends the streams once the queued messages have been sent, and stops the gRPC server.
+func (e *Enumeration) stopGRPC() {
+	e.subs.Lock()
+	s := e.grpcsrv
+	e.subs.Unlock()
+
+	if s != nil {
+		s.once.Do(func() {
+			close(s.quit)
+			s.srv.GracefulStop()
+		})
+	}
+}
+
+// StreamOutput implements the enumpb.EnumerationServer interface.
+func (s *grpcServer) StreamOutput(_ *enumpb.StreamRequest, stream enumpb.Enumeration_StreamOutputServer) error {
+	ch := make(chan *enumpb.Output, grpcStreamBuffer)
+	unsub := s.enum.SubscribeOutput(func(out *requests.Output) {
+		select {
+		case ch <- outputMessage(out):
+		default:
+			s.enum.log().Warnf("gRPC: dropped %s for a client falling behind", out.Name)
+		}
+	})
+	defer unsub()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case msg := <-ch:
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		case <-s.quit:
+			for {
+				select {
+				case msg := <-ch:
+					if err := stream.Send(msg); err != nil {
+						return err
+					}
+				default:
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// StreamResolved implements the enumpb.EnumerationServer interface.
+func (s *grpcServer) StreamResolved(_ *enumpb.StreamRequest, stream enumpb.Enumeration_StreamResolvedServer) error {
+	ch := make(chan *enumpb.Record, grpcStreamBuffer)
+	unsub := s.enum.SubscribeResolved(func(req *requests.DNSRequest) {
+		select {
+		case ch <- recordMessage(req):
+		default:
+			s.enum.log().Warnf("gRPC: dropped %s for a client falling behind", req.Name)
+		}
+	})
+	defer unsub()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case msg := <-ch:
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		case <-s.quit:
+			for {
+				select {
+				case msg := <-ch:
+					if err := stream.Send(msg); err != nil {
+						return err
+					}
+				default:
+					return nil
+				}
+			}
+		}
+	}
+}
+
+func outputMessage(out *requests.Output) *enumpb.Output {
+	msg := &enumpb.Output{
+		Name:        out.Name,
+		Domain:      out.Domain,
+		UnicodeName: out.UnicodeName,
+		Confidence:  int32(out.Confidence),
+	}
+
+	for _, a := range out.Addresses {
+		info := &enumpb.AddressInfo{
+			Cidr:        a.CIDRStr,
+			Asn:         int32(a.ASN),
+			Description: a.Description,
+		}
+		if a.Address != nil {
+			info.Address = a.Address.String()
+		}
+		if info.Cidr == "" && a.Netblock != nil {
+			info.Cidr = a.Netblock.String()
+		}
+		msg.Addresses = append(msg.Addresses, info)
+	}
+	return msg
+}
+
+func recordMessage(req *requests.DNSRequest) *enumpb.Record {
+	msg := &enumpb.Record{
+		Name:   req.Name,
+		Domain: req.Domain,
+		Tag:    req.Tag,
+		Source: req.Source,
+	}
+
+	for _, rec := range req.Records {
+		msg.Records = append(msg.Records, &enumpb.DNSAnswer{
+			Name: rec.Name,
+			Type: uint32(rec.Type),
+			Ttl:  int32(rec.TTL),
+			Data: rec.Data,
+		})
+	}
+	return msg
+}
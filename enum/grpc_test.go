@@ -0,0 +1,103 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/enum/enumpb"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestServeGRPC(t *testing.T) {
+	e := &Enumeration{Config: config.NewConfig()}
+	if err := e.ServeGRPC("127.0.0.1:0"); err != nil {
+		t.Fatalf("failed to start the gRPC server: %v", err)
+	}
+	defer e.stopGRPC()
+	if err := e.ServeGRPC("127.0.0.1:0"); err == nil {
+		t.Errorf("a second gRPC server was started")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, e.grpcsrv.addr.String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to connect to the gRPC server: %v", err)
+	}
+	defer conn.Close()
+
+	client := enumpb.NewEnumerationClient(conn)
+	outputs, err := client.StreamOutput(ctx, &enumpb.StreamRequest{})
+	if err != nil {
+		t.Fatalf("failed to open the output stream: %v", err)
+	}
+	resolved, err := client.StreamResolved(ctx, &enumpb.StreamRequest{})
+	if err != nil {
+		t.Fatalf("failed to open the resolved stream: %v", err)
+	}
+
+	// wait for both streams to subscribe before the names are published
+	for {
+		e.subs.Lock()
+		n := len(e.subs.output) + len(e.subs.resolved)
+		e.subs.Unlock()
+		if n == 2 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("the streams did not subscribe to the enumeration")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	req := &requests.DNSRequest{
+		Name:    "www.owasp.org",
+		Domain:  "owasp.org",
+		Records: []requests.DNSAnswer{{Name: "www.owasp.org", Type: int(dns.TypeA), TTL: 300, Data: "192.0.2.1"}},
+		Tag:     requests.DNS,
+		Source:  "DNS",
+	}
+	e.publishResolved(req)
+	e.publishOutput(req)
+
+	out, err := outputs.Recv()
+	if err != nil {
+		t.Fatalf("failed to receive the output: %v", err)
+	}
+	if out.GetName() != "www.owasp.org" || len(out.GetAddresses()) != 1 || out.GetAddresses()[0].GetAddress() != "192.0.2.1" {
+		t.Errorf("received an unexpected output: %v", out)
+	}
+
+	rec, err := resolved.Recv()
+	if err != nil {
+		t.Fatalf("failed to receive the resolved record: %v", err)
+	}
+	if rec.GetName() != "www.owasp.org" || len(rec.GetRecords()) != 1 || rec.GetRecords()[0].GetType() != uint32(dns.TypeA) {
+		t.Errorf("received an unexpected resolved record: %v", rec)
+	}
+
+	// the streams end once the enumeration has finished
+	e.stopGRPC()
+	if _, err := outputs.Recv(); err != io.EOF {
+		t.Errorf("expected the output stream to end, but got %v", err)
+	}
+	if _, err := resolved.Recv(); err != io.EOF {
+		t.Errorf("expected the resolved stream to end, but got %v", err)
+	}
+	if _, err := net.Dial("tcp", e.grpcsrv.addr.String()); err == nil {
+		t.Errorf("the gRPC server was not stopped")
+	}
+}
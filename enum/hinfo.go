@@ -0,0 +1,115 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+// HINFOSeverity is the severity of the HINFO disclosure findings.
+const HINFOSeverity = "low"
+
+// HINFOFinding is a name publishing the CPU and operating system of its host in an HINFO record.
+type HINFOFinding struct {
+	Name     string
+	CPU      string
+	OS       string
+	Severity string
+}
+
+// queryHINFO obtains the HINFO records of the name, which are not extracted by the resolve package,
+// and reports the hardware and operating system they disclose.
+func (dt *dnsTask) queryHINFO(ctx context.Context, name string, ch chan []requests.DNSAnswer) {
+	if resp, err := dt.enum.dnsQuery(ctx, name, dns.TypeHINFO, dt.enum.Sys.TrustedResolvers(), maxDNSQueryAttempts); err == nil {
+		if records := hinfoAnswers(resp); len(records) > 0 {
+			for _, rec := range records {
+				dt.enum.addHINFOFinding(rec)
+			}
+			ch <- truncateAnswers(records, dt.enum.MaxAnswerBytes)
+			return
+		}
+	}
+	ch <- nil
+}
+
+// hinfoAnswers converts the HINFO records in the answer section, and decodes the CPU and OS fields.
+// The records with both fields empty are dropped, since they disclose nothing.
+func hinfoAnswers(resp *dns.Msg) []requests.DNSAnswer {
+	if resp == nil {
+		return nil
+	}
+
+	var answers []requests.DNSAnswer
+	for _, rr := range resp.Answer {
+		hinfo, ok := rr.(*dns.HINFO)
+		if !ok {
+			continue
+		}
+
+		cpu := strings.TrimSpace(hinfo.Cpu)
+		os := strings.TrimSpace(hinfo.Os)
+		if cpu == "" && os == "" {
+			continue
+		}
+
+		answers = append(answers, requests.DNSAnswer{
+			Name: resolve.RemoveLastDot(hinfo.Hdr.Name),
+			Type: int(dns.TypeHINFO),
+			TTL:  int(hinfo.Hdr.Ttl),
+			Data: strings.TrimSpace(strings.TrimPrefix(hinfo.String(), hinfo.Hdr.String())),
+			HINFO: &requests.HINFOData{
+				CPU: cpu,
+				OS:  os,
+			},
+		})
+	}
+	return answers
+}
+
+// addHINFOFinding records the disclosure of the HINFO answer, once for each name, CPU and OS.
+func (e *Enumeration) addHINFOFinding(rec requests.DNSAnswer) {
+	if rec.HINFO == nil {
+		return
+	}
+
+	finding := HINFOFinding{
+		Name:     strings.ToLower(rec.Name),
+		CPU:      rec.HINFO.CPU,
+		OS:       rec.HINFO.OS,
+		Severity: HINFOSeverity,
+	}
+
+	e.hinfLock.Lock()
+	for _, f := range e.hinfos {
+		if f == finding {
+			e.hinfLock.Unlock()
+			return
+		}
+	}
+	e.hinfos = append(e.hinfos, finding)
+	e.hinfLock.Unlock()
+
+	e.log().Warnf("HINFO disclosure: %s publishes the CPU %q and the OS %q (%s severity)",
+		finding.Name, finding.CPU, finding.OS, HINFOSeverity)
+}
+
+// HINFOFindings returns the names disclosing their hardware and operating system in HINFO records,
+// sorted by name. The records are only queried when QueryHINFO has been enabled.
+func (e *Enumeration) HINFOFindings() []HINFOFinding {
+	e.hinfLock.Lock()
+	defer e.hinfLock.Unlock()
+
+	results := append([]HINFOFinding(nil), e.hinfos...)
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
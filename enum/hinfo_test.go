@@ -0,0 +1,79 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestHINFOAnswers(t *testing.T) {
+	rr, err := dns.NewRR(`www.owasp.org. 300 IN HINFO "INTEL-386" "Windows NT 4.0"`)
+	if err != nil {
+		t.Fatalf("failed to parse the HINFO record: %v", err)
+	}
+
+	resp := new(dns.Msg)
+	resp.Answer = []dns.RR{rr, &dns.HINFO{
+		Hdr: dns.RR_Header{Name: "empty.owasp.org.", Rrtype: dns.TypeHINFO, Class: dns.ClassINET, Ttl: 300},
+	}, &dns.A{
+		Hdr: dns.RR_Header{Name: "www.owasp.org.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+	}}
+
+	answers := hinfoAnswers(resp)
+	if len(answers) != 1 {
+		t.Fatalf("expected 1 HINFO answer, but got %d", len(answers))
+	}
+
+	a := answers[0]
+	if a.Name != "www.owasp.org" || a.Type != int(dns.TypeHINFO) || a.TTL != 300 {
+		t.Errorf("unexpected HINFO answer: %+v", a)
+	}
+	if expected := `"INTEL-386" "Windows NT 4.0"`; a.Data != expected {
+		t.Errorf("expected the data %s, but got %s", expected, a.Data)
+	}
+	if a.HINFO == nil || a.HINFO.CPU != "INTEL-386" || a.HINFO.OS != "Windows NT 4.0" {
+		t.Errorf("the HINFO record was not decoded: %+v", a.HINFO)
+	}
+	if hinfoAnswers(nil) != nil || hinfoAnswers(new(dns.Msg)) != nil {
+		t.Errorf("answers were returned without HINFO records")
+	}
+}
+
+func TestHINFOFindings(t *testing.T) {
+	rr, err := dns.NewRR(`www.owasp.org. 300 IN HINFO "SUN-SPARC" "SunOS 4.1"`)
+	if err != nil {
+		t.Fatalf("failed to parse the HINFO record: %v", err)
+	}
+	legacy, err := dns.NewRR(`legacy.owasp.org. 300 IN HINFO "VAX-11/780" "UNIX"`)
+	if err != nil {
+		t.Fatalf("failed to parse the HINFO record: %v", err)
+	}
+
+	e := &Enumeration{Config: config.NewConfig()}
+	if findings := e.HINFOFindings(); len(findings) != 0 {
+		t.Errorf("expected no findings without HINFO records, but got %v", findings)
+	}
+
+	resp := new(dns.Msg)
+	resp.Answer = []dns.RR{rr, legacy}
+	// the same records provided twice are only reported once
+	for i := 0; i < 2; i++ {
+		for _, a := range hinfoAnswers(resp) {
+			e.addHINFOFinding(a)
+		}
+	}
+
+	expected := []HINFOFinding{
+		{Name: "legacy.owasp.org", CPU: "VAX-11/780", OS: "UNIX", Severity: HINFOSeverity},
+		{Name: "www.owasp.org", CPU: "SUN-SPARC", OS: "SunOS 4.1", Severity: HINFOSeverity},
+	}
+	if got := e.HINFOFindings(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected the findings %v, but got %v", expected, got)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+// SplitHorizonFinding is a name answered differently by the internal and the public resolver of
+// the SplitHorizonResolvers, formatted as the record type and data.
+type SplitHorizonFinding struct {
+	Name     string
+	Internal []string
+	Public   []string
+}
+
+// splitHorizon compares the answers provided by an internal and a public resolver, and keeps the findings.
+type splitHorizon struct {
+	sync.Mutex
+	enum     *Enumeration
+	internal *resolve.Resolvers
+	public   *resolve.Resolvers
+	findings []SplitHorizonFinding
+}
+
+// newSplitHorizon returns nil when the Enumeration has not been configured with both resolvers.
+func newSplitHorizon(e *Enumeration) *splitHorizon {
+	in := e.SplitHorizonResolvers.Internal
+	pub := e.SplitHorizonResolvers.Public
+	if in == "" || pub == "" {
+		return nil
+	}
+
+	sh := &splitHorizon{
+		enum:     e,
+		internal: splitHorizonPool(e, in),
+		public:   splitHorizonPool(e, pub),
+	}
+	if sh.internal.Len() == 0 || sh.public.Len() == 0 {
+		e.log().Errorf("Failed to setup the split-horizon resolvers: %s and %s", in, pub)
+		sh.stop()
+		return nil
+	}
+	return sh
+}
+
+func splitHorizonPool(e *Enumeration, addr string) *resolve.Resolvers {
+	pool := resolve.NewResolvers()
+
+	pool.SetLogger(e.Config.Log)
+	pool.SetTimeout(2 * time.Second)
+	_ = pool.AddResolvers(e.Config.TrustedQPS, addr)
+	return pool
+}
+
+func (sh *splitHorizon) stop() {
+	sh.internal.Stop()
+	sh.public.Stop()
+}
+
+// check queries both resolvers for the name, and records and logs the answer sets when they differ.
+func (sh *splitHorizon) check(ctx context.Context, name string) {
+	internal := sh.answers(ctx, sh.internal, name)
+	public := sh.answers(ctx, sh.public, name)
+	if sameAnswers(internal, public) {
+		return
+	}
+
+	sh.Lock()
+	sh.findings = append(sh.findings, SplitHorizonFinding{
+		Name:     name,
+		Internal: internal,
+		Public:   public,
+	})
+	sh.Unlock()
+
+	sh.enum.log().Warnf("Split-horizon DNS: %s internal answers [%s] public answers [%s]",
+		name, strings.Join(internal, ", "), strings.Join(public, ", "))
+}
+
+func (sh *splitHorizon) answers(ctx context.Context, pool *resolve.Resolvers, name string) []string {
+	var answers []string
+
+	for _, qtype := range FwdQueryTypes {
+		resp, err := sh.enum.dnsQuery(ctx, name, qtype, pool, maxRcodeServerFails)
+		if err != nil || resp == nil {
+			continue
+		}
+
+		for _, a := range resolve.AnswersByType(resolve.ExtractAnswers(resp), qtype) {
+			answers = append(answers, dns.TypeToString[a.Type]+" "+a.Data)
+		}
+	}
+
+	sort.Strings(answers)
+	return answers
+}
+
+func sameAnswers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// SplitHorizonFindings returns the names answered differently by the internal and the public
+// resolver, sorted by name. Nothing is returned unless SplitHorizonResolvers were provided.
+func (e *Enumeration) SplitHorizonFindings() []SplitHorizonFinding {
+	if e.horizon == nil {
+		return nil
+	}
+
+	e.horizon.Lock()
+	defer e.horizon.Unlock()
+
+	results := append([]SplitHorizonFinding(nil), e.horizon.findings...)
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results
+}
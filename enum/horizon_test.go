@@ -0,0 +1,74 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestSplitHorizonFindings(t *testing.T) {
+	internal := startHorizonServer(t, "10.0.0.80")
+	public := startHorizonServer(t, "192.0.2.80")
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.ResolversQPS, cfg.TrustedQPS = 1000, 1000
+	e := &Enumeration{
+		Config: cfg,
+		Sys:    &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: resolve.NewResolvers()},
+	}
+	defer e.Sys.Resolvers().Stop()
+	defer e.Sys.TrustedResolvers().Stop()
+
+	if e.SplitHorizonFindings() != nil {
+		t.Errorf("findings were returned without the split-horizon resolvers")
+	}
+
+	e.SplitHorizonResolvers.Internal = internal
+	e.SplitHorizonResolvers.Public = public
+	e.horizon = newSplitHorizon(e)
+	if e.horizon == nil {
+		t.Fatal("Failed to setup the split-horizon resolvers")
+	}
+	defer e.horizon.stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	e.horizon.check(ctx, "www.owasp.org")
+	e.horizon.check(ctx, "app.owasp.org")
+
+	findings := e.SplitHorizonFindings()
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, but got %v", findings)
+	}
+	if f := findings[0]; f.Name != "app.owasp.org" || len(f.Internal) != 1 || f.Internal[0] != "A 10.0.0.80" ||
+		len(f.Public) != 1 || f.Public[0] != "A 192.0.2.80" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+}
+
+// startHorizonServer returns the address of a resolver answering the A queries over UDP with the address.
+func startHorizonServer(t *testing.T, addr string) string {
+	return startMockUDPDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		if req.Question[0].Qtype == dns.TypeA {
+			m.Answer = append(m.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: req.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+				A:   net.ParseIP(addr),
+			})
+		}
+		_ = w.WriteMsg(m)
+	})
+}
@@ -0,0 +1,131 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"golang.org/x/net/idna"
+)
+
+// idnProfile converts the internationalized names for the DNS queries, while permitting
+// the underscore labels, such as _dmarc, that are not part of host names.
+var idnProfile = idna.New(idna.MapForLookup(), idna.BidiRule(), idna.StrictDomainName(false))
+
+// idnExceptions are the characters outside of the letters, digits and marks that IDNA2008
+// permits in their context, such as the zero width joiners and the Catalan middle dot.
+var idnExceptions = map[rune]struct{}{
+	'\u00b7': {},
+	'\u0375': {},
+	'\u05f3': {},
+	'\u05f4': {},
+	'\u200c': {},
+	'\u200d': {},
+	'\u30fb': {},
+}
+
+// toASCIIName returns the A-label (punycode) form of the name, such as xn--bcher-kva.example.com for
+// bücher.example.com. The names in ASCII are returned unchanged, and an error is returned for the
+// Unicode names that IDNA2008 does not permit, such as the labels with emoji or mixed directions.
+func toASCIIName(name string) (string, error) {
+	if isASCII(name) {
+		return name, nil
+	}
+
+	ascii, err := idnProfile.ToASCII(name)
+	if err != nil {
+		return "", fmt.Errorf("the name %s is not a valid internationalized domain name: %v", name, err)
+	}
+	// The UTS #46 mapping permits the symbols, such as emoji, which IDNA2008 disallows
+	uni, err := idnProfile.ToUnicode(ascii)
+	if err != nil {
+		return "", fmt.Errorf("the name %s is not a valid internationalized domain name: %v", name, err)
+	}
+	for _, r := range uni {
+		if r < utf8.RuneSelf || unicode.In(r, unicode.L, unicode.Nd, unicode.Mn, unicode.Mc) {
+			continue
+		}
+		if _, found := idnExceptions[r]; !found {
+			return "", fmt.Errorf("the name %s contains the disallowed character %U", name, r)
+		}
+	}
+	return ascii, nil
+}
+
+// UnicodeName returns the Unicode form of a name with A-labels, such as bücher.example.com for
+// xn--bcher-kva.example.com, or an empty string when the name has no valid A-labels.
+func UnicodeName(name string) string {
+	if !strings.HasPrefix(name, "xn--") && !strings.Contains(name, ".xn--") {
+		return ""
+	}
+
+	uni, err := idnProfile.ToUnicode(name)
+	if err != nil || uni == name {
+		return ""
+	}
+	return uni
+}
+
+// toASCIIRequest converts the name and domain of the request to their A-label forms.
+func toASCIIRequest(req *requests.DNSRequest) error {
+	name, err := toASCIIName(req.Name)
+	if err != nil {
+		return err
+	}
+
+	domain, err := toASCIIName(req.Domain)
+	if err != nil {
+		return err
+	}
+
+	req.Name = name
+	req.Domain = domain
+	return nil
+}
+
+// normalizeIDNScope replaces the Unicode domains and blacklisted names of the configuration with
+// their A-label forms, so the scope matching operates on the same form as the enumerated names.
+func (e *Enumeration) normalizeIDNScope() error {
+	var changed bool
+	var domains []string
+	for _, d := range e.Config.Domains() {
+		ascii, err := toASCIIName(d)
+		if err != nil {
+			return err
+		}
+		if ascii != d {
+			changed = true
+		}
+		domains = append(domains, ascii)
+	}
+	if changed {
+		e.Config.Lock()
+		e.Config.Scope.Domains = nil
+		e.Config.Unlock()
+		e.Config.AddDomains(domains...)
+	}
+
+	for i, b := range e.Config.Scope.Blacklist {
+		ascii, err := toASCIIName(b)
+		if err != nil {
+			return err
+		}
+		e.Config.Scope.Blacklist[i] = ascii
+	}
+	return nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestToASCIIName(t *testing.T) {
+	tests := []struct {
+		label    string
+		name     string
+		expected string
+		valid    bool
+	}{
+		{"ascii", "www.owasp.org", "www.owasp.org", true},
+		{"a-labels", "xn--bcher-kva.owasp.org", "xn--bcher-kva.owasp.org", true},
+		{"latin", "bücher.owasp.org", "xn--bcher-kva.owasp.org", true},
+		{"uppercase", "BÜCHER.owasp.org", "xn--bcher-kva.owasp.org", true},
+		{"japanese", "ファイル.owasp.org", "xn--bckg9lpd.owasp.org", true},
+		{"mixed scripts", "pаypal.owasp.org", "xn--pypal-4ve.owasp.org", true},
+		{"service label", "_dmarc.bücher.owasp.org", "_dmarc.xn--bcher-kva.owasp.org", true},
+		{"emoji", "😀.owasp.org", "", false},
+		{"emoji with letters", "i❤.owasp.org", "", false},
+		{"mixed directions", "aא.owasp.org", "", false},
+		{"joiner out of context", "a‍b.owasp.org", "", false},
+		{"leading hyphen", "-bücher.owasp.org", "", false},
+	}
+	for _, test := range tests {
+		got, err := toASCIIName(test.name)
+		if test.valid && err != nil {
+			t.Errorf("%s: %s was rejected: %v", test.label, test.name, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("%s: %s was accepted as %s", test.label, test.name, got)
+		} else if got != test.expected {
+			t.Errorf("%s: expected %s, but got %s", test.label, test.expected, got)
+		}
+	}
+}
+
+func TestUnicodeName(t *testing.T) {
+	tests := map[string]string{
+		"xn--bcher-kva.owasp.org":        "bücher.owasp.org",
+		"www.xn--bckg9lpd.owasp.org":     "www.ファイル.owasp.org",
+		"www.owasp.org":                  "",
+		"_dmarc.xn--bcher-kva.owasp.org": "_dmarc.bücher.owasp.org",
+	}
+	for name, expected := range tests {
+		if got := UnicodeName(name); got != expected {
+			t.Errorf("%s: expected %q, but got %q", name, expected, got)
+		}
+	}
+}
+
+func TestIDNScope(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomains("bücher.example", "owasp.org")
+	cfg.Scope.Blacklist = []string{"intern.bücher.example"}
+
+	e := &Enumeration{
+		Config:             cfg,
+		ExcludeNameRegexps: []string{`^test\.`, `^staging-`},
+	}
+	if err := e.normalizeIDNScope(); err != nil {
+		t.Fatalf("failed to normalize the scope: %v", err)
+	}
+	if err := e.compileNameExclusions(); err != nil {
+		t.Fatalf("failed to compile the exclusions: %v", err)
+	}
+
+	if d := cfg.WhichDomain("www.xn--bcher-kva.example"); d != "xn--bcher-kva.example" {
+		t.Errorf("the A-label name was matched to the domain %q", d)
+	}
+	if d := cfg.WhichDomain("www.owasp.org"); d != "owasp.org" {
+		t.Errorf("the ASCII domain was lost from the scope: %q", d)
+	}
+	if !e.blacklisted("www.intern.xn--bcher-kva.example") {
+		t.Errorf("the blacklisted subdomain was not matched in its A-label form")
+	}
+	// the expressions match both forms of the names
+	if !e.excludedName("test.xn--bcher-kva.example") {
+		t.Errorf("the A-label name was not excluded")
+	}
+	if name, _ := toASCIIName("staging-bücher.bücher.example"); !e.excludedName(name) {
+		t.Errorf("the name was not excluded through its Unicode form")
+	}
+
+	cfg.AddDomain("😀.example")
+	if err := e.normalizeIDNScope(); err == nil {
+		t.Errorf("the invalid domain was accepted")
+	}
+}
+
+func TestIDNNewName(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("xn--bcher-kva.example")
+
+	r := newTestEnumSource(&Enumeration{Config: cfg}, 10)
+
+	r.newName(&requests.DNSRequest{Name: "WWW.Bücher.example", Domain: "bücher.example"})
+	r.newName(&requests.DNSRequest{Name: "www.xn--bcher-kva.example", Domain: "xn--bcher-kva.example"})
+	r.newName(&requests.DNSRequest{Name: "😀.bücher.example", Domain: "bücher.example"})
+
+	if n := r.queue.Len(); n != 1 {
+		t.Fatalf("expected one name queued, but got %d", n)
+	}
+	element, _ := r.queue.Next()
+	if req := element.(*requests.DNSRequest); req.Name != "www.xn--bcher-kva.example" || req.Domain != "xn--bcher-kva.example" {
+		t.Errorf("the name was not converted to the A-label form: %s in %s", req.Name, req.Domain)
+	}
+}
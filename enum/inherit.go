@@ -0,0 +1,106 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/owasp-amass/resolve"
+	"golang.org/x/net/publicsuffix"
+)
+
+// defaultCNAMEScopeDepth is the depth of the domains inherited when MaxCNAMEScopeDepth is not set.
+const defaultCNAMEScopeDepth = 2
+
+// checkScopeInheritance validates the suffixes and the depth of the scope inherited through the CNAME targets.
+func (e *Enumeration) checkScopeInheritance() error {
+	if e.InheritScopeViaCNAME && len(e.CNAMEScopeSuffixes) == 0 {
+		return errors.New("the scope inheritance through the CNAME targets requires the allowed suffixes")
+	}
+	if e.MaxCNAMEScopeDepth < 0 {
+		return fmt.Errorf("the maximum depth of the scope inherited through the CNAME targets cannot be negative: %d", e.MaxCNAMEScopeDepth)
+	}
+	return nil
+}
+
+// inheritScope adds the apex domain of the CNAME target to the scope when it matches one of the
+// CNAMEScopeSuffixes, and the depth of the domain does not exceed the MaxCNAMEScopeDepth. The
+// configured domains have a depth of zero, and the inherited domains one more than the domain
+// of the name. The apex domain is returned, or an empty string when it was not added.
+func (e *Enumeration) inheritScope(name, target string) string {
+	if !e.InheritScopeViaCNAME {
+		return ""
+	}
+
+	apex, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(resolve.RemoveLastDot(strings.TrimSpace(target))))
+	if err != nil || apex == "" {
+		return ""
+	}
+	if e.Config.IsDomainInScope(apex) {
+		return e.Config.WhichDomain(apex)
+	}
+	if e.blacklisted(apex) || e.excludedName(apex) || !matchesSuffix(apex, e.CNAMEScopeSuffixes) {
+		return ""
+	}
+
+	max := e.MaxCNAMEScopeDepth
+	if max == 0 {
+		max = defaultCNAMEScopeDepth
+	}
+
+	e.inhLock.Lock()
+	if e.inherit == nil {
+		e.inherit = make(map[string]int)
+	}
+	if _, found := e.inherit[apex]; found {
+		e.inhLock.Unlock()
+		return apex
+	}
+	depth := e.inherit[e.Config.WhichDomain(name)] + 1
+	if depth > max {
+		e.inhLock.Unlock()
+		e.log().Debugf("CNAME scope inheritance: %s was not added, since the depth of %d domains was reached", apex, max)
+		return ""
+	}
+	e.inherit[apex] = depth
+	e.inhLock.Unlock()
+
+	e.Config.AddDomain(apex)
+	e.log().Infof("CNAME scope inheritance: %s was added to the scope through %s -> %s", apex, name, target)
+
+	e.srcLock.RLock()
+	running := e.nameSrc != nil
+	e.srcLock.RUnlock()
+	if running {
+		e.submitDomain(apex)
+	}
+	return apex
+}
+
+// InheritedDomains returns the apex domains added to the scope by InheritScopeViaCNAME, with the
+// depth of each domain from the configured domains.
+func (e *Enumeration) InheritedDomains() map[string]int {
+	e.inhLock.Lock()
+	defer e.inhLock.Unlock()
+
+	domains := make(map[string]int, len(e.inherit))
+	for d, depth := range e.inherit {
+		domains[d] = depth
+	}
+	return domains
+}
+
+// matchesSuffix returns true when the apex domain is one of the suffixes, or a subdomain of one.
+func matchesSuffix(apex string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		suffix = strings.ToLower(strings.Trim(strings.TrimSpace(suffix), "."))
+		if suffix != "" && (apex == suffix || strings.HasSuffix(apex, "."+suffix)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/caffix/netmap"
+	"github.com/caffix/queue"
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestInheritScopeViaCNAME(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	e := &Enumeration{
+		Config:                 cfg,
+		graph:                  g,
+		requests:               queue.NewQueue(),
+		RecordOutOfScopeCNAMEs: true,
+		InheritScopeViaCNAME:   true,
+		CNAMEScopeSuffixes:     []string{"owasp-static.net", "owasp-media.com", "owasp-more.io"},
+	}
+	e.nameSrc = newTestEnumSource(e, 10)
+
+	dm := &dataManager{enum: e}
+	ctx := context.Background()
+	for _, cname := range [][2]string{
+		{"www.owasp.org", "cdn.owasp-static.net"},
+		{"assets.owasp-static.net", "img.owasp-media.com"},
+		// the depth of the inherited domains has been reached
+		{"video.owasp-media.com", "edge.owasp-more.io"},
+		{"login.owasp.org", "owasp.thirdparty.com"},
+	} {
+		req := &requests.DNSRequest{Name: cname[0], Domain: cfg.WhichDomain(cname[0]), Records: []requests.DNSAnswer{
+			{Name: cname[0], Type: int(dns.TypeCNAME), TTL: 300, Data: cname[1]},
+		}}
+		if err := dm.insertCNAME(ctx, req, 0, nil); err != nil {
+			t.Fatalf("failed to insert the CNAME record of %s: %v", cname[0], err)
+		}
+	}
+
+	if n := e.requests.Len(); n != 2 {
+		t.Errorf("expected the inherited domains to be sent to the data sources, but got %d requests", n)
+	}
+
+	expected := map[string]int{"owasp-static.net": 1, "owasp-media.com": 2}
+	if got := e.InheritedDomains(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected the inherited domains %v, but got %v", expected, got)
+	}
+	if cfg.IsDomainInScope("edge.owasp-more.io") || cfg.IsDomainInScope("owasp.thirdparty.com") {
+		t.Errorf("a CNAME target was brought into the scope beyond the depth or suffixes")
+	}
+
+	var queued []string
+	for {
+		element, ok := e.nameSrc.queue.Next()
+		if !ok {
+			break
+		}
+		if req, ok := element.(*requests.DNSRequest); ok && req.Name != req.Domain {
+			queued = append(queued, req.Name)
+		}
+	}
+	sort.Strings(queued)
+	if !reflect.DeepEqual(queued, []string{"cdn.owasp-static.net", "img.owasp-media.com"}) {
+		t.Errorf("expected the inherited CNAME targets to be resolved, but got %v", queued)
+	}
+
+	oos := []OutOfScopeCNAME{
+		{Name: "login.owasp.org", Target: "owasp.thirdparty.com"},
+		{Name: "video.owasp-media.com", Target: "edge.owasp-more.io"},
+	}
+	if got := e.OutOfScopeCNAMEs(); !reflect.DeepEqual(got, oos) {
+		t.Errorf("expected the out of scope CNAMEs %v, but got %v", oos, got)
+	}
+}
+
+func TestCheckScopeInheritance(t *testing.T) {
+	for _, test := range []struct {
+		enabled  bool
+		suffixes []string
+		depth    int
+		valid    bool
+	}{
+		{false, nil, 0, true},
+		{true, []string{"owasp-static.net"}, 3, true},
+		{true, nil, 0, false},
+		{true, []string{"owasp-static.net"}, -1, false},
+	} {
+		e := &Enumeration{InheritScopeViaCNAME: test.enabled, CNAMEScopeSuffixes: test.suffixes, MaxCNAMEScopeDepth: test.depth}
+
+		if err := e.checkScopeInheritance(); (err == nil) != test.valid {
+			t.Errorf("%t %v %d: expected valid to be %t, but got the error %v", test.enabled, test.suffixes, test.depth, test.valid, err)
+		}
+	}
+}
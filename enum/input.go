@@ -6,6 +6,7 @@ package enum
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 
@@ -13,7 +14,6 @@ import (
 	"github.com/caffix/queue"
 	"github.com/caffix/service"
 	"github.com/owasp-amass/amass/v4/requests"
-	bf "github.com/tylertreat/BoomFilters"
 )
 
 const waitForDuration = 10 * time.Second
@@ -23,11 +23,17 @@ type enumSource struct {
 	pipeline *pipeline.Pipeline
 	enum     *Enumeration
 	queue    queue.Queue
-	filter   *bf.StableBloomFilter
+	filter   dedupeFilter
 	done     chan struct{}
 	doneOnce sync.Once
 	release  chan struct{}
 	max      int
+	// The names that entered the pipeline and have not left the store stage
+	flightLock sync.Mutex
+	flightCond *sync.Cond
+	inflight   map[string]struct{}
+	// The time the enumeration became idle, or zero while there is work in progress
+	idleSince time.Time
 }
 
 // newEnumSource returns an initialized input source for the enumeration pipeline.
@@ -38,11 +44,13 @@ func newEnumSource(p *pipeline.Pipeline, e *Enumeration) *enumSource {
 		pipeline: p,
 		enum:     e,
 		queue:    queue.NewQueue(),
-		filter:   bf.NewDefaultStableBloomFilter(1000000, 0.01),
+		filter:   e.newDedupeFilter(),
 		done:     make(chan struct{}),
 		release:  make(chan struct{}, size),
 		max:      size,
+		inflight: make(map[string]struct{}),
 	}
+	r.flightCond = sync.NewCond(&r.flightLock)
 	// Monitor the enumeration for completion or termination
 	go func() {
 		select {
@@ -65,40 +73,140 @@ func newEnumSource(p *pipeline.Pipeline, e *Enumeration) *enumSource {
 func (r *enumSource) Stop() {
 	r.markDone()
 	r.queue.Process(func(e interface{}) {})
-	r.filter.Reset()
+	r.filter.reset()
 }
 
 func (r *enumSource) markDone() {
 	r.doneOnce.Do(func() {
 		close(r.done)
+
+		r.flightLock.Lock()
+		r.flightCond.Broadcast()
+		r.flightLock.Unlock()
 	})
 }
 
+// newName submits the name and blocks while MaxInFlightNames has been reached.
 func (r *enumSource) newName(req *requests.DNSRequest) {
+	r.addName(req, true, false)
+}
+
+// newNameWithoutWait submits the name without blocking, since the pipeline stages must continue
+// moving names to reduce the count in flight, and the root domains are submitted before execution.
+func (r *enumSource) newNameWithoutWait(req *requests.DNSRequest) {
+	r.addName(req, false, false)
+}
+
+// newProvidedName submits the name provided by the user, which is the only name accepted
+// when ValidateListOnly has been enabled.
+func (r *enumSource) newProvidedName(req *requests.DNSRequest) {
+	r.addName(req, true, true)
+}
+
+func (r *enumSource) addName(req *requests.DNSRequest, wait, provided bool) {
 	select {
 	case <-r.done:
 		return
 	default:
 	}
 
+	if r.enum.ValidateListOnly && !provided {
+		r.releaseOutput(1)
+		return
+	}
+
 	if req.Name == "" || !req.Valid() {
 		r.releaseOutput(1)
 		return
 	}
 	// Clean up the newly discovered name and domain
 	requests.SanitizeDNSRequest(req)
+	// Internationalized names are resolved and stored in their A-label form
+	if err := toASCIIRequest(req); err != nil {
+		r.enum.log().Debugf("%v", err)
+		r.releaseOutput(1)
+		return
+	}
+	// Each name is kept within the partition of its own root domain
+	if r.enum.PartitionByDomain {
+		if d := r.enum.RootDomain(req.Name); d != "" {
+			req.Domain = d
+		}
+	}
 
-	if r.enum.Config.Blacklisted(req.Name) {
+	if r.enum.blacklisted(req.Name) || r.enum.excludedName(req.Name) {
+		r.releaseOutput(1)
+		return
+	}
+	if r.enum.NameFilter != nil && !r.enum.NameFilter(req) {
 		r.releaseOutput(1)
 		return
 	}
+	if !r.enum.sourceNameAllowed(req) {
+		r.releaseOutput(1)
+		return
+	}
+	// Each source providing the name is kept, including those repeating a name already accepted
+	r.enum.addSourceHistory(req.Name, req.Source)
 	if !r.accept(req.Name) {
 		r.releaseOutput(1)
 		return
 	}
+	// The technique of the source that provided the name first is kept
+	req.Active = requests.ActiveTag(req.Tag)
+	// The names below the parents flooded by wildcard matches are released at a reduced rate
+	if r.enum.floods != nil && r.enum.floods.hold(req) {
+		return
+	}
+	r.admit(req, wait)
+}
+
+// admit releases the name into the queue of the input source.
+func (r *enumSource) admit(req *requests.DNSRequest, wait bool) {
+	// the wait for the names in flight is part of the time queued in the input source
+	req.Timings.Start()
+	if !r.enterFlight(req.Name, wait) {
+		return
+	}
+	// The names matching the PriorityPatterns are taken from the queue ahead of the others
+	if r.enum.priorityName(req.Name) {
+		r.queue.AppendPriority(req, queue.PriorityHigh)
+		return
+	}
 	r.queue.Append(req)
 }
 
+// enterFlight adds the name to those in flight, and returns false if the enumeration
+// terminated while waiting for the count to drop below MaxInFlightNames.
+func (r *enumSource) enterFlight(name string, wait bool) bool {
+	r.flightLock.Lock()
+	defer r.flightLock.Unlock()
+
+	max := r.enum.MaxInFlightNames
+	for wait && max > 0 && len(r.inflight) >= max {
+		select {
+		case <-r.done:
+			return false
+		default:
+		}
+		r.flightCond.Wait()
+	}
+
+	r.inflight[name] = struct{}{}
+	return true
+}
+
+// leaveFlight removes the name from those in flight.
+func (r *enumSource) leaveFlight(name string) {
+	r.flightLock.Lock()
+	defer r.flightLock.Unlock()
+
+	if _, found := r.inflight[name]; found {
+		delete(r.inflight, name)
+		r.flightCond.Signal()
+	}
+}
+
 func (r *enumSource) newAddr(req *requests.AddrRequest) {
 	select {
 	case <-r.done:
@@ -106,13 +214,19 @@ func (r *enumSource) newAddr(req *requests.AddrRequest) {
 	default:
 	}
 
+	if r.enum.ValidateListOnly {
+		return
+	}
+	if req.Valid() && r.enum.AddressInScope != nil {
+		req.InScope = r.enum.addrInScope(req.Address)
+	}
 	if req.Valid() && req.InScope && r.accept(req.Address) {
 		r.queue.Append(req)
 	}
 }
 
 func (r *enumSource) accept(s string) bool {
-	return !r.filter.TestAndAdd([]byte(s))
+	return !r.filter.testAndAdd(s)
 }
 
 // Next implements the pipeline InputSource interface.
@@ -133,21 +247,51 @@ func (r *enumSource) Next(ctx context.Context) bool {
 			r.markDone()
 			return false
 		case <-t.C:
-			count := r.pipeline.DataItemCount()
-			if !r.enum.requestsPending() && count <= 0 {
-				if r.enum.store.queue.Len() == 0 {
-					r.markDone()
-					return false
-				}
+			now := time.Now()
+			if r.finished(now) {
+				r.markDone()
+				return false
 			}
 			r.fillQueue()
-			t.Reset(waitForDuration)
+			t.Reset(r.nextCheck(now))
 		case <-r.queue.Signal():
 			return true
 		}
 	}
 }
 
+// finished returns true once the pipeline, the store and the input source have been idle, without
+// pending data source requests, for the whole CompletionGracePeriod. The period starts over when
+// the data sources provide more names, so the late results are not lost.
+func (r *enumSource) finished(now time.Time) bool {
+	idle := !r.enum.requestsPending() && r.pipeline.DataItemCount() <= 0 && r.queue.Len() == 0 &&
+		r.enum.store.queue.Len() == 0 && (r.enum.floods == nil || !r.enum.floods.holding())
+	if !idle {
+		r.idleSince = time.Time{}
+		return false
+	}
+
+	if r.idleSince.IsZero() {
+		r.idleSince = now
+		if r.enum.CompletionGracePeriod > 0 {
+			r.enum.log().Debugf("The enumeration is idle, and waiting %s for late data source results", r.enum.CompletionGracePeriod)
+		}
+	}
+	return now.Sub(r.idleSince) >= r.enum.CompletionGracePeriod
+}
+
+// nextCheck returns the time until the completion is checked again, which is sooner than
+// waitForDuration when the CompletionGracePeriod ends first.
+func (r *enumSource) nextCheck(now time.Time) time.Duration {
+	wait := waitForDuration
+	if !r.idleSince.IsZero() {
+		if remaining := r.enum.CompletionGracePeriod - now.Sub(r.idleSince); remaining > 0 && remaining < wait {
+			wait = remaining
+		}
+	}
+	return wait
+}
+
 // Data implements the pipeline InputSource interface.
 func (r *enumSource) Data() pipeline.Data {
 	var data pipeline.Data
@@ -155,6 +299,9 @@ func (r *enumSource) Data() pipeline.Data {
 	if element, ok := r.queue.Next(); ok {
 		data = element.(pipeline.Data)
 	}
+	if req, ok := data.(*requests.DNSRequest); ok {
+		req.Timings.Queued = req.Timings.Lap()
+	}
 	return data
 }
 
@@ -203,6 +350,11 @@ func (r *enumSource) monitorDataSrcOutput(srv service.Service) {
 				r.newName(req)
 			case *requests.AddrRequest:
 				r.newAddr(req)
+			case error:
+				r.releaseOutput(1)
+				if errors.Is(req, requests.ErrRateLimited) {
+					r.enum.sourceRateLimited(srv.String())
+				}
 			}
 		}
 	}
@@ -0,0 +1,246 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caffix/pipeline"
+	"github.com/caffix/queue"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+	bf "github.com/tylertreat/BoomFilters"
+)
+
+// newTestEnumSource returns an input source for the enumeration without a pipeline,
+// which releases up to max names into the pipeline at once.
+func newTestEnumSource(e *Enumeration, max int) *enumSource {
+	r := &enumSource{
+		enum:     e,
+		queue:    queue.NewQueue(),
+		filter:   &bloomFilter{filter: bf.NewDefaultStableBloomFilter(100000, 0.001)},
+		done:     make(chan struct{}),
+		release:  make(chan struct{}, max),
+		max:      max,
+		inflight: make(map[string]struct{}),
+	}
+	r.flightCond = sync.NewCond(&r.flightLock)
+	return r
+}
+
+func TestMaxInFlightNames(t *testing.T) {
+	max := 5
+	e := &Enumeration{
+		Config:           config.NewConfig(),
+		MaxInFlightNames: max,
+	}
+	r := newTestEnumSource(e, 10)
+
+	inflight := func() int {
+		r.flightLock.Lock()
+		defer r.flightLock.Unlock()
+		return len(r.inflight)
+	}
+
+	var names []string
+	for i := 0; i < 100; i++ {
+		names = append(names, "host"+strconv.Itoa(i)+".owasp.org")
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for _, name := range names {
+			r.newName(&requests.DNSRequest{Name: name, Domain: "owasp.org"})
+		}
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	if n := inflight(); n != max || r.queue.Len() != max {
+		t.Fatalf("expected %d names in flight, got %d with %d queued", max, n, r.queue.Len())
+	}
+
+	// Names leaving the store stage allow more to be submitted
+	for _, name := range names[:3] {
+		r.leaveFlight(name)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := inflight(); n != max || r.queue.Len() != max+3 {
+		t.Errorf("expected %d names in flight, got %d with %d queued", max, n, r.queue.Len())
+	}
+
+	r.markDone()
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the blocked submission was not released after the enumeration terminated")
+	}
+	if n := inflight(); n > max {
+		t.Errorf("the names in flight grew to %d beyond the limit of %d", n, max)
+	}
+}
+
+func TestPriorityPatterns(t *testing.T) {
+	e := &Enumeration{
+		Config:           config.NewConfig(),
+		PriorityPatterns: []string{`^(admin|vpn)\.`, `\bapi\b`},
+	}
+	if err := e.compilePriorityPatterns(); err != nil {
+		t.Fatalf("failed to compile the priority patterns: %v", err)
+	}
+	r := newTestEnumSource(e, 10)
+
+	// the interesting names arrive among many ordinary names from concurrent data sources
+	priority := map[string]struct{}{
+		"admin.owasp.org":       {},
+		"vpn.owasp.org":         {},
+		"api.owasp.org":         {},
+		"v2.api.owasp.org":      {},
+		"api-gateway.owasp.org": {},
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 250; i++ {
+				r.newName(&requests.DNSRequest{Name: "host" + strconv.Itoa(w*250+i) + ".owasp.org", Domain: "owasp.org"})
+			}
+		}(w)
+	}
+	for name := range priority {
+		r.newName(&requests.DNSRequest{Name: name, Domain: "owasp.org"})
+	}
+	r.newName(&requests.DNSRequest{Name: "rapid.owasp.org", Domain: "owasp.org"})
+	wg.Wait()
+
+	if n := r.queue.Len(); n != 1006 {
+		t.Fatalf("expected 1006 names in the queue, but got %d", n)
+	}
+	for i := 0; i < len(priority); i++ {
+		req, ok := r.Data().(*requests.DNSRequest)
+		if !ok {
+			t.Fatalf("the queue did not return a name")
+		}
+		if _, found := priority[req.Name]; !found {
+			t.Errorf("expected a priority name at position %d, but got %s", i, req.Name)
+		}
+	}
+	if req, ok := r.Data().(*requests.DNSRequest); !ok || !strings.HasPrefix(req.Name, "host") && req.Name != "rapid.owasp.org" {
+		t.Errorf("expected an ordinary name after the priority names, but got %v", req)
+	}
+
+	e.PriorityPatterns = []string{"("}
+	if err := e.compilePriorityPatterns(); err == nil {
+		t.Errorf("an invalid priority pattern was accepted")
+	}
+}
+
+func TestNameActiveTechnique(t *testing.T) {
+	e := &Enumeration{Config: config.NewConfig()}
+	e.Config.AddDomain("owasp.org")
+	r := newTestEnumSource(e, 10)
+
+	r.newName(&requests.DNSRequest{Name: "brute.owasp.org", Domain: "owasp.org", Tag: requests.BRUTE})
+	r.newName(&requests.DNSRequest{Name: "cert.owasp.org", Domain: "owasp.org", Tag: requests.CERT})
+	// the name provided again by an active technique keeps the passive technique that provided it first
+	r.newName(&requests.DNSRequest{Name: "cert.owasp.org", Domain: "owasp.org", Tag: requests.DNS})
+
+	active := make(map[string]bool)
+	for r.queue.Len() > 0 {
+		req := r.Data().(*requests.DNSRequest)
+		active[req.Name] = req.Active
+	}
+	if len(active) != 2 || !active["brute.owasp.org"] || active["cert.owasp.org"] {
+		t.Errorf("unexpected techniques of the names: %v", active)
+	}
+}
+
+func TestValidateListOnly(t *testing.T) {
+	e := &Enumeration{Config: config.NewConfig(), ValidateListOnly: true}
+	e.Config.AddDomain("owasp.org")
+	r := newTestEnumSource(e, 10)
+	e.nameSrc = r
+
+	if !e.resolveOnly() {
+		t.Errorf("the validation of the list did not imply the forward resolution only")
+	}
+	// neither the root domains nor the names and addresses discovered are submitted
+	e.submitDomainNames()
+	r.newName(&requests.DNSRequest{Name: "discovered.owasp.org", Domain: "owasp.org"})
+	r.newNameWithoutWait(&requests.DNSRequest{Name: "target.owasp.org", Domain: "owasp.org"})
+	r.newAddr(&requests.AddrRequest{Address: "192.0.2.1", Domain: "owasp.org", InScope: true})
+
+	e.Config.ProvidedNames = []string{"www.owasp.org", "mail.owasp.org"}
+	e.submitProvidedNames()
+
+	var names []string
+	for r.queue.Len() > 0 {
+		req, ok := r.Data().(*requests.DNSRequest)
+		if !ok {
+			t.Fatalf("the queue returned data other than a name")
+		}
+		names = append(names, req.Name)
+	}
+	if len(names) != 2 || names[0] != "www.owasp.org" || names[1] != "mail.owasp.org" {
+		t.Errorf("expected only the provided names to be submitted, but got %v", names)
+	}
+}
+
+func TestCompletionGracePeriod(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{
+		Config:                cfg,
+		CompletionGracePeriod: 30 * time.Second,
+		store:                 &dataManager{queue: queue.NewQueue()},
+	}
+	r := newTestEnumSource(e, 10)
+	r.pipeline = pipeline.NewPipeline()
+	e.nameSrc = r
+
+	now := time.Now()
+	if r.finished(now) {
+		t.Fatalf("the enumeration finished before the grace period")
+	}
+	if wait := r.nextCheck(now); wait != waitForDuration {
+		t.Errorf("expected the next check in %s, but got %s", waitForDuration, wait)
+	}
+	// a slow data source still has requests pending
+	e.setRequestsPending(map[string]bool{"Slow Source": true})
+	if r.finished(now.Add(time.Minute)) {
+		t.Errorf("the enumeration finished while the data source requests were pending")
+	}
+	e.setRequestsPending(map[string]bool{"Slow Source": false})
+
+	// the data source returns a name just before the grace period ends
+	now = now.Add(2 * time.Minute)
+	if r.finished(now) {
+		t.Fatalf("the enumeration finished without waiting for the grace period")
+	}
+	now = now.Add(25 * time.Second)
+	r.newNameWithoutWait(&requests.DNSRequest{Name: "late.owasp.org", Domain: "owasp.org"})
+	if r.finished(now) {
+		t.Fatalf("the enumeration finished with the late name queued")
+	}
+	if _, ok := r.queue.Next(); !ok {
+		t.Fatalf("the late name was not queued")
+	}
+
+	// the grace period starts over once the late name has been processed
+	if r.finished(now) || r.finished(now.Add(29*time.Second)) {
+		t.Errorf("the enumeration finished before the grace period started over")
+	}
+	if wait := r.nextCheck(now.Add(29 * time.Second)); wait != time.Second {
+		t.Errorf("expected the next check at the end of the grace period, but got %s", wait)
+	}
+	if !r.finished(now.Add(30 * time.Second)) {
+		t.Errorf("the enumeration did not finish after the grace period")
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+// jsonlOutput writes each resolved name as a line of JSON, in the format of the object storage
+// output, optionally through the compressor of the CompressOutput.
+type jsonlOutput struct {
+	sync.Mutex
+	path string
+	file *os.File
+	comp compressor
+	w    *bufio.Writer
+}
+
+// SetJSONLOutput creates the file at path, or uses STDOUT for "-", and writes each resolved name to
+// it as a line of JSON. When CompressOutput has been set, which must happen before this call, the
+// lines are compressed and the extension of the format is appended to the path.
+func (e *Enumeration) SetJSONLOutput(path string) error {
+	if err := e.checkCompressOutput(); err != nil {
+		return err
+	}
+
+	jo := &jsonlOutput{path: path}
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		jo.path = compressedPath(path, e.CompressOutput)
+
+		f, err := os.OpenFile(jo.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open the JSONL output file %s: %v", jo.path, err)
+		}
+		jo.file = f
+		w = f
+	}
+
+	if e.CompressOutput != "" {
+		comp, err := newCompressor(e.CompressOutput, w)
+		if err != nil {
+			jo.closeFile()
+			return err
+		}
+		jo.comp = comp
+		w = comp
+	}
+
+	jo.w = bufio.NewWriter(w)
+	e.jsonl = jo
+	e.addOutput("JSONL", jo)
+	return nil
+}
+
+func (jo *jsonlOutput) write(req *requests.DNSRequest, sources []string) error {
+	line, err := json.Marshal(&objectRecord{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Name:      req.Name,
+		Domain:    req.Domain,
+		Tag:       req.Tag,
+		Source:    req.Source,
+		Active:    req.Active,
+		Sources:   sources,
+		Records:   req.Records,
+	})
+	if err != nil {
+		return err
+	}
+
+	jo.Lock()
+	defer jo.Unlock()
+
+	if _, err := jo.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+// flush writes the buffered lines through the compressor, and must be called with the lock held.
+func (jo *jsonlOutput) flush() error {
+	if err := jo.w.Flush(); err != nil {
+		return err
+	}
+	if jo.comp != nil {
+		return jo.comp.Flush()
+	}
+	return nil
+}
+
+// stop flushes the lines, completes the compressed stream and closes the file, so the file is valid.
+func (jo *jsonlOutput) stop() error {
+	jo.Lock()
+	defer jo.Unlock()
+
+	err := jo.w.Flush()
+	if jo.comp != nil {
+		if cerr := jo.comp.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if ferr := jo.closeFile(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+func (jo *jsonlOutput) closeFile() error {
+	if jo.file == nil {
+		return nil
+	}
+
+	err := jo.file.Sync()
+	if cerr := jo.file.Close(); err == nil {
+		err = cerr
+	}
+	jo.file = nil
+	return err
+}
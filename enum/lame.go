@@ -0,0 +1,109 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+// lameDelegationTests checks that the nameservers listed for a zone answer authoritatively for it.
+type lameDelegationTests struct {
+	sync.Mutex
+	enum   *Enumeration
+	tested map[string]struct{}
+}
+
+func newLameDelegationTests(e *Enumeration) *lameDelegationTests {
+	return &lameDelegationTests{
+		enum:   e,
+		tested: make(map[string]struct{}),
+	}
+}
+
+// test flags the nameserver when the name does not exist, none of the addresses
+// respond, or no address answers authoritatively for the SOA of the zone.
+func (l *lameDelegationTests) test(ctx context.Context, zone, ns string) {
+	key := zone + "," + ns
+	l.Lock()
+	if _, found := l.tested[key]; found {
+		l.Unlock()
+		return
+	}
+	l.tested[key] = struct{}{}
+	l.Unlock()
+
+	var nxdomain bool
+	var addrs []string
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		resp, err := l.enum.queryBlocking(ctx, resolve.QueryMsg(ns, qtype), l.enum.Sys.TrustedResolvers())
+		if err != nil || resp == nil {
+			continue
+		}
+		if resp.Rcode == dns.RcodeNameError {
+			nxdomain = true
+			break
+		}
+
+		for _, rr := range resolve.AnswersByType(resolve.ExtractAnswers(resp), qtype) {
+			addrs = append(addrs, rr.Data)
+		}
+	}
+
+	if nxdomain {
+		l.enum.log().Warnf("Lame delegation: nameserver %s for %s does not exist, and the name may be available for registration", ns, zone)
+		return
+	}
+	if len(addrs) == 0 {
+		return
+	}
+
+	network := "udp"
+	// UDP is not sent through the SOCKS5 proxy
+	if l.enum.tcpOnly() {
+		network = "tcp"
+	}
+
+	var responded bool
+	for _, addr := range addrs {
+		msg := resolve.QueryMsg(zone, dns.TypeSOA)
+		l.enum.setEDNSBufferSize(msg)
+		msg.RecursionDesired = false
+
+		resp, err := l.enum.exchange(ctx, network, net.JoinHostPort(addr, "53"), msg)
+		if err != nil || resp == nil {
+			continue
+		}
+		if authoritativeSOA(resp, zone) {
+			return
+		}
+		responded = true
+	}
+
+	if responded {
+		l.enum.log().Warnf("Lame delegation: nameserver %s does not answer authoritatively for %s", ns, zone)
+	} else {
+		l.enum.log().Warnf("Lame delegation: nameserver %s for %s is unreachable", ns, zone)
+	}
+}
+
+// authoritativeSOA returns true when the response is an authoritative answer with the SOA record of the zone.
+func authoritativeSOA(resp *dns.Msg, zone string) bool {
+	if resp == nil || resp.Rcode != dns.RcodeSuccess || !resp.Authoritative {
+		return false
+	}
+
+	for _, rr := range resp.Answer {
+		if soa, ok := rr.(*dns.SOA); ok && strings.EqualFold(dns.Fqdn(zone), soa.Hdr.Name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestAuthoritativeSOA(t *testing.T) {
+	soa := &dns.SOA{
+		Hdr:  dns.RR_Header{Name: "owasp.org.", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 300},
+		Ns:   "ns1.owasp.org.",
+		Mbox: "hostmaster.owasp.org.",
+	}
+
+	tests := []struct {
+		zone     string
+		msg      *dns.Msg
+		expected bool
+	}{
+		{"owasp.org", nil, false},
+		{"owasp.org", &dns.Msg{MsgHdr: dns.MsgHdr{Authoritative: true}, Answer: []dns.RR{soa}}, true},
+		{"OWASP.org", &dns.Msg{MsgHdr: dns.MsgHdr{Authoritative: true}, Answer: []dns.RR{soa}}, true},
+		{"owasp.org", &dns.Msg{MsgHdr: dns.MsgHdr{Authoritative: false}, Answer: []dns.RR{soa}}, false},
+		{"owasp.org", &dns.Msg{MsgHdr: dns.MsgHdr{Authoritative: true}}, false},
+		{"owasp.org", &dns.Msg{MsgHdr: dns.MsgHdr{Authoritative: true, Rcode: dns.RcodeRefused}, Answer: []dns.RR{soa}}, false},
+		{"example.com", &dns.Msg{MsgHdr: dns.MsgHdr{Authoritative: true}, Answer: []dns.RR{soa}}, false},
+	}
+
+	for i, test := range tests {
+		if got := authoritativeSOA(test.msg, test.zone); got != test.expected {
+			t.Errorf("Test %d: expected %t, but got %t", i, test.expected, got)
+		}
+	}
+}
+
+func TestLameDelegationProbes(t *testing.T) {
+	authoritative := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		m.Authoritative = true
+		m.Answer = append(m.Answer, &dns.SOA{
+			Hdr:  dns.RR_Header{Name: "owasp.org.", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 300},
+			Ns:   "ns1.owasp.org.",
+			Mbox: "hostmaster.owasp.org.",
+		})
+		_ = w.WriteMsg(m)
+	})
+	lame := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetRcode(req, dns.RcodeRefused)
+		_ = w.WriteMsg(m)
+	})
+	// ns4.owasp.org does not exist, and nothing answers at the address of ns3.owasp.org
+	addr := startMockDNS(t, nameserverHandler(map[string]string{
+		"ns1.owasp.org.": "192.0.2.53",
+		"ns2.owasp.org.": "192.0.2.54",
+		"ns3.owasp.org.": "192.0.2.55",
+	}))
+
+	cfg := config.NewConfig()
+	cfg.TrustedResolvers = []string{addr}
+	var buf bytes.Buffer
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: resolve.NewResolvers()},
+		ForceTCP: true,
+		Logger:   NewLogger(log.New(&buf, "", 0), LevelWarn),
+		tracked:  newTrackedWork(maxTrackedWork),
+	}
+	defer e.Sys.Resolvers().Stop()
+	defer e.Sys.TrustedResolvers().Stop()
+	e.lame = newLameDelegationTests(e)
+	dt := &dnsTask{enum: e}
+
+	bg, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx := amassnet.WithDialer(bg, &redirectDialer{targets: map[string]string{
+		"192.0.2.53:53": authoritative,
+		"192.0.2.54:53": lame,
+	}})
+
+	ch := make(chan []requests.DNSAnswer, 1)
+	dt.queryNS(ctx, "owasp.org", "owasp.org", ch, discardParams{})
+	if rr := <-ch; len(rr) != 3 {
+		t.Fatalf("expected the three NS records, but got %v", rr)
+	}
+	e.tracked.wait()
+	if e.requestsPending() {
+		t.Errorf("the lame delegation probes were still tracked after they completed")
+	}
+
+	out := buf.String()
+	for _, expected := range []string{
+		"nameserver ns2.owasp.org does not answer authoritatively for owasp.org",
+		"nameserver ns3.owasp.org for owasp.org is unreachable",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected the warning %q, but got %q", expected, out)
+		}
+	}
+	if strings.Contains(out, "ns1.owasp.org") {
+		t.Errorf("the authoritative nameserver was reported: %q", out)
+	}
+
+	// the nameserver name that does not exist could be registered by anyone
+	e.lame.test(ctx, "owasp.org", "ns4.owasp.org")
+	if expected := "nameserver ns4.owasp.org for owasp.org does not exist"; !strings.Contains(buf.String(), expected) {
+		t.Errorf("expected the warning %q, but got %q", expected, buf.String())
+	}
+}
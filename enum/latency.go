@@ -0,0 +1,121 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"sort"
+	"time"
+)
+
+// latencyWindow is the number of recent response times kept for each resolver.
+const latencyWindow = 512
+
+// ResolverStats summarizes the DNS queries sent to a resolver. The queries sent through the pools
+// are accounted to the "trusted pool" and "untrusted pool", since the pools select the resolvers.
+type ResolverStats struct {
+	Resolver string
+	Queries  int64
+	// Errors counts the queries without a response, such as the timeouts
+	Errors    int64
+	ErrorRate float64
+	// P50 and P95 are the percentiles of the recent response times
+	P50 time.Duration
+	P95 time.Duration
+}
+
+// resolverLatency keeps the counts and a rolling window of the response times for a resolver.
+type resolverLatency struct {
+	queries int64
+	errors  int64
+	samples [latencyWindow]time.Duration
+	next    int
+	filled  bool
+}
+
+func (rl *resolverLatency) add(rtt time.Duration, failed bool) {
+	rl.queries++
+	if failed {
+		rl.errors++
+		return
+	}
+
+	rl.samples[rl.next] = rtt
+	rl.next = (rl.next + 1) % latencyWindow
+	if rl.next == 0 {
+		rl.filled = true
+	}
+}
+
+func (rl *resolverLatency) stats(resolver string) ResolverStats {
+	s := ResolverStats{
+		Resolver: resolver,
+		Queries:  rl.queries,
+		Errors:   rl.errors,
+	}
+	if rl.queries > 0 {
+		s.ErrorRate = float64(rl.errors) / float64(rl.queries)
+	}
+
+	n := rl.next
+	if rl.filled {
+		n = latencyWindow
+	}
+	if n == 0 {
+		return s
+	}
+
+	sorted := make([]time.Duration, n)
+	copy(sorted, rl.samples[:n])
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	s.P50 = percentile(sorted, 50)
+	s.P95 = percentile(sorted, 95)
+	return s
+}
+
+// percentile returns the nearest-rank percentile of the sorted response times.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	idx := (len(sorted)*p + 99) / 100
+	if idx < 1 {
+		idx = 1
+	}
+	return sorted[idx-1]
+}
+
+// recordLatency accounts the query sent to the resolver, which failed when no response was received.
+func (e *Enumeration) recordLatency(resolver string, start time.Time, failed bool) {
+	rtt := time.Since(start)
+
+	e.statLock.Lock()
+	defer e.statLock.Unlock()
+
+	if e.rstats == nil {
+		e.rstats = make(map[string]*resolverLatency)
+	}
+	rl, found := e.rstats[resolver]
+	if !found {
+		rl = new(resolverLatency)
+		e.rstats[resolver] = rl
+	}
+	rl.add(rtt, failed)
+}
+
+// ResolverStats returns the query counts, error rates and response time percentiles of the resolvers
+// used by ResolveName, the reverse DNS queries and the other queries waiting for their responses,
+// sorted by resolver. It can be called while the enumeration is running.
+func (e *Enumeration) ResolverStats() []ResolverStats {
+	e.statLock.Lock()
+	defer e.statLock.Unlock()
+
+	results := make([]ResolverStats, 0, len(e.rstats))
+	for resolver, rl := range e.rstats {
+		results = append(results, rl.stats(resolver))
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Resolver < results[j].Resolver
+	})
+	return results
+}
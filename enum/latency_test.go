@@ -0,0 +1,94 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestResolverLatency(t *testing.T) {
+	var rl resolverLatency
+	for i := 1; i <= 100; i++ {
+		rl.add(time.Duration(i)*time.Millisecond, false)
+	}
+	for i := 0; i < 25; i++ {
+		rl.add(0, true)
+	}
+
+	s := rl.stats("192.0.2.1:53")
+	if s.Queries != 125 || s.Errors != 25 || s.ErrorRate != 0.2 {
+		t.Errorf("expected 125 queries with 25 errors, but got %d, %d and %f", s.Queries, s.Errors, s.ErrorRate)
+	}
+	if s.P50 != 50*time.Millisecond || s.P95 != 95*time.Millisecond {
+		t.Errorf("expected the percentiles 50ms and 95ms, but got %s and %s", s.P50, s.P95)
+	}
+
+	// the window only keeps the recent response times
+	for i := 0; i < latencyWindow; i++ {
+		rl.add(time.Second, false)
+	}
+	if s := rl.stats("192.0.2.1:53"); s.P50 != time.Second || s.P95 != time.Second {
+		t.Errorf("expected the percentiles to roll over to 1s, but got %s and %s", s.P50, s.P95)
+	}
+}
+
+func TestResolverStats(t *testing.T) {
+	srvAddr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		_ = w.WriteMsg(m)
+	})
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on a TCP port: %v", err)
+	}
+	down := closed.Addr().String()
+	closed.Close()
+
+	cfg := config.NewConfig()
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: resolve.NewResolvers()},
+		ForceTCP: true,
+	}
+	defer e.Sys.Resolvers().Stop()
+	defer e.Sys.TrustedResolvers().Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, addr := range []string{srvAddr, srvAddr, down} {
+		cfg.TrustedResolvers = []string{addr}
+		_, _ = e.queryBlocking(ctx, resolve.QueryMsg("www.owasp.org", dns.TypeA), e.Sys.TrustedResolvers())
+	}
+
+	stats := e.ResolverStats()
+	if len(stats) != 2 {
+		t.Fatalf("expected the stats of 2 resolvers, but got %d", len(stats))
+	}
+	for _, s := range stats {
+		switch s.Resolver {
+		case srvAddr:
+			if s.Queries != 2 || s.Errors != 0 || s.P95 <= 0 {
+				t.Errorf("expected 2 successful queries to %s, but got %+v", s.Resolver, s)
+			}
+		case down:
+			if s.Queries != 1 || s.Errors != 1 || s.ErrorRate != 1 {
+				t.Errorf("expected 1 failed query to %s, but got %+v", s.Resolver, s)
+			}
+		default:
+			t.Errorf("unexpected stats for %s", s.Resolver)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+// queryLOC obtains the LOC records of the name, which are not extracted by the resolve package.
+func (dt *dnsTask) queryLOC(ctx context.Context, name string, ch chan []requests.DNSAnswer) {
+	if resp, err := dt.enum.dnsQuery(ctx, name, dns.TypeLOC, dt.enum.Sys.TrustedResolvers(), maxDNSQueryAttempts); err == nil {
+		if records := locAnswers(resp); len(records) > 0 {
+			ch <- truncateAnswers(records, dt.enum.MaxAnswerBytes)
+			return
+		}
+	}
+	ch <- nil
+}
+
+// locAnswers converts the LOC records in the answer section, and decodes the coordinates.
+// The records using a version other than zero are dropped, as required by RFC 1876.
+func locAnswers(resp *dns.Msg) []requests.DNSAnswer {
+	if resp == nil {
+		return nil
+	}
+
+	var answers []requests.DNSAnswer
+	for _, rr := range resp.Answer {
+		loc, ok := rr.(*dns.LOC)
+		if !ok || loc.Version != 0 {
+			continue
+		}
+
+		answers = append(answers, requests.DNSAnswer{
+			Name: resolve.RemoveLastDot(loc.Hdr.Name),
+			Type: int(dns.TypeLOC),
+			TTL:  int(loc.Hdr.Ttl),
+			Data: strings.TrimSpace(strings.TrimPrefix(loc.String(), loc.Hdr.String())),
+			Location: &requests.LOCData{
+				Latitude:       locDegrees(loc.Latitude, dns.LOC_EQUATOR),
+				Longitude:      locDegrees(loc.Longitude, dns.LOC_PRIMEMERIDIAN),
+				Altitude:       float64(loc.Altitude)/100 - dns.LOC_ALTITUDEBASE,
+				Size:           locMeters(loc.Size),
+				HorizPrecision: locMeters(loc.HorizPre),
+				VertPrecision:  locMeters(loc.VertPre),
+			},
+		})
+	}
+	return answers
+}
+
+// locDegrees converts the thousandths of an arc second from the origin into degrees,
+// which are negative for the south latitudes and the west longitudes.
+func locDegrees(v, origin uint32) float64 {
+	return (float64(v) - float64(origin)) / dns.LOC_DEGREES
+}
+
+// locMeters converts the size and precision encoding, the base in the high nibble and the
+// power of ten in the low nibble, from centimeters into meters.
+func locMeters(v uint8) float64 {
+	return float64(v>>4) * math.Pow10(int(v&0x0f)) / 100
+}
@@ -0,0 +1,54 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"math"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestLOCAnswers(t *testing.T) {
+	rr, err := dns.NewRR("www.owasp.org. 300 IN LOC 52 22 23.000 N 4 53 32.000 W -2.00m 1m 10000m 10m")
+	if err != nil {
+		t.Fatalf("failed to parse the LOC record: %v", err)
+	}
+	bad := *rr.(*dns.LOC)
+	bad.Version = 1
+
+	resp := new(dns.Msg)
+	resp.Answer = []dns.RR{rr, &bad, &dns.A{
+		Hdr: dns.RR_Header{Name: "www.owasp.org.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+	}}
+
+	answers := locAnswers(resp)
+	if len(answers) != 1 {
+		t.Fatalf("expected 1 LOC answer, but got %d", len(answers))
+	}
+
+	a := answers[0]
+	if a.Name != "www.owasp.org" || a.Type != int(dns.TypeLOC) || a.TTL != 300 {
+		t.Errorf("unexpected LOC answer: %+v", a)
+	}
+	if expected := "52 22 23.000 N 04 53 32.000 W -2m 1m 10000m 10m"; a.Data != expected {
+		t.Errorf("expected the data %s, but got %s", expected, a.Data)
+	}
+
+	loc := a.Location
+	if loc == nil {
+		t.Fatalf("the LOC record was not decoded")
+	}
+	near := func(x, y float64) bool { return math.Abs(x-y) < 1e-6 }
+	if !near(loc.Latitude, 52+22.0/60+23.0/3600) || !near(loc.Longitude, -(4+53.0/60+32.0/3600)) {
+		t.Errorf("unexpected coordinates: %f, %f", loc.Latitude, loc.Longitude)
+	}
+	if !near(loc.Altitude, -2) || !near(loc.Size, 1) || !near(loc.HorizPrecision, 10000) || !near(loc.VertPrecision, 10) {
+		t.Errorf("unexpected altitude, size or precision: %+v", loc)
+	}
+	if locAnswers(nil) != nil {
+		t.Errorf("answers were returned without a response")
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import "log"
+
+// LogLevel is the severity of a message written by the Logger.
+type LogLevel int
+
+// The log levels in order of increasing severity.
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// Logger is the leveled logging interface used by the enumeration.
+type Logger interface {
+	Debugf(format string, v ...interface{})
+	Infof(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+// NewLogger returns a Logger that writes the messages at or above the provided level to l.
+func NewLogger(l *log.Logger, level LogLevel) Logger {
+	return &stdLogger{
+		logger: l,
+		level:  level,
+	}
+}
+
+type stdLogger struct {
+	logger *log.Logger
+	level  LogLevel
+}
+
+func (s *stdLogger) printf(level LogLevel, format string, v ...interface{}) {
+	if s.logger != nil && level >= s.level {
+		s.logger.Printf(format, v...)
+	}
+}
+
+// Debugf implements the Logger interface.
+func (s *stdLogger) Debugf(format string, v ...interface{}) {
+	s.printf(LevelDebug, format, v...)
+}
+
+// Infof implements the Logger interface.
+func (s *stdLogger) Infof(format string, v ...interface{}) {
+	s.printf(LevelInfo, format, v...)
+}
+
+// Warnf implements the Logger interface.
+func (s *stdLogger) Warnf(format string, v ...interface{}) {
+	s.printf(LevelWarn, format, v...)
+}
+
+// Errorf implements the Logger interface.
+func (s *stdLogger) Errorf(format string, v ...interface{}) {
+	s.printf(LevelError, format, v...)
+}
@@ -0,0 +1,43 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func (e *Enumeration) addLowTTL(ans requests.DNSAnswer) {
+	e.ttlLock.Lock()
+	defer e.ttlLock.Unlock()
+
+	if e.lowttl == nil {
+		e.lowttl = make(map[string]requests.DNSAnswer)
+	}
+	e.lowttl[ans.Name+"|"+strconv.Itoa(ans.Type)+"|"+ans.Data] = ans
+}
+
+// LowTTLAnswers returns the answers stored with a TTL below the MinTTLFlag, sorted by name.
+func (e *Enumeration) LowTTLAnswers() []requests.DNSAnswer {
+	e.ttlLock.Lock()
+	defer e.ttlLock.Unlock()
+
+	results := make([]requests.DNSAnswer, 0, len(e.lowttl))
+	for _, ans := range e.lowttl {
+		results = append(results, ans)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Name != results[j].Name {
+			return results[i].Name < results[j].Name
+		}
+		if results[i].Type != results[j].Type {
+			return results[i].Type < results[j].Type
+		}
+		return results[i].Data < results[j].Data
+	})
+	return results
+}
@@ -0,0 +1,59 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestLowTTLAnswers(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := config.NewConfig()
+	cfg.Log = log.New(&buf, "", 0)
+	e := &Enumeration{
+		Config:     cfg,
+		MinTTLFlag: 60,
+	}
+	dm := &dataManager{enum: e}
+
+	records := []requests.DNSAnswer{
+		{Name: "www.owasp.org", Type: int(dns.TypeA), TTL: 30, Data: "192.0.2.1"},
+		{Name: "www.owasp.org", Type: int(dns.TypeA), TTL: 300, Data: "192.0.2.2"},
+		{Name: "api.owasp.org", Type: int(dns.TypeCNAME), TTL: 5, Data: "lb.example.com"},
+		{Name: "mail.owasp.org", Type: int(dns.TypeA), TTL: 0, Data: "192.0.2.3"},
+	}
+	for i := range records {
+		dm.checkTTL(&records[i])
+	}
+	// the same answer is only returned once
+	dm.checkTTL(&requests.DNSAnswer{Name: "www.owasp.org", Type: int(dns.TypeA), TTL: 30, Data: "192.0.2.1"})
+
+	if !records[0].LowTTL || records[1].LowTTL || !records[2].LowTTL || records[3].LowTTL {
+		t.Errorf("the answers were not flagged by the TTL: %+v", records)
+	}
+
+	answers := e.LowTTLAnswers()
+	if len(answers) != 2 {
+		t.Fatalf("expected 2 answers with a low TTL, but got %v", answers)
+	}
+	if answers[0].Name != "api.owasp.org" || answers[1].Name != "www.owasp.org" || answers[1].Data != "192.0.2.1" {
+		t.Errorf("unexpected answers with a low TTL: %v", answers)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("Low TTL")) {
+		t.Errorf("the answers with a low TTL were not logged")
+	}
+
+	e.MinTTLFlag = 0
+	ans := requests.DNSAnswer{Name: "app.owasp.org", Type: int(dns.TypeA), TTL: 1, Data: "192.0.2.4"}
+	if dm.checkTTL(&ans); ans.LowTTL {
+		t.Errorf("the answer was flagged without the MinTTLFlag")
+	}
+}
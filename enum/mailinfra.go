@@ -0,0 +1,81 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+// MailExchanger is a mail server of a name, as published by its MX records. The exchangers outside
+// of the scope are kept with InScope unset, and often reveal the third-party mail providers.
+type MailExchanger struct {
+	Name     string
+	Host     string
+	Priority int
+	InScope  bool
+}
+
+// mapMailExchanger records the MX target of the name, and submits the targets within the scope for
+// the forward resolution. The targets outside of the scope are only recorded.
+func (e *Enumeration) mapMailExchanger(name, target string, priority int) {
+	name = strings.ToLower(resolve.RemoveLastDot(name))
+	target = strings.ToLower(resolve.RemoveLastDot(target))
+	domain := e.Config.WhichDomain(target)
+
+	e.mailLock.Lock()
+	if e.mailxs == nil {
+		e.mailxs = make(map[string]MailExchanger)
+	}
+	key := name + "|" + target
+	_, found := e.mailxs[key]
+	e.mailxs[key] = MailExchanger{
+		Name:     name,
+		Host:     target,
+		Priority: priority,
+		InScope:  domain != "",
+	}
+	e.mailLock.Unlock()
+
+	if domain == "" {
+		if !found {
+			e.log().Infof("Out of scope mail exchanger: %s -> %s (priority %d)", name, target, priority)
+		}
+		return
+	}
+
+	e.nameSrc.newNameWithoutWait(&requests.DNSRequest{
+		Name:   target,
+		Domain: domain,
+		Tag:    requests.DNS,
+		Source: "MX",
+	})
+}
+
+// MailInfrastructure returns the mail exchangers published by the MX records, sorted by name and
+// priority. The exchangers are only collected when MapMailInfra has been enabled.
+func (e *Enumeration) MailInfrastructure() []MailExchanger {
+	e.mailLock.Lock()
+	defer e.mailLock.Unlock()
+
+	results := make([]MailExchanger, 0, len(e.mailxs))
+	for _, mx := range e.mailxs {
+		results = append(results, mx)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Name != results[j].Name {
+			return results[i].Name < results[j].Name
+		}
+		if results[i].Priority != results[j].Priority {
+			return results[i].Priority < results[j].Priority
+		}
+		return results[i].Host < results[j].Host
+	})
+	return results
+}
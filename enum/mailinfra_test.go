@@ -0,0 +1,124 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+// startMXServer returns the address of a DNS server answering the MX queries of the zones.
+func startMXServer(t *testing.T, zones map[string]map[string]uint16) string {
+	return startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		name := strings.ToLower(req.Question[0].Name)
+		if hosts, found := zones[name]; !found {
+			m.Rcode = dns.RcodeNameError
+		} else if req.Question[0].Qtype == dns.TypeMX {
+			for host, pref := range hosts {
+				m.Answer = append(m.Answer, &dns.MX{
+					Hdr:        dns.RR_Header{Name: name, Rrtype: dns.TypeMX, Class: dns.ClassINET, Ttl: 300},
+					Preference: pref,
+					Mx:         host,
+				})
+			}
+		}
+		_ = w.WriteMsg(m)
+	})
+}
+
+func TestMapMailInfra(t *testing.T) {
+	addr := startMXServer(t, map[string]map[string]uint16{
+		"owasp.org.": {
+			"mx1.owasp.org.":           10,
+			"mx2.owasp.org.":           20,
+			"aspmx.l.google.com.":      30,
+			"alt1.aspmx.l.google.com.": 40,
+		},
+		"example.com.": {
+			"mail.example.com.":                        5,
+			"example-com.mail.protection.outlook.com.": 10,
+		},
+	})
+
+	cfg := config.NewConfig()
+	cfg.AddDomains("owasp.org", "example.com")
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	e := &Enumeration{
+		Config:       cfg,
+		Sys:          &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP:     true,
+		MapMailInfra: true,
+		graph:        g,
+	}
+	defer e.Sys.Resolvers().Stop()
+	e.nameSrc = newTestEnumSource(e, 10)
+	dt := &dnsTask{enum: e}
+	dm := &dataManager{enum: e}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ch := make(chan []requests.DNSAnswer, 1)
+	for _, domain := range []string{"owasp.org", "example.com"} {
+		dt.queryMX(ctx, domain, ch, nil)
+
+		req := &requests.DNSRequest{Name: domain, Domain: domain, Records: <-ch}
+		if len(req.Records) == 0 {
+			t.Fatalf("no MX records were returned for %s", domain)
+		}
+		for i := range req.Records {
+			if err := dm.insertMX(ctx, req, i, nil); err != nil {
+				t.Errorf("failed to insert the MX record %s of %s: %v", req.Records[i].Data, domain, err)
+			}
+		}
+	}
+
+	expected := []MailExchanger{
+		{Name: "example.com", Host: "mail.example.com", Priority: 5, InScope: true},
+		{Name: "example.com", Host: "example-com.mail.protection.outlook.com", Priority: 10},
+		{Name: "owasp.org", Host: "mx1.owasp.org", Priority: 10, InScope: true},
+		{Name: "owasp.org", Host: "mx2.owasp.org", Priority: 20, InScope: true},
+		{Name: "owasp.org", Host: "aspmx.l.google.com", Priority: 30},
+		{Name: "owasp.org", Host: "alt1.aspmx.l.google.com", Priority: 40},
+	}
+	if got := e.MailInfrastructure(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected the mail exchangers %v, but got %v", expected, got)
+	}
+
+	// only the exchangers within the scope are submitted for the forward resolution
+	submitted := make(map[string]string)
+	e.nameSrc.queue.Process(func(data interface{}) {
+		if req, ok := data.(*requests.DNSRequest); ok {
+			submitted[req.Name] = req.Domain
+		}
+	})
+	want := map[string]string{
+		"mx1.owasp.org":    "owasp.org",
+		"mx2.owasp.org":    "owasp.org",
+		"mail.example.com": "example.com",
+	}
+	if !reflect.DeepEqual(submitted, want) {
+		t.Errorf("expected the names %v to be submitted, but got %v", want, submitted)
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+const (
+	defaultMQReconnectBufSize = 8 << 20
+	defaultMQPublishTimeout   = 30 * time.Second
+	defaultMQQueueSize        = 10000
+	mqConnectTimeout          = 30 * time.Second
+	mqReconnectWait           = 2 * time.Second
+	mqPublishRetryDelay       = 100 * time.Millisecond
+)
+
+// MessageQueueConfig provides the NATS subject receiving each resolved name as a JSON message.
+type MessageQueueConfig struct {
+	// URL is the NATS server, or the servers of a cluster separated by commas,
+	// such as nats://localhost:4222
+	URL     string
+	Subject string
+	// Token, or the Username and Password, authenticate the connection when provided
+	Token    string
+	Username string
+	Password string
+	// ReconnectBufSize is the amount of data buffered while the connection is reestablished,
+	// and defaults to 8 MiB
+	ReconnectBufSize int
+	// PublishTimeout is how long the publisher waits for the buffer to drain once it is full, before
+	// the message is dropped, and defaults to 30 seconds. It also bounds the final flush
+	PublishTimeout time.Duration
+	// QueueSize is the number of messages waiting for the publisher, beyond which the results are
+	// dropped instead of stalling the enumeration, and defaults to 10000
+	QueueSize int
+}
+
+// mqMessage is the JSON message published for each resolved name.
+type mqMessage struct {
+	UUID      string               `json:"uuid"`
+	Timestamp string               `json:"timestamp"`
+	Name      string               `json:"name"`
+	Domain    string               `json:"domain"`
+	Tag       string               `json:"tag"`
+	Source    string               `json:"source"`
+	Active    bool                 `json:"active"`
+	Sources   []string             `json:"sources,omitempty"`
+	Records   []requests.DNSAnswer `json:"records"`
+}
+
+// mqOutput publishes the results to the NATS subject. The connection is reestablished by the
+// client, which buffers the messages meanwhile. The messages are queued for the publisher
+// goroutine, which waits while the buffer is full, so the sink never waits on the connection.
+type mqOutput struct {
+	sync.Mutex
+	conn    *nats.Conn
+	subject string
+	uuid    string
+	timeout time.Duration
+	log     Logger
+	queue   chan *mqPending
+	done    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped bool
+}
+
+// mqPending is a message waiting in the queue for the publisher.
+type mqPending struct {
+	name string
+	data []byte
+}
+
+// SetMessageQueueOutput publishes each resolved name with its records to the NATS subject as a
+// JSON message, carrying the event UUID for the correlation of the messages. The messages still
+// buffered are flushed when the enumeration finishes.
+func (e *Enumeration) SetMessageQueueOutput(cfg MessageQueueConfig) error {
+	if cfg.URL == "" || cfg.Subject == "" {
+		return errors.New("the message queue output requires the server URL and the subject")
+	}
+	if cfg.ReconnectBufSize <= 0 {
+		cfg.ReconnectBufSize = defaultMQReconnectBufSize
+	}
+	if cfg.PublishTimeout <= 0 {
+		cfg.PublishTimeout = defaultMQPublishTimeout
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultMQQueueSize
+	}
+
+	opts := []nats.Option{
+		nats.Name("amass"),
+		nats.Timeout(mqConnectTimeout),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(mqReconnectWait),
+		nats.ReconnectBufSize(cfg.ReconnectBufSize),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				e.log().Warnf("Disconnected from the message queue: %v", err)
+			}
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			e.log().Infof("Reconnected to the message queue at %s", nc.ConnectedUrl())
+		}),
+	}
+	if cfg.Token != "" {
+		opts = append(opts, nats.Token(cfg.Token))
+	}
+	if cfg.Username != "" {
+		opts = append(opts, nats.UserInfo(cfg.Username, cfg.Password))
+	}
+
+	conn, err := nats.Connect(cfg.URL, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the message queue at %s: %v", cfg.URL, err)
+	}
+
+	e.mqout = newMQOutput(conn, cfg, uuid.NewString(), e.log())
+	e.addOutput("message queue", e.mqout)
+	e.log().Infof("The results are published to the %s subject with the event UUID %s", cfg.Subject, e.mqout.uuid)
+	return nil
+}
+
+func newMQOutput(conn *nats.Conn, cfg MessageQueueConfig, id string, log Logger) *mqOutput {
+	ctx, cancel := context.WithCancel(context.Background())
+	mo := &mqOutput{
+		conn:    conn,
+		subject: cfg.Subject,
+		uuid:    id,
+		timeout: cfg.PublishTimeout,
+		log:     log,
+		queue:   make(chan *mqPending, cfg.QueueSize),
+		done:    make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	go mo.publisher()
+	return mo
+}
+
+// write queues the message for the publisher, and the message is dropped when the queue is full.
+func (mo *mqOutput) write(req *requests.DNSRequest, sources []string) error {
+	data, err := json.Marshal(&mqMessage{
+		UUID:      mo.uuid,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Name:      req.Name,
+		Domain:    req.Domain,
+		Tag:       req.Tag,
+		Source:    req.Source,
+		Active:    req.Active,
+		Sources:   sources,
+		Records:   req.Records,
+	})
+	if err != nil {
+		return err
+	}
+
+	mo.Lock()
+	defer mo.Unlock()
+
+	if mo.stopped {
+		return errors.New("the message queue output has been stopped")
+	}
+	select {
+	case mo.queue <- &mqPending{name: req.Name, data: data}:
+	default:
+		return fmt.Errorf("the message queue is full and %s was dropped", req.Name)
+	}
+	return nil
+}
+
+func (mo *mqOutput) publisher() {
+	defer close(mo.done)
+
+	for msg := range mo.queue {
+		if err := mo.publish(msg); err != nil {
+			mo.log.Errorf("Failed to write the message queue output: %v", err)
+		}
+	}
+}
+
+func (mo *mqOutput) publish(msg *mqPending) error {
+	// the buffer fills while the connection is reestablished, so the publisher waits for it to drain
+	t := time.NewTimer(mo.timeout)
+	defer t.Stop()
+
+	for {
+		err := mo.conn.Publish(mo.subject, msg.data)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, nats.ErrReconnectBufExceeded) {
+			return fmt.Errorf("failed to publish %s: %v", msg.name, err)
+		}
+
+		select {
+		case <-time.After(mqPublishRetryDelay):
+		case <-t.C:
+			return fmt.Errorf("failed to publish %s: %v", msg.name, err)
+		case <-mo.ctx.Done():
+			return fmt.Errorf("failed to publish %s: %v", msg.name, err)
+		}
+	}
+}
+
+// stop drains the queue, flushes the buffered messages and closes the connection. The
+// messages still waiting once the PublishTimeout elapses are dropped.
+func (mo *mqOutput) stop() error {
+	mo.Lock()
+	if mo.stopped {
+		mo.Unlock()
+		return nil
+	}
+	mo.stopped = true
+	close(mo.queue)
+	mo.Unlock()
+
+	defer mo.conn.Close()
+	defer mo.cancel()
+
+	var err error
+	t := time.NewTimer(mo.timeout)
+	defer t.Stop()
+	select {
+	case <-mo.done:
+	case <-t.C:
+		mo.cancel()
+		<-mo.done
+		err = fmt.Errorf("the message queue was not drained within %s", mo.timeout)
+	}
+
+	if mo.conn.IsClosed() {
+		return err
+	}
+	if ferr := mo.conn.FlushTimeout(mo.timeout); err == nil {
+		err = ferr
+	}
+	return err
+}
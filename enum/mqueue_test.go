@@ -0,0 +1,204 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+// natsServer is a minimal NATS server collecting the messages published to it.
+type natsServer struct {
+	sync.Mutex
+	l     net.Listener
+	conns []net.Conn
+	msgs  map[string][][]byte
+}
+
+func startNATSServer(t *testing.T) *natsServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on a TCP port: %v", err)
+	}
+
+	s := &natsServer{l: l, msgs: make(map[string][][]byte)}
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.Lock()
+			s.conns = append(s.conns, c)
+			s.Unlock()
+			go s.serve(c)
+		}
+	}()
+	t.Cleanup(s.close)
+	return s
+}
+
+func (s *natsServer) url() string {
+	return "nats://" + s.l.Addr().String()
+}
+
+func (s *natsServer) serve(c net.Conn) {
+	defer c.Close()
+
+	fmt.Fprintf(c, "INFO {\"server_id\":\"test\",\"version\":\"2.9.0\",\"proto\":1,\"max_payload\":1048576}\r\n")
+	r := bufio.NewReader(c)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "PING":
+			fmt.Fprintf(c, "PONG\r\n")
+		case "PUB":
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+			s.Lock()
+			s.msgs[fields[1]] = append(s.msgs[fields[1]], payload[:size])
+			s.Unlock()
+		}
+	}
+}
+
+func (s *natsServer) messages(subject string) [][]byte {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.msgs[subject]
+}
+
+func (s *natsServer) close() {
+	_ = s.l.Close()
+
+	s.Lock()
+	defer s.Unlock()
+	for _, c := range s.conns {
+		_ = c.Close()
+	}
+}
+
+func TestMessageQueueOutput(t *testing.T) {
+	srv := startNATSServer(t)
+	e := &Enumeration{Config: config.NewConfig()}
+
+	if err := e.SetMessageQueueOutput(MessageQueueConfig{URL: srv.url()}); err == nil {
+		t.Errorf("the message queue output was set without a subject")
+	}
+	if err := e.SetMessageQueueOutput(MessageQueueConfig{URL: srv.url(), Subject: "amass.results"}); err != nil {
+		t.Fatalf("failed to set the message queue output: %v", err)
+	}
+
+	sink := e.makeOutputSink()
+	for _, name := range []string{"www.owasp.org", "mail.owasp.org"} {
+		req := &requests.DNSRequest{
+			Name:    name,
+			Domain:  "owasp.org",
+			Records: []requests.DNSAnswer{{Name: name, Type: int(dns.TypeA), TTL: 300, Data: "192.0.2.1"}},
+			Tag:     requests.DNS,
+			Source:  "DNS",
+		}
+		if err := sink(context.Background(), req); err != nil {
+			t.Fatalf("the output sink failed: %v", err)
+		}
+	}
+	// the names without records are not published
+	if err := sink(context.Background(), &requests.DNSRequest{Name: "ftp.owasp.org", Domain: "owasp.org"}); err != nil {
+		t.Fatalf("the output sink failed: %v", err)
+	}
+	if err := e.mqout.stop(); err != nil {
+		t.Fatalf("failed to flush the message queue output: %v", err)
+	}
+
+	msgs := srv.messages("amass.results")
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages on the subject, but got %d", len(msgs))
+	}
+	for i, name := range []string{"www.owasp.org", "mail.owasp.org"} {
+		var m mqMessage
+		if err := json.Unmarshal(msgs[i], &m); err != nil {
+			t.Fatalf("the message is not valid JSON: %v", err)
+		}
+		if m.UUID != e.mqout.uuid || m.Name != name || m.Domain != "owasp.org" || len(m.Records) != 1 || m.Records[0].Data != "192.0.2.1" {
+			t.Errorf("unexpected message for %s: %+v", name, m)
+		}
+	}
+}
+
+func TestMessageQueueBackpressure(t *testing.T) {
+	srv := startNATSServer(t)
+	e := &Enumeration{Config: config.NewConfig()}
+
+	if err := e.SetMessageQueueOutput(MessageQueueConfig{
+		URL:              srv.url(),
+		Subject:          "amass.results",
+		ReconnectBufSize: 64,
+		PublishTimeout:   300 * time.Millisecond,
+		QueueSize:        1,
+	}); err != nil {
+		t.Fatalf("failed to set the message queue output: %v", err)
+	}
+
+	// the messages are buffered while the client reconnects, until the buffer is full
+	srv.close()
+	for !e.mqout.conn.IsReconnecting() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	req := &requests.DNSRequest{
+		Name:    "www.owasp.org",
+		Domain:  "owasp.org",
+		Records: []requests.DNSAnswer{{Name: "www.owasp.org", Type: int(dns.TypeA), TTL: 300, Data: "192.0.2.1"}},
+	}
+	// the publisher waits for the buffer, while the writes return at once and drop the messages beyond the queue
+	var dropped bool
+	for i := 0; i < 10 && !dropped; i++ {
+		start := time.Now()
+		err := e.mqout.write(req, nil)
+		if d := time.Since(start); d > 100*time.Millisecond {
+			t.Errorf("the write waited on the connection for %s", d)
+		}
+		dropped = err != nil
+	}
+	if !dropped {
+		t.Errorf("the messages were accepted beyond the queue")
+	}
+
+	start := time.Now()
+	if err := e.mqout.stop(); err == nil {
+		t.Errorf("the undelivered messages were not reported by stop")
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("stop was not bounded by the publish timeout, and returned after %s", d)
+	}
+	if err := e.mqout.write(req, nil); err == nil {
+		t.Errorf("a message was accepted after the output was stopped")
+	}
+}
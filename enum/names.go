@@ -61,9 +61,13 @@ func (r *subdomainTask) Process(ctx context.Context, data pipeline.Data, tp pipe
 	if !ok {
 		return data, nil
 	}
-	if req == nil || !r.enum.Config.IsDomainInScope(req.Name) {
+	if req == nil || !r.enum.nameInScope(req) {
 		return nil, nil
 	}
+	// Names stored without records are not used to find more subdomains
+	if len(req.Records) == 0 {
+		return req, nil
+	}
 	// Do not further evaluate service subdomains
 	for _, label := range strings.Split(req.Name, ".") {
 		l := strings.ToLower(label)
@@ -74,6 +78,9 @@ func (r *subdomainTask) Process(ctx context.Context, data pipeline.Data, tp pipe
 	}
 
 	if r.checkForSubdomains(ctx, req, tp) {
+		if r.enum.EnablePermutations {
+			r.enum.submitPermutations(req)
+		}
 		r.enum.sendRequests(&requests.ResolvedRequest{
 			Name:    req.Name,
 			Domain:  req.Domain,
@@ -98,6 +105,13 @@ func (r *subdomainTask) checkForSubdomains(ctx context.Context, req *requests.DN
 
 	sub := strings.TrimSpace(strings.Join(nlabels[1:], "."))
 	times := r.timesForSubdomain(sub)
+	// Names beyond the cap for the parent are stored without further evaluation
+	if max := r.enum.MaxSubdomainsPerParent; max > 0 && times > max {
+		if times == max+1 {
+			r.enum.log().Infof("Subdomain cap: %s reached %d names, so the names below it no longer trigger requests", sub, max)
+		}
+		return false
+	}
 	if times == 1 && r.subWithinWildcard(ctx, sub, req.Domain) {
 		r.withinWildcards.Insert(sub)
 		return false
@@ -125,6 +139,13 @@ func (r *subdomainTask) checkForSubdomains(ctx context.Context, req *requests.DN
 }
 
 func (r *subdomainTask) subWithinWildcard(ctx context.Context, name, domain string) bool {
+	if detected, override := r.enum.wildcardOverride(name); override {
+		return detected
+	}
+	if r.enum.rcodes != nil && r.enum.rcodes.branch(ctx, name) {
+		return true
+	}
+
 	for _, t := range FwdQueryTypes {
 		select {
 		case <-ctx.Done():
@@ -132,9 +153,18 @@ func (r *subdomainTask) subWithinWildcard(ctx context.Context, name, domain stri
 		default:
 		}
 
-		if resp, err := r.enum.fwdQuery(ctx, "a."+name, t); err == nil &&
-			len(resp.Answer) > 0 && r.enum.Sys.TrustedResolvers().WildcardDetected(ctx, resp, domain) {
-			return true
+		if resp, err := r.enum.fwdQuery(ctx, "a."+name, t); err == nil && len(resp.Answer) > 0 {
+			if r.enum.known != nil {
+				if detected, found := r.enum.known.detected(resp, domain); found {
+					return detected
+				}
+			}
+			if r.enum.cnames != nil && r.enum.cnames.detected(ctx, resp, domain) {
+				return true
+			}
+			if r.enum.Sys.TrustedResolvers().WildcardDetected(ctx, resp, domain) {
+				return true
+			}
 		}
 	}
 	return false
@@ -0,0 +1,53 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"testing"
+
+	"github.com/caffix/queue"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestMaxSubdomainsPerParent(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{
+		Config:                 cfg,
+		MaxSubdomainsPerParent: 2,
+		requests:               queue.NewQueue(),
+	}
+
+	r := newSubdomainTask(e)
+	defer close(r.done)
+	// the first name below the parent has already been evaluated
+	r.timesForSubdomain("dev.owasp.org")
+
+	req := &requests.DNSRequest{Name: "a.dev.owasp.org", Domain: "owasp.org"}
+	if !r.checkForSubdomains(context.Background(), req, nil) {
+		t.Errorf("the name within the cap did not trigger requests")
+	}
+	if e.requests.Len() != 1 {
+		t.Errorf("expected a subdomain request for the parent, but got %d requests", e.requests.Len())
+	}
+
+	for _, name := range []string{"b.dev.owasp.org", "c.dev.owasp.org"} {
+		req := &requests.DNSRequest{Name: name, Domain: "owasp.org"}
+		if r.checkForSubdomains(context.Background(), req, nil) {
+			t.Errorf("%s beyond the cap triggered requests", name)
+		}
+	}
+	if e.requests.Len() != 1 {
+		t.Errorf("the names beyond the cap sent requests: %d", e.requests.Len())
+	}
+	// other parents have their own counts
+	req = &requests.DNSRequest{Name: "a.www.owasp.org", Domain: "owasp.org"}
+	r.timesForSubdomain("www.owasp.org")
+	if !r.checkForSubdomains(context.Background(), req, nil) {
+		t.Errorf("the name below another parent did not trigger requests")
+	}
+}
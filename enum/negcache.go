@@ -0,0 +1,74 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/owasp-amass/resolve"
+)
+
+// defaultNegativeCacheSize is the number of names kept when NegativeCacheSize is not set.
+const defaultNegativeCacheSize = 100000
+
+// negativeCache keeps the names that received NXDOMAIN until the TTL expires, so the names
+// submitted again by the permutations and the data sources are not queried again. The oldest
+// names are evicted once the cache is full.
+type negativeCache struct {
+	sync.Mutex
+	ttl     time.Duration
+	max     int
+	expires map[string]time.Time
+	order   []string
+}
+
+func newNegativeCache(ttl time.Duration, max int) *negativeCache {
+	if max <= 0 {
+		max = defaultNegativeCacheSize
+	}
+
+	return &negativeCache{
+		ttl:     ttl,
+		max:     max,
+		expires: make(map[string]time.Time),
+	}
+}
+
+func negativeCacheKey(name string) string {
+	return strings.ToLower(resolve.RemoveLastDot(strings.TrimSpace(name)))
+}
+
+// add records that the name does not exist.
+func (n *negativeCache) add(name string) {
+	name = negativeCacheKey(name)
+
+	n.Lock()
+	defer n.Unlock()
+
+	if _, found := n.expires[name]; !found {
+		for len(n.order) >= n.max {
+			delete(n.expires, n.order[0])
+			n.order = n.order[1:]
+		}
+		n.order = append(n.order, name)
+	}
+	n.expires[name] = time.Now().Add(n.ttl)
+}
+
+// has returns true when the name received NXDOMAIN within the TTL.
+func (n *negativeCache) has(name string) bool {
+	name = negativeCacheKey(name)
+
+	n.Lock()
+	defer n.Unlock()
+
+	expires, found := n.expires[name]
+	if !found || time.Now().After(expires) {
+		return false
+	}
+	return true
+}
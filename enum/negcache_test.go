@@ -0,0 +1,103 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestNegativeCache(t *testing.T) {
+	n := newNegativeCache(50*time.Millisecond, 2)
+
+	n.add("a.owasp.org.")
+	if !n.has("A.owasp.org") {
+		t.Errorf("the name was not found in the negative cache")
+	}
+	if n.has("b.owasp.org") {
+		t.Errorf("a name that was not added was found in the negative cache")
+	}
+
+	n.add("b.owasp.org")
+	n.add("c.owasp.org")
+	if n.has("a.owasp.org") {
+		t.Errorf("the oldest name was not evicted from the full cache")
+	}
+	if len(n.expires) != 2 || len(n.order) != 2 {
+		t.Errorf("the cache grew beyond its size: %d names", len(n.expires))
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if n.has("c.owasp.org") {
+		t.Errorf("the name was found after the TTL expired")
+	}
+}
+
+func TestNegativeCacheSkipsQueries(t *testing.T) {
+	var queries int32
+	addr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		atomic.AddInt32(&queries, 1)
+
+		m := new(dns.Msg)
+		m.SetRcode(req, dns.RcodeNameError)
+		_ = w.WriteMsg(m)
+	})
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.Resolvers = []string{addr}
+	pool := resolve.NewResolvers()
+	_ = pool.AddResolvers(10, addr)
+	defer pool.Stop()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: pool, Trusted: resolve.NewResolvers()},
+		ForceTCP: true,
+		fwdTypes: []uint16{dns.TypeA},
+		negcache: newNegativeCache(time.Minute, 0),
+	}
+	defer e.Sys.TrustedResolvers().Stop()
+	e.nameSrc = newTestEnumSource(e, 10)
+
+	dt := newDNSTask(e, false)
+	defer dt.stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	inflight := func() bool {
+		e.nameSrc.flightLock.Lock()
+		defer e.nameSrc.flightLock.Unlock()
+
+		_, found := e.nameSrc.inflight["nxdomain.owasp.org"]
+		return found
+	}
+	for i := 0; i < 3; i++ {
+		e.nameSrc.flightLock.Lock()
+		e.nameSrc.inflight["nxdomain.owasp.org"] = struct{}{}
+		e.nameSrc.flightLock.Unlock()
+
+		_, _ = dt.Process(ctx, &requests.DNSRequest{Name: "nxdomain.owasp.org", Domain: "owasp.org"}, nil)
+		for inflight() && ctx.Err() == nil {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if inflight() {
+			t.Fatalf("the name did not leave the flight after attempt %d", i+1)
+		}
+	}
+
+	if n := atomic.LoadInt32(&queries); n != 1 {
+		t.Errorf("expected the NXDOMAIN name to be queried once, but it was queried %d times", n)
+	}
+}
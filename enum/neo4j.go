@@ -0,0 +1,236 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/miekg/dns"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+const (
+	neo4jBatchSize      = 500
+	neo4jConnectTimeout = 30 * time.Second
+	neo4jWriteTimeout   = 2 * time.Minute
+)
+
+// neo4jKeys provides the property identifying the nodes of each asset type in the netmap model.
+var neo4jKeys = map[string]string{
+	"FQDN":             "name",
+	"IPAddress":        "address",
+	"Netblock":         "cidr",
+	"AutonomousSystem": "number",
+	"RIROrganization":  "name",
+}
+
+// neo4jRelation is an edge type in the netmap model, such as FQDN -a_record-> IPAddress.
+type neo4jRelation struct {
+	From string
+	Type string
+	To   string
+}
+
+type neo4jQuery struct {
+	Cypher string
+	Params map[string]interface{}
+}
+
+// neo4jOutput mirrors the resolved records and infrastructure into a Neo4j database in batches.
+// Each node carries the event UUID, so the results of multiple enumerations coexist.
+type neo4jOutput struct {
+	sync.Mutex
+	uuid  string
+	exec  func(ctx context.Context, queries []neo4jQuery) error
+	done  func(ctx context.Context) error
+	rows  map[neo4jRelation][]interface{}
+	count int
+}
+
+// SetNeo4jOutput connects to the Neo4j database at uri over Bolt and mirrors the resolved records,
+// addresses and netblocks into it, using the same nodes and edges as the graph database.
+func (e *Enumeration) SetNeo4jOutput(uri, user, pass string) error {
+	driver, err := neo4j.NewDriverWithContext(uri, neo4j.BasicAuth(user, pass, ""))
+	if err != nil {
+		return fmt.Errorf("failed to create the Neo4j driver for %s: %v", uri, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), neo4jConnectTimeout)
+	defer cancel()
+	if err := driver.VerifyConnectivity(ctx); err != nil {
+		_ = driver.Close(ctx)
+		return fmt.Errorf("failed to connect to the Neo4j database at %s: %v", uri, err)
+	}
+
+	e.neo4j = newNeo4jOutput(uuid.NewString(), func(ctx context.Context, queries []neo4jQuery) error {
+		session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+		defer session.Close(ctx)
+
+		_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+			for _, q := range queries {
+				if _, err := tx.Run(ctx, q.Cypher, q.Params); err != nil {
+					return nil, err
+				}
+			}
+			return nil, nil
+		})
+		return err
+	}, driver.Close)
+	e.addOutput("Neo4j", e.neo4j)
+	e.log().Infof("The Neo4j nodes of this enumeration carry the event UUID %s", e.neo4j.uuid)
+	return nil
+}
+
+func newNeo4jOutput(id string, exec func(context.Context, []neo4jQuery) error, done func(context.Context) error) *neo4jOutput {
+	return &neo4jOutput{
+		uuid: id,
+		exec: exec,
+		done: done,
+		rows: make(map[neo4jRelation][]interface{}),
+	}
+}
+
+// write mirrors the records of the request, and the source history is set on the node of the name.
+func (no *neo4jOutput) write(req *requests.DNSRequest, sources []string) error {
+	no.Lock()
+	defer no.Unlock()
+
+	// Only the CNAME record is entered for the name, as in the graph database, and the
+	// records of the authority and additional sections are not entered
+	var records []requests.DNSAnswer
+	for _, rec := range req.Records {
+		if sectionRecord(rec) {
+			continue
+		}
+		if uint16(rec.Type) == dns.TypeCNAME {
+			records = []requests.DNSAnswer{rec}
+			break
+		}
+		records = append(records, rec)
+	}
+
+	for _, rec := range records {
+		name := strings.ToLower(resolve.RemoveLastDot(rec.Name))
+		data := strings.ToLower(resolve.RemoveLastDot(strings.TrimSpace(rec.Data)))
+		if name == "" || data == "" {
+			continue
+		}
+
+		var props map[string]interface{}
+		if len(sources) > 0 && strings.EqualFold(name, req.Name) {
+			props = map[string]interface{}{"sources": sources}
+		}
+
+		switch rrtype := uint16(rec.Type); rrtype {
+		case dns.TypeA, dns.TypeAAAA:
+			no.add(neo4jRelation{"FQDN", strings.ToLower(dns.TypeToString[rrtype]) + "_record", "IPAddress"},
+				name, props, data, addressProps(data))
+		case dns.TypeCNAME, dns.TypeNS, dns.TypeMX, dns.TypePTR, dns.TypeSRV:
+			no.add(neo4jRelation{"FQDN", strings.ToLower(dns.TypeToString[rrtype]) + "_record", "FQDN"},
+				name, props, data, nil)
+		}
+	}
+	return no.checkFlush()
+}
+
+// insertInfrastructure mirrors the edges created by the netmap UpsertInfrastructure method.
+func (no *neo4jOutput) insertInfrastructure(asn int, desc, addr, cidr string) error {
+	no.Lock()
+	defer no.Unlock()
+
+	cidrProps := map[string]interface{}{"type": "IPv4"}
+	if ip, _, err := net.ParseCIDR(cidr); err == nil && ip.To4() == nil {
+		cidrProps["type"] = "IPv6"
+	}
+
+	no.add(neo4jRelation{"Netblock", "contains", "IPAddress"}, cidr, cidrProps, addr, addressProps(addr))
+	no.add(neo4jRelation{"AutonomousSystem", "announces", "Netblock"}, asn, nil, cidr, cidrProps)
+	no.add(neo4jRelation{"AutonomousSystem", "managed_by", "RIROrganization"}, asn, nil, desc, nil)
+	return no.checkFlush()
+}
+
+func addressProps(addr string) map[string]interface{} {
+	if ip := net.ParseIP(addr); ip != nil && ip.To4() == nil {
+		return map[string]interface{}{"type": "IPv6"}
+	}
+	return map[string]interface{}{"type": "IPv4"}
+}
+
+// add must be called while holding the lock.
+func (no *neo4jOutput) add(rel neo4jRelation, from interface{}, fprops map[string]interface{}, to interface{}, tprops map[string]interface{}) {
+	if fprops == nil {
+		fprops = map[string]interface{}{}
+	}
+	if tprops == nil {
+		tprops = map[string]interface{}{}
+	}
+
+	no.rows[rel] = append(no.rows[rel], map[string]interface{}{
+		"from":       from,
+		"from_props": fprops,
+		"to":         to,
+		"to_props":   tprops,
+	})
+	no.count++
+}
+
+// checkFlush must be called while holding the lock.
+func (no *neo4jOutput) checkFlush() error {
+	if no.count < neo4jBatchSize {
+		return nil
+	}
+	return no.flush()
+}
+
+// flush must be called while holding the lock.
+func (no *neo4jOutput) flush() error {
+	if no.count == 0 {
+		return nil
+	}
+
+	var queries []neo4jQuery
+	for rel, rows := range no.rows {
+		queries = append(queries, neo4jQuery{
+			Cypher: neo4jMergeQuery(rel),
+			Params: map[string]interface{}{"uuid": no.uuid, "rows": rows},
+		})
+	}
+	no.rows = make(map[neo4jRelation][]interface{})
+	no.count = 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), neo4jWriteTimeout)
+	defer cancel()
+	return no.exec(ctx, queries)
+}
+
+// neo4jMergeQuery returns the Cypher statement that merges a batch of rows for the relation.
+// Labels and relationship types cannot be parameters, so they come from the netmap model.
+func neo4jMergeQuery(rel neo4jRelation) string {
+	return fmt.Sprintf(`UNWIND $rows AS row
+MERGE (a:%s {%s: row.from, uuid: $uuid})
+SET a += row.from_props
+MERGE (b:%s {%s: row.to, uuid: $uuid})
+SET b += row.to_props
+MERGE (a)-[:%s]->(b)`, rel.From, neo4jKeys[rel.From], rel.To, neo4jKeys[rel.To], rel.Type)
+}
+
+func (no *neo4jOutput) stop() error {
+	no.Lock()
+	defer no.Unlock()
+
+	err := no.flush()
+	if e := no.done(context.Background()); err == nil {
+		err = e
+	}
+	return err
+}
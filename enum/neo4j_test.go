@@ -0,0 +1,102 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func TestNeo4jOutput(t *testing.T) {
+	var closed bool
+	var queries []neo4jQuery
+	no := newNeo4jOutput("test-uuid", func(ctx context.Context, q []neo4jQuery) error {
+		queries = append(queries, q...)
+		return nil
+	}, func(ctx context.Context) error {
+		closed = true
+		return nil
+	})
+
+	for _, req := range []*requests.DNSRequest{
+		{
+			Name: "www.owasp.org",
+			Records: []requests.DNSAnswer{
+				{Name: "www.owasp.org", Type: int(dns.TypeA), Data: "192.0.2.1"},
+				{Name: "www.owasp.org", Type: int(dns.TypeAAAA), Data: "2001:db8::1"},
+			},
+		},
+		{
+			Name: "docs.owasp.org",
+			Records: []requests.DNSAnswer{
+				{Name: "docs.owasp.org", Type: int(dns.TypeCNAME), Data: "www.owasp.org."},
+				{Name: "www.owasp.org", Type: int(dns.TypeA), Data: "192.0.2.1"},
+			},
+		},
+	} {
+		if err := no.write(req, []string{"crtsh", "DNS"}); err != nil {
+			t.Fatalf("failed to insert the records of %s: %v", req.Name, err)
+		}
+	}
+	if err := no.insertInfrastructure(64496, "EXAMPLE-AS", "192.0.2.1", "192.0.2.0/24"); err != nil {
+		t.Fatalf("failed to insert the infrastructure: %v", err)
+	}
+	if len(queries) != 0 {
+		t.Errorf("the rows were written before the batch was full")
+	}
+
+	if err := no.stop(); err != nil {
+		t.Fatalf("failed to stop the output: %v", err)
+	}
+	if !closed {
+		t.Errorf("the driver was not closed")
+	}
+
+	// the A record following the CNAME is not entered, as in the graph database
+	expected := map[string]string{
+		"a_record":     "MERGE (a:FQDN {name: row.from, uuid: $uuid})",
+		"aaaa_record":  "MERGE (b:IPAddress {address: row.to, uuid: $uuid})",
+		"cname_record": "MERGE (b:FQDN {name: row.to, uuid: $uuid})",
+		"contains":     "MERGE (a:Netblock {cidr: row.from, uuid: $uuid})",
+		"announces":    "MERGE (a:AutonomousSystem {number: row.from, uuid: $uuid})",
+		"managed_by":   "MERGE (b:RIROrganization {name: row.to, uuid: $uuid})",
+	}
+	if len(queries) != len(expected) {
+		t.Errorf("expected %d queries, but got %d", len(expected), len(queries))
+	}
+	for _, q := range queries {
+		if q.Params["uuid"] != "test-uuid" {
+			t.Errorf("the query did not carry the event UUID: %v", q.Params["uuid"])
+		}
+		if rows := q.Params["rows"].([]interface{}); len(rows) != 1 {
+			t.Errorf("expected one row, but got %d: %s", len(rows), q.Cypher)
+		}
+
+		var found bool
+		for rel, node := range expected {
+			if strings.Contains(q.Cypher, "[:"+rel+"]") {
+				found = true
+				if !strings.Contains(q.Cypher, node) {
+					t.Errorf("%s: the query does not follow the netmap model: %s", rel, q.Cypher)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("unexpected query: %s", q.Cypher)
+		}
+
+		// the source history is set on the node of the name
+		if strings.Contains(q.Cypher, "[:a_record]") {
+			row := q.Params["rows"].([]interface{})[0].(map[string]interface{})
+			if sources, ok := row["from_props"].(map[string]interface{})["sources"].([]string); !ok || len(sources) != 2 {
+				t.Errorf("the source history was not set on the FQDN node: %v", row["from_props"])
+			}
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+// maxNSConsistencySamples is the number of names of each root domain compared across the nameservers.
+const maxNSConsistencySamples = 25
+
+// NSInconsistency is a name, or the SOA of a zone, answered differently by the authoritative
+// nameservers of the zone, such as when the replication between the nameservers has failed.
+type NSInconsistency struct {
+	Name string
+	Zone string
+	// Type is A for the addresses of the name, and SOA for the serial of the zone
+	Type string
+	// Answers are the sorted answers of each nameserver, keyed by the nameserver and its address
+	Answers map[string][]string
+}
+
+// nsServer is an address of an authoritative nameserver.
+type nsServer struct {
+	host string
+	addr string
+}
+
+func (s nsServer) String() string {
+	return s.host + " (" + s.addr + ")"
+}
+
+type nsZone struct {
+	once    sync.Once
+	servers []nsServer
+}
+
+// nsConsistencyChecks compares the answers of the authoritative nameservers for a sample of the names
+// of each root domain, and keeps the findings. The nameservers are cached for each zone cut.
+type nsConsistencyChecks struct {
+	sync.Mutex
+	enum     *Enumeration
+	port     string
+	zones    map[string]*nsZone
+	samples  map[string]int
+	checked  map[string]struct{}
+	findings []NSInconsistency
+}
+
+func newNSConsistencyChecks(e *Enumeration) *nsConsistencyChecks {
+	return &nsConsistencyChecks{
+		enum:    e,
+		port:    "53",
+		zones:   make(map[string]*nsZone),
+		samples: make(map[string]int),
+		checked: make(map[string]struct{}),
+	}
+}
+
+// sample returns true when the name is selected for the comparison, until the sample of the root domain is full.
+func (nc *nsConsistencyChecks) sample(name, domain string) bool {
+	nc.Lock()
+	defer nc.Unlock()
+
+	if _, found := nc.checked[name]; found || nc.samples[domain] >= maxNSConsistencySamples {
+		return false
+	}
+	nc.checked[name] = struct{}{}
+	nc.samples[domain]++
+	return true
+}
+
+// check compares the addresses of the name across the nameservers of its zone, and the SOA serial
+// of the zone the first time the zone is seen.
+func (nc *nsConsistencyChecks) check(ctx context.Context, name, domain string) {
+	name = strings.ToLower(resolve.RemoveLastDot(name))
+	domain = strings.ToLower(domain)
+	if !nc.sample(name, domain) {
+		return
+	}
+
+	zone, servers, first := nc.zoneOf(ctx, name, domain)
+	if len(servers) < 2 {
+		return
+	}
+	if first {
+		nc.compare(ctx, zone, zone, dns.TypeSOA, servers)
+	}
+	nc.compare(ctx, name, zone, dns.TypeA, servers)
+}
+
+// compare queries each nameserver for the name, and records a finding when the authoritative answers differ.
+func (nc *nsConsistencyChecks) compare(ctx context.Context, name, zone string, qtype uint16, servers []nsServer) {
+	answers := make(map[string][]string)
+	distinct := make(map[string]struct{})
+	for _, s := range servers {
+		ans, ok := nc.query(ctx, s.addr, name, qtype)
+		if !ok {
+			continue
+		}
+
+		answers[s.String()] = ans
+		distinct[strings.Join(ans, ",")] = struct{}{}
+	}
+	if len(distinct) < 2 {
+		return
+	}
+
+	finding := NSInconsistency{
+		Name:    name,
+		Zone:    zone,
+		Type:    dns.TypeToString[qtype],
+		Answers: answers,
+	}
+	nc.Lock()
+	nc.findings = append(nc.findings, finding)
+	nc.Unlock()
+
+	var details []string
+	for server, ans := range answers {
+		details = append(details, server+" ["+strings.Join(ans, ", ")+"]")
+	}
+	sort.Strings(details)
+	nc.enum.log().Warnf("Nameserver inconsistency: the %s answers for %s differ across the nameservers of %s: %s",
+		finding.Type, name, zone, strings.Join(details, " "))
+}
+
+// query sends the non-recursive query to the nameserver, and returns the sorted answers of the
+// authoritative response. NXDOMAIN is returned as the only answer when the name does not exist.
+func (nc *nsConsistencyChecks) query(ctx context.Context, addr, name string, qtype uint16) ([]string, bool) {
+	network := "udp"
+	// UDP is not sent through the SOCKS5 proxy
+	if nc.enum.tcpOnly() {
+		network = "tcp"
+	}
+
+	msg := resolve.QueryMsg(name, qtype)
+	nc.enum.setEDNSBufferSize(msg)
+	msg.RecursionDesired = false
+
+	server := net.JoinHostPort(addr, nc.port)
+	resp, err := nc.enum.exchange(ctx, network, server, msg)
+	if err == nil && resp != nil && resp.Truncated && network == "udp" {
+		resp, err = nc.enum.exchange(ctx, "tcp", server, msg)
+	}
+	if err != nil || resp == nil || !resp.Authoritative {
+		return nil, false
+	}
+
+	switch resp.Rcode {
+	case dns.RcodeNameError:
+		return []string{"NXDOMAIN"}, true
+	case dns.RcodeSuccess:
+	default:
+		return nil, false
+	}
+
+	var answers []string
+	for _, rr := range resp.Answer {
+		switch v := rr.(type) {
+		case *dns.SOA:
+			answers = append(answers, strconv.FormatUint(uint64(v.Serial), 10))
+		case *dns.A:
+			answers = append(answers, v.A.String())
+		case *dns.CNAME:
+			answers = append(answers, "CNAME "+strings.ToLower(resolve.RemoveLastDot(v.Target)))
+		}
+	}
+	sort.Strings(answers)
+	return answers, true
+}
+
+// zoneOf returns the closest zone cut containing the name up to the root domain, with its nameservers.
+// The first return value is true for the check that looked up the nameservers of the zone.
+func (nc *nsConsistencyChecks) zoneOf(ctx context.Context, name, domain string) (string, []nsServer, bool) {
+	for zone := name; zone != ""; {
+		if servers, first := nc.zoneServers(ctx, zone); len(servers) > 0 {
+			return zone, servers, first
+		}
+		if zone == domain || !strings.HasSuffix(zone, "."+domain) {
+			break
+		}
+		zone = zone[strings.Index(zone, ".")+1:]
+	}
+	return "", nil, false
+}
+
+func (nc *nsConsistencyChecks) zoneServers(ctx context.Context, zone string) ([]nsServer, bool) {
+	nc.Lock()
+	z, found := nc.zones[zone]
+	if !found {
+		z = new(nsZone)
+		nc.zones[zone] = z
+	}
+	nc.Unlock()
+
+	var first bool
+	z.once.Do(func() {
+		z.servers = nc.lookupServers(ctx, zone)
+		first = true
+	})
+	return z.servers, first
+}
+
+func (nc *nsConsistencyChecks) lookupServers(ctx context.Context, zone string) []nsServer {
+	trusted := nc.enum.Sys.TrustedResolvers()
+
+	resp, err := nc.enum.queryBlocking(ctx, resolve.QueryMsg(zone, dns.TypeNS), trusted)
+	if err != nil || resp == nil || resp.Rcode != dns.RcodeSuccess {
+		return nil
+	}
+
+	var servers []nsServer
+	for _, ns := range resolve.AnswersByType(resolve.ExtractAnswers(resp), dns.TypeNS) {
+		if !strings.EqualFold(resolve.RemoveLastDot(ns.Name), zone) {
+			continue
+		}
+
+		host := strings.ToLower(resolve.RemoveLastDot(ns.Data))
+		for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+			resp, err := nc.enum.queryBlocking(ctx, resolve.QueryMsg(host, qtype), trusted)
+			if err != nil || resp == nil {
+				continue
+			}
+			for _, rr := range resolve.AnswersByType(resolve.ExtractAnswers(resp), qtype) {
+				servers = append(servers, nsServer{host: host, addr: rr.Data})
+			}
+		}
+	}
+	return servers
+}
+
+// NSInconsistencies returns the names and zones answered differently by the authoritative nameservers,
+// sorted by name. The names are only compared when DetectNSInconsistency has been enabled.
+func (e *Enumeration) NSInconsistencies() []NSInconsistency {
+	if e.nscheck == nil {
+		return nil
+	}
+
+	e.nscheck.Lock()
+	defer e.nscheck.Unlock()
+
+	results := append([]NSInconsistency(nil), e.nscheck.findings...)
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Name == results[j].Name {
+			return results[i].Type < results[j].Type
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
@@ -0,0 +1,173 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+// startMockNameserver serves the zone owasp.org with the serial and the address of www.owasp.org,
+// and answers the recursive queries for the names and the nameservers of the zone.
+func startMockNameserver(t *testing.T, addr string, serial uint32, www string) string {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("Failed to listen on %s: %v", addr, err)
+	}
+	serveMockDNS(t, l, nil, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		q := req.Question[0]
+		hdr := dns.RR_Header{Name: q.Name, Rrtype: q.Qtype, Class: dns.ClassINET, Ttl: 300}
+		switch name := strings.ToLower(q.Name); {
+		case req.RecursionDesired && name == "owasp.org." && q.Qtype == dns.TypeNS:
+			m.Answer = append(m.Answer, &dns.NS{Hdr: hdr, Ns: "ns1.owasp.org."}, &dns.NS{Hdr: hdr, Ns: "ns2.owasp.org."})
+		case req.RecursionDesired && name == "ns1.owasp.org." && q.Qtype == dns.TypeA:
+			m.Answer = append(m.Answer, &dns.A{Hdr: hdr, A: net.ParseIP("127.0.0.1")})
+		case req.RecursionDesired && name == "ns2.owasp.org." && q.Qtype == dns.TypeA:
+			m.Answer = append(m.Answer, &dns.A{Hdr: hdr, A: net.ParseIP("127.0.0.2")})
+		case req.RecursionDesired && name == "www.owasp.org." && q.Qtype == dns.TypeA:
+			m.Answer = append(m.Answer, &dns.A{Hdr: hdr, A: net.ParseIP(www)})
+		case req.RecursionDesired:
+		case name == "owasp.org." && q.Qtype == dns.TypeSOA:
+			m.Authoritative = true
+			m.Answer = append(m.Answer, &dns.SOA{Hdr: hdr, Ns: "ns1.owasp.org.", Mbox: "hostmaster.owasp.org.", Serial: serial})
+		case name == "www.owasp.org." && q.Qtype == dns.TypeA:
+			m.Authoritative = true
+			m.Answer = append(m.Answer, &dns.A{Hdr: hdr, A: net.ParseIP(www)})
+		case name == "mail.owasp.org." && q.Qtype == dns.TypeA:
+			m.Authoritative = true
+			m.Answer = append(m.Answer, &dns.A{Hdr: hdr, A: net.ParseIP("192.0.2.25")})
+		default:
+			m.Authoritative = true
+		}
+		_ = w.WriteMsg(m)
+	})
+	return l.Addr().String()
+}
+
+func TestDetectNSInconsistency(t *testing.T) {
+	// the nameservers share the port on different loopback addresses
+	first := startMockNameserver(t, "127.0.0.1:0", 2023010101, "192.0.2.1")
+	_, port, _ := net.SplitHostPort(first)
+	startMockNameserver(t, net.JoinHostPort("127.0.0.2", port), 2023010100, "192.0.2.2")
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.TrustedResolvers = []string{first}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP: true,
+	}
+	defer e.Sys.Resolvers().Stop()
+	e.nscheck = newNSConsistencyChecks(e)
+	e.nscheck.port = port
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	e.nscheck.check(ctx, "www.owasp.org", "owasp.org")
+	e.nscheck.check(ctx, "mail.owasp.org", "owasp.org")
+	// the name is only compared once
+	e.nscheck.check(ctx, "www.owasp.org", "owasp.org")
+
+	findings := e.NSInconsistencies()
+	if len(findings) != 2 {
+		t.Fatalf("expected the SOA and A findings, but got %v", findings)
+	}
+
+	soa := findings[0]
+	if soa.Name != "owasp.org" || soa.Type != "SOA" || soa.Zone != "owasp.org" ||
+		strings.Join(soa.Answers["ns1.owasp.org (127.0.0.1)"], ",") != "2023010101" ||
+		strings.Join(soa.Answers["ns2.owasp.org (127.0.0.2)"], ",") != "2023010100" {
+		t.Errorf("unexpected SOA finding: %+v", soa)
+	}
+
+	a := findings[1]
+	if a.Name != "www.owasp.org" || a.Type != "A" ||
+		strings.Join(a.Answers["ns1.owasp.org (127.0.0.1)"], ",") != "192.0.2.1" ||
+		strings.Join(a.Answers["ns2.owasp.org (127.0.0.2)"], ",") != "192.0.2.2" {
+		t.Errorf("unexpected A finding: %+v", a)
+	}
+}
+
+func TestDetectNSInconsistencyTracked(t *testing.T) {
+	first := startMockNameserver(t, "127.0.0.1:0", 2023010101, "192.0.2.1")
+	_, port, _ := net.SplitHostPort(first)
+	second := startMockNameserver(t, net.JoinHostPort("127.0.0.2", port), 2023010101, "192.0.2.2")
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.ProvidedNames = []string{"www.owasp.org"}
+	cfg.Resolvers = []string{first}
+	cfg.ResolversQPS, cfg.TrustedQPS = 1000, 1000
+	cfg.TrustedResolvers = []string{first}
+	pool := resolve.NewResolvers()
+	_ = pool.AddResolvers(10, first)
+	defer pool.Stop()
+	trusted := resolve.NewResolvers()
+	_ = trusted.AddResolvers(10, first)
+	defer trusted.Stop()
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	src := newTestSource("Inventory")
+	defer func() { _ = src.Stop() }()
+	sys := &systems.SimpleSystem{Cfg: cfg, Pool: pool, Trusted: trusted, Graph: g, Service: src}
+	e := NewEnumeration(cfg, sys, g)
+	e.ForceTCP = true
+	e.DetectNSInconsistency = true
+	e.MaxDuration = 2 * time.Second
+	// the nameservers are queried on port 53
+	e.Dialer = &redirectDialer{targets: map[string]string{
+		"127.0.0.1:53": first,
+		"127.0.0.2:53": second,
+	}}
+
+	if err := e.Start(context.Background()); err != nil && err != context.DeadlineExceeded {
+		t.Fatalf("the enumeration failed: %v", err)
+	}
+
+	findings := e.NSInconsistencies()
+	if len(findings) != 1 {
+		t.Fatalf("expected the A finding once the enumeration returned, but got %v", findings)
+	}
+	if a := findings[0]; a.Name != "www.owasp.org" || a.Type != "A" ||
+		strings.Join(a.Answers["ns1.owasp.org (127.0.0.1)"], ",") != "192.0.2.1" ||
+		strings.Join(a.Answers["ns2.owasp.org (127.0.0.2)"], ",") != "192.0.2.2" {
+		t.Errorf("unexpected A finding: %+v", a)
+	}
+}
+
+func TestNSConsistencySample(t *testing.T) {
+	nc := newNSConsistencyChecks(&Enumeration{Config: config.NewConfig()})
+
+	for i := 0; i < maxNSConsistencySamples; i++ {
+		if !nc.sample("host"+strings.Repeat("x", i)+".owasp.org", "owasp.org") {
+			t.Fatalf("name %d was not sampled", i)
+		}
+	}
+	if nc.sample("last.owasp.org", "owasp.org") {
+		t.Errorf("the name was sampled beyond the sample size of the root domain")
+	}
+	if !nc.sample("www.example.com", "example.com") {
+		t.Errorf("the sample of another root domain was affected")
+	}
+}
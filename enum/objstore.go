@@ -0,0 +1,533 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+const (
+	defaultObjectMaxRecords = 100000
+	defaultObjectMaxAge     = 15 * time.Minute
+	// minObjectPartSize is the smallest part accepted by the S3 multipart uploads, except for the last part
+	minObjectPartSize    = 5 << 20
+	objectRequestTimeout = 2 * time.Minute
+	s3TimeFormat         = "20060102T150405Z"
+)
+
+// ObjectStorageConfig provides the S3-compatible storage receiving the results as JSON-lines objects.
+type ObjectStorageConfig struct {
+	// Endpoint is the URL of the storage service, such as https://s3.us-east-1.amazonaws.com,
+	// and the objects are addressed using the path style
+	Endpoint string
+	// Region is used to sign the requests, and defaults to us-east-1
+	Region       string
+	Bucket       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	// Prefix is prepended to the keys of the objects, which are named by the event UUID and
+	// the sequence number of the object, such as <prefix><uuid>/000001.jsonl
+	Prefix string
+	// MaxRecords is the number of results written to an object before the next object is started
+	MaxRecords int
+	// MaxAge is the period after which the object being written is completed, even when partial
+	MaxAge time.Duration
+	// PartSize is the amount of data sent in each part of the multipart uploads, at least 5 MiB
+	PartSize int
+}
+
+// objectRecord is a line of the JSON-lines objects.
+type objectRecord struct {
+	Timestamp string               `json:"timestamp"`
+	Name      string               `json:"name"`
+	Domain    string               `json:"domain"`
+	Tag       string               `json:"tag"`
+	Source    string               `json:"source"`
+	Active    bool                 `json:"active"`
+	Sources   []string             `json:"sources,omitempty"`
+	Records   []requests.DNSAnswer `json:"records"`
+}
+
+// objectOutput writes the results to rolling objects. The data of each object is sent in parts of a
+// multipart upload as it accumulates, and the object is completed once it reaches MaxRecords or MaxAge.
+// The completed objects are independent, so a consumer can resume from the last sequence number.
+type objectOutput struct {
+	sync.Mutex
+	client   *s3Client
+	log      Logger
+	cfg      ObjectStorageConfig
+	uuid     string
+	seq      int
+	buf      bytes.Buffer
+	records  int
+	timer    *time.Timer
+	uploadID string
+	parts    []s3Part
+	stopped  bool
+}
+
+// SetObjectStorageOutput streams the resolved names with their records to the S3-compatible storage
+// as JSON-lines objects, rotating the objects on the MaxRecords and MaxAge thresholds. The last
+// object is completed when the enumeration finishes.
+func (e *Enumeration) SetObjectStorageOutput(cfg ObjectStorageConfig) error {
+	client, err := newS3Client(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), objectRequestTimeout)
+	defer cancel()
+	if err := client.headBucket(ctx); err != nil {
+		return fmt.Errorf("failed to access the %s bucket at %s: %v", cfg.Bucket, cfg.Endpoint, err)
+	}
+
+	e.objout = newObjectOutput(client, cfg, uuid.NewString(), e.log())
+	e.addOutput("object storage", e.objout)
+	e.log().Infof("The result objects are written to %s/%s%s/", cfg.Bucket, cfg.Prefix, e.objout.uuid)
+	return nil
+}
+
+func newObjectOutput(client *s3Client, cfg ObjectStorageConfig, id string, log Logger) *objectOutput {
+	if cfg.MaxRecords <= 0 {
+		cfg.MaxRecords = defaultObjectMaxRecords
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = defaultObjectMaxAge
+	}
+	if cfg.PartSize < minObjectPartSize {
+		cfg.PartSize = minObjectPartSize
+	}
+
+	return &objectOutput{
+		client: client,
+		log:    log,
+		cfg:    cfg,
+		uuid:   id,
+		seq:    1,
+	}
+}
+
+// key returns the name of the object currently being written.
+func (oo *objectOutput) key() string {
+	return fmt.Sprintf("%s%s/%06d.jsonl", oo.cfg.Prefix, oo.uuid, oo.seq)
+}
+
+func (oo *objectOutput) write(req *requests.DNSRequest, sources []string) error {
+	line, err := json.Marshal(&objectRecord{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Name:      req.Name,
+		Domain:    req.Domain,
+		Tag:       req.Tag,
+		Source:    req.Source,
+		Active:    req.Active,
+		Sources:   sources,
+		Records:   req.Records,
+	})
+	if err != nil {
+		return err
+	}
+
+	oo.Lock()
+	defer oo.Unlock()
+
+	if oo.stopped {
+		return errors.New("the object storage output has been stopped")
+	}
+	if oo.records == 0 {
+		seq := oo.seq
+		oo.timer = time.AfterFunc(oo.cfg.MaxAge, func() { oo.expire(seq) })
+	}
+	oo.buf.Write(line)
+	oo.buf.WriteByte('\n')
+	oo.records++
+
+	if oo.records >= oo.cfg.MaxRecords {
+		return oo.complete()
+	}
+	if oo.buf.Len() >= oo.cfg.PartSize {
+		return oo.uploadPart()
+	}
+	return nil
+}
+
+// expire completes the object once it reaches MaxAge, unless it was already completed. When the
+// object cannot be completed, the data is kept and the timer is armed again, so the partial object
+// is not left waiting for the next write or the final flush.
+func (oo *objectOutput) expire(seq int) {
+	oo.Lock()
+	defer oo.Unlock()
+
+	if oo.stopped || oo.seq != seq || oo.records == 0 {
+		return
+	}
+	if err := oo.complete(); err != nil {
+		oo.log.Errorf("Failed to complete the expired object %s: %v", oo.key(), err)
+		oo.timer = time.AfterFunc(oo.cfg.MaxAge, func() { oo.expire(seq) })
+	}
+}
+
+// uploadPart must be called while holding the lock. The buffer is kept when the part fails,
+// so the data is sent again with the next part.
+func (oo *objectOutput) uploadPart() error {
+	ctx, cancel := context.WithTimeout(context.Background(), objectRequestTimeout)
+	defer cancel()
+
+	if oo.uploadID == "" {
+		id, err := oo.client.createMultipartUpload(ctx, oo.key())
+		if err != nil {
+			return err
+		}
+		oo.uploadID = id
+	}
+
+	num := len(oo.parts) + 1
+	etag, err := oo.client.uploadPart(ctx, oo.key(), oo.uploadID, num, oo.buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	oo.parts = append(oo.parts, s3Part{Number: num, ETag: etag})
+	oo.buf.Reset()
+	return nil
+}
+
+// complete must be called while holding the lock. The small objects are sent in a single request,
+// and the multipart uploads are completed with the remaining data as the last part.
+func (oo *objectOutput) complete() error {
+	if oo.records == 0 && oo.uploadID == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), objectRequestTimeout)
+	defer cancel()
+
+	if oo.uploadID == "" {
+		if err := oo.client.putObject(ctx, oo.key(), oo.buf.Bytes()); err != nil {
+			return err
+		}
+	} else {
+		if oo.buf.Len() > 0 {
+			if err := oo.uploadPart(); err != nil {
+				return err
+			}
+		}
+		if err := oo.client.completeMultipartUpload(ctx, oo.key(), oo.uploadID, oo.parts); err != nil {
+			return err
+		}
+	}
+
+	if oo.timer != nil {
+		oo.timer.Stop()
+		oo.timer = nil
+	}
+	oo.buf.Reset()
+	oo.records = 0
+	oo.uploadID = ""
+	oo.parts = nil
+	oo.seq++
+	return nil
+}
+
+// stop completes the object being written, and the incomplete multipart upload is aborted
+// when the final flush fails, so the storage does not keep the orphaned parts.
+func (oo *objectOutput) stop() error {
+	oo.Lock()
+	defer oo.Unlock()
+
+	if oo.stopped {
+		return nil
+	}
+	oo.stopped = true
+
+	err := oo.complete()
+	if err != nil && oo.uploadID != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), objectRequestTimeout)
+		defer cancel()
+
+		_ = oo.client.abortMultipartUpload(ctx, oo.key(), oo.uploadID)
+	}
+	if oo.timer != nil {
+		oo.timer.Stop()
+	}
+	return err
+}
+
+type s3Part struct {
+	XMLName xml.Name `xml:"Part"`
+	Number  int      `xml:"PartNumber"`
+	ETag    string   `xml:"ETag"`
+}
+
+// s3Client sends the requests of the S3 API used by the output, signed with AWS Signature Version 4.
+type s3Client struct {
+	endpoint *url.URL
+	region   string
+	bucket   string
+	access   string
+	secret   string
+	token    string
+	client   *http.Client
+	now      func() time.Time
+}
+
+func newS3Client(cfg ObjectStorageConfig) (*s3Client, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("the object storage endpoint %s must be an HTTP or HTTPS URL", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, errors.New("the object storage bucket was not provided")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("the object storage access key and secret key were not provided")
+	}
+	if cfg.PartSize != 0 && cfg.PartSize < minObjectPartSize {
+		return nil, fmt.Errorf("the object storage part size must be at least %d bytes: %d", minObjectPartSize, cfg.PartSize)
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &s3Client{
+		endpoint: u,
+		region:   region,
+		bucket:   cfg.Bucket,
+		access:   cfg.AccessKey,
+		secret:   cfg.SecretKey,
+		token:    cfg.SessionToken,
+		client:   amasshttp.DefaultClient,
+		now:      time.Now,
+	}, nil
+}
+
+func (c *s3Client) headBucket(ctx context.Context) error {
+	_, err := c.do(ctx, http.MethodHead, "", nil, nil)
+	return err
+}
+
+func (c *s3Client) putObject(ctx context.Context, key string, body []byte) error {
+	_, err := c.do(ctx, http.MethodPut, key, nil, body)
+	return err
+}
+
+func (c *s3Client) createMultipartUpload(ctx context.Context, key string) (string, error) {
+	resp, err := c.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.Unmarshal(resp, &result); err != nil || result.UploadID == "" {
+		return "", fmt.Errorf("failed to obtain the upload ID for %s", key)
+	}
+	return result.UploadID, nil
+}
+
+func (c *s3Client) uploadPart(ctx context.Context, key, id string, num int, body []byte) (string, error) {
+	params := url.Values{"partNumber": {strconv.Itoa(num)}, "uploadId": {id}}
+
+	req, err := c.newRequest(ctx, http.MethodPut, key, params, body)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return "", fmt.Errorf("part %d of %s did not return an ETag", num, key)
+	}
+	return etag, nil
+}
+
+func (c *s3Client) completeMultipartUpload(ctx context.Context, key, id string, parts []s3Part) error {
+	body, err := xml.Marshal(&struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []s3Part
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, http.MethodPost, key, url.Values{"uploadId": {id}}, body)
+	if err != nil {
+		return err
+	}
+	// the service can report a failure after responding with the status 200
+	if bytes.Contains(resp, []byte("<Error>")) {
+		return fmt.Errorf("failed to complete the multipart upload of %s: %s", key, resp)
+	}
+	return nil
+}
+
+func (c *s3Client) abortMultipartUpload(ctx context.Context, key, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {id}}, nil)
+	return err
+}
+
+// do sends the signed request and returns the response body.
+func (c *s3Client) do(ctx context.Context, method, key string, params url.Values, body []byte) ([]byte, error) {
+	req, err := c.newRequest(ctx, method, key, params, body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+func (c *s3Client) send(req *http.Request) (*http.Response, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s returned the status %s: %s", req.Method, req.URL.Path, resp.Status, bytes.TrimSpace(msg))
+	}
+	return resp, nil
+}
+
+func (c *s3Client) newRequest(ctx context.Context, method, key string, params url.Values, body []byte) (*http.Request, error) {
+	u := *c.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + c.bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+	u.RawPath = s3Escape(u.Path, false)
+	u.RawQuery = s3Query(params)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	c.sign(req, body)
+	return req, nil
+}
+
+// sign adds the AWS Signature Version 4 authorization to the request, covering each header.
+func (c *s3Client) sign(req *http.Request, body []byte) {
+	now := c.now().UTC()
+	amzdate := now.Format(s3TimeFormat)
+	hash := sha256.Sum256(body)
+	payload := hex.EncodeToString(hash[:])
+
+	req.Header.Set("X-Amz-Date", amzdate)
+	req.Header.Set("X-Amz-Content-Sha256", payload)
+	if c.token != "" {
+		req.Header.Set("X-Amz-Security-Token", c.token)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, k := range names {
+		canonical.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	creq := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonical.String(),
+		signed,
+		payload,
+	}, "\n")
+	chash := sha256.Sum256([]byte(creq))
+
+	scope := now.Format("20060102") + "/" + c.region + "/s3/aws4_request"
+	tosign := "AWS4-HMAC-SHA256\n" + amzdate + "\n" + scope + "\n" + hex.EncodeToString(chash[:])
+
+	key := []byte("AWS4" + c.secret)
+	for _, part := range []string{now.Format("20060102"), c.region, "s3", "aws4_request"} {
+		key = s3HMAC(key, part)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.access, scope, signed, hex.EncodeToString(s3HMAC(key, tosign))))
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3Query returns the query string in the canonical form, sorted by the parameter names.
+func s3Query(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, k := range keys {
+		for _, v := range params[k] {
+			pairs = append(pairs, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// s3Escape encodes each byte other than the unreserved characters, and the slashes of the paths.
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '.' || c == '_' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,91 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+const (
+	// openResolverProbe is the unrelated name queried to check for recursion.
+	openResolverProbe = "www.example.com"
+	// openResolverDelay is the minimum period between the open resolver probes.
+	openResolverDelay = 250 * time.Millisecond
+)
+
+// openResolverTests probes the discovered nameservers for recursive answers to unrelated queries.
+type openResolverTests struct {
+	sync.Mutex
+	enum   *Enumeration
+	tested map[string]struct{}
+	limit  *time.Ticker
+}
+
+func newOpenResolverTests(e *Enumeration) *openResolverTests {
+	return &openResolverTests{
+		enum:   e,
+		tested: make(map[string]struct{}),
+		limit:  time.NewTicker(openResolverDelay),
+	}
+}
+
+func (o *openResolverTests) stop() {
+	o.limit.Stop()
+}
+
+// test flags each address of the nameserver that answers recursive queries.
+func (o *openResolverTests) test(ctx context.Context, ns string) {
+	o.Lock()
+	if _, found := o.tested[ns]; found {
+		o.Unlock()
+		return
+	}
+	o.tested[ns] = struct{}{}
+	o.Unlock()
+
+	var addrs []string
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		resp, err := o.enum.dnsQuery(ctx, ns, qtype, o.enum.Sys.TrustedResolvers(), maxRcodeServerFails)
+		if err != nil || resp == nil {
+			continue
+		}
+
+		for _, rr := range resolve.AnswersByType(resolve.ExtractAnswers(resp), qtype) {
+			addrs = append(addrs, rr.Data)
+		}
+	}
+
+	network := "udp"
+	// UDP is not sent through the SOCKS5 proxy
+	if o.enum.SOCKS5Proxy != "" {
+		network = "tcp"
+	}
+
+	for _, addr := range addrs {
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.limit.C:
+		}
+
+		msg := resolve.QueryMsg(openResolverProbe, dns.TypeA)
+		o.enum.setEDNSBufferSize(msg)
+		if resp, err := o.enum.exchange(ctx, network, net.JoinHostPort(addr, "53"), msg); err == nil && recursiveAnswer(resp) {
+			o.enum.log().Warnf("Open resolver: nameserver %s (%s) answered a recursive query for %s", ns, addr, openResolverProbe)
+		}
+	}
+}
+
+// recursiveAnswer returns true when the response provides a non-authoritative answer with recursion available.
+func recursiveAnswer(resp *dns.Msg) bool {
+	return resp != nil && resp.Rcode == dns.RcodeSuccess &&
+		resp.RecursionAvailable && !resp.Authoritative && len(resp.Answer) > 0
+}
@@ -0,0 +1,164 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/pipeline"
+	"github.com/miekg/dns"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestRecursiveAnswer(t *testing.T) {
+	a := &dns.A{
+		Hdr: dns.RR_Header{Name: "www.example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+		A:   net.ParseIP("93.184.216.34"),
+	}
+
+	tests := []struct {
+		msg      *dns.Msg
+		expected bool
+	}{
+		{nil, false},
+		{&dns.Msg{MsgHdr: dns.MsgHdr{RecursionAvailable: true}, Answer: []dns.RR{a}}, true},
+		{&dns.Msg{MsgHdr: dns.MsgHdr{RecursionAvailable: false}, Answer: []dns.RR{a}}, false},
+		{&dns.Msg{MsgHdr: dns.MsgHdr{RecursionAvailable: true, Authoritative: true}, Answer: []dns.RR{a}}, false},
+		{&dns.Msg{MsgHdr: dns.MsgHdr{RecursionAvailable: true}}, false},
+		{&dns.Msg{MsgHdr: dns.MsgHdr{RecursionAvailable: true, Rcode: dns.RcodeRefused}, Answer: []dns.RR{a}}, false},
+	}
+
+	for i, test := range tests {
+		if got := recursiveAnswer(test.msg); got != test.expected {
+			t.Errorf("Test %d: expected %t, but got %t", i, test.expected, got)
+		}
+	}
+}
+
+// redirectDialer sends the connections for the nameserver addresses to the mock servers, and fails
+// the connections to the nameserver addresses without a mock server.
+type redirectDialer struct {
+	targets map[string]string
+}
+
+func (d *redirectDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if _, port, _ := net.SplitHostPort(addr); port == "53" {
+		target, found := d.targets[addr]
+		if !found {
+			return nil, fmt.Errorf("no route to the nameserver at %s", addr)
+		}
+		addr = target
+	}
+
+	var nd net.Dialer
+	return nd.DialContext(ctx, network, addr)
+}
+
+// discardParams provides no other stages, so the data sent to the other stages is dropped.
+type discardParams struct{}
+
+func (discardParams) Pipeline() *pipeline.Pipeline     { return nil }
+func (discardParams) Registry() pipeline.StageRegistry { return nil }
+
+// nameserverHandler answers the NS query for owasp.org with the nameservers, and the address queries
+// for the nameservers with the addresses provided.
+func nameserverHandler(addrs map[string]string) dns.HandlerFunc {
+	return func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		q := req.Question[0]
+		hdr := dns.RR_Header{Name: q.Name, Rrtype: q.Qtype, Class: dns.ClassINET, Ttl: 300}
+		switch name := strings.ToLower(q.Name); {
+		case name == "owasp.org." && q.Qtype == dns.TypeNS:
+			for ns := range addrs {
+				m.Answer = append(m.Answer, &dns.NS{Hdr: hdr, Ns: ns})
+			}
+		case q.Qtype == dns.TypeA:
+			if addr, found := addrs[name]; found && addr != "" {
+				m.Answer = append(m.Answer, &dns.A{Hdr: hdr, A: net.ParseIP(addr)})
+			} else if !found {
+				m.Rcode = dns.RcodeNameError
+			}
+		}
+		_ = w.WriteMsg(m)
+	}
+}
+
+func TestOpenResolverProbes(t *testing.T) {
+	recursive := startMockUDPDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		m.RecursionAvailable = true
+		m.Answer = append(m.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: req.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+			A:   net.ParseIP("93.184.216.34"),
+		})
+		_ = w.WriteMsg(m)
+	})
+	refused := startMockUDPDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetRcode(req, dns.RcodeRefused)
+		_ = w.WriteMsg(m)
+	})
+	addr := startMockUDPDNS(t, nameserverHandler(map[string]string{
+		"ns1.owasp.org.": "192.0.2.53",
+		"ns2.owasp.org.": "192.0.2.54",
+	}))
+
+	cfg := config.NewConfig()
+	var buf bytes.Buffer
+	cfg.Log = log.New(&buf, "", 0)
+	trusted := resolve.NewResolvers()
+	_ = trusted.AddResolvers(100, addr)
+	defer trusted.Stop()
+	e := &Enumeration{
+		Config:  cfg,
+		Sys:     &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		tracked: newTrackedWork(maxTrackedWork),
+	}
+	defer e.Sys.Resolvers().Stop()
+	e.openres = newOpenResolverTests(e)
+	defer e.openres.stop()
+	dt := &dnsTask{enum: e}
+
+	bg, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx := amassnet.WithDialer(bg, &redirectDialer{targets: map[string]string{
+		"192.0.2.53:53": recursive,
+		"192.0.2.54:53": refused,
+	}})
+
+	ch := make(chan []requests.DNSAnswer, 1)
+	dt.queryNS(ctx, "owasp.org", "owasp.org", ch, discardParams{})
+	if rr := <-ch; len(rr) != 2 {
+		t.Fatalf("expected the two NS records, but got %v", rr)
+	}
+	// the probes are still running once the NS records have been returned
+	if !e.requestsPending() {
+		t.Errorf("the open resolver probes were not tracked by the enumeration")
+	}
+
+	e.tracked.wait()
+	if e.requestsPending() {
+		t.Errorf("the open resolver probes were still tracked after they completed")
+	}
+	if out := buf.String(); !strings.Contains(out, "nameserver ns1.owasp.org (192.0.2.53) answered a recursive query") {
+		t.Errorf("the open resolver was not reported: %q", out)
+	} else if strings.Contains(out, "ns2.owasp.org") {
+		t.Errorf("the nameserver refusing the recursive query was reported: %q", out)
+	}
+}
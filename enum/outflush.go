@@ -0,0 +1,89 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"time"
+)
+
+// flusher is implemented by the buffered OutputWriter, such as a bufio.Writer.
+type flusher interface {
+	Flush() error
+}
+
+// startOutputFlusher flushes the buffered outputs every OutputFlushInterval, so the results already
+// produced are written during the lulls of the enumeration. The returned function stops the timer,
+// waits for a flush in progress and performs the final flush, before the outputs are closed.
+func (e *Enumeration) startOutputFlusher() func() {
+	if e.OutputFlushInterval <= 0 {
+		return func() {}
+	}
+
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		t := time.NewTicker(e.OutputFlushInterval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-quit:
+				return
+			case <-t.C:
+				e.flushOutputs()
+			}
+		}
+	}()
+
+	return func() {
+		close(quit)
+		<-done
+		e.flushOutputs()
+	}
+}
+
+// flushOutputs writes the records buffered by the outputs.
+func (e *Enumeration) flushOutputs() {
+	if e.sqlite != nil {
+		e.sqlite.Lock()
+		err := e.sqlite.flush()
+		e.sqlite.Unlock()
+
+		if err != nil {
+			e.log().Errorf("Failed to flush the SQLite output: %v", err)
+		}
+	}
+	if e.neo4j != nil {
+		e.neo4j.Lock()
+		err := e.neo4j.flush()
+		e.neo4j.Unlock()
+
+		if err != nil {
+			e.log().Errorf("Failed to flush the Neo4j output: %v", err)
+		}
+	}
+	if e.jsonl != nil {
+		e.jsonl.Lock()
+		err := e.jsonl.flush()
+		e.jsonl.Unlock()
+
+		if err != nil {
+			e.log().Errorf("Failed to flush the JSONL output: %v", err)
+		}
+	}
+	if e.tmplout != nil {
+		if f, ok := e.tmplout.w.(flusher); ok {
+			e.tmplout.Lock()
+			err := f.Flush()
+			e.tmplout.Unlock()
+
+			if err != nil {
+				e.log().Errorf("Failed to flush the template output: %v", err)
+			}
+		}
+	}
+}
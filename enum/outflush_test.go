@@ -0,0 +1,119 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+// lockedBuffer is safe to read while the flusher writes to it.
+type lockedBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (lb *lockedBuffer) Write(p []byte) (int, error) {
+	lb.Lock()
+	defer lb.Unlock()
+	return lb.buf.Write(p)
+}
+
+func (lb *lockedBuffer) String() string {
+	lb.Lock()
+	defer lb.Unlock()
+	return lb.buf.String()
+}
+
+func TestOutputFlushInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "amass.sqlite")
+	e := &Enumeration{Config: config.NewConfig(), OutputFlushInterval: 50 * time.Millisecond}
+	if err := e.SetSQLiteOutput(path); err != nil {
+		t.Fatalf("failed to set the SQLite output: %v", err)
+	}
+
+	out := new(lockedBuffer)
+	tmplout, err := newTemplateOutput("{{.Name}}", bufio.NewWriter(out))
+	if err != nil {
+		t.Fatalf("failed to create the template output: %v", err)
+	}
+	e.tmplout = tmplout
+
+	req := &requests.DNSRequest{
+		Name:    "www.owasp.org",
+		Domain:  "owasp.org",
+		Records: []requests.DNSAnswer{{Name: "www.owasp.org", Type: int(dns.TypeA), Data: "192.0.2.1"}},
+		Source:  "DNS",
+	}
+	if err := e.sqlite.write(req, nil); err != nil {
+		t.Fatalf("failed to insert the record: %v", err)
+	}
+	if err := e.tmplout.write(req, nil); err != nil {
+		t.Fatalf("failed to render the template: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("failed to open the SQLite database: %v", err)
+	}
+	defer db.Close()
+	count := func() int {
+		var n int
+		_ = db.QueryRow("SELECT COUNT(*) FROM records").Scan(&n)
+		return n
+	}
+	if count() != 0 || out.String() != "" {
+		t.Fatalf("the outputs were written before the flush")
+	}
+
+	// the outputs are flushed without new results or full batches
+	stop := e.startOutputFlusher()
+	deadline := time.Now().Add(5 * time.Second)
+	for (count() != 1 || out.String() != "www.owasp.org\n") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if count() != 1 {
+		t.Errorf("the SQLite batch was not flushed on the timer")
+	}
+	if s := out.String(); s != "www.owasp.org\n" {
+		t.Errorf("the template output was not flushed on the timer: %q", s)
+	}
+
+	// the final flush runs when the flusher is stopped
+	_ = e.tmplout.write(&requests.DNSRequest{Name: "mail.owasp.org", Records: req.Records}, nil)
+	stop()
+	if s := out.String(); !strings.HasSuffix(s, "mail.owasp.org\n") {
+		t.Errorf("the final flush did not run: %q", s)
+	}
+	if err := e.sqlite.stop(); err != nil {
+		t.Errorf("failed to close the SQLite output: %v", err)
+	}
+}
+
+func TestOutputFlushIntervalDisabled(t *testing.T) {
+	out := new(lockedBuffer)
+	w := bufio.NewWriter(out)
+	tmplout, err := newTemplateOutput("{{.Name}}", w)
+	if err != nil {
+		t.Fatalf("failed to create the template output: %v", err)
+	}
+	e := &Enumeration{Config: config.NewConfig(), tmplout: tmplout}
+
+	_ = e.tmplout.write(&requests.DNSRequest{Name: "www.owasp.org"}, nil)
+	e.startOutputFlusher()()
+	if out.String() != "" {
+		t.Errorf("the output was flushed without the OutputFlushInterval")
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import "sort"
+
+// OutOfScopeCNAME is an in scope name with a CNAME record pointing outside of the scope,
+// which can indicate a dangling record to an unclaimed third-party service.
+type OutOfScopeCNAME struct {
+	Name   string
+	Target string
+}
+
+// recordOutOfScopeCNAME marks the target as out of scope without resolving it. The taxonomy of
+// the graph does not provide a relation for the marker, so the targets are kept by the Enumeration,
+// while the CNAME record to the target is stored in the graph.
+func (e *Enumeration) recordOutOfScopeCNAME(name, target string) {
+	e.oosLock.Lock()
+	defer e.oosLock.Unlock()
+
+	if e.oosNames == nil {
+		e.oosNames = make(map[string]string)
+	}
+	if _, found := e.oosNames[name]; !found {
+		e.oosNames[name] = target
+		e.log().Infof("Out of scope CNAME: %s -> %s", name, target)
+	}
+}
+
+// OutOfScopeCNAMEs returns the in scope names with CNAME targets outside of the scope, sorted
+// by name. The names are only collected when RecordOutOfScopeCNAMEs has been enabled.
+func (e *Enumeration) OutOfScopeCNAMEs() []OutOfScopeCNAME {
+	e.oosLock.Lock()
+	defer e.oosLock.Unlock()
+
+	var results []OutOfScopeCNAME
+	for name, target := range e.oosNames {
+		results = append(results, OutOfScopeCNAME{Name: name, Target: target})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
@@ -0,0 +1,65 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestOutOfScopeCNAMEs(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	e := &Enumeration{Config: cfg, graph: g, RecordOutOfScopeCNAMEs: true}
+	dm := &dataManager{enum: e}
+	ctx := context.Background()
+
+	for _, c := range []struct {
+		name   string
+		target string
+	}{
+		{"www.owasp.org", "owasp.github.io"},
+		{"shop.owasp.org", "owasp.myshopify.com"},
+		{"shop.owasp.org", "owasp.myshopify.com"},
+	} {
+		req := &requests.DNSRequest{
+			Name:    c.name,
+			Domain:  "owasp.org",
+			Records: []requests.DNSAnswer{{Name: c.name, Type: int(dns.TypeCNAME), Data: c.target + "."}},
+		}
+		// the enumeration source is not set, so the target must not be submitted for resolution
+		if err := dm.insertCNAME(ctx, req, 0, nil); err != nil {
+			t.Fatalf("failed to insert the CNAME for %s: %v", c.name, err)
+		}
+	}
+
+	expected := []OutOfScopeCNAME{
+		{Name: "shop.owasp.org", Target: "owasp.myshopify.com"},
+		{Name: "www.owasp.org", Target: "owasp.github.io"},
+	}
+	got := e.OutOfScopeCNAMEs()
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d out of scope CNAMEs, but got %d", len(expected), len(got))
+	}
+	for i, c := range expected {
+		if got[i] != c {
+			t.Errorf("expected %v, but got %v", c, got[i])
+		}
+	}
+
+	if !g.IsCNAMENode(ctx, "www.owasp.org", time.Time{}) {
+		t.Errorf("the CNAME record was not stored in the graph")
+	}
+}
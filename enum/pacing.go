@@ -0,0 +1,61 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// resolverPace spaces the queries sent to a resolver without the pools.
+type resolverPace struct {
+	sync.Mutex
+	next time.Time
+}
+
+// reserve returns the delay before the next query can be sent, and moves the next slot forward.
+func (p *resolverPace) reserve(interval time.Duration) time.Duration {
+	p.Lock()
+	defer p.Unlock()
+
+	now := time.Now()
+	// the slots not used are not accumulated into a burst
+	if p.next.Before(now) {
+		p.next = now
+	}
+	delay := p.next.Sub(now)
+	p.next = p.next.Add(interval)
+	return delay
+}
+
+// paceDirect waits until the query can be sent to the resolver at addr without exceeding the QPS of
+// the resolver in the configuration, since the queries sent without the pools bypass their rate
+// limiting, and returns false when the context expires first.
+func (e *Enumeration) paceDirect(ctx context.Context, addr string, trusted bool) bool {
+	qps := e.Config.ResolversQPS
+	if trusted {
+		qps = e.Config.TrustedQPS
+	}
+	if qps <= 0 {
+		return true
+	}
+
+	p, _ := e.paces.LoadOrStore(addr, &resolverPace{})
+	delay := p.(*resolverPace).reserve(time.Second / time.Duration(qps))
+	if delay <= 0 {
+		return true
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// domainEvents assigns each root domain its own sub-event UUID, and keeps the names stored during
+// the enumeration under the sub-event of their root domain. The graph database does not record
+// the events, so the partitions are kept here and queried using EventFQDNs.
+type domainEvents struct {
+	sync.Mutex
+	ids   map[string]string
+	names map[string]map[string]struct{}
+}
+
+func newDomainEvents() *domainEvents {
+	return &domainEvents{
+		ids:   make(map[string]string),
+		names: make(map[string]map[string]struct{}),
+	}
+}
+
+// assign returns the sub-event UUID of the root domain, and creates it for a new root domain.
+func (d *domainEvents) assign(domain string) string {
+	d.Lock()
+	defer d.Unlock()
+
+	return d.assignWithLock(domain)
+}
+
+// assignWithLock must be called while holding the lock.
+func (d *domainEvents) assignWithLock(domain string) string {
+	if id, found := d.ids[domain]; found {
+		return id
+	}
+
+	id := uuid.NewString()
+	d.ids[domain] = id
+	d.names[id] = make(map[string]struct{})
+	return id
+}
+
+// add stores the name under the sub-event of the root domain.
+func (d *domainEvents) add(domain, name string) {
+	if domain == "" || name == "" {
+		return
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	d.names[d.assignWithLock(domain)][name] = struct{}{}
+}
+
+// DomainEvents returns the sub-event UUID assigned to each root domain, keyed by the root domain.
+// Nothing is returned unless PartitionByDomain was set when the enumeration started.
+func (e *Enumeration) DomainEvents() map[string]string {
+	events := make(map[string]string)
+	if e.events == nil {
+		return events
+	}
+
+	e.events.Lock()
+	defer e.events.Unlock()
+
+	for domain, id := range e.events.ids {
+		events[domain] = id
+	}
+	return events
+}
+
+// EventFQDNs returns the sorted names stored during the enumeration under the sub-event UUID.
+func (e *Enumeration) EventFQDNs(id string) []string {
+	if e.events == nil {
+		return nil
+	}
+
+	e.events.Lock()
+	defer e.events.Unlock()
+
+	var names []string
+	for name := range e.events.names[id] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
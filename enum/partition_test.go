@@ -0,0 +1,77 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/caffix/netmap"
+	"github.com/caffix/queue"
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestDomainEvents(t *testing.T) {
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	cfg := config.NewConfig()
+	cfg.AddDomains("owasp.org", "dev.owasp.org", "example.com")
+	e := &Enumeration{
+		Config:            cfg,
+		PartitionByDomain: true,
+		graph:             g,
+		requests:          queue.NewQueue(),
+		events:            newDomainEvents(),
+	}
+	e.nameSrc = newTestEnumSource(e, 10)
+	e.submitDomainNames()
+	dm := newDataManager(e)
+	defer func() { <-dm.Stop() }()
+
+	ctx := context.Background()
+	for _, name := range []string{"www.owasp.org", "api.dev.owasp.org", "mail.example.com", "owasp.org"} {
+		req := &requests.DNSRequest{
+			Name:    name,
+			Domain:  e.RootDomain(name),
+			Records: []requests.DNSAnswer{{Name: name, Type: int(dns.TypeA), Data: "192.0.2.1"}},
+		}
+		if _, err := dm.Process(ctx, req, discardParams{}); err != nil {
+			t.Fatalf("failed to store %s: %v", name, err)
+		}
+	}
+
+	events := e.DomainEvents()
+	if len(events) != 3 {
+		t.Fatalf("expected a sub-event for each of the three root domains, but got %v", events)
+	}
+	ids := make(map[string]struct{})
+	for _, id := range events {
+		ids[id] = struct{}{}
+	}
+	if len(ids) != 3 {
+		t.Errorf("the root domains did not receive their own sub-event UUIDs: %v", events)
+	}
+
+	expected := map[string][]string{
+		"owasp.org":     {"owasp.org", "www.owasp.org"},
+		"dev.owasp.org": {"api.dev.owasp.org"},
+		"example.com":   {"mail.example.com"},
+	}
+	for domain, names := range expected {
+		if got := e.EventFQDNs(events[domain]); !reflect.DeepEqual(got, names) {
+			t.Errorf("%s: expected the names %v, but got %v", domain, names, got)
+		}
+	}
+
+	// the sub-events are not assigned without the partitioning
+	if got := (&Enumeration{Config: cfg}).DomainEvents(); len(got) != 0 {
+		t.Errorf("expected no sub-events without the partitioning, but got %v", got)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owasp-amass/asset-db/types"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+	"github.com/owasp-amass/resolve"
+)
+
+// PathNode is a hop in the resolution path of a name, linked to the next hop.
+type PathNode struct {
+	// Type is fqdn, ipaddr, netblock or asn
+	Type  string
+	Value string
+	// Relation connects the previous hop to this one: cname_record, a_record, aaaa_record, contains or announces
+	Relation string
+	Next     *PathNode
+}
+
+// ResolutionPath returns the paths from the name through the CNAME hops to the addresses, and
+// the netblocks and autonomous systems announcing them, as found in the graph during this
+// enumeration. Each divergent path is returned as a separate chain starting with the name.
+func (e *Enumeration) ResolutionPath(name string) ([]PathNode, error) {
+	if e.graph == nil {
+		return nil, errors.New("the enumeration does not have a graph")
+	}
+
+	name = strings.ToLower(resolve.RemoveLastDot(strings.TrimSpace(name)))
+	since := e.Config.CollectionStartTime.UTC()
+	assets, err := e.graph.DB.FindByContent(domain.FQDN{Name: name}, since)
+	if err != nil || len(assets) == 0 {
+		return nil, fmt.Errorf("the name %s was not found in the graph", name)
+	}
+
+	var results []PathNode
+	for _, hops := range e.pathsFrom(assets[0], "", since, make(map[string]struct{})) {
+		for i := len(hops) - 2; i >= 0; i-- {
+			hops[i].Next = &hops[i+1]
+		}
+		results = append(results, hops[0])
+	}
+	return results, nil
+}
+
+// pathsFrom returns the hops of each path starting with the asset. The assets on the current
+// path are tracked by visited, so that CNAME loops are not followed.
+func (e *Enumeration) pathsFrom(a *types.Asset, relation string, since time.Time, visited map[string]struct{}) [][]PathNode {
+	node, ok := pathNode(a, relation)
+	if !ok {
+		return nil
+	}
+	if _, found := visited[a.ID]; found {
+		return nil
+	}
+	visited[a.ID] = struct{}{}
+	defer delete(visited, a.ID)
+
+	var rels []*types.Relation
+	var next []*types.Asset
+	switch a.Asset.(type) {
+	case domain.FQDN:
+		rels, _ = e.graph.DB.OutgoingRelations(a, since, "cname_record", "a_record", "aaaa_record")
+		for _, rel := range rels {
+			next = append(next, rel.ToAsset)
+		}
+	case network.IPAddress:
+		rels, _ = e.graph.DB.IncomingRelations(a, since, "contains")
+		for _, rel := range rels {
+			next = append(next, rel.FromAsset)
+		}
+	case network.Netblock:
+		rels, _ = e.graph.DB.IncomingRelations(a, since, "announces")
+		for _, rel := range rels {
+			next = append(next, rel.FromAsset)
+		}
+	}
+
+	var paths [][]PathNode
+	for i, n := range next {
+		for _, tail := range e.pathsFrom(e.pathAsset(n, since), rels[i].Type, since, visited) {
+			paths = append(paths, append([]PathNode{node}, tail...))
+		}
+	}
+	if len(paths) == 0 {
+		paths = append(paths, []PathNode{node})
+	}
+	return paths
+}
+
+// pathAsset returns the asset referenced by the relation, which only provides the identifier.
+func (e *Enumeration) pathAsset(a *types.Asset, since time.Time) *types.Asset {
+	if a == nil || a.Asset != nil {
+		return a
+	}
+
+	found, err := e.graph.DB.FindById(a.ID, since)
+	if err != nil {
+		return nil
+	}
+	return found
+}
+
+func pathNode(a *types.Asset, relation string) (PathNode, bool) {
+	if a == nil {
+		return PathNode{}, false
+	}
+
+	node := PathNode{Relation: relation}
+	switch v := a.Asset.(type) {
+	case domain.FQDN:
+		node.Type = "fqdn"
+		node.Value = v.Name
+	case network.IPAddress:
+		node.Type = "ipaddr"
+		node.Value = v.Address.Unmap().String()
+	case network.Netblock:
+		node.Type = "netblock"
+		node.Value = v.Cidr.String()
+	case network.AutonomousSystem:
+		node.Type = "asn"
+		node.Value = strconv.Itoa(v.Number)
+	default:
+		return PathNode{}, false
+	}
+	return node, true
+}
@@ -0,0 +1,77 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestResolutionPath(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.CollectionStartTime = time.Now().Add(-time.Minute)
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	e := &Enumeration{Config: cfg}
+	if _, err := e.ResolutionPath("www.owasp.org"); err == nil {
+		t.Errorf("the path was returned without a graph")
+	}
+
+	e.graph = g
+	ctx := context.Background()
+	if err := g.UpsertCNAME(ctx, "www.owasp.org", "owasp.example.com"); err != nil {
+		t.Fatalf("failed to insert the CNAME record: %v", err)
+	}
+	if err := g.UpsertA(ctx, "owasp.example.com", "192.0.2.1"); err != nil {
+		t.Fatalf("failed to insert the A record: %v", err)
+	}
+	if err := g.UpsertAAAA(ctx, "owasp.example.com", "2001:db8::1"); err != nil {
+		t.Fatalf("failed to insert the AAAA record: %v", err)
+	}
+	if err := g.UpsertInfrastructure(ctx, 64496, "TEST", "192.0.2.1", "192.0.2.0/24"); err != nil {
+		t.Fatalf("failed to insert the infrastructure: %v", err)
+	}
+
+	if _, err := e.ResolutionPath("missing.owasp.org"); err == nil {
+		t.Errorf("the path was returned for a name missing from the graph")
+	}
+
+	paths, err := e.ResolutionPath("WWW.owasp.org.")
+	if err != nil {
+		t.Fatalf("failed to obtain the resolution paths: %v", err)
+	}
+
+	var got []string
+	for _, p := range paths {
+		var hops []string
+		for n := &p; n != nil; n = n.Next {
+			hops = append(hops, n.Relation+":"+n.Type+":"+n.Value)
+		}
+		got = append(got, strings.Join(hops, " "))
+	}
+	sort.Strings(got)
+
+	expected := []string{
+		":fqdn:www.owasp.org cname_record:fqdn:owasp.example.com a_record:ipaddr:192.0.2.1 contains:netblock:192.0.2.0/24 announces:asn:64496",
+		":fqdn:www.owasp.org cname_record:fqdn:owasp.example.com aaaa_record:ipaddr:2001:db8::1",
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d paths, but got %d: %v", len(expected), len(got), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("expected the path %s, but got %s", expected[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+// maxPermutationsPerName caps the number of names generated from each resolved name.
+const maxPermutationsPerName = 50
+
+// PermutationWords are the common prefixes and suffixes added to the first label of the resolved names.
+var PermutationWords = []string{
+	"api",
+	"beta",
+	"dev",
+	"int",
+	"internal",
+	"new",
+	"old",
+	"prod",
+	"qa",
+	"stage",
+	"staging",
+	"test",
+	"uat",
+}
+
+var permutationNumberRE = regexp.MustCompile(`\d+`)
+
+// submitPermutations generates altered forms of the resolved name, and submits those within the scope.
+// Names that were themselves generated are not permuted again, which bounds the discovery chains.
+func (e *Enumeration) submitPermutations(req *requests.DNSRequest) {
+	if req.Tag == requests.ALT || req.Name == req.Domain {
+		return
+	}
+
+	for _, name := range permutations(req.Name, maxPermutationsPerName) {
+		if !e.Config.IsDomainInScope(name) {
+			continue
+		}
+
+		e.nameSrc.newNameWithoutWait(&requests.DNSRequest{
+			Name:   name,
+			Domain: req.Domain,
+			Tag:    requests.ALT,
+			Source: "Permutations",
+		})
+	}
+}
+
+// permutations returns up to max altered forms of the name, produced by changing the numbers
+// in the first label, swapping and removing hyphens, and adding the common prefixes and suffixes.
+func permutations(name string, max int) []string {
+	label, base, found := strings.Cut(strings.ToLower(name), ".")
+	if !found || label == "" || max <= 0 {
+		return nil
+	}
+
+	var results []string
+	seen := map[string]struct{}{label: {}}
+	add := func(l string) bool {
+		l = strings.Trim(l, "-")
+		if _, dup := seen[l]; !dup && l != "" && len(l) <= 63 {
+			seen[l] = struct{}{}
+			results = append(results, l+"."+base)
+		}
+		return len(results) < max
+	}
+
+	for _, l := range numberPermutations(label) {
+		if !add(l) {
+			return results
+		}
+	}
+	for _, l := range hyphenPermutations(label) {
+		if !add(l) {
+			return results
+		}
+	}
+	for _, w := range PermutationWords {
+		if !add(w+"-"+label) || !add(label+"-"+w) {
+			return results
+		}
+	}
+	return results
+}
+
+// numberPermutations replaces each number in the label with its neighbors, or appends
+// numbers to a label without any.
+func numberPermutations(label string) []string {
+	locs := permutationNumberRE.FindAllStringIndex(label, -1)
+	if len(locs) == 0 {
+		return []string{label + "1", label + "2", label + "-1", label + "-2"}
+	}
+
+	var results []string
+	for _, loc := range locs {
+		pre, digits, post := label[:loc[0]], label[loc[0]:loc[1]], label[loc[1]:]
+
+		num, err := strconv.Atoi(digits)
+		if err != nil {
+			continue
+		}
+		for _, delta := range []int{1, -1, 2, -2} {
+			if n := num + delta; n >= 0 {
+				// keep the zero padding of the original number
+				s := strconv.Itoa(n)
+				if len(s) < len(digits) {
+					s = strings.Repeat("0", len(digits)-len(s)) + s
+				}
+				results = append(results, pre+s+post)
+			}
+		}
+		results = append(results, pre+post)
+	}
+	return results
+}
+
+// hyphenPermutations swaps the words around the hyphens of the label, and removes the hyphens.
+func hyphenPermutations(label string) []string {
+	words := strings.Split(label, "-")
+	if len(words) < 2 {
+		return nil
+	}
+
+	reversed := make([]string, len(words))
+	for i, w := range words {
+		reversed[len(words)-1-i] = w
+	}
+	return []string{strings.Join(reversed, "-"), strings.Join(words, "")}
+}
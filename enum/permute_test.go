@@ -0,0 +1,45 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import "testing"
+
+func TestPermutations(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{"web01.owasp.org", []string{"web02.owasp.org", "web00.owasp.org", "web03.owasp.org", "web.owasp.org", "dev-web01.owasp.org"}},
+		{"www.owasp.org", []string{"www1.owasp.org", "www-2.owasp.org", "api-www.owasp.org", "www-test.owasp.org"}},
+		{"mail-east.owasp.org", []string{"east-mail.owasp.org", "maileast.owasp.org", "mail-east1.owasp.org"}},
+	}
+
+	for _, test := range tests {
+		got := permutations(test.name, 100)
+
+		set := make(map[string]struct{}, len(got))
+		for _, name := range got {
+			if name == test.name {
+				t.Errorf("%s: the permutations include the original name", test.name)
+			}
+			if _, dup := set[name]; dup {
+				t.Errorf("%s: %s was generated more than once", test.name, name)
+			}
+			set[name] = struct{}{}
+		}
+		for _, name := range test.expected {
+			if _, found := set[name]; !found {
+				t.Errorf("%s: %s was not generated", test.name, name)
+			}
+		}
+	}
+
+	if got := permutations("www.owasp.org", 5); len(got) != 5 {
+		t.Errorf("expected the permutations to be capped at 5, but got %d", len(got))
+	}
+	if got := permutations("owasp", 10); len(got) != 0 {
+		t.Errorf("expected no permutations for a single label, but got %v", got)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+
+	amassnet "github.com/owasp-amass/amass/v4/net"
+)
+
+// maxSourcePortAttempts is the number of ports tried before the dial fails, since the
+// randomly selected ports can be in use by other sockets.
+const maxSourcePortAttempts = 10
+
+// PortRange is an inclusive range of source ports for the DNS queries.
+type PortRange struct {
+	Min int
+	Max int
+}
+
+func (r PortRange) set() bool {
+	return r.Min != 0 || r.Max != 0
+}
+
+func (r PortRange) check() error {
+	if !r.set() {
+		return nil
+	}
+	if r.Min < 1 || r.Max > 65535 || r.Min > r.Max {
+		return fmt.Errorf("the source port range %d-%d is not valid", r.Min, r.Max)
+	}
+	return nil
+}
+
+// dial connects to the address from a randomly selected port within the range, or from
+// the port assigned by the OS when the range has not been set. The connection is bound to
+// the src address when provided. Each connection is dialed for a single query, so the ports
+// are not held by connections being reused.
+func (r PortRange) dial(ctx context.Context, network, addr string, src net.IP) (net.Conn, error) {
+	if !r.set() && src == nil {
+		return amassnet.DialContext(ctx, network, addr)
+	}
+
+	ip := src
+	if ip == nil && amassnet.LocalAddr != nil {
+		ip, _, _ = net.ParseCIDR(amassnet.LocalAddr.String())
+	}
+	if !r.set() {
+		return localDialer(network, ip, 0).DialContext(ctx, network, addr)
+	}
+
+	var err error
+	for i := 0; i < maxSourcePortAttempts; i++ {
+		port := r.Min + rand.Intn(r.Max-r.Min+1)
+
+		var conn net.Conn
+		if conn, err = localDialer(network, ip, port).DialContext(ctx, network, addr); err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, fmt.Errorf("failed to dial %s from the source port range %d-%d: %v", addr, r.Min, r.Max, err)
+}
+
+// localDialer returns a dialer binding the connections to the local IP address and port.
+func localDialer(network string, ip net.IP, port int) *net.Dialer {
+	if strings.HasPrefix(network, "tcp") {
+		return &net.Dialer{LocalAddr: &net.TCPAddr{IP: ip, Port: port}}
+	}
+	return &net.Dialer{LocalAddr: &net.UDPAddr{IP: ip, Port: port}}
+}
@@ -0,0 +1,116 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestPortRangeCheck(t *testing.T) {
+	for _, test := range []struct {
+		r     PortRange
+		valid bool
+	}{
+		{PortRange{}, true},
+		{PortRange{Min: 40000, Max: 40999}, true},
+		{PortRange{Min: 53, Max: 53}, true},
+		{PortRange{Min: 0, Max: 100}, false},
+		{PortRange{Min: 2000, Max: 1000}, false},
+		{PortRange{Min: 1000, Max: 70000}, false},
+	} {
+		if err := test.r.check(); (err == nil) != test.valid {
+			t.Errorf("%v: expected valid to be %t, but got the error %v", test.r, test.valid, err)
+		}
+	}
+}
+
+func TestSourcePortRange(t *testing.T) {
+	ports := make(chan int, 10)
+	srvAddr := startMockUDPDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		if addr, ok := w.RemoteAddr().(*net.UDPAddr); ok {
+			ports <- addr.Port
+		}
+
+		m := new(dns.Msg)
+		m.SetReply(req)
+		m.Answer = append(m.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: req.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+			A:   net.IP{192, 0, 2, 1},
+		})
+		_ = w.WriteMsg(m)
+	})
+
+	cfg := config.NewConfig()
+	cfg.TrustedResolvers = []string{srvAddr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	r := PortRange{Min: 41000, Max: 41099}
+	e := &Enumeration{
+		Config:          cfg,
+		Sys:             &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		SourcePortRange: r,
+	}
+	defer e.Sys.Resolvers().Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		// the trusted pool is empty, so the answer must come from the direct query
+		resp, err := e.queryBlocking(ctx, resolve.QueryMsg("www.owasp.org", dns.TypeA), trusted)
+		if err != nil || len(resp.Answer) == 0 {
+			t.Fatalf("the query failed: %v", err)
+		}
+		if port := <-ports; port < r.Min || port > r.Max {
+			t.Errorf("the query was sent from port %d outside of the range %d-%d", port, r.Min, r.Max)
+		}
+	}
+}
+
+func TestSourcePortRangePacing(t *testing.T) {
+	srvAddr := startMockUDPDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		_ = w.WriteMsg(m)
+	})
+
+	const qps = 20
+	cfg := config.NewConfig()
+	cfg.TrustedResolvers = []string{srvAddr}
+	cfg.TrustedQPS = qps
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:          cfg,
+		Sys:             &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		SourcePortRange: PortRange{Min: 41100, Max: 41199},
+	}
+	defer e.Sys.Resolvers().Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// the queries bypass the pools, so they are paced to the QPS of the resolver instead
+	const queries = 6
+	start := time.Now()
+	for i := 0; i < queries; i++ {
+		if _, err := e.queryBlocking(ctx, resolve.QueryMsg("www.owasp.org", dns.TypeA), trusted); err != nil {
+			t.Fatalf("the query failed: %v", err)
+		}
+	}
+	if min := (queries - 1) * time.Second / qps; time.Since(start) < min {
+		t.Errorf("expected the %d queries to take at least %s, but they took %s", queries, min, time.Since(start))
+	}
+}
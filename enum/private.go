@@ -0,0 +1,154 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+// The policies of PrivateAddressPolicy for the private and internal addresses found in the answers.
+const (
+	// PrivateAddressKeep stores the private addresses like any other address
+	PrivateAddressKeep = "keep"
+	// PrivateAddressFlag stores the private addresses, and reports them in PrivateAddresses
+	PrivateAddressFlag = "flag"
+	// PrivateAddressDrop removes the private addresses from the answers before they are stored
+	PrivateAddressDrop = "drop"
+)
+
+// PrivateAddress is a private or internal address found in an answer for the name.
+type PrivateAddress struct {
+	Name    string
+	Address string
+	// Type is the type of the record carrying the address, such as A, AAAA or TXT
+	Type string
+}
+
+// privateAddresses applies the PrivateAddressPolicy, and keeps the flagged addresses.
+type privateAddresses struct {
+	sync.Mutex
+	enum    *Enumeration
+	policy  string
+	ranges  []*net.IPNet
+	flagged map[PrivateAddress]struct{}
+}
+
+// checkPrivateAddressPolicy validates the PrivateAddressPolicy.
+func (e *Enumeration) checkPrivateAddressPolicy() error {
+	switch e.PrivateAddressPolicy {
+	case "", PrivateAddressKeep, PrivateAddressFlag, PrivateAddressDrop:
+		return nil
+	}
+	return fmt.Errorf("the private address policy %s is not supported", e.PrivateAddressPolicy)
+}
+
+func newPrivateAddresses(e *Enumeration) *privateAddresses {
+	p := &privateAddresses{
+		enum:    e,
+		policy:  e.PrivateAddressPolicy,
+		flagged: make(map[PrivateAddress]struct{}),
+	}
+	// the same private, loopback, link-local and shared ranges as the rebinding checks
+	for _, s := range DefaultRebindingPrivateRanges {
+		_, cidr, _ := net.ParseCIDR(s)
+		p.ranges = append(p.ranges, cidr)
+	}
+	return p
+}
+
+func (p *privateAddresses) isPrivate(addr string) bool {
+	ip := net.ParseIP(strings.TrimSpace(addr))
+	if ip == nil {
+		return false
+	}
+
+	for _, cidr := range p.ranges {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// allow returns false when the private address must be dropped, and flags it otherwise.
+func (p *privateAddresses) allow(name, addr, rtype string) bool {
+	if !p.isPrivate(addr) {
+		return true
+	}
+	if p.policy == PrivateAddressDrop {
+		return false
+	}
+
+	pa := PrivateAddress{
+		Name:    strings.ToLower(resolve.RemoveLastDot(name)),
+		Address: net.ParseIP(strings.TrimSpace(addr)).String(),
+		Type:    rtype,
+	}
+	p.Lock()
+	_, found := p.flagged[pa]
+	p.flagged[pa] = struct{}{}
+	p.Unlock()
+
+	if !found {
+		p.enum.log().Warnf("Private address: %s has the internal address %s in its %s record", pa.Name, pa.Address, pa.Type)
+	}
+	return true
+}
+
+// filter removes the A and AAAA records with private addresses from the request when they are
+// dropped, and flags them otherwise.
+func (p *privateAddresses) filter(req *requests.DNSRequest) {
+	// the records are not filtered in place, since the slice can be shared with the clones of the request
+	records := make([]requests.DNSAnswer, 0, len(req.Records))
+	for _, rec := range req.Records {
+		if t := uint16(rec.Type); t == dns.TypeA || t == dns.TypeAAAA {
+			if !p.allow(recordOwner(req, rec), rec.Data, dns.TypeToString[t]) {
+				continue
+			}
+		}
+		records = append(records, rec)
+	}
+	req.Records = records
+}
+
+// allowPrivateAddress returns false when the PrivateAddressPolicy drops the address found in an
+// answer of the type for the name.
+func (e *Enumeration) allowPrivateAddress(name, addr, rtype string) bool {
+	return e.privaddr == nil || e.privaddr.allow(name, addr, rtype)
+}
+
+// PrivateAddresses returns the private and internal addresses found in the answers, sorted by name
+// and address. The addresses are only reported when the PrivateAddressPolicy is PrivateAddressFlag.
+func (e *Enumeration) PrivateAddresses() []PrivateAddress {
+	if e.privaddr == nil || e.privaddr.policy != PrivateAddressFlag {
+		return nil
+	}
+
+	e.privaddr.Lock()
+	defer e.privaddr.Unlock()
+
+	results := make([]PrivateAddress, 0, len(e.privaddr.flagged))
+	for pa := range e.privaddr.flagged {
+		results = append(results, pa)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Name != results[j].Name {
+			return results[i].Name < results[j].Name
+		}
+		if results[i].Address != results[j].Address {
+			return results[i].Address < results[j].Address
+		}
+		return results[i].Type < results[j].Type
+	})
+	return results
+}
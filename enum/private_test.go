@@ -0,0 +1,134 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+func TestPrivateAddressPolicy(t *testing.T) {
+	tests := []struct {
+		policy   string
+		stored   []string
+		queued   []string
+		intranet bool
+		flagged  []PrivateAddress
+	}{
+		{
+			policy:   PrivateAddressKeep,
+			stored:   []string{"10.0.0.1", "192.0.2.1"},
+			queued:   []string{"10.0.0.1", "10.1.2.3", "192.0.2.1"},
+			intranet: true,
+		},
+		{
+			policy:   PrivateAddressFlag,
+			stored:   []string{"10.0.0.1", "192.0.2.1"},
+			queued:   []string{"10.0.0.1", "10.1.2.3", "192.0.2.1"},
+			intranet: true,
+			flagged: []PrivateAddress{
+				{Name: "intranet.owasp.org", Address: "10.1.2.3", Type: "A"},
+				{Name: "www.owasp.org", Address: "10.0.0.1", Type: "A"},
+				{Name: "www.owasp.org", Address: "172.16.0.5", Type: "TXT"},
+			},
+		},
+		{
+			policy: PrivateAddressDrop,
+			stored: []string{"192.0.2.1"},
+			queued: []string{"192.0.2.1"},
+		},
+	}
+
+	for _, test := range tests {
+		cfg := config.NewConfig()
+		cfg.AddDomain("owasp.org")
+		g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+
+		e := &Enumeration{Config: cfg, graph: g, PrivateAddressPolicy: test.policy}
+		if err := e.checkPrivateAddressPolicy(); err != nil {
+			t.Fatalf("the %s policy was not accepted: %v", test.policy, err)
+		}
+		if test.policy != PrivateAddressKeep {
+			e.privaddr = newPrivateAddresses(e)
+		}
+		e.nameSrc = newTestEnumSource(e, 10)
+
+		dm := &dataManager{enum: e}
+		ctx := context.Background()
+		for _, req := range []*requests.DNSRequest{
+			{Name: "www.owasp.org", Domain: "owasp.org", Records: []requests.DNSAnswer{
+				{Name: "www.owasp.org", Type: int(dns.TypeA), TTL: 300, Data: "192.0.2.1"},
+				{Name: "www.owasp.org", Type: int(dns.TypeA), TTL: 300, Data: "10.0.0.1"},
+			}},
+			{Name: "www.owasp.org", Domain: "owasp.org", Records: []requests.DNSAnswer{
+				{Name: "www.owasp.org", Type: int(dns.TypeTXT), TTL: 300, Data: "v=spf1 ip4:172.16.0.5 ip4:198.51.100.7 -all"},
+			}},
+			{Name: "intranet.owasp.org", Domain: "owasp.org", Records: []requests.DNSAnswer{
+				{Name: "intranet.owasp.org", Type: int(dns.TypeA), TTL: 300, Data: "10.1.2.3"},
+			}},
+		} {
+			if err := dm.dnsRequest(ctx, req, nil); err != nil {
+				t.Fatalf("the %s policy failed to store %s: %v", test.policy, req.Name, err)
+			}
+		}
+
+		var stored []string
+		if pairs, err := g.NamesToAddrs(ctx, time.Time{}, "www.owasp.org"); err == nil {
+			for _, p := range pairs {
+				stored = append(stored, p.Addr.Address.String())
+			}
+		}
+		sort.Strings(stored)
+		if !reflect.DeepEqual(stored, test.stored) {
+			t.Errorf("the %s policy stored the addresses %v, expected %v", test.policy, stored, test.stored)
+		}
+
+		var queued []string
+		for {
+			element, ok := e.nameSrc.queue.Next()
+			if !ok {
+				break
+			}
+			if req, ok := element.(*requests.AddrRequest); ok {
+				queued = append(queued, req.Address)
+			}
+		}
+		sort.Strings(queued)
+		if !reflect.DeepEqual(queued, test.queued) {
+			t.Errorf("the %s policy queued the addresses %v, expected %v", test.policy, queued, test.queued)
+		}
+
+		assets, err := g.DB.FindByContent(domain.FQDN{Name: "intranet.owasp.org"}, time.Time{})
+		if intranet := err == nil && len(assets) > 0; intranet != test.intranet {
+			t.Errorf("the %s policy stored the name with only private addresses: %t", test.policy, intranet)
+		}
+
+		if flagged := e.PrivateAddresses(); !reflect.DeepEqual(flagged, test.flagged) {
+			t.Errorf("the %s policy flagged %v, expected %v", test.policy, flagged, test.flagged)
+		}
+		g.Remove()
+	}
+}
+
+func TestPrivateAddressPolicyUnsupported(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{Config: cfg, PipelineBufferSize: 1, PrivateAddressPolicy: "hide"}
+
+	if err := e.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "private address policy") {
+		t.Errorf("the enumeration started with an unsupported private address policy: %v", err)
+	}
+}
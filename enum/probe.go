@@ -0,0 +1,168 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+const (
+	// defaultHTTPProbeTimeout is used when HTTPProbeTimeout is not set.
+	defaultHTTPProbeTimeout = 5 * time.Second
+	// maxConcurrentHTTPProbes is the number of hosts probed at the same time.
+	maxConcurrentHTTPProbes = 10
+)
+
+// HTTPProbe is the result of the HEAD request sent to a discovered host.
+type HTTPProbe struct {
+	URL        string
+	StatusCode int
+	Server     string
+	Location   string
+}
+
+// httpProber sends the HEAD requests to the hosts within the scope with bounded concurrency.
+// The taxonomy of the graph does not provide the attributes, so the results are kept in memory.
+type httpProber struct {
+	sync.Mutex
+	enum    *Enumeration
+	client  *http.Client
+	sem     chan struct{}
+	wg      sync.WaitGroup
+	probed  map[string]struct{}
+	results []HTTPProbe
+}
+
+func newHTTPProber(e *Enumeration) *httpProber {
+	timeout := e.HTTPProbeTimeout
+	if timeout <= 0 {
+		timeout = defaultHTTPProbeTimeout
+	}
+
+	return &httpProber{
+		enum: e,
+		client: &http.Client{
+			Timeout: timeout,
+			// the redirect target is recorded instead of being followed
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				DialContext:         amassnet.DialContext,
+				TLSHandshakeTimeout: timeout,
+				TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
+				DisableKeepAlives:   true,
+			},
+		},
+		sem:    make(chan struct{}, maxConcurrentHTTPProbes),
+		probed: make(map[string]struct{}),
+	}
+}
+
+// stop waits for the probes in progress to finish.
+func (p *httpProber) stop() {
+	p.wg.Wait()
+	p.client.CloseIdleConnections()
+}
+
+// probe sends the requests to the host when it is within the scope and has addresses.
+func (p *httpProber) probe(ctx context.Context, req *requests.DNSRequest) {
+	if !p.enum.Config.IsDomainInScope(req.Name) || !hasAddress(req) {
+		return
+	}
+
+	p.Lock()
+	if _, found := p.probed[req.Name]; found {
+		p.Unlock()
+		return
+	}
+	p.probed[req.Name] = struct{}{}
+	p.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		select {
+		case <-ctx.Done():
+			return
+		case p.sem <- struct{}{}:
+		}
+		defer func() { <-p.sem }()
+
+		for _, scheme := range []string{"http", "https"} {
+			if r, err := p.head(ctx, scheme+"://"+req.Name+"/"); err == nil {
+				p.add(r)
+			}
+		}
+	}()
+}
+
+func (p *httpProber) head(ctx context.Context, u string) (*HTTPProbe, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", amasshttp.UserAgent)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return &HTTPProbe{
+		URL:        u,
+		StatusCode: resp.StatusCode,
+		Server:     resp.Header.Get("Server"),
+		Location:   resp.Header.Get("Location"),
+	}, nil
+}
+
+func (p *httpProber) add(r *HTTPProbe) {
+	p.Lock()
+	p.results = append(p.results, *r)
+	p.Unlock()
+
+	p.enum.log().Infof("HTTP probe: %s returned %d", r.URL, r.StatusCode)
+}
+
+func hasAddress(req *requests.DNSRequest) bool {
+	for _, rec := range req.Records {
+		if t := uint16(rec.Type); (t == dns.TypeA || t == dns.TypeAAAA) && !isGlue(rec) && !sectionRecord(rec) {
+			return true
+		}
+	}
+	return false
+}
+
+// HTTPProbes returns the results of the HTTP probes sorted by URL. The hosts are only
+// probed when ProbeHTTP has been enabled.
+func (e *Enumeration) HTTPProbes() []HTTPProbe {
+	if e.prober == nil {
+		return nil
+	}
+
+	e.prober.Lock()
+	results := make([]HTTPProbe, len(e.prober.results))
+	copy(results, e.prober.results)
+	e.prober.Unlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].URL < results[j].URL
+	})
+	return results
+}
@@ -0,0 +1,75 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestHTTPProber(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.Method != http.MethodHead {
+			t.Errorf("the probe sent a %s request", r.Method)
+		}
+
+		w.Header().Set("Server", "nginx")
+		http.Redirect(w, r, "https://owasp.org/login", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{Config: cfg, ProbeHTTP: true}
+	e.prober = newHTTPProber(e)
+	// every host is served by the test server over plain HTTP
+	e.prober.client.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, srv.Listener.Addr().String())
+		},
+	}
+
+	addr := []requests.DNSAnswer{{Name: "www.owasp.org", Type: int(dns.TypeA), Data: "192.0.2.1"}}
+	ctx := context.Background()
+	for _, req := range []*requests.DNSRequest{
+		{Name: "www.owasp.org", Domain: "owasp.org", Records: addr},
+		// each host is only probed once
+		{Name: "www.owasp.org", Domain: "owasp.org", Records: addr},
+		{Name: "www.example.com", Domain: "example.com", Records: addr},
+		{Name: "txt.owasp.org", Domain: "owasp.org", Records: []requests.DNSAnswer{{Type: int(dns.TypeTXT), Data: "v=spf1 -all"}}},
+	} {
+		e.prober.probe(ctx, req)
+	}
+	e.prober.stop()
+
+	probes := e.HTTPProbes()
+	// the HTTPS request fails against the plain HTTP server
+	if len(probes) != 1 {
+		t.Fatalf("expected one probe result, but got %d", len(probes))
+	}
+	expected := HTTPProbe{
+		URL:        "http://www.owasp.org/",
+		StatusCode: http.StatusFound,
+		Server:     "nginx",
+		Location:   "https://owasp.org/login",
+	}
+	if probes[0] != expected {
+		t.Errorf("expected %+v, but got %+v", expected, probes[0])
+	}
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Errorf("expected one request to reach the handler, but got %d", n)
+	}
+}
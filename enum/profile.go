@@ -0,0 +1,226 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+// DomainProfile describes the DNS infrastructure of a root domain, as mapped before the enumeration.
+type DomainProfile struct {
+	Domain      string
+	Nameservers []*NameserverProfile
+	// Serial is the SOA serial returned by the resolvers, or zero when the SOA was not found
+	Serial uint32
+	// DNSSEC is set when the zone publishes DNSKEY records, and Denial is the record type
+	// proving the nonexistence of names, either NSEC or NSEC3
+	DNSSEC bool
+	Denial string
+	// AXFRLikely is set when a nameserver began the zone transfer, and NSECWalkLikely
+	// when the zone denies the existence of names with NSEC records
+	AXFRLikely     bool
+	NSECWalkLikely bool
+}
+
+// NameserverProfile is an authoritative nameserver of the root domain and its addresses.
+type NameserverProfile struct {
+	Host      string
+	Addresses []NameserverAddress
+	// Authoritative is set when an address answered authoritatively for the SOA of the zone,
+	// and Serial is the SOA serial in that answer
+	Authoritative bool
+	Serial        uint32
+	AXFR          bool
+}
+
+// NameserverAddress is an address of the nameserver, with the ASN found in the cache of the System.
+type NameserverAddress struct {
+	Address     string
+	ASN         int
+	Description string
+}
+
+// infraProfiler maps the nameservers, the SOA and the DNSSEC status of the root domains.
+type infraProfiler struct {
+	enum *Enumeration
+	port string
+}
+
+func newInfraProfiler(e *Enumeration) *infraProfiler {
+	return &infraProfiler{
+		enum: e,
+		port: "53",
+	}
+}
+
+// ProfileInfrastructure maps the DNS infrastructure of each root domain in the configuration, without
+// querying the subdomains. The profile provides the authoritative nameservers with their addresses and
+// ASNs, the SOA serial and the DNSSEC status, and indicates whether the zone transfer and the NSEC walk
+// are likely to succeed. The enumeration does not need to be started.
+func (e *Enumeration) ProfileInfrastructure(ctx context.Context) (map[string]*DomainProfile, error) {
+	domains := e.Config.Domains()
+	if len(domains) == 0 {
+		return nil, errors.New("the configuration does not have root domains to profile")
+	}
+
+	p := newInfraProfiler(e)
+	profiles := make(map[string]*DomainProfile, len(domains))
+	for _, domain := range domains {
+		profiles[domain] = p.profile(ctx, domain)
+		if err := ctx.Err(); err != nil {
+			return profiles, err
+		}
+	}
+	return profiles, nil
+}
+
+func (p *infraProfiler) profile(ctx context.Context, domain string) *DomainProfile {
+	dp := &DomainProfile{Domain: domain}
+	trusted := p.enum.Sys.TrustedResolvers()
+
+	if resp, err := p.enum.queryBlocking(ctx, resolve.QueryMsg(domain, dns.TypeSOA), trusted); err == nil {
+		if soa := zoneSOA(resp, domain); soa != nil {
+			dp.Serial = soa.Serial
+		}
+	}
+
+	if resp, err := p.enum.queryBlocking(ctx, resolve.QueryMsg(domain, dns.TypeDNSKEY), trusted); err == nil && resp != nil {
+		for _, rr := range resp.Answer {
+			if key, ok := rr.(*dns.DNSKEY); ok && strings.EqualFold(key.Hdr.Name, dns.Fqdn(domain)) {
+				dp.DNSSEC = true
+				break
+			}
+		}
+	}
+	if dp.DNSSEC {
+		// the nonexistent name reveals how the zone proves the nonexistence of names
+		label := "amass-profile-" + strconv.Itoa(int(dns.Id()))
+		resp, err := p.enum.queryBlocking(ctx, resolve.WalkMsg(label+"."+domain, dns.TypeA), trusted)
+		if err == nil {
+			if denial := denialType(resp); denial != 0 {
+				dp.Denial = dns.TypeToString[denial]
+			}
+		}
+		dp.NSECWalkLikely = dp.Denial == "NSEC"
+	}
+
+	resp, err := p.enum.queryBlocking(ctx, resolve.QueryMsg(domain, dns.TypeNS), trusted)
+	if err != nil || resp == nil {
+		return dp
+	}
+	for _, rr := range resolve.AnswersByType(resolve.ExtractAnswers(resp), dns.TypeNS) {
+		if !strings.EqualFold(resolve.RemoveLastDot(rr.Name), domain) {
+			continue
+		}
+
+		ns := p.nameserver(ctx, domain, strings.ToLower(resolve.RemoveLastDot(rr.Data)))
+		dp.Nameservers = append(dp.Nameservers, ns)
+		if ns.AXFR {
+			dp.AXFRLikely = true
+		}
+	}
+
+	sort.Slice(dp.Nameservers, func(i, j int) bool {
+		return dp.Nameservers[i].Host < dp.Nameservers[j].Host
+	})
+	return dp
+}
+
+// nameserver resolves the addresses of the nameserver, and queries each of them directly for the
+// SOA of the zone and the start of the zone transfer.
+func (p *infraProfiler) nameserver(ctx context.Context, zone, host string) *NameserverProfile {
+	ns := &NameserverProfile{Host: host}
+	trusted := p.enum.Sys.TrustedResolvers()
+
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		resp, err := p.enum.queryBlocking(ctx, resolve.QueryMsg(host, qtype), trusted)
+		if err != nil || resp == nil {
+			continue
+		}
+
+		for _, rr := range resolve.AnswersByType(resolve.ExtractAnswers(resp), qtype) {
+			addr := NameserverAddress{Address: rr.Data}
+			if cache := p.enum.Sys.Cache(); cache != nil {
+				if r := cache.AddrSearch(rr.Data); r != nil {
+					addr.ASN = r.ASN
+					addr.Description = r.Description
+				}
+			}
+			ns.Addresses = append(ns.Addresses, addr)
+		}
+	}
+
+	network := "udp"
+	// UDP is not sent through the SOCKS5 proxy
+	if p.enum.tcpOnly() {
+		network = "tcp"
+	}
+
+	for _, addr := range ns.Addresses {
+		server := net.JoinHostPort(addr.Address, p.port)
+
+		if !ns.Authoritative {
+			msg := resolve.QueryMsg(zone, dns.TypeSOA)
+			p.enum.setEDNSBufferSize(msg)
+			msg.RecursionDesired = false
+
+			resp, err := p.enum.exchange(ctx, network, server, msg)
+			if err == nil && authoritativeSOA(resp, zone) {
+				ns.Authoritative = true
+				ns.Serial = zoneSOA(resp, zone).Serial
+			}
+		}
+		if !ns.AXFR && p.axfrAllowed(ctx, zone, server) {
+			ns.AXFR = true
+		}
+	}
+	return ns
+}
+
+// axfrAllowed requests the zone transfer from the server and returns true when the first message
+// of the transfer starts with the SOA of the zone. The rest of the zone is not read.
+func (p *infraProfiler) axfrAllowed(ctx context.Context, zone, server string) bool {
+	tctx, cancel := context.WithTimeout(ctx, tcpQueryTimeout)
+	defer cancel()
+
+	// the connection is not shared with the pool, since the transfer is abandoned after the first message
+	conn, err := p.enum.dial(tctx, "tcp", server)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+
+	msg := new(dns.Msg)
+	msg.SetAxfr(dns.Fqdn(zone))
+	resp, err := exchangeConn(tctx, &dns.Conn{Conn: conn}, msg)
+	if err != nil || resp == nil || resp.Rcode != dns.RcodeSuccess || len(resp.Answer) == 0 {
+		return false
+	}
+
+	soa, ok := resp.Answer[0].(*dns.SOA)
+	return ok && strings.EqualFold(soa.Hdr.Name, dns.Fqdn(zone))
+}
+
+// zoneSOA returns the SOA record of the zone from the answers of the response, or nil when not found.
+func zoneSOA(resp *dns.Msg, zone string) *dns.SOA {
+	if resp == nil || resp.Rcode != dns.RcodeSuccess {
+		return nil
+	}
+
+	for _, rr := range resp.Answer {
+		if soa, ok := rr.(*dns.SOA); ok && strings.EqualFold(dns.Fqdn(zone), soa.Hdr.Name) {
+			return soa
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestProfileInfrastructure(t *testing.T) {
+	// the server is both the resolver and the authoritative nameserver of owasp.org
+	addr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		q := req.Question[0]
+		hdr := dns.RR_Header{Name: q.Name, Rrtype: q.Qtype, Class: dns.ClassINET, Ttl: 300}
+		soa := &dns.SOA{
+			Hdr:    dns.RR_Header{Name: "owasp.org.", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 300},
+			Ns:     "ns1.owasp.org.",
+			Mbox:   "hostmaster.owasp.org.",
+			Serial: 2023010101,
+		}
+		switch name := strings.ToLower(q.Name); {
+		case name == "owasp.org." && q.Qtype == dns.TypeNS:
+			m.Answer = append(m.Answer, &dns.NS{Hdr: hdr, Ns: "ns1.owasp.org."})
+		case name == "owasp.org." && q.Qtype == dns.TypeSOA:
+			m.Authoritative = !req.RecursionDesired
+			m.Answer = append(m.Answer, soa)
+		case name == "owasp.org." && q.Qtype == dns.TypeDNSKEY:
+			m.Answer = append(m.Answer, &dns.DNSKEY{Hdr: hdr, Flags: 257, Protocol: 3, Algorithm: dns.ECDSAP256SHA256, PublicKey: "AAAA"})
+		case name == "owasp.org." && q.Qtype == dns.TypeAXFR:
+			m.Answer = append(m.Answer, soa, &dns.A{
+				Hdr: dns.RR_Header{Name: "www.owasp.org.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+				A:   net.ParseIP("192.0.2.1"),
+			})
+		case name == "ns1.owasp.org." && q.Qtype == dns.TypeA:
+			m.Answer = append(m.Answer, &dns.A{Hdr: hdr, A: net.ParseIP("127.0.0.1")})
+		case strings.HasPrefix(name, "amass-profile-"):
+			m.Rcode = dns.RcodeNameError
+			m.Ns = append(m.Ns, &dns.NSEC{
+				Hdr:        dns.RR_Header{Name: "owasp.org.", Rrtype: dns.TypeNSEC, Class: dns.ClassINET, Ttl: 300},
+				NextDomain: "www.owasp.org.",
+				TypeBitMap: []uint16{dns.TypeNS, dns.TypeSOA},
+			})
+		default:
+			m.Rcode = dns.RcodeNameError
+		}
+		_ = w.WriteMsg(m)
+	})
+
+	_, port, _ := net.SplitHostPort(addr)
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.Resolvers = []string{addr}
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted, ASNCache: requests.NewASNCache()},
+		ForceTCP: true,
+	}
+	defer e.Sys.Resolvers().Stop()
+	p := newInfraProfiler(e)
+	p.port = port
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dp := p.profile(ctx, "owasp.org")
+	if dp.Serial != 2023010101 {
+		t.Errorf("expected the SOA serial 2023010101, but got %d", dp.Serial)
+	}
+	if !dp.DNSSEC || dp.Denial != "NSEC" || !dp.NSECWalkLikely {
+		t.Errorf("the DNSSEC status was not profiled: %+v", dp)
+	}
+	if !dp.AXFRLikely {
+		t.Errorf("the zone transfer was not detected")
+	}
+	if len(dp.Nameservers) != 1 {
+		t.Fatalf("expected one nameserver, but got %d", len(dp.Nameservers))
+	}
+
+	ns := dp.Nameservers[0]
+	if ns.Host != "ns1.owasp.org" || !ns.Authoritative || ns.Serial != 2023010101 || !ns.AXFR {
+		t.Errorf("unexpected nameserver profile: %+v", ns)
+	}
+	// the loopback address is described by the cache as a reserved block
+	if len(ns.Addresses) != 1 || ns.Addresses[0].Address != "127.0.0.1" || ns.Addresses[0].Description == "" {
+		t.Errorf("unexpected nameserver addresses: %+v", ns.Addresses)
+	}
+}
+
+func TestProfileInfrastructureWithoutDomains(t *testing.T) {
+	e := &Enumeration{Config: config.NewConfig()}
+
+	if _, err := e.ProfileInfrastructure(context.Background()); err == nil {
+		t.Errorf("the infrastructure was profiled without root domains")
+	}
+}
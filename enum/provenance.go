@@ -0,0 +1,46 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"strings"
+
+	"github.com/owasp-amass/resolve"
+)
+
+// resolvingSource is entered in the source history of the names once they have been resolved.
+const resolvingSource = "DNS"
+
+// SourceHistory returns the data sources that provided the name in the order they were first seen,
+// such as the passive source that discovered the name followed by the DNS resolution that confirmed it.
+func (e *Enumeration) SourceHistory(name string) []string {
+	name = strings.ToLower(resolve.RemoveLastDot(strings.TrimSpace(name)))
+
+	e.histLock.Lock()
+	defer e.histLock.Unlock()
+
+	return append([]string(nil), e.history[name]...)
+}
+
+// addSourceHistory appends the source to the history of the name, unless it was already entered.
+func (e *Enumeration) addSourceHistory(name, source string) {
+	name = strings.ToLower(resolve.RemoveLastDot(strings.TrimSpace(name)))
+	if name == "" || source == "" {
+		return
+	}
+
+	e.histLock.Lock()
+	defer e.histLock.Unlock()
+
+	if e.history == nil {
+		e.history = make(map[string][]string)
+	}
+	for _, s := range e.history[name] {
+		if s == source {
+			return
+		}
+	}
+	e.history[name] = append(e.history[name], source)
+}
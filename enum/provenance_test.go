@@ -0,0 +1,37 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSourceHistory(t *testing.T) {
+	e := &Enumeration{}
+
+	if h := e.SourceHistory("www.owasp.org"); len(h) != 0 {
+		t.Errorf("expected no source history, but got %v", h)
+	}
+
+	e.addSourceHistory("www.owasp.org", "crtsh")
+	e.addSourceHistory("WWW.OWASP.ORG.", "HackerTarget")
+	e.addSourceHistory("www.owasp.org", "crtsh")
+	e.addSourceHistory("www.owasp.org", resolvingSource)
+	e.addSourceHistory("www.owasp.org", "")
+	e.addSourceHistory("mail.owasp.org", "Brute Forcing")
+
+	expected := []string{"crtsh", "HackerTarget", resolvingSource}
+	if h := e.SourceHistory("www.owasp.org."); !reflect.DeepEqual(h, expected) {
+		t.Errorf("expected the source history %v, but got %v", expected, h)
+	}
+
+	// the returned history cannot modify the one kept by the enumeration
+	h := e.SourceHistory("mail.owasp.org")
+	h[0] = "modified"
+	if h := e.SourceHistory("mail.owasp.org"); h[0] != "Brute Forcing" {
+		t.Errorf("the source history was modified through the returned slice: %v", h)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/caffix/service"
+	"github.com/miekg/dns"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+	"golang.org/x/net/proxy"
+)
+
+// dialerSource keeps the dialers provided to the data source.
+type dialerSource struct {
+	service.BaseService
+	sync.Mutex
+	dialers []proxy.ContextDialer
+}
+
+func newDialerSource(name string) *dialerSource {
+	src := new(dialerSource)
+	src.BaseService = *service.NewBaseService(src, name)
+	return src
+}
+
+func (d *dialerSource) SetDialer(cd proxy.ContextDialer) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.dialers = append(d.dialers, cd)
+}
+
+func TestSOCKS5ProxyScoped(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{
+		Config:             cfg,
+		PipelineBufferSize: 1,
+		EDNSBufferSize:     dns.DefaultMsgSize,
+		SOCKS5Proxy:        "127.0.0.1:1080",
+		SourcePortRange:    PortRange{Min: 2000, Max: 1000},
+	}
+	if err := e.Start(context.Background()); err == nil {
+		t.Fatalf("the enumeration started with an invalid source port range")
+	}
+
+	addr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetRcode(req, dns.RcodeNameError)
+		_ = w.WriteMsg(m)
+	})
+
+	cfg.ProvidedNames = []string{"www.owasp.org"}
+	cfg.Resolvers = []string{addr}
+	cfg.ResolversQPS, cfg.TrustedQPS = 1000, 1000
+	cfg.TrustedResolvers = []string{addr}
+	pool := resolve.NewResolvers()
+	_ = pool.AddResolvers(10, addr)
+	defer pool.Stop()
+	trusted := resolve.NewResolvers()
+	_ = trusted.AddResolvers(10, addr)
+	defer trusted.Stop()
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	src := newDialerSource("Inventory")
+	defer func() { _ = src.Stop() }()
+	sys := &systems.SimpleSystem{Cfg: cfg, Pool: pool, Trusted: trusted, Graph: g, Service: src}
+	e = NewEnumeration(cfg, sys, g)
+	// nothing listens on the proxy address, so the queries fail instead of reaching the mock server
+	e.SOCKS5Proxy = "127.0.0.1:1"
+	e.MaxDuration = time.Second
+
+	if err := e.Start(context.Background()); err != nil && err != context.DeadlineExceeded {
+		t.Fatalf("the enumeration failed: %v", err)
+	}
+
+	src.Lock()
+	defer src.Unlock()
+	if len(src.dialers) != 2 || src.dialers[0] == nil || src.dialers[1] != nil {
+		t.Errorf("expected the data source to be given the proxy and then the default dialer, but got %v", src.dialers)
+	}
+	// the proxy only applies to the enumeration
+	conn, err := amassnet.DialContext(context.Background(), "udp", addr)
+	if err != nil {
+		t.Fatalf("the SOCKS5 proxy remained in use after the enumeration returned: %v", err)
+	}
+	conn.Close()
+}
@@ -0,0 +1,150 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+// maxConcurrentReverseBatches is the number of TCP connections carrying pipelined PTR queries at once.
+const maxConcurrentReverseBatches = 4
+
+// reverseBatchQuery sends the PTR queries of the addresses over a single TCP connection to a
+// trusted resolver without waiting for each response, as allowed by RFC 7766, and returns the PTR
+// requests by address. The addresses left without a response, such as when the resolver does not
+// process the pipelined queries, are queried individually, and the resolver is remembered so the
+// following batches are sent individually as well.
+func (e *Enumeration) reverseBatchQuery(ctx context.Context, addrs []string) map[string]*requests.DNSRequest {
+	results := make(map[string]*requests.DNSRequest)
+
+	server, err := e.directResolver(dns.TypePTR, true)
+	if err == nil {
+		if _, found := e.nopipe.Load(server); !found {
+			err = e.pipelinePTR(ctx, server, addrs, results)
+		}
+	}
+
+	for _, addr := range addrs {
+		if _, found := results[addr]; found {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return results
+		default:
+		}
+
+		if req, err := e.reverseDNSQuery(ctx, addr); err == nil {
+			results[addr] = req
+		}
+	}
+	// the addresses without PTR records were answered, and are not part of the results
+	for addr, req := range results {
+		if req == nil {
+			delete(results, addr)
+		}
+	}
+	return results
+}
+
+// pipelinePTR writes the PTR queries of the addresses to the connection, and then reads the
+// responses as they arrive. Each answered address is added to the results, which hold nil for
+// the addresses without PTR records.
+func (e *Enumeration) pipelinePTR(ctx context.Context, server string, addrs []string, results map[string]*requests.DNSRequest) error {
+	tctx, cancel := context.WithTimeout(ctx, tcpQueryTimeout)
+	defer cancel()
+
+	conn, err := e.dial(tctx, "tcp", server)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	co := &dns.Conn{Conn: conn}
+	if deadline, ok := tctx.Deadline(); ok {
+		_ = co.SetDeadline(deadline)
+	}
+
+	type pending struct {
+		addr string
+		msg  *dns.Msg
+	}
+	queries := make(map[uint16]pending, len(addrs))
+	for _, addr := range addrs {
+		msg := resolve.ReverseMsg(addr)
+		if msg == nil || !e.spendQuery() {
+			continue
+		}
+		e.setEDNSBufferSize(msg)
+		e.setRecursionDesired(msg)
+		for _, found := queries[msg.Id]; found; _, found = queries[msg.Id] {
+			msg.Id = dns.Id()
+		}
+
+		if err := co.WriteMsg(msg); err != nil {
+			return err
+		}
+		queries[msg.Id] = pending{addr: addr, msg: msg}
+	}
+
+	start := time.Now()
+	var answered int
+	for answered < len(queries) {
+		resp, err := co.ReadMsg()
+		if err != nil {
+			if answered > 0 {
+				// the resolver closed the connection or stopped answering after the first responses
+				e.nopipe.Store(server, struct{}{})
+			}
+			e.recordLatency(server, start, true)
+			return fmt.Errorf("the pipelined PTR queries to %s received %d of %d responses: %v", server, answered, len(queries), err)
+		}
+
+		q, found := queries[resp.Id]
+		if !found {
+			continue
+		}
+		delete(queries, resp.Id)
+		answered++
+		if e.rawlog != nil {
+			e.rawlog.record(q.msg, resp, server)
+		}
+
+		switch resp.Rcode {
+		case dns.RcodeSuccess, dns.RcodeNameError:
+			results[q.addr], _ = e.ptrRequest(q.msg, resp)
+		}
+	}
+	e.recordLatency(server, start, false)
+	return nil
+}
+
+// ptrRequest returns a DNSRequest containing the PTR records from the response to the query.
+func (e *Enumeration) ptrRequest(msg, resp *dns.Msg) (*requests.DNSRequest, error) {
+	ptr := resolve.RemoveLastDot(msg.Question[0].Name)
+	if resp == nil {
+		return nil, errors.New("the reverse DNS query failed")
+	}
+
+	rr := resolve.AnswersByType(resolve.ExtractAnswers(resp), dns.TypePTR)
+	if len(rr) == 0 {
+		return nil, fmt.Errorf("no PTR records were found for %s", ptr)
+	}
+
+	return &requests.DNSRequest{
+		Name:    ptr,
+		Domain:  ptr,
+		Records: e.collectAnswers(resp, rr),
+		Tag:     requests.DNS,
+		Source:  "Reverse DNS",
+	}, nil
+}
@@ -0,0 +1,55 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+// queryOrder returns the order of the record types queried for a name, which is shuffled for
+// each name when RandomizeQueryOrder is set.
+func (e *Enumeration) queryOrder() []uint16 {
+	types := e.fwdTypes
+	if len(types) == 0 {
+		types = FwdQueryTypes
+	}
+	if !e.RandomizeQueryOrder || len(types) < 2 {
+		return types
+	}
+
+	types = append([]uint16(nil), types...)
+	rand.Shuffle(len(types), func(i, j int) {
+		types[i], types[j] = types[j], types[i]
+	})
+	return types
+}
+
+// nextTypeIn returns the record type following the provided type in the query order.
+func nextTypeIn(types []uint16, qtype uint16) (uint16, bool) {
+	for i, t := range types {
+		if t == qtype && i+1 < len(types) {
+			return types[i+1], true
+		}
+	}
+	return 0, false
+}
+
+// aliasAnswers returns the CNAME answers of the queried name, which the resolvers include in the
+// responses for the other record types when the name is an alias. When the order is randomized,
+// these answers end the queries for the name, as when the CNAME record is queried first.
+func aliasAnswers(ans []*resolve.ExtractedAnswer, name string) []*resolve.ExtractedAnswer {
+	var aliases []*resolve.ExtractedAnswer
+
+	for _, a := range resolve.AnswersByType(ans, dns.TypeCNAME) {
+		if strings.EqualFold(resolve.RemoveLastDot(a.Name), resolve.RemoveLastDot(name)) {
+			aliases = append(aliases, a)
+		}
+	}
+	return aliases
+}
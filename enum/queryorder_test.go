@@ -0,0 +1,142 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestQueryOrder(t *testing.T) {
+	e := &Enumeration{fwdTypes: []uint16{dns.TypeCNAME, dns.TypeA, dns.TypeAAAA, dns.TypeTXT}}
+
+	for i := 0; i < 10; i++ {
+		if types := e.queryOrder(); !equalTypes(types, e.fwdTypes) {
+			t.Fatalf("the query order changed without RandomizeQueryOrder: %v", types)
+		}
+	}
+
+	e.RandomizeQueryOrder = true
+	orders := make(map[string]struct{})
+	for i := 0; i < 200; i++ {
+		types := e.queryOrder()
+
+		sorted := append([]uint16(nil), types...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		if !equalTypes(sorted, []uint16{dns.TypeA, dns.TypeCNAME, dns.TypeTXT, dns.TypeAAAA}) {
+			t.Fatalf("the query order is not a permutation of the types: %v", types)
+		}
+		orders[dns.TypeToString[types[0]]+dns.TypeToString[types[1]]+dns.TypeToString[types[2]]] = struct{}{}
+	}
+	if len(orders) < 2 {
+		t.Errorf("the query order was not randomized across the names")
+	}
+	if !equalTypes(e.fwdTypes, []uint16{dns.TypeCNAME, dns.TypeA, dns.TypeAAAA, dns.TypeTXT}) {
+		t.Errorf("the shuffle changed the configured types: %v", e.fwdTypes)
+	}
+}
+
+func TestRandomizeQueryOrder(t *testing.T) {
+	addr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		q := req.Question[0]
+		switch strings.ToLower(q.Name) {
+		case "alias.owasp.org.":
+			// the alias is revealed by the queries for any of the types
+			m.Answer = append(m.Answer, &dns.CNAME{
+				Hdr:    dns.RR_Header{Name: q.Name, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 300},
+				Target: "www.owasp.org.",
+			})
+			if q.Qtype == dns.TypeA {
+				m.Answer = append(m.Answer, &dns.A{
+					Hdr: dns.RR_Header{Name: "www.owasp.org.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+					A:   net.ParseIP("192.0.2.1"),
+				})
+			}
+		case "www.owasp.org.":
+			if q.Qtype == dns.TypeA {
+				m.Answer = append(m.Answer, &dns.A{
+					Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+					A:   net.ParseIP("192.0.2.1"),
+				})
+			} else if q.Qtype == dns.TypeAAAA {
+				m.Answer = append(m.Answer, &dns.AAAA{
+					Hdr:  dns.RR_Header{Name: q.Name, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: 300},
+					AAAA: net.ParseIP("2001:db8::1"),
+				})
+			}
+		default:
+			m.Rcode = dns.RcodeNameError
+		}
+		_ = w.WriteMsg(m)
+	})
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.Resolvers = []string{addr}
+	// the queries sent without the pools are paced to the QPS of the resolvers
+	cfg.ResolversQPS, cfg.TrustedQPS = 1000, 1000
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:              cfg,
+		Sys:                 &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP:            true,
+		RandomizeQueryOrder: true,
+	}
+	defer e.Sys.Resolvers().Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	// the records do not depend on the order of the queries
+	for i := 0; i < 10; i++ {
+		req, err := e.ResolveName(ctx, "alias.owasp.org", "owasp.org")
+		if err != nil {
+			t.Fatalf("failed to resolve the alias: %v", err)
+		}
+		if len(req.Records) != 1 || req.Records[0].Type != int(dns.TypeCNAME) || req.Records[0].Data != "www.owasp.org" {
+			t.Fatalf("expected only the CNAME record of the alias, but got %v", req.Records)
+		}
+
+		req, err = e.ResolveName(ctx, "www.owasp.org", "owasp.org")
+		if err != nil {
+			t.Fatalf("failed to resolve the name: %v", err)
+		}
+		var types []int
+		for _, rec := range req.Records {
+			types = append(types, rec.Type)
+		}
+		sort.Ints(types)
+		if len(types) != 2 || types[0] != int(dns.TypeA) || types[1] != int(dns.TypeAAAA) {
+			t.Fatalf("expected the A and AAAA records of the name, but got %v", req.Records)
+		}
+	}
+}
+
+func equalTypes(a, b []uint16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// minRampUpFraction is the fraction of the pool QPS allowed at the start of the ramp-up.
+const minRampUpFraction = 0.1
+
+// qpsRamp paces the queries sent to a resolver pool at the start of the enumeration, with the rate
+// increasing linearly from a tenth of the pool QPS to the full rate over the ramp-up window.
+type qpsRamp struct {
+	sync.Mutex
+	start  time.Time
+	window time.Duration
+	max    float64
+	next   time.Time
+}
+
+func newQPSRamp(qps int, window time.Duration) *qpsRamp {
+	return &qpsRamp{
+		start:  time.Now(),
+		window: window,
+		max:    float64(qps),
+	}
+}
+
+// rate returns the queries per second allowed once the elapsed time has passed since the start.
+func (r *qpsRamp) rate(elapsed time.Duration) float64 {
+	frac := minRampUpFraction + (1-minRampUpFraction)*float64(elapsed)/float64(r.window)
+	if rate := r.max * frac; rate > 1 {
+		return rate
+	}
+	return 1
+}
+
+// wait blocks until the query can be sent at the current rate, and returns false when the context
+// expires first. The queries are not delayed once the window has passed.
+func (r *qpsRamp) wait(ctx context.Context) bool {
+	r.Lock()
+	now := time.Now()
+	elapsed := now.Sub(r.start)
+	if elapsed >= r.window {
+		r.Unlock()
+		return true
+	}
+	// the slots not used are not accumulated into a burst
+	if r.next.Before(now) {
+		r.next = now
+	}
+	delay := r.next.Sub(now)
+	r.next = r.next.Add(time.Duration(float64(time.Second) / r.rate(elapsed)))
+	r.Unlock()
+
+	if delay <= 0 {
+		return true
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+	}
+	return true
+}
+
+// rampingUp returns true while the queries to the pool are paced by the QPSRampUpDuration.
+func (e *Enumeration) rampingUp(trusted bool) bool {
+	r := e.ramp
+	if trusted {
+		r = e.trRamp
+	}
+	return r != nil && time.Since(r.start) < r.window
+}
+
+// startQPSRamps begins the ramp-up of each resolver pool to its maximum QPS.
+func (e *Enumeration) startQPSRamps() {
+	if e.QPSRampUpDuration <= 0 {
+		return
+	}
+
+	e.ramp = newQPSRamp(e.Sys.Resolvers().QPS(), e.QPSRampUpDuration)
+	e.trRamp = newQPSRamp(e.Sys.TrustedResolvers().QPS(), e.QPSRampUpDuration)
+}
+
+// rampUp waits for the ramp-up of the pool, and returns false when the context expires first.
+func (e *Enumeration) rampUp(ctx context.Context, trusted bool) bool {
+	if !e.rampingUp(trusted) {
+		return true
+	}
+	if trusted {
+		return e.trRamp.wait(ctx)
+	}
+	return e.ramp.wait(ctx)
+}
@@ -0,0 +1,77 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestQPSRampRate(t *testing.T) {
+	r := newQPSRamp(100, 10*time.Second)
+
+	tests := []struct {
+		elapsed  time.Duration
+		expected float64
+	}{
+		{0, 10},
+		{5 * time.Second, 55},
+		{10 * time.Second, 100},
+	}
+	for _, test := range tests {
+		if got := r.rate(test.elapsed); math.Abs(got-test.expected) > 0.001 {
+			t.Errorf("after %s, expected %f QPS, but got %f", test.elapsed, test.expected, got)
+		}
+	}
+
+	// at least one query per second is allowed
+	if got := newQPSRamp(2, time.Minute).rate(0); got != 1 {
+		t.Errorf("expected the minimum of 1 QPS, but got %f", got)
+	}
+}
+
+func TestQPSRampWait(t *testing.T) {
+	// ten queries per second are allowed at the start
+	r := newQPSRamp(100, time.Hour)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if !r.wait(context.Background()) {
+			t.Fatalf("the ramp-up failed without the context expiring")
+		}
+	}
+	if d := time.Since(start); d < 250*time.Millisecond {
+		t.Errorf("four queries were allowed within %s at the start of the ramp-up", d)
+	}
+
+	// the ramp-up must not delay the shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	r.next = time.Now().Add(time.Hour)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	if r.wait(ctx) {
+		t.Errorf("the query was allowed after the context expired")
+	}
+
+	// the queries are not delayed once the window has passed
+	r = newQPSRamp(1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	start = time.Now()
+	for i := 0; i < 10; i++ {
+		r.wait(context.Background())
+	}
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Errorf("the queries were delayed for %s after the ramp-up", d)
+	}
+
+	e := &Enumeration{}
+	if e.rampingUp(true) || !e.rampUp(context.Background(), false) {
+		t.Errorf("the queries were paced without the ramp-up")
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// rawResponseDataFile is the append-only log of the DNS messages in wire format. Each record
+	// is the request followed by the response, and each message is prefixed by its length as a
+	// 32-bit big-endian integer
+	rawResponseDataFile = "responses.bin"
+	// rawResponseIndexFile contains a JSON line for each record in the data file
+	rawResponseIndexFile = "responses.jsonl"
+	// rawResponseQueueSize is the number of records buffered for the writer
+	rawResponseQueueSize = 10000
+)
+
+// rawResponseEntry is the line written to the index for each record in the data file.
+type rawResponseEntry struct {
+	Timestamp      time.Time `json:"timestamp"`
+	Resolver       string    `json:"resolver"`
+	Name           string    `json:"name"`
+	Type           string    `json:"type"`
+	Offset         int64     `json:"offset"`
+	RequestLength  int       `json:"request_length"`
+	ResponseLength int       `json:"response_length"`
+}
+
+type rawResponseRecord struct {
+	entry    rawResponseEntry
+	request  []byte
+	response []byte
+}
+
+// rawResponseLog persists the DNS requests and responses for offline analysis. The records are
+// written by a single goroutine, and are dropped when the writer falls behind the queries.
+type rawResponseLog struct {
+	sync.RWMutex
+	closed  bool
+	records chan *rawResponseRecord
+	done    chan struct{}
+	data    *os.File
+	index   *os.File
+	dropped int64
+	err     error
+}
+
+func newRawResponseLog(dir string) (*rawResponseLog, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create the raw response directory %s: %v", dir, err)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	data, err := os.OpenFile(filepath.Join(dir, rawResponseDataFile), flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the raw response log: %v", err)
+	}
+
+	index, err := os.OpenFile(filepath.Join(dir, rawResponseIndexFile), flags, 0644)
+	if err != nil {
+		_ = data.Close()
+		return nil, fmt.Errorf("failed to open the raw response index: %v", err)
+	}
+
+	rl := &rawResponseLog{
+		records: make(chan *rawResponseRecord, rawResponseQueueSize),
+		done:    make(chan struct{}),
+		data:    data,
+		index:   index,
+	}
+	go rl.write()
+	return rl, nil
+}
+
+// record queues the request and response exchanged with the resolver without blocking the caller.
+func (rl *rawResponseLog) record(req, resp *dns.Msg, resolver string) {
+	if req == nil || resp == nil || len(resp.Question) == 0 {
+		return
+	}
+
+	reqb, err := req.Pack()
+	if err != nil {
+		return
+	}
+	respb, err := resp.Pack()
+	if err != nil {
+		return
+	}
+
+	rl.queue(reqb, respb, resp, resolver)
+}
+
+// queue is used when the request was packed before being sent, since the pools can modify the message.
+func (rl *rawResponseLog) queue(reqb, respb []byte, resp *dns.Msg, resolver string) {
+	q := resp.Question[0]
+
+	rl.RLock()
+	defer rl.RUnlock()
+	if rl.closed {
+		return
+	}
+
+	select {
+	case rl.records <- &rawResponseRecord{
+		entry: rawResponseEntry{
+			Timestamp: time.Now(),
+			Resolver:  resolver,
+			Name:      q.Name,
+			Type:      dns.TypeToString[q.Qtype],
+		},
+		request:  reqb,
+		response: respb,
+	}:
+	default:
+		atomic.AddInt64(&rl.dropped, 1)
+	}
+}
+
+func (rl *rawResponseLog) write() {
+	defer close(rl.done)
+
+	data := bufio.NewWriter(rl.data)
+	index := bufio.NewWriter(rl.index)
+	enc := json.NewEncoder(index)
+
+	offset, err := rl.data.Seek(0, io.SeekEnd)
+	if err != nil {
+		rl.err = err
+	}
+
+	var prefix [4]byte
+	for r := range rl.records {
+		if rl.err != nil {
+			continue
+		}
+
+		r.entry.Offset = offset
+		r.entry.RequestLength = len(r.request)
+		r.entry.ResponseLength = len(r.response)
+		for _, b := range [][]byte{r.request, r.response} {
+			binary.BigEndian.PutUint32(prefix[:], uint32(len(b)))
+			if _, err := data.Write(prefix[:]); err != nil {
+				rl.err = err
+			}
+			if _, err := data.Write(b); err != nil {
+				rl.err = err
+			}
+			offset += int64(len(prefix) + len(b))
+		}
+		if err := enc.Encode(&r.entry); err != nil {
+			rl.err = err
+		}
+	}
+
+	if err := data.Flush(); err != nil && rl.err == nil {
+		rl.err = err
+	}
+	if err := index.Flush(); err != nil && rl.err == nil {
+		rl.err = err
+	}
+}
+
+// close waits for the queued records to be written and returns the first error encountered.
+func (rl *rawResponseLog) close() error {
+	rl.Lock()
+	rl.closed = true
+	close(rl.records)
+	rl.Unlock()
+	<-rl.done
+
+	err := rl.err
+	if e := rl.data.Close(); err == nil {
+		err = e
+	}
+	if e := rl.index.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		if n := atomic.LoadInt64(&rl.dropped); n > 0 {
+			err = fmt.Errorf("%d records were dropped since the writer fell behind", n)
+		}
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestRawResponseLog(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "raw")
+	rl, err := newRawResponseLog(dir)
+	if err != nil {
+		t.Fatalf("failed to create the raw response log: %v", err)
+	}
+
+	names := []string{"www.owasp.org.", "mail.owasp.org."}
+	for _, name := range names {
+		req := resolve.QueryMsg(name, dns.TypeA)
+		resp := new(dns.Msg)
+		resp.SetReply(req)
+		resp.Answer = append(resp.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+			A:   []byte{192, 0, 2, 1},
+		})
+		rl.record(req, resp, "192.0.2.53:53")
+	}
+	if err := rl.close(); err != nil {
+		t.Fatalf("failed to close the raw response log: %v", err)
+	}
+	// records are silently ignored after the log has been closed
+	rl.record(resolve.QueryMsg("late.owasp.org", dns.TypeA), new(dns.Msg), "")
+
+	data, err := os.ReadFile(filepath.Join(dir, rawResponseDataFile))
+	if err != nil {
+		t.Fatalf("failed to read the data file: %v", err)
+	}
+	f, err := os.Open(filepath.Join(dir, rawResponseIndexFile))
+	if err != nil {
+		t.Fatalf("failed to open the index file: %v", err)
+	}
+	defer f.Close()
+
+	var i int
+	scanner := bufio.NewScanner(f)
+	for ; scanner.Scan(); i++ {
+		var entry rawResponseEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("failed to parse the index entry: %v", err)
+		}
+		if i >= len(names) || entry.Name != names[i] || entry.Type != "A" || entry.Resolver != "192.0.2.53:53" {
+			t.Errorf("unexpected index entry %d: %+v", i, entry)
+			continue
+		}
+
+		offset := entry.Offset
+		for j, length := range []int{entry.RequestLength, entry.ResponseLength} {
+			if l := int(binary.BigEndian.Uint32(data[offset:])); l != length {
+				t.Errorf("entry %d: message %d has the length %d in the data file, expected %d", i, j, l, length)
+			}
+			offset += 4
+
+			msg := new(dns.Msg)
+			if err := msg.Unpack(data[offset : offset+int64(length)]); err != nil {
+				t.Errorf("entry %d: failed to unpack message %d: %v", i, j, err)
+			} else if msg.Response != (j == 1) || msg.Question[0].Name != names[i] {
+				t.Errorf("entry %d: message %d does not match the index", i, j)
+			}
+			offset += int64(length)
+		}
+	}
+	if i != len(names) {
+		t.Errorf("expected %d index entries, but got %d", len(names), i)
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+const (
+	// RebindingSeverity is the severity of the DNS rebinding findings.
+	RebindingSeverity = "medium"
+	// defaultRebindingLowTTL is the TTL, in seconds, considered suspiciously low when RebindingLowTTL is not set.
+	defaultRebindingLowTTL = 60
+)
+
+// DefaultRebindingPrivateRanges are the private, loopback, link-local and shared address ranges
+// used when RebindingPrivateRanges is not set.
+var DefaultRebindingPrivateRanges = []string{
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+}
+
+// RebindingFinding is a name resolving to both public and private addresses, which allows a DNS
+// rebinding attack against the clients of the name.
+type RebindingFinding struct {
+	Name    string
+	Public  []string
+	Private []string
+	// MinTTL is the lowest TTL of the address records, and LowTTL is set when it does not exceed RebindingLowTTL
+	MinTTL   int
+	LowTTL   bool
+	Severity string
+}
+
+type rebindingAddrs struct {
+	public  map[string]struct{}
+	private map[string]struct{}
+	minTTL  int
+}
+
+// rebindingChecks collects the addresses resolved for each name, and keeps the findings.
+type rebindingChecks struct {
+	sync.Mutex
+	enum     *Enumeration
+	private  []*net.IPNet
+	lowTTL   int
+	names    map[string]*rebindingAddrs
+	reported map[string]int
+	findings []RebindingFinding
+}
+
+func newRebindingChecks(e *Enumeration) (*rebindingChecks, error) {
+	ranges := e.RebindingPrivateRanges
+	if len(ranges) == 0 {
+		ranges = DefaultRebindingPrivateRanges
+	}
+
+	lowTTL := e.RebindingLowTTL
+	if lowTTL < 0 {
+		return nil, fmt.Errorf("the rebinding low TTL cannot be negative: %d", lowTTL)
+	} else if lowTTL == 0 {
+		lowTTL = defaultRebindingLowTTL
+	}
+
+	r := &rebindingChecks{
+		enum:     e,
+		lowTTL:   lowTTL,
+		names:    make(map[string]*rebindingAddrs),
+		reported: make(map[string]int),
+	}
+	for _, s := range ranges {
+		_, cidr, err := net.ParseCIDR(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("the rebinding private range %s is not a valid CIDR", s)
+		}
+		r.private = append(r.private, cidr)
+	}
+	return r, nil
+}
+
+func (r *rebindingChecks) isPrivate(ip net.IP) bool {
+	for _, cidr := range r.private {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// check adds the A and AAAA records of the request, including those reached through the CNAME
+// records, to the addresses of the name, and records the finding once the name resolves to both
+// public and private addresses. The finding is updated as more addresses are resolved.
+func (r *rebindingChecks) check(req *requests.DNSRequest) {
+	var added bool
+	name := strings.ToLower(resolve.RemoveLastDot(req.Name))
+
+	r.Lock()
+	addrs, found := r.names[name]
+	if !found {
+		addrs = &rebindingAddrs{
+			public:  make(map[string]struct{}),
+			private: make(map[string]struct{}),
+			minTTL:  -1,
+		}
+		r.names[name] = addrs
+	}
+
+	for _, rec := range req.Records {
+		if t := uint16(rec.Type); (t != dns.TypeA && t != dns.TypeAAAA) || sectionRecord(rec) {
+			continue
+		}
+		ip := net.ParseIP(strings.TrimSpace(rec.Data))
+		if ip == nil {
+			continue
+		}
+
+		set := addrs.public
+		if r.isPrivate(ip) {
+			set = addrs.private
+		}
+		if _, found := set[ip.String()]; !found {
+			set[ip.String()] = struct{}{}
+			added = true
+		}
+		if addrs.minTTL == -1 || rec.TTL < addrs.minTTL {
+			addrs.minTTL = rec.TTL
+		}
+	}
+
+	if !added || len(addrs.public) == 0 || len(addrs.private) == 0 {
+		r.Unlock()
+		return
+	}
+
+	finding := RebindingFinding{
+		Name:     name,
+		Public:   sortedKeys(addrs.public),
+		Private:  sortedKeys(addrs.private),
+		MinTTL:   addrs.minTTL,
+		LowTTL:   addrs.minTTL <= r.lowTTL,
+		Severity: RebindingSeverity,
+	}
+	if idx, found := r.reported[name]; found {
+		r.findings[idx] = finding
+	} else {
+		r.reported[name] = len(r.findings)
+		r.findings = append(r.findings, finding)
+	}
+	r.Unlock()
+
+	var low string
+	if finding.LowTTL {
+		low = fmt.Sprintf(" with a TTL of %d seconds", finding.MinTTL)
+	}
+	r.enum.log().Warnf("Possible rebinding: %s resolves to the public %s and the private %s%s (%s severity)",
+		name, strings.Join(finding.Public, ","), strings.Join(finding.Private, ","), low, RebindingSeverity)
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// RebindingFindings returns the names resolving to both public and private addresses, sorted by
+// name. The names are only checked when DetectRebinding has been enabled.
+func (e *Enumeration) RebindingFindings() []RebindingFinding {
+	if e.rebind == nil {
+		return nil
+	}
+
+	e.rebind.Lock()
+	defer e.rebind.Unlock()
+
+	results := append([]RebindingFinding(nil), e.rebind.findings...)
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
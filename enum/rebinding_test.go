@@ -0,0 +1,95 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestRebindingChecks(t *testing.T) {
+	e := &Enumeration{Config: config.NewConfig()}
+	if e.RebindingFindings() != nil {
+		t.Errorf("findings were returned without the checks")
+	}
+
+	e.RebindingPrivateRanges = []string{"not-a-cidr"}
+	if _, err := newRebindingChecks(e); err == nil {
+		t.Errorf("the invalid private range was accepted")
+	}
+	e.RebindingPrivateRanges = nil
+
+	rebind, err := newRebindingChecks(e)
+	if err != nil {
+		t.Fatalf("failed to create the checks: %v", err)
+	}
+	e.rebind = rebind
+
+	a := func(name, addr string, ttl int) requests.DNSAnswer {
+		return requests.DNSAnswer{Name: name, Type: int(dns.TypeA), TTL: ttl, Data: addr}
+	}
+	aaaa := func(name, addr string, ttl int) requests.DNSAnswer {
+		return requests.DNSAnswer{Name: name, Type: int(dns.TypeAAAA), TTL: ttl, Data: addr}
+	}
+
+	rebind.check(&requests.DNSRequest{Name: "www.owasp.org", Records: []requests.DNSAnswer{
+		a("www.owasp.org", "192.0.2.1", 300),
+		a("www.owasp.org", "198.51.100.1", 300),
+	}})
+	rebind.check(&requests.DNSRequest{Name: "intranet.owasp.org", Records: []requests.DNSAnswer{
+		a("intranet.owasp.org", "10.0.0.1", 300),
+	}})
+	// the addresses of one name arrive in separate requests and through a CNAME
+	rebind.check(&requests.DNSRequest{Name: "app.owasp.org", Records: []requests.DNSAnswer{
+		{Name: "app.owasp.org", Type: int(dns.TypeCNAME), TTL: 300, Data: "lb.example.com"},
+		a("lb.example.com", "203.0.113.10", 30),
+	}})
+	rebind.check(&requests.DNSRequest{Name: "app.owasp.org", Records: []requests.DNSAnswer{
+		aaaa("app.owasp.org", "fd00::1", 300),
+	}})
+	rebind.check(&requests.DNSRequest{Name: "dev.owasp.org", Records: []requests.DNSAnswer{
+		a("dev.owasp.org", "127.0.0.1", 600),
+		a("dev.owasp.org", "192.0.2.7", 600),
+	}})
+
+	expected := []RebindingFinding{
+		{
+			Name:     "app.owasp.org",
+			Public:   []string{"203.0.113.10"},
+			Private:  []string{"fd00::1"},
+			MinTTL:   30,
+			LowTTL:   true,
+			Severity: RebindingSeverity,
+		},
+		{
+			Name:     "dev.owasp.org",
+			Public:   []string{"192.0.2.7"},
+			Private:  []string{"127.0.0.1"},
+			MinTTL:   600,
+			Severity: RebindingSeverity,
+		},
+	}
+	if got := e.RebindingFindings(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected the findings %+v, but got %+v", expected, got)
+	}
+
+	// the configured ranges replace the defaults
+	e.RebindingPrivateRanges = []string{"192.0.2.0/24"}
+	if rebind, err = newRebindingChecks(e); err != nil {
+		t.Fatalf("failed to create the checks: %v", err)
+	}
+	e.rebind = rebind
+	rebind.check(&requests.DNSRequest{Name: "www.owasp.org", Records: []requests.DNSAnswer{
+		a("www.owasp.org", "192.0.2.1", 300),
+		a("www.owasp.org", "198.51.100.1", 300),
+	}})
+	if got := e.RebindingFindings(); len(got) != 1 || got[0].Name != "www.owasp.org" {
+		t.Errorf("the configured private range was not used: %+v", got)
+	}
+}
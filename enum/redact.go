@@ -0,0 +1,121 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+// RedactPlaceholder replaces the addresses within the RedactCIDRs in the output.
+const RedactPlaceholder = "[redacted]"
+
+// RedactableFields are the output fields that can be listed in RedactFields.
+var RedactableFields = []string{"source", "tag", "ttl", "asn", "description", "netblock", "geo", "confidence"}
+
+func (e *Enumeration) checkRedactFields() error {
+	for _, f := range e.RedactFields {
+		var found bool
+		for _, r := range RedactableFields {
+			if strings.EqualFold(f, r) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("the output field %s cannot be redacted, the fields are %s", f, strings.Join(RedactableFields, ", "))
+		}
+	}
+	return nil
+}
+
+// Redacted returns true when the output field is listed in RedactFields.
+func (e *Enumeration) Redacted(field string) bool {
+	for _, f := range e.RedactFields {
+		if strings.EqualFold(f, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactAddress returns the RedactPlaceholder for the addresses within the RedactCIDRs, and the
+// address unchanged otherwise, including the values that are not addresses.
+func (e *Enumeration) RedactAddress(addr string) string {
+	if ip := net.ParseIP(strings.TrimSpace(addr)); ip != nil && e.redactedIP(ip) {
+		return RedactPlaceholder
+	}
+	return addr
+}
+
+func (e *Enumeration) redactedIP(ip net.IP) bool {
+	for _, cidr := range e.RedactCIDRs {
+		if cidr != nil && cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactOutput returns a copy of the output without the addresses within the RedactCIDRs, since
+// the addresses of the Output cannot hold the placeholder, and without the fields in RedactFields.
+func (e *Enumeration) RedactOutput(out *requests.Output) *requests.Output {
+	if len(e.RedactFields) == 0 && len(e.RedactCIDRs) == 0 {
+		return out
+	}
+
+	o := out.Clone().(*requests.Output)
+	o.Addresses = nil
+	for _, a := range out.Addresses {
+		if a.Address != nil && e.redactedIP(a.Address) {
+			continue
+		}
+		if e.Redacted("asn") {
+			a.ASN = 0
+		}
+		if e.Redacted("description") {
+			a.Description = ""
+		}
+		if e.Redacted("netblock") {
+			a.Netblock = nil
+			a.CIDRStr = ""
+		}
+		if e.Redacted("geo") {
+			a.Geo = nil
+		}
+		o.Addresses = append(o.Addresses, a)
+	}
+	if e.Redacted("confidence") {
+		o.Confidence = 0
+	}
+	return o
+}
+
+// redactRequest returns a copy of the request sent to the outputs, with the addresses within the
+// RedactCIDRs replaced by the placeholder, and without the fields in RedactFields. The request
+// stored in the graph is not changed.
+func (e *Enumeration) redactRequest(req *requests.DNSRequest) *requests.DNSRequest {
+	if len(e.RedactFields) == 0 && len(e.RedactCIDRs) == 0 {
+		return req
+	}
+
+	r := req.Clone().(*requests.DNSRequest)
+	for i := range r.Records {
+		r.Records[i].Data = e.RedactAddress(r.Records[i].Data)
+		if e.Redacted("ttl") {
+			r.Records[i].TTL = 0
+		}
+	}
+	if e.Redacted("source") {
+		r.Source = ""
+	}
+	if e.Redacted("tag") {
+		r.Tag = ""
+	}
+	return r
+}
@@ -0,0 +1,92 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestRedactRequest(t *testing.T) {
+	_, internal, _ := net.ParseCIDR("10.0.0.0/8")
+	e := &Enumeration{
+		Config:       config.NewConfig(),
+		RedactFields: []string{"Source", "ttl"},
+		RedactCIDRs:  []*net.IPNet{internal},
+	}
+	if err := e.checkRedactFields(); err != nil {
+		t.Errorf("the redacted fields were rejected: %v", err)
+	}
+
+	req := &requests.DNSRequest{
+		Name:   "www.owasp.org",
+		Domain: "owasp.org",
+		Records: []requests.DNSAnswer{
+			{Name: "www.owasp.org", Type: int(dns.TypeA), TTL: 300, Data: "10.1.2.3"},
+			{Name: "www.owasp.org", Type: int(dns.TypeA), TTL: 300, Data: "192.0.2.1"},
+		},
+		Tag:    requests.API,
+		Source: "PaidAPI",
+	}
+
+	var got *requests.Output
+	e.SubscribeOutput(func(out *requests.Output) { got = out })
+	if err := e.makeOutputSink()(context.Background(), req); err != nil {
+		t.Fatalf("the output sink failed: %v", err)
+	}
+	if got == nil || len(got.Addresses) != 1 || got.Addresses[0].Address.String() != "192.0.2.1" {
+		t.Errorf("expected only the address outside of the redacted range in the output, but got %v", got)
+	}
+
+	out := e.redactRequest(req)
+	if out.Records[0].Data != RedactPlaceholder || out.Records[1].Data != "192.0.2.1" {
+		t.Errorf("the address within the redacted range was not masked: %v", out.Records)
+	}
+	if out.Source != "" || out.Records[0].TTL != 0 || out.Tag != requests.API {
+		t.Errorf("the redacted fields were not omitted: %v", out)
+	}
+	// the request stored in the graph keeps the full data
+	if req.Records[0].Data != "10.1.2.3" || req.Source != "PaidAPI" || req.Records[0].TTL != 300 {
+		t.Errorf("the original request was changed by the redaction: %v", req)
+	}
+
+	e.RedactFields = []string{"password"}
+	if err := e.checkRedactFields(); err == nil {
+		t.Errorf("an unknown field was accepted for the redaction")
+	}
+}
+
+func TestRedactOutput(t *testing.T) {
+	_, internal, _ := net.ParseCIDR("10.0.0.0/8")
+	e := &Enumeration{RedactFields: []string{"asn", "geo"}, RedactCIDRs: []*net.IPNet{internal}}
+
+	out := &requests.Output{
+		Name:   "www.owasp.org",
+		Domain: "owasp.org",
+		Addresses: []requests.AddressInfo{
+			{Address: net.ParseIP("10.1.2.3"), CIDRStr: "10.0.0.0/8"},
+			{Address: net.ParseIP("192.0.2.1"), CIDRStr: "192.0.2.0/24", ASN: 64496, Geo: &requests.GeoLocation{City: "Paris"}},
+		},
+	}
+
+	o := e.RedactOutput(out)
+	if len(o.Addresses) != 1 || o.Addresses[0].Address.String() != "192.0.2.1" {
+		t.Fatalf("expected the address within the redacted range to be omitted, but got %v", o.Addresses)
+	}
+	if a := o.Addresses[0]; a.ASN != 0 || a.Geo != nil || a.CIDRStr != "192.0.2.0/24" {
+		t.Errorf("expected only the redacted fields to be omitted, but got %v", a)
+	}
+	if len(out.Addresses) != 2 || out.Addresses[1].ASN != 64496 {
+		t.Errorf("the original output was changed by the redaction: %v", out.Addresses)
+	}
+	if e.RedactAddress("192.0.2.1") != "192.0.2.1" || e.RedactAddress("www.owasp.org") != "www.owasp.org" {
+		t.Errorf("the values outside of the redacted ranges were masked")
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+const (
+	// defaultReverseSweepSize allows full sweeps of the blocks up to a /16 when ReverseSweepSize is not set
+	defaultReverseSweepSize = 65536
+	// maxConcurrentReverseQueries is the number of reverse DNS queries outstanding at once
+	maxConcurrentReverseQueries = 100
+)
+
+// ReverseOnly sweeps the reverse DNS of the addresses in the block, without the forward resolution
+// and the data sources, and stores the PTR records for the names within the scope in the graph.
+// Up to ReverseSweepSize addresses are swept from the start of the block. The PTR requests with
+// the records in scope are returned, and the enumeration does not need to be started. The addresses
+// rejected by the AddressInScope hook are skipped. When ReverseSweepCheckpointFile has been set,
+// the sweep resumes after the last address swept in the block by a previous run.
+func (e *Enumeration) ReverseOnly(ctx context.Context, cidr *net.IPNet) ([]*requests.DNSRequest, error) {
+	if cidr == nil {
+		return nil, errors.New("the netblock to sweep was not provided")
+	}
+	if e.graph == nil {
+		return nil, errors.New("the enumeration does not have a graph database")
+	}
+	if _, err := e.sweepCheckpoint(); err != nil {
+		return nil, err
+	}
+
+	size := e.ReverseSweepSize
+	if size <= 0 {
+		size = defaultReverseSweepSize
+	}
+
+	results := e.sweepReverse(ctx, cidr, size)
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+// sweepReverse queries the PTR records of up to size addresses from the start of the block, and
+// returns the requests with the records in scope. The addresses rejected by AddressInScope are skipped.
+func (e *Enumeration) sweepReverse(ctx context.Context, cidr *net.IPNet, size int) []*requests.DNSRequest {
+	prog := e.newSweepProgress(cidr, sweepHosts(cidr, size))
+	defer prog.finish()
+
+	if e.ReverseBatchSize > 0 {
+		return e.sweepReverseBatches(ctx, prog)
+	}
+
+	var lock sync.Mutex
+	var results []*requests.DNSRequest
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentReverseQueries)
+loop:
+	for i, ip := range prog.hosts {
+		if !e.addrPermitted(ip.String()) {
+			prog.complete(i)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			break loop
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(idx int, addr string) {
+			defer func() { <-sem }()
+			defer wg.Done()
+
+			if req := e.reverseInScope(ctx, addr); req != nil {
+				lock.Lock()
+				results = append(results, req)
+				lock.Unlock()
+			}
+			if ctx.Err() == nil {
+				prog.complete(idx)
+			}
+		}(i, ip.String())
+	}
+	wg.Wait()
+	return results
+}
+
+// sweepReverseBatches performs the sweep with the PTR queries pipelined in groups of ReverseBatchSize.
+func (e *Enumeration) sweepReverseBatches(ctx context.Context, prog *sweepProgress) []*requests.DNSRequest {
+	var batches [][]int
+	var batch []int
+	for i, ip := range prog.hosts {
+		if !e.addrPermitted(ip.String()) {
+			prog.complete(i)
+			continue
+		}
+
+		batch = append(batch, i)
+		if len(batch) >= e.ReverseBatchSize {
+			batches = append(batches, batch)
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+
+	var lock sync.Mutex
+	var results []*requests.DNSRequest
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentReverseBatches)
+loop:
+	for _, b := range batches {
+		select {
+		case <-ctx.Done():
+			break loop
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(idxs []int) {
+			defer func() { <-sem }()
+			defer wg.Done()
+
+			addrs := make([]string, 0, len(idxs))
+			for _, idx := range idxs {
+				addrs = append(addrs, prog.hosts[idx].String())
+			}
+
+			ptrs := e.reverseBatchQuery(ctx, addrs)
+			for _, addr := range addrs {
+				if req, found := ptrs[addr]; found {
+					if req = e.storeReverseInScope(ctx, addr, req); req != nil {
+						lock.Lock()
+						results = append(results, req)
+						lock.Unlock()
+					}
+				}
+			}
+			if ctx.Err() == nil {
+				for _, idx := range idxs {
+					prog.complete(idx)
+				}
+			}
+		}(b)
+	}
+	wg.Wait()
+	return results
+}
+
+// reverseInScope queries the PTR records of the address and stores those pointing at names in scope.
+func (e *Enumeration) reverseInScope(ctx context.Context, addr string) *requests.DNSRequest {
+	req, err := e.reverseDNSQuery(ctx, addr)
+	if err != nil {
+		return nil
+	}
+	return e.storeReverseInScope(ctx, addr, req)
+}
+
+// storeReverseInScope stores the PTR records of the request pointing at names in scope, and
+// returns the request with only those records.
+func (e *Enumeration) storeReverseInScope(ctx context.Context, addr string, req *requests.DNSRequest) *requests.DNSRequest {
+	var records []requests.DNSAnswer
+	for _, rec := range req.Records {
+		target := strings.ToLower(resolve.RemoveLastDot(rec.Data))
+		if target == "" || e.Config.WhichDomain(target) == "" || e.blacklisted(target) {
+			continue
+		}
+		if err := e.graph.UpsertPTR(ctx, req.Name, target); err != nil {
+			e.log().Errorf("Failed to insert the PTR record for %s: %v", addr, err)
+			if !e.graphWriteFailed(graphFailoverRecord{Type: "PTR record", Name: req.Name, Value: target}, err) {
+				continue
+			}
+		}
+		records = append(records, rec)
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	req.Records = records
+	return req
+}
+
+// sweepHosts returns up to num addresses from the start of the block, including the
+// network and broadcast addresses, which can also have PTR records.
+func sweepHosts(cidr *net.IPNet, num int) []net.IP {
+	var hosts []net.IP
+
+	ip := cidr.IP.Mask(cidr.Mask)
+	for len(hosts) < num && cidr.Contains(ip) {
+		host := make(net.IP, len(ip))
+		copy(host, ip)
+		hosts = append(hosts, host)
+
+		amassnet.IPInc(ip)
+		if ip.Equal(cidr.IP.Mask(cidr.Mask)) {
+			// the address wrapped around
+			break
+		}
+	}
+	return hosts
+}
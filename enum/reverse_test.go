@@ -0,0 +1,253 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestSweepHosts(t *testing.T) {
+	for _, test := range []struct {
+		cidr     string
+		num      int
+		expected int
+		first    string
+	}{
+		{"192.0.2.0/29", 100, 8, "192.0.2.0"},
+		{"192.0.2.77/24", 10, 10, "192.0.2.0"},
+		{"2001:db8::/64", 5, 5, "2001:db8::"},
+		{"255.255.255.254/31", 10, 2, "255.255.255.254"},
+	} {
+		_, cidr, _ := net.ParseCIDR(test.cidr)
+		hosts := sweepHosts(cidr, test.num)
+		if len(hosts) != test.expected {
+			t.Errorf("%s: expected %d addresses, but got %d", test.cidr, test.expected, len(hosts))
+			continue
+		}
+		if hosts[0].String() != test.first {
+			t.Errorf("%s: expected the sweep to start at %s, but got %s", test.cidr, test.first, hosts[0])
+		}
+	}
+}
+
+func TestReverseOnly(t *testing.T) {
+	srvAddr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		q := req.Question[0]
+		targets := map[string]string{
+			"1.2.0.192.in-addr.arpa.": "www.owasp.org.",
+			"2.2.0.192.in-addr.arpa.": "host.example.com.",
+		}
+		if target, found := targets[strings.ToLower(q.Name)]; found && q.Qtype == dns.TypePTR {
+			m.Answer = append(m.Answer, &dns.PTR{
+				Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypePTR, Class: dns.ClassINET, Ttl: 300},
+				Ptr: target,
+			})
+		} else {
+			m.Rcode = dns.RcodeNameError
+		}
+		_ = w.WriteMsg(m)
+	})
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.TrustedResolvers = []string{srvAddr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP: true,
+		graph:    g,
+	}
+	defer e.Sys.Resolvers().Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, cidr, _ := net.ParseCIDR("192.0.2.0/29")
+	reqs, err := e.ReverseOnly(ctx, cidr)
+	if err != nil {
+		t.Fatalf("the sweep failed: %v", err)
+	}
+	if len(reqs) != 1 || reqs[0].Name != "1.2.0.192.in-addr.arpa" || len(reqs[0].Records) != 1 {
+		t.Fatalf("expected only the PTR record for the name in scope, but got %v", reqs)
+	}
+	if assets, err := g.DB.FindByContent(domain.FQDN{Name: "www.owasp.org"}, time.Time{}); err != nil || len(assets) == 0 {
+		t.Errorf("the name in scope was not stored")
+	}
+	if assets, err := g.DB.FindByContent(domain.FQDN{Name: "host.example.com"}, time.Time{}); err == nil && len(assets) > 0 {
+		t.Errorf("the name outside of the scope was stored")
+	}
+}
+
+func TestResolvePTRForAddressesTracked(t *testing.T) {
+	release := make(chan struct{})
+	addr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		<-release
+		_ = w.WriteMsg(ptrHandler(req))
+	})
+
+	e := newReverseBatchEnum(t, addr, 0)
+	defer e.graph.Remove()
+	defer e.Sys.Resolvers().Stop()
+	defer e.Sys.TrustedResolvers().Stop()
+	e.ResolvePTRForAddresses = true
+	e.tracked = newTrackedWork(maxTrackedWork)
+	e.nameSrc = newTestEnumSource(e, 10)
+	dm := newDataManager(e)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dm.reverseAddr(ctx, "192.0.2.1", nil)
+	// the enumeration cannot be declared complete while the PTR lookup is running
+	if !e.requestsPending() {
+		t.Errorf("the PTR lookup was not tracked by the enumeration")
+	}
+
+	close(release)
+	e.tracked.wait()
+	if e.requestsPending() {
+		t.Errorf("the PTR lookup was still tracked after it completed")
+	}
+	if assets, err := e.graph.DB.FindByContent(domain.FQDN{Name: "www.owasp.org"}, time.Time{}); err != nil || len(assets) == 0 {
+		t.Errorf("the PTR record was not stored before the tracked work was drained")
+	}
+	<-dm.Stop()
+}
+
+// ptrHandler answers the PTR queries of 192.0.2.1 with a name in scope and 192.0.2.2 with a name
+// outside of the scope, and the other queries with NXDOMAIN.
+func ptrHandler(req *dns.Msg) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetReply(req)
+
+	q := req.Question[0]
+	targets := map[string]string{
+		"1.2.0.192.in-addr.arpa.": "www.owasp.org.",
+		"2.2.0.192.in-addr.arpa.": "host.example.com.",
+	}
+	if target, found := targets[strings.ToLower(q.Name)]; found && q.Qtype == dns.TypePTR {
+		m.Answer = append(m.Answer, &dns.PTR{
+			Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypePTR, Class: dns.ClassINET, Ttl: 300},
+			Ptr: target,
+		})
+	} else {
+		m.Rcode = dns.RcodeNameError
+	}
+	return m
+}
+
+func newReverseBatchEnum(tb testing.TB, addr string, batch int) *Enumeration {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.TrustedResolvers = []string{addr}
+
+	return &Enumeration{
+		Config:           cfg,
+		Sys:              &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: resolve.NewResolvers()},
+		ForceTCP:         true,
+		ReverseBatchSize: batch,
+		graph:            netmap.NewGraph("local", filepath.Join(tb.TempDir(), "graph.db"), ""),
+	}
+}
+
+func TestReverseOnlyBatches(t *testing.T) {
+	srvAddr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		_ = w.WriteMsg(ptrHandler(req))
+	})
+
+	// this server answers a single query on each connection, and cannot pipeline
+	single, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on a TCP port: %v", err)
+	}
+	defer single.Close()
+	go func() {
+		for {
+			conn, err := single.Accept()
+			if err != nil {
+				return
+			}
+
+			co := &dns.Conn{Conn: conn}
+			if req, err := co.ReadMsg(); err == nil {
+				_ = co.WriteMsg(ptrHandler(req))
+			}
+			conn.Close()
+		}
+	}()
+
+	_, cidr, _ := net.ParseCIDR("192.0.2.0/29")
+	for _, addr := range []string{srvAddr, single.Addr().String()} {
+		e := newReverseBatchEnum(t, addr, 4)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		reqs, err := e.ReverseOnly(ctx, cidr)
+		cancel()
+		if err != nil {
+			t.Errorf("%s: the sweep failed: %v", addr, err)
+		} else if len(reqs) != 1 || reqs[0].Name != "1.2.0.192.in-addr.arpa" || len(reqs[0].Records) != 1 {
+			t.Errorf("%s: expected only the PTR record for the name in scope, but got %v", addr, reqs)
+		}
+		if assets, err := e.graph.DB.FindByContent(domain.FQDN{Name: "www.owasp.org"}, time.Time{}); err != nil || len(assets) == 0 {
+			t.Errorf("%s: the name in scope was not stored", addr)
+		}
+
+		_, nopipe := e.nopipe.Load(addr)
+		if expected := addr == single.Addr().String(); nopipe != expected {
+			t.Errorf("%s: expected the resolver to be remembered as not pipelining: %t", addr, expected)
+		}
+		e.Sys.Resolvers().Stop()
+		e.Sys.TrustedResolvers().Stop()
+		e.graph.Remove()
+	}
+}
+
+func BenchmarkReverseSweep(b *testing.B) {
+	addr := startMockDNS(b, func(w dns.ResponseWriter, req *dns.Msg) {
+		_ = w.WriteMsg(ptrHandler(req))
+	})
+
+	_, cidr, _ := net.ParseCIDR("192.0.2.0/24")
+	for _, bench := range []struct {
+		name  string
+		batch int
+	}{
+		{"individual", 0},
+		{"pipelined", 64},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			e := newReverseBatchEnum(b, addr, bench.batch)
+			defer e.graph.Remove()
+			defer e.Sys.TrustedResolvers().Stop()
+			defer e.Sys.Resolvers().Stop()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := e.ReverseOnly(context.Background(), cidr); err != nil {
+					b.Fatalf("the sweep failed: %v", err)
+				}
+			}
+		})
+	}
+}
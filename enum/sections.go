@@ -0,0 +1,83 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+// maxSectionRecords is the number of records kept from the authority and additional sections of each response.
+const maxSectionRecords = 16
+
+// sectionAnswers converts the records in the authority and additional sections of the response,
+// marked with their section, and keeps up to maxSectionRecords of each section.
+func sectionAnswers(resp *dns.Msg) []requests.DNSAnswer {
+	if resp == nil {
+		return nil
+	}
+
+	var answers []requests.DNSAnswer
+	for _, s := range []struct {
+		name string
+		rrs  []dns.RR
+	}{
+		{name: requests.AUTHORITY, rrs: resp.Ns},
+		{name: requests.ADDITIONAL, rrs: resp.Extra},
+	} {
+		for i, rr := range s.rrs {
+			if i >= maxSectionRecords {
+				break
+			}
+
+			hdr := rr.Header()
+			ans := requests.DNSAnswer{
+				Name:    strings.ToLower(resolve.RemoveLastDot(hdr.Name)),
+				Type:    int(hdr.Rrtype),
+				TTL:     int(hdr.Ttl),
+				Data:    strings.TrimSpace(strings.TrimPrefix(rr.String(), hdr.String())),
+				Section: s.name,
+			}
+			// the OPT pseudo-record carries the EDNS parameters instead of a TTL
+			if opt, ok := rr.(*dns.OPT); ok {
+				ans.TTL = 0
+				ans.Data = fmt.Sprintf("version %d udp %d do %t", opt.Version(), opt.UDPSize(), opt.Do())
+			}
+			answers = append(answers, ans)
+		}
+	}
+	return answers
+}
+
+// appendSections adds the authority and additional records of the response to the records, when
+// CaptureFullResponse is set, skipping those already carried from the responses for other types.
+func (e *Enumeration) appendSections(records []requests.DNSAnswer, resp *dns.Msg) []requests.DNSAnswer {
+	if !e.CaptureFullResponse {
+		return records
+	}
+
+	for _, ans := range truncateAnswers(sectionAnswers(resp), e.MaxAnswerBytes) {
+		var found bool
+		for _, rec := range records {
+			if rec.Section == ans.Section && rec.Type == ans.Type && rec.Name == ans.Name && rec.Data == ans.Data {
+				found = true
+				break
+			}
+		}
+		if !found {
+			records = append(records, ans)
+		}
+	}
+	return records
+}
+
+// sectionRecord returns true when the record was taken from the authority or additional section.
+func sectionRecord(rec requests.DNSAnswer) bool {
+	return rec.Section != ""
+}
@@ -0,0 +1,126 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestCaptureFullResponse(t *testing.T) {
+	addr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		q := req.Question[0]
+		if strings.ToLower(q.Name) != "www.owasp.org." {
+			m.Rcode = dns.RcodeNameError
+			_ = w.WriteMsg(m)
+			return
+		}
+		if q.Qtype == dns.TypeA {
+			m.Answer = append(m.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+				A:   net.ParseIP("192.0.2.1"),
+			})
+		}
+		m.Ns = append(m.Ns, &dns.NS{
+			Hdr: dns.RR_Header{Name: "owasp.org.", Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: 3600},
+			Ns:  "ns1.owasp.org.",
+		})
+		m.Extra = append(m.Extra, &dns.A{
+			Hdr: dns.RR_Header{Name: "ns1.owasp.org.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 3600},
+			A:   net.ParseIP("192.0.2.53"),
+		})
+		m.SetEdns0(1232, false)
+		_ = w.WriteMsg(m)
+	})
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.Resolvers = []string{addr}
+	// the queries sent without the pools are paced to the QPS of the resolvers
+	cfg.ResolversQPS, cfg.TrustedQPS = 1000, 1000
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP: true,
+	}
+	defer e.Sys.Resolvers().Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := e.ResolveName(ctx, "www.owasp.org", "owasp.org")
+	if err != nil {
+		t.Fatalf("failed to resolve the name: %v", err)
+	}
+	if len(req.Records) != 1 {
+		t.Errorf("expected only the answer without CaptureFullResponse, but got %v", req.Records)
+	}
+
+	e.CaptureFullResponse = true
+	req, err = e.ResolveName(ctx, "www.owasp.org", "owasp.org")
+	if err != nil {
+		t.Fatalf("failed to resolve the name: %v", err)
+	}
+
+	sections := make(map[string][]requests.DNSAnswer)
+	for _, rec := range req.Records {
+		sections[rec.Section] = append(sections[rec.Section], rec)
+	}
+	if a := sections[""]; len(a) != 1 || a[0].Data != "192.0.2.1" {
+		t.Errorf("unexpected records in the answer section: %v", a)
+	}
+	if ns := sections[requests.AUTHORITY]; len(ns) != 1 || ns[0].Type != int(dns.TypeNS) || ns[0].Name != "owasp.org" || ns[0].Data != "ns1.owasp.org." {
+		t.Errorf("unexpected records in the authority section: %v", ns)
+	}
+	// the records repeated in the responses for the other types are kept once
+	extra := sections[requests.ADDITIONAL]
+	if len(extra) != 2 {
+		t.Fatalf("expected the glue and OPT records in the additional section, but got %v", extra)
+	}
+	if extra[0].Name != "ns1.owasp.org" || extra[0].Data != "192.0.2.53" {
+		t.Errorf("unexpected glue record in the additional section: %v", extra[0])
+	}
+	if extra[1].Type != int(dns.TypeOPT) || !strings.Contains(extra[1].Data, "udp 1232") {
+		t.Errorf("unexpected OPT record in the additional section: %v", extra[1])
+	}
+	// the records of the other sections are not the addresses of the name
+	if !hasAddress(req) || len(newTemplateResult(req).Addresses) != 1 {
+		t.Errorf("the additional records were used as the addresses of the name")
+	}
+}
+
+func TestSectionAnswersBound(t *testing.T) {
+	resp := new(dns.Msg)
+	for i := 0; i < 3*maxSectionRecords; i++ {
+		resp.Extra = append(resp.Extra, &dns.A{
+			Hdr: dns.RR_Header{Name: "ns" + strconv.Itoa(i) + ".owasp.org.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+			A:   net.ParseIP("192.0.2.1"),
+		})
+	}
+
+	if answers := sectionAnswers(resp); len(answers) != maxSectionRecords {
+		t.Errorf("expected %d records from the large section, but got %d", maxSectionRecords, len(answers))
+	}
+	if sectionAnswers(nil) != nil {
+		t.Errorf("answers were returned without a response")
+	}
+}
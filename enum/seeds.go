@@ -0,0 +1,189 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"crypto/x509"
+	"encoding/csv"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+// certificateSource is the source of the names extracted from the provided certificates.
+const certificateSource = "Certificate"
+
+// loadProvidedNamesCSV reads the seed names from the CSV file, and skips the names outside of the scope.
+func (e *Enumeration) loadProvidedNamesCSV(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open the provided names CSV file %s: %v", path, err)
+	}
+	defer f.Close()
+
+	names, skipped, err := e.parseProvidedNamesCSV(f)
+	if err != nil {
+		return fmt.Errorf("failed to parse the provided names CSV file %s: %v", path, err)
+	}
+	if skipped > 0 {
+		e.log().Infof("Skipped %d names from %s that are not in scope", skipped, path)
+	}
+
+	e.csvNames = names
+	return nil
+}
+
+// parseProvidedNamesCSV returns the in scope names in the name, domain and source columns of the CSV
+// data, along with the number of rows that were skipped for being out of scope.
+func (e *Enumeration) parseProvidedNamesCSV(r io.Reader) ([]ProvidedName, int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var skipped int
+	var names []ProvidedName
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, 0, err
+		}
+
+		field := func(i int) string {
+			if i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		name := strings.ToLower(field(0))
+		if name == "" || (first && name == "name") {
+			continue
+		}
+
+		domain := strings.ToLower(field(1))
+		if domain == "" {
+			domain = e.Config.WhichDomain(name)
+		}
+		if domain == "" || !e.Config.IsDomainInScope(domain) ||
+			(name != domain && !strings.HasSuffix(name, "."+domain)) {
+			skipped++
+			continue
+		}
+
+		names = append(names, ProvidedName{
+			Name:   name,
+			Domain: domain,
+			Source: field(2),
+		})
+	}
+	return names, skipped, nil
+}
+
+// loadProvidedCertificates reads the seed names from the PEM certificates in the file, and skips the
+// names outside of the scope. The malformed certificates are counted and skipped.
+func (e *Enumeration) loadProvidedCertificates(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read the provided certificates file %s: %v", path, err)
+	}
+
+	names, wildcards, malformed, skipped := e.parseProvidedCertificates(data)
+	if len(names) == 0 && len(wildcards) == 0 && malformed == 0 && skipped == 0 {
+		return fmt.Errorf("no certificates were found in the PEM file %s", path)
+	}
+	if malformed > 0 {
+		e.log().Warnf("Skipped %d malformed certificates in %s", malformed, path)
+	}
+	if skipped > 0 {
+		e.log().Infof("Skipped %d names from the certificates in %s that are not in scope", skipped, path)
+	}
+	for _, w := range wildcards {
+		e.log().Infof("Wildcard certificate name: %s", w)
+	}
+
+	e.pemNames = names
+	return nil
+}
+
+// parseProvidedCertificates returns the in scope names from the common names and DNS names of the PEM
+// certificates, the in scope wildcard names, and the numbers of malformed certificates and skipped names.
+// The base of each wildcard name is included when CertificateWildcardBases has been set.
+func (e *Enumeration) parseProvidedCertificates(data []byte) ([]ProvidedName, []string, int, int) {
+	var skipped, malformed int
+	var names []ProvidedName
+	var wildcards []string
+	seen := make(map[string]struct{})
+
+	add := func(name string) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return
+		}
+		if _, found := seen[name]; found {
+			return
+		}
+		seen[name] = struct{}{}
+
+		base := name
+		if strings.HasPrefix(name, "*.") {
+			base = name[2:]
+		}
+
+		domain := e.Config.WhichDomain(base)
+		if domain == "" {
+			domain = e.expandScope(base)
+		}
+		if domain == "" || !e.Config.IsDomainInScope(base) {
+			skipped++
+			return
+		}
+		if base != name {
+			wildcards = append(wildcards, name)
+			if !e.CertificateWildcardBases {
+				return
+			}
+			if _, found := seen[base]; found {
+				return
+			}
+			seen[base] = struct{}{}
+		}
+
+		names = append(names, ProvidedName{
+			Name:   base,
+			Domain: domain,
+			Tag:    requests.CERT,
+			Source: certificateSource,
+		})
+	}
+
+	for rest := data; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			malformed++
+			continue
+		}
+
+		add(cert.Subject.CommonName)
+		for _, name := range cert.DNSNames {
+			add(name)
+		}
+	}
+	return names, wildcards, malformed, skipped
+}
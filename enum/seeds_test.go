@@ -0,0 +1,111 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestParseProvidedNamesCSV(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomains("owasp.org", "example.com")
+	e := &Enumeration{Config: cfg}
+
+	data := `name,domain,source
+www.owasp.org,owasp.org,Analyst Notes
+"mail.example.com","example.com","Ticket 42, Client A"
+ API.owasp.org , , 
+www.example.net,example.net,Other Client
+www.owasp.org,example.com,Wrong Domain
+# comments are ignored
+ftp.owasp.org
+`
+	names, skipped, err := e.parseProvidedNamesCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to parse the CSV data: %v", err)
+	}
+
+	expected := []ProvidedName{
+		{Name: "www.owasp.org", Domain: "owasp.org", Source: "Analyst Notes"},
+		{Name: "mail.example.com", Domain: "example.com", Source: "Ticket 42, Client A"},
+		{Name: "api.owasp.org", Domain: "owasp.org"},
+		{Name: "ftp.owasp.org", Domain: "owasp.org"},
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected the names %v, but got %v", expected, names)
+	}
+	if skipped != 2 {
+		t.Errorf("expected 2 rows to be skipped, but got %d", skipped)
+	}
+
+	if _, _, err := e.parseProvidedNamesCSV(strings.NewReader("www.owasp.org,\"owasp.org\n")); err == nil {
+		t.Errorf("the malformed CSV data was accepted")
+	}
+}
+
+func TestParseProvidedCertificates(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{Config: cfg}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate the key: %v", err)
+	}
+	certPEM := func(cn string, sans ...string) []byte {
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: cn},
+			DNSNames:     sans,
+			NotBefore:    time.Now(),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+		if err != nil {
+			t.Fatalf("failed to create the certificate: %v", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+
+	var data []byte
+	data = append(data, certPEM("www.owasp.org", "www.owasp.org", "*.dev.owasp.org", "www.example.com")...)
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("malformed")})...)
+	data = append(data, certPEM("API.owasp.org", "dev.owasp.org")...)
+
+	names, wildcards, malformed, skipped := e.parseProvidedCertificates(data)
+	expected := []ProvidedName{
+		{Name: "www.owasp.org", Domain: "owasp.org", Tag: requests.CERT, Source: certificateSource},
+		{Name: "api.owasp.org", Domain: "owasp.org", Tag: requests.CERT, Source: certificateSource},
+		{Name: "dev.owasp.org", Domain: "owasp.org", Tag: requests.CERT, Source: certificateSource},
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, but got %v", expected, names)
+	}
+	if len(wildcards) != 1 || wildcards[0] != "*.dev.owasp.org" {
+		t.Errorf("unexpected wildcard names: %v", wildcards)
+	}
+	if malformed != 1 || skipped != 1 {
+		t.Errorf("expected one malformed certificate and one skipped name, but got %d and %d", malformed, skipped)
+	}
+
+	e.CertificateWildcardBases = true
+	names, _, _, _ = e.parseProvidedCertificates(data)
+	if len(names) != 3 || names[1].Name != "dev.owasp.org" {
+		t.Errorf("the base of the wildcard name was not seeded: %v", names)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/owasp-amass/resolve"
+)
+
+// The methods in this file change the settings of a running enumeration. The exported fields are
+// only read without synchronization once Start has been called, so the settings that can change
+// mid-run are guarded by setLock, while the scope is guarded by the Config itself.
+
+// AddDomain brings the root domain into the scope, and releases it to the pipeline and the data
+// sources when the enumeration is running. It is safe to call from other goroutines.
+func (e *Enumeration) AddDomain(domain string) error {
+	domain, err := toASCIIName(strings.ToLower(resolve.RemoveLastDot(strings.TrimSpace(domain))))
+	if err != nil {
+		return err
+	}
+	if domain == "" || !strings.Contains(domain, ".") {
+		return fmt.Errorf("the domain %q is not valid", domain)
+	}
+	if e.Config.IsDomainInScope(domain) {
+		return fmt.Errorf("the domain %s is already in scope", domain)
+	}
+
+	e.Config.AddDomain(domain)
+	e.log().Infof("Scope: %s was added to the running enumeration", domain)
+
+	e.srcLock.RLock()
+	running := e.nameSrc != nil
+	e.srcLock.RUnlock()
+	if running {
+		e.submitDomain(domain)
+	}
+	return nil
+}
+
+// BlacklistSubdomain stops the names under the subdomain from being processed, in addition to
+// the Blacklist. It is safe to call from other goroutines, and the names already in the pipeline
+// are not recalled.
+func (e *Enumeration) BlacklistSubdomain(sub string) error {
+	sub, err := toASCIIName(strings.ToLower(resolve.RemoveLastDot(strings.TrimSpace(sub))))
+	if err != nil {
+		return err
+	}
+	if sub == "" {
+		return errors.New("the subdomain to blacklist cannot be empty")
+	}
+
+	e.setLock.Lock()
+	defer e.setLock.Unlock()
+
+	for _, b := range e.blocked {
+		if b == sub {
+			return nil
+		}
+	}
+	e.blocked = append(e.blocked, sub)
+	return nil
+}
+
+// blacklistedAtRuntime returns true when the name is under a subdomain added by BlacklistSubdomain.
+func (e *Enumeration) blacklistedAtRuntime(name string) bool {
+	e.setLock.RLock()
+	defer e.setLock.RUnlock()
+
+	return len(e.blocked) > 0 && hasDomainSuffix(name, e.blocked)
+}
+
+// SetSourceRequestCap changes the cap on the requests sent to the named data source, and zero
+// removes the cap. It is safe to call from other goroutines, and the requests already sent to
+// the data source count towards the new cap.
+func (e *Enumeration) SetSourceRequestCap(name string, max int) error {
+	if max < 0 {
+		return fmt.Errorf("the request cap of %s cannot be negative: %d", name, max)
+	}
+
+	e.setLock.Lock()
+	defer e.setLock.Unlock()
+	// the map is replaced, since a copy of the field may be held by the caller
+	caps := make(map[string]int, len(e.SourceRequestCaps)+1)
+	for k, v := range e.SourceRequestCaps {
+		caps[k] = v
+	}
+	caps[name] = max
+	e.SourceRequestCaps = caps
+	return nil
+}
+
+// sourceRequestCap returns the cap on the requests sent to the named data source.
+func (e *Enumeration) sourceRequestCap(name string) (int, bool) {
+	e.setLock.RLock()
+	defer e.setLock.RUnlock()
+
+	max, found := e.SourceRequestCaps[name]
+	return max, found
+}
+
+// PauseDataSources holds the requests to the data sources, such as to save the API quotas, while
+// the DNS resolution, brute forcing and recursion of the known names continue. The requests already
+// sent are completed. The held requests are dropped when the enumeration ends before being resumed.
+// It is safe to call from other goroutines.
+func (e *Enumeration) PauseDataSources() {
+	e.setLock.Lock()
+	defer e.setLock.Unlock()
+
+	if !e.srcPause {
+		e.srcPause = true
+		e.log().Infof("Data sources: requests are paused")
+	}
+}
+
+// ResumeDataSources releases the requests held by PauseDataSources to the data sources. It is safe
+// to call from other goroutines.
+func (e *Enumeration) ResumeDataSources() {
+	e.setLock.Lock()
+	defer e.setLock.Unlock()
+
+	if !e.srcPause {
+		return
+	}
+	e.srcPause = false
+	e.log().Infof("Data sources: requests are resumed")
+
+	select {
+	case e.resume <- struct{}{}:
+	default:
+	}
+}
+
+// dataSourcesPaused returns true when the requests to the data sources are held by PauseDataSources.
+func (e *Enumeration) dataSourcesPaused() bool {
+	e.setLock.RLock()
+	defer e.setLock.RUnlock()
+
+	return e.srcPause
+}
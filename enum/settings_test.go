@@ -0,0 +1,108 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caffix/queue"
+	"github.com/caffix/service"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestRuntimeSettings(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{
+		Config:            cfg,
+		SourceRequestCaps: map[string]int{"crtsh": 5},
+	}
+
+	if err := e.AddDomain("OWASP.net."); err != nil || !cfg.IsDomainInScope("www.owasp.net") {
+		t.Errorf("the domain was not added to the scope: %v", err)
+	}
+	for _, d := range []string{"owasp.org", "", "localhost"} {
+		if err := e.AddDomain(d); err == nil {
+			t.Errorf("the domain %q was accepted", d)
+		}
+	}
+
+	if err := e.BlacklistSubdomain("Dev.OWASP.org"); err != nil {
+		t.Errorf("failed to blacklist the subdomain: %v", err)
+	}
+	if !e.blacklisted("www.dev.owasp.org") || e.blacklisted("www.owasp.org") {
+		t.Errorf("the runtime blacklist did not match the expected names")
+	}
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 10; i++ {
+		wg.Add(2)
+		go func(max int) {
+			defer wg.Done()
+			_ = e.SetSourceRequestCap("dnsdumpster", max)
+		}(i)
+		go func() {
+			defer wg.Done()
+			_, _ = e.sourceRequestCap("crtsh")
+		}()
+	}
+	wg.Wait()
+
+	if max, found := e.sourceRequestCap("crtsh"); !found || max != 5 {
+		t.Errorf("the existing cap was lost: %d", max)
+	}
+	if _, found := e.sourceRequestCap("dnsdumpster"); !found {
+		t.Errorf("the new cap was not set")
+	}
+	if err := e.SetSourceRequestCap("crtsh", -1); err == nil {
+		t.Errorf("the negative cap was accepted")
+	}
+}
+
+func TestPauseDataSources(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	src := newTestSource("Inventory")
+	if err := src.Start(); err != nil {
+		t.Fatalf("failed to start the data source: %v", err)
+	}
+	defer func() { _ = src.Stop() }()
+
+	e := &Enumeration{
+		Config:   cfg,
+		srcs:     []service.Service{src},
+		requests: queue.NewQueue(),
+		limited:  make(chan string, 10),
+		resume:   make(chan struct{}, 1),
+	}
+	var cancel context.CancelFunc
+	e.ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	e.done = make(chan struct{})
+	defer close(e.done)
+	go e.manageDataSrcRequests()
+
+	e.PauseDataSources()
+	e.sendRequests(&requests.DNSRequest{Name: "owasp.org", Domain: "owasp.org"})
+	select {
+	case in := <-src.Input():
+		t.Errorf("the data source received a request while paused: %v", in)
+	case <-time.After(250 * time.Millisecond):
+	}
+
+	e.ResumeDataSources()
+	select {
+	case <-e.ctx.Done():
+		t.Errorf("the held request was not released")
+	case in := <-src.Input():
+		if req, ok := in.(*requests.DNSRequest); !ok || req.Domain != "owasp.org" {
+			t.Errorf("the data source received an unexpected request: %v", in)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+// sinkholeThreshold is the number of sinkholed answers from a resolver after which
+// the resolver is avoided by the queries sent without the pools.
+const sinkholeThreshold = 3
+
+// sinkholes discards the answers consisting solely of the SinkholeAddresses, and
+// counts the sinkholed answers returned by each resolver.
+type sinkholes struct {
+	sync.Mutex
+	enum   *Enumeration
+	nets   []*net.IPNet
+	counts map[string]int
+}
+
+// newSinkholes parses the SinkholeAddresses, which are IP addresses or CIDRs.
+func newSinkholes(e *Enumeration) (*sinkholes, error) {
+	s := &sinkholes{
+		enum:   e,
+		counts: make(map[string]int),
+	}
+
+	for _, addr := range e.SinkholeAddresses {
+		addr = strings.TrimSpace(addr)
+		if ip := net.ParseIP(addr); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			s.nets = append(s.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, cidr, err := net.ParseCIDR(addr)
+		if err != nil {
+			return nil, fmt.Errorf("the sinkhole address %s is not a valid IP address or CIDR", addr)
+		}
+		s.nets = append(s.nets, cidr)
+	}
+	return s, nil
+}
+
+func (s *sinkholes) contains(ip net.IP) bool {
+	for _, cidr := range s.nets {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// sinkholed returns true when the answer has address records, and all of them carry sinkhole addresses.
+func (s *sinkholes) sinkholed(resp *dns.Msg) bool {
+	var found bool
+
+	for _, rr := range resp.Answer {
+		var ip net.IP
+
+		switch v := rr.(type) {
+		case *dns.A:
+			ip = v.A
+		case *dns.AAAA:
+			ip = v.AAAA
+		default:
+			continue
+		}
+		if !s.contains(ip) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// check discards the answer when it consists solely of sinkhole addresses, by turning the response
+// into a SERVFAIL so the query is retried, and counts it for the resolver. The responses received
+// through the pools are accounted to the "trusted pool" and "untrusted pool", like the latencies.
+func (s *sinkholes) check(resolver string, resp *dns.Msg) bool {
+	if resp == nil || resp.Rcode != dns.RcodeSuccess || !s.sinkholed(resp) {
+		return false
+	}
+
+	s.Lock()
+	s.counts[resolver]++
+	n := s.counts[resolver]
+	s.Unlock()
+
+	if n == sinkholeThreshold {
+		s.enum.log().Warnf("The resolver %s repeatedly answered with sinkhole addresses", resolver)
+	}
+
+	resp.Rcode = dns.RcodeServerFailure
+	resp.Answer = nil
+	return true
+}
+
+// avoid removes the resolvers that repeatedly answered with sinkhole addresses, unless all of them did.
+func (s *sinkholes) avoid(addrs []string) []string {
+	s.Lock()
+	defer s.Unlock()
+
+	var kept []string
+	for _, addr := range addrs {
+		hostport := addr
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			hostport = net.JoinHostPort(addr, "53")
+		}
+		if s.counts[hostport] < sinkholeThreshold {
+			kept = append(kept, addr)
+		}
+	}
+
+	if len(kept) == 0 {
+		return addrs
+	}
+	return kept
+}
+
+// SinkholeCounts returns the number of answers consisting solely of the SinkholeAddresses
+// returned by each resolver, which were discarded. It can be called while the enumeration is running.
+func (e *Enumeration) SinkholeCounts() map[string]int {
+	counts := make(map[string]int)
+	if e.sinkhole == nil {
+		return counts
+	}
+
+	e.sinkhole.Lock()
+	defer e.sinkhole.Unlock()
+
+	for resolver, n := range e.sinkhole.counts {
+		counts[resolver] = n
+	}
+	return counts
+}
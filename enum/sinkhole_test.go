@@ -0,0 +1,114 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func startSinkholeServer(t *testing.T, addr func(name string) string) (string, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on a TCP port: %v", err)
+	}
+
+	stop := serveMockDNS(t, l, nil, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		name := req.Question[0].Name
+		m.Answer = append(m.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+			A:   net.ParseIP(addr(name)),
+		})
+		_ = w.WriteMsg(m)
+	})
+	return l.Addr().String(), stop
+}
+
+func TestSinkholeAddresses(t *testing.T) {
+	// the resolver sinkholes the names of the ads, and answers the other queries
+	sinkholer, stop := startSinkholeServer(t, func(name string) string {
+		if strings.HasPrefix(name, "ads.") {
+			return "198.51.100.1"
+		}
+		return "192.0.2.1"
+	})
+	defer stop()
+	honest, stop := startSinkholeServer(t, func(name string) string { return "192.0.2.9" })
+	defer stop()
+
+	cfg := config.NewConfig()
+	cfg.Resolvers = []string{sinkholer}
+	// the queries sent without the pools are paced to the QPS of the resolvers
+	cfg.ResolversQPS, cfg.TrustedQPS = 1000, 1000
+	e := &Enumeration{
+		Config:            cfg,
+		Sys:               &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: resolve.NewResolvers()},
+		ForceTCP:          true,
+		SinkholeAddresses: []string{"198.51.100.0/24", "0.0.0.0"},
+	}
+	defer e.Sys.Resolvers().Stop()
+	defer e.Sys.TrustedResolvers().Stop()
+
+	sinkhole, err := newSinkholes(e)
+	if err != nil {
+		t.Fatalf("failed to parse the sinkhole addresses: %v", err)
+	}
+	e.sinkhole = sinkhole
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := func(name string) *dns.Msg {
+		resp, err := e.queryBlocking(ctx, resolve.QueryMsg(name, dns.TypeA), e.Sys.Resolvers())
+		if err != nil {
+			t.Fatalf("the query for %s failed: %v", name, err)
+		}
+		return resp
+	}
+
+	for i := 0; i < sinkholeThreshold; i++ {
+		if resp := query("ads.owasp.org"); resp.Rcode != dns.RcodeServerFailure || len(resp.Answer) > 0 {
+			t.Errorf("the sinkholed answer was not discarded: %v", resp)
+		}
+	}
+	if resp := query("www.owasp.org"); resp.Rcode != dns.RcodeSuccess || len(resp.Answer) != 1 {
+		t.Errorf("the answer without sinkhole addresses was discarded: %v", resp)
+	}
+
+	// the resolver returning sinkholes is avoided once another resolver is available
+	cfg.Resolvers = []string{sinkholer, honest}
+	for i := 0; i < 10; i++ {
+		resp := query("ads.owasp.org")
+		if resp.Rcode != dns.RcodeSuccess || len(resp.Answer) != 1 || resp.Answer[0].(*dns.A).A.String() != "192.0.2.9" {
+			t.Fatalf("the query was not sent to the resolver without sinkholes: %v", resp)
+		}
+	}
+
+	expected := map[string]int{sinkholer: sinkholeThreshold}
+	if counts := e.SinkholeCounts(); !reflect.DeepEqual(counts, expected) {
+		t.Errorf("expected the sinkhole counts %v, but got %v", expected, counts)
+	}
+}
+
+func TestSinkholeAddressesInvalid(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	e := &Enumeration{Config: cfg, PipelineBufferSize: 1, SinkholeAddresses: []string{"sinkhole.owasp.org"}}
+
+	if err := e.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "sinkhole address") {
+		t.Errorf("the enumeration started with an invalid sinkhole address: %v", err)
+	}
+}
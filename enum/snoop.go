@@ -0,0 +1,25 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import "github.com/miekg/dns"
+
+// cacheSnoopSource is the source of the names resolved from the cache of the resolvers.
+const cacheSnoopSource = "Cache Snoop"
+
+// cacheReferral returns true when the resolver answered the non-recursive query with a
+// referral, which indicates the name was not present in the cache of the resolver.
+func cacheReferral(resp *dns.Msg) bool {
+	if resp.Rcode != dns.RcodeSuccess || len(resp.Answer) > 0 {
+		return false
+	}
+
+	for _, rr := range resp.Ns {
+		if rr.Header().Rrtype == dns.TypeNS {
+			return true
+		}
+	}
+	return false
+}
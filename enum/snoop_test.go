@@ -0,0 +1,52 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestCacheReferral(t *testing.T) {
+	ns := &dns.NS{
+		Hdr: dns.RR_Header{Name: "org.", Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: 3600},
+		Ns:  "a0.org.afilias-nst.info.",
+	}
+	soa := &dns.SOA{
+		Hdr: dns.RR_Header{Name: "owasp.org.", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 3600},
+		Ns:  "ns1.owasp.org.",
+	}
+	a := &dns.A{
+		Hdr: dns.RR_Header{Name: "www.owasp.org.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+		A:   []byte{192, 0, 2, 1},
+	}
+
+	for _, test := range []struct {
+		label    string
+		rcode    int
+		answer   []dns.RR
+		ns       []dns.RR
+		expected bool
+	}{
+		{"cache hit", dns.RcodeSuccess, []dns.RR{a}, nil, false},
+		{"cache hit with authority", dns.RcodeSuccess, []dns.RR{a}, []dns.RR{ns}, false},
+		{"referral", dns.RcodeSuccess, nil, []dns.RR{ns}, true},
+		{"cached negative answer", dns.RcodeSuccess, nil, []dns.RR{soa}, false},
+		{"refused", dns.RcodeRefused, nil, []dns.RR{ns}, false},
+		{"empty", dns.RcodeSuccess, nil, nil, false},
+	} {
+		resp := new(dns.Msg)
+		resp.SetReply(resolve.QueryMsg("www.owasp.org", dns.TypeA))
+		resp.Rcode = test.rcode
+		resp.Answer = test.answer
+		resp.Ns = test.ns
+
+		if got := cacheReferral(resp); got != test.expected {
+			t.Errorf("%s: expected %t, but got %t", test.label, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+// SOASerialChange is a zone with a SOA serial that changed between the enumerations.
+type SOASerialChange struct {
+	Zone     string
+	Previous uint32
+	Current  uint32
+}
+
+// soaSerialRun is the entry of the SOASerialFile for each enumeration, keyed by the event UUID.
+type soaSerialRun struct {
+	Time    time.Time         `json:"time"`
+	Serials map[string]uint32 `json:"serials"`
+}
+
+// soaSerials keeps the SOA serial of each zone seen by the enumeration, and the serials of the
+// previous enumerations read from the SOASerialFile.
+type soaSerials struct {
+	sync.Mutex
+	enum    *Enumeration
+	path    string
+	port    string
+	uuid    string
+	start   time.Time
+	runs    map[string]*soaSerialRun
+	serials map[string]uint32
+}
+
+func newSOASerials(e *Enumeration, path string) (*soaSerials, error) {
+	s := &soaSerials{
+		enum:    e,
+		path:    path,
+		port:    "53",
+		uuid:    uuid.NewString(),
+		start:   time.Now().UTC(),
+		runs:    make(map[string]*soaSerialRun),
+		serials: make(map[string]uint32),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the SOA serial file %s: %v", path, err)
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &s.runs); err != nil {
+			return nil, fmt.Errorf("failed to parse the SOA serial file %s: %v", path, err)
+		}
+	}
+	return s, nil
+}
+
+// record keeps the serial of the zone from the SOA answers of the response. When the answers carry
+// different serials, the serial of the primary master named by the MNAME is queried directly, and
+// the most recent serial of the answers is kept when the primary master does not answer.
+func (s *soaSerials) record(ctx context.Context, zone string, resp *dns.Msg) {
+	zone = strings.ToLower(resolve.RemoveLastDot(zone))
+
+	var answers []*dns.SOA
+	for _, rr := range resp.Answer {
+		if soa, ok := rr.(*dns.SOA); ok && strings.EqualFold(soa.Hdr.Name, dns.Fqdn(zone)) {
+			answers = append(answers, soa)
+		}
+	}
+	if len(answers) == 0 {
+		return
+	}
+
+	serial := answers[0].Serial
+	var differ bool
+	for _, soa := range answers[1:] {
+		if soa.Serial != serial {
+			differ = true
+		}
+		if serialNewer(soa.Serial, serial) {
+			serial = soa.Serial
+		}
+	}
+	if differ {
+		if primary, ok := s.primarySerial(ctx, zone, answers); ok {
+			serial = primary
+		}
+	}
+
+	s.Lock()
+	s.serials[zone] = serial
+	s.Unlock()
+}
+
+// primarySerial queries the primary masters named by the SOA answers directly for the serial of the zone.
+func (s *soaSerials) primarySerial(ctx context.Context, zone string, answers []*dns.SOA) (uint32, bool) {
+	network := "udp"
+	// UDP is not sent through the SOCKS5 proxy
+	if s.enum.tcpOnly() {
+		network = "tcp"
+	}
+
+	trusted := s.enum.Sys.TrustedResolvers()
+	masters := make(map[string]struct{})
+	for _, soa := range answers {
+		host := strings.ToLower(resolve.RemoveLastDot(soa.Ns))
+		if _, found := masters[host]; found {
+			continue
+		}
+		masters[host] = struct{}{}
+
+		resp, err := s.enum.queryBlocking(ctx, resolve.QueryMsg(host, dns.TypeA), trusted)
+		if err != nil || resp == nil {
+			continue
+		}
+
+		for _, rr := range resolve.AnswersByType(resolve.ExtractAnswers(resp), dns.TypeA) {
+			msg := resolve.QueryMsg(zone, dns.TypeSOA)
+			s.enum.setEDNSBufferSize(msg)
+			msg.RecursionDesired = false
+
+			resp, err := s.enum.exchange(ctx, network, net.JoinHostPort(rr.Data, s.port), msg)
+			if err == nil && authoritativeSOA(resp, zone) {
+				return zoneSOA(resp, zone).Serial, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// serialNewer returns true when the serial a follows the serial b, using the serial number
+// arithmetic of RFC 1982, so the serials wrapping around are still ordered.
+func serialNewer(a, b uint32) bool {
+	return a != b && int32(a-b) > 0
+}
+
+// write adds the serials of this enumeration to the file under its event UUID, and replaces the
+// file atomically, through a temporary file renamed over it.
+func (s *soaSerials) write() error {
+	s.Lock()
+	defer s.Unlock()
+
+	run := &soaSerialRun{Time: s.start, Serials: make(map[string]uint32, len(s.serials))}
+	for zone, serial := range s.serials {
+		run.Serials[zone] = serial
+	}
+
+	runs := make(map[string]*soaSerialRun, len(s.runs)+1)
+	for id, r := range s.runs {
+		runs[id] = r
+	}
+	runs[s.uuid] = run
+
+	data, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// SOASerialChanges compares the SOA serials of the zones seen by this enumeration with those of the
+// previous enumeration identified by prevUUID in the SOASerialFile, and returns the zones with a
+// changed serial sorted by zone. The zones seen by only one of the enumerations are not compared.
+func (e *Enumeration) SOASerialChanges(prevUUID string) ([]SOASerialChange, error) {
+	if e.soaser == nil {
+		return nil, errors.New("the SOA serials are only tracked when the SOASerialFile has been set")
+	}
+
+	e.soaser.Lock()
+	defer e.soaser.Unlock()
+
+	prev, found := e.soaser.runs[prevUUID]
+	if !found {
+		return nil, fmt.Errorf("the enumeration %s was not found in the SOA serial file %s", prevUUID, e.soaser.path)
+	}
+
+	var changes []SOASerialChange
+	for zone, serial := range e.soaser.serials {
+		if p, found := prev.Serials[zone]; found && p != serial {
+			changes = append(changes, SOASerialChange{
+				Zone:     zone,
+				Previous: p,
+				Current:  serial,
+			})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Zone < changes[j].Zone })
+	return changes, nil
+}
+
+// SOASerialUUID returns the event UUID keeping the SOA serials of this enumeration in the SOASerialFile,
+// to be provided to SOASerialChanges by the following enumerations.
+func (e *Enumeration) SOASerialUUID() string {
+	if e.soaser == nil {
+		return ""
+	}
+	return e.soaser.uuid
+}
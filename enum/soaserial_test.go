@@ -0,0 +1,150 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func soaResponse(zone string, serials ...uint32) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(zone), dns.TypeSOA)
+	for _, serial := range serials {
+		m.Answer = append(m.Answer, &dns.SOA{
+			Hdr:    dns.RR_Header{Name: dns.Fqdn(zone), Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 300},
+			Ns:     "ns1." + dns.Fqdn(zone),
+			Mbox:   "hostmaster." + dns.Fqdn(zone),
+			Serial: serial,
+		})
+	}
+	return m
+}
+
+func TestSOASerialChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "serials.json")
+	e := &Enumeration{Config: config.NewConfig()}
+
+	first, err := newSOASerials(e, path)
+	if err != nil {
+		t.Fatalf("Failed to create the SOA serials: %v", err)
+	}
+	first.record(context.Background(), "owasp.org.", soaResponse("owasp.org", 2023010101))
+	first.record(context.Background(), "example.com", soaResponse("example.com", 7))
+	if err := first.write(); err != nil {
+		t.Fatalf("Failed to write the SOA serial file: %v", err)
+	}
+
+	second, err := newSOASerials(e, path)
+	if err != nil {
+		t.Fatalf("Failed to read the SOA serial file: %v", err)
+	}
+	second.record(context.Background(), "owasp.org", soaResponse("owasp.org", 2023010102))
+	second.record(context.Background(), "example.com", soaResponse("example.com", 7))
+	second.record(context.Background(), "example.net", soaResponse("example.net", 1))
+	e.soaser = second
+
+	changes, err := e.SOASerialChanges(first.uuid)
+	if err != nil {
+		t.Fatalf("Failed to compare the SOA serials: %v", err)
+	}
+	if len(changes) != 1 || changes[0] != (SOASerialChange{Zone: "owasp.org", Previous: 2023010101, Current: 2023010102}) {
+		t.Errorf("unexpected SOA serial changes: %+v", changes)
+	}
+	if _, err := e.SOASerialChanges("unknown"); err == nil {
+		t.Errorf("the SOA serials were compared with an unknown enumeration")
+	}
+
+	// the runs of the file are kept when the serials of the second enumeration are added
+	if err := second.write(); err != nil {
+		t.Fatalf("Failed to write the SOA serial file: %v", err)
+	}
+	third, err := newSOASerials(e, path)
+	if err != nil {
+		t.Fatalf("Failed to read the SOA serial file: %v", err)
+	}
+	if len(third.runs) != 2 || third.runs[second.uuid].Serials["example.net"] != 1 {
+		t.Errorf("unexpected runs in the SOA serial file: %+v", third.runs)
+	}
+}
+
+func TestSOASerialFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "serials.json")
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatalf("Failed to write the file: %v", err)
+	}
+
+	if _, err := newSOASerials(&Enumeration{}, path); err == nil {
+		t.Errorf("the invalid SOA serial file was accepted")
+	}
+}
+
+func TestSOASerialPrimaryMaster(t *testing.T) {
+	// the primary master ns1.owasp.org answers the SOA of the zone with its own serial
+	addr := startMockNameserver(t, "127.0.0.1:0", 2023010105, "192.0.2.1")
+	_, port, _ := net.SplitHostPort(addr)
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP: true,
+	}
+	defer e.Sys.Resolvers().Stop()
+
+	s, err := newSOASerials(e, filepath.Join(t.TempDir(), "serials.json"))
+	if err != nil {
+		t.Fatalf("Failed to create the SOA serials: %v", err)
+	}
+	s.port = port
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s.record(ctx, "owasp.org", soaResponse("owasp.org", 2023010101, 2023010109))
+	if serial := s.serials["owasp.org"]; serial != 2023010105 {
+		t.Errorf("expected the serial 2023010105 of the primary master, but got %d", serial)
+	}
+
+	// the most recent serial is kept when the primary master does not answer
+	s.port = "1"
+	s.record(ctx, "owasp.org", soaResponse("owasp.org", 4294967295, 3))
+	if serial := s.serials["owasp.org"]; serial != 3 {
+		t.Errorf("expected the serial 3 following the wrap around, but got %d", serial)
+	}
+}
+
+func TestSerialNewer(t *testing.T) {
+	cases := []struct {
+		a, b uint32
+		want bool
+	}{
+		{2, 1, true},
+		{1, 2, false},
+		{1, 1, false},
+		{0, 4294967295, true},
+		{4294967295, 0, false},
+	}
+
+	for _, c := range cases {
+		if got := serialNewer(c.a, c.b); got != c.want {
+			t.Errorf("serialNewer(%d, %d) = %v, expected %v", c.a, c.b, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+// maxSPFLookups is the limit of RFC 7208 on the terms causing DNS lookups during the evaluation of a policy.
+const maxSPFLookups = 10
+
+// SPFExpansion is the result of following the include chain of the SPF record for a domain.
+type SPFExpansion struct {
+	Domain string
+	// Domains referenced by the include, redirect, a, mx, ptr and exists terms along the chain
+	Domains []string
+	// Networks authorized by the ip4 and ip6 mechanisms along the chain
+	Networks []string
+	// Lookups is the number of terms causing DNS lookups, counted until the limit was exceeded
+	Lookups int
+	// ExceedsLimit is true when the policy requires more than the 10 DNS lookups allowed by
+	// RFC 7208, so the receivers evaluate it to a permanent error
+	ExceedsLimit bool
+}
+
+type spfExpander struct {
+	enum *Enumeration
+	exp  *SPFExpansion
+	seen map[string]struct{}
+}
+
+// ExpandedSPF returns a copy of the SPF expansion for the domain, or nil when ExpandSPF
+// was not set or the domain has no SPF record.
+func (e *Enumeration) ExpandedSPF(domain string) *SPFExpansion {
+	e.spfLock.Lock()
+	defer e.spfLock.Unlock()
+
+	exp, found := e.spf[strings.ToLower(domain)]
+	if !found {
+		return nil
+	}
+
+	c := *exp
+	c.Domains = append([]string(nil), exp.Domains...)
+	c.Networks = append([]string(nil), exp.Networks...)
+	return &c
+}
+
+// expandSPF follows the include and redirect terms of the SPF record for the domain, within the
+// lookup limit of RFC 7208, and feeds the referenced domains within the scope into the pipeline.
+func (e *Enumeration) expandSPF(ctx context.Context, domain, record string) *SPFExpansion {
+	domain = strings.ToLower(domain)
+	x := &spfExpander{
+		enum: e,
+		exp:  &SPFExpansion{Domain: domain},
+		seen: map[string]struct{}{domain: {}},
+	}
+	x.expand(ctx, domain, record)
+
+	if x.exp.ExceedsLimit {
+		e.log().Warnf("SPF: the record of %s requires more than the %d DNS lookups allowed by RFC 7208", domain, maxSPFLookups)
+	}
+	for _, name := range x.exp.Domains {
+		if d := strings.ToLower(e.Config.WhichDomain(name)); d != "" && e.nameSrc != nil {
+			e.nameSrc.newNameWithoutWait(&requests.DNSRequest{
+				Name:   name,
+				Domain: d,
+			})
+		}
+	}
+
+	e.spfLock.Lock()
+	if e.spf == nil {
+		e.spf = make(map[string]*SPFExpansion)
+	}
+	e.spf[domain] = x.exp
+	e.spfLock.Unlock()
+	return x.exp
+}
+
+func (x *spfExpander) expand(ctx context.Context, name, record string) {
+	terms := strings.Fields(strings.ToLower(strings.Trim(record, "\"")))
+	if len(terms) == 0 || terms[0] != "v=spf1" {
+		return
+	}
+
+	for _, term := range terms[1:] {
+		mech, value := parseSPFTerm(term)
+
+		switch mech {
+		case "ip4", "ip6":
+			if value != "" {
+				x.exp.Networks = appendUnique(x.exp.Networks, value)
+			}
+		case "include", "redirect":
+			if !x.lookup() {
+				return
+			}
+			if value == "" || strings.Contains(value, "%") {
+				continue
+			}
+			x.exp.Domains = appendUnique(x.exp.Domains, value)
+
+			if _, found := x.seen[value]; found {
+				continue
+			}
+			x.seen[value] = struct{}{}
+			if rec := x.record(ctx, value); rec != "" {
+				x.expand(ctx, value, rec)
+			}
+			if x.exp.ExceedsLimit {
+				return
+			}
+		case "a", "mx", "ptr", "exists":
+			if !x.lookup() {
+				return
+			}
+			if value != "" && !strings.Contains(value, "%") {
+				x.exp.Domains = appendUnique(x.exp.Domains, value)
+			}
+		}
+	}
+}
+
+// lookup counts a term causing a DNS lookup, and returns false once the limit has been exceeded.
+func (x *spfExpander) lookup() bool {
+	x.exp.Lookups++
+	if x.exp.Lookups > maxSPFLookups {
+		x.exp.Lookups = maxSPFLookups
+		x.exp.ExceedsLimit = true
+		return false
+	}
+	return true
+}
+
+// record returns the SPF record published in the TXT records of the name.
+func (x *spfExpander) record(ctx context.Context, name string) string {
+	resp, err := x.enum.dnsQuery(ctx, name, dns.TypeTXT, x.enum.Sys.TrustedResolvers(), maxDNSQueryAttempts)
+	if err != nil || resp == nil {
+		return ""
+	}
+
+	for _, rr := range resolve.AnswersByType(resolve.ExtractAnswers(resp), dns.TypeTXT) {
+		if requests.ClassifyTXT(name, rr.Data) == requests.SPF {
+			return rr.Data
+		}
+	}
+	return ""
+}
+
+// parseSPFTerm returns the mechanism or modifier of the term, without the qualifier,
+// and its domain or network, without the CIDR lengths of the a and mx mechanisms.
+func parseSPFTerm(term string) (string, string) {
+	term = strings.TrimLeft(term, "+-~?")
+
+	mech, value := term, ""
+	if i := strings.IndexAny(term, ":="); i >= 0 {
+		mech, value = term[:i], term[i+1:]
+	} else if i := strings.Index(term, "/"); i >= 0 {
+		mech = term[:i]
+	}
+
+	switch mech {
+	case "a", "mx":
+		if i := strings.Index(value, "/"); i >= 0 {
+			value = value[:i]
+		}
+	}
+	return mech, strings.TrimSuffix(value, ".")
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, item := range list {
+		if item == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,120 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestParseSPFTerm(t *testing.T) {
+	for _, test := range []struct {
+		term  string
+		mech  string
+		value string
+	}{
+		{"include:_spf.owasp.org", "include", "_spf.owasp.org"},
+		{"~all", "all", ""},
+		{"-ip4:192.0.2.0/24", "ip4", "192.0.2.0/24"},
+		{"ip6:2001:db8::/32", "ip6", "2001:db8::/32"},
+		{"a", "a", ""},
+		{"a/24", "a", ""},
+		{"mx:mail.owasp.org/24//64", "mx", "mail.owasp.org"},
+		{"redirect=_spf.owasp.org.", "redirect", "_spf.owasp.org"},
+	} {
+		if mech, value := parseSPFTerm(test.term); mech != test.mech || value != test.value {
+			t.Errorf("%s: expected %s and %s, but got %s and %s", test.term, test.mech, test.value, mech, value)
+		}
+	}
+}
+
+func TestExpandSPF(t *testing.T) {
+	addr := startSPFServer(t, map[string]string{
+		"owasp.org.":             "v=spf1 include:_spf.owasp.org include:_spf.example.com a:mail.owasp.org -all",
+		"_spf.owasp.org.":        "v=spf1 ip4:192.0.2.0/24 include:_spf.owasp.org ~all",
+		"_spf.example.com.":      "v=spf1 ip6:2001:db8::/32 redirect=_spf2.example.com",
+		"_spf2.example.com.":     "v=spf1 ip4:198.51.100.1 -all",
+		"toomany.owasp.org.":     "v=spf1 include:_a.toomany.owasp.org include:_b.toomany.owasp.org -all",
+		"_a.toomany.owasp.org.":  "v=spf1 a:a1.example.com a:a2.example.com a:a3.example.com a:a4.example.com -all",
+		"_b.toomany.owasp.org.":  "v=spf1 mx:b1.example.com mx:b2.example.com mx:b3.example.com mx:b4.example.com mx:b5.example.com -all",
+		"unrelated.example.com.": "some other TXT record",
+	})
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP: true,
+	}
+	defer e.Sys.Resolvers().Stop()
+	e.nameSrc = newTestEnumSource(e, 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	e.expandSPF(ctx, "owasp.org", "v=spf1 include:_spf.owasp.org include:_spf.example.com a:mail.owasp.org -all")
+	exp := e.ExpandedSPF("owasp.org")
+	if exp == nil {
+		t.Fatalf("the SPF expansion of owasp.org was not kept")
+	}
+	if expected := []string{"_spf.owasp.org", "_spf.example.com", "_spf2.example.com", "mail.owasp.org"}; !reflect.DeepEqual(exp.Domains, expected) {
+		t.Errorf("expected the referenced domains %v, but got %v", expected, exp.Domains)
+	}
+	if expected := []string{"192.0.2.0/24", "2001:db8::/32", "198.51.100.1"}; !reflect.DeepEqual(exp.Networks, expected) {
+		t.Errorf("expected the authorized networks %v, but got %v", expected, exp.Networks)
+	}
+	// the include loop of _spf.owasp.org is counted once more without being followed
+	if exp.Lookups != 5 || exp.ExceedsLimit {
+		t.Errorf("expected 5 lookups within the limit, but got %d and %t", exp.Lookups, exp.ExceedsLimit)
+	}
+	// the referenced domains within the scope are resolved
+	if n := e.nameSrc.queue.Len(); n != 2 {
+		t.Errorf("expected 2 names to be resolved, but got %d", n)
+	}
+
+	exp = e.expandSPF(ctx, "toomany.owasp.org", "v=spf1 include:_a.toomany.owasp.org include:_b.toomany.owasp.org -all")
+	if !exp.ExceedsLimit || exp.Lookups != maxSPFLookups {
+		t.Errorf("expected the record to exceed the limit, but got %d lookups and %t", exp.Lookups, exp.ExceedsLimit)
+	}
+	if n := len(exp.Domains); n != maxSPFLookups {
+		t.Errorf("expected %d referenced domains before the limit, but got %d", maxSPFLookups, n)
+	}
+
+	if e.ExpandedSPF("unrelated.example.com") != nil {
+		t.Errorf("an expansion was returned for a domain that was not expanded")
+	}
+}
+
+// startSPFServer returns the address of a DNS server answering the TXT queries with the records.
+func startSPFServer(t *testing.T, records map[string]string) string {
+	return startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		name := strings.ToLower(req.Question[0].Name)
+		if txt, found := records[name]; !found {
+			m.Rcode = dns.RcodeNameError
+		} else if req.Question[0].Qtype == dns.TypeTXT {
+			m.Answer = append(m.Answer, &dns.TXT{
+				Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 300},
+				Txt: []string{txt},
+			})
+		}
+		_ = w.WriteMsg(m)
+	})
+}
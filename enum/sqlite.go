@@ -0,0 +1,133 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	_ "github.com/glebarez/go-sqlite"
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+const sqliteBatchSize int = 500
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS records (
+	name TEXT NOT NULL,
+	type TEXT NOT NULL,
+	data TEXT NOT NULL,
+	source TEXT NOT NULL DEFAULT '',
+	first_seen DATETIME NOT NULL,
+	last_seen DATETIME NOT NULL,
+	UNIQUE(name, type, data)
+);
+CREATE INDEX IF NOT EXISTS records_name_idx ON records(name);
+CREATE INDEX IF NOT EXISTS records_data_idx ON records(data);`
+
+const sqliteUpsert = `
+INSERT INTO records (name, type, data, source, first_seen, last_seen) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(name, type, data) DO UPDATE SET last_seen = excluded.last_seen`
+
+// sqliteOutput writes the resolved DNS records to a SQLite database in batches.
+type sqliteOutput struct {
+	sync.Mutex
+	db    *sql.DB
+	batch []*sqliteRecord
+}
+
+type sqliteRecord struct {
+	Name   string
+	Type   string
+	Data   string
+	Source string
+	Seen   time.Time
+}
+
+// SetSQLiteOutput opens or creates the SQLite database at path and writes each resolved record to it.
+func (e *Enumeration) SetSQLiteOutput(path string) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("failed to open the SQLite database %s: %v", path, err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		_ = db.Close()
+		return fmt.Errorf("failed to create the SQLite schema in %s: %v", path, err)
+	}
+
+	e.sqlite = &sqliteOutput{db: db}
+	e.addOutput("SQLite", e.sqlite)
+	return nil
+}
+
+// write adds the records of the request to the batch, and the database does not keep the source history.
+func (so *sqliteOutput) write(req *requests.DNSRequest, _ []string) error {
+	so.Lock()
+	defer so.Unlock()
+
+	now := time.Now().UTC()
+	for _, rec := range req.Records {
+		rrtype, found := dns.TypeToString[uint16(rec.Type)]
+		if !found {
+			rrtype = fmt.Sprintf("TYPE%d", rec.Type)
+		}
+
+		so.batch = append(so.batch, &sqliteRecord{
+			Name:   rec.Name,
+			Type:   rrtype,
+			Data:   rec.Data,
+			Source: req.Source,
+			Seen:   now,
+		})
+	}
+
+	if len(so.batch) < sqliteBatchSize {
+		return nil
+	}
+	return so.flush()
+}
+
+// flush must be called while holding the lock.
+func (so *sqliteOutput) flush() error {
+	if len(so.batch) == 0 {
+		return nil
+	}
+
+	tx, err := so.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(sqliteUpsert)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range so.batch {
+		if _, err := stmt.Exec(r.Name, r.Type, r.Data, r.Source, r.Seen, r.Seen); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	so.batch = nil
+	return tx.Commit()
+}
+
+func (so *sqliteOutput) stop() error {
+	so.Lock()
+	defer so.Unlock()
+
+	err := so.flush()
+	if e := so.db.Close(); err == nil {
+		err = e
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// checkSourceAddress validates the SourceIP and Interface, and selects the local address the DNS
+// queries are bound to. The address of the Interface is selected when the SourceIP is not provided,
+// with a preference for IPv4, and the SourceIP must be assigned to the Interface when both are set.
+func (e *Enumeration) checkSourceAddress() error {
+	if e.SourceIP == nil && e.Interface == "" {
+		return nil
+	}
+	if e.Dialer != nil {
+		return errors.New("the source address cannot be used with the custom dialer")
+	}
+	if e.SOCKS5Proxy != "" {
+		return errors.New("the source address cannot be used with the SOCKS5 proxy")
+	}
+
+	addrs, err := localAddrs(e.Interface)
+	if err != nil {
+		return err
+	}
+
+	where := "this host"
+	if e.Interface != "" {
+		where = "the network interface " + e.Interface
+	}
+	if e.SourceIP != nil {
+		for _, ip := range addrs {
+			if ip.Equal(e.SourceIP) {
+				e.srcaddr = ip
+				return nil
+			}
+		}
+		return fmt.Errorf("the source IP %s is not assigned to %s", e.SourceIP, where)
+	}
+
+	for _, ip := range addrs {
+		if e.srcaddr == nil || ip.To4() != nil {
+			e.srcaddr = ip
+		}
+		if ip.To4() != nil {
+			break
+		}
+	}
+	if e.srcaddr == nil {
+		return fmt.Errorf("%s does not have assigned IP addresses", where)
+	}
+	return nil
+}
+
+// localAddrs returns the IP addresses assigned to the network interface, or to any interface of the
+// host when the name is empty.
+func localAddrs(name string) ([]net.IP, error) {
+	var addrs []net.Addr
+	var err error
+
+	if name == "" {
+		addrs, err = net.InterfaceAddrs()
+	} else {
+		var iface *net.Interface
+
+		iface, err = net.InterfaceByName(name)
+		if err != nil {
+			return nil, fmt.Errorf("the network interface %s was not found: %v", name, err)
+		}
+		addrs, err = iface.Addrs()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain the local addresses: %v", err)
+	}
+
+	var ips []net.IP
+	for _, addr := range addrs {
+		switch v := addr.(type) {
+		case *net.IPNet:
+			ips = append(ips, v.IP)
+		case *net.IPAddr:
+			ips = append(ips, v.IP)
+		}
+	}
+	return ips, nil
+}
+
+// bound returns true when the DNS queries are bound to a local address, and are then sent
+// without the resolver pools, since the pools bind their own sockets.
+func (e *Enumeration) bound() bool {
+	return e.srcaddr != nil
+}
@@ -0,0 +1,106 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestSourceIP(t *testing.T) {
+	sources := make(chan string, 10)
+	srvAddr := startMockUDPDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		if addr, ok := w.RemoteAddr().(*net.UDPAddr); ok {
+			sources <- addr.IP.String()
+		}
+
+		m := new(dns.Msg)
+		m.SetReply(req)
+		m.Answer = append(m.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: req.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+			A:   net.IP{192, 0, 2, 1},
+		})
+		_ = w.WriteMsg(m)
+	})
+
+	cfg := config.NewConfig()
+	cfg.TrustedResolvers = []string{srvAddr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		SourceIP: net.ParseIP("127.0.0.1"),
+	}
+	defer e.Sys.Resolvers().Stop()
+
+	if err := e.checkSourceAddress(); err != nil {
+		t.Fatalf("the loopback address was not accepted: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// the trusted pool is empty, so the answer must come from the query bound to the source IP
+	resp, err := e.queryBlocking(ctx, resolve.QueryMsg("www.owasp.org", dns.TypeA), trusted)
+	if err != nil || len(resp.Answer) == 0 {
+		t.Fatalf("the query failed: %v", err)
+	}
+	if src := <-sources; src != "127.0.0.1" {
+		t.Errorf("the query was sent from %s instead of the source IP", src)
+	}
+}
+
+func TestSourceAddressInterface(t *testing.T) {
+	iface, err := loopbackInterface()
+	if err != nil {
+		t.Skipf("the loopback interface is not available: %v", err)
+	}
+
+	e := &Enumeration{Config: config.NewConfig(), Interface: iface.Name}
+	if err := e.checkSourceAddress(); err != nil || e.srcaddr == nil || !e.srcaddr.IsLoopback() {
+		t.Errorf("the address of the interface %s was not selected: %v, %v", iface.Name, e.srcaddr, err)
+	}
+
+	e = &Enumeration{Config: config.NewConfig(), Interface: iface.Name, SourceIP: net.ParseIP("192.0.2.123")}
+	if err := e.checkSourceAddress(); err == nil || !strings.Contains(err.Error(), "not assigned") {
+		t.Errorf("the source IP not assigned to the interface was accepted: %v", err)
+	}
+}
+
+func TestSourceAddressInvalid(t *testing.T) {
+	for _, e := range []*Enumeration{
+		{Config: config.NewConfig(), SourceIP: net.ParseIP("192.0.2.123")},
+		{Config: config.NewConfig(), Interface: "amass-missing0"},
+		{Config: config.NewConfig(), SourceIP: net.ParseIP("127.0.0.1"), SOCKS5Proxy: "127.0.0.1:1080"},
+	} {
+		if err := e.checkSourceAddress(); err == nil {
+			t.Errorf("the source IP %v and interface %q were accepted", e.SourceIP, e.Interface)
+		}
+	}
+}
+
+func loopbackInterface() (*net.Interface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range ifaces {
+		if ifaces[i].Flags&net.FlagLoopback != 0 && ifaces[i].Flags&net.FlagUp != 0 {
+			return &ifaces[i], nil
+		}
+	}
+	return nil, net.UnknownNetworkError("loopback")
+}
@@ -0,0 +1,99 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/datasrcs"
+)
+
+// DataSourceStatus reports whether a data source selected by the configuration is able to run.
+type DataSourceStatus struct {
+	Name string
+	Type string
+	// RequiresCredentials is true when the data source checks its configuration before starting
+	RequiresCredentials bool
+	// Configured is true when credentials for the data source are present in the configuration
+	Configured bool
+	// Valid is true when the data source accepted its configuration and is able to start
+	Valid bool
+	// Error explains why the data source declined to start
+	Error string
+}
+
+// credentialChecker is implemented by the data sources that know whether they require credentials.
+type credentialChecker interface {
+	RequiresCredentials() bool
+}
+
+// ValidateDataSources reports, for each data source selected by the configuration, whether it
+// requires credentials and whether they are present and accepted by the check of the data source.
+// The data sources missing their credentials are otherwise left out of the enumeration silently.
+func (e *Enumeration) ValidateDataSources() []DataSourceStatus {
+	var results []DataSourceStatus
+
+	for _, src := range datasrcs.SelectedDataSources(e.Config, datasrcs.GetAllSources(e.Sys)) {
+		status := DataSourceStatus{
+			Name:       src.String(),
+			Type:       src.Description(),
+			Configured: e.credentialsConfigured(src.String()),
+		}
+		if c, ok := src.(credentialChecker); ok {
+			status.RequiresCredentials = c.RequiresCredentials()
+		}
+		// the data sources run their checks when started, and start even when a check fails
+		if err := src.Start(); err != nil {
+			status.Error = err.Error()
+		} else {
+			status.Valid = true
+		}
+		_ = src.Stop()
+
+		results = append(results, status)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
+
+// credentialsConfigured returns true when the configuration has credentials for the named data source.
+func (e *Enumeration) credentialsConfigured(name string) bool {
+	dsc := e.Config.GetDataSourceConfig(name)
+	if dsc == nil {
+		return false
+	}
+
+	for _, c := range dsc.Creds {
+		if c != nil && (c.Username != "" || c.Password != "" || c.Apikey != "" || c.Secret != "") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRequiredCredentials returns an error naming the selected data sources that require
+// credentials and are unable to start, when RequireAllCredentials has been set.
+func (e *Enumeration) checkRequiredCredentials() error {
+	if !e.RequireAllCredentials {
+		return nil
+	}
+
+	var missing []string
+	for _, status := range e.ValidateDataSources() {
+		if status.RequiresCredentials && !status.Valid {
+			missing = append(missing, status.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("the data sources are missing valid credentials: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestValidateDataSources(t *testing.T) {
+	cfg := config.NewConfig()
+	// the scripts are only acquired when the output directory exists
+	cfg.Dir = t.TempDir()
+	cfg.AddDomain("owasp.org")
+	cfg.SourceFilter.Include = true
+	cfg.SourceFilter.Sources = []string{"HackerTarget", "Shodan"}
+	sys := &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: resolve.NewResolvers()}
+	defer sys.Pool.Stop()
+	defer sys.Trusted.Stop()
+
+	e := &Enumeration{Config: cfg, Sys: sys}
+	statuses := e.ValidateDataSources()
+	if len(statuses) != 2 {
+		t.Fatalf("expected the status of 2 data sources, but got %d", len(statuses))
+	}
+	if s := statuses[0]; s.Name != "HackerTarget" || s.RequiresCredentials || !s.Valid {
+		t.Errorf("unexpected status for the data source without credentials: %+v", s)
+	}
+	if s := statuses[1]; s.Name != "Shodan" || !s.RequiresCredentials || s.Configured || s.Valid || s.Error == "" {
+		t.Errorf("unexpected status for the unconfigured data source: %+v", s)
+	}
+
+	e.RequireAllCredentials = true
+	if err := e.checkRequiredCredentials(); err == nil || !strings.Contains(err.Error(), "Shodan") {
+		t.Errorf("the enumeration did not abort for the unconfigured data source: %v", err)
+	}
+
+	cfg.DataSrcConfigs = &config.DataSourceConfig{GlobalOptions: make(map[string]int)}
+	cfg.DataSrcConfigs.Datasources = []*config.DataSource{{
+		Name:  "Shodan",
+		Creds: map[string]*config.Credentials{"account": {Name: "account", Apikey: "key"}},
+	}}
+	statuses = e.ValidateDataSources()
+	if s := statuses[1]; !s.RequiresCredentials || !s.Configured || !s.Valid {
+		t.Errorf("unexpected status for the configured data source: %+v", s)
+	}
+	if err := e.checkRequiredCredentials(); err != nil {
+		t.Errorf("the configured data sources were rejected: %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sourceCheckpointInterval is the period between the writes of the pagination checkpoints.
+const sourceCheckpointInterval = 5 * time.Second
+
+// PaginationCheckpointer is implemented by the data sources able to resume their paginated queries
+// across enumerations. The cursors are opaque to the enumeration, and keyed by the query, such as
+// the domain name. A data source removes the cursor of a query once its pagination has completed.
+type PaginationCheckpointer interface {
+	// PaginationCursors returns the cursors of the queries with a pagination in progress
+	PaginationCursors() map[string]string
+	// RestorePaginationCursors provides the cursors saved by the previous enumeration before
+	// the data source receives any request
+	RestorePaginationCursors(cursors map[string]string)
+}
+
+// unsafeFileChars are the characters of the data source names replaced in the checkpoint file names.
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// sourceCheckpoint is the checkpoint file of a data source, with the cursors last written to it.
+type sourceCheckpoint struct {
+	src     PaginationCheckpointer
+	path    string
+	written map[string]string
+}
+
+// sourceCheckpoints saves the pagination cursors of the data sources in the DataSourceCheckpointDir,
+// with a file for each data source, so the paginations interrupted by a crash resume on restart.
+type sourceCheckpoints struct {
+	sync.Mutex
+	enum  *Enumeration
+	files []*sourceCheckpoint
+}
+
+// newSourceCheckpoints creates the directory, and restores the cursors of the data sources
+// implementing the PaginationCheckpointer from their checkpoint files.
+func newSourceCheckpoints(e *Enumeration) (*sourceCheckpoints, error) {
+	if err := os.MkdirAll(e.DataSourceCheckpointDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create the data source checkpoint directory %s: %v", e.DataSourceCheckpointDir, err)
+	}
+
+	sc := &sourceCheckpoints{enum: e}
+	for _, src := range e.srcs {
+		p, ok := src.(PaginationCheckpointer)
+		if !ok {
+			continue
+		}
+
+		name := unsafeFileChars.ReplaceAllString(strings.ToLower(src.String()), "_")
+		cp := &sourceCheckpoint{
+			src:  p,
+			path: filepath.Join(e.DataSourceCheckpointDir, name+".json"),
+		}
+
+		cursors, err := readSourceCheckpoint(cp.path)
+		if err != nil {
+			return nil, err
+		}
+		if len(cursors) > 0 {
+			p.RestorePaginationCursors(cursors)
+			e.log().Infof("Resuming the pagination of %s for %d queries", src.String(), len(cursors))
+		}
+		cp.written = cursors
+		sc.files = append(sc.files, cp)
+	}
+	return sc, nil
+}
+
+func readSourceCheckpoint(path string) (map[string]string, error) {
+	cursors := make(map[string]string)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cursors, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the data source checkpoint file %s: %v", path, err)
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &cursors); err != nil {
+			return nil, fmt.Errorf("failed to parse the data source checkpoint file %s: %v", path, err)
+		}
+	}
+	return cursors, nil
+}
+
+// run writes the checkpoints periodically until the enumeration is done.
+func (sc *sourceCheckpoints) run(done <-chan struct{}) {
+	t := time.NewTicker(sourceCheckpointInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-t.C:
+			sc.write()
+		}
+	}
+}
+
+// write replaces the checkpoint files of the data sources with cursors that changed since the
+// previous write, and removes the files once the data sources have no pagination in progress.
+func (sc *sourceCheckpoints) write() {
+	sc.Lock()
+	defer sc.Unlock()
+
+	for _, cp := range sc.files {
+		cursors := cp.src.PaginationCursors()
+		if reflect.DeepEqual(cursors, cp.written) || (len(cursors) == 0 && len(cp.written) == 0) {
+			continue
+		}
+
+		var err error
+		if len(cursors) == 0 {
+			if err = os.Remove(cp.path); errors.Is(err, os.ErrNotExist) {
+				err = nil
+			}
+		} else {
+			err = writeSourceCheckpoint(cp.path, cursors)
+		}
+		if err != nil {
+			sc.enum.log().Errorf("Failed to write the data source checkpoint file %s: %v", cp.path, err)
+			continue
+		}
+		cp.written = cursors
+	}
+}
+
+// writeSourceCheckpoint replaces the file atomically, through a temporary file renamed over it.
+func writeSourceCheckpoint(path string, cursors map[string]string) error {
+	data, err := json.MarshalIndent(cursors, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,116 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/caffix/service"
+	"github.com/owasp-amass/config/config"
+)
+
+type paginatedSource struct {
+	service.BaseService
+	sync.Mutex
+	cursors map[string]string
+}
+
+func newPaginatedSource(name string) *paginatedSource {
+	src := &paginatedSource{cursors: make(map[string]string)}
+	src.BaseService = *service.NewBaseService(src, name)
+	return src
+}
+
+func (p *paginatedSource) PaginationCursors() map[string]string {
+	p.Lock()
+	defer p.Unlock()
+
+	cursors := make(map[string]string, len(p.cursors))
+	for k, v := range p.cursors {
+		cursors[k] = v
+	}
+	return cursors
+}
+
+func (p *paginatedSource) RestorePaginationCursors(cursors map[string]string) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.cursors = cursors
+}
+
+func (p *paginatedSource) set(key, cursor string) {
+	p.Lock()
+	defer p.Unlock()
+
+	if cursor == "" {
+		delete(p.cursors, key)
+	} else {
+		p.cursors[key] = cursor
+	}
+}
+
+func TestSourceCheckpoints(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "checkpoints")
+	src := newPaginatedSource("Paged API")
+	e := &Enumeration{
+		Config:                  config.NewConfig(),
+		DataSourceCheckpointDir: dir,
+		srcs:                    []service.Service{src, newTestSource("Unpaged")},
+	}
+
+	sc, err := newSourceCheckpoints(e)
+	if err != nil {
+		t.Fatalf("failed to create the checkpoints: %v", err)
+	}
+	if len(sc.files) != 1 {
+		t.Fatalf("expected a checkpoint for the paginated source only, but got %d", len(sc.files))
+	}
+
+	path := filepath.Join(dir, "paged_api.json")
+	// the enumeration is interrupted in the middle of the pagination
+	src.set("owasp.org", "7")
+	src.set("example.com", "2")
+	sc.write()
+
+	restored := newPaginatedSource("Paged API")
+	e.srcs = []service.Service{restored}
+	if _, err := newSourceCheckpoints(e); err != nil {
+		t.Fatalf("failed to restore the checkpoints: %v", err)
+	}
+	expected := map[string]string{"owasp.org": "7", "example.com": "2"}
+	if c := restored.PaginationCursors(); !reflect.DeepEqual(c, expected) {
+		t.Errorf("expected the restored cursors %v, but got %v", expected, c)
+	}
+
+	// the file is removed once the paginations have completed
+	src.set("owasp.org", "")
+	src.set("example.com", "")
+	sc.write()
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the checkpoint file remained after the paginations completed: %v", err)
+	}
+}
+
+func TestSourceCheckpointsInvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "paged_api.json"), []byte("not json"), 0644); err != nil {
+		t.Fatalf("failed to write the checkpoint file: %v", err)
+	}
+
+	e := &Enumeration{
+		Config:                  config.NewConfig(),
+		DataSourceCheckpointDir: dir,
+		srcs:                    []service.Service{newPaginatedSource("Paged API")},
+	}
+	if _, err := newSourceCheckpoints(e); err == nil {
+		t.Errorf("the invalid checkpoint file was accepted")
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/datasrcs/scripting"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+// maxSRVPortProbes is the number of SRV record names keyed by port number queried for each subdomain.
+const maxSRVPortProbes = 32
+
+// buildSRVPortProbes prepares the _<port>._tcp and _<port>._udp label prefixes of the SRVPortProbes,
+// skipping the duplicates and the names already queried by the data sources for the SRV services.
+func (e *Enumeration) buildSRVPortProbes() error {
+	seen := make(map[string]struct{})
+	for _, name := range scripting.SRVServiceNames() {
+		seen[name] = struct{}{}
+	}
+
+	e.srvports = nil
+	for _, port := range e.SRVPortProbes {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("the SRV probe port %d is not valid", port)
+		}
+
+		for _, proto := range []string{"tcp", "udp"} {
+			name := "_" + strconv.Itoa(port) + "._" + proto
+			if _, found := seen[name]; found {
+				continue
+			}
+
+			seen[name] = struct{}{}
+			e.srvports = append(e.srvports, name)
+		}
+	}
+
+	if len(e.srvports) > maxSRVPortProbes {
+		e.log().Warnf("SRV probes: only the first %d of %d names are queried for each subdomain", maxSRVPortProbes, len(e.srvports))
+		e.srvports = e.srvports[:maxSRVPortProbes]
+	}
+	return nil
+}
+
+// querySRVPorts obtains the SRV records advertised under the port number names of the subdomain.
+func (dt *dnsTask) querySRVPorts(ctx context.Context, name string, ch chan []requests.DNSAnswer) {
+	var records []requests.DNSAnswer
+
+	for _, prefix := range dt.enum.srvports {
+		select {
+		case <-ctx.Done():
+			ch <- records
+			return
+		default:
+		}
+
+		resp, err := dt.enum.dnsQuery(ctx, prefix+"."+name, dns.TypeSRV, dt.enum.Sys.TrustedResolvers(), maxDNSQueryAttempts)
+		if err != nil {
+			continue
+		}
+		if rr := resolve.AnswersByType(resolve.ExtractAnswers(resp), dns.TypeSRV); len(rr) > 0 {
+			records = append(records, dt.enum.collectAnswers(resp, rr)...)
+		}
+	}
+	ch <- records
+}
@@ -0,0 +1,48 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/datasrcs/scripting"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestBuildSRVPortProbes(t *testing.T) {
+	defer func() { _ = scripting.SetSRVServices(nil) }()
+
+	e := &Enumeration{Config: config.NewConfig()}
+	for _, invalid := range []int{0, -1, 65536} {
+		e.SRVPortProbes = []int{443, invalid}
+		if err := e.buildSRVPortProbes(); err == nil {
+			t.Errorf("the port %d was accepted", invalid)
+		}
+	}
+
+	if err := scripting.SetSRVServices([]scripting.SRVService{{Service: "5060", Proto: "udp"}}); err != nil {
+		t.Fatalf("the SRV services were rejected: %v", err)
+	}
+	e.SRVPortProbes = []int{443, 5060, 443}
+	if err := e.buildSRVPortProbes(); err != nil {
+		t.Fatalf("the ports were rejected: %v", err)
+	}
+	expected := []string{"_443._tcp", "_443._udp", "_5060._tcp"}
+	if !reflect.DeepEqual(e.srvports, expected) {
+		t.Errorf("expected the probes %v, but got %v", expected, e.srvports)
+	}
+
+	e.SRVPortProbes = nil
+	for port := 1; port <= maxSRVPortProbes; port++ {
+		e.SRVPortProbes = append(e.SRVPortProbes, port)
+	}
+	if err := e.buildSRVPortProbes(); err != nil {
+		t.Fatalf("the ports were rejected: %v", err)
+	}
+	if len(e.srvports) != maxSRVPortProbes {
+		t.Errorf("expected %d probes for each subdomain, but got %d", maxSRVPortProbes, len(e.srvports))
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+// The stages of the enumeration reported to the OnStageComplete callback, in the order they complete.
+const (
+	// StageSeeded follows the submission of the ASNs to the data sources and of the root
+	// domains to the pipeline and the data sources
+	StageSeeded = "seeded"
+	// StageNamesSubmitted follows the submission of the names known by the graph databases
+	// and the names provided by the user
+	StageNamesSubmitted = "names_submitted"
+	// StagePipelineDrained follows the end of the pipeline, once no names remain in flight
+	// and the data sources have no pending requests
+	StagePipelineDrained = "pipeline_drained"
+	// StageStored follows the storage of the remaining results in the graph database,
+	// before the outputs are flushed and Start returns
+	StageStored = "stored"
+)
+
+// stageComplete reports the completed stage to the OnStageComplete callback when provided.
+func (e *Enumeration) stageComplete(stage string) {
+	if e.OnStageComplete != nil {
+		e.OnStageComplete(stage)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestOnStageComplete(t *testing.T) {
+	addr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetRcode(req, dns.RcodeNameError)
+		_ = w.WriteMsg(m)
+	})
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.ProvidedNames = []string{"www.owasp.org", "mail.owasp.org"}
+	cfg.Resolvers = []string{addr}
+	// the queries sent without the pools are paced to the QPS of the resolvers
+	cfg.ResolversQPS, cfg.TrustedQPS = 1000, 1000
+	cfg.TrustedResolvers = []string{addr}
+	pool := resolve.NewResolvers()
+	_ = pool.AddResolvers(10, addr)
+	defer pool.Stop()
+	trusted := resolve.NewResolvers()
+	_ = trusted.AddResolvers(10, addr)
+	defer trusted.Stop()
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	src := newTestSource("Inventory")
+	defer func() { _ = src.Stop() }()
+	sys := &systems.SimpleSystem{Cfg: cfg, Pool: pool, Trusted: trusted, Graph: g, Service: src}
+	e := NewEnumeration(cfg, sys, g)
+	e.ForceTCP = true
+	e.MaxDuration = 2 * time.Second
+
+	var lock sync.Mutex
+	var stages []string
+	e.OnStageComplete = func(stage string) {
+		lock.Lock()
+		defer lock.Unlock()
+		stages = append(stages, stage)
+	}
+
+	if err := e.Start(context.Background()); err != nil && err != context.DeadlineExceeded {
+		t.Fatalf("the enumeration failed: %v", err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	expected := []string{StageSeeded, StageNamesSubmitted, StagePipelineDrained, StageStored}
+	if len(stages) != len(expected) {
+		t.Fatalf("expected the stages %v, but got %v", expected, stages)
+	}
+	for i, stage := range expected {
+		if stages[i] != stage {
+			t.Errorf("expected the stages %v, but got %v", expected, stages)
+			break
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/owasp-amass/asset-db/types"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+	"github.com/owasp-amass/open-asset-model/network"
+)
+
+const stixTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// stixNamespace is the namespace of the deterministic identifiers of the STIX Cyber-observable Objects.
+var stixNamespace = uuid.MustParse("00abedb4-aa42-466c-9c01-fed23315a9b7")
+
+type stixBundle struct {
+	Type    string        `json:"type"`
+	ID      string        `json:"id"`
+	Objects []interface{} `json:"objects"`
+}
+
+type stixObservable struct {
+	Type        string `json:"type"`
+	SpecVersion string `json:"spec_version"`
+	ID          string `json:"id"`
+	Value       string `json:"value,omitempty"`
+	Number      int    `json:"number,omitempty"`
+	Name        string `json:"name,omitempty"`
+}
+
+type stixRelationship struct {
+	Type             string `json:"type"`
+	SpecVersion      string `json:"spec_version"`
+	ID               string `json:"id"`
+	Created          string `json:"created"`
+	Modified         string `json:"modified"`
+	RelationshipType string `json:"relationship_type"`
+	SourceRef        string `json:"source_ref"`
+	TargetRef        string `json:"target_ref"`
+}
+
+// stixExport collects the objects of the bundle once, using the identifiers as the keys.
+type stixExport struct {
+	e       *Enumeration
+	since   time.Time
+	ids     map[string]string
+	objects []interface{}
+	seen    map[string]struct{}
+}
+
+// ExportSTIX writes the names within the scope found since the enumeration started, along with
+// their addresses, netblocks and autonomous systems, to w as a STIX 2.1 bundle. The names are
+// domain-name objects with resolves-to relationships to the ipv4-addr, ipv6-addr and CNAME target
+// domain-name objects, the netblocks are address objects with CIDR values that contain the
+// addresses, and the netblocks belong to the autonomous-system objects.
+func (e *Enumeration) ExportSTIX(w io.Writer) error {
+	if e.graph == nil {
+		return errors.New("the enumeration does not have a graph")
+	}
+
+	x := &stixExport{
+		e:     e,
+		since: e.Config.CollectionStartTime.UTC(),
+		ids:   make(map[string]string),
+		seen:  make(map[string]struct{}),
+	}
+
+	var scope []oam.Asset
+	for _, d := range e.Config.Domains() {
+		scope = append(scope, domain.FQDN{Name: d})
+	}
+	// the graph returns an error when no names are within the scope
+	names, _ := e.graph.DB.FindByScope(scope, x.since)
+	sort.Slice(names, func(i, j int) bool {
+		return stixKey(names[i]) < stixKey(names[j])
+	})
+
+	for _, a := range names {
+		if fqdn, ok := a.Asset.(domain.FQDN); ok && e.Config.IsDomainInScope(fqdn.Name) {
+			x.addName(a)
+		}
+	}
+
+	bundle := &stixBundle{
+		Type:    "bundle",
+		ID:      "bundle--" + uuid.New().String(),
+		Objects: x.objects,
+	}
+	if bundle.Objects == nil {
+		bundle.Objects = []interface{}{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(bundle)
+}
+
+// addName adds the name with its addresses and the CNAME targets, which are followed out of scope.
+func (x *stixExport) addName(a *types.Asset) string {
+	id, added := x.observable(a)
+	if id == "" || !added {
+		return id
+	}
+
+	rels, err := x.e.graph.DB.OutgoingRelations(a, x.since, "a_record", "aaaa_record", "cname_record")
+	if err != nil {
+		return id
+	}
+	for _, rel := range rels {
+		var target string
+		if rel.Type == "cname_record" {
+			target = x.addName(x.find(rel.ToAsset))
+		} else {
+			target = x.addAddress(x.find(rel.ToAsset))
+		}
+		x.relationship(rel, "resolves-to", id, target)
+	}
+	return id
+}
+
+// addAddress adds the address with the netblocks containing it, and their autonomous systems.
+func (x *stixExport) addAddress(a *types.Asset) string {
+	id, added := x.observable(a)
+	if id == "" || !added {
+		return id
+	}
+
+	rels, err := x.e.graph.DB.IncomingRelations(a, x.since, "contains")
+	if err != nil {
+		return id
+	}
+	for _, rel := range rels {
+		nb := x.find(rel.FromAsset)
+		netblock, added := x.observable(nb)
+		x.relationship(rel, "contains", netblock, id)
+		if netblock == "" || !added {
+			continue
+		}
+
+		announced, err := x.e.graph.DB.IncomingRelations(nb, x.since, "announces")
+		if err != nil {
+			continue
+		}
+		for _, arel := range announced {
+			as, _ := x.observable(x.find(arel.FromAsset))
+			x.relationship(arel, "belongs-to", netblock, as)
+		}
+	}
+	return id
+}
+
+// observable adds the STIX Cyber-observable Object for the asset, and returns its identifier and
+// whether it was added by this call. An empty identifier is returned for the unsupported assets.
+func (x *stixExport) observable(a *types.Asset) (string, bool) {
+	if a == nil {
+		return "", false
+	}
+	if id, found := x.ids[a.ID]; found {
+		return id, false
+	}
+
+	obj := &stixObservable{SpecVersion: "2.1"}
+	switch v := a.Asset.(type) {
+	case domain.FQDN:
+		obj.Type = "domain-name"
+		obj.Value = v.Name
+	case network.IPAddress:
+		obj.Type = "ipv4-addr"
+		if v.Address.Is6() && !v.Address.Is4In6() {
+			obj.Type = "ipv6-addr"
+		}
+		obj.Value = v.Address.Unmap().String()
+	case network.Netblock:
+		obj.Type = "ipv4-addr"
+		if v.Cidr.Addr().Is6() && !v.Cidr.Addr().Is4In6() {
+			obj.Type = "ipv6-addr"
+		}
+		obj.Value = v.Cidr.String()
+	case network.AutonomousSystem:
+		obj.Type = "autonomous-system"
+		obj.Number = v.Number
+		if rels, err := x.e.graph.DB.OutgoingRelations(a, x.since, "managed_by"); err == nil && len(rels) > 0 {
+			if org := x.find(rels[0].ToAsset); org != nil {
+				if rir, ok := org.Asset.(network.RIROrganization); ok {
+					obj.Name = rir.Name
+				}
+			}
+		}
+	default:
+		return "", false
+	}
+
+	// the identifiers of the observables are derived from their ID contributing properties
+	contrib := `{"value":` + strconv.Quote(obj.Value) + `}`
+	if obj.Type == "autonomous-system" {
+		contrib = `{"number":` + strconv.Itoa(obj.Number) + `}`
+	}
+	obj.ID = obj.Type + "--" + uuid.NewSHA1(stixNamespace, []byte(contrib)).String()
+
+	x.ids[a.ID] = obj.ID
+	x.objects = append(x.objects, obj)
+	return obj.ID, true
+}
+
+// find returns the asset referenced by the relation, which only provides the identifier.
+func (x *stixExport) find(a *types.Asset) *types.Asset {
+	if a == nil || a.Asset != nil {
+		return a
+	}
+
+	found, err := x.e.graph.DB.FindById(a.ID, x.since)
+	if err != nil {
+		return nil
+	}
+	return found
+}
+
+func (x *stixExport) relationship(rel *types.Relation, rtype, source, target string) {
+	if source == "" || target == "" {
+		return
+	}
+
+	key := source + "|" + rtype + "|" + target
+	if _, found := x.seen[key]; found {
+		return
+	}
+	x.seen[key] = struct{}{}
+
+	created := rel.CreatedAt.UTC()
+	modified := rel.LastSeen.UTC()
+	if modified.Before(created) {
+		modified = created
+	}
+
+	x.objects = append(x.objects, &stixRelationship{
+		Type:             "relationship",
+		SpecVersion:      "2.1",
+		ID:               "relationship--" + uuid.NewSHA1(stixNamespace, []byte(key)).String(),
+		Created:          created.Format(stixTimeFormat),
+		Modified:         modified.Format(stixTimeFormat),
+		RelationshipType: rtype,
+		SourceRef:        source,
+		TargetRef:        target,
+	})
+}
+
+// stixKey returns the value used to order the assets in the bundle.
+func stixKey(a *types.Asset) string {
+	if fqdn, ok := a.Asset.(domain.FQDN); ok {
+		return fqdn.Name
+	}
+	return a.ID
+}
@@ -0,0 +1,122 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestExportSTIX(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.CollectionStartTime = time.Now().Add(-time.Minute)
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	e := &Enumeration{Config: cfg}
+	if err := e.ExportSTIX(&bytes.Buffer{}); err == nil {
+		t.Errorf("the export did not fail without a graph")
+	}
+
+	e.graph = g
+	ctx := context.Background()
+	if err := g.UpsertA(ctx, "www.owasp.org", "192.0.2.1"); err != nil {
+		t.Fatalf("failed to insert the A record: %v", err)
+	}
+	if err := g.UpsertAAAA(ctx, "www.owasp.org", "2001:db8::1"); err != nil {
+		t.Fatalf("failed to insert the AAAA record: %v", err)
+	}
+	if err := g.UpsertCNAME(ctx, "docs.owasp.org", "docs.example.com"); err != nil {
+		t.Fatalf("failed to insert the CNAME record: %v", err)
+	}
+	if err := g.UpsertA(ctx, "docs.example.com", "192.0.2.1"); err != nil {
+		t.Fatalf("failed to insert the A record: %v", err)
+	}
+	if err := g.UpsertInfrastructure(ctx, 64496, "TEST", "192.0.2.1", "192.0.2.0/24"); err != nil {
+		t.Fatalf("failed to insert the infrastructure: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := e.ExportSTIX(&buf); err != nil {
+		t.Fatalf("failed to export the STIX bundle: %v", err)
+	}
+
+	var bundle struct {
+		Type    string                   `json:"type"`
+		ID      string                   `json:"id"`
+		Objects []map[string]interface{} `json:"objects"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &bundle); err != nil {
+		t.Fatalf("the bundle is not valid JSON: %v", err)
+	}
+	if bundle.Type != "bundle" || !strings.HasPrefix(bundle.ID, "bundle--") {
+		t.Errorf("unexpected bundle type and identifier: %s %s", bundle.Type, bundle.ID)
+	}
+
+	ids := make(map[string]string)
+	values := make(map[string]string)
+	rels := make(map[string]int)
+	for _, obj := range bundle.Objects {
+		id, _ := obj["id"].(string)
+		otype, _ := obj["type"].(string)
+		if !strings.HasPrefix(id, otype+"--") || obj["spec_version"] != "2.1" {
+			t.Errorf("the object has an invalid identifier or version: %v", obj)
+		}
+
+		switch otype {
+		case "relationship":
+			source := values[obj["source_ref"].(string)]
+			target := values[obj["target_ref"].(string)]
+			rels[source+" "+obj["relationship_type"].(string)+" "+target]++
+		case "autonomous-system":
+			values[id] = "AS" + jsonNumber(obj["number"])
+			if obj["name"] != "TEST" {
+				t.Errorf("the autonomous system name was not exported: %v", obj)
+			}
+		default:
+			ids[obj["value"].(string)] = otype
+			values[id] = obj["value"].(string)
+		}
+	}
+
+	for value, otype := range map[string]string{
+		"www.owasp.org":    "domain-name",
+		"docs.owasp.org":   "domain-name",
+		"docs.example.com": "domain-name",
+		"192.0.2.1":        "ipv4-addr",
+		"2001:db8::1":      "ipv6-addr",
+		"192.0.2.0/24":     "ipv4-addr",
+	} {
+		if ids[value] != otype {
+			t.Errorf("expected %s to be exported as %s, but got %q", value, otype, ids[value])
+		}
+	}
+	for _, rel := range []string{
+		"www.owasp.org resolves-to 192.0.2.1",
+		"www.owasp.org resolves-to 2001:db8::1",
+		"docs.owasp.org resolves-to docs.example.com",
+		"docs.example.com resolves-to 192.0.2.1",
+		"192.0.2.0/24 contains 192.0.2.1",
+		"192.0.2.0/24 belongs-to AS64496",
+	} {
+		if rels[rel] != 1 {
+			t.Errorf("expected the relationship %s once, but got %d", rel, rels[rel])
+		}
+	}
+}
+
+func jsonNumber(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
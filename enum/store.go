@@ -15,12 +15,12 @@ import (
 
 	"github.com/caffix/pipeline"
 	"github.com/caffix/queue"
+	"github.com/caffix/stringset"
 	"github.com/miekg/dns"
 	amassnet "github.com/owasp-amass/amass/v4/net"
 	amassdns "github.com/owasp-amass/amass/v4/net/dns"
 	"github.com/owasp-amass/amass/v4/requests"
 	"github.com/owasp-amass/resolve"
-	bf "github.com/tylertreat/BoomFilters"
 	"golang.org/x/net/publicsuffix"
 )
 
@@ -30,7 +30,9 @@ type dataManager struct {
 	queue       queue.Queue
 	signalDone  chan struct{}
 	confirmDone chan struct{}
-	filter      *bf.StableBloomFilter
+	filter      dedupeFilter
+	reversed    *stringset.Set
+	batch       *graphBatch
 }
 
 // newDataManager returns a dataManager specific to the provided Enumeration.
@@ -40,7 +42,13 @@ func newDataManager(e *Enumeration) *dataManager {
 		queue:       queue.NewQueue(),
 		signalDone:  make(chan struct{}, 2),
 		confirmDone: make(chan struct{}, 2),
-		filter:      bf.NewDefaultStableBloomFilter(1000000, 0.01),
+		filter:      e.newDedupeFilter(),
+		reversed:    stringset.New(),
+	}
+	if e.GraphBatchSize > 0 {
+		dm.batch = newGraphBatch(e.GraphBatchSize, e.GraphFlushInterval, func(err error) {
+			e.log().Warnf("%v", err)
+		})
 	}
 
 	go dm.processASNRequests()
@@ -48,7 +56,11 @@ func newDataManager(e *Enumeration) *dataManager {
 }
 
 func (dm *dataManager) Stop() chan struct{} {
-	dm.filter.Reset()
+	if dm.batch != nil {
+		dm.batch.stop()
+	}
+	dm.filter.reset()
+	dm.reversed.Close()
 	close(dm.signalDone)
 	return dm.confirmDone
 }
@@ -69,9 +81,20 @@ func (dm *dataManager) Process(ctx context.Context, data pipeline.Data, tp pipel
 		}
 
 		id = v.Name
+		if err := dm.enum.processAnswers(v); err != nil {
+			dm.enum.log().Warnf("%s was not stored: %v", v.Name, err)
+			dm.enum.nameSrc.leaveFlight(v.Name)
+			return nil, nil
+		}
 		if err := dm.dnsRequest(ctx, v, tp); err != nil {
-			dm.enum.Config.Log.Print(err.Error())
+			dm.enum.log().Warnf("%v", err)
+		} else if len(v.Records) > 0 {
+			dm.enum.markSeen(v)
+			if dm.enum.events != nil {
+				dm.enum.events.add(dm.enum.RootDomain(v.Name), v.Name)
+			}
 		}
+		dm.enum.nameSrc.leaveFlight(v.Name)
 	case *requests.AddrRequest:
 		if v == nil {
 			return nil, nil
@@ -79,26 +102,79 @@ func (dm *dataManager) Process(ctx context.Context, data pipeline.Data, tp pipel
 
 		id = v.Address
 		if err := dm.addrRequest(ctx, v, tp); err != nil {
-			dm.enum.Config.Log.Print(err.Error())
+			dm.enum.log().Warnf("%v", err)
 		}
 	}
 
-	if id != "" && dm.filter.TestAndAdd([]byte(id)) {
+	if id != "" && dm.filter.testAndAdd(id) {
 		return nil, nil
 	}
+	if v, ok := data.(*requests.DNSRequest); ok {
+		v.Timings.Store = v.Timings.Lap()
+	}
+	if v, ok := data.(*requests.DNSRequest); ok && len(v.Records) > 0 {
+		dm.enum.publishResolved(v)
+	}
 	return data, nil
 }
 
+// processAnswers calls the AnswerProcessors in order on the resolved request, and returns the
+// first error.
+func (e *Enumeration) processAnswers(req *requests.DNSRequest) error {
+	if len(req.Records) == 0 {
+		return nil
+	}
+
+	for i, p := range e.AnswerProcessors {
+		if err := p(req); err != nil {
+			return fmt.Errorf("answer processor %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
 func (dm *dataManager) dnsRequest(ctx context.Context, req *requests.DNSRequest, tp pipeline.TaskParams) error {
-	if dm.enum.Config.Blacklisted(req.Name) {
+	if dm.enum.blacklisted(req.Name) {
 		return nil
 	}
+	if dm.enum.privaddr != nil {
+		dm.enum.privaddr.filter(req)
+	}
+	if len(req.Records) == 0 {
+		if !dm.enum.unresolvable(req) {
+			return nil
+		}
+
+		dm.enum.scoreName(req)
+		dm.enum.log().Debugf("%s from %s did not resolve", req.Name, req.Source)
+		return dm.upsert("FQDN", req.Name, "", func() error {
+			_, err := dm.enum.graph.UpsertFQDN(ctx, req.Name)
+			return err
+		})
+	}
+	dm.enum.addSourceHistory(req.Name, resolvingSource)
+	dm.enum.scoreName(req)
+	if dm.enum.rebind != nil && dm.enum.Config.IsDomainInScope(req.Name) {
+		dm.enum.rebind.check(req)
+	}
+	if dm.enum.horizon != nil && dm.enum.Config.IsDomainInScope(req.Name) {
+		dm.enum.goTracked(ctx, func() { dm.enum.horizon.check(ctx, req.Name) })
+	}
+	if dm.enum.vantage != nil && dm.enum.Config.IsDomainInScope(req.Name) {
+		dm.enum.goTracked(ctx, func() { dm.enum.vantage.check(ctx, req.Name) })
+	}
+	if dm.enum.nscheck != nil && dm.enum.Config.IsDomainInScope(req.Name) {
+		dm.enum.goTracked(ctx, func() { dm.enum.nscheck.check(ctx, req.Name, req.Domain) })
+	}
 	// Check for CNAME records first
 	for i, r := range req.Records {
-		req.Records[i].Name = strings.Trim(strings.ToLower(r.Name), ".")
-		req.Records[i].Data = strings.Trim(strings.ToLower(r.Data), ".")
+		requests.SanitizeDNSAnswer(&req.Records[i])
+		dm.checkTTL(&req.Records[i])
 
-		if uint16(r.Type) == dns.TypeCNAME {
+		if uint16(r.Type) == dns.TypeCNAME && !sectionRecord(r) {
+			if dm.enum.PreferCNAMEWithAddresses {
+				return dm.insertCNAMEWithAddresses(ctx, req, i, tp)
+			}
 			// Do not enter more than the CNAME record
 			return dm.insertCNAME(ctx, req, i, tp)
 		}
@@ -112,6 +188,11 @@ func (dm *dataManager) dnsRequest(ctx context.Context, req *requests.DNSRequest,
 		default:
 		}
 
+		// the authority and additional records do not belong to the name
+		if sectionRecord(r) {
+			continue
+		}
+
 		var e error
 		switch uint16(r.Type) {
 		case dns.TypeA:
@@ -132,6 +213,8 @@ func (dm *dataManager) dnsRequest(ctx context.Context, req *requests.DNSRequest,
 			e = dm.insertSOA(ctx, req, i, tp)
 		case dns.TypeSPF:
 			e = dm.insertSPF(ctx, req, i, tp)
+		case dns.TypeURI:
+			e = dm.insertURI(ctx, req, i, tp)
 		}
 		if err == nil {
 			err = e
@@ -140,6 +223,36 @@ func (dm *dataManager) dnsRequest(ctx context.Context, req *requests.DNSRequest,
 	return err
 }
 
+// upsert executes the graph operation, or queues it when the graph upserts are batched.
+func (dm *dataManager) upsert(label, name, value string, op func() error) error {
+	run := func() error {
+		if err := op(); err != nil {
+			if dm.enum.graphWriteFailed(graphFailoverRecord{Type: label, Name: name, Value: value}, err) {
+				return fmt.Errorf("failed to insert %s, kept in the failover file: %v", label, err)
+			}
+			return fmt.Errorf("failed to insert %s: %v", label, err)
+		}
+		dm.enum.graphWriteSucceeded()
+		return nil
+	}
+
+	if dm.batch == nil {
+		return run()
+	}
+	dm.batch.add(label+"|"+name+"|"+value, run)
+	return nil
+}
+
+// checkTTL flags the answer when its TTL is below the MinTTLFlag, and keeps it for LowTTLAnswers.
+func (dm *dataManager) checkTTL(ans *requests.DNSAnswer) {
+	if min := dm.enum.MinTTLFlag; min > 0 && ans.TTL > 0 && ans.TTL < min {
+		ans.LowTTL = true
+		dm.enum.addLowTTL(*ans)
+		dm.enum.log().Warnf("Low TTL: %s %s record with a TTL of %d seconds: %s",
+			ans.Name, dns.TypeToString[uint16(ans.Type)], ans.TTL, ans.Data)
+	}
+}
+
 func (dm *dataManager) insertCNAME(ctx context.Context, req *requests.DNSRequest, recidx int, tp pipeline.TaskParams) error {
 	target := resolve.RemoveLastDot(req.Records[recidx].Data)
 	if target == "" {
@@ -150,15 +263,58 @@ func (dm *dataManager) insertCNAME(ctx context.Context, req *requests.DNSRequest
 	if err != nil || domain == "" {
 		return errors.New("failed to extract a domain name from the FQDN")
 	}
-	// Important - Allows chained CNAME records to be resolved until an A/AAAA record
-	dm.enum.nameSrc.newName(&requests.DNSRequest{
-		Name:   target,
-		Domain: strings.ToLower(domain),
+	if !dm.enum.Config.IsDomainInScope(target) && dm.enum.Config.IsDomainInScope(req.Name) {
+		dm.enum.inheritScope(req.Name, target)
+	}
+	if !dm.enum.Config.IsDomainInScope(target) {
+		dm.enum.expandScope(target)
+	}
+	if dm.enum.takeover != nil && dm.enum.Config.IsDomainInScope(req.Name) {
+		dm.enum.goTracked(ctx, func() { dm.enum.takeover.check(ctx, req.Name, "CNAME", target) })
+	}
+	if dm.enum.RecordOutOfScopeCNAMEs && !dm.enum.Config.IsDomainInScope(target) && dm.enum.Config.IsDomainInScope(req.Name) {
+		if err := dm.upsert("CNAME", req.Name, target, func() error {
+			return dm.enum.graph.UpsertCNAME(ctx, req.Name, target)
+		}); err != nil {
+			return err
+		}
+		dm.enum.recordOutOfScopeCNAME(req.Name, target)
+		return nil
+	}
+	// Important - Allows chained CNAME records to be resolved until an A/AAAA record,
+	// unless the target leads back to the name and the chain would never end
+	if !dm.enum.cnameLoop(req.Name, target) {
+		dm.enum.nameSrc.newNameWithoutWait(&requests.DNSRequest{
+			Name:   target,
+			Domain: strings.ToLower(domain),
+		})
+	}
+	return dm.upsert("CNAME", req.Name, target, func() error {
+		return dm.enum.graph.UpsertCNAME(ctx, req.Name, target)
 	})
-	if err := dm.enum.graph.UpsertCNAME(ctx, req.Name, target); err != nil {
-		return fmt.Errorf("failed to insert CNAME: %v", err)
+}
+
+// insertCNAMEWithAddresses enters the CNAME record, and the A and AAAA records of the CNAME targets.
+func (dm *dataManager) insertCNAMEWithAddresses(ctx context.Context, req *requests.DNSRequest, recidx int, tp pipeline.TaskParams) error {
+	err := dm.insertCNAME(ctx, req, recidx, tp)
+
+	for i, r := range req.Records {
+		if !chainTarget(req, r.Name) {
+			continue
+		}
+
+		var e error
+		switch uint16(r.Type) {
+		case dns.TypeA:
+			e = dm.insertA(ctx, req, i, tp)
+		case dns.TypeAAAA:
+			e = dm.insertAAAA(ctx, req, i, tp)
+		}
+		if err == nil {
+			err = e
+		}
 	}
-	return nil
+	return err
 }
 
 func (dm *dataManager) insertA(ctx context.Context, req *requests.DNSRequest, recidx int, tp pipeline.TaskParams) error {
@@ -172,9 +328,13 @@ func (dm *dataManager) insertA(ctx context.Context, req *requests.DNSRequest, re
 		InScope: true,
 		Domain:  req.Domain,
 	})
-	if err := dm.enum.graph.UpsertA(ctx, req.Name, addr); err != nil {
-		return fmt.Errorf("failed to insert A record: %v", err)
+	name := recordOwner(req, req.Records[recidx])
+	if err := dm.upsert("A record", name, addr, func() error {
+		return dm.enum.graph.UpsertA(ctx, name, addr)
+	}); err != nil {
+		return err
 	}
+	dm.reverseAddr(ctx, addr, tp)
 	return nil
 }
 
@@ -189,12 +349,30 @@ func (dm *dataManager) insertAAAA(ctx context.Context, req *requests.DNSRequest,
 		InScope: true,
 		Domain:  req.Domain,
 	})
-	if err := dm.enum.graph.UpsertAAAA(ctx, req.Name, addr); err != nil {
-		return fmt.Errorf("failed to insert AAAA record: %v", err)
+	name := recordOwner(req, req.Records[recidx])
+	if err := dm.upsert("AAAA record", name, addr, func() error {
+		return dm.enum.graph.UpsertAAAA(ctx, name, addr)
+	}); err != nil {
+		return err
 	}
+	dm.reverseAddr(ctx, addr, tp)
 	return nil
 }
 
+// reverseAddr stores the PTR records for addresses not reversed previously during the enumeration.
+func (dm *dataManager) reverseAddr(ctx context.Context, addr string, tp pipeline.TaskParams) {
+	if !dm.enum.ResolvePTRForAddresses || dm.reversed.Has(addr) || !dm.enum.addrPermitted(addr) {
+		return
+	}
+	dm.reversed.Insert(addr)
+
+	dm.enum.goTracked(ctx, func() {
+		if req, err := dm.enum.reverseDNSQuery(ctx, addr); err == nil {
+			_ = dm.dnsRequest(ctx, req, tp)
+		}
+	})
+}
+
 func (dm *dataManager) insertPTR(ctx context.Context, req *requests.DNSRequest, recidx int, tp pipeline.TaskParams) error {
 	target := resolve.RemoveLastDot(req.Records[recidx].Data)
 	if target == "" {
@@ -202,18 +380,25 @@ func (dm *dataManager) insertPTR(ctx context.Context, req *requests.DNSRequest,
 	}
 	// Do not go further if the target is not in scope
 	domain := strings.ToLower(dm.enum.Config.WhichDomain(target))
+	if domain == "" {
+		domain = dm.enum.expandScope(target)
+	}
+	if domain == "" && dm.enum.addrInScope(amassdns.ReverseNameToIP(req.Name)) {
+		if d, err := publicsuffix.EffectiveTLDPlusOne(target); err == nil {
+			domain = strings.ToLower(d)
+		}
+	}
 	if domain == "" {
 		return nil
 	}
 	// Important - Allows the target DNS name to be resolved in the forward direction
-	dm.enum.nameSrc.newName(&requests.DNSRequest{
+	dm.enum.nameSrc.newNameWithoutWait(&requests.DNSRequest{
 		Name:   target,
 		Domain: domain,
 	})
-	if err := dm.enum.graph.UpsertPTR(ctx, req.Name, target); err != nil {
-		return fmt.Errorf("failed to insert PTR record: %v", err)
-	}
-	return nil
+	return dm.upsert("PTR record", req.Name, target, func() error {
+		return dm.enum.graph.UpsertPTR(ctx, req.Name, target)
+	})
 }
 
 func (dm *dataManager) insertSRV(ctx context.Context, req *requests.DNSRequest, recidx int, tp pipeline.TaskParams) error {
@@ -223,15 +408,14 @@ func (dm *dataManager) insertSRV(ctx context.Context, req *requests.DNSRequest,
 		return errors.New("failed to extract service info from the DNS answer data")
 	}
 	if domain := dm.enum.Config.WhichDomain(target); domain != "" {
-		dm.enum.nameSrc.newName(&requests.DNSRequest{
+		dm.enum.nameSrc.newNameWithoutWait(&requests.DNSRequest{
 			Name:   target,
 			Domain: domain,
 		})
 	}
-	if err := dm.enum.graph.UpsertSRV(ctx, service, target); err != nil {
-		return fmt.Errorf("failed to insert SRV record: %v", err)
-	}
-	return nil
+	return dm.upsert("SRV record", service, target, func() error {
+		return dm.enum.graph.UpsertSRV(ctx, service, target)
+	})
 }
 
 func (dm *dataManager) insertNS(ctx context.Context, req *requests.DNSRequest, recidx int, tp pipeline.TaskParams) error {
@@ -244,16 +428,16 @@ func (dm *dataManager) insertNS(ctx context.Context, req *requests.DNSRequest, r
 	if err != nil || domain == "" {
 		return errors.New("failed to extract a domain name from the FQDN")
 	}
-	if d := strings.ToLower(domain); target != d {
-		dm.enum.nameSrc.newName(&requests.DNSRequest{
+	// the nameservers with glue records do not need the forward lookup
+	if d := strings.ToLower(domain); target != d && !hasGlue(req, target) {
+		dm.enum.nameSrc.newNameWithoutWait(&requests.DNSRequest{
 			Name:   target,
 			Domain: d,
 		})
 	}
-	if err := dm.enum.graph.UpsertNS(ctx, req.Name, target); err != nil {
-		return fmt.Errorf("failed to insert NS record: %v", err)
-	}
-	return nil
+	return dm.upsert("NS record", req.Name, target, func() error {
+		return dm.enum.graph.UpsertNS(ctx, req.Name, target)
+	})
 }
 
 func (dm *dataManager) insertMX(ctx context.Context, req *requests.DNSRequest, recidx int, tp pipeline.TaskParams) error {
@@ -266,42 +450,56 @@ func (dm *dataManager) insertMX(ctx context.Context, req *requests.DNSRequest, r
 	if err != nil || domain == "" {
 		return errors.New("failed to extract a domain name from the FQDN")
 	}
-	if d := strings.ToLower(domain); target != d {
-		dm.enum.nameSrc.newName(&requests.DNSRequest{
+	if dm.enum.MapMailInfra {
+		dm.enum.mapMailExchanger(req.Name, target, req.Records[recidx].Priority)
+	} else if d := strings.ToLower(domain); target != d {
+		dm.enum.nameSrc.newNameWithoutWait(&requests.DNSRequest{
 			Name:   target,
 			Domain: d,
 		})
 	}
-	if err := dm.enum.graph.UpsertMX(ctx, req.Name, target); err != nil {
-		return fmt.Errorf("failed to insert MX record: %v", err)
-	}
-	return nil
+	return dm.upsert("MX record", req.Name, target, func() error {
+		return dm.enum.graph.UpsertMX(ctx, req.Name, target)
+	})
 }
 
 func (dm *dataManager) insertTXT(ctx context.Context, req *requests.DNSRequest, recidx int, tp pipeline.TaskParams) error {
 	if dm.enum.Config.IsDomainInScope(req.Name) {
-		dm.findNamesAndAddresses(ctx, req.Records[recidx].Data, req.Domain, tp)
+		dm.findNamesAndAddresses(ctx, req, recidx, tp)
 	}
 	return nil
 }
 
 func (dm *dataManager) insertSOA(ctx context.Context, req *requests.DNSRequest, recidx int, tp pipeline.TaskParams) error {
 	if dm.enum.Config.IsDomainInScope(req.Name) {
-		dm.findNamesAndAddresses(ctx, req.Records[recidx].Data, req.Domain, tp)
+		dm.findNamesAndAddresses(ctx, req, recidx, tp)
 	}
 	return nil
 }
 
 func (dm *dataManager) insertSPF(ctx context.Context, req *requests.DNSRequest, recidx int, tp pipeline.TaskParams) error {
 	if dm.enum.Config.IsDomainInScope(req.Name) {
-		dm.findNamesAndAddresses(ctx, req.Records[recidx].Data, req.Domain, tp)
+		dm.findNamesAndAddresses(ctx, req, recidx, tp)
 	}
 	return nil
 }
 
-func (dm *dataManager) findNamesAndAddresses(ctx context.Context, data, domain string, tp pipeline.TaskParams) {
+func (dm *dataManager) insertURI(ctx context.Context, req *requests.DNSRequest, recidx int, tp pipeline.TaskParams) error {
+	if dm.enum.Config.IsDomainInScope(req.Name) {
+		dm.findNamesAndAddresses(ctx, req, recidx, tp)
+	}
+	return nil
+}
+
+func (dm *dataManager) findNamesAndAddresses(ctx context.Context, req *requests.DNSRequest, recidx int, tp pipeline.TaskParams) {
+	data, domain := req.Records[recidx].Data, req.Domain
+	rtype := dns.TypeToString[uint16(req.Records[recidx].Type)]
+
 	ipre := regexp.MustCompile(amassnet.IPv4RE)
 	for _, ip := range ipre.FindAllString(data, -1) {
+		if !dm.enum.allowPrivateAddress(req.Name, ip, rtype) {
+			continue
+		}
 		dm.enum.nameSrc.newAddr(&requests.AddrRequest{
 			Address: ip,
 			Domain:  domain,
@@ -311,7 +509,7 @@ func (dm *dataManager) findNamesAndAddresses(ctx context.Context, data, domain s
 	subre := amassdns.AnySubdomainRegex()
 	for _, name := range subre.FindAllString(data, -1) {
 		if domain := strings.ToLower(dm.enum.Config.WhichDomain(name)); domain != "" {
-			dm.enum.nameSrc.newName(&requests.DNSRequest{
+			dm.enum.nameSrc.newNameWithoutWait(&requests.DNSRequest{
 				Name:   name,
 				Domain: domain,
 			})
@@ -331,14 +529,14 @@ func (dm *dataManager) addrRequest(ctx context.Context, req *requests.AddrReques
 	}
 	if yes, prefix := amassnet.IsReservedAddress(req.Address); yes {
 		var err error
-		if e := dm.enum.graph.UpsertInfrastructure(ctx, 0, amassnet.ReservedCIDRDescription, req.Address, prefix); e != nil {
+		if e := dm.upsertInfrastructure(ctx, 0, amassnet.ReservedCIDRDescription, req.Address, prefix); e != nil {
 			err = e
 		}
 		return err
 	}
 	if r := dm.enum.Sys.Cache().AddrSearch(req.Address); r != nil {
 		var err error
-		if e := dm.enum.graph.UpsertInfrastructure(ctx, r.ASN, r.Description, req.Address, r.Prefix); e != nil {
+		if e := dm.upsertInfrastructure(ctx, r.ASN, r.Description, req.Address, r.Prefix); e != nil {
 			err = e
 		}
 		return err
@@ -348,6 +546,28 @@ func (dm *dataManager) addrRequest(ctx context.Context, req *requests.AddrReques
 	return nil
 }
 
+// upsertInfrastructure stores the address, netblock and autonomous system in the graph,
+// and mirrors them into the Neo4j output when it has been set.
+func (dm *dataManager) upsertInfrastructure(ctx context.Context, asn int, desc, addr, cidr string) error {
+	if err := dm.enum.graph.UpsertInfrastructure(ctx, asn, desc, addr, cidr); err != nil {
+		dm.enum.graphWriteFailed(graphFailoverRecord{
+			Type:        graphInfrastructure,
+			Name:        addr,
+			Value:       cidr,
+			ASN:         asn,
+			Description: desc,
+		}, err)
+		return err
+	}
+	dm.enum.graphWriteSucceeded()
+	if dm.enum.neo4j != nil {
+		if err := dm.enum.neo4j.insertInfrastructure(asn, desc, addr, cidr); err != nil {
+			dm.enum.log().Errorf("Failed to write the Neo4j output: %v", err)
+		}
+	}
+	return nil
+}
+
 func (dm *dataManager) processASNRequests() {
 loop:
 	for {
@@ -373,7 +593,7 @@ func (dm *dataManager) nextInfraInfo() {
 	ctx := context.Background()
 	req := e.(*requests.AddrRequest)
 	if r := dm.enum.Sys.Cache().AddrSearch(req.Address); r != nil {
-		_ = dm.enum.graph.UpsertInfrastructure(ctx, r.ASN, r.Description, req.Address, r.Prefix)
+		_ = dm.upsertInfrastructure(ctx, r.ASN, r.Description, req.Address, r.Prefix)
 		return
 	}
 
@@ -388,7 +608,7 @@ loop:
 
 		time.Sleep(2 * time.Second)
 		if r := dm.enum.Sys.Cache().AddrSearch(req.Address); r != nil {
-			_ = dm.enum.graph.UpsertInfrastructure(ctx, r.ASN, r.Description, req.Address, r.Prefix)
+			_ = dm.upsertInfrastructure(ctx, r.ASN, r.Description, req.Address, r.Prefix)
 			return
 		}
 	}
@@ -396,7 +616,7 @@ loop:
 	asn := 0
 	desc := "Unknown"
 	prefix := fakePrefix(req.Address)
-	_ = dm.enum.graph.UpsertInfrastructure(ctx, asn, desc, req.Address, prefix)
+	_ = dm.upsertInfrastructure(ctx, asn, desc, req.Address, prefix)
 
 	first, cidr, _ := net.ParseCIDR(prefix)
 	dm.enum.Sys.Cache().Update(&requests.ASNRequest{
@@ -0,0 +1,108 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/owasp-amass/amass/v4/requests"
+	oam "github.com/owasp-amass/open-asset-model"
+	"github.com/owasp-amass/open-asset-model/domain"
+)
+
+// RunSummary provides the totals for the findings of an enumeration.
+type RunSummary struct {
+	// Names is the number of unique names within the scope
+	Names int
+	// Addresses is the number of unique IP addresses
+	Addresses int
+	// Records is the number of DNS records for each record type, such as "A" or "CNAME"
+	Records map[string]int
+	// Sources is the number of resolved names first discovered by each data source
+	Sources   map[string]int
+	Netblocks int
+	ASNs      int
+}
+
+// Summary aggregates the findings stored in the graph since the enumeration started, along
+// with the data sources that discovered the names. It is safe to call after Start returns.
+func (e *Enumeration) Summary() (*RunSummary, error) {
+	if e.graph == nil {
+		return nil, errors.New("the enumeration does not have a graph")
+	}
+
+	since := e.Config.CollectionStartTime.UTC()
+	s := &RunSummary{
+		Records: make(map[string]int),
+		Sources: make(map[string]int),
+	}
+
+	var scope []oam.Asset
+	for _, d := range e.Config.Domains() {
+		scope = append(scope, domain.FQDN{Name: d})
+	}
+	// the graph returns an error when no names are within the scope
+	names, _ := e.graph.DB.FindByScope(scope, since)
+
+	seen := make(map[string]struct{})
+	for _, a := range names {
+		fqdn, ok := a.Asset.(domain.FQDN)
+		if !ok || !e.Config.IsDomainInScope(fqdn.Name) {
+			continue
+		}
+		if _, found := seen[fqdn.Name]; found {
+			continue
+		}
+		seen[fqdn.Name] = struct{}{}
+		s.Names++
+
+		rels, err := e.graph.DB.OutgoingRelations(a, since)
+		if err != nil {
+			continue
+		}
+		for _, rel := range rels {
+			if rrtype := strings.TrimSuffix(rel.Type, "_record"); rrtype != rel.Type {
+				s.Records[strings.ToUpper(rrtype)]++
+			}
+		}
+	}
+
+	for _, c := range []struct {
+		atype oam.AssetType
+		count *int
+	}{
+		{oam.IPAddress, &s.Addresses},
+		{oam.Netblock, &s.Netblocks},
+		{oam.ASN, &s.ASNs},
+	} {
+		// the graph stores each asset once, regardless of how many times it was found
+		if assets, err := e.graph.DB.FindByType(c.atype, since); err == nil {
+			*c.count = len(assets)
+		}
+	}
+
+	e.sumLock.Lock()
+	for src, n := range e.srcCount {
+		s.Sources[src] = n
+	}
+	e.sumLock.Unlock()
+	return s, nil
+}
+
+// countSource attributes the resolved name to the data source that discovered it.
+func (e *Enumeration) countSource(req *requests.DNSRequest) {
+	if len(req.Records) == 0 || req.Source == "" {
+		return
+	}
+
+	e.sumLock.Lock()
+	defer e.sumLock.Unlock()
+
+	if e.srcCount == nil {
+		e.srcCount = make(map[string]int)
+	}
+	e.srcCount[req.Source]++
+}
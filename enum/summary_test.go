@@ -0,0 +1,71 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestSummary(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.CollectionStartTime = time.Now().Add(-time.Minute)
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	e := &Enumeration{Config: cfg}
+	if _, err := e.Summary(); err == nil {
+		t.Errorf("the summary did not fail without a graph")
+	}
+
+	e.graph = g
+	ctx := context.Background()
+	if err := g.UpsertA(ctx, "www.owasp.org", "192.0.2.1"); err != nil {
+		t.Fatalf("failed to insert the A record: %v", err)
+	}
+	if err := g.UpsertA(ctx, "mail.owasp.org", "192.0.2.1"); err != nil {
+		t.Fatalf("failed to insert the A record: %v", err)
+	}
+	if err := g.UpsertCNAME(ctx, "docs.owasp.org", "www.owasp.org"); err != nil {
+		t.Fatalf("failed to insert the CNAME record: %v", err)
+	}
+	if err := g.UpsertInfrastructure(ctx, 64496, "TEST", "192.0.2.1", "192.0.2.0/24"); err != nil {
+		t.Fatalf("failed to insert the infrastructure: %v", err)
+	}
+
+	for _, req := range []*requests.DNSRequest{
+		{Name: "www.owasp.org", Source: "Crtsh", Records: []requests.DNSAnswer{{Type: 1, Data: "192.0.2.1"}}},
+		{Name: "mail.owasp.org", Source: "Crtsh", Records: []requests.DNSAnswer{{Type: 1, Data: "192.0.2.1"}}},
+		{Name: "docs.owasp.org", Source: "Brute Forcing", Records: []requests.DNSAnswer{{Type: 5, Data: "www.owasp.org"}}},
+		{Name: "dead.owasp.org", Source: "Crtsh"},
+	} {
+		e.countSource(req)
+	}
+
+	s, err := e.Summary()
+	if err != nil {
+		t.Fatalf("failed to obtain the summary: %v", err)
+	}
+	// the root domain name is stored along with the names
+	if s.Names != 4 {
+		t.Errorf("expected 4 names, but got %d", s.Names)
+	}
+	if s.Addresses != 1 || s.Netblocks != 1 || s.ASNs != 1 {
+		t.Errorf("expected one address, netblock and ASN, but got %d, %d and %d", s.Addresses, s.Netblocks, s.ASNs)
+	}
+	if s.Records["A"] != 2 || s.Records["CNAME"] != 1 {
+		t.Errorf("unexpected record counts: %v", s.Records)
+	}
+	if s.Sources["Crtsh"] != 2 || s.Sources["Brute Forcing"] != 1 {
+		t.Errorf("unexpected source counts: %v", s.Sources)
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+// TakeoverSeverity is the severity of the subdomain takeover findings.
+const TakeoverSeverity = "high"
+
+// TakeoverFingerprint identifies the CNAME or NS targets of a cloud service where the resources
+// can be claimed by anyone, and the response codes showing that the target is unclaimed.
+type TakeoverFingerprint struct {
+	Service string
+	// Type is the record type delegating to the service: CNAME or NS
+	Type string
+	// Pattern is the regular expression matched against the target of the record
+	Pattern string
+	// Rcodes are received when the target is unclaimed. The CNAME targets default to NXDOMAIN,
+	// and the nameservers default to SERVFAIL and REFUSED for the delegated zone
+	Rcodes []string
+}
+
+// TakeoverFinding is an in scope name delegated to an unclaimed target of a cloud service.
+type TakeoverFinding struct {
+	Name        string
+	Type        string
+	Target      string
+	Service     string
+	Fingerprint string
+	Rcode       string
+	Severity    string
+}
+
+var defaultTakeoverFingerprints = []TakeoverFingerprint{
+	{Service: "Azure App Service", Type: "CNAME", Pattern: `\.azurewebsites\.net$`},
+	{Service: "Azure Cloud Services", Type: "CNAME", Pattern: `\.cloudapp\.(net|azure\.com)$`},
+	{Service: "Azure CDN", Type: "CNAME", Pattern: `\.azureedge\.net$`},
+	{Service: "Azure Traffic Manager", Type: "CNAME", Pattern: `\.trafficmanager\.net$`},
+	{Service: "Azure Blob Storage", Type: "CNAME", Pattern: `\.blob\.core\.windows\.net$`},
+	{Service: "AWS Elastic Beanstalk", Type: "CNAME", Pattern: `\.elasticbeanstalk\.com$`},
+	{Service: "AWS Route 53", Type: "NS", Pattern: `\.awsdns-[0-9]+\.(com|net|org|co\.uk)$`},
+	{Service: "Azure DNS", Type: "NS", Pattern: `\.azure-dns\.(com|net|org|info)$`},
+	{Service: "Google Cloud DNS", Type: "NS", Pattern: `\.googledomains\.com$`},
+	{Service: "DigitalOcean DNS", Type: "NS", Pattern: `^ns[1-3]\.digitalocean\.com$`},
+}
+
+type takeoverFingerprint struct {
+	TakeoverFingerprint
+	re     *regexp.Regexp
+	rcodes map[int]struct{}
+}
+
+// takeoverChecks verifies the CNAME and NS targets matching the fingerprints, and keeps the findings.
+type takeoverChecks struct {
+	sync.Mutex
+	enum     *Enumeration
+	fps      []*takeoverFingerprint
+	port     string
+	checked  map[string]struct{}
+	findings []TakeoverFinding
+}
+
+func newTakeoverChecks(e *Enumeration) (*takeoverChecks, error) {
+	fingerprints := e.TakeoverFingerprints
+	if len(fingerprints) == 0 {
+		fingerprints = defaultTakeoverFingerprints
+	}
+
+	t := &takeoverChecks{
+		enum:    e,
+		port:    "53",
+		checked: make(map[string]struct{}),
+	}
+	for _, fp := range fingerprints {
+		f, err := compileTakeoverFingerprint(fp)
+		if err != nil {
+			return nil, err
+		}
+		t.fps = append(t.fps, f)
+	}
+	return t, nil
+}
+
+func compileTakeoverFingerprint(fp TakeoverFingerprint) (*takeoverFingerprint, error) {
+	fp.Type = strings.ToUpper(strings.TrimSpace(fp.Type))
+	if fp.Type != "CNAME" && fp.Type != "NS" {
+		return nil, fmt.Errorf("the takeover fingerprint %s has the unsupported record type %s", fp.Service, fp.Type)
+	}
+
+	re, err := regexp.Compile(fp.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("the takeover fingerprint %s has an invalid pattern: %v", fp.Service, err)
+	}
+
+	names := fp.Rcodes
+	if len(names) == 0 && fp.Type == "CNAME" {
+		names = []string{"NXDOMAIN"}
+	} else if len(names) == 0 {
+		names = []string{"SERVFAIL", "REFUSED"}
+	}
+	rcodes := make(map[int]struct{})
+	for _, s := range names {
+		rcode, found := dns.StringToRcode[strings.ToUpper(strings.TrimSpace(s))]
+		if !found || rcode == dns.RcodeSuccess {
+			return nil, fmt.Errorf("the takeover fingerprint %s has the invalid rcode %s", fp.Service, s)
+		}
+		rcodes[rcode] = struct{}{}
+	}
+
+	return &takeoverFingerprint{
+		TakeoverFingerprint: fp,
+		re:                  re,
+		rcodes:              rcodes,
+	}, nil
+}
+
+// match returns the fingerprint of the record type matching the target.
+func (t *takeoverChecks) match(rrtype, target string) *takeoverFingerprint {
+	target = strings.ToLower(resolve.RemoveLastDot(target))
+
+	for _, fp := range t.fps {
+		if fp.Type == rrtype && fp.re.MatchString(target) {
+			return fp
+		}
+	}
+	return nil
+}
+
+// check verifies that the CNAME target or the nameserver of the name is unclaimed when the
+// target matches a fingerprint, and records the finding.
+func (t *takeoverChecks) check(ctx context.Context, name, rrtype, target string) {
+	target = strings.ToLower(resolve.RemoveLastDot(target))
+	fp := t.match(rrtype, target)
+	if fp == nil {
+		return
+	}
+
+	key := name + "," + rrtype + "," + target
+	t.Lock()
+	if _, found := t.checked[key]; found {
+		t.Unlock()
+		return
+	}
+	t.checked[key] = struct{}{}
+	t.Unlock()
+
+	var rcode int
+	var unclaimed bool
+	if rrtype == "CNAME" {
+		rcode, unclaimed = t.unclaimedTarget(ctx, target, fp)
+	} else {
+		rcode, unclaimed = t.unclaimedZone(ctx, name, target, fp)
+	}
+	if !unclaimed {
+		return
+	}
+
+	finding := TakeoverFinding{
+		Name:        name,
+		Type:        rrtype,
+		Target:      target,
+		Service:     fp.Service,
+		Fingerprint: fp.Pattern,
+		Rcode:       dns.RcodeToString[rcode],
+		Severity:    TakeoverSeverity,
+	}
+	t.Lock()
+	t.findings = append(t.findings, finding)
+	t.Unlock()
+
+	t.enum.log().Warnf("Takeover: %s %s %s is unclaimed on %s with %s (%s severity)",
+		name, rrtype, target, fp.Service, finding.Rcode, TakeoverSeverity)
+}
+
+// unclaimedTarget returns true when the CNAME target receives one of the rcodes of the fingerprint.
+func (t *takeoverChecks) unclaimedTarget(ctx context.Context, target string, fp *takeoverFingerprint) (int, bool) {
+	resp, err := t.enum.queryBlocking(ctx, resolve.QueryMsg(target, dns.TypeA), t.enum.Sys.TrustedResolvers())
+	if err != nil || resp == nil {
+		return 0, false
+	}
+
+	_, found := fp.rcodes[resp.Rcode]
+	return resp.Rcode, found
+}
+
+// unclaimedZone returns true when the nameserver does not exist, or each address of the nameserver
+// answers the SOA query for the delegated zone with one of the rcodes of the fingerprint.
+func (t *takeoverChecks) unclaimedZone(ctx context.Context, zone, ns string, fp *takeoverFingerprint) (int, bool) {
+	var addrs []string
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		resp, err := t.enum.queryBlocking(ctx, resolve.QueryMsg(ns, qtype), t.enum.Sys.TrustedResolvers())
+		if err != nil || resp == nil {
+			continue
+		}
+		if resp.Rcode == dns.RcodeNameError {
+			return resp.Rcode, true
+		}
+
+		for _, rr := range resolve.AnswersByType(resolve.ExtractAnswers(resp), qtype) {
+			addrs = append(addrs, rr.Data)
+		}
+	}
+
+	network := "udp"
+	// UDP is not sent through the SOCKS5 proxy
+	if t.enum.tcpOnly() {
+		network = "tcp"
+	}
+
+	rcode := -1
+	for _, addr := range addrs {
+		msg := resolve.QueryMsg(zone, dns.TypeSOA)
+		t.enum.setEDNSBufferSize(msg)
+		msg.RecursionDesired = false
+
+		resp, err := t.enum.exchange(ctx, network, net.JoinHostPort(addr, t.port), msg)
+		if err != nil || resp == nil {
+			continue
+		}
+		if _, found := fp.rcodes[resp.Rcode]; !found {
+			return resp.Rcode, false
+		}
+		rcode = resp.Rcode
+	}
+	return rcode, rcode != -1
+}
+
+// TakeoverFindings returns the in scope names delegated to unclaimed targets of the cloud services,
+// sorted by name. The names are only checked when DetectTakeovers has been enabled.
+func (e *Enumeration) TakeoverFindings() []TakeoverFinding {
+	if e.takeover == nil {
+		return nil
+	}
+
+	e.takeover.Lock()
+	defer e.takeover.Unlock()
+
+	results := append([]TakeoverFinding(nil), e.takeover.findings...)
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Name == results[j].Name {
+			return results[i].Target < results[j].Target
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
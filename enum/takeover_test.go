@@ -0,0 +1,160 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/caffix/netmap"
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestTakeoverChecks(t *testing.T) {
+	addr := startTakeoverServer(t)
+	_, port, _ := net.SplitHostPort(addr)
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.TrustedResolvers = []string{addr}
+	e := &Enumeration{
+		Config:          cfg,
+		Sys:             &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: resolve.NewResolvers()},
+		ForceTCP:        true,
+		DetectTakeovers: true,
+	}
+	defer e.Sys.Resolvers().Stop()
+	defer e.Sys.TrustedResolvers().Stop()
+
+	tc, err := newTakeoverChecks(e)
+	if err != nil {
+		t.Fatalf("Failed to compile the default fingerprints: %v", err)
+	}
+	tc.port = port
+	e.takeover = tc
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tc.check(ctx, "app.owasp.org", "CNAME", "gone.azurewebsites.net.")
+	tc.check(ctx, "www.owasp.org", "CNAME", "live.azurewebsites.net")
+	tc.check(ctx, "blog.owasp.org", "CNAME", "gone.example.com")
+	tc.check(ctx, "dangling.owasp.org", "NS", "ns1.digitalocean.com")
+	tc.check(ctx, "claimed.owasp.org", "NS", "ns1.digitalocean.com")
+
+	findings := e.TakeoverFindings()
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, but got %d: %v", len(findings), findings)
+	}
+	if f := findings[0]; f.Name != "app.owasp.org" || f.Target != "gone.azurewebsites.net" ||
+		f.Service != "Azure App Service" || f.Rcode != "NXDOMAIN" || f.Severity != TakeoverSeverity {
+		t.Errorf("unexpected CNAME finding: %+v", f)
+	}
+	if f := findings[1]; f.Name != "dangling.owasp.org" || f.Type != "NS" ||
+		f.Service != "DigitalOcean DNS" || f.Rcode != "REFUSED" || f.Fingerprint == "" {
+		t.Errorf("unexpected NS finding: %+v", f)
+	}
+
+	for _, fp := range []TakeoverFingerprint{
+		{Service: "bad type", Type: "MX", Pattern: `example\.com$`},
+		{Service: "bad pattern", Type: "CNAME", Pattern: `(`},
+		{Service: "bad rcode", Type: "NS", Pattern: `example\.com$`, Rcodes: []string{"NOERROR"}},
+	} {
+		e.TakeoverFingerprints = []TakeoverFingerprint{fp}
+		if _, err := newTakeoverChecks(e); err == nil {
+			t.Errorf("the fingerprint %s was accepted", fp.Service)
+		}
+	}
+}
+
+func TestTakeoverChecksTracked(t *testing.T) {
+	addr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		q := req.Question[0]
+		switch {
+		case q.Name == "app.owasp.org." && (q.Qtype == dns.TypeA || q.Qtype == dns.TypeCNAME):
+			m.Answer = append(m.Answer, &dns.CNAME{
+				Hdr:    dns.RR_Header{Name: q.Name, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 300},
+				Target: "gone.azurewebsites.net.",
+			})
+		default:
+			m.Rcode = dns.RcodeNameError
+		}
+		_ = w.WriteMsg(m)
+	})
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.ProvidedNames = []string{"app.owasp.org"}
+	cfg.Resolvers = []string{addr}
+	cfg.ResolversQPS, cfg.TrustedQPS = 1000, 1000
+	cfg.TrustedResolvers = []string{addr}
+	pool := resolve.NewResolvers()
+	_ = pool.AddResolvers(10, addr)
+	defer pool.Stop()
+	trusted := resolve.NewResolvers()
+	_ = trusted.AddResolvers(10, addr)
+	defer trusted.Stop()
+	g := netmap.NewGraph("local", filepath.Join(t.TempDir(), "graph.db"), "")
+	defer g.Remove()
+
+	src := newTestSource("Inventory")
+	defer func() { _ = src.Stop() }()
+	sys := &systems.SimpleSystem{Cfg: cfg, Pool: pool, Trusted: trusted, Graph: g, Service: src}
+	e := NewEnumeration(cfg, sys, g)
+	e.ForceTCP = true
+	e.DetectTakeovers = true
+	e.MaxDuration = 2 * time.Second
+
+	if err := e.Start(context.Background()); err != nil && err != context.DeadlineExceeded {
+		t.Fatalf("the enumeration failed: %v", err)
+	}
+
+	findings := e.TakeoverFindings()
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding once the enumeration returned, but got %d: %v", len(findings), findings)
+	}
+	if f := findings[0]; f.Name != "app.owasp.org" || f.Target != "gone.azurewebsites.net" || f.Rcode != "NXDOMAIN" {
+		t.Errorf("unexpected CNAME finding: %+v", f)
+	}
+}
+
+// startTakeoverServer returns the address of a DNS server acting as the resolver and the nameserver
+// of the cloud services, where gone.azurewebsites.net and the zone of dangling.owasp.org are unclaimed.
+func startTakeoverServer(t *testing.T) string {
+	return startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		q := req.Question[0]
+		switch {
+		case q.Qtype == dns.TypeSOA && q.Name == "dangling.owasp.org.":
+			m.Rcode = dns.RcodeRefused
+		case q.Qtype == dns.TypeSOA:
+			m.Authoritative = true
+			m.Answer = append(m.Answer, &dns.SOA{
+				Hdr:  dns.RR_Header{Name: q.Name, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 300},
+				Ns:   "ns1.digitalocean.com.",
+				Mbox: "hostmaster." + q.Name,
+			})
+		case q.Name == "gone.azurewebsites.net.":
+			m.Rcode = dns.RcodeNameError
+		case q.Qtype == dns.TypeA:
+			m.Answer = append(m.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+				A:   net.ParseIP("127.0.0.1"),
+			})
+		}
+		_ = w.WriteMsg(m)
+	})
+}
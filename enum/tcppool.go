@@ -0,0 +1,143 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// defaultTCPPoolIdleTimeout is used when TCPPoolIdleTimeout is not set, and follows
+// the idle timeout recommended for the DNS servers in RFC 7766.
+const defaultTCPPoolIdleTimeout = 10 * time.Second
+
+type idleConn struct {
+	conn *dns.Conn
+	used time.Time
+}
+
+// tcpPool keeps the idle TCP connections to each DNS server so that the following queries
+// to the same server avoid the handshakes. Each connection carries one query at a time.
+// The zone transfers stream many messages over the connection, so they dial their own.
+type tcpPool struct {
+	sync.Mutex
+	maxIdle int
+	timeout time.Duration
+	dial    func(ctx context.Context, network, addr string) (net.Conn, error)
+	idle    map[string][]*idleConn
+	closed  bool
+}
+
+func newTCPPool(e *Enumeration) *tcpPool {
+	timeout := e.TCPPoolIdleTimeout
+	if timeout <= 0 {
+		timeout = defaultTCPPoolIdleTimeout
+	}
+
+	return &tcpPool{
+		maxIdle: e.TCPPoolMaxIdle,
+		timeout: timeout,
+		dial:    e.dial,
+		idle:    make(map[string][]*idleConn),
+	}
+}
+
+// exchange sends the message over a connection to the server at addr. When a reused connection
+// fails, as happens after the server closes it, the message is sent again over a fresh one.
+func (p *tcpPool) exchange(ctx context.Context, addr string, msg *dns.Msg) (*dns.Msg, error) {
+	for {
+		co, reused, err := p.get(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := exchangeConn(ctx, co, msg)
+		if err == nil {
+			p.put(addr, co)
+			return resp, nil
+		}
+
+		_ = co.Close()
+		if !reused || ctx.Err() != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *tcpPool) get(ctx context.Context, addr string) (*dns.Conn, bool, error) {
+	now := time.Now()
+
+	p.Lock()
+	for conns := p.idle[addr]; len(conns) > 0; conns = p.idle[addr] {
+		// take the most recently used connection
+		ic := conns[len(conns)-1]
+		p.idle[addr] = conns[:len(conns)-1]
+
+		if now.Sub(ic.used) < p.timeout {
+			p.Unlock()
+			return ic.conn, true, nil
+		}
+		_ = ic.conn.Close()
+	}
+	p.Unlock()
+
+	conn, err := p.dial(ctx, "tcp", addr)
+	if err != nil {
+		return nil, false, err
+	}
+	return &dns.Conn{Conn: conn}, false, nil
+}
+
+func (p *tcpPool) put(addr string, co *dns.Conn) {
+	_ = co.SetDeadline(time.Time{})
+
+	p.Lock()
+	defer p.Unlock()
+
+	if p.closed || len(p.idle[addr]) >= p.maxIdle {
+		_ = co.Close()
+		return
+	}
+	p.idle[addr] = append(p.idle[addr], &idleConn{conn: co, used: time.Now()})
+}
+
+// stop closes the idle connections, and the connections returned afterwards.
+func (p *tcpPool) stop() {
+	p.Lock()
+	defer p.Unlock()
+
+	p.closed = true
+	for addr, conns := range p.idle {
+		for _, ic := range conns {
+			_ = ic.conn.Close()
+		}
+		delete(p.idle, addr)
+	}
+}
+
+// exchangeConn writes the message to the connection and reads the response within the context deadline.
+func exchangeConn(ctx context.Context, co *dns.Conn, msg *dns.Msg) (*dns.Msg, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = co.SetDeadline(deadline)
+	}
+
+	if opt := msg.IsEdns0(); opt != nil {
+		co.UDPSize = opt.UDPSize()
+	}
+	if err := co.WriteMsg(msg); err != nil {
+		return nil, err
+	}
+
+	resp, err := co.ReadMsg()
+	if err == nil && resp.Id != msg.Id {
+		return nil, errors.New("the response ID does not match the query")
+	}
+	return resp, err
+}
@@ -0,0 +1,122 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+type countingListener struct {
+	net.Listener
+	accepted int64
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		atomic.AddInt64(&l.accepted, 1)
+	}
+	return conn, err
+}
+
+// tcpPoolServer starts a DNS server over TCP that counts the accepted connections,
+// and closes each connection after the response when closeConns is true.
+func tcpPoolServer(tb testing.TB, closeConns bool) (*countingListener, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatalf("Failed to listen on a TCP port: %v", err)
+	}
+
+	cl := &countingListener{Listener: l}
+	stop := serveMockDNS(tb, cl, nil, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		m.Answer = append(m.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: req.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+			A:   net.IP{192, 0, 2, 1},
+		})
+		_ = w.WriteMsg(m)
+		if closeConns {
+			_ = w.Close()
+		}
+	})
+	return cl, stop
+}
+
+func TestTCPPool(t *testing.T) {
+	for _, test := range []struct {
+		label      string
+		maxIdle    int
+		idle       time.Duration
+		closeConns bool
+		expected   int64
+	}{
+		{"no pool", 0, 0, false, 5},
+		{"pool", 2, time.Minute, false, 1},
+		{"expired", 2, time.Nanosecond, false, 5},
+		{"closed by the server", 2, time.Minute, true, 5},
+	} {
+		cl, shutdown := tcpPoolServer(t, test.closeConns)
+
+		e := &Enumeration{TCPPoolMaxIdle: test.maxIdle, TCPPoolIdleTimeout: test.idle}
+		if e.TCPPoolMaxIdle > 0 {
+			e.tcpConns = newTCPPool(e)
+		}
+
+		for i := 0; i < 5; i++ {
+			msg := resolve.QueryMsg("www.owasp.org", dns.TypeA)
+			resp, err := e.exchange(context.Background(), "tcp", cl.Addr().String(), msg)
+			if err != nil || len(resp.Answer) == 0 {
+				t.Errorf("%s: query %d failed: %v", test.label, i, err)
+			}
+		}
+		if e.tcpConns != nil {
+			e.tcpConns.stop()
+		}
+		shutdown()
+
+		if n := atomic.LoadInt64(&cl.accepted); n != test.expected {
+			t.Errorf("%s: expected %d connections, but the server accepted %d", test.label, test.expected, n)
+		}
+	}
+}
+
+func BenchmarkTCPQueries(b *testing.B) {
+	for _, bench := range []struct {
+		label   string
+		maxIdle int
+	}{
+		{"per-query", 0},
+		{"pooled", 4},
+	} {
+		b.Run(bench.label, func(b *testing.B) {
+			cl, shutdown := tcpPoolServer(b, false)
+			defer shutdown()
+
+			e := &Enumeration{TCPPoolMaxIdle: bench.maxIdle}
+			if e.TCPPoolMaxIdle > 0 {
+				e.tcpConns = newTCPPool(e)
+				defer e.tcpConns.stop()
+			}
+
+			addr := cl.Addr().String()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				msg := resolve.QueryMsg("www.owasp.org", dns.TypeA)
+				if _, err := e.exchange(context.Background(), "tcp", addr, msg); err != nil {
+					b.Fatalf("the query failed: %v", err)
+				}
+			}
+			b.ReportMetric(float64(atomic.LoadInt64(&cl.accepted))/float64(b.N), "handshakes/op")
+		})
+	}
+}
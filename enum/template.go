@@ -0,0 +1,126 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+// TemplateResult is the data available to the OutputTemplate for each resolved name, such as
+// {{.Name}} {{join .Addresses ","}} or {{range .Records}}{{.Type}} {{.Data}} {{end}}.
+type TemplateResult struct {
+	Name      string
+	Domain    string
+	Addresses []string
+	Records   []TemplateRecord
+	Tag       string
+	Source    string
+	Active    bool
+}
+
+// TemplateRecord is a DNS record of the resolved name, with the type in its text form.
+type TemplateRecord struct {
+	Name string
+	Type string
+	TTL  int
+	Data string
+}
+
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// templateOutput renders each resolved name through the template and writes it on its own line.
+type templateOutput struct {
+	sync.Mutex
+	tmpl *template.Template
+	w    io.Writer
+	// comp is the compressor wrapping the OutputWriter when CompressOutput has been set
+	comp compressor
+}
+
+func newTemplateOutput(text string, w io.Writer) (*templateOutput, error) {
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the output template: %v", err)
+	}
+	// catch the references to unknown fields before the enumeration starts
+	if err := tmpl.Execute(io.Discard, &TemplateResult{}); err != nil {
+		return nil, fmt.Errorf("failed to evaluate the output template: %v", err)
+	}
+
+	return &templateOutput{tmpl: tmpl, w: w}, nil
+}
+
+func (to *templateOutput) write(req *requests.DNSRequest, _ []string) error {
+	var buf bytes.Buffer
+
+	if err := to.tmpl.Execute(&buf, newTemplateResult(req)); err != nil {
+		return err
+	}
+	if b := buf.Bytes(); len(b) == 0 || b[len(b)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+
+	to.Lock()
+	defer to.Unlock()
+
+	_, err := to.w.Write(buf.Bytes())
+	return err
+}
+
+// stop completes the compressed stream of the results, and the OutputWriter is not closed.
+func (to *templateOutput) stop() error {
+	if to.comp == nil {
+		return nil
+	}
+
+	to.Lock()
+	defer to.Unlock()
+
+	return to.comp.Close()
+}
+
+func newTemplateResult(req *requests.DNSRequest) *TemplateResult {
+	result := &TemplateResult{
+		Name:   req.Name,
+		Domain: req.Domain,
+		Tag:    req.Tag,
+		Source: req.Source,
+		Active: req.Active,
+	}
+
+	for _, rec := range req.Records {
+		rrtype, found := dns.TypeToString[uint16(rec.Type)]
+		if !found {
+			rrtype = fmt.Sprintf("TYPE%d", rec.Type)
+		}
+
+		result.Records = append(result.Records, TemplateRecord{
+			Name: rec.Name,
+			Type: rrtype,
+			TTL:  rec.TTL,
+			Data: rec.Data,
+		})
+		if t := uint16(rec.Type); (t != dns.TypeA && t != dns.TypeAAAA) || isGlue(rec) || sectionRecord(rec) {
+			continue
+		}
+		if ip := net.ParseIP(rec.Data); ip != nil {
+			result.Addresses = append(result.Addresses, ip.String())
+		}
+	}
+	return result
+}
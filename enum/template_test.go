@@ -0,0 +1,57 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func TestTemplateOutput(t *testing.T) {
+	req := &requests.DNSRequest{
+		Name:   "www.owasp.org",
+		Domain: "owasp.org",
+		Tag:    requests.DNS,
+		Source: "DNS",
+		Records: []requests.DNSAnswer{
+			{Name: "www.owasp.org", Type: int(dns.TypeCNAME), TTL: 300, Data: "owasp.org"},
+			{Name: "owasp.org", Type: int(dns.TypeA), TTL: 60, Data: "192.0.2.1"},
+			{Name: "owasp.org", Type: int(dns.TypeAAAA), TTL: 60, Data: "2001:db8::1"},
+		},
+	}
+
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{`{{.Name}} {{join .Addresses ","}}`, "www.owasp.org 192.0.2.1,2001:db8::1\n"},
+		{`{{.Domain}} {{.Tag}} {{upper .Source}}` + "\n", "owasp.org dns DNS\n"},
+		{`{{range .Records}}{{.Type}}={{.Data}} {{end}}`, "CNAME=owasp.org A=192.0.2.1 AAAA=2001:db8::1 \n"},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+
+		to, err := newTemplateOutput(test.text, &buf)
+		if err != nil {
+			t.Fatalf("Failed to parse the template %s: %v", test.text, err)
+		}
+		if err := to.write(req, nil); err != nil {
+			t.Errorf("Failed to render the template %s: %v", test.text, err)
+		}
+		if got := buf.String(); got != test.expected {
+			t.Errorf("%s: expected %q, but got %q", test.text, test.expected, got)
+		}
+	}
+
+	for _, text := range []string{`{{.Name`, `{{.Unknown}}`, `{{missing .Name}}`} {
+		if _, err := newTemplateOutput(text, &bytes.Buffer{}); err == nil {
+			t.Errorf("the invalid template %s was accepted", text)
+		}
+	}
+}
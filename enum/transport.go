@@ -0,0 +1,363 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+	"golang.org/x/net/proxy"
+)
+
+const tcpQueryTimeout = 10 * time.Second
+
+// tcpOnly returns true when the DNS queries must be sent over TCP.
+func (e *Enumeration) tcpOnly() bool {
+	return e.ForceTCP || e.SOCKS5Proxy != ""
+}
+
+// setEDNSBufferSize advertises the configured EDNS0 UDP buffer size in the DNS message.
+func (e *Enumeration) setEDNSBufferSize(msg *dns.Msg) {
+	size := e.EDNSBufferSize
+	if size == 0 {
+		size = defaultEDNSBufferSize
+	}
+
+	if opt := msg.IsEdns0(); opt != nil {
+		opt.SetUDPSize(size)
+		return
+	}
+	msg.SetEdns0(size, false)
+}
+
+// setRecursionDesired clears the RD bit of the DNS message when only the resolver caches are queried.
+func (e *Enumeration) setRecursionDesired(msg *dns.Msg) {
+	msg.RecursionDesired = !e.NonRecursive
+}
+
+// spendQuery returns false once the MaxTotalQueries budget has been spent, and cancels the enumeration
+// when that happens, so the data source requests stop and the pipeline drains. Each query counts once,
+// including the retry over TCP of a truncated response.
+func (e *Enumeration) spendQuery() bool {
+	if e.MaxTotalQueries <= 0 {
+		return true
+	}
+
+	n := e.queries.Add(1)
+	if n == e.MaxTotalQueries+1 {
+		e.log().Warnf("The budget of %d DNS queries has been spent, stopping the enumeration", e.MaxTotalQueries)
+		if e.cancel != nil {
+			e.cancel()
+		}
+	}
+	return n <= e.MaxTotalQueries
+}
+
+// budgetSpent returns true when the queries are being refused due to MaxTotalQueries.
+func (e *Enumeration) budgetSpent() bool {
+	return e.MaxTotalQueries > 0 && e.queries.Load() > e.MaxTotalQueries
+}
+
+// jitter waits for a random period up to QueryJitter, and returns false when the context expires first.
+func (e *Enumeration) jitter(ctx context.Context) bool {
+	if e.QueryJitter <= 0 {
+		return true
+	}
+
+	t := time.NewTimer(time.Duration(rand.Int63n(int64(e.QueryJitter) + 1)))
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+	}
+	return true
+}
+
+// query sends the DNS message using the pool of the task, or over TCP when required by the settings.
+// The request is removed from the registry when the query budget has been spent, or when the
+// context expires during the jitter or ramp-up, which delay the query without blocking the task.
+func (dt *dnsTask) query(ctx context.Context, msg *dns.Msg) {
+	if !dt.enum.spendQuery() {
+		go dt.delReqWithDecrement(key(msg.Id, msg.Question[0].Name))
+		return
+	}
+	if dt.enum.QueryJitter > 0 || dt.enum.rampingUp(dt.trusted) {
+		go func() {
+			if !dt.enum.jitter(ctx) || !dt.enum.rampUp(ctx, dt.trusted) {
+				dt.delReqWithDecrement(key(msg.Id, msg.Question[0].Name))
+				return
+			}
+			dt.send(ctx, msg)
+		}()
+		return
+	}
+	dt.send(ctx, msg)
+}
+
+func (dt *dnsTask) send(ctx context.Context, msg *dns.Msg) {
+	dt.enum.setEDNSBufferSize(msg)
+	dt.enum.setRecursionDesired(msg)
+	if dt.enum.cookies != nil {
+		dt.enum.cookies.add(msg, "")
+	}
+	if dt.enum.tcpOnly() {
+		go dt.tcpQuery(ctx, msg)
+		return
+	}
+	// the pools bind their own sockets and select among their resolvers
+	if dt.enum.SourcePortRange.set() || dt.enum.bound() || dt.enum.forced() {
+		go dt.directQuery(ctx, "udp", msg)
+		return
+	}
+	if dt.enum.rawlog != nil {
+		dt.recordedQuery(ctx, msg)
+		return
+	}
+	dt.resolvers(msg.Question[0].Qtype).Query(ctx, msg, dt.resps)
+}
+
+// recordedQuery sends the DNS message using the pool of the task, and writes the
+// request and response to the raw response log before the response is processed.
+func (dt *dnsTask) recordedQuery(ctx context.Context, msg *dns.Msg) {
+	pool := dt.resolvers(msg.Question[0].Qtype)
+
+	reqb, err := msg.Pack()
+	if err != nil {
+		pool.Query(ctx, msg, dt.resps)
+		return
+	}
+
+	ch := make(chan *dns.Msg, 1)
+	pool.Query(ctx, msg, ch)
+	go func() {
+		select {
+		case <-ctx.Done():
+			return
+		case resp := <-ch:
+			if respb, err := resp.Pack(); err == nil && len(resp.Question) > 0 {
+				dt.enum.rawlog.queue(reqb, respb, resp, dt.trust+" pool")
+			}
+
+			select {
+			case <-ctx.Done():
+			case dt.resps <- resp:
+			}
+		}
+	}()
+}
+
+// resolvers returns the current pool of the task, since the system can replace the untrusted pool.
+// The untrusted queries for record types with dedicated resolvers are sent to the pool of the type.
+func (dt *dnsTask) resolvers(qtype uint16) *resolve.Resolvers {
+	if dt.trusted {
+		return dt.enum.Sys.TrustedResolvers()
+	}
+	return dt.enum.poolForType(qtype, dt.enum.Sys.Resolvers())
+}
+
+// retryTruncated resends the query over TCP, since the response from the pool was truncated.
+func (dt *dnsTask) retryTruncated(ctx context.Context, resp *dns.Msg) {
+	msg := resolve.QueryMsg(resp.Question[0].Name, resp.Question[0].Qtype)
+	// The ID is kept, since it identifies the request in the registry
+	msg.Id = resp.Id
+	go dt.tcpQuery(ctx, msg)
+}
+
+func (dt *dnsTask) tcpQuery(ctx context.Context, msg *dns.Msg) {
+	dt.directQuery(ctx, "tcp", msg)
+}
+
+// directQuery sends the DNS message without the pool, and truncated responses over UDP
+// are processed by the task, which retries the query over TCP.
+func (dt *dnsTask) directQuery(ctx context.Context, network string, msg *dns.Msg) {
+	resp, err := dt.enum.directExchange(ctx, network, msg, dt.trusted)
+	if err != nil || (network == "tcp" && resp.Truncated) {
+		// Allow the task to retry the query
+		resp = new(dns.Msg)
+		resp.SetRcode(msg, dns.RcodeServerFailure)
+	}
+	dt.resps <- resp
+}
+
+// queryBlocking performs the DNS query using the resolver pool, or over TCP when required by
+// the settings. Responses still truncated after the pool attempted TCP are retried over TCP.
+func (e *Enumeration) queryBlocking(ctx context.Context, msg *dns.Msg, r *resolve.Resolvers) (*dns.Msg, error) {
+	if !e.spendQuery() {
+		return nil, errors.New("the DNS query budget has been spent")
+	}
+	if !e.jitter(ctx) {
+		return nil, ctx.Err()
+	}
+	trusted := r != e.Sys.Resolvers()
+	if !e.rampUp(ctx, trusted) {
+		return nil, ctx.Err()
+	}
+	e.setEDNSBufferSize(msg)
+	e.setRecursionDesired(msg)
+	if !trusted {
+		if resp, err := e.affinityExchange(ctx, msg); err == nil {
+			return resp, nil
+		}
+		r = e.poolForType(msg.Question[0].Qtype, r)
+	}
+	if e.tcpOnly() {
+		return e.tcpExchange(ctx, msg, trusted)
+	}
+
+	var resp *dns.Msg
+	var err error
+	if e.SourcePortRange.set() || e.bound() || e.forced() || e.Dialer != nil {
+		resp, err = e.directExchange(ctx, "udp", msg, trusted)
+	} else {
+		resp, err = e.poolExchange(ctx, msg, r, trusted)
+	}
+	if err == nil && resp != nil && resp.Truncated {
+		if tresp, terr := e.tcpExchange(ctx, msg, trusted); terr == nil {
+			return tresp, nil
+		}
+	}
+	return resp, err
+}
+
+// poolExchange sends the DNS message using the resolver pool.
+func (e *Enumeration) poolExchange(ctx context.Context, msg *dns.Msg, r *resolve.Resolvers, trusted bool) (*dns.Msg, error) {
+	if e.cookies != nil {
+		e.cookies.add(msg, "")
+	}
+
+	pool := "untrusted pool"
+	if trusted {
+		pool = "trusted pool"
+	}
+
+	start := time.Now()
+	resp, err := r.QueryBlocking(ctx, msg)
+	e.recordLatency(pool, start, err != nil || resp == nil || resp.Rcode == resolve.RcodeNoResponse)
+	if err == nil && e.rawlog != nil {
+		e.rawlog.record(msg, resp, pool)
+	}
+	if err == nil && resp != nil && e.cookies != nil && !e.cookies.check(resp, "") {
+		return nil, errors.New("the response contained the wrong DNS client cookie")
+	}
+	if err == nil && e.sinkhole != nil {
+		e.sinkhole.check(pool, resp)
+	}
+	return resp, err
+}
+
+// tcpExchange sends the DNS message over a TCP connection to a randomly selected resolver.
+// The connection is dialed through the SOCKS5 proxy when one has been configured.
+func (e *Enumeration) tcpExchange(ctx context.Context, msg *dns.Msg, trusted bool) (*dns.Msg, error) {
+	return e.directExchange(ctx, "tcp", msg, trusted)
+}
+
+// directExchange sends the DNS message to a randomly selected resolver without using the pools,
+// or to the ForceResolver when it has been set.
+func (e *Enumeration) directExchange(ctx context.Context, network string, msg *dns.Msg, trusted bool) (*dns.Msg, error) {
+	addr, err := e.directResolver(msg.Question[0].Qtype, trusted)
+	if err != nil {
+		return nil, err
+	}
+	if !e.paceDirect(ctx, addr, trusted) {
+		return nil, ctx.Err()
+	}
+	return e.addrExchange(ctx, network, addr, msg)
+}
+
+// addrExchange sends the DNS message to the resolver at the host:port without using the pools.
+func (e *Enumeration) addrExchange(ctx context.Context, network, addr string, msg *dns.Msg) (*dns.Msg, error) {
+	e.setRecursionDesired(msg)
+	if e.cookies != nil {
+		e.cookies.add(msg, addr)
+	}
+
+	start := time.Now()
+	resp, err := e.exchange(ctx, network, addr, msg)
+	e.recordLatency(addr, start, err != nil)
+	if err == nil && e.rawlog != nil {
+		e.rawlog.record(msg, resp, addr)
+	}
+	if err == nil && e.cookies != nil && !e.cookies.check(resp, addr) {
+		return nil, errors.New("the response contained the wrong DNS client cookie")
+	}
+	if err == nil && e.sinkhole != nil {
+		e.sinkhole.check(addr, resp)
+	}
+	return resp, err
+}
+
+// directResolver returns the host:port of a randomly selected resolver for the queries sent without the pools.
+func (e *Enumeration) directResolver(qtype uint16, trusted bool) (string, error) {
+	addrs := e.Config.Resolvers
+	if a, found := e.typeAddr[qtype]; found && !trusted {
+		addrs = a
+	}
+	if trusted {
+		addrs = e.Config.TrustedResolvers
+		if len(addrs) == 0 {
+			addrs = config.DefaultBaselineResolvers
+		}
+	}
+	if e.forced() {
+		addrs = []string{e.ForceResolver}
+	} else if e.sinkhole != nil {
+		addrs = e.sinkhole.avoid(addrs)
+	}
+	if len(addrs) == 0 {
+		return "", errors.New("no resolvers are available for the direct query")
+	}
+
+	addr := addrs[rand.Intn(len(addrs))]
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "53")
+	}
+	return addr, nil
+}
+
+// exchange sends the DNS message to the server at addr using connections from amassnet.DialContext,
+// or from a source port within the SourcePortRange when it has been set. The TCP connections are
+// reused across the queries to the same server when the pool has been enabled by TCPPoolMaxIdle.
+func (e *Enumeration) exchange(ctx context.Context, network, addr string, msg *dns.Msg) (*dns.Msg, error) {
+	tctx, cancel := context.WithTimeout(ctx, tcpQueryTimeout)
+	defer cancel()
+
+	if network == "tcp" && e.tcpConns != nil {
+		return e.tcpConns.exchange(tctx, addr, msg)
+	}
+
+	conn, err := e.dial(tctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return exchangeConn(tctx, &dns.Conn{Conn: conn}, msg)
+}
+
+// netDialer returns the dialer of the connections established by the enumeration, where the SOCKS5Proxy
+// takes precedence over the Dialer, or nil when neither has been set.
+func (e *Enumeration) netDialer() proxy.ContextDialer {
+	if e.socks != nil {
+		return e.socks
+	}
+	return e.Dialer
+}
+
+// dial connects to the address through the SOCKS5Proxy or the Dialer when one has been set, or from
+// the SourcePortRange and the source address selected by the SourceIP and Interface.
+func (e *Enumeration) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	if d := e.netDialer(); d != nil {
+		return d.DialContext(ctx, network, addr)
+	}
+	return e.SourcePortRange.dial(ctx, network, addr, e.srcaddr)
+}
@@ -0,0 +1,305 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestQueryBlockingTruncated(t *testing.T) {
+	addr := startLargeTXTServer(t)
+
+	cfg := config.NewConfig()
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	_ = trusted.AddResolvers(10, addr)
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config: cfg,
+		Sys:    &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+	}
+	defer e.Sys.Resolvers().Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, force := range []bool{false, true} {
+		e.ForceTCP = force
+
+		resp, err := e.queryBlocking(ctx, resolve.QueryMsg("big.owasp.org", dns.TypeTXT), trusted)
+		if err != nil || resp == nil {
+			t.Fatalf("ForceTCP %t: the query failed: %v", force, err)
+		}
+		if resp.Truncated || len(resp.Answer) != largeTXTRecords {
+			t.Errorf("ForceTCP %t: expected %d untruncated answers, got %d", force, largeTXTRecords, len(resp.Answer))
+		}
+	}
+}
+
+// recordingDialer keeps the network and address of each connection it establishes.
+type recordingDialer struct {
+	sync.Mutex
+	targets []string
+}
+
+func (d *recordingDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	d.Lock()
+	d.targets = append(d.targets, network+" "+addr)
+	d.Unlock()
+
+	var nd net.Dialer
+	return nd.DialContext(ctx, network, addr)
+}
+
+func TestCustomDialer(t *testing.T) {
+	addr := startLargeTXTServer(t)
+
+	cfg := config.NewConfig()
+	cfg.TrustedResolvers = []string{addr}
+	// the pools have no resolvers, so the queries only succeed through the dialer
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	d := &recordingDialer{}
+	e := &Enumeration{
+		Config: cfg,
+		Sys:    &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		Dialer: d,
+	}
+	defer e.Sys.Resolvers().Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := e.queryBlocking(ctx, resolve.QueryMsg("big.owasp.org", dns.TypeTXT), trusted)
+	if err != nil || resp == nil || len(resp.Answer) != largeTXTRecords {
+		t.Fatalf("the query through the dialer failed: %v", err)
+	}
+
+	d.Lock()
+	defer d.Unlock()
+	// the truncated response over UDP is followed by the query over TCP
+	expected := []string{"udp " + addr, "tcp " + addr}
+	if strings.Join(d.targets, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected the dial targets %v, but got %v", expected, d.targets)
+	}
+}
+
+func TestEDNSBufferSize(t *testing.T) {
+	sizes := make(chan uint16, 1)
+	addr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		var size uint16
+		if opt := req.IsEdns0(); opt != nil {
+			size = opt.UDPSize()
+		}
+		sizes <- size
+
+		m := new(dns.Msg)
+		m.SetReply(req)
+		_ = w.WriteMsg(m)
+	})
+
+	cfg := config.NewConfig()
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP: true,
+	}
+	defer e.Sys.Resolvers().Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, test := range []struct {
+		size     uint16
+		expected uint16
+	}{
+		{0, defaultEDNSBufferSize},
+		{dns.MinMsgSize, dns.MinMsgSize},
+		{4096, 4096},
+		{dns.MaxMsgSize, dns.MaxMsgSize},
+	} {
+		e.EDNSBufferSize = test.size
+
+		if _, err := e.queryBlocking(ctx, resolve.QueryMsg("www.owasp.org", dns.TypeA), trusted); err != nil {
+			t.Fatalf("Size %d: the query failed: %v", test.size, err)
+		}
+		if got := <-sizes; got != test.expected {
+			t.Errorf("Size %d: expected the OPT record to advertise %d, but got %d", test.size, test.expected, got)
+		}
+	}
+}
+
+func TestNonRecursive(t *testing.T) {
+	rd := make(chan bool, 1)
+	addr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		rd <- req.RecursionDesired
+
+		m := new(dns.Msg)
+		m.SetReply(req)
+		_ = w.WriteMsg(m)
+	})
+
+	cfg := config.NewConfig()
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP: true,
+	}
+	defer e.Sys.Resolvers().Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, nonrecursive := range []bool{false, true} {
+		e.NonRecursive = nonrecursive
+
+		if _, err := e.queryBlocking(ctx, resolve.QueryMsg("www.owasp.org", dns.TypeA), trusted); err != nil {
+			t.Fatalf("NonRecursive %t: the query failed: %v", nonrecursive, err)
+		}
+		if got := <-rd; got == nonrecursive {
+			t.Errorf("NonRecursive %t: the query had the RD bit set to %t", nonrecursive, got)
+		}
+	}
+}
+
+func TestMaxTotalQueries(t *testing.T) {
+	var queries int32
+	addr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		atomic.AddInt32(&queries, 1)
+
+		m := new(dns.Msg)
+		m.SetReply(req)
+		_ = w.WriteMsg(m)
+	})
+
+	cfg := config.NewConfig()
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:          cfg,
+		Sys:             &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP:        true,
+		MaxTotalQueries: 2,
+	}
+	defer e.Sys.Resolvers().Stop()
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+	defer e.cancel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for i := 1; i <= 3; i++ {
+		_, err := e.queryBlocking(ctx, resolve.QueryMsg("www.owasp.org", dns.TypeA), trusted)
+		if i <= 2 && err != nil {
+			t.Errorf("query %d within the budget failed: %v", i, err)
+		} else if i > 2 && err == nil {
+			t.Errorf("query %d beyond the budget was sent", i)
+		}
+		// the enumeration begins draining once a query is refused
+		if stopped := e.ctx.Err() != nil; stopped != (i > 2) {
+			t.Errorf("after query %d, expected the enumeration stopped to be %t, but got %t", i, i > 2, stopped)
+		}
+	}
+	if n := atomic.LoadInt32(&queries); n != 2 {
+		t.Errorf("expected the server to receive 2 queries, but got %d", n)
+	}
+	if !e.budgetSpent() {
+		t.Errorf("the budget was not reported as spent")
+	}
+}
+
+func TestQueryJitter(t *testing.T) {
+	e := &Enumeration{QueryJitter: 20 * time.Millisecond}
+
+	for i := 0; i < 5; i++ {
+		start := time.Now()
+		if !e.jitter(context.Background()) {
+			t.Fatalf("the jitter failed without the context expiring")
+		}
+		if d := time.Since(start); d > time.Second {
+			t.Errorf("the jitter waited %s beyond the configured maximum", d)
+		}
+	}
+
+	// the jitter must not delay the shutdown
+	e.QueryJitter = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := e.queryBlocking(ctx, resolve.QueryMsg("www.owasp.org", dns.TypeA), nil)
+	if err == nil {
+		t.Errorf("the query was sent after the context expired")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("the jitter delayed the cancellation by %s", d)
+	}
+
+	// zero disables the jitter
+	e.QueryJitter = 0
+	if !e.jitter(ctx) {
+		t.Errorf("the jitter waited while disabled")
+	}
+}
+
+const largeTXTRecords = 20
+
+// startLargeTXTServer returns the address of a DNS server that truncates its large TXT responses over UDP.
+func startLargeTXTServer(t *testing.T) string {
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on a TCP port: %v", err)
+	}
+	udp, err := net.ListenPacket("udp", tcp.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to listen on a UDP port: %v", err)
+	}
+
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		for i := 0; i < largeTXTRecords; i++ {
+			m.Answer = append(m.Answer, &dns.TXT{
+				Hdr: dns.RR_Header{Name: req.Question[0].Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 300},
+				Txt: []string{strings.Repeat("a", 200)},
+			})
+		}
+		if w.RemoteAddr().Network() == "udp" {
+			m.Truncate(dns.MinMsgSize)
+		}
+		_ = w.WriteMsg(m)
+	})
+
+	serveMockDNS(t, tcp, nil, handler)
+	serveMockDNS(t, nil, udp, handler)
+	return tcp.Addr().String()
+}
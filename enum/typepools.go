@@ -0,0 +1,65 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/resolve"
+)
+
+// parseResolversByType validates the record type names of ResolversByType, and returns the resolvers for each type.
+func (e *Enumeration) parseResolversByType() (map[uint16][]string, error) {
+	addrs := make(map[uint16][]string)
+
+	for name, resolvers := range e.ResolversByType {
+		qtype, found := dns.StringToType[strings.ToUpper(strings.TrimSpace(name))]
+		if !found {
+			return nil, fmt.Errorf("the resolver record type %s is not valid", name)
+		}
+		if len(resolvers) == 0 {
+			return nil, fmt.Errorf("no resolvers were provided for the %s record type", name)
+		}
+		addrs[qtype] = append(addrs[qtype], resolvers...)
+	}
+	return addrs, nil
+}
+
+// buildTypePools creates a resolver pool for each of the record types in ResolversByType.
+func (e *Enumeration) buildTypePools() error {
+	addrs, err := e.parseResolversByType()
+	if err != nil {
+		return err
+	}
+
+	e.typeAddr = addrs
+	e.typePool = make(map[uint16]*resolve.Resolvers, len(addrs))
+	for qtype, resolvers := range addrs {
+		pool := systems.NewUntrustedPool(e.Config, resolvers)
+		if pool.Len() == 0 {
+			e.stopTypePools()
+			return fmt.Errorf("none of the resolvers for the %s record type are usable", dns.TypeToString[qtype])
+		}
+		e.typePool[qtype] = pool
+	}
+	return nil
+}
+
+func (e *Enumeration) stopTypePools() {
+	for _, pool := range e.typePool {
+		pool.Stop()
+	}
+}
+
+// poolForType returns the resolver pool assigned to the record type, or the provided default pool.
+func (e *Enumeration) poolForType(qtype uint16, def *resolve.Resolvers) *resolve.Resolvers {
+	if pool, found := e.typePool[qtype]; found {
+		return pool
+	}
+	return def
+}
@@ -0,0 +1,97 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestParseResolversByType(t *testing.T) {
+	e := &Enumeration{ResolversByType: map[string][]string{
+		" txt ": {"192.0.2.1"},
+		"A":     {"192.0.2.2", "192.0.2.3"},
+	}}
+
+	addrs, err := e.parseResolversByType()
+	if err != nil {
+		t.Fatalf("the valid record types were rejected: %v", err)
+	}
+	if len(addrs[dns.TypeTXT]) != 1 || len(addrs[dns.TypeA]) != 2 || len(addrs) != 2 {
+		t.Errorf("unexpected resolvers by type: %v", addrs)
+	}
+
+	for _, invalid := range []map[string][]string{
+		{"BOGUS": {"192.0.2.1"}},
+		{"MX": nil},
+	} {
+		e.ResolversByType = invalid
+		if _, err := e.parseResolversByType(); err == nil {
+			t.Errorf("the invalid setting %v was accepted", invalid)
+		}
+	}
+}
+
+func TestResolversByType(t *testing.T) {
+	start := func(hits chan string, label string) string {
+		addr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+			hits <- label
+
+			m := new(dns.Msg)
+			m.SetReply(req)
+			_ = w.WriteMsg(m)
+		})
+		return addr
+	}
+
+	hits := make(chan string, 1)
+	cfg := config.NewConfig()
+	cfg.Resolvers = []string{start(hits, "default")}
+	cfg.TrustedResolvers = []string{start(hits, "trusted")}
+	txt := start(hits, "txt")
+
+	pool := resolve.NewResolvers()
+	defer pool.Stop()
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:          cfg,
+		Sys:             &systems.SimpleSystem{Cfg: cfg, Pool: pool, Trusted: trusted},
+		ForceTCP:        true,
+		ResolversByType: map[string][]string{"TXT": {txt}},
+	}
+	addrs, err := e.parseResolversByType()
+	if err != nil {
+		t.Fatalf("failed to parse the resolvers by type: %v", err)
+	}
+	e.typeAddr = addrs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, test := range []struct {
+		qtype    uint16
+		pool     *resolve.Resolvers
+		expected string
+	}{
+		{dns.TypeTXT, pool, "txt"},
+		{dns.TypeA, pool, "default"},
+		{dns.TypeTXT, trusted, "trusted"},
+	} {
+		if _, err := e.queryBlocking(ctx, resolve.QueryMsg("owasp.org", test.qtype), test.pool); err != nil {
+			t.Fatalf("the %s query failed: %v", dns.TypeToString[test.qtype], err)
+		}
+		if got := <-hits; got != test.expected {
+			t.Errorf("the %s query was sent to the %s resolver, expected %s", dns.TypeToString[test.qtype], got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/datasrcs/scripting"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+// defaultURIServices are the service names queried for URI records when the user has not
+// provided the SRV services, such as the examples of RFC 7553.
+var defaultURIServices = []string{
+	"_http._tcp",
+	"_https._tcp",
+	"_ftp._tcp",
+	"_sip._tcp",
+	"_sip._udp",
+	"_sips._tcp",
+	"_ldap._tcp",
+	"_kerberos._udp",
+}
+
+// buildURIServices prepares the _service._proto label prefixes queried for URI records, using the
+// SRV services provided by the user, or the defaults, followed by the names of the SRVPortProbes.
+func (e *Enumeration) buildURIServices() {
+	services := scripting.SRVServiceNames()
+	if len(services) == 0 {
+		services = defaultURIServices
+	}
+
+	seen := make(map[string]struct{})
+	e.urisvcs = nil
+	for _, name := range append(append([]string(nil), services...), e.srvports...) {
+		if _, found := seen[name]; found {
+			continue
+		}
+
+		seen[name] = struct{}{}
+		e.urisvcs = append(e.urisvcs, name)
+	}
+
+	if len(e.urisvcs) > maxSRVPortProbes {
+		e.log().Warnf("URI records: only the first %d of %d names are queried for each subdomain", maxSRVPortProbes, len(e.urisvcs))
+		e.urisvcs = e.urisvcs[:maxSRVPortProbes]
+	}
+}
+
+// queryURI obtains the URI records advertised under the service names of the subdomain, which are
+// not extracted by the resolve package.
+func (dt *dnsTask) queryURI(ctx context.Context, name string, ch chan []requests.DNSAnswer) {
+	var records []requests.DNSAnswer
+
+	for _, prefix := range dt.enum.urisvcs {
+		select {
+		case <-ctx.Done():
+			ch <- records
+			return
+		default:
+		}
+
+		resp, err := dt.enum.dnsQuery(ctx, prefix+"."+name, dns.TypeURI, dt.enum.Sys.TrustedResolvers(), maxDNSQueryAttempts)
+		if err != nil {
+			continue
+		}
+		if rr := uriAnswers(resp); len(rr) > 0 {
+			records = append(records, truncateAnswers(rr, dt.enum.MaxAnswerBytes)...)
+		}
+	}
+	ch <- records
+}
+
+// uriAnswers converts the URI records in the answer section, and decodes the priority, weight and target.
+func uriAnswers(resp *dns.Msg) []requests.DNSAnswer {
+	if resp == nil {
+		return nil
+	}
+
+	var answers []requests.DNSAnswer
+	for _, rr := range resp.Answer {
+		uri, ok := rr.(*dns.URI)
+		if !ok || uri.Target == "" {
+			continue
+		}
+
+		answers = append(answers, requests.DNSAnswer{
+			Name: resolve.RemoveLastDot(uri.Hdr.Name),
+			Type: int(dns.TypeURI),
+			TTL:  int(uri.Hdr.Ttl),
+			Data: uri.Target,
+			URI: &requests.URIData{
+				Priority: uri.Priority,
+				Weight:   uri.Weight,
+				Target:   uri.Target,
+			},
+		})
+	}
+	return answers
+}
@@ -0,0 +1,63 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/datasrcs/scripting"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+)
+
+func TestURIAnswers(t *testing.T) {
+	rr, err := dns.NewRR(`_http._tcp.www.owasp.org. 300 IN URI 10 1 "https://www.owasp.org/api"`)
+	if err != nil {
+		t.Fatalf("failed to parse the URI record: %v", err)
+	}
+
+	resp := new(dns.Msg)
+	resp.Answer = []dns.RR{rr, &dns.A{
+		Hdr: dns.RR_Header{Name: "_http._tcp.www.owasp.org.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+	}}
+
+	answers := uriAnswers(resp)
+	if len(answers) != 1 {
+		t.Fatalf("expected 1 URI answer, but got %d", len(answers))
+	}
+
+	a := answers[0]
+	if a.Name != "_http._tcp.www.owasp.org" || a.Type != int(dns.TypeURI) || a.TTL != 300 || a.Data != "https://www.owasp.org/api" {
+		t.Errorf("unexpected URI answer: %+v", a)
+	}
+	if expected := (&requests.URIData{Priority: 10, Weight: 1, Target: "https://www.owasp.org/api"}); !reflect.DeepEqual(a.URI, expected) {
+		t.Errorf("expected the URI %+v, but got %+v", expected, a.URI)
+	}
+	if uriAnswers(nil) != nil {
+		t.Errorf("answers were returned without a response")
+	}
+}
+
+func TestBuildURIServices(t *testing.T) {
+	defer func() { _ = scripting.SetSRVServices(nil) }()
+
+	e := &Enumeration{Config: config.NewConfig()}
+	e.buildURIServices()
+	if !reflect.DeepEqual(e.urisvcs, defaultURIServices) {
+		t.Errorf("expected the default services %v, but got %v", defaultURIServices, e.urisvcs)
+	}
+
+	// the SRV services provided by the user replace the defaults, and the port probes follow them
+	if err := scripting.SetSRVServices([]scripting.SRVService{{Service: "http", Proto: "tcp"}}); err != nil {
+		t.Fatalf("the SRV services were rejected: %v", err)
+	}
+	e.srvports = []string{"_8443._tcp", "_http._tcp"}
+	e.buildURIServices()
+	if expected := []string{"_http._tcp", "_8443._tcp"}; !reflect.DeepEqual(e.urisvcs, expected) {
+		t.Errorf("expected the services %v, but got %v", expected, e.urisvcs)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+// unspecifiedNetwork is the block of the A records rejected by the default validator, since the
+// addresses cannot be reached and are returned by DNS filters for the blocked names.
+var _, unspecifiedNetwork, _ = net.ParseCIDR("0.0.0.0/8")
+
+// defaultAValidator rejects the A records pointing into 0.0.0.0/8.
+func defaultAValidator(ans requests.DNSAnswer) bool {
+	ip := net.ParseIP(ans.Data)
+	return ip != nil && !unspecifiedNetwork.Contains(ip)
+}
+
+// recordValidator returns the validator of the record type from the RecordValidators, the default
+// validator of the A records when none was provided, or nil when the type is not validated.
+func (e *Enumeration) recordValidator(rrtype int) func(requests.DNSAnswer) bool {
+	name, found := dns.TypeToString[uint16(rrtype)]
+	if !found {
+		return nil
+	}
+
+	for t, v := range e.RecordValidators {
+		if strings.EqualFold(t, name) {
+			return v
+		}
+	}
+	if rrtype == int(dns.TypeA) {
+		return defaultAValidator
+	}
+	return nil
+}
+
+// validAnswers drops the answers rejected by the validator of their record type.
+func (e *Enumeration) validAnswers(answers []requests.DNSAnswer) []requests.DNSAnswer {
+	valid := answers[:0]
+
+	for _, ans := range answers {
+		if v := e.recordValidator(ans.Type); v != nil && !v(ans) {
+			e.log().Debugf("The %s record of %s was rejected by the validator: %s", dns.TypeToString[uint16(ans.Type)], ans.Name, ans.Data)
+			continue
+		}
+		valid = append(valid, ans)
+	}
+	return valid
+}
@@ -0,0 +1,84 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestRecordValidators(t *testing.T) {
+	answers := func() []requests.DNSAnswer {
+		return []requests.DNSAnswer{
+			{Name: "www.owasp.org", Type: int(dns.TypeA), Data: "192.0.2.1"},
+			{Name: "blocked.owasp.org", Type: int(dns.TypeA), Data: "0.0.0.0"},
+			{Name: "www.owasp.org", Type: int(dns.TypeAAAA), Data: "::"},
+			{Name: "owasp.org", Type: int(dns.TypeTXT), Data: "v=spf1 -all"},
+			{Name: "owasp.org", Type: int(dns.TypeTXT), Data: strings.Repeat("x", 300)},
+			{Name: "owasp.org", Type: int(dns.TypeMX), Data: "mail.owasp.org"},
+		}
+	}
+	dataOf := func(answers []requests.DNSAnswer) []string {
+		var data []string
+		for _, a := range answers {
+			data = append(data, a.Data)
+		}
+		return data
+	}
+
+	tests := []struct {
+		validators map[string]func(requests.DNSAnswer) bool
+		expected   []string
+	}{
+		// the default validator only rejects the A records pointing into 0.0.0.0/8
+		{nil, []string{"192.0.2.1", "::", "v=spf1 -all", strings.Repeat("x", 300), "mail.owasp.org"}},
+		{map[string]func(requests.DNSAnswer) bool{
+			"txt": func(a requests.DNSAnswer) bool { return len(a.Data) <= 255 },
+		}, []string{"192.0.2.1", "::", "v=spf1 -all", "mail.owasp.org"}},
+		{map[string]func(requests.DNSAnswer) bool{
+			"AAAA": func(a requests.DNSAnswer) bool {
+				ip := net.ParseIP(a.Data)
+				return ip != nil && !ip.IsUnspecified()
+			},
+			"MX": func(a requests.DNSAnswer) bool { return strings.HasSuffix(a.Data, ".owasp.org") },
+		}, []string{"192.0.2.1", "v=spf1 -all", strings.Repeat("x", 300), "mail.owasp.org"}},
+		// the validator provided for the A records replaces the default
+		{map[string]func(requests.DNSAnswer) bool{
+			"A": func(a requests.DNSAnswer) bool { return true },
+		}, []string{"192.0.2.1", "0.0.0.0", "::", "v=spf1 -all", strings.Repeat("x", 300), "mail.owasp.org"}},
+	}
+
+	for i, test := range tests {
+		e := &Enumeration{Config: config.NewConfig(), RecordValidators: test.validators}
+
+		got := dataOf(e.validAnswers(answers()))
+		if strings.Join(got, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("Test %d: expected %v, but got %v", i, test.expected, got)
+		}
+	}
+}
+
+func TestCollectAnswersValidated(t *testing.T) {
+	resp := new(dns.Msg)
+	resp.SetQuestion("www.owasp.org.", dns.TypeA)
+	for _, addr := range []string{"0.0.0.0", "192.0.2.1"} {
+		resp.Answer = append(resp.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: "www.owasp.org.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+			A:   net.ParseIP(addr),
+		})
+	}
+
+	e := &Enumeration{Config: config.NewConfig()}
+	records := e.collectAnswers(resp, resolve.ExtractAnswers(resp))
+	if len(records) != 1 || records[0].Data != "192.0.2.1" {
+		t.Errorf("expected only the reachable address, but got %v", records)
+	}
+}
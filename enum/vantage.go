@@ -0,0 +1,177 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+	amassnet "github.com/owasp-amass/amass/v4/net"
+	"github.com/owasp-amass/resolve"
+	"golang.org/x/net/proxy"
+)
+
+// RegionalAnswers holds the answer sets obtained for a name from each region of the VantageProxies.
+type RegionalAnswers struct {
+	// Regions maps each region to the sorted answers, formatted as the record type and data
+	Regions map[string][]string
+	// Differ is true when the regions did not obtain the same answers
+	Differ bool
+}
+
+type vantagePoint struct {
+	region string
+	dialer proxy.ContextDialer
+}
+
+// vantagePoints resolves the names through the SOCKS5 proxy of each region, so the DNS
+// answers that depend on the location of the client, such as CDN mappings, are revealed.
+type vantagePoints struct {
+	enum   *Enumeration
+	points []*vantagePoint
+}
+
+// newVantagePoints returns nil when the Enumeration has not been configured with VantageProxies.
+func newVantagePoints(e *Enumeration) (*vantagePoints, error) {
+	if len(e.VantageProxies) == 0 {
+		return nil, nil
+	}
+
+	vp := &vantagePoints{enum: e}
+	for region, addr := range e.VantageProxies {
+		if strings.TrimSpace(region) == "" {
+			return nil, fmt.Errorf("the vantage proxy %s does not have a region", addr)
+		}
+
+		d, err := amassnet.NewSOCKS5Dialer(addr)
+		if err != nil {
+			return nil, fmt.Errorf("the vantage proxy for the %s region: %v", region, err)
+		}
+		vp.points = append(vp.points, &vantagePoint{region: region, dialer: d})
+	}
+
+	sort.Slice(vp.points, func(i, j int) bool {
+		return vp.points[i].region < vp.points[j].region
+	})
+	return vp, nil
+}
+
+// check resolves the name from each region, keeps the answer sets, and logs the names
+// with answers that differ across the regions. The regions that failed are left out.
+func (vp *vantagePoints) check(ctx context.Context, name string) *RegionalAnswers {
+	ra := &RegionalAnswers{Regions: make(map[string][]string)}
+
+	var first []string
+	for _, p := range vp.points {
+		answers, err := vp.answers(ctx, p, name)
+		if err != nil {
+			vp.enum.log().Debugf("Vantage points: failed to resolve %s from the %s region: %v", name, p.region, err)
+			continue
+		}
+
+		ra.Regions[p.region] = answers
+		if len(ra.Regions) == 1 {
+			first = answers
+		} else if !sameAnswers(first, answers) {
+			ra.Differ = true
+		}
+	}
+	if ra.Differ {
+		vp.enum.log().Warnf("Geo DNS: %s answers differ across the regions: %s", name, ra.String())
+	}
+
+	vp.enum.addRegionalAnswers(name, ra)
+	return ra
+}
+
+func (vp *vantagePoints) answers(ctx context.Context, p *vantagePoint, name string) ([]string, error) {
+	var answers []string
+
+	for _, qtype := range FwdQueryTypes {
+		if !vp.enum.spendQuery() {
+			return nil, errors.New("the DNS query budget has been spent")
+		}
+
+		resp, err := vp.exchange(ctx, p, resolve.QueryMsg(name, qtype))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, a := range resolve.AnswersByType(resolve.ExtractAnswers(resp), qtype) {
+			answers = append(answers, dns.TypeToString[a.Type]+" "+a.Data)
+		}
+	}
+
+	sort.Strings(answers)
+	return answers, nil
+}
+
+// exchange sends the DNS message over TCP through the proxy, since UDP is often unsupported by SOCKS5 proxies.
+func (vp *vantagePoints) exchange(ctx context.Context, p *vantagePoint, msg *dns.Msg) (*dns.Msg, error) {
+	addr, err := vp.enum.directResolver(msg.Question[0].Qtype, true)
+	if err != nil {
+		return nil, err
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, tcpQueryTimeout)
+	defer cancel()
+
+	conn, err := p.dialer.DialContext(tctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	vp.enum.setEDNSBufferSize(msg)
+	vp.enum.setRecursionDesired(msg)
+	return exchangeConn(tctx, &dns.Conn{Conn: conn}, msg)
+}
+
+// String returns the answers of each region in the order of the region names.
+func (ra *RegionalAnswers) String() string {
+	regions := make([]string, 0, len(ra.Regions))
+	for region := range ra.Regions {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	var parts []string
+	for _, region := range regions {
+		parts = append(parts, fmt.Sprintf("%s [%s]", region, strings.Join(ra.Regions[region], ", ")))
+	}
+	return strings.Join(parts, " ")
+}
+
+// RegionalAnswers returns a copy of the answer sets obtained for the name from each region of the
+// VantageProxies, or nil when the name was not resolved from the vantage points.
+func (e *Enumeration) RegionalAnswers(name string) *RegionalAnswers {
+	e.geoLock.Lock()
+	defer e.geoLock.Unlock()
+
+	ra, found := e.regional[strings.ToLower(name)]
+	if !found {
+		return nil
+	}
+
+	c := &RegionalAnswers{Regions: make(map[string][]string, len(ra.Regions)), Differ: ra.Differ}
+	for region, answers := range ra.Regions {
+		c.Regions[region] = append([]string(nil), answers...)
+	}
+	return c
+}
+
+func (e *Enumeration) addRegionalAnswers(name string, ra *RegionalAnswers) {
+	e.geoLock.Lock()
+	defer e.geoLock.Unlock()
+
+	if e.regional == nil {
+		e.regional = make(map[string]*RegionalAnswers)
+	}
+	e.regional[strings.ToLower(name)] = ra
+}
@@ -0,0 +1,162 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+func TestVantagePoints(t *testing.T) {
+	us := startVantageProxy(t, startRegionalServer(t, "192.0.2.1"))
+	eu := startVantageProxy(t, startRegionalServer(t, "192.0.2.2"))
+	asia := startVantageProxy(t, startRegionalServer(t, "192.0.2.1"))
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	// the proxies connect to their own regional servers regardless of the resolver
+	cfg.TrustedResolvers = []string{"192.0.2.53"}
+	e := &Enumeration{
+		Config: cfg,
+		Sys:    &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: resolve.NewResolvers()},
+	}
+	defer e.Sys.Resolvers().Stop()
+	defer e.Sys.TrustedResolvers().Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	e.VantageProxies = map[string]string{"us": us, "asia": asia}
+	vp, err := newVantagePoints(e)
+	if err != nil {
+		t.Fatalf("Failed to setup the vantage points: %v", err)
+	}
+	if ra := vp.check(ctx, "www.owasp.org"); ra.Differ || len(ra.Regions) != 2 {
+		t.Errorf("the same answers were flagged as different: %s", ra.String())
+	}
+
+	e.VantageProxies["eu"] = eu
+	if vp, err = newVantagePoints(e); err != nil {
+		t.Fatalf("Failed to setup the vantage points: %v", err)
+	}
+	vp.check(ctx, "www.owasp.org")
+
+	ra := e.RegionalAnswers("www.owasp.org")
+	if ra == nil || !ra.Differ {
+		t.Fatalf("the different answers were not flagged: %v", ra)
+	}
+	if answers := ra.Regions["eu"]; len(answers) != 1 || answers[0] != "A 192.0.2.2" {
+		t.Errorf("expected the eu region to obtain A 192.0.2.2, but got %v", answers)
+	}
+	if expected := "asia [A 192.0.2.1] eu [A 192.0.2.2] us [A 192.0.2.1]"; ra.String() != expected {
+		t.Errorf("expected %s, but got %s", expected, ra.String())
+	}
+	if e.RegionalAnswers("other.owasp.org") != nil {
+		t.Errorf("answers were returned for a name that was not resolved from the vantage points")
+	}
+
+	for _, proxies := range []map[string]string{{"": us}, {"us": "http://127.0.0.1:8080"}} {
+		e.VantageProxies = proxies
+		if _, err := newVantagePoints(e); err == nil {
+			t.Errorf("the vantage proxies %v were accepted", proxies)
+		}
+	}
+}
+
+// startRegionalServer returns the address of a DNS server answering the A queries with the address.
+func startRegionalServer(t *testing.T, addr string) string {
+	srvAddr := startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		if req.Question[0].Qtype == dns.TypeA {
+			m.Answer = append(m.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: req.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+				A:   net.ParseIP(addr),
+			})
+		}
+		_ = w.WriteMsg(m)
+	})
+
+	return srvAddr
+}
+
+// startVantageProxy returns the address of a SOCKS5 proxy connecting each CONNECT request to the
+// backend, so each proxy reaches the DNS server of its own region.
+func startVantageProxy(t *testing.T, backend string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on a TCP port: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveVantageProxy(conn, backend)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func serveVantageProxy(c net.Conn, backend string) {
+	defer c.Close()
+
+	buf := make([]byte, 262)
+	// Version and authentication methods
+	if _, err := io.ReadFull(c, buf[:2]); err != nil || buf[0] != 5 {
+		return
+	}
+	if _, err := io.ReadFull(c, buf[:buf[1]]); err != nil {
+		return
+	}
+	if _, err := c.Write([]byte{5, 0}); err != nil {
+		return
+	}
+	// The CONNECT request, and the destination is ignored
+	if _, err := io.ReadFull(c, buf[:4]); err != nil || buf[1] != 1 {
+		return
+	}
+	var alen int
+	switch buf[3] {
+	case 1:
+		alen = net.IPv4len
+	case 4:
+		alen = net.IPv6len
+	case 3:
+		if _, err := io.ReadFull(c, buf[:1]); err != nil {
+			return
+		}
+		alen = int(buf[0])
+	default:
+		return
+	}
+	if _, err := io.ReadFull(c, buf[:alen+2]); err != nil {
+		return
+	}
+
+	b, err := net.Dial("tcp", backend)
+	if err != nil {
+		_, _ = c.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer b.Close()
+	if _, err := c.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		return
+	}
+
+	go func() { _, _ = io.Copy(b, c) }()
+	_, _ = io.Copy(c, b)
+}
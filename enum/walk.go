@@ -0,0 +1,86 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/resolve"
+)
+
+// nsecWalk follows the NSEC chain of the zone and feeds the discovered names back into the enumeration.
+func (dt *dnsTask) nsecWalk(ctx context.Context, zone, domain string) {
+	dt.Lock()
+	if _, found := dt.walked[zone]; found {
+		dt.Unlock()
+		return
+	}
+	dt.walked[zone] = struct{}{}
+	dt.Unlock()
+
+	// The NSEC traversal is performed over UDP by the resolver pool
+	if dt.enum.tcpOnly() {
+		dt.enum.log().Infof("NSEC walk: %s was skipped, since the DNS queries are restricted to TCP", zone)
+		return
+	}
+
+	pool := dt.enum.Sys.TrustedResolvers()
+	// Learn how the zone proves the nonexistence of names
+	label := "nsec-walk-" + strconv.Itoa(int(dns.Id()))
+	resp, err := dt.enum.queryBlocking(ctx, resolve.WalkMsg(label+"."+zone, dns.TypeA), pool)
+	if err != nil {
+		return
+	}
+
+	switch denialType(resp) {
+	case dns.TypeNSEC3:
+		dt.enum.log().Infof("NSEC walk: %s uses NSEC3 and the walk was skipped", zone)
+		return
+	case dns.TypeNSEC:
+	default:
+		return
+	}
+
+	nsecs, err := pool.NsecTraversal(ctx, zone)
+	if err != nil && len(nsecs) == 0 {
+		dt.enum.log().Debugf("NSEC walk: %s: %v", zone, err)
+		return
+	}
+
+	for _, nsec := range nsecs {
+		name := strings.ToLower(resolve.RemoveLastDot(nsec.NextDomain))
+
+		if d := dt.enum.Config.WhichDomain(name); d != "" {
+			dt.enum.nameSrc.newNameWithoutWait(&requests.DNSRequest{
+				Name:   name,
+				Domain: d,
+				Tag:    requests.DNS,
+				Source: "NSEC Walk",
+			})
+		}
+	}
+}
+
+// denialType returns the record type used by the response to deny the existence of a name,
+// or zero when the response carries neither NSEC nor NSEC3 records.
+func denialType(resp *dns.Msg) uint16 {
+	if resp == nil {
+		return 0
+	}
+
+	for _, rr := range append(resp.Answer, resp.Ns...) {
+		switch rr.(type) {
+		case *dns.NSEC3:
+			return dns.TypeNSEC3
+		case *dns.NSEC:
+			return dns.TypeNSEC
+		}
+	}
+	return 0
+}
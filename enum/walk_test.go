@@ -0,0 +1,33 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestDenialType(t *testing.T) {
+	nsec := &dns.NSEC{Hdr: dns.RR_Header{Name: "owasp.org.", Rrtype: dns.TypeNSEC}, NextDomain: "www.owasp.org."}
+	nsec3 := &dns.NSEC3{Hdr: dns.RR_Header{Name: "abc.owasp.org.", Rrtype: dns.TypeNSEC3}}
+
+	tests := []struct {
+		msg      *dns.Msg
+		expected uint16
+	}{
+		{nil, 0},
+		{&dns.Msg{}, 0},
+		{&dns.Msg{Ns: []dns.RR{nsec}}, dns.TypeNSEC},
+		{&dns.Msg{Ns: []dns.RR{nsec3}}, dns.TypeNSEC3},
+		{&dns.Msg{Answer: []dns.RR{nsec}}, dns.TypeNSEC},
+	}
+
+	for i, test := range tests {
+		if got := denialType(test.msg); got != test.expected {
+			t.Errorf("Test %d: expected %d, but got %d", i, test.expected, got)
+		}
+	}
+}
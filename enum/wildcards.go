@@ -0,0 +1,311 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+// numOfCNAMEWildcardTests is the number of unlikely names queried when testing a subdomain.
+const numOfCNAMEWildcardTests = 3
+
+// cnameWildcards detects subdomains that wildcard a CNAME to a fixed target, such as a CDN,
+// which causes every name within the subdomain to resolve through the same target.
+type cnameWildcards struct {
+	sync.Mutex
+	enum    *Enumeration
+	query   func(ctx context.Context, name string) string
+	targets map[string]*cnameWildcard
+}
+
+type cnameWildcard struct {
+	once   sync.Once
+	target string
+}
+
+func newCNAMEWildcards(e *Enumeration) *cnameWildcards {
+	c := &cnameWildcards{
+		enum:    e,
+		targets: make(map[string]*cnameWildcard),
+	}
+	c.query = c.queryCNAME
+	return c
+}
+
+// detected returns true when the CNAME at the question name of the response has the same
+// target as the wildcard CNAME of a subdomain between the name and the provided domain.
+func (c *cnameWildcards) detected(ctx context.Context, resp *dns.Msg, domain string) bool {
+	if len(resp.Question) == 0 {
+		return false
+	}
+
+	name := strings.ToLower(resolve.RemoveLastDot(resp.Question[0].Name))
+	target := cnameTarget(resp, name)
+	if target == "" {
+		return false
+	}
+
+	domain = strings.ToLower(resolve.RemoveLastDot(domain))
+	labels := strings.Split(name, ".")
+	if len(labels) <= len(strings.Split(domain, ".")) {
+		return false
+	}
+
+	var found bool
+	resolve.RegisteredToFQDN(domain, strings.Join(labels[1:], "."), func(sub string) bool {
+		if t := c.wildcardTarget(ctx, sub); t != "" && t == target {
+			found = true
+		}
+		return found
+	})
+	return found
+}
+
+// wildcardTarget returns the target of the wildcard CNAME for the subdomain, or an empty string.
+func (c *cnameWildcards) wildcardTarget(ctx context.Context, sub string) string {
+	c.Lock()
+	w, found := c.targets[sub]
+	if !found {
+		w = new(cnameWildcard)
+		c.targets[sub] = w
+	}
+	c.Unlock()
+
+	w.once.Do(func() {
+		if w.target = c.test(ctx, sub); w.target != "" {
+			c.enum.log().Infof("DNS wildcard detected: CNAME *.%s -> %s", sub, w.target)
+		}
+	})
+	return w.target
+}
+
+// test queries unlikely names within the subdomain, and returns the CNAME target when all the names share it.
+func (c *cnameWildcards) test(ctx context.Context, sub string) string {
+	var target string
+
+	for i := 0; i < numOfCNAMEWildcardTests; i++ {
+		var name string
+		for name == "" {
+			name = resolve.UnlikelyName(sub)
+		}
+
+		t := c.query(ctx, name)
+		if t == "" || (i > 0 && t != target) {
+			return ""
+		}
+		target = t
+	}
+	return target
+}
+
+func (c *cnameWildcards) queryCNAME(ctx context.Context, name string) string {
+	resp, err := c.enum.dnsQuery(ctx, name, dns.TypeCNAME, c.enum.Sys.TrustedResolvers(), maxRcodeServerFails)
+	if err != nil || resp == nil {
+		return ""
+	}
+	return cnameTarget(resp, name)
+}
+
+// cnameTarget returns the target of the CNAME record owned by the name in the response.
+func cnameTarget(resp *dns.Msg, name string) string {
+	for _, rr := range resp.Answer {
+		if cname, ok := rr.(*dns.CNAME); ok && strings.EqualFold(resolve.RemoveLastDot(cname.Hdr.Name), name) {
+			return strings.ToLower(resolve.RemoveLastDot(cname.Target))
+		}
+	}
+	return ""
+}
+
+// numOfRcodeWildcardTests is the number of unlikely names queried when testing a subdomain for an rcode wildcard.
+const numOfRcodeWildcardTests = 3
+
+// rcodeWildcards detects subdomains where the authoritative servers answer every unlikely name with
+// one of the WildcardDetectRCODEs, such as SERVFAIL, while the subdomain itself resolves. The names
+// receiving those rcodes within the subdomains are treated as wildcard matches instead of being retried.
+type rcodeWildcards struct {
+	sync.Mutex
+	enum   *Enumeration
+	rcodes map[int]struct{}
+	query  func(ctx context.Context, name string) (int, bool)
+	subs   map[string]*rcodeWildcard
+}
+
+type rcodeWildcard struct {
+	once     sync.Once
+	detected bool
+}
+
+func newRcodeWildcards(e *Enumeration) (*rcodeWildcards, error) {
+	rcodes := make(map[int]struct{})
+	for _, s := range e.WildcardDetectRCODEs {
+		rcode, found := dns.StringToRcode[strings.ToUpper(strings.TrimSpace(s))]
+		if !found {
+			return nil, fmt.Errorf("the wildcard detection rcode %s is not valid", s)
+		}
+		if rcode == dns.RcodeSuccess || rcode == dns.RcodeNameError {
+			return nil, fmt.Errorf("the wildcard detection rcode %s cannot be used", s)
+		}
+		rcodes[rcode] = struct{}{}
+	}
+
+	r := &rcodeWildcards{
+		enum:   e,
+		rcodes: rcodes,
+		subs:   make(map[string]*rcodeWildcard),
+	}
+	r.query = r.queryRcode
+	return r, nil
+}
+
+// matches returns true when the rcode is one of the WildcardDetectRCODEs.
+func (r *rcodeWildcards) matches(rcode int) bool {
+	_, found := r.rcodes[rcode]
+	return found
+}
+
+// detected returns true when the rcode received for the name is one of the WildcardDetectRCODEs,
+// and a subdomain between the name and the provided domain has the same behavior for unlikely names.
+func (r *rcodeWildcards) detected(ctx context.Context, name, domain string, rcode int) bool {
+	if !r.matches(rcode) {
+		return false
+	}
+
+	name = strings.ToLower(resolve.RemoveLastDot(name))
+	domain = strings.ToLower(resolve.RemoveLastDot(domain))
+	labels := strings.Split(name, ".")
+	if len(labels) <= len(strings.Split(domain, ".")) {
+		return false
+	}
+
+	var found bool
+	resolve.RegisteredToFQDN(domain, strings.Join(labels[1:], "."), func(sub string) bool {
+		found = r.branch(ctx, sub)
+		return found
+	})
+	return found
+}
+
+// branch returns true when the unlikely names within the subdomain consistently receive one of the
+// WildcardDetectRCODEs while the subdomain itself resolves. The result is cached for each subdomain.
+func (r *rcodeWildcards) branch(ctx context.Context, sub string) bool {
+	r.Lock()
+	w, found := r.subs[sub]
+	if !found {
+		w = new(rcodeWildcard)
+		r.subs[sub] = w
+	}
+	r.Unlock()
+
+	w.once.Do(func() {
+		if w.detected = r.test(ctx, sub); w.detected {
+			r.enum.log().Infof("DNS wildcard detected: *.%s receives the %v rcodes", sub, r.enum.WildcardDetectRCODEs)
+		}
+	})
+	return w.detected
+}
+
+func (r *rcodeWildcards) test(ctx context.Context, sub string) bool {
+	if rcode, ok := r.query(ctx, sub); !ok || rcode != dns.RcodeSuccess {
+		return false
+	}
+
+	for i := 0; i < numOfRcodeWildcardTests; i++ {
+		var name string
+		for name == "" {
+			name = resolve.UnlikelyName(sub)
+		}
+
+		if rcode, ok := r.query(ctx, name); !ok || !r.matches(rcode) {
+			return false
+		}
+	}
+	return true
+}
+
+// queryRcode returns the rcode of the A query for the name, and false when no response was received.
+func (r *rcodeWildcards) queryRcode(ctx context.Context, name string) (int, bool) {
+	resp, err := r.enum.queryBlocking(ctx, resolve.QueryMsg(name, dns.TypeA), r.enum.Sys.TrustedResolvers())
+	if err != nil || resp == nil {
+		return 0, false
+	}
+	return resp.Rcode, true
+}
+
+// knownWildcards provides the wildcard answers of the subdomains set in KnownWildcards, which
+// replace the probe queries of the wildcard detection for the names below those subdomains.
+type knownWildcards struct {
+	subs map[string]map[string]struct{}
+}
+
+func newKnownWildcards(e *Enumeration) (*knownWildcards, error) {
+	k := &knownWildcards{subs: make(map[string]map[string]struct{})}
+
+	for sub, addrs := range e.KnownWildcards {
+		s := strings.ToLower(strings.Trim(strings.TrimSpace(sub), "."))
+		if s == "" {
+			return nil, fmt.Errorf("the known wildcard subdomain %q is not valid", sub)
+		}
+
+		set := make(map[string]struct{})
+		for _, addr := range addrs {
+			ip := net.ParseIP(strings.TrimSpace(addr))
+			if ip == nil {
+				return nil, fmt.Errorf("the known wildcard answer %s of %s is not a valid IP address", addr, sub)
+			}
+			set[ip.String()] = struct{}{}
+		}
+		k.subs[s] = set
+	}
+	return k, nil
+}
+
+// detected checks the response against the answers of the closest subdomain in KnownWildcards,
+// between the parent of the question name and the provided domain. The second return value is
+// false when none of these subdomains were provided, and the live detection is required.
+func (k *knownWildcards) detected(resp *dns.Msg, domain string) (bool, bool) {
+	if len(resp.Question) == 0 {
+		return false, false
+	}
+
+	name := strings.ToLower(resolve.RemoveLastDot(resp.Question[0].Name))
+	domain = strings.ToLower(resolve.RemoveLastDot(domain))
+	if labels := strings.Split(name, "."); len(labels) > len(strings.Split(domain, ".")) {
+		name = strings.Join(labels[1:], ".")
+	}
+
+	for sub := name; sub == domain || strings.HasSuffix(sub, "."+domain); {
+		if addrs, found := k.subs[sub]; found {
+			return matchesKnownAnswers(resp, addrs), true
+		}
+		idx := strings.Index(sub, ".")
+		if idx == -1 {
+			break
+		}
+		sub = sub[idx+1:]
+	}
+	return false, false
+}
+
+// matchesKnownAnswers returns true when an address in the response is one of the wildcard answers.
+func matchesKnownAnswers(resp *dns.Msg, addrs map[string]struct{}) bool {
+	for _, a := range resolve.ExtractAnswers(resp) {
+		if a.Type != dns.TypeA && a.Type != dns.TypeAAAA {
+			continue
+		}
+		if ip := net.ParseIP(a.Data); ip != nil {
+			if _, found := addrs[ip.String()]; found {
+				return true
+			}
+		}
+	}
+	return false
+}
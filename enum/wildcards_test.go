@@ -0,0 +1,311 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/amass/v4/requests"
+	"github.com/owasp-amass/amass/v4/systems"
+	"github.com/owasp-amass/config/config"
+	"github.com/owasp-amass/resolve"
+)
+
+const wildcardCNAMETarget = "owasp.org.cdn.example.net."
+
+func TestCNAMEWildcards(t *testing.T) {
+	var queries int32
+	addr := startCNAMEWildcardZone(t, &queries)
+
+	cfg := config.NewConfig()
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP: true,
+	}
+	defer e.Sys.Resolvers().Stop()
+	c := newCNAMEWildcards(e)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cname := func(name, target string) *dns.Msg {
+		resp := new(dns.Msg)
+		resp.SetReply(resolve.QueryMsg(name, dns.TypeA))
+		if target != "" {
+			resp.Answer = append(resp.Answer, &dns.CNAME{
+				Hdr:    dns.RR_Header{Name: dns.Fqdn(name), Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 300},
+				Target: target,
+			})
+		}
+		return resp
+	}
+
+	tests := []struct {
+		label    string
+		resp     *dns.Msg
+		expected bool
+	}{
+		{"wildcard target", cname("bogus.static.owasp.org", wildcardCNAMETarget), true},
+		{"wildcard target below the subdomain", cname("a.bogus.static.owasp.org", wildcardCNAMETarget), true},
+		{"different target", cname("www.static.owasp.org", "www.owasp.org.cdn.example.net."), false},
+		{"no wildcard", cname("www.owasp.org", wildcardCNAMETarget), false},
+		{"no CNAME", cname("mail.static.owasp.org", ""), false},
+	}
+	for _, test := range tests {
+		if got := c.detected(ctx, test.resp, "owasp.org"); got != test.expected {
+			t.Errorf("%s: expected %t, but got %t", test.label, test.expected, got)
+		}
+	}
+
+	// the results for each subdomain are cached
+	before := atomic.LoadInt32(&queries)
+	c.detected(ctx, cname("other.static.owasp.org", wildcardCNAMETarget), "owasp.org")
+	if after := atomic.LoadInt32(&queries); after != before {
+		t.Errorf("the subdomain was tested again with %d queries", after-before)
+	}
+}
+
+// startCNAMEWildcardZone returns the address of a DNS server for a zone that wildcards a CNAME to a fixed target.
+func startCNAMEWildcardZone(t *testing.T, queries *int32) string {
+	return startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		atomic.AddInt32(queries, 1)
+
+		m := new(dns.Msg)
+		m.SetReply(req)
+		if name := strings.ToLower(req.Question[0].Name); strings.HasSuffix(name, ".static.owasp.org.") {
+			m.Answer = append(m.Answer, &dns.CNAME{
+				Hdr:    dns.RR_Header{Name: name, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 300},
+				Target: wildcardCNAMETarget,
+			})
+		} else {
+			m.Rcode = dns.RcodeNameError
+		}
+		_ = w.WriteMsg(m)
+	})
+}
+
+func TestRcodeWildcards(t *testing.T) {
+	addr := startRcodeWildcardZone(t)
+
+	cfg := config.NewConfig()
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:               cfg,
+		Sys:                  &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP:             true,
+		WildcardDetectRCODEs: []string{"servfail"},
+	}
+	defer e.Sys.Resolvers().Stop()
+
+	r, err := newRcodeWildcards(e)
+	if err != nil {
+		t.Fatalf("Failed to create the rcode wildcard detection: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		label    string
+		name     string
+		rcode    int
+		expected bool
+	}{
+		{"servfail wildcard", "bogus.broken.owasp.org", dns.RcodeServerFailure, true},
+		{"servfail wildcard below the subdomain", "a.bogus.broken.owasp.org", dns.RcodeServerFailure, true},
+		{"rcode not listed", "bogus.broken.owasp.org", dns.RcodeRefused, false},
+		{"no wildcard", "bogus.www.owasp.org", dns.RcodeServerFailure, false},
+	}
+	for _, test := range tests {
+		if got := r.detected(ctx, test.name, "owasp.org", test.rcode); got != test.expected {
+			t.Errorf("%s: expected %t, but got %t", test.label, test.expected, got)
+		}
+	}
+
+	if !r.branch(ctx, "broken.owasp.org") {
+		t.Errorf("the subdomain was not detected as an rcode wildcard")
+	}
+
+	for _, rcodes := range [][]string{{"BOGUS"}, {"NOERROR"}, {"NXDOMAIN"}} {
+		e.WildcardDetectRCODEs = rcodes
+		if _, err := newRcodeWildcards(e); err == nil {
+			t.Errorf("the rcodes %v were accepted", rcodes)
+		}
+	}
+}
+
+// startRcodeWildcardZone returns the address of a DNS server for a zone that answers SERVFAIL
+// for the unknown names within broken.owasp.org, while the subdomain and its known names resolve.
+func startRcodeWildcardZone(t *testing.T) string {
+	known := map[string]struct{}{
+		"broken.owasp.org.":     {},
+		"www.broken.owasp.org.": {},
+		"www.owasp.org.":        {},
+	}
+
+	return startMockDNS(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		name := strings.ToLower(req.Question[0].Name)
+		if _, found := known[name]; found {
+			m.Answer = append(m.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+				A:   net.ParseIP("192.0.2.1"),
+			})
+		} else if strings.HasSuffix(name, ".broken.owasp.org.") {
+			m.Rcode = dns.RcodeServerFailure
+		} else {
+			m.Rcode = dns.RcodeNameError
+		}
+		_ = w.WriteMsg(m)
+	})
+}
+
+func TestProvidedNamesBypassWildcards(t *testing.T) {
+	var queries int32
+	addr := startCNAMEWildcardZone(t, &queries)
+
+	cfg := config.NewConfig()
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP: true,
+	}
+	defer e.Sys.Resolvers().Stop()
+	e.cnames = newCNAMEWildcards(e)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp := new(dns.Msg)
+	resp.SetReply(resolve.QueryMsg("bogus.static.owasp.org", dns.TypeA))
+	resp.Answer = append(resp.Answer, &dns.CNAME{
+		Hdr:    dns.RR_Header{Name: "bogus.static.owasp.org.", Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 300},
+		Target: wildcardCNAMETarget,
+	})
+
+	provided := &requests.DNSRequest{Name: "bogus.static.owasp.org", Domain: "owasp.org", Tag: requests.EXTERNAL}
+	if e.wildcardDetected(ctx, provided, resp) {
+		t.Errorf("the name provided by the user was filtered as a wildcard")
+	}
+
+	discovered := &requests.DNSRequest{Name: "bogus.static.owasp.org", Domain: "owasp.org", Tag: requests.BRUTE}
+	if !e.wildcardDetected(ctx, discovered, resp) {
+		t.Errorf("the discovered name was not filtered as a wildcard")
+	}
+
+	// the wildcard suffix lists take precedence over the user input
+	e.WildcardForceDynamic = []string{"static.owasp.org"}
+	if !e.wildcardDetected(ctx, provided, resp) {
+		t.Errorf("the provided name was not filtered by the wildcard suffix list")
+	}
+}
+
+func TestKnownWildcards(t *testing.T) {
+	var queries int32
+	addr := startCNAMEWildcardZone(t, &queries)
+
+	cfg := config.NewConfig()
+	cfg.TrustedResolvers = []string{addr}
+	trusted := resolve.NewResolvers()
+	defer trusted.Stop()
+
+	e := &Enumeration{
+		Config:   cfg,
+		Sys:      &systems.SimpleSystem{Cfg: cfg, Pool: resolve.NewResolvers(), Trusted: trusted},
+		ForceTCP: true,
+		KnownWildcards: map[string][]string{
+			"Dyn.owasp.org.":       {"192.0.2.1", "2001:db8::1"},
+			"legacy.owasp.org":     nil,
+			"dyn.legacy.owasp.org": {" 192.0.2.50 "},
+		},
+	}
+	defer e.Sys.Resolvers().Stop()
+	e.cnames = newCNAMEWildcards(e)
+
+	known, err := newKnownWildcards(e)
+	if err != nil {
+		t.Fatalf("failed to create the known wildcards: %v", err)
+	}
+	e.known = known
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	answer := func(name string, rr dns.RR) *dns.Msg {
+		resp := new(dns.Msg)
+		resp.SetReply(resolve.QueryMsg(name, rr.Header().Rrtype))
+		rr.Header().Name = dns.Fqdn(name)
+		rr.Header().Class = dns.ClassINET
+		rr.Header().Ttl = 300
+		resp.Answer = append(resp.Answer, rr)
+		return resp
+	}
+	a := func(name, ip string) *dns.Msg {
+		return answer(name, &dns.A{Hdr: dns.RR_Header{Rrtype: dns.TypeA}, A: net.ParseIP(ip)})
+	}
+
+	tests := []struct {
+		label    string
+		resp     *dns.Msg
+		expected bool
+	}{
+		{"wildcard address", a("bogus.dyn.owasp.org", "192.0.2.1"), true},
+		{"wildcard address below the subdomain", answer("a.b.dyn.owasp.org", &dns.AAAA{
+			Hdr: dns.RR_Header{Rrtype: dns.TypeAAAA}, AAAA: net.ParseIP("2001:db8::1"),
+		}), true},
+		{"different address", a("www.dyn.owasp.org", "192.0.2.10"), false},
+		{"subdomain without a wildcard", a("old.legacy.owasp.org", "192.0.2.1"), false},
+		{"closest subdomain", a("x.dyn.legacy.owasp.org", "192.0.2.50"), true},
+	}
+	for _, test := range tests {
+		req := &requests.DNSRequest{Name: resolve.RemoveLastDot(test.resp.Question[0].Name), Domain: "owasp.org", Tag: requests.BRUTE}
+
+		if got := e.wildcardDetected(ctx, req, test.resp); got != test.expected {
+			t.Errorf("%s: expected %t, but got %t", test.label, test.expected, got)
+		}
+	}
+	if n := atomic.LoadInt32(&queries); n != 0 {
+		t.Errorf("the known wildcards were probed with %d queries", n)
+	}
+
+	// the names without a known wildcard fall back to the live detection
+	resp := answer("bogus.static.owasp.org", &dns.CNAME{Hdr: dns.RR_Header{Rrtype: dns.TypeCNAME}, Target: wildcardCNAMETarget})
+	req := &requests.DNSRequest{Name: "bogus.static.owasp.org", Domain: "owasp.org", Tag: requests.BRUTE}
+	if !e.wildcardDetected(ctx, req, resp) {
+		t.Errorf("the wildcard without a known answer was not detected")
+	}
+	if atomic.LoadInt32(&queries) == 0 {
+		t.Errorf("the live detection was not performed")
+	}
+
+	for _, known := range []map[string][]string{
+		{"dyn.owasp.org": {"bogus"}},
+		{".": {"192.0.2.1"}},
+	} {
+		if _, err := newKnownWildcards(&Enumeration{KnownWildcards: known}); err == nil {
+			t.Errorf("the known wildcards %v were accepted", known)
+		}
+	}
+}
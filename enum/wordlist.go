@@ -0,0 +1,143 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	amasshttp "github.com/owasp-amass/amass/v4/net/http"
+)
+
+// maxWordlistPages is the number of pages followed when fetching the wordlist from the WordlistURL.
+const maxWordlistPages = 100
+
+var (
+	wordlistLabel = regexp.MustCompile(`^[a-z0-9_]([a-z0-9_-]{0,61}[a-z0-9_])?$`)
+	linkNextPage  = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+)
+
+// checkWordlistURL validates the URL of the wordlist service.
+func (e *Enumeration) checkWordlistURL() error {
+	if e.WordlistURL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(e.WordlistURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("the wordlist URL %s must be an HTTP or HTTPS URL", e.WordlistURL)
+	}
+	return nil
+}
+
+// fetchWordlist replaces the brute forcing wordlist with the labels obtained from the WordlistURL,
+// which receives the domains in scope as the domain query parameters. The local wordlist is kept
+// when the service cannot be reached or returns no labels.
+func (e *Enumeration) fetchWordlist(ctx context.Context) {
+	words, err := e.requestWordlist(ctx)
+	if err == nil && len(words) == 0 {
+		err = fmt.Errorf("no labels were returned")
+	}
+	if err != nil {
+		e.log().Warnf("Wordlist service: %v, using the local wordlist of %d labels", err, len(e.Config.Wordlist))
+		return
+	}
+
+	e.Config.Wordlist = words
+	e.log().Infof("Wordlist service: obtained %d labels from %s", len(words), e.WordlistURL)
+}
+
+// requestWordlist follows the pages of the wordlist service through the next links of the Link
+// header, and returns the unique labels from the responses with one label on each line.
+func (e *Enumeration) requestWordlist(ctx context.Context) ([]string, error) {
+	u, err := url.Parse(e.WordlistURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	for _, d := range e.Config.Domains() {
+		q.Add("domain", d)
+	}
+	u.RawQuery = q.Encode()
+
+	var words []string
+	seen := make(map[string]struct{})
+	for page, next := 0, u.String(); next != "" && page < maxWordlistPages; page++ {
+		resp, err := amasshttp.RequestWebPage(ctx, &amasshttp.Request{
+			URL:    next,
+			Header: amasshttp.Header(e.WordlistHeaders),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to request %s: %v", next, err)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("%s returned the status %s", next, resp.Status)
+		}
+
+		scanner := bufio.NewScanner(strings.NewReader(resp.Body))
+		for scanner.Scan() {
+			word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			if _, found := seen[word]; found || !wordlistLabel.MatchString(word) {
+				continue
+			}
+
+			seen[word] = struct{}{}
+			words = append(words, word)
+		}
+
+		next = ""
+		if m := linkNextPage.FindStringSubmatch(resp.Header["Link"]); len(m) > 1 {
+			ref, err := url.Parse(m[1])
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse the next page of %s: %v", u, err)
+			}
+			next = u.ResolveReference(ref).String()
+		}
+	}
+	return words, nil
+}
+
+// sampleWordlist replaces the brute forcing wordlist with a random sample of the BruteSamplePercent
+// of its labels, kept in their order. The sample is drawn from an RNG seeded with the SampleSeed.
+func (e *Enumeration) sampleWordlist() {
+	seed := e.SampleSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	total := len(e.Config.Wordlist)
+	e.Config.Wordlist = sampleLabels(e.Config.Wordlist, e.BruteSamplePercent, seed)
+	e.log().Infof("Brute forcing sample: trying %d of the %d labels (%d%%) with the seed %d",
+		len(e.Config.Wordlist), total, e.BruteSamplePercent, seed)
+}
+
+// sampleLabels returns the percent of the labels selected randomly, with at least one label selected.
+func sampleLabels(labels []string, percent int, seed int64) []string {
+	if percent >= 100 || len(labels) == 0 {
+		return labels
+	}
+
+	n := len(labels) * percent / 100
+	if n == 0 {
+		n = 1
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	picked := r.Perm(len(labels))[:n]
+	sort.Ints(picked)
+
+	sample := make([]string, 0, n)
+	for _, i := range picked {
+		sample = append(sample, labels[i])
+	}
+	return sample
+}
@@ -0,0 +1,109 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestFetchWordlist(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("domain") != "owasp.org" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", `</words?domain=owasp.org&page=2>; rel="next"`)
+			fmt.Fprint(w, "www\nDev\n\nnot a label\n")
+		case "2":
+			fmt.Fprint(w, "api\nwww\n")
+		}
+	}))
+	defer srv.Close()
+
+	cfg := config.NewConfig()
+	cfg.AddDomain("owasp.org")
+	cfg.Wordlist = []string{"local"}
+	e := &Enumeration{
+		Config:          cfg,
+		WordlistURL:     srv.URL + "/words",
+		WordlistHeaders: map[string]string{"Authorization": "Bearer secret"},
+	}
+
+	e.fetchWordlist(context.Background())
+	if expected := []string{"www", "dev", "api"}; !reflect.DeepEqual(cfg.Wordlist, expected) {
+		t.Errorf("expected the wordlist %v, but got %v", expected, cfg.Wordlist)
+	}
+
+	// the local wordlist is kept when the request fails
+	cfg.Wordlist = []string{"local"}
+	e.WordlistHeaders = nil
+	e.fetchWordlist(context.Background())
+	if !reflect.DeepEqual(cfg.Wordlist, []string{"local"}) {
+		t.Errorf("the local wordlist was replaced after the failed request: %v", cfg.Wordlist)
+	}
+
+	for _, u := range []string{"ftp://192.0.2.1/words", "words.txt", "http://"} {
+		e.WordlistURL = u
+		if err := e.checkWordlistURL(); err == nil {
+			t.Errorf("the wordlist URL %s was accepted", u)
+		}
+	}
+}
+
+func TestSampleWordlist(t *testing.T) {
+	var words []string
+	for i := 0; i < 10000; i++ {
+		words = append(words, fmt.Sprintf("label%d", i))
+	}
+
+	cfg := config.NewConfig()
+	cfg.Wordlist = words
+	e := &Enumeration{Config: cfg, BruteSamplePercent: 10, SampleSeed: 42}
+	e.sampleWordlist()
+
+	if n := len(cfg.Wordlist); n != 1000 {
+		t.Errorf("expected 1000 of the 10000 labels to be sampled, but got %d", n)
+	}
+	// the same seed provides the same sample
+	if again := sampleLabels(words, 10, 42); !reflect.DeepEqual(again, cfg.Wordlist) {
+		t.Errorf("the sample was not reproduced with the same seed")
+	}
+	if other := sampleLabels(words, 10, 7); reflect.DeepEqual(other, cfg.Wordlist) {
+		t.Errorf("the sample was not changed by a different seed")
+	}
+	if all := sampleLabels(words, 100, 42); len(all) != len(words) {
+		t.Errorf("the full wordlist was not kept at 100 percent")
+	}
+	if one := sampleLabels(words[:5], 1, 42); len(one) != 1 {
+		t.Errorf("expected at least one label to be sampled, but got %d", len(one))
+	}
+}
+
+func TestBruteSamplePercentRange(t *testing.T) {
+	for _, percent := range []int{-1, 101} {
+		cfg := config.NewConfig()
+		cfg.AddDomain("owasp.org")
+		e := &Enumeration{Config: cfg, PipelineBufferSize: 1, BruteSamplePercent: percent}
+
+		if err := e.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "sample percentage") {
+			t.Errorf("the enumeration started with a brute forcing sample of %d percent: %v", percent, err)
+		}
+	}
+}
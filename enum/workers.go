@@ -0,0 +1,90 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// maxTrackedWork is the number of tracked work items allowed to run at the same time.
+const maxTrackedWork = 100
+
+// trackedWork bounds the work started outside of the pipeline, and counts the work not completed,
+// so the enumeration is not declared complete while the work could still provide new data.
+type trackedWork struct {
+	wg      sync.WaitGroup
+	slots   chan struct{}
+	running atomic.Int64
+}
+
+func newTrackedWork(max int) *trackedWork {
+	return &trackedWork{slots: make(chan struct{}, max)}
+}
+
+// pending returns true while any of the tracked work has not completed.
+func (t *trackedWork) pending() bool {
+	return t != nil && t.running.Load() > 0
+}
+
+// wait blocks until all of the tracked work has completed.
+func (t *trackedWork) wait() {
+	if t != nil {
+		t.wg.Wait()
+	}
+}
+
+// goTracked runs the work on its own goroutine, and blocks while maxTrackedWork items are running.
+// The work is dropped when the context expires before a slot becomes available. Without tracking,
+// as when the enumeration has not been started, the work runs on the calling goroutine.
+func (e *Enumeration) goTracked(ctx context.Context, work func()) {
+	t := e.tracked
+	if t == nil {
+		work()
+		return
+	}
+
+	t.wg.Add(1)
+	t.running.Add(1)
+	done := func() {
+		t.running.Add(-1)
+		t.wg.Done()
+	}
+
+	select {
+	case <-ctx.Done():
+		done()
+		return
+	case t.slots <- struct{}{}:
+	}
+
+	go func() {
+		defer done()
+		defer func() { <-t.slots }()
+		work()
+	}()
+}
+
+// goResolve runs the resolution work on its own goroutine, and blocks while MaxResolveWorkers are
+// busy. The work is dropped when the context expires before a worker becomes available. The work
+// must not call goResolve itself, since it would wait on the workers while holding one of them.
+func (e *Enumeration) goResolve(ctx context.Context, work func()) {
+	if e.workers == nil {
+		go work()
+		return
+	}
+
+	select {
+	case <-ctx.Done():
+		return
+	case e.workers <- struct{}{}:
+	}
+
+	go func() {
+		defer func() { <-e.workers }()
+		work()
+	}()
+}
@@ -0,0 +1,89 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package enum
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxResolveWorkers(t *testing.T) {
+	const workers = 8
+	const flood = 10000
+
+	e := &Enumeration{MaxResolveWorkers: workers, workers: make(chan struct{}, workers)}
+	before := runtime.NumGoroutine()
+
+	var busy, peak int32
+	var wg sync.WaitGroup
+	release := make(chan struct{})
+	ctx := context.Background()
+
+	submitted := make(chan struct{})
+	go func() {
+		defer close(submitted)
+		for i := 0; i < flood; i++ {
+			wg.Add(1)
+			e.goResolve(ctx, func() {
+				defer wg.Done()
+
+				n := atomic.AddInt32(&busy, 1)
+				for {
+					if p := atomic.LoadInt32(&peak); n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+						break
+					}
+				}
+				<-release
+				atomic.AddInt32(&busy, -1)
+			})
+		}
+	}()
+
+	// the submission of the flood blocks once all the workers are busy
+	time.Sleep(100 * time.Millisecond)
+	if n := runtime.NumGoroutine() - before; n > workers+2 {
+		t.Errorf("expected at most %d goroutines for the flood of requests, but got %d", workers+2, n)
+	}
+
+	close(release)
+	<-submitted
+	wg.Wait()
+	if p := atomic.LoadInt32(&peak); p > workers || p == 0 {
+		t.Errorf("expected at most %d workers busy at once, but got %d", workers, p)
+	}
+
+	// the work waiting for a worker is dropped once the context expires
+	for i := 0; i < workers; i++ {
+		e.workers <- struct{}{}
+	}
+	cctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ran := make(chan struct{})
+	e.goResolve(cctx, func() { close(ran) })
+	select {
+	case <-ran:
+		t.Errorf("the work ran after the context expired")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func BenchmarkMaxResolveWorkers(b *testing.B) {
+	e := &Enumeration{MaxResolveWorkers: 64, workers: make(chan struct{}, 64)}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < b.N; i++ {
+		wg.Add(1)
+		e.goResolve(ctx, func() {
+			defer wg.Done()
+			time.Sleep(time.Microsecond)
+		})
+	}
+	wg.Wait()
+}
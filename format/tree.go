@@ -0,0 +1,178 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package format
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+// maxTreeAddrs is the number of addresses displayed for each name before they are collapsed.
+const maxTreeAddrs = 3
+
+// Tree keeps the discovered names grouped by root domain and parent subdomain, along with
+// their addresses, so the results can be rendered as a tree while the enumeration runs.
+type Tree struct {
+	sync.Mutex
+	roots map[string]*treeNode
+	count int
+}
+
+type treeNode struct {
+	name     string
+	addrs    []string
+	children map[string]*treeNode
+}
+
+// NewTree returns an empty Tree.
+func NewTree() *Tree {
+	return &Tree{roots: make(map[string]*treeNode)}
+}
+
+func newTreeNode(name string) *treeNode {
+	return &treeNode{name: name, children: make(map[string]*treeNode)}
+}
+
+// Insert adds the name and addresses of the output under its root domain, creating the
+// subdomains between them that were not discovered, such as dev.owasp.org for a.dev.owasp.org.
+func (t *Tree) Insert(out *requests.Output) {
+	name := strings.ToLower(strings.Trim(out.Name, "."))
+	domain := strings.ToLower(strings.Trim(out.Domain, "."))
+	if name == "" || domain == "" || (name != domain && !strings.HasSuffix(name, "."+domain)) {
+		return
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	node, found := t.roots[domain]
+	if !found {
+		node = newTreeNode(domain)
+		t.roots[domain] = node
+	}
+
+	if name != domain {
+		labels := strings.Split(strings.TrimSuffix(name, "."+domain), ".")
+		for i := len(labels) - 1; i >= 0; i-- {
+			sub := strings.Join(labels[i:], ".") + "." + domain
+
+			child, found := node.children[sub]
+			if !found {
+				child = newTreeNode(sub)
+				node.children[sub] = child
+			}
+			node = child
+		}
+	}
+
+	if len(node.addrs) == 0 && len(out.Addresses) > 0 {
+		t.count++
+	}
+	for _, a := range out.Addresses {
+		if a.Address == nil {
+			continue
+		}
+		if addr := a.Address.String(); !containsString(node.addrs, addr) {
+			node.addrs = append(node.addrs, addr)
+		}
+	}
+}
+
+// Len returns the number of names with addresses in the tree.
+func (t *Tree) Len() int {
+	t.Lock()
+	defer t.Unlock()
+
+	return t.count
+}
+
+// Fprint renders the tree, with each line cut to the width and the children beyond maxChildren
+// of each node collapsed into a count. Zero values for width and maxChildren remove the limits.
+func (t *Tree) Fprint(out io.Writer, width, maxChildren int) {
+	t.Lock()
+	defer t.Unlock()
+
+	for _, root := range sortedNodes(t.roots) {
+		fmt.Fprintln(out, cutLine(root.label(), width))
+		root.fprintChildren(out, "", width, maxChildren)
+	}
+}
+
+func (n *treeNode) fprintChildren(out io.Writer, prefix string, width, maxChildren int) {
+	children := sortedNodes(n.children)
+
+	var collapsed int
+	if maxChildren > 0 && len(children) > maxChildren {
+		collapsed = len(children) - (maxChildren - 1)
+		children = children[:maxChildren-1]
+	}
+
+	for i, child := range children {
+		branch, indent := "├── ", "│   "
+		if i == len(children)-1 && collapsed == 0 {
+			branch, indent = "└── ", "    "
+		}
+
+		fmt.Fprintln(out, cutLine(prefix+branch+child.label(), width))
+		child.fprintChildren(out, prefix+indent, width, maxChildren)
+	}
+	if collapsed > 0 {
+		fmt.Fprintln(out, cutLine(fmt.Sprintf("%s└── ... %d more", prefix, collapsed), width))
+	}
+}
+
+func (n *treeNode) label() string {
+	if len(n.addrs) == 0 {
+		return n.name
+	}
+
+	addrs := n.addrs
+	var more string
+	if len(addrs) > maxTreeAddrs {
+		more = fmt.Sprintf(" +%d", len(addrs)-maxTreeAddrs)
+		addrs = addrs[:maxTreeAddrs]
+	}
+	return fmt.Sprintf("%s [%s%s]", n.name, strings.Join(addrs, ", "), more)
+}
+
+func sortedNodes(nodes map[string]*treeNode) []*treeNode {
+	sorted := make([]*treeNode, 0, len(nodes))
+	for _, n := range nodes {
+		sorted = append(sorted, n)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].name < sorted[j].name
+	})
+	return sorted
+}
+
+// cutLine shortens the line to the width in characters, ending it with an ellipsis.
+func cutLine(line string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(line) <= width {
+		return line
+	}
+	if width == 1 {
+		return "…"
+	}
+
+	runes := []rune(line)
+	return string(runes[:width-1]) + "…"
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package format
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/owasp-amass/amass/v4/requests"
+)
+
+func treeOutput(name, domain string, addrs ...string) *requests.Output {
+	out := &requests.Output{Name: name, Domain: domain}
+	for _, a := range addrs {
+		out.Addresses = append(out.Addresses, requests.AddressInfo{Address: net.ParseIP(a)})
+	}
+	return out
+}
+
+func TestTree(t *testing.T) {
+	tree := NewTree()
+	tree.Insert(treeOutput("www.owasp.org", "owasp.org", "192.0.2.1", "2001:db8::1"))
+	tree.Insert(treeOutput("a.dev.owasp.org", "owasp.org", "192.0.2.2"))
+	tree.Insert(treeOutput("www.owasp.org", "owasp.org", "192.0.2.1"))
+	tree.Insert(treeOutput("mail.example.com", "example.com", "192.0.2.3"))
+	tree.Insert(treeOutput("www.example.net", "owasp.org", "192.0.2.4"))
+
+	if n := tree.Len(); n != 3 {
+		t.Errorf("expected 3 names in the tree, but got %d", n)
+	}
+
+	var buf bytes.Buffer
+	tree.Fprint(&buf, 0, 0)
+	expected := `example.com
+└── mail.example.com [192.0.2.3]
+owasp.org
+├── dev.owasp.org
+│   └── a.dev.owasp.org [192.0.2.2]
+└── www.owasp.org [192.0.2.1, 2001:db8::1]
+`
+	if got := buf.String(); got != expected {
+		t.Errorf("expected the tree:\n%s\nbut got:\n%s", expected, got)
+	}
+}
+
+func TestTreeCollapsing(t *testing.T) {
+	tree := NewTree()
+	for _, label := range []string{"a", "b", "c", "d", "e"} {
+		tree.Insert(treeOutput(label+".owasp.org", "owasp.org", "192.0.2.1", "192.0.2.2", "192.0.2.3", "192.0.2.4"))
+	}
+
+	var buf bytes.Buffer
+	tree.Fprint(&buf, 30, 3)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	expected := []string{
+		"owasp.org",
+		"├── a.owasp.org [192.0.2.1, 1…",
+		"├── b.owasp.org [192.0.2.1, 1…",
+		"└── ... 3 more",
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, but got %d:\n%s", len(expected), len(lines), buf.String())
+	}
+	for i, line := range lines {
+		if line != expected[i] {
+			t.Errorf("line %d: expected %q, but got %q", i, expected[i], line)
+		}
+	}
+
+	// the addresses beyond the maximum are counted
+	buf.Reset()
+	tree.Fprint(&buf, 0, 0)
+	if !strings.Contains(buf.String(), "a.owasp.org [192.0.2.1, 192.0.2.2, 192.0.2.3 +1]") {
+		t.Errorf("the addresses were not collapsed:\n%s", buf.String())
+	}
+}
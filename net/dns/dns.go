@@ -98,6 +98,33 @@ func IPv6NibbleFormat(ip string) string {
 	return strings.Join(reversed, ".")
 }
 
+// ReverseNameToIP returns the IP address represented by the provided
+// in-addr.arpa or ip6.arpa name, or an empty string if it is not valid.
+func ReverseNameToIP(name string) string {
+	name = strings.ToLower(strings.Trim(strings.TrimSpace(name), "."))
+
+	var addr string
+	if strings.HasSuffix(name, ".in-addr.arpa") {
+		addr = ReverseIP(strings.TrimSuffix(name, ".in-addr.arpa"))
+	} else if strings.HasSuffix(name, ".ip6.arpa") {
+		nibbles := strings.Split(ReverseIP(strings.TrimSuffix(name, ".ip6.arpa")), ".")
+		if len(nibbles) != 32 {
+			return ""
+		}
+
+		var groups []string
+		for i := 0; i < len(nibbles); i += 4 {
+			groups = append(groups, strings.Join(nibbles[i:i+4], ""))
+		}
+		addr = strings.Join(groups, ":")
+	}
+
+	if ip := net.ParseIP(addr); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
 func expandIPv6Addr(addr string) string {
 	ip := net.ParseIP(addr)
 
@@ -142,6 +142,25 @@ func TestIPv6NibbleFormat(t *testing.T) {
 	}
 }
 
+func TestReverseNameToIP(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Expected string
+	}{
+		{"0.4.237.72.in-addr.arpa", "72.237.4.0"},
+		{"1.1.168.192.IN-ADDR.ARPA.", "192.168.1.1"},
+		{"f.1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.4.0.0.0.3.2.0.0.1.c.c.5.a.d.d.f.ip6.arpa", "fdda:5cc1:23:4::1f"},
+		{"1.2.3.ip6.arpa", ""},
+		{"www.owasp.org", ""},
+	}
+
+	for _, test := range tests {
+		if r := ReverseNameToIP(test.Name); r != test.Expected {
+			t.Errorf("%s caused %s to be returned instead of %s", test.Name, r, test.Expected)
+		}
+	}
+}
+
 func TestExpandIPv6Addr(t *testing.T) {
 	tests := []struct {
 		Address  string
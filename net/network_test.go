@@ -5,9 +5,15 @@
 package net
 
 import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
 	"net"
 	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestIsIPv4(t *testing.T) {
@@ -253,3 +259,143 @@ func TestIPDec(t *testing.T) {
 		}
 	}
 }
+
+func TestDialContextSOCKS5(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on a TCP port: %v", err)
+	}
+	defer target.Close()
+
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = io.WriteString(conn, "Hello unit test")
+			conn.Close()
+		}
+	}()
+
+	var proxied int32
+	socks, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on a TCP port: %v", err)
+	}
+	defer socks.Close()
+
+	go func() {
+		for {
+			conn, err := socks.Accept()
+			if err != nil {
+				return
+			}
+			go serveSOCKS5(conn, &proxied)
+		}
+	}()
+
+	d, err := NewSOCKS5Dialer(socks.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to setup the SOCKS5 proxy: %v", err)
+	}
+
+	bg, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx := WithDialer(bg, d)
+
+	if _, err := DialContext(ctx, "udp", target.Addr().String()); err == nil {
+		t.Errorf("DialContext failed to reject UDP through the SOCKS5 proxy")
+	}
+
+	conn, err := DialContext(ctx, "tcp", target.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial through the SOCKS5 proxy: %v", err)
+	}
+	defer conn.Close()
+
+	data, err := io.ReadAll(conn)
+	if err != nil || string(data) != "Hello unit test" {
+		t.Errorf("Failed to read the expected data through the SOCKS5 proxy: %s", string(data))
+	}
+	if atomic.LoadInt32(&proxied) != 1 {
+		t.Errorf("The connection did not traverse the SOCKS5 proxy")
+	}
+
+	// the proxy only applies to the context provided with the dialer
+	if c, err := DialContext(bg, "udp", target.Addr().String()); err != nil {
+		t.Errorf("the SOCKS5 proxy was used without the dialer in the context: %v", err)
+	} else {
+		c.Close()
+	}
+}
+
+type countingDialer struct {
+	dialed []string
+}
+
+func (d *countingDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	d.dialed = append(d.dialed, network+" "+addr)
+	return nil, errors.New("the dialer refused the connection")
+}
+
+func TestWithDialer(t *testing.T) {
+	d := &countingDialer{}
+	bg, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx := WithDialer(bg, d)
+
+	if _, err := DialContext(ctx, "udp", "192.0.2.53:53"); err == nil {
+		t.Errorf("the error of the dialer was not returned")
+	}
+	if _, err := DialContext(ctx, "tcp", "192.0.2.53:853"); err == nil {
+		t.Errorf("the error of the dialer was not returned")
+	}
+	if len(d.dialed) != 2 || d.dialed[0] != "udp 192.0.2.53:53" || d.dialed[1] != "tcp 192.0.2.53:853" {
+		t.Errorf("the connections were not established through the dialer: %v", d.dialed)
+	}
+
+	if WithDialer(bg, nil) != bg {
+		t.Errorf("the context was copied without a dialer")
+	}
+	if _, err := DialContext(bg, "udp", "127.0.0.1:53"); err != nil {
+		t.Errorf("the default dialer was not used without the dialer in the context: %v", err)
+	}
+	if len(d.dialed) != 2 {
+		t.Errorf("the dialer was used without being in the context")
+	}
+}
+
+// serveSOCKS5 handles a single unauthenticated SOCKS5 CONNECT request for an IPv4 address.
+func serveSOCKS5(c net.Conn, proxied *int32) {
+	defer c.Close()
+
+	buf := make([]byte, 262)
+	// Version and authentication methods
+	if _, err := io.ReadFull(c, buf[:2]); err != nil || buf[0] != 5 {
+		return
+	}
+	if _, err := io.ReadFull(c, buf[:buf[1]]); err != nil {
+		return
+	}
+	if _, err := c.Write([]byte{5, 0}); err != nil {
+		return
+	}
+	// The CONNECT request with an IPv4 address
+	if _, err := io.ReadFull(c, buf[:10]); err != nil || buf[1] != 1 || buf[3] != 1 {
+		return
+	}
+
+	addr := net.JoinHostPort(net.IP(buf[4:8]).String(), strconv.Itoa(int(binary.BigEndian.Uint16(buf[8:10]))))
+	remote, err := net.Dial("tcp", addr)
+	if err != nil {
+		return
+	}
+	defer remote.Close()
+
+	if _, err := c.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		return
+	}
+	atomic.AddInt32(proxied, 1)
+	_, _ = io.Copy(c, remote)
+}
@@ -5,6 +5,7 @@
 package requests
 
 import (
+	"errors"
 	"net"
 	"strings"
 	"time"
@@ -27,12 +28,181 @@ const (
 	OutputTopic        = "amass:output"
 )
 
+// Tags used to identify how a DNS name was discovered.
+const (
+	NONE     = "none"
+	ALT      = "alt"
+	API      = "api"
+	ARCHIVE  = "archive"
+	BRUTE    = "brute"
+	CERT     = "cert"
+	CRAWL    = "crawl"
+	DNS      = "dns"
+	EXTERNAL = "ext"
+	SCRAPE   = "scrape"
+)
+
+// ActiveTag returns true when the tag identifies an active technique, which sends DNS queries to
+// discover the names, such as brute forcing, alterations, zone transfers and sweeps. The names
+// obtained from the data sources and the provided names are passive.
+func ActiveTag(tag string) bool {
+	switch tag {
+	case ALT, BRUTE, DNS:
+		return true
+	}
+	return false
+}
+
+// Classifications of the TXT records that carry email security policies.
+const (
+	DKIM  = "DKIM"
+	DMARC = "DMARC"
+	SPF   = "SPF"
+)
+
+// Sections of the DNS responses holding the records kept in addition to the answers.
+const (
+	AUTHORITY  = "authority"
+	ADDITIONAL = "additional"
+)
+
+// GLUE classifies the A and AAAA records taken from the additional section of the NS responses,
+// which belong to the nameserver names instead of the name that was queried.
+const GLUE = "glue"
+
+// ErrRateLimited is sent by a data source on its output channel after being rate limited.
+var ErrRateLimited = errors.New("the data source has been rate limited")
+
 // DNSAnswer is the type used by Amass to represent a DNS record.
 type DNSAnswer struct {
 	Name string `json:"name"`
 	Type int    `json:"type"`
 	TTL  int    `json:"TTL"`
 	Data string `json:"data"`
+	// LowTTL is set when the TTL is below the MinTTLFlag of the enumeration
+	LowTTL bool `json:"low_ttl,omitempty"`
+	// Classification identifies the policy carried by TXT records, such as DMARC
+	Classification string `json:"classification,omitempty"`
+	// Truncated is set when the data was cut to fit the maximum size of the answer set,
+	// and the following answers of the set were dropped
+	Truncated bool `json:"truncated,omitempty"`
+	// Cert is the decoded content of a CERT record
+	Cert *CERTData `json:"cert,omitempty"`
+	// Location is the decoded content of a LOC record
+	Location *LOCData `json:"location,omitempty"`
+	// HINFO is the decoded content of an HINFO record
+	HINFO *HINFOData `json:"hinfo,omitempty"`
+	// Prefixes are the decoded address prefixes of an APL record
+	Prefixes []APLPrefix `json:"prefixes,omitempty"`
+	// Priority is the preference of an MX record, where the lowest value is preferred
+	Priority int `json:"priority,omitempty"`
+	// OpenPGPKey is the decoded content of an OPENPGPKEY record
+	OpenPGPKey *OpenPGPKeyData `json:"openpgpkey,omitempty"`
+	// SMIMEA is the decoded content of an SMIMEA record
+	SMIMEA *SMIMEAData `json:"smimea,omitempty"`
+	// URI is the decoded content of a URI record
+	URI *URIData `json:"uri,omitempty"`
+	// Section is set for the records taken from the authority or additional section of the
+	// response, which belong to the zone or other names instead of the name that was queried
+	Section string `json:"section,omitempty"`
+}
+
+// CERTData is the content of a CERT record, which can carry a certificate or a PGP key.
+type CERTData struct {
+	// Type is the certificate type, such as PKIX or PGP
+	Type        string `json:"type"`
+	KeyTag      uint16 `json:"key_tag"`
+	Algorithm   string `json:"algorithm"`
+	Certificate []byte `json:"certificate"`
+}
+
+// LOCData is the physical location published in a LOC record, in degrees and meters.
+type LOCData struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude"`
+	// Size is the diameter of the sphere enclosing the location
+	Size float64 `json:"size"`
+	// HorizPrecision and VertPrecision are the precision of the coordinates
+	HorizPrecision float64 `json:"horiz_precision"`
+	VertPrecision  float64 `json:"vert_precision"`
+}
+
+// HINFOData is the host information published in an HINFO record, as described by RFC 1035.
+type HINFOData struct {
+	CPU string `json:"cpu"`
+	OS  string `json:"os"`
+}
+
+// URIData is the service endpoint published in a URI record, as described by RFC 7553.
+type URIData struct {
+	Priority uint16 `json:"priority"`
+	Weight   uint16 `json:"weight"`
+	Target   string `json:"target"`
+}
+
+// OpenPGPKeyData is the OpenPGP public key published in an OPENPGPKEY record for an email address.
+type OpenPGPKeyData struct {
+	// LocalPart is the part of the email address hashed into the name of the record
+	LocalPart string `json:"local_part"`
+	PublicKey []byte `json:"public_key"`
+}
+
+// SMIMEAData is the S/MIME certificate association published in an SMIMEA record for an email address.
+type SMIMEAData struct {
+	// LocalPart is the part of the email address hashed into the name of the record
+	LocalPart    string `json:"local_part"`
+	Usage        uint8  `json:"usage"`
+	Selector     uint8  `json:"selector"`
+	MatchingType uint8  `json:"matching_type"`
+	// Certificate is the full certificate or public key, or its digest, depending on the MatchingType
+	Certificate []byte `json:"certificate"`
+}
+
+// APLPrefix is an address prefix listed in an APL record, which is excluded from the list when negated.
+type APLPrefix struct {
+	// Family is the IANA address family: 1 for IPv4 and 2 for IPv6
+	Family   int    `json:"family"`
+	Prefix   string `json:"prefix"`
+	Negation bool   `json:"negation"`
+}
+
+// Timings is the time spent by a name in each stage of the enumeration pipeline, measured with
+// the monotonic clock at the stage boundaries. The time between the stages is counted in the next
+// stage, and the stages skipped by the name are left at zero.
+type Timings struct {
+	// Queued is the time spent in the input source before entering the pipeline
+	Queued time.Duration `json:"queued"`
+	// Resolution is the time spent resolving the name, including the retries
+	Resolution time.Duration `json:"resolution"`
+	// Validation is the time spent validating the answers with the trusted resolvers
+	Validation time.Duration `json:"validation"`
+	// Store is the time spent storing the name in the graph
+	Store time.Duration `json:"store"`
+	mark  time.Time
+}
+
+// Start marks the beginning of the first stage.
+func (t *Timings) Start() {
+	t.mark = time.Now()
+}
+
+// Lap returns the time elapsed since the previous stage boundary, and marks the start of the next
+// stage. Zero is returned when the first stage was not started.
+func (t *Timings) Lap() time.Duration {
+	now := time.Now()
+
+	var d time.Duration
+	if !t.mark.IsZero() {
+		d = now.Sub(t.mark)
+	}
+	t.mark = now
+	return d
+}
+
+// Total returns the time spent across all the stages.
+func (t Timings) Total() time.Duration {
+	return t.Queued + t.Resolution + t.Validation + t.Store
 }
 
 // DNSRequest handles data needed throughout Service processing of a DNS name.
@@ -40,14 +210,29 @@ type DNSRequest struct {
 	Name    string
 	Domain  string
 	Records []DNSAnswer
+	Tag     string
+	Source  string
+	// FirstSeen and LastSeen are the times of the first and the latest discovery of the name
+	FirstSeen time.Time
+	LastSeen  time.Time
+	// Active is true when the name was obtained by an active technique, according to ActiveTag
+	Active bool
+	// Timings is the time spent by the name in each stage of the pipeline
+	Timings Timings
 }
 
 // Clone implements pipeline Data.
 func (d *DNSRequest) Clone() pipeline.Data {
 	return &DNSRequest{
-		Name:    d.Name,
-		Domain:  d.Domain,
-		Records: append([]DNSAnswer(nil), d.Records...),
+		Name:      d.Name,
+		Domain:    d.Domain,
+		Records:   append([]DNSAnswer(nil), d.Records...),
+		Tag:       d.Tag,
+		Source:    d.Source,
+		FirstSeen: d.FirstSeen,
+		LastSeen:  d.LastSeen,
+		Active:    d.Active,
+		Timings:   d.Timings,
 	}
 }
 
@@ -248,14 +433,30 @@ type Output struct {
 	Name      string        `json:"name"`
 	Domain    string        `json:"domain"`
 	Addresses []AddressInfo `json:"addresses"`
+	FirstSeen time.Time     `json:"first_seen,omitempty"`
+	LastSeen  time.Time     `json:"last_seen,omitempty"`
+	// UnicodeName is the Unicode form of an internationalized name, such as bücher.example.com
+	UnicodeName string `json:"unicode_name,omitempty"`
+	// Confidence is the score, from 0 to 100, of the corroborating sources and the resolution of the name
+	Confidence int `json:"confidence,omitempty"`
+	// Active is true when the name was obtained by an active technique, according to ActiveTag
+	Active bool `json:"active"`
+	// Timings is the time spent by the name in each stage of the pipeline
+	Timings Timings `json:"timings"`
 }
 
 // Clone implements pipeline Data.
 func (o *Output) Clone() pipeline.Data {
 	return &Output{
-		Name:      o.Name,
-		Domain:    o.Domain,
-		Addresses: append([]AddressInfo(nil), o.Addresses...),
+		Name:        o.Name,
+		Domain:      o.Domain,
+		Addresses:   append([]AddressInfo(nil), o.Addresses...),
+		FirstSeen:   o.FirstSeen,
+		LastSeen:    o.LastSeen,
+		UnicodeName: o.UnicodeName,
+		Confidence:  o.Confidence,
+		Active:      o.Active,
+		Timings:     o.Timings,
 	}
 }
 
@@ -281,11 +482,21 @@ func (o *Output) Complete(passive bool) bool {
 
 // AddressInfo stores all network addressing info for the Output type.
 type AddressInfo struct {
-	Address     net.IP     `json:"ip"`
-	Netblock    *net.IPNet `json:"-"`
-	CIDRStr     string     `json:"cidr"`
-	ASN         int        `json:"asn"`
-	Description string     `json:"desc"`
+	Address     net.IP       `json:"ip"`
+	Netblock    *net.IPNet   `json:"-"`
+	CIDRStr     string       `json:"cidr"`
+	ASN         int          `json:"asn"`
+	Description string       `json:"desc"`
+	Geo         *GeoLocation `json:"geo,omitempty"`
+}
+
+// GeoLocation contains the geolocation information obtained for an IP address.
+type GeoLocation struct {
+	CountryCode  string `json:"country_code,omitempty"`
+	Country      string `json:"country,omitempty"`
+	City         string `json:"city,omitempty"`
+	ASN          int    `json:"asn,omitempty"`
+	Organization string `json:"org,omitempty"`
 }
 
 // SanitizeDNSRequest cleans the Name and Domain elements of the receiver.
@@ -299,3 +510,67 @@ func SanitizeDNSRequest(req *DNSRequest) {
 	req.Domain = strings.TrimSpace(req.Domain)
 	req.Domain = strings.Trim(req.Domain, ".")
 }
+
+// SanitizeDNSAnswer cleans the Name of the receiver and extracts the Data according to the record type.
+func SanitizeDNSAnswer(ans *DNSAnswer) {
+	ans.Name = strings.Trim(strings.ToLower(strings.TrimSpace(ans.Name)), ".")
+	ans.Data = NormalizeAnswerData(uint16(ans.Type), ans.Data)
+}
+
+// NormalizeAnswerData parses the DNS answer data based on the provided record type.
+func NormalizeAnswerData(rrtype uint16, data string) string {
+	data = strings.TrimSpace(data)
+
+	switch rrtype {
+	case dns.TypeA, dns.TypeAAAA:
+		if ip := net.ParseIP(data); ip != nil {
+			return ip.String()
+		}
+	case dns.TypeCNAME, dns.TypeNS, dns.TypeMX, dns.TypePTR, dns.TypeSRV:
+		// the target name is the last field, following priorities, weights, ports, etc.
+		fields := answerFields(data)
+		for i := len(fields) - 1; i >= 0; i-- {
+			if name := strings.Trim(strings.ToLower(fields[i]), "."); isAnswerName(name) {
+				return name
+			}
+		}
+		return ""
+	case dns.TypeSOA:
+		// the primary nameserver is the first field
+		if fields := answerFields(data); len(fields) > 0 {
+			return strings.Trim(strings.ToLower(fields[0]), ".")
+		}
+	}
+	return data
+}
+
+// ClassifyTXT returns the email security policy carried by the TXT record for the name,
+// or an empty string when the record is not recognized.
+func ClassifyTXT(name, data string) string {
+	name = strings.Trim(strings.ToLower(strings.TrimSpace(name)), ".")
+	data = strings.ToLower(strings.Trim(strings.TrimSpace(data), "\""))
+
+	switch {
+	case strings.HasPrefix(name, "_dmarc."):
+		return DMARC
+	case strings.Contains(name, "._domainkey.") || strings.HasPrefix(data, "v=dkim1"):
+		return DKIM
+	case data == "v=spf1" || strings.HasPrefix(data, "v=spf1 "):
+		return SPF
+	}
+	return ""
+}
+
+func answerFields(data string) []string {
+	return strings.FieldsFunc(data, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
+
+func isAnswerName(name string) bool {
+	if name == "" || net.ParseIP(name) != nil || !strings.Contains(name, ".") {
+		return false
+	}
+	_, ok := dns.IsDomainName(name)
+	return ok
+}
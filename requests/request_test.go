@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/miekg/dns"
 	"github.com/stretchr/testify/require"
 )
 
@@ -25,6 +26,23 @@ func TestDNSRequestClone(t *testing.T) {
 				Records: append([]DNSAnswer(nil), []DNSAnswer{}...),
 			},
 		},
+		{
+			name: "Active test",
+			req: DNSRequest{
+				Name:   "www.example.com",
+				Domain: "example.com",
+				Tag:    BRUTE,
+				Active: true,
+			},
+		},
+		{
+			name: "Timings test",
+			req: DNSRequest{
+				Name:    "www.example.com",
+				Domain:  "example.com",
+				Timings: Timings{Queued: time.Second, Resolution: 2 * time.Second},
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -32,11 +50,36 @@ func TestDNSRequestClone(t *testing.T) {
 			require.Equal(t, clone.Name, test.req.Name)
 			require.Equal(t, clone.Domain, test.req.Domain)
 			require.Equal(t, clone.Records, test.req.Records)
+			require.Equal(t, clone.Active, test.req.Active)
+			require.Equal(t, clone.Timings, test.req.Timings)
 		})
 	}
 
 }
 
+func TestTimingsLap(t *testing.T) {
+	var timings Timings
+	require.Zero(t, timings.Lap(), "the lap without a start")
+
+	timings.Start()
+	time.Sleep(10 * time.Millisecond)
+	timings.Queued = timings.Lap()
+	require.GreaterOrEqual(t, timings.Queued, 10*time.Millisecond)
+
+	timings.Resolution = timings.Lap()
+	require.Less(t, timings.Resolution, timings.Queued)
+	require.Equal(t, timings.Queued+timings.Resolution, timings.Total())
+}
+
+func TestActiveTag(t *testing.T) {
+	for _, tag := range []string{ALT, BRUTE, DNS} {
+		require.True(t, ActiveTag(tag), tag)
+	}
+	for _, tag := range []string{NONE, API, ARCHIVE, CERT, CRAWL, EXTERNAL, SCRAPE, ""} {
+		require.False(t, ActiveTag(tag), tag)
+	}
+}
+
 func TestDNSRequestValid(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -328,6 +371,55 @@ func TestSanitizeDNSRequest(t *testing.T) {
 
 }
 
+func TestNormalizeAnswerData(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		rrtype   uint16
+		data     string
+		expected string
+	}{
+		{"NS trailing dot", dns.TypeNS, "NS1.Example.com.", "ns1.example.com"},
+		{"NS chained names", dns.TypeNS, "ns1.example.com.,ns2.example.com.", "ns2.example.com"},
+		{"NS resource record", dns.TypeNS, "example.com. 3600 IN NS ns3.example.com.", "ns3.example.com"},
+		{"CNAME trailing dot", dns.TypeCNAME, "www.example.com.", "www.example.com"},
+		{"MX preference", dns.TypeMX, "10 mail.example.com.", "mail.example.com"},
+		{"SRV target", dns.TypeSRV, "0 5 5060 sip.example.com.", "sip.example.com"},
+		{"SOA primary nameserver", dns.TypeSOA, "ns1.example.com.,hostmaster.example.com.", "ns1.example.com"},
+		{"AAAA address", dns.TypeAAAA, " 2001:DB8::1 ", "2001:db8::1"},
+		{"Missing target", dns.TypeNS, "3600", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, NormalizeAnswerData(test.rrtype, test.data))
+		})
+	}
+}
+
+func TestClassifyTXT(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		owner    string
+		data     string
+		expected string
+	}{
+		{"DMARC", "_dmarc.example.com.", "v=DMARC1; p=reject; rua=mailto:dmarc@example.com", DMARC},
+		{"DKIM selector", "selector1._domainkey.example.com", "v=DKIM1; k=rsa; p=MIGfMA0", DKIM},
+		{"DKIM without version", "google._domainkey.example.com", "k=rsa; p=MIGfMA0", DKIM},
+		{"SPF", "example.com", "v=spf1 include:_spf.google.com ~all", SPF},
+		{"SPF quoted", "example.com", "\"v=spf1 -all\"", SPF},
+		{"SPF uppercase", "example.com", "V=SPF1 mx -all", SPF},
+		{"SPF lookalike", "example.com", "v=spf10 -all", ""},
+		{"Site verification", "example.com", "google-site-verification=abc123", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, ClassifyTXT(test.owner, test.data))
+		})
+	}
+}
+
 func TestASNRequestClone(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
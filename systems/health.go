@@ -0,0 +1,159 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/owasp-amass/resolve"
+)
+
+const (
+	// healthCheckTimeout is the time allowed for a resolver to answer the health probe.
+	healthCheckTimeout = 3 * time.Second
+	// maxConcurrentHealthChecks limits the number of probes sent to the resolvers at once.
+	maxConcurrentHealthChecks = 50
+)
+
+// resolverHealth periodically probes the untrusted resolvers and rebuilds the pool
+// without the resolvers that repeatedly fail, restoring them once they recover.
+type resolverHealth struct {
+	sys         *LocalSystem
+	interval    time.Duration
+	maxFailures int
+	probe       func(ctx context.Context, addr string) bool
+	failures    map[string]int
+	removed     map[string]struct{}
+}
+
+// StartResolverHealthChecks probes each of the untrusted resolvers every interval, and temporarily
+// removes the resolvers from the pool after maxFailures consecutive probes have failed.
+func (l *LocalSystem) StartResolverHealthChecks(interval time.Duration, maxFailures int) error {
+	if interval <= 0 || maxFailures <= 0 {
+		return errors.New("the resolver health check interval and failure count must be positive")
+	}
+	if l.health != nil {
+		return errors.New("the resolver health checks have already been started")
+	}
+
+	l.health = &resolverHealth{
+		sys:         l,
+		interval:    interval,
+		maxFailures: maxFailures,
+		probe:       probeResolver,
+		failures:    make(map[string]int),
+		removed:     make(map[string]struct{}),
+	}
+	go l.health.run()
+	return nil
+}
+
+func (h *resolverHealth) run() {
+	t := time.NewTicker(h.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-h.sys.done:
+			return
+		case <-t.C:
+			if h.check() {
+				h.sys.replacePool(h.healthy())
+			}
+		}
+	}
+}
+
+// check probes all the resolvers and returns true when the set of healthy resolvers changed.
+func (h *resolverHealth) check() bool {
+	addrs := h.sys.Cfg.Resolvers
+	results := make([]bool, len(addrs))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentHealthChecks)
+	for i, addr := range addrs {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(idx int, a string) {
+			defer func() { <-sem }()
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+			defer cancel()
+			results[idx] = h.probe(ctx, a)
+		}(i, addr)
+	}
+	wg.Wait()
+
+	var changed bool
+	for i, addr := range addrs {
+		_, removed := h.removed[addr]
+
+		if results[i] {
+			h.failures[addr] = 0
+			if removed {
+				delete(h.removed, addr)
+				h.sys.Cfg.Log.Printf("Resolver %s has recovered and was restored to the pool", addr)
+				changed = true
+			}
+			continue
+		}
+
+		h.failures[addr]++
+		if !removed && h.failures[addr] >= h.maxFailures {
+			h.removed[addr] = struct{}{}
+			h.sys.Cfg.Log.Printf("Resolver %s failed %d health checks and was removed from the pool", addr, h.failures[addr])
+			changed = true
+		}
+	}
+	return changed
+}
+
+// healthy returns the addresses of the resolvers that have not been removed.
+func (h *resolverHealth) healthy() []string {
+	var addrs []string
+
+	for _, addr := range h.sys.Cfg.Resolvers {
+		if _, found := h.removed[addr]; !found {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// replacePool swaps in a pool built from the provided resolvers. The previous pool is stopped
+// after a grace period, allowing the queries already sent to it to complete.
+func (l *LocalSystem) replacePool(addrs []string) {
+	if len(addrs) == 0 {
+		l.Cfg.Log.Printf("All the resolvers failed the health checks, so the pool was left unchanged")
+		return
+	}
+
+	pool := NewUntrustedPool(l.Cfg, addrs)
+	pool.SetRateTracker(l.rate)
+
+	l.poolLock.Lock()
+	old := l.pool
+	l.pool = pool
+	l.poolLock.Unlock()
+
+	time.AfterFunc(2*healthCheckTimeout, old.Stop)
+}
+
+// probeResolver returns true when the resolver successfully answers a query for the root nameservers.
+func probeResolver(ctx context.Context, addr string) bool {
+	msg := resolve.QueryMsg(".", dns.TypeNS)
+	c := &dns.Client{Net: "udp", Timeout: healthCheckTimeout}
+
+	resp, _, err := c.ExchangeContext(ctx, msg, addr)
+	return err == nil && resp != nil && resp.Rcode == dns.RcodeSuccess && len(resp.Answer) > 0
+}
@@ -0,0 +1,82 @@
+// Copyright © by Jeff Foley 2017-2023. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+// SPDX-License-Identifier: Apache-2.0
+
+package systems
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/owasp-amass/config/config"
+)
+
+func TestResolverHealthCheck(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Resolvers = []string{"192.0.2.1:53", "192.0.2.2:53", "192.0.2.3:53"}
+
+	var lock sync.Mutex
+	down := map[string]bool{"192.0.2.2:53": true}
+	h := &resolverHealth{
+		sys:         &LocalSystem{Cfg: cfg},
+		interval:    time.Minute,
+		maxFailures: 2,
+		probe: func(ctx context.Context, addr string) bool {
+			lock.Lock()
+			defer lock.Unlock()
+			return !down[addr]
+		},
+		failures: make(map[string]int),
+		removed:  make(map[string]struct{}),
+	}
+
+	all := []string{"192.0.2.1:53", "192.0.2.2:53", "192.0.2.3:53"}
+	tests := []struct {
+		down     map[string]bool
+		changed  bool
+		expected []string
+	}{
+		// the first failure is tolerated
+		{map[string]bool{"192.0.2.2:53": true}, false, all},
+		{map[string]bool{"192.0.2.2:53": true}, true, []string{"192.0.2.1:53", "192.0.2.3:53"}},
+		{map[string]bool{"192.0.2.2:53": true}, false, []string{"192.0.2.1:53", "192.0.2.3:53"}},
+		// a single success restores the resolver
+		{map[string]bool{}, true, all},
+		// the failure count was reset by the success
+		{map[string]bool{"192.0.2.2:53": true}, false, all},
+	}
+
+	for i, test := range tests {
+		lock.Lock()
+		down = test.down
+		lock.Unlock()
+
+		if changed := h.check(); changed != test.changed {
+			t.Errorf("Check %d: expected changed to be %t, but got %t", i, test.changed, changed)
+		}
+		if got := h.healthy(); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Check %d: expected the healthy resolvers %v, but got %v", i, test.expected, got)
+		}
+	}
+}
+
+func TestStartResolverHealthChecks(t *testing.T) {
+	l := &LocalSystem{Cfg: config.NewConfig(), done: make(chan struct{})}
+	defer close(l.done)
+
+	if err := l.StartResolverHealthChecks(0, 3); err == nil {
+		t.Errorf("the zero interval was accepted")
+	}
+	if err := l.StartResolverHealthChecks(time.Minute, 0); err == nil {
+		t.Errorf("the zero failure count was accepted")
+	}
+	if err := l.StartResolverHealthChecks(time.Minute, 3); err != nil {
+		t.Errorf("the valid settings were rejected: %v", err)
+	}
+	if err := l.StartResolverHealthChecks(time.Minute, 3); err == nil {
+		t.Errorf("the health checks were started twice")
+	}
+}
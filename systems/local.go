@@ -27,8 +27,11 @@ import (
 // LocalSystem implements a System to be executed within a single process.
 type LocalSystem struct {
 	Cfg               *config.Config
+	poolLock          sync.Mutex
 	pool              *resolve.Resolvers
 	trusted           *resolve.Resolvers
+	rate              *resolve.RateTracker
+	health            *resolverHealth
 	graphs            []*netmap.Graph
 	cache             *requests.ASNCache
 	done              chan struct{}
@@ -66,6 +69,7 @@ func NewLocalSystem(cfg *config.Config) (*LocalSystem, error) {
 		Cfg:        cfg,
 		pool:       pool,
 		trusted:    trusted,
+		rate:       rate,
 		cache:      requests.NewASNCache(),
 		done:       make(chan struct{}, 2),
 		addSource:  make(chan service.Service),
@@ -99,6 +103,9 @@ func (l *LocalSystem) Config() *config.Config {
 
 // Resolvers implements the System interface.
 func (l *LocalSystem) Resolvers() *resolve.Resolvers {
+	l.poolLock.Lock()
+	defer l.poolLock.Unlock()
+
 	return l.pool
 }
 
@@ -190,7 +197,7 @@ func (l *LocalSystem) Shutdown() error {
 		//g.Close()
 	}
 
-	l.pool.Stop()
+	l.Resolvers().Stop()
 	l.trusted.Stop()
 	l.cache = nil
 	return nil
@@ -319,12 +326,18 @@ func untrustedResolvers(cfg *config.Config) (*resolve.Resolvers, int) {
 	}
 	cfg.Resolvers = checkAddresses(cfg.Resolvers)
 
+	pool := NewUntrustedPool(cfg, cfg.Resolvers)
+	return pool, pool.Len()
+}
+
+// NewUntrustedPool returns a pool of the provided resolvers using the settings of the untrusted resolvers.
+func NewUntrustedPool(cfg *config.Config, addrs []string) *resolve.Resolvers {
 	pool := resolve.NewResolvers()
 	pool.SetLogger(cfg.Log)
 	if cfg.MaxDNSQueries > 0 {
 		pool.SetMaxQPS(cfg.MaxDNSQueries)
 	}
-	_ = pool.AddResolvers(cfg.ResolversQPS, cfg.Resolvers...)
+	_ = pool.AddResolvers(cfg.ResolversQPS, addrs...)
 	pool.SetTimeout(3 * time.Second)
 	pool.SetThresholdOptions(&resolve.ThresholdOptions{
 		ThresholdValue:      20,
@@ -335,7 +348,7 @@ func untrustedResolvers(cfg *config.Config) (*resolve.Resolvers, int) {
 		CountQueryRefusals:  true,
 	})
 	pool.ClientSubnetCheck()
-	return pool, pool.Len()
+	return pool
 }
 
 func publicResolverAddrs(cfg *config.Config) []string {